@@ -0,0 +1,55 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+The typecheck command reports syntax and type errors in Go packages.
+
+Usage: typecheck [flags] package...
+
+The typecheck command loads the specified packages using
+golang.org/x/tools/go/packages, then parses and type-checks them,
+reporting each error it finds. Packages are expressed in the notation
+of 'go list' (or other underlying build system if you are using an
+alternative golang.org/x/go/packages driver), so the command works in
+module mode, in GOPATH mode, and on lists of .go files alike.
+Dependencies are type-checked from export data, so only the specified
+packages are checked from source.
+
+It is a successor to cmd/gotype, which is a copy of a command in the
+Go distribution that knows nothing of modules, intended for editor
+integrations that do not use the Language Server Protocol.
+
+The -test flag causes it to check the test variants of each package
+too. The -tags flag specifies additional build tags.
+
+The -modified flag causes it to read an archive of modified files
+from standard input, so that the contents of unsaved editor buffers
+are checked in place of the contents of the files on disk. The
+archive format is that of golang.org/x/tools/go/buildutil's
+ParseOverlayArchive: each file consists of its name, its decimal size
+in bytes, and its contents, separated by newlines, with no newline
+after the contents.
+
+The command exits with status 1 if it reports any errors.
+
+# Output
+
+By default, each error is printed on a line of the form
+
+	file:line:col: message
+
+The -json flag instead prints a JSON array of records of this form:
+
+	{
+		"Package": "example.com/p", // ID of the package containing the error
+		"Kind":    "type",          // "list", "parse", or "type"
+		"File":    "/path/to/p/p.go",
+		"Line":    3,
+		"Col":     14,
+		"Message": "undefined: y"
+	}
+
+File, Line, and Col are zero if the position of the error is unknown.
+*/
+package main
@@ -0,0 +1,222 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.20
+
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/telemetry"
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/packages"
+)
+
+//go:embed doc.go
+var doc string
+
+// flags
+var (
+	testFlag     = flag.Bool("test", false, "include implicit test packages")
+	tagsFlag     = flag.String("tags", "", "comma-separated list of extra build tags (see: go help buildconstraint)")
+	modifiedFlag = flag.Bool("modified", false, "read an archive of modified files from standard input")
+	jsonFlag     = flag.Bool("json", false, "output JSON records")
+)
+
+func usage() {
+	// Extract the content of the /* ... */ comment in doc.go.
+	_, after, _ := strings.Cut(doc, "/*\n")
+	doc, _, _ := strings.Cut(after, "*/")
+	io.WriteString(flag.CommandLine.Output(), doc+`
+Flags:
+
+`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	telemetry.Start(telemetry.Config{ReportCrashes: true})
+
+	log.SetPrefix("typecheck: ")
+	log.SetFlags(0) // no time prefix
+
+	flag.Usage = usage
+	flag.Parse()
+	if len(flag.Args()) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg := &packages.Config{
+		BuildFlags: []string{"-tags=" + *tagsFlag},
+		Mode:       packages.LoadSyntax,
+		Tests:      *testFlag,
+	}
+	if *modifiedFlag {
+		overlay, err := readOverlay(os.Stdin)
+		if err != nil {
+			log.Fatalf("-modified: %v", err)
+		}
+		cfg.Overlay = overlay
+	}
+	initial, err := packages.Load(cfg, flag.Args()...)
+	if err != nil {
+		log.Fatalf("Load: %v", err)
+	}
+	if len(initial) == 0 {
+		log.Fatalf("no packages")
+	}
+
+	errors := packageErrors(initial)
+	if *jsonFlag {
+		if errors == nil {
+			errors = []jsonError{} // print [], not null
+		}
+		data, err := json.MarshalIndent(errors, "", "\t")
+		if err != nil {
+			log.Fatalf("internal error marshalling JSON: %v", err)
+		}
+		os.Stdout.Write(data)
+		fmt.Println()
+	} else {
+		for _, e := range errors {
+			if e.File != "" {
+				fmt.Printf("%s: %s\n", e.position(), e.Message)
+			} else {
+				fmt.Println(e.Message)
+			}
+		}
+	}
+	if len(errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// readOverlay reads an archive of modified files, in the format
+// accepted by [buildutil.ParseOverlayArchive], and returns it as an
+// overlay keyed by absolute file name.
+func readOverlay(r io.Reader) (map[string][]byte, error) {
+	archive, err := buildutil.ParseOverlayArchive(r)
+	if err != nil {
+		return nil, err
+	}
+	overlay := make(map[string][]byte, len(archive))
+	for name, content := range archive {
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return nil, err
+		}
+		overlay[abs] = content
+	}
+	return overlay, nil
+}
+
+// packageErrors returns the errors of the packages and their
+// dependencies, in the order reported by [packages.PrintErrors].
+// An error common to several packages, such as to a package and its
+// test variant, is reported once.
+func packageErrors(pkgs []*packages.Package) []jsonError {
+	var errors []jsonError
+	seen := make(map[jsonError]bool)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			// The build system compiles each package to obtain
+			// export data, and reports the compiler's output as a
+			// list error, such as "# p\np.go:1:2: undefined: x".
+			// Omit it for packages checked from source, since it
+			// duplicates the parse and type errors.
+			if err.Kind == packages.ListError && pkg.Syntax != nil && strings.HasPrefix(err.Msg, "# ") {
+				continue
+			}
+			e := newJSONError(pkg, err)
+			key := e
+			key.Package = ""
+			if !seen[key] {
+				seen[key] = true
+				errors = append(errors, e)
+			}
+		}
+	})
+	return errors
+}
+
+// A jsonError describes an error in the output of the -json flag.
+// Keep in sync with doc comment!
+type jsonError struct {
+	Package string
+	Kind    string // "list", "parse", or "type"
+	File    string `json:",omitempty"`
+	Line    int    `json:",omitempty"`
+	Col     int    `json:",omitempty"`
+	Message string
+}
+
+func newJSONError(pkg *packages.Package, err packages.Error) jsonError {
+	e := jsonError{Package: pkg.ID, Message: err.Msg}
+	switch err.Kind {
+	case packages.ListError:
+		e.Kind = "list"
+	case packages.ParseError:
+		e.Kind = "parse"
+	case packages.TypeError:
+		e.Kind = "type"
+	default:
+		e.Kind = "unknown"
+	}
+	e.File, e.Line, e.Col = splitPos(err.Pos)
+	return e
+}
+
+// position returns the position of the error in the form file:line:col.
+func (e jsonError) position() string {
+	posn := e.File
+	if e.Line > 0 {
+		posn += ":" + strconv.Itoa(e.Line)
+		if e.Col > 0 {
+			posn += ":" + strconv.Itoa(e.Col)
+		}
+	}
+	return posn
+}
+
+// splitPos splits a position of the form "file:line:col" or
+// "file:line", as found in [packages.Error.Pos], into its parts.
+// An unknown position, "" or "-", yields zero values.
+func splitPos(pos string) (file string, line, col int) {
+	if pos == "" || pos == "-" {
+		return "", 0, 0
+	}
+	// Parse trailing numbers, taking care with
+	// Windows file names such as C:\p.go.
+	var nums []int
+	for len(nums) < 2 {
+		i := strings.LastIndexByte(pos, ':')
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(pos[i+1:])
+		if err != nil {
+			break
+		}
+		nums = append(nums, n)
+		pos = pos[:i]
+	}
+	switch len(nums) {
+	case 1:
+		line = nums[0]
+	case 2:
+		line, col = nums[1], nums[0]
+	}
+	return pos, line, col
+}
@@ -0,0 +1,197 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.20
+
+package main_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/internal/testenv"
+	"golang.org/x/tools/txtar"
+)
+
+// Test runs the typecheck command on each scenario
+// described by a testdata/*.txtar file.
+func Test(t *testing.T) {
+	testenv.NeedsTool(t, "go")
+	if runtime.GOOS == "android" {
+		t.Skipf("the dependencies are not available on android")
+	}
+
+	exe := buildTypecheck(t)
+
+	matches, err := filepath.Glob("testdata/*.txtar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filename := range matches {
+		filename := filename
+		t.Run(filename, func(t *testing.T) {
+			t.Parallel()
+
+			ar, err := txtar.ParseFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Write the archive files to the temp directory.
+			tmpdir := t.TempDir()
+			for _, f := range ar.Files {
+				filename := filepath.Join(tmpdir, f.Name)
+				if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filename, f.Data, 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Parse archive comment as directives of these forms:
+			//
+			//  [!]typecheck args...	command-line arguments
+			//  stdin file		file whose contents are the standard input
+			//  [!]want arg		expected/unwanted string in output
+			//
+			// Args may be Go-quoted strings.
+			type testcase struct {
+				linenum int
+				args    []string
+				stdin   string
+				wantErr bool
+				want    map[string]bool // string -> sense
+			}
+			var cases []*testcase
+			var current *testcase
+			for i, line := range strings.Split(string(ar.Comment), "\n") {
+				line = strings.TrimSpace(line)
+				if line == "" || line[0] == '#' {
+					continue // skip blanks and comments
+				}
+
+				words, err := words(line)
+				if err != nil {
+					t.Fatalf("cannot break line into words: %v (%s)", err, line)
+				}
+				switch kind := words[0]; kind {
+				case "typecheck", "!typecheck":
+					current = &testcase{
+						linenum: i + 1,
+						want:    make(map[string]bool),
+						args:    words[1:],
+						wantErr: kind[0] == '!',
+					}
+					cases = append(cases, current)
+				case "stdin":
+					if current == nil {
+						t.Fatalf("'stdin' directive must be after 'typecheck'")
+					}
+					if len(words) != 2 {
+						t.Fatalf("'stdin' directive needs argument <<%s>>", line)
+					}
+					current.stdin = words[1]
+				case "want", "!want":
+					if current == nil {
+						t.Fatalf("'want' directive must be after 'typecheck'")
+					}
+					if len(words) != 2 {
+						t.Fatalf("'want' directive needs argument <<%s>>", line)
+					}
+					current.want[words[1]] = kind[0] != '!'
+				default:
+					t.Fatalf("%s: invalid directive %q", filename, kind)
+				}
+			}
+
+			for _, tc := range cases {
+				t.Run(fmt.Sprintf("L%d", tc.linenum), func(t *testing.T) {
+					// Run the command.
+					cmd := exec.Command(exe, tc.args...)
+					if tc.stdin != "" {
+						f, err := os.Open(filepath.Join(tmpdir, tc.stdin))
+						if err != nil {
+							t.Fatal(err)
+						}
+						defer f.Close()
+						cmd.Stdin = f
+					}
+					cmd.Stdout = new(bytes.Buffer)
+					cmd.Stderr = new(bytes.Buffer)
+					cmd.Dir = tmpdir
+					cmd.Env = append(os.Environ(), "GOPROXY=", "GO111MODULE=on")
+					err := cmd.Run()
+					if err != nil && !tc.wantErr {
+						t.Fatalf("typecheck failed: %v (stdout=%s, stderr=%s)", err, cmd.Stdout, cmd.Stderr)
+					} else if err == nil && tc.wantErr {
+						t.Fatalf("typecheck succeeded unexpectedly (stdout=%s)", cmd.Stdout)
+					}
+					got := fmt.Sprint(cmd.Stdout)
+
+					// Check each want directive.
+					for str, sense := range tc.want {
+						ok := true
+						if strings.Contains(got, str) != sense {
+							if sense {
+								t.Errorf("missing %q", str)
+							} else {
+								t.Errorf("unwanted %q", str)
+							}
+							ok = false
+						}
+						if !ok {
+							t.Errorf("got: <<%s>>", got)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+// buildTypecheck builds the typecheck executable.
+// It returns its path.
+func buildTypecheck(t *testing.T) string {
+	bin := filepath.Join(t.TempDir(), "typecheck")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	cmd := exec.Command("go", "build", "-o", bin)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Building typecheck: %v\n%s", err, out)
+	}
+	return bin
+}
+
+// words breaks a string into words, respecting
+// Go string quotations around words with spaces.
+func words(s string) ([]string, error) {
+	var words []string
+	for s != "" {
+		s = strings.TrimSpace(s)
+		var word string
+		if s[0] == '"' || s[0] == '`' {
+			prefix, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, err
+			}
+			s = s[len(prefix):]
+			word, _ = strconv.Unquote(prefix)
+		} else {
+			prefix, rest, _ := strings.Cut(s, " ")
+			s = rest
+			word = prefix
+		}
+		words = append(words, word)
+	}
+	return words, nil
+}
@@ -11,6 +11,7 @@ import (
 	"go/format"
 	"go/token"
 	"go/types"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
@@ -26,6 +27,7 @@ import (
 	"golang.org/x/tools/go/analysis/internal/checker"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/internal/diff"
+	"golang.org/x/tools/internal/proxydir"
 	"golang.org/x/tools/internal/testenv"
 	"golang.org/x/tools/txtar"
 )
@@ -278,6 +280,131 @@ func RunWithSuggestedFixes(t Testing, dir string, a *analysis.Analyzer, patterns
 	return r
 }
 
+// RunWithGoldenEdits behaves like Run, but additionally verifies the
+// combined effect of all the suggested fixes, as would a client that
+// applies them as a single workspace edit, which may change several
+// files, even in different packages or modules.
+//
+// The expected result is the txtar archive in the golden file, which
+// contains a section for each file changed by the fixes, named by its
+// slash-separated path relative to dir, holding the complete new
+// contents of the file. Go files are compared after formatting.
+// It is an error for a fix to change a file with no section, or a
+// file outside dir, and for a section to name a file left unchanged.
+//
+// A fix reported for a file common to two packages, such as a package
+// and its test variant, is applied only once. Fixes that conflict are
+// reported as errors.
+func RunWithGoldenEdits(t Testing, dir, golden string, a *analysis.Analyzer, patterns ...string) []*Result {
+	r := Run(t, dir, a, patterns...)
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		t.Errorf("%v", err)
+		return r
+	}
+	ar, err := txtar.ParseFile(golden)
+	if err != nil {
+		t.Errorf("error reading golden file: %v", err)
+		return r
+	}
+
+	// Gather the distinct edits to each file.
+	type fileEdit struct {
+		filename string
+		edit     diff.Edit
+	}
+	seen := make(map[fileEdit]bool)
+	fileEdits := make(map[string][]diff.Edit) // by slash-separated relative name
+	for _, act := range r {
+		for _, diag := range act.Diagnostics {
+			for _, fix := range diag.SuggestedFixes {
+				for _, edit := range fix.TextEdits {
+					start, end := edit.Pos, edit.End
+					if !end.IsValid() {
+						end = start
+					}
+					file := act.Pass.Fset.File(start)
+					if file == nil || start > end || act.Pass.Fset.File(end) != file {
+						t.Errorf("diagnostic for analysis %v contains Suggested Fix with malformed edit: [%v, %v)",
+							act.Pass.Analyzer.Name, start, end)
+						continue
+					}
+					rel, err := filepath.Rel(dir, file.Name())
+					if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+						t.Errorf("suggested fix %q edits %s, which is outside %s", fix.Message, file.Name(), dir)
+						continue
+					}
+					fe := fileEdit{filepath.ToSlash(rel), diff.Edit{
+						Start: file.Offset(start),
+						End:   file.Offset(end),
+						New:   string(edit.NewText),
+					}}
+					if !seen[fe] {
+						seen[fe] = true
+						fileEdits[fe.filename] = append(fileEdits[fe.filename], fe.edit)
+					}
+				}
+			}
+		}
+	}
+
+	// Compare the edited files with the golden sections.
+	sections := make(map[string][]byte)
+	for _, f := range ar.Files {
+		sections[f.Name] = f.Data
+	}
+	var names []string
+	for name := range fileEdits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		want, ok := sections[name]
+		if !ok {
+			t.Errorf("%s: no section for file %s, which is changed by suggested fixes", golden, name)
+			continue
+		}
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		orig, err := os.ReadFile(filename)
+		if err != nil {
+			t.Errorf("error reading %s: %v", filename, err)
+			continue
+		}
+		if strings.HasSuffix(name, ".go") {
+			err = applyDiffsAndCompare(orig, want, fileEdits[name], filename)
+		} else {
+			err = applyDiffsAndCompareText(orig, want, fileEdits[name], filename)
+		}
+		if err != nil {
+			t.Errorf("%s", err)
+		}
+	}
+	for _, f := range ar.Files {
+		if _, ok := fileEdits[f.Name]; !ok {
+			t.Errorf("%s: section for file %s, which is not changed by suggested fixes", golden, f.Name)
+		}
+	}
+	return r
+}
+
+// applyDiffsAndCompareText is like applyDiffsAndCompare,
+// but for non-Go files, which are compared without formatting,
+// apart from trailing newlines.
+func applyDiffsAndCompareText(src, golden []byte, edits []diff.Edit, fileName string) error {
+	out, err := diff.ApplyBytes(src, edits)
+	if err != nil {
+		return fmt.Errorf("%s: error applying fixes: %v (see possible explanations at RunWithSuggestedFixes)", fileName, err)
+	}
+	want := string(bytes.TrimRight(golden, "\n")) + "\n"
+	got := string(bytes.TrimRight(out, "\n")) + "\n"
+	if got != want {
+		unified := diff.Unified(fileName+".golden", "actual", want, got)
+		return fmt.Errorf("suggested fixes failed for %s:\n%s", fileName, unified)
+	}
+	return nil
+}
+
 // applyDiffsAndCompare applies edits to src and compares the results against
 // golden after formatting both. fileName is use solely for error reporting.
 func applyDiffsAndCompare(src, golden []byte, edits []diff.Edit, fileName string) error {
@@ -312,8 +439,19 @@ func applyDiffsAndCompare(src, golden []byte, edits []diff.Edit, fileName string
 // "//...// want..." or "/*...// want... */" as if it starts at 'want'.
 //
 // If the directory contains a go.mod file, Run treats it as the root of the
-// Go module in which to work. Otherwise, Run treats it as the root of a
-// GOPATH-style tree, with package contained in the src subdirectory.
+// Go module in which to work. If it contains a go.work file, Run treats it
+// as the root of a workspace, whose modules may be in subdirectories.
+// Otherwise, Run treats it as the root of a GOPATH-style tree, with
+// package contained in the src subdirectory.
+//
+// In module mode, the proxy subdirectory of the directory, if any,
+// provides the external dependencies of the modules, through a module
+// proxy. Its subdirectories are named module@version, as in
+// proxy/example.com/dep@v1.0.0, and each contains the files of one
+// version of one module, including its go.mod file. The go command
+// records the checksums of the dependencies in the go.sum or
+// go.work.sum file of the directory, so a test that uses the proxy
+// should copy its files to a temporary directory.
 //
 // An expectation of a Diagnostic is specified by a string literal
 // containing a regular expression that must match the diagnostic
@@ -349,7 +487,10 @@ func Run(t Testing, dir string, a *analysis.Analyzer, patterns ...string) []*Res
 		testenv.NeedsGoPackages(t)
 	}
 
-	pkgs, err := loadPackages(a, dir, patterns...)
+	pkgs, cleanup, err := loadPackages(a, dir, patterns...)
+	if cleanup != nil {
+		defer cleanup()
+	}
 	if err != nil {
 		t.Errorf("loading %s: %v", patterns, err)
 		return nil
@@ -375,20 +516,31 @@ func Run(t Testing, dir string, a *analysis.Analyzer, patterns ...string) []*Res
 type Result = checker.TestAnalyzerResult
 
 // loadPackages uses go/packages to load a specified packages (from source, with
-// dependencies) from dir, which is the root of a GOPATH-style project tree.
+// dependencies) from dir, which is the root of a GOPATH-style project tree,
+// a module, or a workspace.
 // loadPackages returns an error if any package had an error, or the pattern
-// matched no packages.
-func loadPackages(a *analysis.Analyzer, dir string, patterns ...string) ([]*packages.Package, error) {
+// matched no packages. The cleanup function, if non-nil, deletes the
+// temporary module cache of the proxy.
+func loadPackages(a *analysis.Analyzer, dir string, patterns ...string) (_ []*packages.Package, cleanup func(), _ error) {
 	env := []string{"GOPATH=" + dir, "GO111MODULE=off", "GOWORK=off"} // GOPATH mode
 
-	// Undocumented module mode. Will be replaced by something better.
-	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
-		gowork := filepath.Join(dir, "go.work")
-		if _, err := os.Stat(gowork); err != nil {
-			gowork = "off"
-		}
+	// Module mode.
+	gowork := filepath.Join(dir, "go.work")
+	if _, err := os.Stat(gowork); err != nil {
+		gowork = "off"
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil || gowork != "off" {
+		env = []string{"GO111MODULE=on", "GOPROXY=off", "GOWORK=" + gowork}
 
-		env = []string{"GO111MODULE=on", "GOPROXY=off", "GOWORK=" + gowork} // module mode
+		proxy := filepath.Join(dir, "proxy")
+		if _, err := os.Stat(proxy); err == nil {
+			var proxyEnv []string
+			proxyEnv, cleanup, err = writeProxy(proxy)
+			if err != nil {
+				return nil, cleanup, fmt.Errorf("writing module proxy: %v", err)
+			}
+			env = append(env, proxyEnv...)
+		}
 	}
 
 	// packages.Load loads the real standard library, not a minimal
@@ -409,14 +561,14 @@ func loadPackages(a *analysis.Analyzer, dir string, patterns ...string) ([]*pack
 	}
 	pkgs, err := packages.Load(cfg, patterns...)
 	if err != nil {
-		return nil, err
+		return nil, cleanup, err
 	}
 
 	// If any named package couldn't be loaded at all
 	// (e.g. the Name field is unset), fail fast.
 	for _, pkg := range pkgs {
 		if pkg.Name == "" {
-			return nil, fmt.Errorf("failed to load %q: Errors=%v",
+			return nil, cleanup, fmt.Errorf("failed to load %q: Errors=%v",
 				pkg.PkgPath, pkg.Errors)
 		}
 	}
@@ -427,14 +579,76 @@ func loadPackages(a *analysis.Analyzer, dir string, patterns ...string) ([]*pack
 	// errors are expected and are going to be fixed.
 	if !a.RunDespiteErrors {
 		if packages.PrintErrors(pkgs) > 0 {
-			return nil, fmt.Errorf("there were package loading errors (and RunDespiteErrors is false)")
+			return nil, cleanup, fmt.Errorf("there were package loading errors (and RunDespiteErrors is false)")
 		}
 	}
 
 	if len(pkgs) == 0 {
-		return nil, fmt.Errorf("no packages matched %s", patterns)
+		return nil, cleanup, fmt.Errorf("no packages matched %s", patterns)
+	}
+	return pkgs, cleanup, nil
+}
+
+// writeProxy writes the modules in the proxy tree of a test directory
+// (see Run) to a temporary module proxy, and returns the environment
+// variables that cause the go command to use it, with an empty module
+// cache. The cleanup function deletes the proxy and the cache.
+func writeProxy(tree string) (env []string, cleanup func(), _ error) {
+	tmpdir, err := os.MkdirTemp("", "analysistest-proxy")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() { os.RemoveAll(tmpdir) }
+	proxy := filepath.Join(tmpdir, "proxy")
+
+	// Each module@version directory holds a module.
+	err = filepath.WalkDir(tree, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(tree, path)
+		if err != nil {
+			return err
+		}
+		module, version, ok := strings.Cut(filepath.ToSlash(rel), "@")
+		if !ok {
+			return nil
+		}
+		files := make(map[string][]byte)
+		err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			name, err := filepath.Rel(path, file)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(name)] = data
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := proxydir.WriteModuleVersion(proxy, module, version, files); err != nil {
+			return err
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, cleanup, err
+	}
+
+	env = []string{
+		"GOPROXY=" + proxydir.ToURL(proxy),
+		"GOMODCACHE=" + filepath.Join(tmpdir, "modcache"),
+		"GOSUMDB=off",
+		"GOFLAGS=-modcacherw", // so that cleanup can delete the cache
 	}
-	return pkgs, nil
+	return env, cleanup, nil
 }
 
 // check inspects an analysis pass on which the analysis has already
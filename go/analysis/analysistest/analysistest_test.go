@@ -6,9 +6,11 @@ package analysistest_test
 
 import (
 	"fmt"
+	"go/ast"
 	"go/token"
 	"log"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -17,6 +19,8 @@ import (
 	"golang.org/x/tools/go/analysis/analysistest"
 	"golang.org/x/tools/go/analysis/passes/findcall"
 	"golang.org/x/tools/internal/testenv"
+	"golang.org/x/tools/internal/testfiles"
+	"golang.org/x/tools/txtar"
 )
 
 func init() {
@@ -207,3 +211,193 @@ type errorfunc func(string)
 func (f errorfunc) Errorf(format string, args ...interface{}) {
 	f(fmt.Sprintf(format, args...))
 }
+
+// TestModules tests a workspace of several modules with an external
+// dependency served by the proxy.
+func TestModules(t *testing.T) {
+	testenv.NeedsTool(t, "go")
+
+	findcall.Analyzer.Flags.Set("name", "println")
+
+	const src = `
+-- go.work --
+go 1.18
+
+use (
+	./a
+	./b
+)
+
+-- a/go.mod --
+module example.com/a
+
+go 1.18
+
+require example.com/dep v1.0.0
+
+-- a/a.go --
+package a
+
+import (
+	"example.com/b"
+	"example.com/dep"
+)
+
+func f() {
+	println(b.B, dep.D) // want "call of println"
+}
+
+-- b/go.mod --
+module example.com/b
+
+go 1.18
+
+-- b/b.go --
+package b
+
+const B = 1
+
+func g() {
+	println() // want "call of println"
+}
+
+-- proxy/example.com/dep@v1.0.0/go.mod --
+module example.com/dep
+
+go 1.18
+
+-- proxy/example.com/dep@v1.0.0/dep.go --
+package dep
+
+const D = 2
+
+func h() { println() } // not analyzed
+`
+	dir := testfiles.ExtractTxtarToTmp(t, txtar.Parse([]byte(src)))
+	analysistest.Run(t, dir, findcall.Analyzer, "example.com/a", "example.com/b")
+}
+
+// TestGoldenEdits tests the comparison of suggested fixes that
+// change several files with a golden archive.
+func TestGoldenEdits(t *testing.T) {
+	testenv.NeedsTool(t, "go")
+
+	// rename reports each declaration of a function F and offers to
+	// rename it, and each call to it, to G.
+	rename := &analysis.Analyzer{
+		Name:      "rename",
+		Doc:       "renames F to G",
+		FactTypes: []analysis.Fact{new(renamed)},
+		Run: func(pass *analysis.Pass) (any, error) {
+			for _, f := range pass.Files {
+				ast.Inspect(f, func(n ast.Node) bool {
+					id, ok := n.(*ast.Ident)
+					if !ok || id.Name != "F" {
+						return true
+					}
+					edit := analysis.TextEdit{Pos: id.Pos(), End: id.End(), NewText: []byte("G")}
+					if pass.TypesInfo.Defs[id] != nil {
+						pass.ExportObjectFact(pass.TypesInfo.Defs[id], new(renamed))
+						pass.Report(analysis.Diagnostic{
+							Pos:            id.Pos(),
+							Message:        "rename F",
+							SuggestedFixes: []analysis.SuggestedFix{{Message: "rename F to G", TextEdits: []analysis.TextEdit{edit}}},
+						})
+					} else if obj := pass.TypesInfo.Uses[id]; obj != nil && pass.ImportObjectFact(obj, new(renamed)) {
+						pass.Report(analysis.Diagnostic{
+							Pos:            id.Pos(),
+							Message:        "call of F",
+							SuggestedFixes: []analysis.SuggestedFix{{Message: "rename F to G", TextEdits: []analysis.TextEdit{edit}}},
+						})
+					}
+					return true
+				})
+			}
+			return nil, nil
+		},
+	}
+
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+
+-- a/a.go --
+package a
+
+func F() {} // want "rename F" F:"renamed"
+
+-- a/a_test.go --
+package a
+
+func init() { F() } // want "call of F"
+
+-- b/b.go --
+package b
+
+import "example.com/a"
+
+func f() { a.F() } // want "call of F"
+
+-- c/c.go --
+package c
+
+func f() {}
+
+-- golden.txtar --
+-- a/a.go --
+package a
+
+func G() {} // want "rename F" F:"renamed"
+
+-- a/a_test.go --
+package a
+
+func init() { G() } // want "call of F"
+
+-- b/b.go --
+package b
+
+import "example.com/a"
+
+func f() { a.G() } // want "call of F"
+
+-- c/c.go --
+package c
+
+func f() {}
+`
+	ar := txtar.Parse([]byte(src))
+	// Nested archives are not supported: split off the golden file.
+	for i, f := range ar.Files {
+		if f.Name == "golden.txtar" {
+			ar.Files = ar.Files[:i]
+			break
+		}
+	}
+	_, golden, _ := strings.Cut(src, "-- golden.txtar --\n")
+	dir := testfiles.ExtractTxtarToTmp(t, ar)
+	goldenFile := filepath.Join(dir, "golden.txtar")
+	if err := os.WriteFile(goldenFile, []byte(golden), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	t2 := errorfunc(func(s string) { got = append(got, s) }) // a fake *testing.T
+	analysistest.RunWithGoldenEdits(t2, dir, goldenFile, rename, "example.com/...")
+
+	want := []string{
+		goldenFile + ": section for file c/c.go, which is not changed by suggested fixes",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%s\nwant:\n%s",
+			strings.Join(got, "\n"),
+			strings.Join(want, "\n"))
+	}
+}
+
+type renamed struct{}
+
+func (*renamed) AFact()         {}
+func (*renamed) String() string { return "renamed" }
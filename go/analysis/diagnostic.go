@@ -4,7 +4,10 @@
 
 package analysis
 
-import "go/token"
+import (
+	"fmt"
+	"go/token"
+)
 
 // A Diagnostic is a message associated with a source location or range.
 //
@@ -32,6 +35,22 @@ type Diagnostic struct {
 	// see https://pkg.go.dev/net/url#URL.ResolveReference.
 	URL string
 
+	// Code is an optional identifier of the specific problem, such
+	// as an error code, that is finer-grained than Category.
+	// Drivers may display it alongside the message.
+	Code string
+
+	// Severity is an optional hint of the importance of the
+	// diagnostic. Drivers may map it to their own levels, and
+	// should let the user's configuration take precedence over it.
+	Severity Severity
+
+	// Tags are optional attributes of the code to which the
+	// diagnostic applies, which drivers may use to render it, for
+	// example by fading unnecessary code or striking through
+	// deprecated code.
+	Tags []DiagnosticTag
+
 	// SuggestedFixes is an optional list of fixes to address the
 	// problem described by the diagnostic. Each one represents
 	// an alternative strategy; at most one may be applied.
@@ -51,6 +70,51 @@ type Diagnostic struct {
 	Related []RelatedInformation
 }
 
+// A Severity is a hint of the importance of a Diagnostic.
+type Severity int
+
+const (
+	SeverityUnspecified Severity = iota // the driver's default
+	SeverityError
+	SeverityWarning
+	SeverityInfo
+	SeverityHint
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityUnspecified:
+		return "unspecified"
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// A DiagnosticTag is an attribute of the code reported by a Diagnostic.
+type DiagnosticTag int
+
+const (
+	TagUnnecessary DiagnosticTag = iota + 1 // unused or unreachable code
+	TagDeprecated                           // use of a deprecated declaration
+)
+
+func (t DiagnosticTag) String() string {
+	switch t {
+	case TagUnnecessary:
+		return "unnecessary"
+	case TagDeprecated:
+		return "deprecated"
+	}
+	return fmt.Sprintf("DiagnosticTag(%d)", int(t))
+}
+
 // RelatedInformation contains information related to a diagnostic.
 // For example, a diagnostic that flags duplicated declarations of a
 // variable may include one RelatedInformation per existing
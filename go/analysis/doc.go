@@ -175,14 +175,21 @@ Diagnostic is defined as:
 The optional Category field is a short identifier that classifies the
 kind of message when an analysis produces several kinds of diagnostic.
 
-The [Diagnostic] struct does not have a field to indicate its severity
-because opinions about the relative importance of Analyzers and their
-diagnostics vary widely among users. The design of this framework does
-not hold each Analyzer responsible for identifying the severity of its
-diagnostics. Instead, we expect that drivers will allow the user to
+Opinions about the relative importance of Analyzers and their
+diagnostics vary widely among users, so the design of this framework
+does not hold each Analyzer responsible for identifying the severity of
+its diagnostics. Instead, we expect that drivers will allow the user to
 customize the filtering and prioritization of diagnostics based on the
 producing Analyzer and optional Category, according to the user's
-preferences.
+preferences. The optional Severity field of a [Diagnostic] is merely a
+hint, for use in the absence of such configuration.
+
+Other optional fields describe a diagnostic in a structured form that
+drivers can map to their output formats, so that an Analyzer need not
+encode such metadata in the message: Code identifies the specific
+problem, such as an error code; URL locates its documentation; and Tags
+indicate that the diagnosed code is unnecessary or deprecated, for
+drivers that render such code differently.
 
 Most Analyzers inspect typed Go syntax trees, but a few, such as asmdecl
 and buildtag, inspect the raw text of Go source files or even non-Go
@@ -367,7 +367,11 @@ type JSONSuggestedFix struct {
 // TODO(matloob): include End position if present.
 type JSONDiagnostic struct {
 	Category       string                   `json:"category,omitempty"`
-	Posn           string                   `json:"posn"` // e.g. "file.go:line:column"
+	Code           string                   `json:"code,omitempty"`
+	URL            string                   `json:"url,omitempty"`
+	Severity       string                   `json:"severity,omitempty"` // e.g. "warning"
+	Tags           []string                 `json:"tags,omitempty"`     // e.g. "deprecated"
+	Posn           string                   `json:"posn"`               // e.g. "file.go:line:column"
 	Message        string                   `json:"message"`
 	SuggestedFixes []JSONSuggestedFix       `json:"suggested_fixes,omitempty"`
 	Related        []JSONRelatedInformation `json:"related,omitempty"`
@@ -417,8 +421,20 @@ func (tree JSONTree) Add(fset *token.FileSet, id, name string, diags []analysis.
 					Message: r.Message,
 				})
 			}
+			var severity string
+			if f.Severity != analysis.SeverityUnspecified {
+				severity = f.Severity.String()
+			}
+			var tags []string
+			for _, tag := range f.Tags {
+				tags = append(tags, tag.String())
+			}
 			jdiag := JSONDiagnostic{
 				Category:       f.Category,
+				Code:           f.Code,
+				URL:            f.URL,
+				Severity:       severity,
+				Tags:           tags,
 				Posn:           fset.Position(f.Pos).String(),
 				Message:        f.Message,
 				SuggestedFixes: fixes,
@@ -5,7 +5,9 @@
 package analysisflags_test
 
 import (
+	"encoding/json"
 	"fmt"
+	"go/token"
 	"os"
 	"os/exec"
 	"runtime"
@@ -66,3 +68,39 @@ func TestExec(t *testing.T) {
 		}
 	}
 }
+
+// TestJSONTree tests the JSON form of the structured fields of a
+// diagnostic.
+func TestJSONTree(t *testing.T) {
+	fset := token.NewFileSet()
+	f := fset.AddFile("a.go", -1, 100)
+	f.SetLines([]int{0, 50})
+
+	tree := make(analysisflags.JSONTree)
+	tree.Add(fset, "p", "a1", []analysis.Diagnostic{
+		{
+			Pos:      f.Pos(60),
+			Category: "cat",
+			Code:     "C1",
+			URL:      "https://example.com/C1",
+			Severity: analysis.SeverityWarning,
+			Tags:     []analysis.DiagnosticTag{analysis.TagUnnecessary, analysis.TagDeprecated},
+			Message:  "oops",
+		},
+		{
+			Pos:     f.Pos(0),
+			Message: "plain",
+		},
+	}, nil)
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	want := `{"p":{"a1":[` +
+		`{"category":"cat","code":"C1","url":"https://example.com/C1","severity":"warning","tags":["unnecessary","deprecated"],"posn":"a.go:2:11","message":"oops"},` +
+		`{"posn":"a.go:1:1","message":"plain"}]}}`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
@@ -5,9 +5,11 @@
 package lostcancel
 
 import (
+	"bytes"
 	_ "embed"
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
 
 	"golang.org/x/tools/go/analysis"
@@ -77,6 +79,10 @@ func runFunc(pass *analysis.Pass, node ast.Node) {
 	// Maps each cancel variable to its defining ValueSpec/AssignStmt.
 	cancelvars := make(map[*types.Var]ast.Node)
 
+	// Maps each cancel variable to the statement after which a
+	// deferred call to it may be inserted, if any.
+	deferAfter := make(map[*types.Var]ast.Stmt)
+
 	// TODO(adonovan): opt: refactor to make a single pass
 	// over the AST using inspect.WithStack and node types
 	// {FuncDecl,FuncLit,CallExpr,SelectorExpr}.
@@ -105,22 +111,47 @@ func runFunc(pass *analysis.Pass, node ast.Node) {
 			return true
 		}
 		var id *ast.Ident // id of cancel var
+		declares := false // whether stmt declares the cancel var
 		stmt := stack[len(stack)-3]
 		switch stmt := stmt.(type) {
 		case *ast.ValueSpec:
 			if len(stmt.Names) > 1 {
 				id = stmt.Names[1]
+				declares = true
 			}
 		case *ast.AssignStmt:
 			if len(stmt.Lhs) > 1 {
 				id, _ = stmt.Lhs[1].(*ast.Ident)
+				declares = stmt.Tok == token.DEFINE
 			}
 		}
+		after := deferPoint(stack[:len(stack)-2])
 		if id != nil {
 			if id.Name == "_" {
-				pass.ReportRangef(id,
-					"the cancel function returned by context.%s should be called, not discarded, to avoid a context leak",
-					n.(*ast.SelectorExpr).Sel.Name)
+				diag := analysis.Diagnostic{
+					Pos: id.Pos(),
+					End: id.End(),
+					Message: fmt.Sprintf("the cancel function returned by context.%s should be called, not discarded, to avoid a context leak",
+						n.(*ast.SelectorExpr).Sel.Name),
+				}
+				// Offer to name the cancel function and defer a call to it,
+				// unless the name "cancel" is already in use, either
+				// anywhere in the enclosing scope, or visible from it.
+				if after != nil && declares {
+					scope := pass.Pkg.Scope().Innermost(id.Pos())
+					if _, obj := scope.LookupParent("cancel", id.Pos()); obj == nil && scope.Lookup("cancel") == nil {
+						if edit, ok := insertDefer(pass, after, "cancel"); ok {
+							diag.SuggestedFixes = []analysis.SuggestedFix{{
+								Message: "Defer call to cancel",
+								TextEdits: []analysis.TextEdit{
+									{Pos: id.Pos(), End: id.End(), NewText: []byte("cancel")},
+									edit,
+								},
+							}}
+						}
+					}
+				}
+				pass.Report(diag)
 			} else if v, ok := pass.TypesInfo.Uses[id].(*types.Var); ok {
 				// If the cancel variable is defined outside function scope,
 				// do not analyze it.
@@ -129,6 +160,9 @@ func runFunc(pass *analysis.Pass, node ast.Node) {
 				}
 			} else if v, ok := pass.TypesInfo.Defs[id].(*types.Var); ok {
 				cancelvars[v] = stmt
+				if after != nil {
+					deferAfter[v] = after
+				}
 			}
 		}
 		return true
@@ -171,7 +205,22 @@ func runFunc(pass *analysis.Pass, node ast.Node) {
 	for v, stmt := range cancelvars {
 		if ret := lostCancelPath(pass, g, v, stmt, sig); ret != nil {
 			lineno := pass.Fset.Position(stmt.Pos()).Line
-			pass.ReportRangef(stmt, "the %s function is not used on all paths (possible context leak)", v.Name())
+			diag := analysis.Diagnostic{
+				Pos:     stmt.Pos(),
+				End:     stmt.End(),
+				Message: fmt.Sprintf("the %s function is not used on all paths (possible context leak)", v.Name()),
+			}
+			// A deferred call is safe to add only if the cancel
+			// function is merely called, and does not escape.
+			if after, ok := deferAfter[v]; ok && onlyCalled(pass.TypesInfo, node, v) {
+				if edit, ok := insertDefer(pass, after, v.Name()); ok {
+					diag.SuggestedFixes = []analysis.SuggestedFix{{
+						Message:   fmt.Sprintf("Defer call to %s", v.Name()),
+						TextEdits: []analysis.TextEdit{edit},
+					}}
+				}
+			}
+			pass.Report(diag)
 
 			pos, end := ret.Pos(), ret.End()
 			// golang/go#64547: cfg.Block.Return may return a synthetic
@@ -190,6 +239,92 @@ func runFunc(pass *analysis.Pass, node ast.Node) {
 
 func isCall(n ast.Node) bool { _, ok := n.(*ast.CallExpr); return ok }
 
+// deferPoint returns the statement, defined by the AssignStmt or
+// ValueSpec at the top of the stack, after which a deferred call may be
+// inserted: one that is an element of a statement list, not within a
+// loop. Otherwise it returns nil.
+func deferPoint(stack []ast.Node) ast.Stmt {
+	var stmt ast.Stmt
+	i := len(stack) - 1
+	switch n := stack[i].(type) {
+	case *ast.AssignStmt:
+		stmt = n
+	case *ast.ValueSpec:
+		// [DeclStmt GenDecl ValueSpec]
+		if i < 2 {
+			return nil
+		}
+		i -= 2
+		decl, ok := stack[i].(*ast.DeclStmt)
+		if !ok {
+			return nil
+		}
+		stmt = decl
+	default:
+		return nil
+	}
+	if i < 1 {
+		return nil
+	}
+	switch stack[i-1].(type) {
+	case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+	default:
+		return nil // e.g. the init statement of an if statement
+	}
+	for _, n := range stack[:i] {
+		switch n.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			return nil // a deferred call would run only at function exit
+		}
+	}
+	return stmt
+}
+
+// insertDefer returns an edit that inserts a deferred call to the
+// named function on the line after stmt, with the same indentation if
+// the driver provides the file content, or none otherwise.
+func insertDefer(pass *analysis.Pass, stmt ast.Stmt, name string) (analysis.TextEdit, bool) {
+	tf := pass.Fset.File(stmt.Pos())
+	if tf == nil || tf.Line(stmt.End()) >= tf.LineCount() {
+		return analysis.TextEdit{}, false
+	}
+	var indent []byte
+	if pass.ReadFile != nil {
+		content, err := pass.ReadFile(tf.Name())
+		if err != nil || tf.Size() != len(content) {
+			return analysis.TextEdit{}, false
+		}
+		line := content[tf.Offset(tf.LineStart(tf.Line(stmt.Pos()))):]
+		indent = line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+	}
+	next := tf.LineStart(tf.Line(stmt.End()) + 1)
+	return analysis.TextEdit{
+		Pos:     next,
+		End:     next,
+		NewText: []byte(fmt.Sprintf("%sdefer %s()\n", indent, name)),
+	}, true
+}
+
+// onlyCalled reports whether each use of v within node is a call v().
+func onlyCalled(info *types.Info, node ast.Node, v *types.Var) bool {
+	called := make(map[*ast.Ident]bool)
+	ok := true
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if id, isIdent := n.Fun.(*ast.Ident); isIdent {
+				called[id] = true
+			}
+		case *ast.Ident:
+			if info.Uses[n] == v && !called[n] {
+				ok = false
+			}
+		}
+		return ok
+	})
+	return ok
+}
+
 // isContextWithCancel reports whether n is one of the qualified identifiers
 // context.With{Cancel,Timeout,Deadline}.
 func isContextWithCancel(info *types.Info, n ast.Node) bool {
@@ -14,4 +14,5 @@ import (
 func Test(t *testing.T) {
 	testdata := analysistest.TestData()
 	analysistest.Run(t, testdata, lostcancel.Analyzer, "a", "b", "typeparams")
+	analysistest.RunWithSuggestedFixes(t, testdata, lostcancel.Analyzer, "fix")
 }
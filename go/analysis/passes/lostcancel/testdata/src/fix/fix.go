@@ -0,0 +1,73 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fix
+
+import "context"
+
+var bg = context.Background()
+
+func _() {
+	ctx, _ := context.WithCancel(bg) // want "should be called, not discarded"
+	print(ctx)
+}
+
+func _() {
+	var ctx, _ = context.WithCancel(bg) // want "should be called, not discarded"
+	print(ctx)
+}
+
+func _(cancel int) {
+	ctx, _ := context.WithCancel(bg) // want "should be called, not discarded"
+	print(ctx, cancel)
+}
+
+func _() {
+	ctx, _ := context.WithCancel(bg) // want "should be called, not discarded"
+	print(ctx)
+	cancel := 0
+	print(cancel)
+}
+
+func _() {
+	ctx, stop := context.WithCancel(bg) // want "not used on all paths"
+	if ctx.Err() != nil {
+		stop()
+		return
+	}
+	print(ctx)
+} // want "may be reached without using the stop var"
+
+func _(ch chan int) {
+	switch <-ch {
+	case 0:
+		ctx, cancel := context.WithCancel(bg) // want "not used on all paths"
+		if ctx.Err() != nil {
+			cancel()
+		}
+	}
+} // want "may be reached without using the cancel var"
+
+func _() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(bg) // want "not used on all paths"
+	if ctx.Err() != nil {
+		return ctx, cancel
+	}
+	return ctx, nil // want "may be reached without using the cancel var"
+}
+
+func _() {
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(bg) // want "not used on all paths"
+		if ctx.Err() != nil {
+			cancel()
+		}
+	}
+} // want "may be reached without using the cancel var"
+
+func _() {
+	if ctx, cancel := context.WithCancel(bg); ctx.Err() != nil { // want "not used on all paths"
+		cancel()
+	}
+} // want "may be reached without using the cancel var"
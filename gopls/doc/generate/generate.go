@@ -8,7 +8,8 @@
 //	gopls/doc/commands.md   -- from loading gopls/internal/protocol/command
 //	gopls/doc/analyzers.md  -- from linking gopls/internal/settings.DefaultAnalyzers
 //	gopls/doc/inlayHints.md -- from linking gopls/internal/golang.AllInlayHints
-//	gopls/internal/doc/api.json -- all of the above in a single value, for 'gopls api-json'
+//	gopls/internal/doc/api.json -- all of the above in a single value, for 'gopls api-json',
+//	                               plus examples of analyzer fixes from their tests
 //
 // Run it with this command:
 //
@@ -22,17 +23,20 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/gopls/internal/cache"
@@ -43,6 +47,8 @@ import (
 	"golang.org/x/tools/gopls/internal/settings"
 	"golang.org/x/tools/gopls/internal/util/maps"
 	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/diff"
+	"golang.org/x/tools/txtar"
 )
 
 func main() {
@@ -144,10 +150,13 @@ func loadAPI() (*doc.API, error) {
 
 	defaults := settings.DefaultOptions()
 	api := &doc.API{
-		Options:   map[string][]*doc.Option{},
-		Analyzers: loadAnalyzers(settings.DefaultAnalyzers), // no staticcheck analyzers
+		Options: map[string][]*doc.Option{},
 	}
 
+	api.Analyzers, err = loadAnalyzers(settings.DefaultAnalyzers) // no staticcheck analyzers
+	if err != nil {
+		return nil, err
+	}
 	api.Commands, err = loadCommands()
 	if err != nil {
 		return nil, err
@@ -589,23 +598,173 @@ func loadLenses(settingsPkg *packages.Package, defaults map[settings.CodeLensSou
 	return lenses, nil
 }
 
-func loadAnalyzers(m map[string]*settings.Analyzer) []*doc.Analyzer {
+func loadAnalyzers(m map[string]*settings.Analyzer) ([]*doc.Analyzer, error) {
 	var sorted []string
 	for _, a := range m {
 		sorted = append(sorted, a.Analyzer().Name)
 	}
 	sort.Strings(sorted)
+	// Analyzer tests are found relative to the x/tools module.
+	cmd := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "golang.org/x/tools")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", cmd, err)
+	}
+	moduleDir := strings.TrimSpace(string(out))
+
 	var json []*doc.Analyzer
 	for _, name := range sorted {
 		a := m[name]
+		example, err := loadAnalyzerExample(moduleDir, a.Analyzer())
+		if err != nil {
+			return nil, fmt.Errorf("loading example of %s: %v", name, err)
+		}
 		json = append(json, &doc.Analyzer{
 			Name:    a.Analyzer().Name,
 			Doc:     a.Analyzer().Doc,
 			URL:     a.Analyzer().URL,
 			Default: a.EnabledByDefault(),
+			Example: example,
 		})
 	}
-	return json
+	return json, nil
+}
+
+// loadAnalyzerExample returns an example of the fixes of the analyzer,
+// taken from the golden files of the tests in the testdata directory
+// of its package, or nil if there are none or the analyzer is not
+// defined in the x/tools module, whose directory is moduleDir. Of all
+// the pairs of a test file and its golden file, it chooses the one
+// with the smallest change, showing only the changed lines and a
+// little context.
+func loadAnalyzerExample(moduleDir string, a *analysis.Analyzer) (*doc.AnalyzerExample, error) {
+	// The package of the analyzer is that of its Run function.
+	name := runtime.FuncForPC(reflect.ValueOf(a.Run).Pointer()).Name()
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return nil, fmt.Errorf("can't determine package of function %s", name)
+	}
+	const modulePath = "golang.org/x/tools/"
+	pkgPath := name[:slash+1+dot]
+	if !strings.HasPrefix(pkgPath, modulePath) {
+		return nil, nil
+	}
+	dir := filepath.Join(moduleDir, filepath.FromSlash(strings.TrimPrefix(pkgPath, modulePath)))
+
+	var best *doc.AnalyzerExample
+	bestSize := 0
+	err := filepath.WalkDir(filepath.Join(dir, "testdata"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && d == nil {
+				return filepath.SkipDir // no testdata
+			}
+			return err
+		}
+		if !strings.HasSuffix(path, ".go.golden") {
+			return nil
+		}
+		before, err := os.ReadFile(strings.TrimSuffix(path, ".golden"))
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		after, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// A golden file may be an archive of the results
+		// of each fix; use the first.
+		if bytes.HasPrefix(after, []byte("-- ")) {
+			if ar := txtar.Parse(after); len(ar.Files) > 0 {
+				after = ar.Files[0].Data
+			}
+		}
+		example := exampleOf(string(before), string(after))
+		if example == nil {
+			return nil
+		}
+		if size := strings.Count(example.Before, "\n") + strings.Count(example.After, "\n"); best == nil || size < bestSize {
+			rel, err := filepath.Rel(moduleDir, strings.TrimSuffix(path, ".golden"))
+			if err != nil {
+				return err
+			}
+			example.File = filepath.ToSlash(rel)
+			best, bestSize = example, size
+		}
+		return nil
+	})
+	return best, err
+}
+
+// exampleOf returns the fragments of the before and after text of a
+// test file that contain the first group of nearby changes, with two
+// lines of context, ignoring the "// want" comments of the test. It
+// returns nil if the texts do not differ.
+func exampleOf(before, after string) *doc.AnalyzerExample {
+	before, after = withoutWants(before), withoutWants(after)
+	edits := diff.Strings(before, after)
+	if len(edits) == 0 {
+		return nil
+	}
+
+	// Find the lines of the first group of edits,
+	// and the start offsets of the lines.
+	const context = 2
+	line := func(offset int) int { return strings.Count(before[:offset], "\n") }
+	first, last := line(edits[0].Start), line(edits[0].End)
+	n := 1
+	for n < len(edits) && line(edits[n].Start) <= last+2*context {
+		last = line(edits[n].End)
+		n++
+	}
+	lines := strings.SplitAfter(before, "\n")
+	lo, hi := first-context, last+context+1
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(lines) {
+		hi = len(lines)
+	}
+	start := len(strings.Join(lines[:lo], ""))
+	end := start + len(strings.Join(lines[lo:hi], ""))
+
+	// Apply the edits to the fragment.
+	fragment := before[start:end]
+	var shifted []diff.Edit
+	for _, edit := range edits[:n] {
+		edit.Start -= start
+		edit.End -= start
+		if edit.End > len(fragment) {
+			edit.End = len(fragment)
+		}
+		shifted = append(shifted, edit)
+	}
+	fixed, err := diff.Apply(fragment, shifted)
+	if err != nil {
+		return nil
+	}
+	return &doc.AnalyzerExample{Before: fragment, After: fixed}
+}
+
+// wantRx matches the "// want" comment of an analysistest test file.
+var wantRx = regexp.MustCompile(`\s*// want .*$`)
+
+// withoutWants returns the text of a test file without its "// want"
+// comments, and without the lines that contain only such a comment.
+func withoutWants(text string) string {
+	var buf strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if stripped := wantRx.ReplaceAllString(strings.TrimSuffix(line, "\n"), ""); len(stripped) < len(line)-1 {
+			if strings.TrimSpace(stripped) == "" {
+				continue
+			}
+			line = stripped + "\n"
+		}
+		buf.WriteString(line)
+	}
+	return buf.String()
 }
 
 func loadHints(m map[string]*golang.Hint) []*doc.Hint {
@@ -0,0 +1,135 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package alwaysnilerror
+
+import (
+	_ "embed"
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/gopls/internal/analysis/conformance"
+	"golang.org/x/tools/internal/analysisinternal"
+)
+
+//go:embed doc.go
+var doc string
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "alwaysnilerror",
+	Doc:      analysisinternal.MustExtractDoc(doc, "alwaysnilerror"),
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+	URL:      "https://pkg.go.dev/golang.org/x/tools/gopls/internal/analysis/alwaysnilerror",
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	// Gather global information:
+	// - functions used other than in call position
+	//
+	// (See the unusedparams analyzer for a longer discussion of
+	// "address-taken" functions.)
+	addressTaken := make(map[types.Object]bool)
+	{
+		callPosn := make(map[*ast.Ident]bool) // all idents f appearing in f() calls
+		filter := []ast.Node{
+			(*ast.CallExpr)(nil),
+		}
+		inspect.Preorder(filter, func(n ast.Node) {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				fun := astutil.Unparen(n.Fun)
+				switch fun_ := fun.(type) {
+				case *ast.IndexExpr:
+					fun = fun_.X // f[T]()
+				case *ast.IndexListExpr:
+					fun = fun_.X // f[K, V]()
+				}
+				switch fun := fun.(type) {
+				case *ast.Ident:
+					callPosn[fun] = true
+				case *ast.SelectorExpr:
+					callPosn[fun.Sel] = true
+				}
+			}
+		})
+		for id, obj := range pass.TypesInfo.Uses {
+			if _, ok := obj.(*types.Func); ok && !callPosn[id] {
+				addressTaken[obj] = true
+			}
+		}
+	}
+
+	conform := conformance.NewChecker(pass.TypesInfo)
+
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.FuncDecl)
+			if !ok || decl.Body == nil || decl.Name.IsExported() {
+				continue
+			}
+			fn, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func)
+			if !ok || addressTaken[fn] {
+				continue
+			}
+			if decl.Recv != nil && conform.MayImplement(fn) {
+				continue
+			}
+
+			// Is the final result an error?
+			results := fn.Type().(*types.Signature).Results()
+			if results.Len() == 0 || !types.Identical(results.At(results.Len()-1).Type(), errorType) {
+				continue
+			}
+
+			if alwaysNil(pass.TypesInfo, decl.Body, results.Len()) {
+				field := decl.Type.Results.List[len(decl.Type.Results.List)-1]
+				pass.ReportRangef(field, "error result of %s is always nil", decl.Name.Name)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// alwaysNil reports whether body contains at least one return
+// statement and every return statement (excluding those of nested
+// function literals) explicitly supplies a nil error as its final
+// operand.
+func alwaysNil(info *types.Info, body *ast.BlockStmt, nresults int) bool {
+	var (
+		nreturns int
+		ok       = true
+	)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if !ok {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false // returns of a nested function are irrelevant
+		case *ast.ReturnStmt:
+			nreturns++
+			// A bare return, or "return f()" of a multi-valued
+			// call, does not make the error value evident.
+			if len(n.Results) != nresults {
+				ok = false
+				return false
+			}
+			last := n.Results[len(n.Results)-1]
+			if tv, found := info.Types[last]; !found || !tv.IsNil() {
+				ok = false
+			}
+		}
+		return true
+	})
+	return ok && nreturns > 0
+}
@@ -0,0 +1,13 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The alwaysnilerror command runs the alwaysnilerror analyzer.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+	"golang.org/x/tools/gopls/internal/analysis/alwaysnilerror"
+)
+
+func main() { singlechecker.Main(alwaysnilerror.Analyzer) }
@@ -0,0 +1,29 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package alwaysnilerror defines an analyzer that checks for error
+// results of functions that are always nil.
+//
+// # Analyzer alwaysnilerror
+//
+// alwaysnilerror: check for error results that are always nil
+//
+// The alwaysnilerror analyzer reports functions whose final result
+// is of type error but whose every return statement supplies a
+// literal nil for it. Such a result burdens every caller with
+// dead error handling.
+//
+// To ensure soundness, it ignores the same functions as the
+// unusedparams analyzer:
+//   - "address-taken" functions, that is, functions that are used as
+//     a value rather than being called directly; their signatures may
+//     be required to conform to a func type.
+//   - exported functions or methods, since they may be address-taken
+//     in another package.
+//   - methods whose receiver type implements an interface declared in
+//     the same package that contains a method of the same name, since
+//     the method's signature is then required to conform to it.
+//   - functions containing a bare "return" statement, or returning the
+//     results of another call, since the error value is not evident.
+package alwaysnilerror
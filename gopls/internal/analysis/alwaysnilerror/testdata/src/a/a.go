@@ -0,0 +1,86 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package a
+
+import (
+	"errors"
+	"fmt"
+)
+
+func f(x int) (int, error) { // want "error result of f is always nil"
+	if x > 0 {
+		return x, nil
+	}
+	return 0, nil
+}
+
+func g() error { // want "error result of g is always nil"
+	fmt.Println()
+	return nil
+}
+
+func h(x int) (int, error) { // no finding: a non-nil error is returned
+	if x > 0 {
+		return x, nil
+	}
+	return 0, errors.New("negative")
+}
+
+func bare() (err error) { // no finding: bare return
+	return
+}
+
+func forward() (int, error) { // no finding: results of another call
+	return f(1)
+}
+
+func nested() error { // want "error result of nested is always nil"
+	_ = func() error { return errors.New("nested") }
+	return nil
+}
+
+func Exported() error { return nil } // no finding: exported
+
+func addressTaken() error { return nil } // no finding: address-taken
+
+var _ = addressTaken
+
+type closer interface {
+	close() error
+}
+
+type T int
+
+func (T) close() error { return nil } // no finding: T implements closer
+
+func (T) open() error { return nil } // want "error result of open is always nil"
+
+// W's method flush is promoted to file, which, unlike W, implements
+// flusher.
+type W int
+
+func (*W) flush() error { return nil } // no finding: file implements flusher
+
+type file struct{ *W }
+
+func (file) name() string { return "" }
+
+type flusher interface {
+	flush() error
+	name() string
+}
+
+var _ flusher = file{}
+
+type U int
+
+func (U) close(x int) error { return nil } // want "error result of close is always nil"
+
+func _() {
+	f(1)
+	g()
+	h(1)
+	nested()
+}
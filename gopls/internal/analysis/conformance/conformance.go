@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package conformance reports whether the signature of a method may
+// be required to conform to a method of an interface, for analyzers
+// that must not suggest changes to the signatures of such methods.
+package conformance
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// A Checker reports whether methods of a package may be required to
+// conform to the interfaces declared in it.
+type Checker struct {
+	ifaces []*types.Interface // interfaces with methods declared in the package
+	types  []types.Type       // named and struct types declared in the package
+}
+
+// NewChecker returns a Checker for the package described by info.
+func NewChecker(info *types.Info) *Checker {
+	c := new(Checker)
+	for expr, tv := range info.Types {
+		switch expr.(type) {
+		case *ast.InterfaceType:
+			if t, ok := tv.Type.(*types.Interface); ok && t.NumMethods() > 0 {
+				c.ifaces = append(c.ifaces, t)
+			}
+		case *ast.StructType:
+			// An unnamed struct type, such as struct{T}, may
+			// satisfy an interface using a promoted method.
+			c.types = append(c.types, tv.Type)
+		}
+	}
+	for _, obj := range info.Defs {
+		if tname, ok := obj.(*types.TypeName); ok && !tname.IsAlias() {
+			if _, ok := tname.Type().Underlying().(*types.Interface); !ok {
+				c.types = append(c.types, tname.Type())
+			}
+		}
+	}
+	return c
+}
+
+// MayImplement reports whether method fn may be required to conform
+// to a method of an interface declared in the package: whether a type
+// of the package whose method set includes fn, either the receiver
+// type or a type that embeds it, satisfies an interface that has a
+// method of the same name.
+//
+// Only the interfaces of the package are considered, as an interface
+// of another package cannot require an unexported method.
+func (c *Checker) MayImplement(fn *types.Func) bool {
+	for _, iface := range c.ifaces {
+		if obj, _, _ := types.LookupFieldOrMethod(iface, false, fn.Pkg(), fn.Name()); obj == nil {
+			continue
+		}
+		for _, t := range c.types {
+			obj, _, _ := types.LookupFieldOrMethod(t, true, fn.Pkg(), fn.Name())
+			if m, ok := obj.(*types.Func); !ok || m.Origin() != fn.Origin() {
+				continue
+			}
+			// Implements is undefined for uninstantiated generic
+			// types, so conservatively treat a name match as
+			// conformance in that case.
+			if named, ok := t.(*types.Named); ok && named.TypeParams().Len() > named.TypeArgs().Len() {
+				return true
+			}
+			if types.Implements(t, iface) || types.Implements(types.NewPointer(t), iface) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package contextfield defines an analyzer that checks for
+// context.Context values stored in struct fields.
+//
+// # Analyzer contextfield
+//
+// contextfield: check for contexts stored in struct fields
+//
+// The contextfield analyzer reports fields of type context.Context in
+// the declarations of named struct types, for example:
+//
+//	type Server struct {
+//		ctx context.Context // "context.Context should not be stored in a struct field"
+//	}
+//
+// As the documentation of the context package explains, a Context
+// should not be stored inside a struct type, but passed explicitly to
+// each function or method that needs it, as its first parameter. A
+// stored context outlives the operation for which it was created,
+// which makes its cancellation and deadline hard to reason about.
+//
+// Fields of anonymous struct types, such as the cases of a table-driven
+// test, are not reported.
+package contextfield
@@ -0,0 +1,46 @@
+package a
+
+import (
+	"context"
+	ctx "context"
+)
+
+type Server struct {
+	name string
+	ctx  context.Context // want "context.Context should not be stored in a struct field"
+}
+
+type Job struct {
+	context.Context // want "context.Context should not be stored in a struct field"
+}
+
+type Renamed struct {
+	parent ctx.Context // want "context.Context should not be stored in a struct field"
+}
+
+type (
+	Grouped struct {
+		a, b context.Context // want "context.Context should not be stored in a struct field"
+	}
+	Pointer struct {
+		ctx *context.Context // ok: not a context
+	}
+	Func struct {
+		ctx func() context.Context // ok
+	}
+)
+
+type Generic[T any] struct {
+	ctx context.Context // want "context.Context should not be stored in a struct field"
+	t   T
+}
+
+var cases = []struct {
+	ctx context.Context // ok: anonymous struct
+}{}
+
+type Ctx = context.Context
+
+type Alias struct {
+	ctx Ctx // want "context.Context should not be stored in a struct field"
+}
@@ -0,0 +1,254 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package contextparam
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/gopls/internal/analysis/conformance"
+	"golang.org/x/tools/internal/aliases"
+	"golang.org/x/tools/internal/analysisinternal"
+)
+
+//go:embed doc.go
+var doc string
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "contextparam",
+	Doc:      analysisinternal.MustExtractDoc(doc, "contextparam"),
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+	URL:      "https://pkg.go.dev/golang.org/x/tools/gopls/internal/analysis/contextparam",
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	// Gather global information:
+	// - the calls of each function, and the functions used
+	//   other than in call position
+	//
+	// (See the unusedparams analyzer for a longer discussion of
+	// "address-taken" functions.)
+	calls := make(map[types.Object][]*ast.CallExpr)
+	addressTaken := make(map[types.Object]bool)
+	{
+		callPosn := make(map[*ast.Ident]bool) // all idents f appearing in f() calls
+		filter := []ast.Node{
+			(*ast.CallExpr)(nil),
+			(*ast.InterfaceType)(nil),
+		}
+		inspect.Preorder(filter, func(n ast.Node) {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				fun := astutil.Unparen(n.Fun)
+				switch fun_ := fun.(type) {
+				case *ast.IndexExpr:
+					fun = fun_.X // f[T]()
+				case *ast.IndexListExpr:
+					fun = fun_.X // f[K, V]()
+				}
+				var id *ast.Ident
+				switch fun := fun.(type) {
+				case *ast.Ident:
+					id = fun
+				case *ast.SelectorExpr:
+					id = fun.Sel
+				}
+				if id != nil {
+					callPosn[id] = true
+					if fn, ok := pass.TypesInfo.Uses[id].(*types.Func); ok {
+						calls[fn.Origin()] = append(calls[fn.Origin()], n)
+					}
+				}
+
+			case *ast.InterfaceType:
+				for _, method := range n.Methods.List {
+					if ftype, ok := method.Type.(*ast.FuncType); ok && len(method.Names) == 1 {
+						if _, field := contextParam(pass.TypesInfo, ftype.Params); field != nil {
+							pass.ReportRangef(field, "context.Context should be the first parameter of %s", method.Names[0].Name)
+						}
+					}
+				}
+			}
+		})
+		for id, obj := range pass.TypesInfo.Uses {
+			if fn, ok := obj.(*types.Func); ok && !callPosn[id] {
+				addressTaken[fn.Origin()] = true
+			}
+		}
+	}
+
+	conform := conformance.NewChecker(pass.TypesInfo)
+
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			fn, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			if decl.Recv != nil && conform.MayImplement(fn) {
+				continue // reported at the interface
+			}
+			index, field := contextParam(pass.TypesInfo, decl.Type.Params)
+			if field == nil {
+				continue
+			}
+			diag := analysis.Diagnostic{
+				Pos:     field.Pos(),
+				End:     field.End(),
+				Message: fmt.Sprintf("context.Context should be the first parameter of %s", decl.Name.Name),
+			}
+			if !decl.Name.IsExported() && !addressTaken[fn] && len(field.Names) < 2 {
+				if edits, ok := moveToFront(pass, decl.Type.Params, field, index, calls[fn]); ok {
+					diag.SuggestedFixes = []analysis.SuggestedFix{{
+						Message:   "Move context parameter to the front",
+						TextEdits: edits,
+					}}
+				}
+			}
+			pass.Report(diag)
+		}
+	}
+	return nil, nil
+}
+
+// contextParam returns the first parameter of type context.Context
+// in the list, as its index and the field that declares it, if it is
+// preceded by parameters other than *testing.T and the like.
+func contextParam(info *types.Info, params *ast.FieldList) (int, *ast.Field) {
+	index := 0      // index of the first parameter of field
+	leading := true // all preceding parameters are testing ones
+	for _, field := range params.List {
+		t := info.TypeOf(field.Type)
+		if isContext(t) {
+			if leading {
+				return 0, nil
+			}
+			return index, field
+		}
+		leading = leading && isTesting(t)
+		index += numParams(field)
+	}
+	return 0, nil
+}
+
+// numParams returns the number of parameters declared by field.
+func numParams(field *ast.Field) int {
+	if len(field.Names) == 0 {
+		return 1
+	}
+	return len(field.Names)
+}
+
+// moveToFront returns the edits that move the specified parameter to
+// the front of the list, and the corresponding argument to the front
+// of each call. It reports false if a call passes the context as an
+// expression other than a variable or field, or does not pass
+// arguments for each parameter.
+func moveToFront(pass *analysis.Pass, params *ast.FieldList, field *ast.Field, index int, calls []*ast.CallExpr) ([]analysis.TextEdit, bool) {
+	var buf bytes.Buffer
+	var names []string
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(&buf, "%s ", strings.Join(names, ", "))
+	}
+	if err := printer.Fprint(&buf, pass.Fset, field.Type); err != nil {
+		return nil, false
+	}
+	buf.WriteString(", ")
+
+	prev := params.List[0]
+	for _, f := range params.List {
+		if f == field {
+			break
+		}
+		prev = f
+	}
+	edits := []analysis.TextEdit{
+		{Pos: params.List[0].Pos(), End: params.List[0].Pos(), NewText: []byte(buf.String())},
+		{Pos: prev.End(), End: field.End()},
+	}
+
+	nparams := 0
+	for _, f := range params.List {
+		nparams += numParams(f)
+	}
+	for _, call := range calls {
+		if len(call.Args) != nparams || !isVarOrField(call.Args[index]) {
+			return nil, false
+		}
+		buf.Reset()
+		if err := printer.Fprint(&buf, pass.Fset, call.Args[index]); err != nil {
+			return nil, false
+		}
+		buf.WriteString(", ")
+		edits = append(edits,
+			analysis.TextEdit{Pos: call.Args[0].Pos(), End: call.Args[0].Pos(), NewText: []byte(buf.String())},
+			analysis.TextEdit{Pos: call.Args[index-1].End(), End: call.Args[index].End()})
+	}
+	return edits, true
+}
+
+// isVarOrField reports whether e is an identifier or a selection of a
+// field or package member, whose evaluation has no side effects.
+func isVarOrField(e ast.Expr) bool {
+	switch e := astutil.Unparen(e).(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		return isVarOrField(e.X)
+	}
+	return false
+}
+
+// isContext reports whether t is context.Context.
+func isContext(t types.Type) bool {
+	return isNamed(t, "context", "Context")
+}
+
+// isTesting reports whether t is *testing.T, *testing.B, *testing.F,
+// or testing.TB.
+func isTesting(t types.Type) bool {
+	if ptr, ok := aliases.Unalias(t).(*types.Pointer); ok {
+		return isNamed(ptr.Elem(), "testing", "T", "B", "F")
+	}
+	return isNamed(t, "testing", "TB")
+}
+
+// isNamed reports whether t is a named type of the specified package
+// with one of the given names.
+func isNamed(t types.Type, pkgPath string, names ...string) bool {
+	named, ok := aliases.Unalias(t).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != pkgPath {
+		return false
+	}
+	for _, name := range names {
+		if obj.Name() == name {
+			return true
+		}
+	}
+	return false
+}
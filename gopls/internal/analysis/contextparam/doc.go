@@ -0,0 +1,34 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package contextparam defines an analyzer that checks for
+// context.Context parameters that are not the first parameter.
+//
+// # Analyzer contextparam
+//
+// contextparam: check that a context is the first parameter
+//
+// The contextparam analyzer reports functions, methods, and interface
+// methods that have a parameter of type context.Context other than
+// the first, for example:
+//
+//	func fetch(url string, ctx context.Context) error
+//
+// By convention, a Context is the first parameter of each function
+// that needs it, conventionally named ctx. A parameter of type
+// *testing.T, *testing.B, *testing.F, or testing.TB may precede it.
+//
+// Methods whose receiver type implements an interface of the same
+// package that has a method of the same name are not reported, since
+// the interface determines their signature; the interface method is
+// reported instead.
+//
+// The analyzer suggests a fix that moves the parameter to the front,
+// along with the corresponding argument of each call. It is offered
+// only for unexported functions whose every use is a direct call that
+// passes the context as a variable or field, so that all calls can be
+// updated without changing the order of evaluation. The fix updates
+// only the calls in the package being analyzed, which may exclude
+// calls in its test files.
+package contextparam
@@ -0,0 +1,56 @@
+package a
+
+import (
+	"context"
+	"testing"
+)
+
+func ok(ctx context.Context, url string) {}
+
+func fetch(url string, ctx context.Context) error { // want "context.Context should be the first parameter of fetch"
+	return nil
+}
+
+func Fetch(url string, ctx context.Context) {} // want "context.Context should be the first parameter of Fetch"
+
+func grouped(a, b int, ctx context.Context, c int) {} // want "context.Context should be the first parameter of grouped"
+
+func unnamed(int, context.Context) {} // want "context.Context should be the first parameter of unnamed"
+
+func helper(t *testing.T, ctx context.Context) {}
+
+func helperTB(tb testing.TB, ctx context.Context, name string) {}
+
+func valued(url string, ctx context.Context) {} // want "context.Context should be the first parameter of valued"
+
+var _ = valued
+
+func computed(url string, ctx context.Context) {} // want "context.Context should be the first parameter of computed"
+
+type server struct{ ctx context.Context }
+
+func (s *server) handle(name string, ctx context.Context) {} // want "context.Context should be the first parameter of handle"
+
+type Handler interface {
+	Serve(name string, ctx context.Context) // want "context.Context should be the first parameter of Serve"
+}
+
+type impl struct{}
+
+func (impl) Serve(name string, ctx context.Context) {}
+
+func generic[T any](x T, ctx context.Context) {} // want "context.Context should be the first parameter of generic"
+
+func _(ctx context.Context, s *server) {
+	_ = fetch("a", ctx)
+	_ = fetch(
+		"b",
+		ctx,
+	)
+	grouped(1, 2, ctx, 3)
+	unnamed(1, ctx)
+	computed("c", context.Background())
+	s.handle("d", s.ctx)
+	generic[int](1, ctx)
+	generic("e", (ctx))
+}
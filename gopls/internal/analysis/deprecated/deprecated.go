@@ -6,10 +6,13 @@ package deprecated
 
 import (
 	"bytes"
+	"fmt"
 	"go/ast"
 	"go/format"
 	"go/token"
 	"go/types"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -43,7 +46,7 @@ func checkDeprecated(pass *analysis.Pass) (interface{}, error) {
 		return nil, err
 	}
 
-	reportDeprecation := func(depr *deprecationFact, node ast.Node) {
+	reportDeprecation := func(depr *deprecationFact, node ast.Node, obj types.Object) {
 		// TODO(hyangah): staticcheck.CheckDeprecated has more complex logic. Do we need it here?
 		// TODO(hyangah): Scrub depr.Msg. depr.Msg may contain Go comments
 		// markdown syntaxes but LSP diagnostics do not support markdown syntax.
@@ -54,7 +57,18 @@ func checkDeprecated(pass *analysis.Pass) (interface{}, error) {
 			buf.Reset()
 			buf.WriteString("declaration")
 		}
-		pass.ReportRangef(node, "%s is deprecated: %s", buf, depr.Msg)
+		diag := analysis.Diagnostic{
+			Pos:     node.Pos(),
+			End:     node.End(),
+			Message: fmt.Sprintf("%s is deprecated: %s", buf, depr.Msg),
+			Tags:    []analysis.DiagnosticTag{analysis.TagDeprecated},
+		}
+		if sel, ok := node.(*ast.SelectorExpr); ok && obj != nil && depr.ReplName != "" {
+			if fix, ok := replacementFix(pass, sel, obj, buf.String(), depr); ok {
+				diag.SuggestedFixes = []analysis.SuggestedFix{fix}
+			}
+		}
+		pass.Report(diag)
 	}
 
 	nodeFilter := []ast.Node{(*ast.SelectorExpr)(nil)}
@@ -97,7 +111,7 @@ func checkDeprecated(pass *analysis.Pass) (interface{}, error) {
 		}
 
 		if depr, ok := deprs.objects[obj]; ok {
-			reportDeprecation(depr, sel)
+			reportDeprecation(depr, sel, obj)
 		}
 	})
 
@@ -134,14 +148,23 @@ func checkDeprecated(pass *analysis.Pass) (interface{}, error) {
 				continue
 			}
 			if depr, ok := deprs.packages[imp]; ok {
-				reportDeprecation(depr, spec.Path)
+				reportDeprecation(depr, spec.Path, nil)
 			}
 		}
 	}
 	return nil, nil
 }
 
-type deprecationFact struct{ Msg string }
+type deprecationFact struct {
+	Msg string
+
+	// ReplPkg and ReplName identify the replacement named by Msg in
+	// the form "Use Y instead", if any. ReplName is the name of a
+	// package-level object of the package whose path is ReplPkg or, if
+	// the deprecated object is a field or method, the name of a field
+	// or method of the same type.
+	ReplPkg, ReplName string
+}
 
 func (*deprecationFact) AFact()           {}
 func (d *deprecationFact) String() string { return "Deprecated: " + d.Msg }
@@ -173,6 +196,7 @@ func collectDeprecatedNames(pass *analysis.Pass, ins *inspector.Inspector) (depr
 		return ""
 	}
 
+	var file *ast.File // the file being inspected, in which to resolve replacements
 	doDocs := func(names []*ast.Ident, docs *ast.CommentGroup) {
 		alt := extractDeprecatedMessage([]*ast.CommentGroup{docs})
 		if alt == "" {
@@ -181,7 +205,9 @@ func collectDeprecatedNames(pass *analysis.Pass, ins *inspector.Inspector) (depr
 
 		for _, name := range names {
 			obj := pass.TypesInfo.ObjectOf(name)
-			pass.ExportObjectFact(obj, &deprecationFact{alt})
+			fact := &deprecationFact{Msg: alt}
+			fact.ReplPkg, fact.ReplName = replacement(pass, file, obj, alt)
+			pass.ExportObjectFact(obj, fact)
 		}
 	}
 
@@ -195,7 +221,7 @@ func collectDeprecatedNames(pass *analysis.Pass, ins *inspector.Inspector) (depr
 		// constants like SIGKILL, and I am not comfortable
 		// telling them to use x/sys for that.
 		if pass.Pkg.Path() != "syscall" {
-			pass.ExportPackageFact(&deprecationFact{alt})
+			pass.ExportPackageFact(&deprecationFact{Msg: alt})
 		}
 	}
 	nodeFilter := []ast.Node{
@@ -211,6 +237,8 @@ func collectDeprecatedNames(pass *analysis.Pass, ins *inspector.Inspector) (depr
 		var names []*ast.Ident
 		var docs *ast.CommentGroup
 		switch node := node.(type) {
+		case *ast.File:
+			file = node
 		case *ast.GenDecl:
 			switch node.Tok {
 			case token.TYPE, token.CONST, token.VAR:
@@ -265,3 +293,170 @@ func collectDeprecatedNames(pass *analysis.Pass, ins *inspector.Inspector) (depr
 
 	return out, nil
 }
+
+// useInstead matches a deprecation message that names a replacement Y
+// in the form "Use Y instead", where Y is N, P.N, or T.N, possibly
+// written as a doc link.
+var useInstead = regexp.MustCompile(`(?i)\buse\s+\[?([\pL_][\pL\pN_]*(?:\.[\pL_][\pL\pN_]*)?)\]?(?:\(\))?\s+instead\b`)
+
+// replacement returns the package path and name of the replacement for
+// the deprecated object obj that is named by its deprecation message,
+// resolving package names in the given file. It returns "" if the
+// message names no replacement that can be resolved.
+func replacement(pass *analysis.Pass, file *ast.File, obj types.Object, msg string) (pkgPath, name string) {
+	m := useInstead.FindStringSubmatch(msg)
+	if m == nil {
+		return "", ""
+	}
+	qual, name, ok := strings.Cut(m[1], ".")
+	if !ok {
+		return pass.Pkg.Path(), qual // N
+	}
+	if isMember(obj) {
+		// T.N names a field or method N of the same type.
+		if _, ok := pass.Pkg.Scope().Lookup(qual).(*types.TypeName); ok {
+			return pass.Pkg.Path(), name
+		}
+		return "", ""
+	}
+	// P.N names a member of a package imported as P.
+	if file != nil {
+		if pkgName, ok := pass.TypesInfo.Scopes[file].Lookup(qual).(*types.PkgName); ok {
+			return pkgName.Imported().Path(), name
+		}
+	}
+	return "", ""
+}
+
+// isMember reports whether obj is a field or method.
+func isMember(obj types.Object) bool {
+	switch obj := obj.(type) {
+	case *types.Func:
+		return obj.Type().(*types.Signature).Recv() != nil
+	case *types.Var:
+		return obj.IsField()
+	}
+	return false
+}
+
+// replacementFix returns a fix that replaces sel, a reference to the
+// deprecated object obj formatted as old, by a reference to the
+// replacement recorded in depr. If the replacement is a function of
+// the same signature, the fix is a plain rewrite. Otherwise it only
+// renames the reference, and adds a TODO comment for the rest.
+func replacementFix(pass *analysis.Pass, sel *ast.SelectorExpr, obj types.Object, old string, depr *deprecationFact) (analysis.SuggestedFix, bool) {
+	var file *ast.File
+	for _, f := range pass.Files {
+		if pass.Fset.File(f.Pos()) == pass.Fset.File(sel.Pos()) {
+			file = f
+			break
+		}
+	}
+	if file == nil {
+		return analysis.SuggestedFix{}, false
+	}
+
+	var (
+		repl  types.Object // the replacement, if it can be found
+		edits []analysis.TextEdit
+		text  string // the new reference
+	)
+	renameSel := func() {
+		text = types.ExprString(sel.X) + "." + depr.ReplName
+		edits = append(edits, analysis.TextEdit{Pos: sel.Sel.Pos(), End: sel.Sel.End(), NewText: []byte(depr.ReplName)})
+	}
+	if isMember(obj) {
+		selection, ok := pass.TypesInfo.Selections[sel]
+		if !ok || depr.ReplPkg != obj.Pkg().Path() {
+			return analysis.SuggestedFix{}, false
+		}
+		repl, _, _ = types.LookupFieldOrMethod(selection.Recv(), false, obj.Pkg(), depr.ReplName)
+		if repl == nil {
+			return analysis.SuggestedFix{}, false
+		}
+		renameSel()
+	} else {
+		// The replacement package, if the reference has it in its dependencies.
+		var pkg *types.Package
+		for _, p := range append([]*types.Package{obj.Pkg()}, append(obj.Pkg().Imports(), pass.Pkg.Imports()...)...) {
+			if p.Path() == depr.ReplPkg {
+				pkg = p
+				break
+			}
+		}
+		pkgName := path.Base(depr.ReplPkg)
+		if pkg != nil {
+			if repl = pkg.Scope().Lookup(depr.ReplName); repl == nil {
+				return analysis.SuggestedFix{}, false
+			}
+			pkgName = pkg.Name()
+		}
+		if depr.ReplPkg == obj.Pkg().Path() {
+			renameSel()
+		} else {
+			id, ok := sel.X.(*ast.Ident)
+			if !ok {
+				return analysis.SuggestedFix{}, false
+			}
+			if _, ok := pass.TypesInfo.Uses[id].(*types.PkgName); !ok {
+				return analysis.SuggestedFix{}, false
+			}
+			name, importEdit := analysisinternal.AddImport(pass.TypesInfo, file, sel.Pos(), depr.ReplPkg, pkgName)
+			text = name + "." + depr.ReplName
+			edits = append(edits, analysis.TextEdit{Pos: sel.Pos(), End: sel.End(), NewText: []byte(text)})
+			if importEdit.NewText != nil {
+				edits = append(edits, importEdit)
+			}
+		}
+	}
+	if repl != nil && !repl.Exported() && repl.Pkg() != pass.Pkg {
+		return analysis.SuggestedFix{}, false // inaccessible
+	}
+
+	if repl != nil && sameSignature(obj, repl) {
+		return analysis.SuggestedFix{
+			Message:   fmt.Sprintf("Replace %s with %s", old, text),
+			TextEdits: edits,
+		}, true
+	}
+
+	// Insert the TODO comment above the line of the reference,
+	// with the same indentation.
+	tf := pass.Fset.File(sel.Pos())
+	lineStart := tf.LineStart(tf.Line(sel.Pos()))
+	indent := ""
+	if pass.ReadFile != nil {
+		if content, err := pass.ReadFile(tf.Name()); err == nil {
+			line := content[tf.Offset(lineStart):]
+			indent = string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+		}
+	}
+	edits = append(edits, analysis.TextEdit{
+		Pos:     lineStart,
+		End:     lineStart,
+		NewText: []byte(fmt.Sprintf("%s// TODO: check this use of %s, which replaces deprecated %s.\n", indent, text, old)),
+	})
+	return analysis.SuggestedFix{
+		Message:   fmt.Sprintf("Replace %s with %s and add a TODO comment", old, text),
+		TextEdits: edits,
+	}, true
+}
+
+// sameSignature reports whether old and new are functions or methods
+// of identical signatures, ignoring receivers, so that a reference to
+// one may be replaced by a reference to the other.
+func sameSignature(old, new types.Object) bool {
+	oldFn, ok1 := old.(*types.Func)
+	newFn, ok2 := new.(*types.Func)
+	if !ok1 || !ok2 {
+		return false
+	}
+	sig := func(fn *types.Func) *types.Signature {
+		sig := fn.Type().(*types.Signature)
+		if sig.Recv() != nil {
+			sig = types.NewSignatureType(nil, nil, nil, sig.Params(), sig.Results(), sig.Variadic())
+		}
+		return sig
+	}
+	return types.Identical(sig(oldFn), sig(newFn))
+}
@@ -13,4 +13,5 @@ import (
 func Test(t *testing.T) {
 	testdata := analysistest.TestData()
 	analysistest.Run(t, testdata, Analyzer, "a")
+	analysistest.RunWithSuggestedFixes(t, testdata, Analyzer, "b")
 }
@@ -11,6 +11,12 @@
 // The deprecated analyzer looks for deprecated symbols and package
 // imports.
 //
+// When the deprecation notice of a symbol names its replacement in the
+// form "Use Y instead", the analyzer suggests a fix that replaces the
+// reference by one to Y, adding an import if needed. If Y is not a
+// function of the same signature, the fix also adds a TODO comment to
+// check the rewritten use.
+//
 // See https://go.dev/wiki/Deprecated to learn about Go's convention
 // for documenting and signaling deprecated identifiers.
 package deprecated
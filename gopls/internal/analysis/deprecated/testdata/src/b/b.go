@@ -0,0 +1,15 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package b
+
+import "lib"
+
+func _(t lib.T) {
+	_ = lib.Old("x")       // want "lib.Old is deprecated: Use New instead."
+	_ = lib.Upper("x")     // want "lib.Upper is deprecated: .*"
+	_ = lib.Join("x", "y") // want "lib.Join is deprecated: .*"
+	_ = t.Start(1)         // want "t.Start is deprecated: .*"
+	lib.Missing()          // want "lib.Missing is deprecated: .*"
+}
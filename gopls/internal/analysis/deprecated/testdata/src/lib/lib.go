@@ -0,0 +1,43 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lib
+
+import "strings"
+
+// Old returns s.
+//
+// Deprecated: Use New instead.
+func Old(s string) string { return s }
+
+// New returns s.
+func New(s string) string { return s }
+
+// Upper returns s in upper case.
+//
+// Deprecated: Use [strings.ToUpper] instead.
+func Upper(s string) string { return strings.ToUpper(s) }
+
+// Join joins a and b.
+//
+// Deprecated: Use JoinAll instead.
+func Join(a, b string) string { return a + b }
+
+// JoinAll joins its arguments.
+func JoinAll(elems ...string) string { return strings.Join(elems, "") }
+
+type T struct{}
+
+// Start starts n things.
+//
+// Deprecated: Use T.Run instead.
+func (T) Start(n int) error { return nil }
+
+// Run runs n things.
+func (T) Run(n int) error { return nil }
+
+// Missing has a replacement that does not exist.
+//
+// Deprecated: Use Absent instead.
+func Missing() {}
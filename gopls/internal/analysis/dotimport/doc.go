@@ -0,0 +1,24 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dotimport defines an analyzer that reports dot-imports.
+//
+// # Analyzer dotimport
+//
+// dotimport: report dot-imports
+//
+// The dotimport analyzer reports imports of the form
+//
+//	import . "example.com/pkg"
+//
+// which make the exported declarations of a package available in the
+// importing file without qualification. Dot-imports make it hard to
+// tell where an identifier is declared, and an identifier added to
+// the imported package may conflict with a declaration of the
+// importing package.
+//
+// The analyzer suggests a fix that removes the dot and qualifies each
+// identifier of the file that refers to the imported package, unless
+// the name of the package is already in use in the file.
+package dotimport
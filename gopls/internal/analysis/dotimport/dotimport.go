@@ -0,0 +1,164 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dotimport
+
+import (
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/internal/analysisinternal"
+)
+
+//go:embed doc.go
+var doc string
+
+var Analyzer = &analysis.Analyzer{
+	Name: "dotimport",
+	Doc:  analysisinternal.MustExtractDoc(doc, "dotimport"),
+	Run:  run,
+	URL:  "https://pkg.go.dev/golang.org/x/tools/gopls/internal/analysis/dotimport",
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	for _, file := range pass.Files {
+		for _, spec := range file.Imports {
+			if spec.Name == nil || spec.Name.Name != "." {
+				continue
+			}
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			diag := analysis.Diagnostic{
+				Pos:     spec.Pos(),
+				End:     spec.End(),
+				Message: fmt.Sprintf("should not use dot-import of %q", path),
+			}
+			if edits, err := Qualify(file, pass.Pkg, pass.TypesInfo, spec); err == nil {
+				diag.SuggestedFixes = []analysis.SuggestedFix{{
+					Message:   fmt.Sprintf("Qualify identifiers imported from %q", path),
+					TextEdits: edits,
+				}}
+			}
+			pass.Report(diag)
+		}
+	}
+	return nil, nil
+}
+
+// Qualify returns the edits that convert the dot-import spec of file
+// to an ordinary import, qualifying each identifier of the file that
+// refers to a package-level declaration of the imported package by
+// the name of the package. It fails if that name is already in use.
+func Qualify(file *ast.File, pkg *types.Package, info *types.Info, spec *ast.ImportSpec) ([]analysis.TextEdit, error) {
+	pkgName := importedPkgName(info, spec)
+	if pkgName == nil || spec.Name == nil || spec.Name.Name != "." {
+		return nil, fmt.Errorf("not a dot-import")
+	}
+	imported := pkgName.Imported()
+	name := imported.Name()
+	fileScope := info.Scopes[file]
+	if fileScope == nil {
+		return nil, fmt.Errorf("no scope for file")
+	}
+	if fileScope.Lookup(name) != nil || pkg.Scope().Lookup(name) != nil {
+		return nil, fmt.Errorf("the name %s is already in use", name)
+	}
+	edits := []analysis.TextEdit{{Pos: spec.Name.Pos(), End: spec.Path.Pos()}}
+	var err error
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			// Don't visit the selector of a qualified identifier.
+			if x, ok := n.X.(*ast.Ident); ok {
+				if _, ok := info.Uses[x].(*types.PkgName); ok {
+					return false
+				}
+			}
+		case *ast.Ident:
+			obj := info.Uses[n]
+			if obj == nil || obj.Pkg() != imported || obj.Parent() != imported.Scope() {
+				return true
+			}
+			if _, shadow := fileScope.Innermost(n.Pos()).LookupParent(name, n.Pos()); shadow != nil && err == nil {
+				err = fmt.Errorf("the name %s is already in use at %s", name, n.Name)
+			}
+			edits = append(edits, analysis.TextEdit{Pos: n.Pos(), End: n.Pos(), NewText: []byte(name + ".")})
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+// Dot returns the edits that convert the import spec of file to a
+// dot-import, removing the qualifier of each reference to the
+// imported package in the file. It fails if an exported name of the
+// imported package is declared by the importing package, or by another
+// dot-import of file, or if a reference would denote another
+// declaration without its qualifier.
+func Dot(file *ast.File, pkg *types.Package, info *types.Info, spec *ast.ImportSpec) ([]analysis.TextEdit, error) {
+	pkgName := importedPkgName(info, spec)
+	if pkgName == nil || spec.Name != nil && (spec.Name.Name == "." || spec.Name.Name == "_") {
+		return nil, fmt.Errorf("not an ordinary import")
+	}
+	imported := pkgName.Imported()
+	fileScope := info.Scopes[file]
+	if fileScope == nil {
+		return nil, fmt.Errorf("no scope for file")
+	}
+	for _, name := range imported.Scope().Names() {
+		if token.IsExported(name) && (pkg.Scope().Lookup(name) != nil || fileScope.Lookup(name) != nil) {
+			return nil, fmt.Errorf("%s is also declared in package %s", name, pkg.Name())
+		}
+	}
+
+	var edits []analysis.TextEdit
+	if spec.Name != nil {
+		edits = append(edits, analysis.TextEdit{Pos: spec.Name.Pos(), End: spec.Name.End(), NewText: []byte(".")})
+	} else {
+		edits = append(edits, analysis.TextEdit{Pos: spec.Path.Pos(), End: spec.Path.Pos(), NewText: []byte(". ")})
+	}
+	var err error
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return err == nil
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok || info.Uses[x] != pkgName {
+			return true
+		}
+		if _, obj := fileScope.Innermost(sel.Pos()).LookupParent(sel.Sel.Name, sel.Pos()); obj != nil && err == nil {
+			err = fmt.Errorf("%s would refer to another declaration", sel.Sel.Name)
+		}
+		edits = append(edits, analysis.TextEdit{Pos: x.Pos(), End: sel.Sel.Pos()})
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+// importedPkgName returns the package name declared by an import
+// spec, or nil if it is not known.
+func importedPkgName(info *types.Info, spec *ast.ImportSpec) *types.PkgName {
+	var obj types.Object
+	if spec.Name != nil {
+		obj = info.Defs[spec.Name]
+	} else {
+		obj = info.Implicits[spec]
+	}
+	pkgName, _ := obj.(*types.PkgName)
+	return pkgName
+}
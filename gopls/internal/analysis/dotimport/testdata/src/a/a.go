@@ -0,0 +1,12 @@
+package a
+
+import (
+	"fmt"
+	. "strings" // want `should not use dot-import of "strings"`
+)
+
+func _(s string) string {
+	var b Builder
+	b.WriteString(ToUpper(s))
+	return fmt.Sprint(b.Len(), Repeat(s, 2))
+}
@@ -0,0 +1,8 @@
+package a
+
+import . "unicode" // want `should not use dot-import of "unicode"`
+
+// No fix is suggested: the name unicode is in use at IsUpper.
+func _(unicode rune) bool {
+	return IsUpper(unicode)
+}
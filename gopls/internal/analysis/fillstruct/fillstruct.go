@@ -124,94 +124,121 @@ func Diagnose(f *ast.File, start, end token.Pos, pkg *types.Package, info *types
 	return diags
 }
 
-const FixCategory = "fillstruct" // recognized by gopls ApplyFix
-
-// SuggestedFix computes the suggested fix for the kinds of
-// diagnostics produced by the Analyzer above.
-func SuggestedFix(fset *token.FileSet, start, end token.Pos, content []byte, file *ast.File, pkg *types.Package, info *types.Info) (*token.FileSet, *analysis.SuggestedFix, error) {
-	if info == nil {
-		return nil, nil, fmt.Errorf("nil types.Info")
-	}
+// Categories of the fill struct fixes, recognized by gopls ApplyFix.
+const (
+	FixCategory         = "fillstruct"          // fill the accessible fields
+	FixCategoryExported = "fillstruct_exported" // fill only the exported fields
+	FixCategoryInScope  = "fillstruct_inscope"  // fill with variables in scope
+)
 
-	pos := start // don't use the end
+// Options configures the struct literal computed by [Fill].
+type Options struct {
+	// ExportedOnly restricts the fields filled to exported ones.
+	ExportedOnly bool
+
+	// InScope causes a field to be filled with a variable in scope of
+	// a matching type, even if its name is unlike that of the field.
+	// By default, only variables with similar names are used.
+	InScope bool
+
+	// Depth is the depth of nesting up to which the fields of struct
+	// literals are filled. At depth 1 (or 0), a field of struct type
+	// is filled with an empty literal such as T{}; at depth 2, the
+	// fields of that literal are filled too, and so on.
+	Depth int
+}
 
-	// TODO(rstambler): Using ast.Inspect would probably be more efficient than
-	// calling PathEnclosingInterval. Switch this approach.
-	path, _ := astutil.PathEnclosingInterval(file, pos, pos)
-	if len(path) == 0 {
-		return nil, nil, fmt.Errorf("no enclosing ast.Node")
+// Variants returns a diagnostic for each variant of the fix of diag,
+// a diagnostic returned by [Diagnose], that would fill the struct
+// literal differently from the default: one that fills only the
+// exported fields, if some of the fields to fill are unexported; and
+// one that fills fields with variables in scope, if some field has a
+// matching variable that would not otherwise be used.
+func Variants(file *ast.File, diag analysis.Diagnostic, pkg *types.Package, info *types.Info) []analysis.Diagnostic {
+	expr, tStruct, err := structLiteral(file, diag.Pos, pkg, info)
+	if err != nil || len(diag.SuggestedFixes) == 0 {
+		return nil
 	}
-	var expr *ast.CompositeLit
-	for _, n := range path {
-		if node, ok := n.(*ast.CompositeLit); ok {
-			expr = node
-			break
+	name := strings.TrimPrefix(diag.SuggestedFixes[0].Message, "Fill ")
+	prefilled := prefilledFields(expr)
+	fieldTyps := fieldTypes(tStruct, pkg)
+	matches := analysisinternal.MatchingIdents(fieldTyps, file, diag.Pos, info, pkg)
+
+	var exported, unexported, inScope bool
+	for i, fieldTyp := range fieldTyps {
+		field := tStruct.Field(i)
+		if _, ok := prefilled[field.Name()]; ok || fieldTyp == nil {
+			continue
+		}
+		if field.Exported() {
+			exported = true
+		} else {
+			unexported = true
+		}
+		if names := matches[fieldTyp]; len(names) > 0 && fuzzy.BestMatch(field.Name(), names) == "" {
+			inScope = true
 		}
 	}
 
-	typ := info.TypeOf(expr)
-	if typ == nil {
-		return nil, nil, fmt.Errorf("no composite literal")
+	var variants []analysis.Diagnostic
+	variant := func(category, title string) {
+		variants = append(variants, analysis.Diagnostic{
+			Message:  diag.Message,
+			Pos:      diag.Pos,
+			End:      diag.End,
+			Category: category,
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message: title,
+				// No TextEdits => computed later by gopls.
+			}},
+		})
 	}
-
-	// Find reference to the type declaration of the struct being initialized.
-	typ = typeparams.Deref(typ)
-	tStruct, ok := typ.Underlying().(*types.Struct)
-	if !ok {
-		return nil, nil, fmt.Errorf("%s is not a (pointer to) struct type",
-			types.TypeString(typ, types.RelativeTo(pkg)))
+	if exported && unexported {
+		variant(FixCategoryExported, fmt.Sprintf("Fill exported fields of %s", name))
 	}
-	// Inv: typ is the possibly-named struct type.
+	if inScope {
+		variant(FixCategoryInScope, fmt.Sprintf("Fill %s with variables in scope", name))
+	}
+	return variants
+}
 
-	fieldCount := tStruct.NumFields()
+// SuggestedFix computes the suggested fix for the kinds of
+// diagnostics produced by the Analyzer above.
+func SuggestedFix(fset *token.FileSet, start, end token.Pos, content []byte, file *ast.File, pkg *types.Package, info *types.Info) (*token.FileSet, *analysis.SuggestedFix, error) {
+	return Fill(fset, start, content, file, pkg, info, Options{})
+}
+
+// Fill computes the fix that fills the struct literal at start, as
+// configured by opts.
+func Fill(fset *token.FileSet, start token.Pos, content []byte, file *ast.File, pkg *types.Package, info *types.Info, opts Options) (*token.FileSet, *analysis.SuggestedFix, error) {
+	if info == nil {
+		return nil, nil, fmt.Errorf("nil types.Info")
+	}
+	expr, tStruct, err := structLiteral(file, start, pkg, info)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// Check which types have already been filled in. (we only want to fill in
 	// the unfilled types, or else we'll blat user-supplied details)
-	prefilledFields := map[string]ast.Expr{}
-	for _, e := range expr.Elts {
-		if kv, ok := e.(*ast.KeyValueExpr); ok {
-			if key, ok := kv.Key.(*ast.Ident); ok {
-				prefilledFields[key.Name] = kv.Value
-			}
-		}
-	}
+	prefilled := prefilledFields(expr)
 
-	// Use a new fileset to build up a token.File for the new composite
-	// literal. We need one line for foo{, one line for }, and one line for
-	// each field we're going to set. format.Node only cares about line
-	// numbers, so we don't need to set columns, and each line can be
-	// 1 byte long.
-	// TODO(adonovan): why is this necessary? The position information
-	// is going to be wrong for the existing trees in prefilledFields.
-	// Can't the formatter just do its best with an empty fileset?
-	fakeFset := token.NewFileSet()
-	tok := fakeFset.AddFile("", -1, fieldCount+2)
-
-	line := 2 // account for 1-based lines and the left brace
-	var fieldTyps []types.Type
-	for i := 0; i < fieldCount; i++ {
-		field := tStruct.Field(i)
-		// Ignore fields that are not accessible in the current package.
-		if field.Pkg() != nil && field.Pkg() != pkg && !field.Exported() {
-			fieldTyps = append(fieldTyps, nil)
-			continue
-		}
-		fieldTyps = append(fieldTyps, field.Type())
-	}
+	fieldTyps := fieldTypes(tStruct, pkg)
 	matches := analysisinternal.MatchingIdents(fieldTyps, file, start, info, pkg)
+	f := &filler{file: file, pkg: pkg, opts: opts}
+	lbrace := f.newLine()
 	var elts []ast.Expr
 	for i, fieldTyp := range fieldTyps {
 		if fieldTyp == nil {
 			continue // TODO(adonovan): is this reachable?
 		}
 		fieldName := tStruct.Field(i).Name()
-
-		tok.AddLine(line - 1) // add 1 byte per line
-		if line > tok.LineCount() {
-			panic(fmt.Sprintf("invalid line number %v (of %v) for fillstruct", line, tok.LineCount()))
+		value, ok := prefilled[fieldName]
+		if !ok && opts.ExportedOnly && !tStruct.Field(i).Exported() {
+			continue
 		}
-		pos := tok.LineStart(line)
 
+		pos := f.newLine()
 		kv := &ast.KeyValueExpr{
 			Key: &ast.Ident{
 				NamePos: pos,
@@ -219,8 +246,8 @@ func SuggestedFix(fset *token.FileSet, start, end token.Pos, content []byte, fil
 			},
 			Colon: pos,
 		}
-		if expr, ok := prefilledFields[fieldName]; ok {
-			kv.Value = expr
+		if ok {
+			kv.Value = value
 		} else {
 			names, ok := matches[fieldTyp]
 			if !ok {
@@ -230,16 +257,19 @@ func SuggestedFix(fset *token.FileSet, start, end token.Pos, content []byte, fil
 			// Find the name most similar to the field name.
 			// If no name matches the pattern, generate a zero value.
 			// NOTE: We currently match on the name of the field key rather than the field type.
-			if best := fuzzy.BestMatch(fieldName, names); best != "" {
+			best := fuzzy.BestMatch(fieldName, names)
+			if best == "" && opts.InScope && len(names) > 0 {
+				best = names[len(names)-1] // the most recently declared
+			}
+			if best != "" {
 				kv.Value = ast.NewIdent(best)
-			} else if v := populateValue(file, pkg, fieldTyp); v != nil {
+			} else if v := f.value(fieldTyp, 1); v != nil {
 				kv.Value = v
 			} else {
 				return nil, nil, nil // no fix to suggest
 			}
 		}
 		elts = append(elts, kv)
-		line++
 	}
 
 	// If all of the struct's fields are unexported, we have nothing to do.
@@ -247,19 +277,11 @@ func SuggestedFix(fset *token.FileSet, start, end token.Pos, content []byte, fil
 		return nil, nil, fmt.Errorf("no elements to fill")
 	}
 
-	// Add the final line for the right brace. Offset is the number of
-	// bytes already added plus 1.
-	tok.AddLine(len(elts) + 1)
-	line = len(elts) + 2
-	if line > tok.LineCount() {
-		panic(fmt.Sprintf("invalid line number %v (of %v) for fillstruct", line, tok.LineCount()))
-	}
-
 	cl := &ast.CompositeLit{
 		Type:   expr.Type,
-		Lbrace: tok.LineStart(1),
+		Lbrace: lbrace,
 		Elts:   elts,
-		Rbrace: tok.LineStart(line),
+		Rbrace: f.newLine(),
 	}
 
 	// Find the line on which the composite literal is declared.
@@ -275,12 +297,12 @@ func SuggestedFix(fset *token.FileSet, start, end token.Pos, content []byte, fil
 
 	// First pass through the formatter: turn the expr into a string.
 	var formatBuf bytes.Buffer
-	if err := format.Node(&formatBuf, fakeFset, cl); err != nil {
+	if err := format.Node(&formatBuf, f.fileSet(), cl); err != nil {
 		return nil, nil, fmt.Errorf("failed to run first format on:\n%s\ngot err: %v", cl.Type, err)
 	}
 	sug := indent(formatBuf.Bytes(), whitespace)
 
-	if len(prefilledFields) > 0 {
+	if len(prefilled) > 0 {
 		// Attempt a second pass through the formatter to line up columns.
 		sourced, err := format.Source(sug)
 		if err == nil {
@@ -299,6 +321,160 @@ func SuggestedFix(fset *token.FileSet, start, end token.Pos, content []byte, fil
 	}, nil
 }
 
+// structLiteral returns the innermost composite literal of struct (or
+// pointer to struct) type enclosing pos, and its struct type.
+func structLiteral(file *ast.File, pos token.Pos, pkg *types.Package, info *types.Info) (*ast.CompositeLit, *types.Struct, error) {
+	// TODO(rstambler): Using ast.Inspect would probably be more efficient than
+	// calling PathEnclosingInterval. Switch this approach.
+	path, _ := astutil.PathEnclosingInterval(file, pos, pos)
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("no enclosing ast.Node")
+	}
+	var expr *ast.CompositeLit
+	for _, n := range path {
+		if node, ok := n.(*ast.CompositeLit); ok {
+			expr = node
+			break
+		}
+	}
+
+	typ := info.TypeOf(expr)
+	if typ == nil {
+		return nil, nil, fmt.Errorf("no composite literal")
+	}
+
+	// Find reference to the type declaration of the struct being initialized.
+	typ = typeparams.Deref(typ)
+	tStruct, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a (pointer to) struct type",
+			types.TypeString(typ, types.RelativeTo(pkg)))
+	}
+	return expr, tStruct, nil
+}
+
+// prefilledFields returns the values of the fields set by the
+// key/value elements of a struct literal.
+func prefilledFields(expr *ast.CompositeLit) map[string]ast.Expr {
+	prefilled := make(map[string]ast.Expr)
+	for _, e := range expr.Elts {
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok {
+				prefilled[key.Name] = kv.Value
+			}
+		}
+	}
+	return prefilled
+}
+
+// fieldTypes returns the type of each field of a struct, or nil for
+// fields that are not accessible in pkg.
+func fieldTypes(tStruct *types.Struct, pkg *types.Package) []types.Type {
+	var fieldTyps []types.Type
+	for i := 0; i < tStruct.NumFields(); i++ {
+		field := tStruct.Field(i)
+		// Ignore fields that are not accessible in the current package.
+		if field.Pkg() != nil && field.Pkg() != pkg && !field.Exported() {
+			fieldTyps = append(fieldTyps, nil)
+			continue
+		}
+		fieldTyps = append(fieldTyps, field.Type())
+	}
+	return fieldTyps
+}
+
+// A filler builds the syntax of a filled struct literal.
+//
+// The syntax is positioned in a fake file that has one line for each
+// line of the literal: format.Node only cares about line numbers, so
+// we don't need to set columns, and each line can be 1 byte long.
+// TODO(adonovan): why is this necessary? The position information
+// is going to be wrong for the existing trees in prefilled fields.
+// Can't the formatter just do its best with an empty fileset?
+type filler struct {
+	file  *ast.File
+	pkg   *types.Package
+	opts  Options
+	lines int // number of lines of the fake file
+}
+
+// newLine adds a line to the fake file and returns its position.
+func (f *filler) newLine() token.Pos {
+	f.lines++
+	return token.Pos(f.lines) // the file's base is 1
+}
+
+// fileSet returns a file set containing the fake file.
+func (f *filler) fileSet() *token.FileSet {
+	fset := token.NewFileSet()
+	tok := fset.AddFile("", -1, f.lines)
+	lines := make([]int, f.lines)
+	for i := range lines {
+		lines[i] = i
+	}
+	tok.SetLines(lines)
+	return fset
+}
+
+// value returns an expression to fill a field of type typ of a struct
+// literal at the given depth of nesting, or nil if there is none.
+func (f *filler) value(typ types.Type, depth int) ast.Expr {
+	if depth < f.opts.Depth {
+		if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+			if lit := f.structValue(ptr.Elem(), depth+1); lit != nil {
+				return &ast.UnaryExpr{Op: token.AND, X: lit}
+			}
+		} else if lit := f.structValue(typ, depth+1); lit != nil {
+			return lit
+		}
+	}
+	return populateValue(f.file, f.pkg, typ)
+}
+
+// structValue returns a struct literal of type typ, which is at the
+// given depth of nesting, with its fields filled, or nil if typ is not
+// a struct type with fields to fill.
+func (f *filler) structValue(typ types.Type, depth int) ast.Expr {
+	if _, ok := aliases.Unalias(typ).(*types.TypeParam); ok {
+		return nil
+	}
+	tStruct, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	texpr := analysisinternal.TypeExpr(f.file, f.pkg, typ)
+	if texpr == nil {
+		return nil
+	}
+	lines := f.lines // restored if the literal is abandoned
+	lit := &ast.CompositeLit{
+		Type:   texpr,
+		Lbrace: token.Pos(f.lines), // on the line of the field
+	}
+	for i, fieldTyp := range fieldTypes(tStruct, f.pkg) {
+		if fieldTyp == nil || f.opts.ExportedOnly && !tStruct.Field(i).Exported() {
+			continue
+		}
+		pos := f.newLine()
+		v := f.value(fieldTyp, depth)
+		if v == nil {
+			f.lines = lines
+			return nil
+		}
+		lit.Elts = append(lit.Elts, &ast.KeyValueExpr{
+			Key:   &ast.Ident{NamePos: pos, Name: tStruct.Field(i).Name()},
+			Colon: pos,
+			Value: v,
+		})
+	}
+	if len(lit.Elts) == 0 {
+		f.lines = lines
+		return nil
+	}
+	lit.Rbrace = f.newLine()
+	return lit
+}
+
 // indent works line by line through str, indenting (prefixing) each line with
 // ind.
 func indent(str, ind []byte) []byte {
@@ -10,7 +10,12 @@
 // The possible cases are: for a type switch, each accessible named
 // type T or pointer *T that is assignable to the interface type; and
 // for an 'enum' switch, each accessible named constant of the same
-// type as the switch value.
+// type as the switch value. Existing cases are preserved.
+//
+// In gopls, the candidate types for a type switch include those
+// declared in the current package and in any workspace package it
+// depends on, not just the package of the interface; imports are added
+// as needed.
 //
 // For an 'enum' switch, it will suggest cases for all possible values of the
 // type.
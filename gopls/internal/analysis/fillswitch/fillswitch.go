@@ -12,13 +12,24 @@ import (
 	"go/types"
 
 	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/gopls/internal/util/typesutil"
 )
 
 // Diagnose computes diagnostics for switch statements with missing cases
 // overlapping with the provided start and end position of file f.
 //
 // If either start or end is invalid, the entire file is inspected.
-func Diagnose(f *ast.File, start, end token.Pos, pkg *types.Package, info *types.Info) []analysis.Diagnostic {
+//
+// The missing cases of a type switch are the accessible types that
+// implement its interface and are declared in the package of the
+// interface, the current package, or any of the packages of impls.
+//
+// The qualifier qual determines how names declared in other packages
+// are written; if nil, the names of the file's imports are used.
+func Diagnose(f *ast.File, start, end token.Pos, pkg *types.Package, info *types.Info, impls []*types.Package, qual types.Qualifier) []analysis.Diagnostic {
+	if qual == nil {
+		qual = typesutil.FileQualifier(f, pkg, info)
+	}
 	var diags []analysis.Diagnostic
 	ast.Inspect(f, func(n ast.Node) bool {
 		if n == nil {
@@ -28,14 +39,7 @@ func Diagnose(f *ast.File, start, end token.Pos, pkg *types.Package, info *types
 			end.IsValid() && n.Pos() > end {
 			return false // skip non-overlapping subtree
 		}
-		var fix *analysis.SuggestedFix
-		switch n := n.(type) {
-		case *ast.SwitchStmt:
-			fix = suggestedFixSwitch(n, pkg, info)
-		case *ast.TypeSwitchStmt:
-			fix = suggestedFixTypeSwitch(n, pkg, info)
-		}
-		if fix != nil {
+		if fix := SuggestedFix(n, pkg, info, impls, qual); fix != nil {
 			diags = append(diags, analysis.Diagnostic{
 				Message:        fix.Message,
 				Pos:            n.Pos(),
@@ -49,7 +53,21 @@ func Diagnose(f *ast.File, start, end token.Pos, pkg *types.Package, info *types
 	return diags
 }
 
-func suggestedFixTypeSwitch(stmt *ast.TypeSwitchStmt, pkg *types.Package, info *types.Info) *analysis.SuggestedFix {
+// SuggestedFix returns the fix that adds the missing cases of the
+// switch statement n, or nil if n is not such a statement or has no
+// missing cases. The remaining parameters are as for [Diagnose],
+// except that qual must not be nil.
+func SuggestedFix(n ast.Node, pkg *types.Package, info *types.Info, impls []*types.Package, qual types.Qualifier) *analysis.SuggestedFix {
+	switch n := n.(type) {
+	case *ast.SwitchStmt:
+		return suggestedFixSwitch(n, pkg, info, qual)
+	case *ast.TypeSwitchStmt:
+		return suggestedFixTypeSwitch(n, pkg, info, impls, qual)
+	}
+	return nil
+}
+
+func suggestedFixTypeSwitch(stmt *ast.TypeSwitchStmt, pkg *types.Package, info *types.Info, impls []*types.Package, qual types.Qualifier) *analysis.SuggestedFix {
 	if hasDefaultCase(stmt.Body) {
 		return nil
 	}
@@ -62,52 +80,54 @@ func suggestedFixTypeSwitch(stmt *ast.TypeSwitchStmt, pkg *types.Package, info *
 	existingCases := caseTypes(stmt.Body, info)
 	// Gather accessible package-level concrete types
 	// that implement the switch interface type.
-	scope := namedType.Obj().Pkg().Scope()
 	var buf bytes.Buffer
-	for _, name := range scope.Names() {
-		obj := scope.Lookup(name)
-		if tname, ok := obj.(*types.TypeName); !ok || tname.IsAlias() {
-			continue // not a defined type
-		}
-
-		if types.IsInterface(obj.Type()) {
+	seen := make(map[*types.Package]bool)
+	for _, p := range append([]*types.Package{namedType.Obj().Pkg(), pkg}, impls...) {
+		if p == nil || seen[p] {
 			continue
 		}
+		seen[p] = true
+		scope := p.Scope()
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			if tname, ok := obj.(*types.TypeName); !ok || tname.IsAlias() {
+				continue // not a defined type
+			}
 
-		samePkg := obj.Pkg() == pkg
-		if !samePkg && !obj.Exported() {
-			continue // inaccessible
-		}
-
-		var key caseType
-		if types.AssignableTo(obj.Type(), namedType.Obj().Type()) {
-			key.named = obj.Type().(*types.Named)
-		} else if ptr := types.NewPointer(obj.Type()); types.AssignableTo(ptr, namedType.Obj().Type()) {
-			key.named = obj.Type().(*types.Named)
-			key.ptr = true
-		}
-
-		if key.named != nil {
-			if existingCases[key] {
+			if types.IsInterface(obj.Type()) {
 				continue
 			}
 
-			if buf.Len() > 0 {
-				buf.WriteString("\t")
+			samePkg := obj.Pkg() == pkg
+			if !samePkg && !obj.Exported() {
+				continue // inaccessible
 			}
 
-			buf.WriteString("case ")
-			if key.ptr {
-				buf.WriteByte('*')
+			var key caseType
+			if types.AssignableTo(obj.Type(), namedType.Obj().Type()) {
+				key.named = obj.Type().(*types.Named)
+			} else if ptr := types.NewPointer(obj.Type()); types.AssignableTo(ptr, namedType.Obj().Type()) {
+				key.named = obj.Type().(*types.Named)
+				key.ptr = true
 			}
 
-			if p := key.named.Obj().Pkg(); p != pkg {
-				// TODO: use the correct package name when the import is renamed
-				buf.WriteString(p.Name())
-				buf.WriteByte('.')
+			if key.named != nil {
+				if existingCases[key] {
+					continue
+				}
+
+				if buf.Len() > 0 {
+					buf.WriteString("\t")
+				}
+
+				buf.WriteString("case ")
+				if key.ptr {
+					buf.WriteByte('*')
+				}
+
+				writeQualified(&buf, key.named.Obj(), qual)
+				buf.WriteString(":\n")
 			}
-			buf.WriteString(key.named.Obj().Name())
-			buf.WriteString(":\n")
 		}
 	}
 
@@ -134,7 +154,7 @@ func suggestedFixTypeSwitch(stmt *ast.TypeSwitchStmt, pkg *types.Package, info *
 	}
 }
 
-func suggestedFixSwitch(stmt *ast.SwitchStmt, pkg *types.Package, info *types.Info) *analysis.SuggestedFix {
+func suggestedFixSwitch(stmt *ast.SwitchStmt, pkg *types.Package, info *types.Info, qual types.Qualifier) *analysis.SuggestedFix {
 	if hasDefaultCase(stmt.Body) {
 		return nil
 	}
@@ -160,11 +180,7 @@ func suggestedFixSwitch(stmt *ast.SwitchStmt, pkg *types.Package, info *types.In
 			}
 
 			buf.WriteString("case ")
-			if c.Pkg() != pkg {
-				buf.WriteString(c.Pkg().Name())
-				buf.WriteByte('.')
-			}
-			buf.WriteString(c.Name())
+			writeQualified(&buf, c, qual)
 			buf.WriteString(":\n")
 		}
 	}
@@ -185,6 +201,16 @@ func suggestedFixSwitch(stmt *ast.SwitchStmt, pkg *types.Package, info *types.In
 	}
 }
 
+// writeQualified writes the name of the package-level object obj,
+// qualified by the name given to its package by qual.
+func writeQualified(buf *bytes.Buffer, obj types.Object, qual types.Qualifier) {
+	if name := qual(obj.Pkg()); name != "" {
+		buf.WriteString(name)
+		buf.WriteByte('.')
+	}
+	buf.WriteString(obj.Name())
+}
+
 func addDefaultCase(buf *bytes.Buffer, named *types.Named, expr ast.Expr) {
 	var dottedBuf bytes.Buffer
 	// writeDotted emits a dotted path a.b.c.
@@ -20,7 +20,7 @@ var analyzer = &analysis.Analyzer{
 	Doc:  "test only",
 	Run: func(pass *analysis.Pass) (any, error) {
 		for _, f := range pass.Files {
-			for _, diag := range fillswitch.Diagnose(f, token.NoPos, token.NoPos, pass.Pkg, pass.TypesInfo) {
+			for _, diag := range fillswitch.Diagnose(f, token.NoPos, token.NoPos, pass.Pkg, pass.TypesInfo, nil, nil) {
 				pass.Report(diag)
 			}
 		}
@@ -0,0 +1,23 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package generatedirective defines an Analyzer that checks the type
+// names in //go:generate directives, and the parsing of those
+// directives used by gopls to resolve the types and packages they
+// refer to.
+//
+// # Analyzer generate
+//
+// generate: check type names in //go:generate directives
+//
+// This analyzer reports the names in the -type flag of a //go:generate
+// directive that do not denote a type declared by the package. The
+// -type flag is the conventional way of naming the types to process
+// for generators such as stringer:
+//
+//	//go:generate stringer -type=Color,Shape
+//
+// A misspelled or renamed type otherwise goes unnoticed until the next
+// time "go generate" is run.
+package generatedirective
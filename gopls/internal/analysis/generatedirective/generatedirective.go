@@ -0,0 +1,167 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generatedirective
+
+import (
+	_ "embed"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/internal/analysisinternal"
+)
+
+//go:embed doc.go
+var doc string
+
+var Analyzer = &analysis.Analyzer{
+	Name:             "generate",
+	Doc:              analysisinternal.MustExtractDoc(doc, "generate"),
+	Run:              run,
+	RunDespiteErrors: true,
+	URL:              "https://pkg.go.dev/golang.org/x/tools/gopls/internal/analysis/generatedirective",
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, f := range pass.Files {
+		for _, ref := range Refs(f) {
+			if ref.IsPackage {
+				continue
+			}
+			switch obj := pass.Pkg.Scope().Lookup(ref.Name); obj.(type) {
+			case *types.TypeName:
+				// ok
+			case nil:
+				pass.Reportf(ref.Pos, "go:generate directive refers to undeclared type %s", ref.Name)
+			default:
+				pass.Reportf(ref.Pos, "go:generate directive refers to %s, which is not a type", ref.Name)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// A Ref is a reference to a type or a package in the arguments of a
+// //go:generate directive.
+type Ref struct {
+	Name      string    // the type name, or the package path
+	Pos, End  token.Pos // the extent of Name within the directive
+	IsPackage bool      // Name is the path of a package run by "go run"
+}
+
+// Refs returns the references in the //go:generate directives of the
+// file, in order. The references are:
+//
+//   - each name in the comma-separated list of a -type flag, which
+//     conventionally names types declared by the package, as in
+//     "stringer -type=Color,Shape";
+//   - the package path of a "go run" command, without its version, as
+//     in "go run golang.org/x/tools/cmd/stringer@latest".
+func Refs(file *ast.File) []Ref {
+	var refs []Ref
+	for _, cg := range file.Comments {
+		for _, c := range cg.List {
+			if strings.HasPrefix(c.Text, "//go:generate ") {
+				refs = append(refs, directiveRefs(c)...)
+			}
+		}
+	}
+	return refs
+}
+
+// A word is a word of the command line of a //go:generate directive.
+type word struct {
+	text   string
+	pos    token.Pos
+	quoted bool
+}
+
+// directiveRefs returns the references in the //go:generate directive c.
+func directiveRefs(c *ast.Comment) []Ref {
+	// Split the command line into words, as go generate does:
+	// words are separated by spaces and tabs, and a double-quoted
+	// string is a single word.
+	var words []word
+	text := c.Text
+	for i := len("//go:generate"); i < len(text); {
+		if text[i] == ' ' || text[i] == '\t' {
+			i++
+			continue
+		}
+		if text[i] == '"' {
+			quoted, err := strconv.QuotedPrefix(text[i:])
+			if err != nil {
+				break // go generate rejects the directive
+			}
+			words = append(words, word{quoted, c.Slash + token.Pos(i), true})
+			i += len(quoted)
+			continue
+		}
+		j := i
+		for j < len(text) && text[j] != ' ' && text[j] != '\t' {
+			j++
+		}
+		words = append(words, word{text[i:j], c.Slash + token.Pos(i), false})
+		i = j
+	}
+
+	var refs []Ref
+	// typeList appends a reference for each name in the
+	// comma-separated list at pos.
+	typeList := func(list string, pos token.Pos) {
+		for _, name := range strings.Split(list, ",") {
+			if token.IsIdentifier(name) {
+				refs = append(refs, Ref{Name: name, Pos: pos, End: pos + token.Pos(len(name))})
+			}
+			pos += token.Pos(len(name) + len(","))
+		}
+	}
+
+	// A "go run" command names the package to run
+	// by its first non-flag argument.
+	goRun := len(words) > 2 && words[0].text == "go" && words[1].text == "run"
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if w.quoted {
+			continue
+		}
+		if !strings.HasPrefix(w.text, "-") {
+			if goRun && i > 1 {
+				goRun = false
+				if isPackagePath(w.text) {
+					path := w.text
+					if at := strings.IndexByte(path, '@'); at >= 0 {
+						path = path[:at]
+					}
+					refs = append(refs, Ref{Name: path, Pos: w.pos, End: w.pos + token.Pos(len(path)), IsPackage: true})
+				}
+			}
+			continue
+		}
+		flag := strings.TrimPrefix(w.text[len("-"):], "-")
+		if flag == "type" && i+1 < len(words) && !words[i+1].quoted {
+			i++
+			typeList(words[i].text, words[i].pos)
+		} else if strings.HasPrefix(flag, "type=") {
+			value := flag[len("type="):]
+			typeList(value, w.pos+token.Pos(len(w.text)-len(value)))
+		}
+	}
+	return refs
+}
+
+// isPackagePath reports whether the argument of "go run" is the path
+// of a package, as opposed to a relative or absolute directory, or a
+// list of files.
+func isPackagePath(arg string) bool {
+	return arg != "" &&
+		!strings.HasPrefix(arg, ".") &&
+		!strings.HasPrefix(arg, "/") &&
+		!strings.HasSuffix(arg, ".go") &&
+		!strings.Contains(arg, "$")
+}
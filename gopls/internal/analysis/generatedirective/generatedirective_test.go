@@ -0,0 +1,46 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package generatedirective_test
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/gopls/internal/analysis/generatedirective"
+)
+
+func Test(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, generatedirective.Analyzer, "a")
+}
+
+func TestRefs(t *testing.T) {
+	const src = `package p
+
+//go:generate go run golang.org/x/tools/cmd/stringer@v0.1.0 -type T,U -output "x y.go"
+//go:generate go run ./gen -- -type=V
+//go:generate stringer -type="W"
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ref := range generatedirective.Refs(f) {
+		if text := src[fset.Position(ref.Pos).Offset:fset.Position(ref.End).Offset]; text != ref.Name {
+			t.Errorf("Ref %q has extent %q", ref.Name, text)
+		}
+		got = append(got, fmt.Sprintf("%s %t", ref.Name, ref.IsPackage))
+	}
+	want := []string{"golang.org/x/tools/cmd/stringer true", "T false", "U false", "V false"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Refs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,16 @@
+package a
+
+//go:generate stringer -type=Color
+//go:generate stringer -type=Color,Shape,Size // want "go:generate directive refers to undeclared type Size"
+//go:generate stringer -type Colour // want "go:generate directive refers to undeclared type Colour"
+//go:generate enumer --type=Shape -linecomment
+//go:generate stringer -type=defaultColor // want "go:generate directive refers to defaultColor, which is not a type"
+//go:generate go run golang.org/x/tools/cmd/stringer@latest -type=Color
+//go:generate echo "-type=Missing"
+//go:generate stringer -type=$GOTYPE
+
+type Color int
+
+type Shape int
+
+const defaultColor Color = 0
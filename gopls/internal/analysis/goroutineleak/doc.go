@@ -0,0 +1,42 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package goroutineleak defines an analyzer that checks for goroutines
+// that may never terminate.
+//
+// # Analyzer goroutineleak
+//
+// goroutineleak: check for goroutines that may leak
+//
+// The goroutineleak analyzer reports go statements whose function
+// literal appears to block or loop forever, or to misuse a
+// sync.WaitGroup. It applies three heuristics:
+//
+//   - Blocking on an unreferenced channel: the goroutine sends to or
+//     receives from an unbuffered channel created by the enclosing
+//     function that is not referenced anywhere outside the goroutine,
+//     so no other goroutine can ever communicate with it:
+//
+//     ch := make(chan int)
+//     go func() { ch <- compute() }() // blocks forever
+//
+//     Communications in a select statement with a default case don't
+//     block, and references within a nested function literal, which
+//     may be another goroutine, count as references outside.
+//
+//   - Ignoring cancellation: the enclosing function has a
+//     context.Context parameter, but the goroutine contains an infinite
+//     loop with no return or break and never refers to any context, so
+//     cancellation of the context cannot stop it.
+//
+//   - WaitGroup mismatches: the goroutine calls Add on a WaitGroup of
+//     the enclosing function, which races with a call to Wait (Add
+//     should be called before the go statement); or the go statement
+//     immediately follows a call to wg.Add, but the goroutine never
+//     calls wg.Done, either directly or through a local closure or a
+//     function of the package, so a call to wg.Wait never returns.
+//
+// These heuristics consider only function literals in go statements,
+// not calls to named functions.
+package goroutineleak
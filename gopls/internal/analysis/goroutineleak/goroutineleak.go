@@ -0,0 +1,467 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package goroutineleak
+
+import (
+	_ "embed"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/internal/analysisinternal"
+	"golang.org/x/tools/internal/typesinternal"
+)
+
+//go:embed doc.go
+var doc string
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "goroutineleak",
+	Doc:      analysisinternal.MustExtractDoc(doc, "goroutineleak"),
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+	URL:      "https://pkg.go.dev/golang.org/x/tools/gopls/internal/analysis/goroutineleak",
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	// Index the uses of each local variable, the initializer of
+	// each variable defined by "x := ..." or "var x = ...", and
+	// the declaration of each function of the package.
+	uses := make(map[*types.Var][]*ast.Ident)
+	for id, obj := range pass.TypesInfo.Uses {
+		if v, ok := obj.(*types.Var); ok && !v.IsField() {
+			uses[v] = append(uses[v], id)
+		}
+	}
+	inits := make(map[*types.Var]ast.Expr)
+	inspect.Preorder([]ast.Node{(*ast.AssignStmt)(nil), (*ast.ValueSpec)(nil)}, func(n ast.Node) {
+		var lhs, rhs []ast.Expr
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE {
+				return
+			}
+			lhs, rhs = n.Lhs, n.Rhs
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				lhs = append(lhs, name)
+			}
+			rhs = n.Values
+		}
+		if len(lhs) != len(rhs) {
+			return
+		}
+		for i, e := range lhs {
+			if id, ok := e.(*ast.Ident); ok {
+				if v, ok := pass.TypesInfo.Defs[id].(*types.Var); ok {
+					inits[v] = rhs[i]
+				}
+			}
+		}
+	})
+
+	decls := make(map[*types.Func]*ast.FuncDecl)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if decl, ok := decl.(*ast.FuncDecl); ok && decl.Body != nil {
+				if fn, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func); ok {
+					decls[fn] = decl
+				}
+			}
+		}
+	}
+
+	inspect.WithStack([]ast.Node{(*ast.GoStmt)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		stmt := n.(*ast.GoStmt)
+		lit, ok := astutil.Unparen(stmt.Call.Fun).(*ast.FuncLit)
+		if !ok {
+			return true
+		}
+		// within reports whether pos lies within the function literal.
+		within := func(pos token.Pos) bool {
+			return lit.Pos() <= pos && pos < lit.End()
+		}
+		// A function literal nested within the goroutine may run as
+		// another goroutine, so its references don't count as the
+		// goroutine's own.
+		var nested []*ast.FuncLit
+		ast.Inspect(lit.Body, func(n ast.Node) bool {
+			if n, ok := n.(*ast.FuncLit); ok {
+				nested = append(nested, n)
+				return false
+			}
+			return true
+		})
+		// own reports whether pos lies within the function literal
+		// but not within a nested one.
+		own := func(pos token.Pos) bool {
+			if !within(pos) {
+				return false
+			}
+			for _, n := range nested {
+				if n.Pos() <= pos && pos < n.End() {
+					return false
+				}
+			}
+			return true
+		}
+		// local reports whether v is a variable declared outside the
+		// function literal but referenced only within it.
+		local := func(v *types.Var) bool {
+			if within(v.Pos()) {
+				return false
+			}
+			for _, id := range uses[v] {
+				if !own(id.Pos()) {
+					return false
+				}
+			}
+			return true
+		}
+
+		// Heuristic 1: blocking on an unreferenced channel.
+		var visit func(n ast.Node) bool
+		visit = func(n ast.Node) bool {
+			var (
+				rng ast.Node // the blocking operation
+				ch  ast.Expr
+				op  string
+			)
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.SelectStmt:
+				// The communications of a select statement
+				// with a default case don't block.
+				if hasDefault(n) {
+					for _, clause := range n.Body.List {
+						for _, stmt := range clause.(*ast.CommClause).Body {
+							ast.Inspect(stmt, visit)
+						}
+					}
+					return false
+				}
+			case *ast.SendStmt:
+				rng, ch, op = n, n.Chan, "send to"
+			case *ast.UnaryExpr:
+				if n.Op == token.ARROW {
+					rng, ch, op = n, n.X, "receive from"
+				}
+			case *ast.RangeStmt:
+				rng, ch, op = n.X, n.X, "range over"
+			}
+			id, ok := astutil.Unparen(ch).(*ast.Ident)
+			if !ok {
+				return true
+			}
+			v, ok := pass.TypesInfo.Uses[id].(*types.Var)
+			if !ok || !local(v) || !isUnbufferedMake(pass.TypesInfo, inits[v]) {
+				return true
+			}
+			pass.ReportRangef(rng, "goroutine blocks forever: %s %s, which is not referenced outside the goroutine", op, id.Name)
+			return false
+		}
+		ast.Inspect(lit.Body, visit)
+
+		// Heuristic 2: ignoring cancellation.
+		if ctx := contextParam(pass.TypesInfo, stack); ctx != nil && !mentionsContext(pass.TypesInfo, stmt.Call) {
+			if loop := endlessLoop(pass.TypesInfo, lit.Body); loop != nil {
+				pass.Reportf(loop.For, "goroutine loops forever without observing cancellation of %s", ctx.Name())
+			}
+		}
+
+		// Heuristic 3: WaitGroup mismatches.
+		ast.Inspect(lit.Body, func(n ast.Node) bool {
+			if _, ok := n.(*ast.FuncLit); ok {
+				return false // a nested goroutine may legitimately call Add
+			}
+			if call, ok := n.(*ast.CallExpr); ok {
+				if wg := waitGroupCall(pass.TypesInfo, call, "Add"); wg != nil {
+					if id := rootIdent(wg); id != nil && !within(pass.TypesInfo.ObjectOf(id).Pos()) {
+						pass.ReportRangef(call, "%s.Add called within the goroutine races with %[1]s.Wait; call it before the go statement", types.ExprString(wg))
+					}
+				}
+			}
+			return true
+		})
+		if prev := previousStmt(stack, stmt); prev != nil {
+			if expr, ok := prev.(*ast.ExprStmt); ok {
+				if call, ok := expr.X.(*ast.CallExpr); ok {
+					if wg := waitGroupCall(pass.TypesInfo, call, "Add"); wg != nil && !mayCallDone(pass.TypesInfo, inits, decls, stmt.Call, wg) {
+						pass.ReportRangef(stmt, "goroutine started after %s.Add never calls %[1]s.Done", types.ExprString(wg))
+					}
+				}
+			}
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// isUnbufferedMake reports whether e is a call make(chan T) or
+// make(chan T, 0).
+func isUnbufferedMake(info *types.Info, e ast.Expr) bool {
+	call, ok := astutil.Unparen(e).(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 || len(call.Args) > 2 {
+		return false
+	}
+	if id, ok := astutil.Unparen(call.Fun).(*ast.Ident); !ok || info.Uses[id] != types.Universe.Lookup("make") {
+		return false
+	}
+	if _, ok := info.TypeOf(call.Args[0]).Underlying().(*types.Chan); !ok {
+		return false
+	}
+	if len(call.Args) == 2 {
+		tv := info.Types[call.Args[1]]
+		return tv.Value != nil && constant.Sign(tv.Value) == 0
+	}
+	return true
+}
+
+// hasDefault reports whether the select statement has a default case.
+func hasDefault(stmt *ast.SelectStmt) bool {
+	for _, clause := range stmt.Body.List {
+		if clause.(*ast.CommClause).Comm == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// contextParam returns a context.Context parameter of the function
+// that immediately encloses the go statement at the top of the stack,
+// or nil if there is none.
+func contextParam(info *types.Info, stack []ast.Node) *types.Var {
+	for i := len(stack) - 1; i >= 0; i-- {
+		var ftype *ast.FuncType
+		switch n := stack[i].(type) {
+		case *ast.FuncDecl:
+			ftype = n.Type
+		case *ast.FuncLit:
+			ftype = n.Type
+		default:
+			continue
+		}
+		for _, field := range ftype.Params.List {
+			for _, name := range field.Names {
+				if v, ok := info.Defs[name].(*types.Var); ok && isContext(v.Type()) {
+					return v
+				}
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// mentionsContext reports whether n refers to any variable of type
+// context.Context.
+func mentionsContext(info *types.Info, n ast.Node) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if v, ok := info.Uses[id].(*types.Var); ok && isContext(v.Type()) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// endlessLoop returns the first loop "for { ... }" in body (but not in
+// nested function literals) that contains no return statement, no
+// goto, no call to panic, and no break statement that exits it.
+func endlessLoop(info *types.Info, body *ast.BlockStmt) *ast.ForStmt {
+	var result *ast.ForStmt
+	ast.Inspect(body, func(n ast.Node) bool {
+		if result != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ForStmt:
+			if n.Cond == nil && !exits(info, n.Body, n.Body, true) {
+				result = n
+				return false
+			}
+		}
+		return true
+	})
+	return result
+}
+
+// exits reports whether executing n, within the body of a loop, may
+// leave the loop: by return, goto, panic, a break statement whose
+// label is declared outside the loop body, or (if unlabeled is set) an
+// unlabeled break that refers to the loop.
+func exits(info *types.Info, body *ast.BlockStmt, n ast.Node, unlabeled bool) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			found = true
+		case *ast.BranchStmt:
+			switch n.Tok {
+			case token.GOTO:
+				found = true
+			case token.BREAK:
+				if n.Label == nil {
+					found = unlabeled
+				} else if label := info.Uses[n.Label]; label != nil {
+					found = label.Pos() < body.Pos() || label.Pos() >= body.End()
+				}
+			}
+		case *ast.CallExpr:
+			if id, ok := astutil.Unparen(n.Fun).(*ast.Ident); ok && info.Uses[id] == types.Universe.Lookup("panic") {
+				found = true
+			}
+		case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			// An unlabeled break within a nested statement refers to it.
+			if unlabeled {
+				found = exits(info, body, n, false)
+				return false
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// waitGroupCall returns the operand x of a call x.method(...), where x
+// is a sync.WaitGroup or pointer to one, or nil.
+func waitGroupCall(info *types.Info, call *ast.CallExpr, method string) ast.Expr {
+	sel, ok := astutil.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != method {
+		return nil
+	}
+	if !isNamedType(typesinternal.Unpointer(info.TypeOf(sel.X)), "sync", "WaitGroup") {
+		return nil
+	}
+	return sel.X
+}
+
+// mayCallDone reports whether n may call Done on the WaitGroup x:
+// whether it refers to the variable at the root of x (e.g. wg in wg
+// or s.wg), for example to call Done or to pass it to another
+// function, or whether it calls Done on any WaitGroup. It follows
+// references to local closures defined by "f := func() { ... }" and
+// calls to functions declared in the package, which may call Done
+// on behalf of n.
+func mayCallDone(info *types.Info, inits map[*types.Var]ast.Expr, decls map[*types.Func]*ast.FuncDecl, n ast.Node, x ast.Expr) bool {
+	root := rootIdent(x)
+	if root == nil {
+		return true // conservatively
+	}
+	obj := info.ObjectOf(root)
+	seen := make(map[ast.Node]bool)
+	var search func(n ast.Node) bool
+	search = func(n ast.Node) bool {
+		if n == nil || seen[n] {
+			return false
+		}
+		seen[n] = true
+		found := false
+		ast.Inspect(n, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Ident:
+				switch use := info.Uses[n].(type) {
+				case *types.Var:
+					if use == obj {
+						found = true
+					} else if lit, ok := astutil.Unparen(inits[use]).(*ast.FuncLit); ok {
+						found = search(lit.Body)
+					}
+				case *types.Func:
+					if decl := decls[use.Origin()]; decl != nil {
+						found = search(decl.Body)
+					}
+				}
+			case *ast.CallExpr:
+				if waitGroupCall(info, n, "Done") != nil {
+					found = true
+				}
+			}
+			return !found
+		})
+		return found
+	}
+	return search(n)
+}
+
+// rootIdent returns the identifier at the root of a selector
+// expression such as x, &x, or x.f.g, or nil.
+func rootIdent(e ast.Expr) *ast.Ident {
+	for {
+		switch x := astutil.Unparen(e).(type) {
+		case *ast.Ident:
+			return x
+		case *ast.SelectorExpr:
+			e = x.X
+		case *ast.UnaryExpr:
+			if x.Op != token.AND {
+				return nil
+			}
+			e = x.X
+		case *ast.StarExpr:
+			e = x.X
+		default:
+			return nil
+		}
+	}
+}
+
+// previousStmt returns the statement that precedes stmt in its
+// enclosing statement list, or nil.
+func previousStmt(stack []ast.Node, stmt ast.Stmt) ast.Stmt {
+	if len(stack) < 2 {
+		return nil
+	}
+	var list []ast.Stmt
+	switch parent := stack[len(stack)-2].(type) {
+	case *ast.BlockStmt:
+		list = parent.List
+	case *ast.CaseClause:
+		list = parent.Body
+	case *ast.CommClause:
+		list = parent.Body
+	}
+	for i, s := range list {
+		if s == stmt && i > 0 {
+			return list[i-1]
+		}
+	}
+	return nil
+}
+
+func isContext(t types.Type) bool {
+	return isNamedType(t, "context", "Context")
+}
+
+// isNamedType reports whether t is the named type pkgPath.name.
+func isNamedType(t types.Type, pkgPath, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == name && obj.Pkg() != nil && obj.Pkg().Path() == pkgPath
+}
@@ -0,0 +1,243 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package a
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+func compute() int { return 0 }
+
+// Blocking on an unreferenced channel.
+
+func send() {
+	ch := make(chan int)
+	go func() {
+		ch <- compute() // want "goroutine blocks forever: send to ch, which is not referenced outside the goroutine"
+	}()
+}
+
+func receive() {
+	var ch = make(chan int, 0)
+	go func() {
+		println(<-ch) // want "goroutine blocks forever: receive from ch, which is not referenced outside the goroutine"
+	}()
+}
+
+func rangeOver() {
+	ch := make(chan int)
+	go func() {
+		for range ch { // want "goroutine blocks forever: range over ch, which is not referenced outside the goroutine"
+		}
+	}()
+}
+
+func received() int {
+	ch := make(chan int)
+	go func() {
+		ch <- compute() // ok: received below
+	}()
+	return <-ch
+}
+
+func buffered() {
+	ch := make(chan int, 1)
+	go func() {
+		ch <- compute() // ok: buffered
+	}()
+}
+
+func param(ch chan int) {
+	go func() {
+		ch <- compute() // ok: the caller may receive
+	}()
+}
+
+func argument() {
+	ch := make(chan int)
+	go func(ch chan int) {
+		ch <- compute() // ok: not the variable of the enclosing function
+	}(ch)
+}
+
+func selectDefault(x int) {
+	ch := make(chan int)
+	go func() {
+		select {
+		case ch <- x: // ok: the select doesn't block
+		default:
+		}
+		select {
+		case v := <-ch: // ok: the select doesn't block
+			println(v)
+		default:
+		}
+	}()
+}
+
+func selectNoDefault(x int) {
+	ch := make(chan int)
+	go func() {
+		select {
+		case ch <- x: // want "goroutine blocks forever: send to ch, which is not referenced outside the goroutine"
+		}
+	}()
+}
+
+func nestedGoroutine() {
+	ch := make(chan int)
+	go func() {
+		go func() {
+			ch <- 1 // ok: received by the enclosing goroutine
+		}()
+		<-ch
+	}()
+}
+
+// Ignoring cancellation.
+
+func loop(ctx context.Context) {
+	go func() {
+		for { // want "goroutine loops forever without observing cancellation of ctx"
+			time.Sleep(time.Second)
+		}
+	}()
+}
+
+func observed(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+}
+
+func passed(ctx context.Context, work func(context.Context)) {
+	go func(ctx context.Context) {
+		for {
+			work(ctx)
+		}
+	}(ctx)
+}
+
+func breaks(ctx context.Context, done func() bool) {
+	go func() {
+	outer:
+		for {
+			for {
+				if done() {
+					break outer
+				}
+			}
+		}
+	}()
+}
+
+func innerBreak(ctx context.Context, ch chan int) {
+	go func() {
+		for { // want "goroutine loops forever without observing cancellation of ctx"
+			select {
+			case <-ch:
+				break // exits only the select
+			}
+		}
+	}()
+}
+
+func noContext() {
+	go func() {
+		for {
+			time.Sleep(time.Second) // ok: no context to observe
+		}
+	}()
+}
+
+// WaitGroup mismatches.
+
+func addInside(n int) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		go func() {
+			wg.Add(1) // want "wg.Add called within the goroutine races with wg.Wait; call it before the go statement"
+			defer wg.Done()
+		}()
+	}
+	wg.Wait()
+}
+
+func noDone() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() { // want "goroutine started after wg.Add never calls wg.Done"
+		compute()
+	}()
+	wg.Wait()
+}
+
+type server struct{ wg sync.WaitGroup }
+
+func (s *server) start() {
+	s.wg.Add(1)
+	go func() { // want "goroutine started after s.wg.Add never calls s.wg.Done"
+		compute()
+	}()
+}
+
+func done() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		compute()
+	}()
+	wg.Wait()
+}
+
+func doneByArgument(worker func(*sync.WaitGroup)) {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func(wg *sync.WaitGroup) {
+		worker(wg)
+	}(&wg)
+	wg.Wait()
+}
+
+func doneByClosure() {
+	var wg sync.WaitGroup
+	done := func() { wg.Done() }
+	wg.Add(1)
+	go func() {
+		defer done()
+		compute()
+	}()
+	wg.Wait()
+}
+
+var globalWG sync.WaitGroup
+
+func finish() { globalWG.Done() }
+
+func doneByFunction() {
+	globalWG.Add(1)
+	go func() {
+		defer finish()
+	}()
+	globalWG.Wait()
+}
+
+func (s *server) finish() { s.wg.Done() }
+
+func (s *server) doneByMethod() {
+	s.wg.Add(1)
+	go func() {
+		defer s.finish()
+	}()
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package locks defines an analyzer that checks for misuse of
+// sync.Mutex and sync.RWMutex.
+//
+// # Analyzer locks
+//
+// locks: check for locks that are not released, or that lock a copy
+//
+// The locks analyzer complements the copylocks analyzer of go vet with
+// two checks of the use of sync.Mutex and sync.RWMutex.
+//
+// The first reports a lock that is not released on every path: a
+// function calls mu.Lock (or mu.RLock), and unlocks the same mutex on
+// some paths, but may return with it still held on others, often due
+// to an early return:
+//
+//	mu.Lock()
+//	if err != nil {
+//		return err // mu is still locked
+//	}
+//	mu.Unlock()
+//
+// A deferred call to mu.Unlock releases the lock on every path,
+// including panics. Functions that never unlock the mutex, such as
+// helpers that acquire a lock for their caller, are not reported, nor
+// are paths that hand the release to another function of the package,
+// by passing it the value containing the mutex or by returning a bound
+// method value. The check uses the SSA form of each function, so it follows the control
+// flow of loops and switches, and of locks acquired under a condition
+// that is tested again before the lock is released.
+//
+// The second reports a method with a value receiver that locks a
+// mutex contained in the receiver. Since the receiver is a copy, the
+// lock does not exclude callers of other methods. (The copylocks
+// analyzer reports the copy itself.) A suggested fix changes the
+// receiver to a pointer:
+//
+//	type Counter struct {
+//		sync.Mutex
+//		n int
+//	}
+//
+//	func (c Counter) Get() int {
+//		c.Lock() // locks a copy of c.Mutex
+//		defer c.Unlock()
+//		return c.n
+//	}
+package locks
@@ -0,0 +1,364 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package locks
+
+import (
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/internal/analysisinternal"
+)
+
+//go:embed doc.go
+var doc string
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "locks",
+	Doc:      analysisinternal.MustExtractDoc(doc, "locks"),
+	Requires: []*analysis.Analyzer{buildssa.Analyzer, inspect.Analyzer},
+	Run:      run,
+	URL:      "https://pkg.go.dev/golang.org/x/tools/gopls/internal/analysis/locks",
+}
+
+// unlocks maps the full name of each method that acquires a lock to
+// the name of the method that releases it.
+var unlocks = map[string]string{
+	"(*sync.Mutex).Lock":    "(*sync.Mutex).Unlock",
+	"(*sync.RWMutex).Lock":  "(*sync.RWMutex).Unlock",
+	"(*sync.RWMutex).RLock": "(*sync.RWMutex).RUnlock",
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	ssainfo := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
+
+	calls := make(map[token.Pos]*ast.CallExpr) // by position of left parenthesis
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		calls[call.Lparen] = call
+	})
+	for _, fn := range ssainfo.SrcFuncs {
+		checkUnreleased(pass, fn, calls)
+	}
+
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		checkValueReceiver(pass, n.(*ast.FuncDecl))
+	})
+	return nil, nil
+}
+
+// A lockKey identifies a mutex within a function: the path of field
+// selections and loads by which it is reached from a base value, such
+// as a parameter, free variable, global, or local variable.
+type lockKey struct {
+	base ssa.Value
+	path string
+}
+
+// keyOf returns the key of the mutex whose address is ptr.
+func keyOf(ptr ssa.Value) lockKey {
+	switch ptr := ptr.(type) {
+	case *ssa.FieldAddr:
+		key := keyOf(ptr.X)
+		key.path += fmt.Sprintf(".%d", ptr.Field)
+		return key
+	case *ssa.UnOp:
+		if ptr.Op == token.MUL {
+			key := keyOf(ptr.X)
+			key.path += "*"
+			return key
+		}
+	}
+	return lockKey{base: ptr}
+}
+
+// lockCall returns the full name of the static callee of the call
+// instruction, and its receiver, if it is a method of sync.Mutex or
+// sync.RWMutex.
+func lockCall(call *ssa.CallCommon) (string, ssa.Value) {
+	callee := call.StaticCallee()
+	if callee == nil || len(call.Args) == 0 {
+		return "", nil
+	}
+	if name := syncMethod(callee); name != "" {
+		return name, call.Args[0]
+	}
+	return "", nil
+}
+
+// syncMethod returns the full name of the method of the sync package
+// of which fn is the body or bound method wrapper, or "".
+func syncMethod(fn *ssa.Function) string {
+	obj, ok := fn.Object().(*types.Func)
+	if !ok || obj.Pkg() == nil || obj.Pkg().Path() != "sync" {
+		return ""
+	}
+	return obj.FullName()
+}
+
+// checkUnreleased reports each lock acquired by the function that is
+// released on some paths, but not on every path to a return.
+func checkUnreleased(pass *analysis.Pass, fn *ssa.Function, calls map[token.Pos]*ast.CallExpr) {
+	type lock struct {
+		call   *ssa.Call
+		block  *ssa.BasicBlock
+		index  int // of call within block
+		key    lockKey
+		unlock string
+	}
+	var (
+		locks    []lock
+		released = make(map[lockKey]map[string]bool) // names of methods called to unlock each mutex
+		deferred = make(map[lockKey]map[string]bool) // names of methods deferred, or bound, to unlock each mutex
+	)
+	addUnlock := func(m map[lockKey]map[string]bool, key lockKey, name string) {
+		if m[key] == nil {
+			m[key] = make(map[string]bool)
+		}
+		m[key][name] = true
+	}
+	for _, b := range fn.Blocks {
+		for i, instr := range b.Instrs {
+			switch instr := instr.(type) {
+			case *ssa.Call:
+				name, recv := lockCall(instr.Common())
+				if recv == nil {
+					continue
+				}
+				if unlock, ok := unlocks[name]; ok {
+					locks = append(locks, lock{instr, b, i, keyOf(recv), unlock})
+				} else {
+					addUnlock(released, keyOf(recv), name)
+				}
+			case *ssa.Defer:
+				name, recv := lockCall(instr.Common())
+				if recv == nil {
+					// A deferred closure, dynamic call, or call
+					// of a function of this package may unlock
+					// anything.
+					if callee := instr.Common().StaticCallee(); callee == nil || callee.Pkg == fn.Pkg {
+						return
+					}
+					continue
+				}
+				addUnlock(deferred, keyOf(recv), name)
+			case *ssa.MakeClosure:
+				// A bound method value such as mu.Unlock hands the
+				// release of the lock to its eventual caller.
+				// So may a bound method of this package.
+				if callee, ok := instr.Fn.(*ssa.Function); ok && len(instr.Bindings) == 1 && callee.Object() != nil {
+					if name := syncMethod(callee); name != "" {
+						addUnlock(deferred, keyOf(instr.Bindings[0]), name)
+					} else if callee.Object().Pkg() == fn.Pkg.Pkg {
+						return
+					}
+				}
+			}
+		}
+	}
+
+	for _, l := range locks {
+		if deferred[l.key][l.unlock] || !released[l.key][l.unlock] {
+			continue
+		}
+		if ret := unreleasedPath(fn.Pkg, l.block, l.index, l.key, l.unlock); ret != nil {
+			call, ok := calls[l.call.Pos()]
+			if !ok {
+				continue
+			}
+			sel, ok := astutil.Unparen(call.Fun).(*ast.SelectorExpr)
+			if !ok {
+				continue
+			}
+			pos := ret.Pos()
+			if !pos.IsValid() {
+				// An implicit return at the end of the function.
+				switch syntax := fn.Syntax().(type) {
+				case *ast.FuncDecl:
+					pos = syntax.Body.Rbrace
+				case *ast.FuncLit:
+					pos = syntax.Body.Rbrace
+				default:
+					continue
+				}
+			}
+			mutex := types.ExprString(sel.X)
+			pass.Report(analysis.Diagnostic{
+				Pos:     call.Pos(),
+				End:     call.End(),
+				Message: fmt.Sprintf("%s is not released on every path: %s may return with it held at line %d", mutex, fn.Name(), pass.Fset.Position(pos).Line),
+				Related: []analysis.RelatedInformation{{
+					Pos:     pos,
+					End:     pos,
+					Message: fmt.Sprintf("return with %s held", mutex),
+				}},
+			})
+		}
+	}
+}
+
+// unreleasedPath returns a return instruction reachable from the
+// instruction at index i of block b, the call that acquires the lock
+// of the mutex identified by key, along a path that does not call the
+// method unlock on it, or nil if there is none.
+//
+// A call of a function of pkg that is passed the value containing the
+// mutex, such as a method of the value, may release the lock, so it
+// ends the path.
+//
+// Paths that contradict the outcome of a condition tested before the
+// lock was acquired, and tested again before it is released, are not
+// considered: if a block ending in an if statement dominates the lock
+// through one of its successors only, the condition is known.
+func unreleasedPath(pkg *ssa.Package, b *ssa.BasicBlock, i int, key lockKey, unlock string) *ssa.Return {
+	known := make(map[ssa.Value]bool)
+	for p := b; p.Idom() != nil; p = p.Idom() {
+		idom := p.Idom()
+		if len(idom.Instrs) == 0 {
+			continue
+		}
+		if cond, ok := idom.Instrs[len(idom.Instrs)-1].(*ssa.If); ok && idom.Succs[0] != idom.Succs[1] {
+			for j, succ := range idom.Succs {
+				if len(succ.Preds) == 1 && succ.Dominates(b) {
+					known[cond.Cond] = j == 0
+				}
+			}
+		}
+	}
+
+	seen := make(map[*ssa.BasicBlock]bool)
+	var visit func(b *ssa.BasicBlock, instrs []ssa.Instruction) *ssa.Return
+	visit = func(b *ssa.BasicBlock, instrs []ssa.Instruction) *ssa.Return {
+		for _, instr := range instrs {
+			switch instr := instr.(type) {
+			case *ssa.Call:
+				name, recv := lockCall(instr.Common())
+				if recv != nil && keyOf(recv) == key && (name == unlock || unlocks[name] == unlock) {
+					return nil // released, or (erroneously) acquired again
+				}
+				if callee := instr.Common().StaticCallee(); callee != nil && callee.Pkg == pkg {
+					for _, arg := range instr.Common().Args {
+						if keyOf(arg).base == key.base {
+							return nil // may be released by the callee
+						}
+					}
+				}
+			case *ssa.Return:
+				return instr
+			case *ssa.If:
+				if v, ok := known[instr.Cond]; ok {
+					succ := b.Succs[1]
+					if v {
+						succ = b.Succs[0]
+					}
+					if !seen[succ] {
+						seen[succ] = true
+						return visit(succ, succ.Instrs)
+					}
+					return nil
+				}
+			}
+		}
+		for _, succ := range b.Succs {
+			if !seen[succ] {
+				seen[succ] = true
+				if ret := visit(succ, succ.Instrs); ret != nil {
+					return ret
+				}
+			}
+		}
+		return nil
+	}
+	return visit(b, b.Instrs[i+1:])
+}
+
+// checkValueReceiver reports each call that locks a mutex contained
+// in the value receiver of the method decl.
+func checkValueReceiver(pass *analysis.Pass, decl *ast.FuncDecl) {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 || len(decl.Recv.List[0].Names) == 0 || decl.Body == nil {
+		return
+	}
+	field := decl.Recv.List[0]
+	recv, ok := pass.TypesInfo.Defs[field.Names[0]].(*types.Var)
+	if !ok {
+		return
+	}
+	if _, ok := recv.Type().Underlying().(*types.Pointer); ok {
+		return
+	}
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false // a closure may be called after the method returns
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := astutil.Unparen(call.Fun).(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		selection, ok := pass.TypesInfo.Selections[sel]
+		if !ok || selection.Kind() != types.MethodVal || selection.Indirect() {
+			return true
+		}
+		method, ok := selection.Obj().(*types.Func)
+		if !ok {
+			return true
+		}
+		if _, ok := unlocks[method.FullName()]; !ok {
+			return true
+		}
+		// The mutex must be reached from the receiver through
+		// field selections only, without pointer indirection.
+		x := sel.X
+		for {
+			if _, ok := pass.TypesInfo.TypeOf(x).Underlying().(*types.Pointer); ok {
+				return true
+			}
+			x = astutil.Unparen(x)
+			inner, ok := x.(*ast.SelectorExpr)
+			if !ok {
+				break
+			}
+			if s, ok := pass.TypesInfo.Selections[inner]; !ok || s.Kind() != types.FieldVal || s.Indirect() {
+				return true
+			}
+			x = inner.X
+		}
+		if id, ok := x.(*ast.Ident); !ok || pass.TypesInfo.Uses[id] != recv {
+			return true
+		}
+
+		mutex := types.ExprString(sel.X)
+		if len(selection.Index()) > 1 {
+			// A method promoted from an embedded mutex.
+			mutex += "." + method.Type().(*types.Signature).Recv().Type().(*types.Pointer).Elem().(*types.Named).Obj().Name()
+		}
+		pass.Report(analysis.Diagnostic{
+			Pos:     call.Pos(),
+			End:     call.End(),
+			Message: fmt.Sprintf("%s locks a copy of %s, as method %s has a value receiver", types.ExprString(sel), mutex, decl.Name.Name),
+			SuggestedFixes: []analysis.SuggestedFix{{
+				Message: "Use a pointer receiver",
+				TextEdits: []analysis.TextEdit{{
+					Pos:     field.Type.Pos(),
+					End:     field.Type.Pos(),
+					NewText: []byte("*"),
+				}},
+			}},
+		})
+		return true
+	})
+}
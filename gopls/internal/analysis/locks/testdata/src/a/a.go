@@ -0,0 +1,192 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package a
+
+import (
+	"errors"
+	"sync"
+)
+
+type cache struct {
+	mu    sync.Mutex
+	rw    sync.RWMutex
+	items map[string]int
+}
+
+func (c *cache) earlyReturn(key string) (int, error) {
+	c.mu.Lock() // want `c.mu is not released on every path: earlyReturn may return with it held at line 22`
+	v, ok := c.items[key]
+	if !ok {
+		return 0, errors.New("missing")
+	}
+	c.mu.Unlock()
+	return v, nil
+}
+
+func (c *cache) readLock(key string) int {
+	c.rw.RLock() // want `c.rw is not released on every path`
+	if key == "" {
+		return 0
+	}
+	defer c.mu.Unlock() // a different mutex
+	c.rw.RUnlock()
+	return c.items[key]
+}
+
+func (c *cache) allPaths(key string) int {
+	c.mu.Lock()
+	v, ok := c.items[key]
+	if !ok {
+		c.mu.Unlock()
+		return 0
+	}
+	c.mu.Unlock()
+	return v
+}
+
+func (c *cache) deferred(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key == "" {
+		return 0
+	}
+	return c.items[key]
+}
+
+func (c *cache) deferredClosure(key string) int {
+	c.mu.Lock()
+	defer func() {
+		c.mu.Unlock()
+	}()
+	if key == "" {
+		return 0
+	}
+	return c.items[key]
+}
+
+// lock acquires the lock for its caller.
+func (c *cache) lock() {
+	c.mu.Lock()
+}
+
+func (c *cache) conditional(key string, locked bool) int {
+	if locked {
+		c.mu.Lock()
+	}
+	v := c.items[key]
+	if locked {
+		c.mu.Unlock()
+	}
+	return v
+}
+
+func (c *cache) loop(keys []string) int {
+	total := 0
+	for _, key := range keys {
+		c.mu.Lock()
+		total += c.items[key]
+		c.mu.Unlock()
+	}
+	return total
+}
+
+func (c *cache) switchCase(key string) int {
+	c.mu.Lock() // want `c.mu is not released on every path: switchCase may return with it held at line 105`
+	switch key {
+	case "a":
+		c.mu.Unlock()
+		return 1
+	case "b":
+		c.mu.Unlock()
+		return 2
+	}
+	return 0
+}
+
+var global sync.Mutex
+
+func implicitReturn(b bool) {
+	global.Lock() // want `global is not released on every path: implicitReturn may return with it held at line 115`
+	if b {
+		global.Unlock()
+	}
+}
+
+func panics(m map[string]int) int {
+	global.Lock()
+	v, ok := m[""]
+	if !ok {
+		panic("missing")
+	}
+	global.Unlock()
+	return v
+}
+
+type counter struct {
+	sync.Mutex
+	n int
+}
+
+func (c counter) get() int {
+	c.Lock() // want `c.Lock locks a copy of c.Mutex, as method get has a value receiver`
+	defer c.Unlock()
+	return c.n
+}
+
+type stats struct {
+	mu  sync.RWMutex
+	ptr *sync.Mutex
+	n   int
+}
+
+func (s stats) read() int {
+	s.mu.RLock() // want `s.mu.RLock locks a copy of s.mu, as method read has a value receiver`
+	defer s.mu.RUnlock()
+	s.ptr.Lock() // ok: the mutex is shared
+	defer s.ptr.Unlock()
+	return s.n
+}
+
+func (s *stats) write(n int) {
+	s.mu.Lock()
+	s.n = n
+	s.mu.Unlock()
+}
+
+// rlock returns a function that releases the read lock, which is
+// held on return.
+func (s *stats) rlock(fast bool) func() {
+	s.mu.RLock()
+	if fast {
+		return s.mu.RUnlock
+	}
+	s.mu.RUnlock()
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+// hold holds the lock until release is called, if keep is set.
+func (s *stats) hold(keep bool) int {
+	s.mu.Lock()
+	if keep {
+		s.n++
+		return s.n
+	}
+	s.release()
+	return 0
+}
+
+func (s *stats) release() {
+	s.mu.Unlock()
+}
+
+func (s *stats) handOff() (int, func()) {
+	s.mu.Lock()
+	if s.n == 0 {
+		s.mu.Unlock()
+		return 0, nil
+	}
+	return s.n, s.release
+}
@@ -0,0 +1,36 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package naming defines an analyzer that checks the names of
+// declarations against Go naming conventions.
+//
+// # Analyzer naming
+//
+// naming: check names against Go naming conventions
+//
+// The naming analyzer reports declared names that do not follow the
+// conventions of Go code:
+//
+//   - names should use MixedCaps rather than underscores, as in
+//     maxLength rather than max_length or MAX_LENGTH;
+//   - initialisms and acronyms, such as ID, URL, and HTTP, should have
+//     a consistent case, as in userID or ServeHTTP rather than userId
+//     or ServeHttp;
+//   - names should not be longer than a configurable limit.
+//
+// The names of test functions, such as Test_parse or Example_basic,
+// may contain underscores.
+//
+// Each report suggests a fix that renames the declaration and all of
+// its references, when the name is not too long.
+//
+// The analyzer is configured by its flags, which gopls sets from the
+// analyzerFlags setting:
+//
+//   - initialisms: a comma-separated list of initialisms, such as
+//     "GRPC,K8S", in addition to the common ones;
+//   - underscores: whether to report underscores in names (default
+//     true);
+//   - maxlen: the maximum length of a name, or 0 for no limit.
+package naming
@@ -0,0 +1,281 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package naming
+
+import (
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/internal/analysisinternal"
+)
+
+//go:embed doc.go
+var doc string
+
+var Analyzer = &analysis.Analyzer{
+	Name: "naming",
+	Doc:  analysisinternal.MustExtractDoc(doc, "naming"),
+	Run:  run,
+	URL:  "https://pkg.go.dev/golang.org/x/tools/gopls/internal/analysis/naming",
+}
+
+const FixCategory = "naming" // recognized by gopls ApplyFix
+
+var (
+	initialisms = initialismsFlag{}
+	underscores = true
+	maxLen      = 0
+)
+
+func init() {
+	Analyzer.Flags.Var(initialisms, "initialisms", "comma-separated list of initialisms, such as GRPC, in addition to the common ones")
+	Analyzer.Flags.BoolVar(&underscores, "underscores", underscores, "whether to report underscores in names")
+	Analyzer.Flags.IntVar(&maxLen, "maxlen", maxLen, "maximum length of a name, or 0 for no limit")
+}
+
+// commonInitialisms are the initialisms whose case should be
+// consistent in names, as in the go/lint tool.
+var commonInitialisms = [...]string{
+	"ACL", "API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID", "HTML", "HTTP",
+	"HTTPS", "ID", "IP", "JSON", "LHS", "QPS", "RAM", "RHS", "RPC", "SLA",
+	"SMTP", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "UID", "UUID",
+	"URI", "URL", "UTF8", "VM", "XML", "XMPP", "XSRF", "XSS",
+}
+
+// initialismsFlag is the set of additional initialisms of the
+// -initialisms flag. Like the -funcs flag of printf, it accumulates
+// the values with which it is set.
+type initialismsFlag map[string]bool
+
+func (f initialismsFlag) String() string {
+	var list []string
+	for s := range f {
+		list = append(list, s)
+	}
+	return strings.Join(list, ",")
+}
+
+func (f initialismsFlag) Set(value string) error {
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			f[strings.ToUpper(s)] = true
+		}
+	}
+	return nil
+}
+
+func isInitialism(word string) bool {
+	for _, s := range commonInitialisms {
+		if s == word {
+			return true
+		}
+	}
+	return initialisms[word]
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	for _, file := range pass.Files {
+		isTestFile := strings.HasSuffix(pass.Fset.File(file.Pos()).Name(), "_test.go")
+		ast.Inspect(file, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			obj := pass.TypesInfo.Defs[id]
+			switch obj := obj.(type) {
+			case nil, *types.PkgName:
+				return true
+			case *types.Var:
+				if obj.Embedded() {
+					return true
+				}
+			case *types.Func:
+				if isTestFile && isTestFunc(obj) {
+					return true
+				}
+			}
+			check(pass, id, obj)
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// check reports the identifier id that declares obj if its name does
+// not follow the conventions.
+func check(pass *analysis.Pass, id *ast.Ident, obj types.Object) {
+	name := id.Name
+	if strings.HasPrefix(name, "_") {
+		return // blank, or a special name such as those of cgo
+	}
+	kind := kindOf(obj)
+	if maxLen > 0 && utf8.RuneCountInString(name) > maxLen {
+		pass.Report(analysis.Diagnostic{
+			Pos:     id.Pos(),
+			End:     id.End(),
+			Message: fmt.Sprintf("%s name %s is longer than %d characters", kind, name, maxLen),
+		})
+		return
+	}
+	if underscores && isAllCaps(name) {
+		pass.Report(analysis.Diagnostic{
+			Pos:     id.Pos(),
+			End:     id.End(),
+			Message: fmt.Sprintf("%s name %s should use MixedCaps, not ALL_CAPS", kind, name),
+		})
+		return
+	}
+	want := Conventional(name)
+	if want == name {
+		return
+	}
+	msg := fmt.Sprintf("%s name %s should be %s", kind, name, want)
+	if strings.Contains(name, "_") && !strings.Contains(want, "_") {
+		msg = fmt.Sprintf("%s name %s should use MixedCaps, as in %s", kind, name, want)
+	}
+	pass.Report(analysis.Diagnostic{
+		Pos:      id.Pos(),
+		End:      id.End(),
+		Message:  msg,
+		Category: FixCategory,
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message: fmt.Sprintf("Rename %s to %s", name, want),
+			// No TextEdits => computed by gopls command
+		}},
+	})
+}
+
+// Conventional returns the name that follows the conventions of Go
+// code, as configured by the flags of the analyzer, in place of the
+// given one.
+func Conventional(name string) string {
+	if !underscores {
+		// Fix the case of the initialisms of each part.
+		parts := strings.Split(name, "_")
+		for i, part := range parts {
+			parts[i] = mixedCaps(part)
+		}
+		return strings.Join(parts, "_")
+	}
+	return mixedCaps(name)
+}
+
+// mixedCaps returns name with underscores removed and initialisms in
+// consistent case. It is based on lintName in golang.org/x/lint.
+func mixedCaps(name string) string {
+	allLower := true
+	for _, r := range name {
+		if !unicode.IsLower(r) {
+			allLower = false
+			break
+		}
+	}
+	if allLower {
+		return name
+	}
+
+	// Split the name at each lower to non-lower transition and at
+	// underscores, and check each word for an initialism.
+	runes := []rune(name)
+	w, i := 0, 0 // start of the word, scan
+	for i+1 <= len(runes) {
+		eow := false // end of the word
+		if i+1 == len(runes) {
+			eow = true
+		} else if runes[i+1] == '_' {
+			// Remove the run of underscores, leaving one between two
+			// digits.
+			eow = true
+			n := 1
+			for i+n+1 < len(runes) && runes[i+n+1] == '_' {
+				n++
+			}
+			if i+n+1 < len(runes) && unicode.IsDigit(runes[i]) && unicode.IsDigit(runes[i+n+1]) {
+				n--
+			}
+			copy(runes[i+1:], runes[i+n+1:])
+			runes = runes[:len(runes)-n]
+		} else if unicode.IsLower(runes[i]) && !unicode.IsLower(runes[i+1]) {
+			eow = true
+		}
+		i++
+		if !eow {
+			continue
+		}
+
+		word := string(runes[w:i])
+		if u := strings.ToUpper(word); isInitialism(u) {
+			// The case of an initialism is lower only at the start.
+			if w == 0 && unicode.IsLower(runes[w]) {
+				u = strings.ToLower(u)
+			}
+			copy(runes[w:], []rune(u))
+		} else if w > 0 && strings.ToLower(word) == word {
+			// A lowercase word after the first starts a new word.
+			runes[w] = unicode.ToUpper(runes[w])
+		}
+		w = i
+	}
+	return string(runes)
+}
+
+// isAllCaps reports whether name is of the form MAX_LENGTH.
+func isAllCaps(name string) bool {
+	if !strings.Contains(name, "_") {
+		return false
+	}
+	for _, r := range name {
+		if unicode.IsLower(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTestFunc reports whether fn is a test, benchmark, example, or fuzz
+// function, whose name may contain underscores.
+func isTestFunc(fn *types.Func) bool {
+	if fn.Type().(*types.Signature).Recv() != nil {
+		return false
+	}
+	for _, prefix := range [...]string{"Test", "Benchmark", "Example", "Fuzz"} {
+		if strings.HasPrefix(fn.Name(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// kindOf returns a word describing the kind of obj.
+func kindOf(obj types.Object) string {
+	switch obj := obj.(type) {
+	case *types.Const:
+		return "const"
+	case *types.TypeName:
+		if _, ok := obj.Type().(*types.TypeParam); ok {
+			return "type parameter"
+		}
+		return "type"
+	case *types.Func:
+		if obj.Type().(*types.Signature).Recv() != nil {
+			return "method"
+		}
+		return "func"
+	case *types.Var:
+		if obj.IsField() {
+			return "field"
+		}
+		return "var"
+	case *types.Label:
+		return "label"
+	}
+	return "name"
+}
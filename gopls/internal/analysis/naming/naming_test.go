@@ -0,0 +1,49 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package naming_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/gopls/internal/analysis/naming"
+)
+
+func Test(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, naming.Analyzer, "a")
+}
+
+func TestFlags(t *testing.T) {
+	for _, f := range [][2]string{{"initialisms", "grpc,k8s"}, {"underscores", "false"}, {"maxlen", "12"}} {
+		if err := naming.Analyzer.Flags.Set(f[0], f[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func() {
+		naming.Analyzer.Flags.Set("underscores", "true")
+		naming.Analyzer.Flags.Set("maxlen", "0")
+	}()
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, naming.Analyzer, "b")
+}
+
+func TestConventional(t *testing.T) {
+	for _, test := range []struct{ name, want string }{
+		{"foo", "foo"},
+		{"foo_bar", "fooBar"},
+		{"fooId", "fooID"},
+		{"Url", "URL"},
+		{"urlString", "urlString"},
+		{"HttpsServer", "HTTPSServer"},
+		{"jsonApi_v2", "jsonAPIV2"},
+		{"v1_2", "v1_2"},
+		{"IDs", "IDs"},
+	} {
+		if got := naming.Conventional(test.name); got != test.want {
+			t.Errorf("Conventional(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
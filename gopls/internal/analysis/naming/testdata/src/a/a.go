@@ -0,0 +1,33 @@
+package a
+
+import "net/http"
+
+const MAX_LENGTH = 10 // want "const name MAX_LENGTH should use MixedCaps, not ALL_CAPS"
+
+const maxLength = 10
+
+type userInfo struct {
+	UserId  int    // want "field name UserId should be UserID"
+	HomeUrl string // want "field name HomeUrl should be HomeURL"
+	http.Handler
+}
+
+func (u *userInfo) ServeHttp(w http.ResponseWriter, r *http.Request) {} // want "method name ServeHttp should be ServeHTTP"
+
+func parse_json(data []byte) (json_data any) { // want "func name parse_json should use MixedCaps, as in parseJSON" "var name json_data should use MixedCaps, as in jsonData"
+	var id, uid int
+	var Id int // want "var name Id should be ID"
+	var urlStr, xmlHTTPRequest string
+	_, _, _, _, _ = id, uid, Id, urlStr, xmlHTTPRequest
+	return nil
+}
+
+func _() {
+	_ = func(_cgo int) {}
+	for i_1 := 0; i_1 < 1; i_1++ { // want "var name i_1 should use MixedCaps, as in i1"
+	}
+	for v1_2 := 0; v1_2 < 1; v1_2++ {
+	}
+}
+
+func Map[Elem_type any](x Elem_type) {} // want "type parameter name Elem_type should use MixedCaps, as in ElemType"
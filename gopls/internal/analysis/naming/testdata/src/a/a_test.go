@@ -0,0 +1,9 @@
+package a
+
+import "testing"
+
+func Test_parse(t *testing.T) {}
+
+func Example_basic() {}
+
+func helper_func() {} // want "func name helper_func should use MixedCaps, as in helperFunc"
@@ -0,0 +1,11 @@
+package b
+
+type grpcClient struct{} // initialisms at the start are lowercase
+
+type MyGrpcConn struct{} // want "type name MyGrpcConn should be MyGRPCConn"
+
+var k8s_Id int // want "var name k8s_Id should be k8s_ID"
+
+var MAX_LENGTH = 1
+
+var configurationFileName string // want "var name configurationFileName is longer than 12 characters"
@@ -0,0 +1,42 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package structlayout defines an analyzer that reports struct types
+// whose fields could be reordered to use less memory.
+//
+// # Analyzer structlayout
+//
+// structlayout: find structs whose fields could be reordered to reduce padding
+//
+// The structlayout analyzer reports the declaration of each named
+// struct type whose size could be reduced by placing its fields in a
+// different order, for example:
+//
+//	type T struct { // struct of size 12 could be 8
+//		a byte
+//		b int32
+//		c byte
+//	}
+//
+// Its suggested fix, "Optimize layout", moves each field together with
+// its doc comment and line comment, and keeps fields that are declared
+// together (as in "x, y int") together. The fix is not offered when the
+// struct contains comments that belong to no field.
+//
+// Unlike the fieldalignment analyzer, structlayout is concerned only
+// with size, not with the number of pointer bytes, and it does not
+// report structs whose field order may be significant:
+//
+//   - structs declared in files that import "C", whose layout may need
+//     to match a C declaration;
+//   - structs with blank (_) fields, which typically denote explicit
+//     padding; and
+//   - structs with a field tag for an encoding that depends on field
+//     order, such as `binary`, `asn1`, `struc`, or `xdr`.
+//
+// Be aware that the most compact order is not always the most
+// efficient: it may separate fields that are accessed together, or
+// cause two fields updated by different goroutines to share a cache
+// line.
+package structlayout
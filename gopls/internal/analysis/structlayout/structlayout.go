@@ -0,0 +1,233 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package structlayout
+
+import (
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/internal/analysisinternal"
+)
+
+//go:embed doc.go
+var doc string
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "structlayout",
+	Doc:      analysisinternal.MustExtractDoc(doc, "structlayout"),
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+	URL:      "https://pkg.go.dev/golang.org/x/tools/gopls/internal/analysis/structlayout",
+}
+
+// orderedTags are the keys of struct tags used by encodings that
+// depend on the order of fields.
+var orderedTags = []string{"asn1", "binary", "struc", "xdr"}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	cgo := make(map[*token.File]bool) // files that import "C"
+	for _, f := range pass.Files {
+		for _, spec := range f.Imports {
+			if path, _ := strconv.Unquote(spec.Path.Value); path == "C" {
+				cgo[pass.Fset.File(f.Pos())] = true
+			}
+		}
+	}
+
+	inspect.WithStack([]ast.Node{(*ast.TypeSpec)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		spec := n.(*ast.TypeSpec)
+		node, ok := spec.Type.(*ast.StructType)
+		if !ok || cgo[pass.Fset.File(spec.Pos())] || orderSensitive(node) {
+			return true
+		}
+		typ, ok := pass.TypesInfo.TypeOf(node).(*types.Struct)
+		if !ok {
+			return true
+		}
+		order := optimalOrder(pass.TypesInfo, pass.TypesSizes, node.Fields.List)
+		size, optsize := pass.TypesSizes.Sizeof(typ), pass.TypesSizes.Sizeof(reorder(typ, node.Fields.List, order))
+		if optsize >= size {
+			return true
+		}
+		diag := analysis.Diagnostic{
+			Pos:     node.Struct,
+			End:     node.Struct + token.Pos(len("struct")),
+			Message: fmt.Sprintf("struct of size %d could be %d", size, optsize),
+		}
+		file := stack[0].(*ast.File)
+		if edit, ok := rearrange(pass, file, node, order); ok {
+			diag.SuggestedFixes = []analysis.SuggestedFix{{
+				Message:   "Optimize layout",
+				TextEdits: []analysis.TextEdit{edit},
+			}}
+		}
+		pass.Report(diag)
+		return true
+	})
+	return nil, nil
+}
+
+// orderSensitive reports whether the order of the struct's fields may
+// be significant, because it has blank (padding) fields or tags for an
+// order-dependent encoding.
+func orderSensitive(node *ast.StructType) bool {
+	for _, field := range node.Fields.List {
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				return true
+			}
+		}
+		if field.Tag != nil {
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return true // conservatively
+			}
+			for _, key := range orderedTags {
+				if _, ok := reflect.StructTag(tag).Lookup(key); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// optimalOrder returns a permutation of the field declarations that
+// minimizes padding. All the fields of a declaration share a type, so
+// declarations are sorted as units: zero-sized fields first (a
+// trailing one would require padding), then by decreasing alignment,
+// then by decreasing size. The sort is stable so that equivalent
+// fields keep their relative order.
+func optimalOrder(info *types.Info, sizes types.Sizes, fields []*ast.Field) []int {
+	type elem struct {
+		index           int
+		alignof, sizeof int64
+	}
+	elems := make([]elem, len(fields))
+	for i, field := range fields {
+		t := info.TypeOf(field.Type)
+		elems[i] = elem{i, sizes.Alignof(t), sizes.Sizeof(t)}
+	}
+	sort.SliceStable(elems, func(i, j int) bool {
+		ei, ej := elems[i], elems[j]
+		if zeroi, zeroj := ei.sizeof == 0, ej.sizeof == 0; zeroi != zeroj {
+			return zeroi
+		}
+		if ei.alignof != ej.alignof {
+			return ei.alignof > ej.alignof
+		}
+		return ei.sizeof > ej.sizeof
+	})
+	order := make([]int, len(elems))
+	for i, e := range elems {
+		order[i] = e.index
+	}
+	return order
+}
+
+// reorder returns the struct type whose fields are those of typ, with
+// the field declarations permuted by order.
+func reorder(typ *types.Struct, fields []*ast.Field, order []int) *types.Struct {
+	// Compute the index in typ of the first field of each declaration.
+	start := make([]int, len(fields))
+	n := 0
+	for i, field := range fields {
+		start[i] = n
+		n += numFields(field)
+	}
+	var vars []*types.Var
+	for _, i := range order {
+		for j := 0; j < numFields(fields[i]); j++ {
+			vars = append(vars, typ.Field(start[i]+j))
+		}
+	}
+	return types.NewStruct(vars, nil)
+}
+
+// numFields returns the number of fields declared by field.
+func numFields(field *ast.Field) int {
+	if len(field.Names) == 0 {
+		return 1 // embedded
+	}
+	return len(field.Names)
+}
+
+// rearrange returns an edit that reorders the field declarations of
+// the struct, each with its comments. It fails if the struct contains
+// comments that are attached to no field, or if a line holds more than
+// one field.
+func rearrange(pass *analysis.Pass, file *ast.File, node *ast.StructType, order []int) (analysis.TextEdit, bool) {
+	tokFile := pass.Fset.File(node.Pos())
+	content, err := pass.ReadFile(tokFile.Name())
+	if err != nil {
+		return analysis.TextEdit{}, false
+	}
+	offset := func(pos token.Pos) int { return tokFile.Offset(pos) }
+	line := func(pos token.Pos) int { return tokFile.Line(pos) }
+
+	// Compute the extent of each field declaration with its comments.
+	fields := node.Fields.List
+	type extent struct{ start, end token.Pos }
+	extents := make([]extent, len(fields))
+	attached := make(map[*ast.CommentGroup]bool)
+	for i, field := range fields {
+		ext := extent{field.Pos(), field.End()}
+		if field.Doc != nil {
+			ext.start = field.Doc.Pos()
+			attached[field.Doc] = true
+		}
+		if field.Comment != nil {
+			ext.end = field.Comment.End()
+			attached[field.Comment] = true
+		}
+		if i > 0 && line(ext.start) == line(extents[i-1].end) {
+			return analysis.TextEdit{}, false // fields share a line
+		}
+		extents[i] = ext
+	}
+	for _, cg := range file.Comments {
+		if node.Fields.Opening < cg.Pos() && cg.End() <= node.Fields.Closing && !attached[cg] {
+			return analysis.TextEdit{}, false // free-floating comment
+		}
+	}
+	if line(node.Fields.Opening) == line(node.Fields.Closing) {
+		return analysis.TextEdit{}, false // single-line struct
+	}
+
+	// Preserve the indentation of the fields and the closing brace.
+	indent := func(pos token.Pos) string {
+		start := offset(tokFile.LineStart(line(pos)))
+		text := string(content[start:offset(pos)])
+		return text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+	}
+	var buf strings.Builder
+	buf.WriteString("{\n")
+	for _, i := range order {
+		buf.WriteString(indent(extents[i].start))
+		buf.Write(content[offset(extents[i].start):offset(extents[i].end)])
+		buf.WriteString("\n")
+	}
+	buf.WriteString(indent(node.Fields.Closing))
+	return analysis.TextEdit{
+		Pos:     node.Fields.Opening,
+		End:     node.Fields.Closing,
+		NewText: []byte(buf.String()),
+	}, true
+}
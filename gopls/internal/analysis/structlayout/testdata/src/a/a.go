@@ -0,0 +1,81 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package a
+
+type Bad struct { // want "struct of size 12 could be 8"
+	a byte
+	b int32
+	c byte
+}
+
+type Good struct {
+	b int32
+	a byte
+	c byte
+}
+
+// Comments move with their fields.
+type Commented struct { // want "struct of size 12 could be 8"
+	// flag is a flag.
+	flag bool
+
+	// count counts things.
+	// It has a two-line comment.
+	count int32 // never negative
+
+	x, y int8 // coordinates
+}
+
+type Embedded struct { // want "struct of size 16 could be 12"
+	bool
+	Good
+	uint8
+}
+
+type Empty struct { // want "struct of size 8 could be 4"
+	n int32
+	z [0]int32
+}
+
+// No fix: the struct has a comment attached to no field.
+type Floating struct { // want "struct of size 12 could be 8"
+	a byte
+
+	// Section.
+
+	b int32
+	c byte
+}
+
+// No fix: fields share a line.
+type Shared struct { // want "struct of size 12 could be 8"
+	a byte; b int32
+	c byte
+}
+
+type Padded struct {
+	a byte
+	_ [3]byte
+	b int32
+	c byte
+}
+
+type Tagged struct {
+	a byte  `binary:"a"`
+	b int32 `binary:"b"`
+	c byte  `binary:"c"`
+}
+
+type JSON struct { // want "struct of size 12 could be 8"
+	A byte  `json:"a"`
+	B int32 `json:"b"`
+	C byte  `json:"c"`
+}
+
+var _ = struct { // ok: not a named type
+	a byte
+	b int32
+	c byte
+}{}
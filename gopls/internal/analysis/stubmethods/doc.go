@@ -25,13 +25,19 @@
 //
 //	type NegativeErr struct{}
 //
-// This analyzer will suggest a fix to declare this method:
+// This analyzer will suggest a fix, "Implement error for NegativeErr",
+// to declare this method:
 //
-//	// Error implements error.Error.
-//	func (NegativeErr) Error() string {
+//	// Error implements error.
+//	func (n NegativeErr) Error() string {
 //		panic("unimplemented")
 //	}
 //
+// The receiver of each new method is named consistently with the
+// existing methods of the type. If the interface is an instance of
+// a generic interface, such as Getter[int], the type arguments are
+// substituted in the signatures of the new methods.
+//
 // (At least, it appears to behave that way, but technically it
 // doesn't use the SuggestedFix mechanism and the stub is created by
 // logic in gopls's golang.stub function.)
@@ -85,14 +85,18 @@ func DiagnosticForError(fset *token.FileSet, file *ast.File, start, end token.Po
 		return analysis.Diagnostic{}, false
 	}
 	qf := typesutil.FileQualifier(file, si.Concrete.Obj().Pkg(), info)
-	iface := types.TypeString(si.Interface.Type(), qf)
+	iface := types.TypeString(si.Interface, qf)
+	conc := types.TypeString(si.Concrete, qf)
+	if si.Pointer {
+		conc = "*" + conc
+	}
 	return analysis.Diagnostic{
 		Pos:      start,
 		End:      end,
 		Message:  msg,
 		Category: FixCategory,
 		SuggestedFixes: []analysis.SuggestedFix{{
-			Message: fmt.Sprintf("Declare missing methods of %s", iface),
+			Message: fmt.Sprintf("Implement %s for %s", iface, conc),
 			// No TextEdits => computed later by gopls.
 		}},
 	}, true
@@ -104,14 +108,14 @@ const FixCategory = "stubmethods" // recognized by gopls ApplyFix
 // that wants to stub out an interface type
 type StubInfo struct {
 	// Interface is the interface that the client wants to implement.
-	// When the interface is defined, the underlying object will be a TypeName.
-	// Note that we keep track of types.Object instead of types.Type in order
-	// to keep a reference to the declaring object's package and the ast file
-	// in the case where the concrete type file requires a new import that happens to be renamed
-	// in the interface file.
+	// If the interface is generic, it is the instantiated type
+	// (e.g. Getter[int]), so that the methods to be stubbed have
+	// their type parameters substituted by the type arguments.
+	// The declaring object, Interface.Obj(), provides a reference
+	// to the interface's package.
 	// TODO(marwan-at-work): implement interface literals.
 	Fset      *token.FileSet // the FileSet used to type-check the types below
-	Interface *types.TypeName
+	Interface *types.Named
 	Concrete  *types.Named
 	Pointer   bool
 }
@@ -195,7 +199,7 @@ func fromCallExpr(fset *token.FileSet, info *types.Info, pos token.Pos, call *as
 	if paramType == nil {
 		return nil // A type error prevents us from determining the param type.
 	}
-	iface := ifaceObjFromType(paramType)
+	iface := ifaceFromType(paramType)
 	if iface == nil {
 		return nil
 	}
@@ -335,15 +339,17 @@ func fromAssignStmt(fset *token.FileSet, info *types.Info, assign *ast.AssignStm
 }
 
 // ifaceType returns the named interface type to which e refers, if any.
-func ifaceType(e ast.Expr, info *types.Info) *types.TypeName {
+func ifaceType(e ast.Expr, info *types.Info) *types.Named {
 	tv, ok := info.Types[e]
 	if !ok {
 		return nil
 	}
-	return ifaceObjFromType(tv.Type)
+	return ifaceFromType(tv.Type)
 }
 
-func ifaceObjFromType(t types.Type) *types.TypeName {
+// ifaceFromType returns the named interface type t, if any.
+// Instances of generic interfaces are returned as is, not as their origin.
+func ifaceFromType(t types.Type) *types.Named {
 	named, ok := aliases.Unalias(t).(*types.Named)
 	if !ok {
 		return nil
@@ -357,7 +363,7 @@ func ifaceObjFromType(t types.Type) *types.TypeName {
 	if named.Obj().Pkg() == nil && named.Obj().Name() != "error" {
 		return nil
 	}
-	return named.Obj()
+	return named
 }
 
 // concreteType tries to extract the *types.Named that defines
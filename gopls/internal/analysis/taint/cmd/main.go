@@ -0,0 +1,16 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The taint command runs the sqlinjection, cmdinjection, and
+// pathinjection analyzers.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/multichecker"
+	"golang.org/x/tools/gopls/internal/analysis/taint"
+)
+
+func main() {
+	multichecker.Main(taint.SQLAnalyzer, taint.CommandAnalyzer, taint.PathAnalyzer)
+}
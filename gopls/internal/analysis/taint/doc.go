@@ -0,0 +1,64 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package taint defines analyzers that report flows of untrusted
+// input to security-sensitive operations.
+//
+// The analyzers share a taint analysis of the SSA form of each
+// function. Values obtained from an HTTP request (such as the result
+// of r.FormValue, or the r.URL, r.Header, r.Form, and r.Body fields of
+// an *http.Request) or from the environment (os.Getenv, os.LookupEnv,
+// os.Environ) are tainted, and so is any string-like value computed
+// from a tainted one: by concatenation, conversion, or a call to
+// which a tainted value is passed, such as fmt.Sprintf or
+// strings.Join. Numbers and booleans are never tainted, so for
+// example the result of strconv.Atoi is considered safe, as are the
+// results of filepath.Base and path.Base.
+//
+// The analysis is intraprocedural: it does not follow tainted values
+// into the functions to which they are passed, nor through variables
+// captured by closures.
+//
+// Each diagnostic explains how the untrusted input reaches the
+// operation, starting from its source.
+//
+// In gopls, these analyzers are disabled by default. When enabled,
+// they run only on packages whose files are all saved, since the
+// analysis is too costly to repeat on every edit.
+//
+// # Analyzer sqlinjection
+//
+// sqlinjection: check for SQL queries built from untrusted input
+//
+// The sqlinjection analyzer reports calls to the Query, QueryRow,
+// Exec, and Prepare methods (and their Context variants) of the DB,
+// Tx, and Conn types of database/sql whose query string depends on
+// untrusted input, for example:
+//
+//	name := r.FormValue("name")
+//	db.Query("SELECT * FROM users WHERE name = '" + name + "'")
+//
+// Use query parameters instead:
+//
+//	db.Query("SELECT * FROM users WHERE name = ?", name)
+//
+// # Analyzer cmdinjection
+//
+// cmdinjection: check for commands built from untrusted input
+//
+// The cmdinjection analyzer reports calls to exec.Command,
+// exec.CommandContext, and os.StartProcess whose program name or
+// arguments depend on untrusted input.
+//
+// # Analyzer pathinjection
+//
+// pathinjection: check for file paths built from untrusted input
+//
+// The pathinjection analyzer reports calls to functions of the os
+// package that open, create, or remove files (such as os.Open,
+// os.ReadFile, and os.RemoveAll), and to http.ServeFile, whose file
+// name depends on untrusted input, which may allow access to files
+// outside the intended directory. Reduce the input to a single path
+// element with filepath.Base, or check it with filepath.IsLocal.
+package taint
@@ -0,0 +1,434 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package taint
+
+import (
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/internal/analysisinternal"
+)
+
+//go:embed doc.go
+var doc string
+
+const url = "https://pkg.go.dev/golang.org/x/tools/gopls/internal/analysis/taint"
+
+var SQLAnalyzer = &analysis.Analyzer{
+	Name:     "sqlinjection",
+	Doc:      analysisinternal.MustExtractDoc(doc, "sqlinjection"),
+	Requires: []*analysis.Analyzer{flowAnalyzer},
+	Run:      sinkRunner("SQL query", sqlSinks),
+	URL:      url,
+}
+
+var CommandAnalyzer = &analysis.Analyzer{
+	Name:     "cmdinjection",
+	Doc:      analysisinternal.MustExtractDoc(doc, "cmdinjection"),
+	Requires: []*analysis.Analyzer{flowAnalyzer},
+	Run:      sinkRunner("command", commandSinks),
+	URL:      url,
+}
+
+var PathAnalyzer = &analysis.Analyzer{
+	Name:     "pathinjection",
+	Doc:      analysisinternal.MustExtractDoc(doc, "pathinjection"),
+	Requires: []*analysis.Analyzer{flowAnalyzer},
+	Run:      sinkRunner("file path", pathSinks),
+	URL:      url,
+}
+
+// flowAnalyzer computes the tainted values of each function of the
+// package, for use by the sink analyzers.
+var flowAnalyzer = &analysis.Analyzer{
+	Name:       "taintflow",
+	Doc:        "compute the flows of untrusted input within each function",
+	Requires:   []*analysis.Analyzer{buildssa.Analyzer},
+	Run:        runFlows,
+	ResultType: reflect.TypeOf((*flows)(nil)),
+}
+
+// Sinks map the full name of a function (see [types.Func.FullName])
+// to the indices of its sensitive parameters. For a method, the
+// receiver is parameter 0, as in SSA.
+var (
+	sqlSinks = map[string][]int{
+		"(*database/sql.DB).Exec":              {1},
+		"(*database/sql.DB).ExecContext":       {2},
+		"(*database/sql.DB).Prepare":           {1},
+		"(*database/sql.DB).PrepareContext":    {2},
+		"(*database/sql.DB).Query":             {1},
+		"(*database/sql.DB).QueryContext":      {2},
+		"(*database/sql.DB).QueryRow":          {1},
+		"(*database/sql.DB).QueryRowContext":   {2},
+		"(*database/sql.Tx).Exec":              {1},
+		"(*database/sql.Tx).ExecContext":       {2},
+		"(*database/sql.Tx).Prepare":           {1},
+		"(*database/sql.Tx).PrepareContext":    {2},
+		"(*database/sql.Tx).Query":             {1},
+		"(*database/sql.Tx).QueryContext":      {2},
+		"(*database/sql.Tx).QueryRow":          {1},
+		"(*database/sql.Tx).QueryRowContext":   {2},
+		"(*database/sql.Conn).ExecContext":     {2},
+		"(*database/sql.Conn).PrepareContext":  {2},
+		"(*database/sql.Conn).QueryContext":    {2},
+		"(*database/sql.Conn).QueryRowContext": {2},
+	}
+	commandSinks = map[string][]int{
+		"os/exec.Command":        {0, 1},
+		"os/exec.CommandContext": {1, 2},
+		"os.StartProcess":        {0, 1},
+	}
+	pathSinks = map[string][]int{
+		"os.Chmod":            {0},
+		"os.Create":           {0},
+		"os.DirFS":            {0},
+		"os.Mkdir":            {0},
+		"os.MkdirAll":         {0},
+		"os.Open":             {0},
+		"os.OpenFile":         {0},
+		"os.ReadDir":          {0},
+		"os.ReadFile":         {0},
+		"os.Remove":           {0},
+		"os.RemoveAll":        {0},
+		"os.Rename":           {0, 1},
+		"os.Symlink":          {0, 1},
+		"os.Truncate":         {0},
+		"os.WriteFile":        {0},
+		"io/ioutil.ReadDir":   {0},
+		"io/ioutil.ReadFile":  {0},
+		"io/ioutil.WriteFile": {0},
+		"net/http.ServeFile":  {2},
+	}
+)
+
+// Sources and sanitizers.
+var (
+	// sourceFuncs maps the full name of each function whose results
+	// are untrusted to the origin of the input.
+	sourceFuncs = map[string]origin{
+		"os.Environ":                          fromEnv,
+		"os.Getenv":                           fromEnv,
+		"os.LookupEnv":                        fromEnv,
+		"(*net/http.Request).Cookie":          fromRequest,
+		"(*net/http.Request).Cookies":         fromRequest,
+		"(*net/http.Request).FormFile":        fromRequest,
+		"(*net/http.Request).FormValue":       fromRequest,
+		"(*net/http.Request).MultipartReader": fromRequest,
+		"(*net/http.Request).PathValue":       fromRequest,
+		"(*net/http.Request).PostFormValue":   fromRequest,
+		"(*net/http.Request).Referer":         fromRequest,
+		"(*net/http.Request).UserAgent":       fromRequest,
+	}
+
+	// requestFields is the set of untrusted fields of net/http.Request.
+	requestFields = map[string]bool{
+		"Body":          true,
+		"Form":          true,
+		"Header":        true,
+		"Host":          true,
+		"MultipartForm": true,
+		"PostForm":      true,
+		"RequestURI":    true,
+		"Trailer":       true,
+		"URL":           true,
+	}
+
+	// sanitizers is the set of functions whose results are safe even
+	// if their arguments are not.
+	sanitizers = map[string]bool{
+		"path.Base":          true,
+		"path/filepath.Base": true,
+	}
+)
+
+// An origin describes where untrusted input comes from.
+type origin string
+
+const (
+	fromRequest origin = "an HTTP request"
+	fromEnv     origin = "the environment"
+)
+
+// A step is one step in the flow of untrusted input from its source.
+type step struct {
+	pos    token.Pos
+	msg    string
+	origin origin
+	prev   *step // nil for the source
+}
+
+// flows is the result of flowAnalyzer.
+type flows struct {
+	tainted map[ssa.Value]*step         // last step of each tainted value
+	funcs   []*ssa.Function             // the functions of the package
+	calls   map[token.Pos]*ast.CallExpr // calls, by position of their left parenthesis
+}
+
+// maxSteps bounds the number of steps reported as the related
+// information of a diagnostic.
+const maxSteps = 10
+
+func runFlows(pass *analysis.Pass) (any, error) {
+	ssainfo := pass.ResultOf[buildssa.Analyzer].(*buildssa.SSA)
+	result := &flows{
+		tainted: make(map[ssa.Value]*step),
+		funcs:   ssainfo.SrcFuncs,
+		calls:   make(map[token.Pos]*ast.CallExpr),
+	}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				result.calls[call.Lparen] = call
+			}
+			return true
+		})
+	}
+	for _, fn := range ssainfo.SrcFuncs {
+		result.analyze(fn)
+	}
+	return result, nil
+}
+
+// analyze computes the tainted values of fn, iterating to a fixed point.
+func (f *flows) analyze(fn *ssa.Function) {
+	for changed := true; changed; {
+		changed = false
+		taint := func(v ssa.Value, s *step) {
+			if v != nil && s != nil && f.tainted[v] == nil && carriesText(v.Type()) {
+				f.tainted[v] = s
+				changed = true
+			}
+		}
+		// operand returns the step of a tainted operand of instr, or nil.
+		var rands []*ssa.Value
+		operand := func(instr ssa.Instruction) *step {
+			rands = instr.Operands(rands[:0])
+			for _, v := range rands {
+				if s := f.tainted[*v]; s != nil {
+					return s
+				}
+			}
+			return nil
+		}
+
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				switch instr := instr.(type) {
+				case ssa.CallInstruction:
+					common := instr.Common()
+					name := calleeName(common)
+					var value ssa.Value // nil for go and defer
+					if call, ok := instr.(*ssa.Call); ok {
+						value = call
+					}
+					if o, ok := sourceFuncs[name]; ok {
+						taint(value, &step{pos: instr.Pos(), msg: "untrusted input from " + name, origin: o})
+						continue
+					}
+					if sanitizers[name] {
+						continue
+					}
+					if s := operand(instr); s != nil {
+						desc := name
+						if desc == "" {
+							desc = common.Description()
+						}
+						next := &step{pos: instr.Pos(), msg: "flows through call to " + desc, origin: s.origin, prev: s}
+						taint(value, next)
+						// The callee may store the input in the
+						// variables to which its arguments point,
+						// such as a strings.Builder.
+						if !isSink(name) {
+							for _, arg := range common.Args {
+								if _, ok := arg.Type().Underlying().(*types.Pointer); ok {
+									taint(root(arg), next)
+								}
+							}
+						}
+					}
+
+				case *ssa.FieldAddr:
+					if isRequest(instr.X.Type()) && requestFields[fieldName(instr.X.Type(), instr.Field)] {
+						taint(instr, requestField(instr.Pos(), fieldName(instr.X.Type(), instr.Field)))
+					} else {
+						taint(instr, operand(instr))
+					}
+
+				case *ssa.Field:
+					if isRequest(instr.X.Type()) && requestFields[fieldName(instr.X.Type(), instr.Field)] {
+						taint(instr, requestField(instr.Pos(), fieldName(instr.X.Type(), instr.Field)))
+					} else {
+						taint(instr, operand(instr))
+					}
+
+				case *ssa.BinOp:
+					if s := operand(instr); s != nil {
+						if instr.Op == token.ADD && instr.Pos().IsValid() {
+							s = &step{pos: instr.Pos(), msg: "flows into string concatenation", origin: s.origin, prev: s}
+						}
+						taint(instr, s)
+					}
+
+				case *ssa.Store:
+					taint(root(instr.Addr), f.tainted[instr.Val])
+
+				case *ssa.MapUpdate:
+					if s := f.tainted[instr.Key]; s != nil {
+						taint(root(instr.Map), s)
+					}
+					taint(root(instr.Map), f.tainted[instr.Value])
+
+				case *ssa.Send:
+					taint(root(instr.Chan), f.tainted[instr.X])
+
+				default:
+					if v, ok := instr.(ssa.Value); ok {
+						taint(v, operand(instr))
+					}
+				}
+			}
+		}
+	}
+}
+
+// requestField returns the source step of a field of an HTTP request.
+func requestField(pos token.Pos, name string) *step {
+	return &step{pos: pos, msg: fmt.Sprintf("untrusted input from field %s of net/http.Request", name), origin: fromRequest}
+}
+
+// isSink reports whether the named function is a sink of any analyzer.
+func isSink(name string) bool {
+	for _, sinks := range []map[string][]int{sqlSinks, commandSinks, pathSinks} {
+		if _, ok := sinks[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sinkRunner returns the Run function of an analyzer that reports
+// calls that pass tainted values for sensitive parameters of the
+// specified sink functions. The kind describes the sensitive values.
+func sinkRunner(kind string, sinks map[string][]int) func(*analysis.Pass) (any, error) {
+	return func(pass *analysis.Pass) (any, error) {
+		flows := pass.ResultOf[flowAnalyzer].(*flows)
+		for _, fn := range flows.funcs {
+			for _, b := range fn.Blocks {
+				for _, instr := range b.Instrs {
+					call, ok := instr.(ssa.CallInstruction)
+					if !ok {
+						continue
+					}
+					name := calleeName(call.Common())
+					for _, i := range sinks[name] {
+						if i >= len(call.Common().Args) {
+							continue
+						}
+						if s := flows.tainted[call.Common().Args[i]]; s != nil {
+							flows.report(pass, call, fmt.Sprintf("%s passed to %s depends on untrusted input from %s", kind, name, s.origin), s)
+							break
+						}
+					}
+				}
+			}
+		}
+		return nil, nil
+	}
+}
+
+// report reports a diagnostic at the specified call, with related
+// information that explains the flow of the input from its source.
+func (f *flows) report(pass *analysis.Pass, call ssa.CallInstruction, msg string, last *step) {
+	var steps []*step
+	for s := last; s != nil; s = s.prev {
+		steps = append(steps, s)
+	}
+	var related []analysis.RelatedInformation
+	for i := len(steps) - 1; i >= 0 && len(related) < maxSteps; i-- {
+		if s := steps[i]; s.pos.IsValid() {
+			related = append(related, analysis.RelatedInformation{Pos: s.pos, Message: s.msg})
+		}
+	}
+	start, end := call.Pos(), call.Pos()
+	if expr, ok := f.calls[call.Pos()]; ok {
+		start, end = expr.Pos(), expr.End()
+	}
+	pass.Report(analysis.Diagnostic{
+		Pos:     start,
+		End:     end,
+		Message: msg,
+		Related: related,
+	})
+}
+
+// calleeName returns the full name of the function or method called
+// by a static call or an interface method call, or "".
+func calleeName(common *ssa.CallCommon) string {
+	if common.IsInvoke() {
+		return common.Method.FullName()
+	}
+	if callee := common.StaticCallee(); callee != nil {
+		if obj, ok := callee.Object().(*types.Func); ok {
+			return obj.FullName()
+		}
+	}
+	return ""
+}
+
+// root returns the variable whose memory an address refers to, such
+// as x for &x.f[i].
+func root(v ssa.Value) ssa.Value {
+	for {
+		switch x := v.(type) {
+		case *ssa.FieldAddr:
+			v = x.X
+		case *ssa.IndexAddr:
+			v = x.X
+		case *ssa.Slice:
+			v = x.X
+		default:
+			return v
+		}
+	}
+}
+
+// carriesText reports whether a value of type t may hold untrusted
+// text. Numbers and booleans cannot.
+func carriesText(t types.Type) bool {
+	if basic, ok := t.Underlying().(*types.Basic); ok {
+		return basic.Info()&types.IsString != 0
+	}
+	return true
+}
+
+// isRequest reports whether t is net/http.Request or a pointer to it.
+func isRequest(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Name() == "Request" && obj.Pkg() != nil && obj.Pkg().Path() == "net/http"
+}
+
+// fieldName returns the name of the ith field of the struct type t,
+// or the struct type to which t points.
+func fieldName(t types.Type, i int) string {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	return t.Underlying().(*types.Struct).Field(i).Name()
+}
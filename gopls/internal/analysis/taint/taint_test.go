@@ -0,0 +1,19 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package taint_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/gopls/internal/analysis/taint"
+)
+
+func Test(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, taint.SQLAnalyzer, "sqlinjection")
+	analysistest.Run(t, testdata, taint.CommandAnalyzer, "cmdinjection")
+	analysistest.Run(t, testdata, taint.PathAnalyzer, "pathinjection")
+}
@@ -0,0 +1,36 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmdinjection
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/exec"
+)
+
+func name(r *http.Request) {
+	exec.Command(r.FormValue("tool")) // want `command passed to os/exec.Command depends on untrusted input from an HTTP request`
+}
+
+func args(ctx context.Context, r *http.Request) {
+	exec.CommandContext(ctx, "grep", "-r", r.URL.Path) // want `command passed to os/exec.CommandContext depends on untrusted input from an HTTP request`
+}
+
+func shell() {
+	editor, ok := os.LookupEnv("EDITOR")
+	if !ok {
+		editor = "vi"
+	}
+	exec.Command("sh", "-c", editor+" file.txt").Run() // want `command passed to os/exec.Command depends on untrusted input from the environment`
+}
+
+func goroutine(r *http.Request) {
+	go exec.Command(r.FormValue("tool")).Run() // want `command passed to os/exec.Command depends on untrusted input from an HTTP request`
+}
+
+func safe() {
+	exec.Command("ls", "-l").Run() // ok
+}
@@ -0,0 +1,27 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathinjection
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+func open(r *http.Request) {
+	os.Open(filepath.Join("/var/data", r.FormValue("file"))) // want `file path passed to os.Open depends on untrusted input from an HTTP request`
+}
+
+func serve(w http.ResponseWriter, r *http.Request) {
+	http.ServeFile(w, r, "static/"+r.URL.Path) // want `file path passed to net/http.ServeFile depends on untrusted input from an HTTP request`
+}
+
+func remove() {
+	os.RemoveAll(os.Getenv("HOME") + "/cache") // want `file path passed to os.RemoveAll depends on untrusted input from the environment`
+}
+
+func base(r *http.Request) {
+	os.ReadFile(filepath.Join("/var/data", filepath.Base(r.FormValue("file")))) // ok: a single path element
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sqlinjection
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func concat(db *sql.DB, r *http.Request) {
+	name := r.FormValue("name")
+	db.Query("SELECT * FROM users WHERE name = '" + name + "'") // want `SQL query passed to \(\*database/sql.DB\).Query depends on untrusted input from an HTTP request`
+}
+
+func sprintf(db *sql.DB, r *http.Request) {
+	q := fmt.Sprintf("DELETE FROM t WHERE id = %s", r.URL.Query().Get("id"))
+	db.Exec(q) // want `SQL query passed to \(\*database/sql.DB\).Exec depends on untrusted input from an HTTP request`
+}
+
+func builder(tx *sql.Tx) {
+	var b strings.Builder
+	b.WriteString("SELECT * FROM ")
+	b.WriteString(os.Getenv("TABLE"))
+	tx.QueryRow(b.String()) // want `SQL query passed to \(\*database/sql.Tx\).QueryRow depends on untrusted input from the environment`
+}
+
+func branch(db *sql.DB, r *http.Request, admin bool) {
+	table := "users"
+	if admin {
+		table = r.Header.Get("X-Table")
+	}
+	db.Prepare("SELECT * FROM " + table) // want `SQL query passed to \(\*database/sql.DB\).Prepare depends on untrusted input`
+}
+
+func parameters(db *sql.DB, r *http.Request) {
+	db.Query("SELECT * FROM users WHERE name = ?", r.FormValue("name")) // ok: a query parameter
+}
+
+func number(db *sql.DB, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		return
+	}
+	db.Query("SELECT * FROM users WHERE id = " + strconv.Itoa(id)) // ok: a number
+}
+
+func constant(db *sql.DB) {
+	db.Query("SELECT * FROM users") // ok
+}
@@ -18,9 +18,10 @@
 //     be required to conform to a func type.
 //   - exported functions or methods, since they may be address-taken
 //     in another package.
-//   - unexported methods whose name matches an interface method
-//     declared in the same package, since the method's signature
-//     may be required to conform to the interface type.
+//   - unexported methods of types, or of types that embed them, that
+//     satisfy an interface with the method declared in the same
+//     package, since the method's signature may be required to conform
+//     to the interface type.
 //   - functions with empty bodies, or containing just a call to panic.
 //   - parameters that are unnamed, or named "_", the blank identifier.
 //
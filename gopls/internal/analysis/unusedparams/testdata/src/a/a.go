@@ -73,7 +73,40 @@ func Exported(unused int) {} // no finding: an exported function may be address-
 type T int
 
 func (T) m(f bool) { println() } // want "unused parameter: f"
-func (T) n(f bool) { println() } // no finding: n may match the interface method parent.n
+func (T) n(f bool) { println() } // no finding: T satisfies parent, whose method n it must conform to
+
+// U has a method named n, but does not satisfy parent.
+type U int
+
+func (U) n(x int) { println() } // want "unused parameter: x"
+
+// V satisfies only an interface declared within a function.
+type V int
+
+func (V) o(f bool) { println() } // no finding: V satisfies the local interface below
+
+func _() {
+	type local interface{ o(bool) }
+	var _ local = V(0)
+}
+
+// W's method p is promoted to X, which, unlike W, satisfies an
+// interface with p.
+type W int
+
+func (W) p(f bool) { println() } // no finding: X satisfies the local interface below
+
+type X struct{ W }
+
+func (X) q() {}
+
+func _() {
+	type local interface {
+		p(bool)
+		q()
+	}
+	var _ local = X{}
+}
 
 func _() {
 	var fib func(x, y int) int
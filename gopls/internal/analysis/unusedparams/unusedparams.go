@@ -13,6 +13,7 @@ import (
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/gopls/internal/analysis/conformance"
 	"golang.org/x/tools/gopls/internal/util/slices"
 	"golang.org/x/tools/internal/analysisinternal"
 )
@@ -63,16 +64,13 @@ func run(pass *analysis.Pass) (any, error) {
 
 	// Gather global information:
 	// - uses of functions not in call position
-	// - unexported interface methods
 	// - all referenced variables
 
 	usesOutsideCall := make(map[types.Object][]*ast.Ident)
-	unexportedIMethodNames := make(map[string]bool)
 	{
 		callPosn := make(map[*ast.Ident]bool) // all idents f appearing in f() calls
 		filter := []ast.Node{
 			(*ast.CallExpr)(nil),
-			(*ast.InterfaceType)(nil),
 		}
 		inspect.Preorder(filter, func(n ast.Node) {
 			switch n := n.(type) {
@@ -101,19 +99,6 @@ func run(pass *analysis.Pass) (any, error) {
 						callPosn[id] = true
 					}
 				}
-
-			case *ast.InterfaceType:
-				// Record the set of names of unexported interface methods.
-				// (It would be more precise to record signatures but
-				// generics makes it tricky, and this conservative
-				// heuristic is close enough.)
-				t := pass.TypesInfo.TypeOf(n).(*types.Interface)
-				for i := 0; i < t.NumExplicitMethods(); i++ {
-					m := t.ExplicitMethod(i)
-					if !m.Exported() && m.Name() != "_" {
-						unexportedIMethodNames[m.Name()] = true
-					}
-				}
 			}
 		})
 
@@ -136,6 +121,8 @@ func run(pass *analysis.Pass) (any, error) {
 		}
 	}
 
+	conform := conformance.NewChecker(pass.TypesInfo)
+
 	// Check each non-address-taken function's parameters are all used.
 	filter := []ast.Node{
 		(*ast.FuncDecl)(nil),
@@ -167,15 +154,15 @@ func run(pass *analysis.Pass) (any, error) {
 				return true
 			}
 
-			// Ignore methods that match the name of any
-			// interface method declared in this package,
-			// as the method's signature may need to conform
-			// to the interface.
-			if n.Recv != nil && unexportedIMethodNames[n.Name.Name] {
+			fn = pass.TypesInfo.Defs[n.Name].(*types.Func)
+
+			// Ignore methods whose signature may be required
+			// to conform to an interface declared in this
+			// package.
+			if n.Recv != nil && conform.MayImplement(fn.(*types.Func)) {
 				return true
 			}
 
-			fn = pass.TypesInfo.Defs[n.Name].(*types.Func)
 			ftype, body = n.Type, n.Body
 
 		case *ast.FuncLit:
@@ -0,0 +1,87 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package baseline defines the fingerprint by which gopls identifies a
+// diagnostic across runs, and the format of a baseline file, which
+// records the fingerprints of existing diagnostics so that only new
+// findings are reported.
+//
+// A fingerprint deliberately excludes the line and column of the
+// diagnostic, so that it is stable under edits elsewhere in the file.
+// Instead it incorporates the (trimmed) text of the line on which the
+// diagnostic starts, so that two findings with the same message in
+// the same file are usually distinguished.
+package baseline
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Fingerprint returns a stable identifier for a diagnostic.
+//
+// The relPath argument is the slash-separated path of the diagnosed
+// file relative to the workspace folder, source and code identify the
+// producer of the diagnostic (e.g. "analyzer/printf" and "default"),
+// and line is the text of the line on which the diagnostic starts.
+func Fingerprint(relPath, source, code, message, line string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "path: %s\n", relPath)
+	fmt.Fprintf(h, "source: %s\n", source)
+	fmt.Fprintf(h, "code: %s\n", code)
+	fmt.Fprintf(h, "message: %s\n", strings.TrimSpace(message))
+	fmt.Fprintf(h, "line: %s\n", strings.TrimSpace(line))
+	return fmt.Sprintf("%x", h.Sum(nil)[:16])
+}
+
+// A Set is a set of diagnostic fingerprints.
+type Set map[string]bool
+
+// file is the JSON schema of a baseline file.
+type file struct {
+	// Fingerprints is the sorted list of suppressed fingerprints.
+	Fingerprints []string `json:"fingerprints"`
+}
+
+// Parse parses the contents of a baseline file.
+func Parse(data []byte) (Set, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid baseline file: %v", err)
+	}
+	set := make(Set, len(f.Fingerprints))
+	for _, fp := range f.Fingerprints {
+		set[fp] = true
+	}
+	return set, nil
+}
+
+// Format returns the contents of a baseline file recording the
+// fingerprints of set.
+func Format(set Set) ([]byte, error) {
+	f := file{Fingerprints: make([]string, 0, len(set))}
+	for fp := range set {
+		f.Fingerprints = append(f.Fingerprints, fp)
+	}
+	sort.Strings(f.Fingerprints)
+	data, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// RelPath returns the slash-separated form of filename relative to the
+// workspace folder, as used in fingerprints. Files outside the folder
+// are denoted by their absolute path.
+func RelPath(folder, filename string) string {
+	if rel, err := filepath.Rel(folder, filename); err == nil && !strings.HasPrefix(rel, "..") {
+		filename = rel
+	}
+	return filepath.ToSlash(filename)
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package baseline_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/baseline"
+)
+
+func TestFingerprint(t *testing.T) {
+	fp := baseline.Fingerprint("a/a.go", "printf", "default", "bad format", "\tfmt.Printf(x)")
+
+	// Fingerprints ignore surrounding whitespace...
+	if got := baseline.Fingerprint("a/a.go", "printf", "default", "bad format\n", "fmt.Printf(x)  "); got != fp {
+		t.Errorf("fingerprint changed with whitespace: got %s, want %s", got, fp)
+	}
+	// ...but not changes to any component.
+	for _, other := range []string{
+		baseline.Fingerprint("b/a.go", "printf", "default", "bad format", "fmt.Printf(x)"),
+		baseline.Fingerprint("a/a.go", "shadow", "default", "bad format", "fmt.Printf(x)"),
+		baseline.Fingerprint("a/a.go", "printf", "other", "bad format", "fmt.Printf(x)"),
+		baseline.Fingerprint("a/a.go", "printf", "default", "worse format", "fmt.Printf(x)"),
+		baseline.Fingerprint("a/a.go", "printf", "default", "bad format", "fmt.Printf(y)"),
+	} {
+		if other == fp {
+			t.Errorf("fingerprints unexpectedly collide: %s", fp)
+		}
+	}
+}
+
+func TestFormatParse(t *testing.T) {
+	want := baseline.Set{"b": true, "a": true}
+	data, err := baseline.Format(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := baseline.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse(Format(%v)) = %v", want, got)
+	}
+	if _, err := baseline.Parse([]byte("not json")); err == nil {
+		t.Error("Parse of invalid file succeeded")
+	}
+}
+
+func TestRelPath(t *testing.T) {
+	folder := filepath.FromSlash("/work")
+	if got, want := baseline.RelPath(folder, filepath.FromSlash("/work/a/a.go")), "a/a.go"; got != want {
+		t.Errorf("RelPath(inside) = %q, want %q", got, want)
+	}
+	outside := filepath.FromSlash("/other/a.go")
+	if got, want := baseline.RelPath(folder, outside), filepath.ToSlash(outside); got != want {
+		t.Errorf("RelPath(outside) = %q, want %q", got, want)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"encoding/gob"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -144,6 +145,18 @@ import (
    (vet) to record and serialize analysis facts. The fact
    serialization mechanism is analogous to "deep" export data.
 
+   Even when the cache key of a root package changes, not all of its
+   analysis need be repeated. Some analyzers are "declaration-local"
+   (see settings.Analyzer.Local): the diagnostics they report within a
+   function depend only on its syntax and on the types of the
+   package, and they use no facts. Their results are additionally
+   cached for each function declaration, keyed by its text and by the
+   "skeleton" of the package, that is, the text of all its files with
+   function bodies elided. After an edit within one function body,
+   only that function is re-analyzed by the local analyzers; the
+   cached results for the other functions are relocated to their
+   current lines. See declanalysis.go.
+
 */
 
 // TODO(adonovan):
@@ -196,7 +209,7 @@ func (s *Snapshot) Analyze(ctx context.Context, pkgs map[PackageID]*metadata.Pac
 	toSrc := make(map[*analysis.Analyzer]*settings.Analyzer)
 	var enabledAnalyzers []*analysis.Analyzer // enabled subset + transitive requirements
 	for _, a := range analyzers {
-		if enabled, ok := s.Options().Analyses[a.Analyzer().Name]; enabled || !ok && a.EnabledByDefault() {
+		if a.Enabled(s.Options()) {
 			toSrc[a.Analyzer()] = a
 			enabledAnalyzers = append(enabledAnalyzers, a.Analyzer())
 		}
@@ -208,6 +221,21 @@ func (s *Snapshot) Analyze(ctx context.Context, pkgs map[PackageID]*metadata.Pac
 
 	enabledAnalyzers = requiredAnalyzers(enabledAnalyzers)
 
+	// Declaration-local analyzers may be applied incrementally to the
+	// functions of root packages, unless they (or their requirements)
+	// use facts.
+	local := make(map[*analysis.Analyzer]bool)
+	for a, src := range toSrc {
+		if src.Local() {
+			local[a] = true
+			for _, req := range requiredAnalyzers([]*analysis.Analyzer{a}) {
+				if len(req.FactTypes) > 0 {
+					delete(local, a)
+				}
+			}
+		}
+	}
+
 	// Perform basic sanity checks.
 	// (Ideally we would do this only once.)
 	if err := analysis.Validate(enabledAnalyzers); err != nil {
@@ -322,6 +350,7 @@ func (s *Snapshot) Analyze(ctx context.Context, pkgs map[PackageID]*metadata.Pac
 			return nil, err
 		}
 		root.analyzers = enabledAnalyzers
+		root.local = local
 		roots = append(roots, root)
 	}
 
@@ -535,6 +564,7 @@ type analysisNode struct {
 	exportDeps      map[PackagePath]*analysisNode // subset of allDeps ref'd by export data (+self)
 	summary         *analyzeSummary               // serializable result of analyzing this package
 	stableNames     map[*analysis.Analyzer]string // cross-process stable names for Analyzers
+	local           map[*analysis.Analyzer]bool   // declaration-local analyzers (root nodes only)
 
 	typesOnce sync.Once      // guards lazy population of types and typesErr fields
 	types     *types.Package // type information lazily imported from summary
@@ -729,6 +759,14 @@ var cacheLimit = make(chan unit, 32)
 // compiled Go files, and vdeps (successor) information
 // (export data and facts).
 func (an *analysisNode) cacheKey() [sha256.Size]byte {
+	return an.inputsKey(an.analyzers, an.files)
+}
+
+// inputsKey returns a cache key for the application of the specified
+// analyzers to the specified files of the package, which are all
+// of its files for cacheKey, and none for the key of incremental
+// analysis of its declarations.
+func (an *analysisNode) inputsKey(analyzers []*analysis.Analyzer, files []file.Handle) [sha256.Size]byte {
 	hasher := sha256.New()
 
 	// In principle, a key must be the hash of an
@@ -736,14 +774,26 @@ func (an *analysisNode) cacheKey() [sha256.Size]byte {
 	// If it's ambiguous, we risk collisions.
 
 	// analyzers
-	fmt.Fprintf(hasher, "analyzers: %d\n", len(an.analyzers))
-	for _, a := range an.analyzers {
+	fmt.Fprintf(hasher, "analyzers: %d\n", len(analyzers))
+	for _, a := range analyzers {
 		fmt.Fprintln(hasher, a.Name)
+		a.Flags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(hasher, "flag: %s=%s\n", f.Name, f.Value)
+		})
+		if digest := settings.PluginDigest(a); digest != "" {
+			fmt.Fprintf(hasher, "plugin: %s\n", digest)
+		}
 	}
 
 	// package metadata
 	mp := an.mp
 	fmt.Fprintf(hasher, "package: %s %s %s\n", mp.ID, mp.Name, mp.PkgPath)
+	if len(mp.CompiledGoFiles) > 0 {
+		dir := mp.CompiledGoFiles[0].Dir().Path()
+		if digest := settings.StaticcheckConfigDigest(analyzers, dir); digest != "" {
+			fmt.Fprintf(hasher, "staticcheck.conf: %s\n", digest)
+		}
+	}
 	fmt.Fprintf(hasher, "viewtype: %s\n", an.viewType) // (affects diagnostics)
 
 	// We can ignore m.DepsBy{Pkg,Import}Path: although the logic
@@ -763,8 +813,8 @@ func (an *analysisNode) cacheKey() [sha256.Size]byte {
 	}
 
 	// file names and contents
-	fmt.Fprintf(hasher, "files: %d\n", len(an.files))
-	for _, fh := range an.files {
+	fmt.Fprintf(hasher, "files: %d\n", len(files))
+	for _, fh := range files {
 		fmt.Fprintln(hasher, fh.Identity())
 	}
 
@@ -879,40 +929,30 @@ func (an *analysisNode) run(ctx context.Context) (*analyzeSummary, error) {
 
 	// -- analysis --
 
-	// Build action graph for this package.
-	// Each graph node (action) is one unit of analysis.
-	actions := make(map[*analysis.Analyzer]*action)
-	var mkAction func(a *analysis.Analyzer) *action
-	mkAction = func(a *analysis.Analyzer) *action {
-		act, ok := actions[a]
-		if !ok {
-			var hdeps []*action
-			for _, req := range a.Requires {
-				hdeps = append(hdeps, mkAction(req))
-			}
-			act = &action{
-				a:          a,
-				fsource:    an.fsource,
-				stableName: an.stableNames[a],
-				pkg:        pkg,
-				vdeps:      an.succs,
-				hdeps:      hdeps,
-			}
-			actions[a] = act
+	// Declaration-local analyzers are applied separately, to the
+	// declarations for which no results are cached, provided that
+	// the package is well-typed.
+	var analyzers, local []*analysis.Analyzer
+	for _, a := range an.analyzers {
+		if an.local[a] && pkg.compiles {
+			local = append(local, a)
+		} else {
+			analyzers = append(analyzers, a)
 		}
-		return act
 	}
 
 	// Build actions for initial package.
-	var roots []*action
-	for _, a := range an.analyzers {
-		roots = append(roots, mkAction(a))
-	}
+	roots := an.actions(pkg, analyzers)
 
 	// Execute the graph in parallel.
 	execActions(ctx, roots)
 	// Inv: each root's summary is set (whether success or error).
 
+	var localSummaries map[string]*actionSummary
+	if len(local) > 0 {
+		localSummaries = an.runLocal(ctx, pkg, local)
+	}
+
 	// Don't return (or cache) the result in case of cancellation.
 	if err := ctx.Err(); err != nil {
 		return nil, err // cancelled
@@ -926,6 +966,9 @@ func (an *analysisNode) run(ctx context.Context) (*analyzeSummary, error) {
 		}
 		summaries[root.stableName] = root.summary
 	}
+	for name, summary := range localSummaries {
+		summaries[name] = summary
+	}
 
 	return &analyzeSummary{
 		Export:         pkg.export,
@@ -935,6 +978,39 @@ func (an *analysisNode) run(ctx context.Context) (*analyzeSummary, error) {
 	}, nil
 }
 
+// actions builds the graph of actions that apply the specified
+// analyzers to the package, and returns their root actions.
+// Each graph node (action) is one unit of analysis.
+func (an *analysisNode) actions(pkg *analysisPackage, analyzers []*analysis.Analyzer) []*action {
+	actions := make(map[*analysis.Analyzer]*action)
+	var mkAction func(a *analysis.Analyzer) *action
+	mkAction = func(a *analysis.Analyzer) *action {
+		act, ok := actions[a]
+		if !ok {
+			var hdeps []*action
+			for _, req := range a.Requires {
+				hdeps = append(hdeps, mkAction(req))
+			}
+			act = &action{
+				a:          a,
+				fsource:    an.fsource,
+				stableName: an.stableNames[a],
+				pkg:        pkg,
+				vdeps:      an.succs,
+				hdeps:      hdeps,
+			}
+			actions[a] = act
+		}
+		return act
+	}
+
+	var roots []*action
+	for _, a := range analyzers {
+		roots = append(roots, mkAction(a))
+	}
+	return roots
+}
+
 // Postcondition: analysisPackage.types and an.exportDeps are populated.
 func (an *analysisNode) typeCheck(parsed []*parsego.File) *analysisPackage {
 	mp := an.mp
@@ -1554,6 +1630,7 @@ type gobDiagnostic struct {
 	Location       protocol.Location
 	Severity       protocol.DiagnosticSeverity
 	Code           string
+	Category       string // of an analysis diagnostic; names its command-based fixes
 	CodeHref       string
 	Source         string
 	Message        string
@@ -1628,25 +1705,58 @@ func toGobDiagnostic(posToLocation func(start, end token.Pos) (protocol.Location
 	// information as "Source(Code)" where code is a link to CodeHref.
 	// (The code field must be nonempty for anything to appear.)
 	diagURL := effectiveURL(a, diag)
-	code := "default"
+	category := "default"
 	if diag.Category != "" {
-		code = diag.Category
+		category = diag.Category
+	}
+	code := category
+	if diag.Code != "" {
+		code = diag.Code
+	}
+
+	var tags []protocol.DiagnosticTag
+	for _, tag := range diag.Tags {
+		switch tag {
+		case analysis.TagUnnecessary:
+			tags = append(tags, protocol.Unnecessary)
+		case analysis.TagDeprecated:
+			tags = append(tags, protocol.Deprecated)
+		}
 	}
 
 	return gobDiagnostic{
 		Location: loc,
 		// Severity for analysis diagnostics is dynamic,
-		// based on user configuration per analyzer.
+		// based on user configuration per analyzer;
+		// this is merely the analyzer's hint.
+		Severity:       toProtocolSeverity(diag.Severity),
 		Code:           code,
+		Category:       category,
 		CodeHref:       diagURL,
 		Source:         a.Name,
 		Message:        diag.Message,
 		SuggestedFixes: fixes,
 		Related:        related,
-		// Analysis diagnostics do not contain tags.
+		Tags:           tags,
 	}, nil
 }
 
+// toProtocolSeverity converts the severity hint of an analysis
+// diagnostic to the protocol's severity, or zero if unspecified.
+func toProtocolSeverity(severity analysis.Severity) protocol.DiagnosticSeverity {
+	switch severity {
+	case analysis.SeverityError:
+		return protocol.SeverityError
+	case analysis.SeverityWarning:
+		return protocol.SeverityWarning
+	case analysis.SeverityInfo:
+		return protocol.SeverityInformation
+	case analysis.SeverityHint:
+		return protocol.SeverityHint
+	}
+	return 0
+}
+
 // effectiveURL computes the effective URL of diag,
 // using the algorithm specified at Diagnostic.URL.
 func effectiveURL(a *analysis.Analyzer, diag analysis.Diagnostic) string {
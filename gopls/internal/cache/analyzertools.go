@@ -0,0 +1,329 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/label"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/gocommand"
+	"golang.org/x/tools/internal/memoize"
+)
+
+// AnalyzerToolDiagnostics returns the diagnostics reported by the
+// external analyzer tools of the analyzerTools setting for the
+// directories of the specified packages, which should have no unsaved
+// changes: the tools read the package files from disk.
+//
+// The tools are run by "go vet -vettool", which analyzes the
+// dependencies of each package as well, so that analysis facts
+// propagate from them. The results for each directory are memoized
+// until a file of its packages, or of their dependencies, changes.
+func (s *Snapshot) AnalyzerToolDiagnostics(ctx context.Context, pkgs map[PackageID]*metadata.Package) ([]*Diagnostic, error) {
+	if len(s.Options().AnalyzerTools) == 0 {
+		return nil, nil
+	}
+
+	dirs := make(map[protocol.DocumentURI]bool)
+	for _, mp := range pkgs {
+		if len(mp.CompiledGoFiles) > 0 && !metadata.IsCommandLineArguments(mp.ID) {
+			dirs[mp.CompiledGoFiles[0].Dir()] = true
+		}
+	}
+
+	type toolResult struct {
+		diags []*Diagnostic
+		err   error
+	}
+
+	var diags []*Diagnostic
+	for dir := range dirs {
+		s.mu.Lock()
+		entry, hit := s.analyzerToolHandles.Get(dir)
+		s.mu.Unlock()
+
+		// Cache miss?
+		if !hit {
+			dir := dir
+			handle := memoize.NewPromise("analyzerTools", func(ctx context.Context, arg interface{}) interface{} {
+				diags, err := analyzerToolsImpl(ctx, arg.(*Snapshot), dir)
+				return toolResult{diags, err}
+			})
+
+			entry = handle
+			s.mu.Lock()
+			s.analyzerToolHandles.Set(dir, entry, nil)
+			s.mu.Unlock()
+		}
+
+		// Await result.
+		v, err := s.awaitPromise(ctx, entry)
+		if err != nil {
+			return nil, err
+		}
+		res := v.(toolResult)
+		if res.err != nil {
+			// A failing tool should not prevent the reporting of
+			// other diagnostics.
+			event.Error(ctx, "running analyzer tools", res.err, label.Directory.Of(dir.Path()))
+			continue
+		}
+		diags = append(diags, res.diags...)
+	}
+	return diags, nil
+}
+
+// analyzerToolsImpl runs each analyzer tool on the packages in dir.
+func analyzerToolsImpl(ctx context.Context, snapshot *Snapshot, dir protocol.DocumentURI) ([]*Diagnostic, error) {
+	opts := snapshot.Options()
+
+	// Run the tools in a deterministic order.
+	var tools []string
+	for tool := range opts.AnalyzerTools {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	var diags []*Diagnostic
+	for _, tool := range tools {
+		// The -a flag forces the vet actions to run: the go
+		// command caches their results, but replays only the
+		// tool's standard output on a cache hit, whereas with
+		// -json the tool reports its findings on stderr.
+		args := []string{"-a", "-vettool=" + tool, "-json"}
+		args = append(args, opts.AnalyzerTools[tool]...)
+		args = append(args, ".")
+		inv, cleanup, err := snapshot.GoCommandInvocation(false, &gocommand.Invocation{
+			Verb:       "vet",
+			Args:       args,
+			WorkingDir: dir.Path(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		// With -json, the tool reports its findings and exits
+		// zero; a non-zero exit means that it failed.
+		stdout, stderr, friendlyErr, err := snapshot.View().GoCommandRunner().RunRaw(ctx, *inv)
+		cleanup()
+		if err != nil {
+			return nil, err
+		}
+		if friendlyErr != nil {
+			return nil, fmt.Errorf("analyzer tool %s: %v", tool, friendlyErr)
+		}
+		// The findings are on stderr, but parse stdout too, in
+		// case they come from the cache of the go command.
+		tree, err := parseVetJSON(append(stdout.Bytes(), stderr.Bytes()...))
+		if err != nil {
+			return nil, fmt.Errorf("analyzer tool %s: %v", tool, err)
+		}
+		diags = append(diags, toolDiagnostics(ctx, snapshot, tree)...)
+	}
+	return diags, nil
+}
+
+// A vetTree is the JSON output of a unitchecker analyzer tool: a
+// mapping from package ID and analyzer name to a list of diagnostics,
+// or an error.
+type vetTree map[string]map[string]json.RawMessage
+
+type vetDiagnostic struct {
+	Category       string `json:"category,omitempty"`
+	Posn           string `json:"posn"`
+	Message        string `json:"message"`
+	SuggestedFixes []struct {
+		Message string `json:"message"`
+		Edits   []struct {
+			Filename string `json:"filename"`
+			Start    int    `json:"start"`
+			End      int    `json:"end"`
+			New      string `json:"new"`
+		} `json:"edits"`
+	} `json:"suggested_fixes,omitempty"`
+	Related []struct {
+		Posn    string `json:"posn"`
+		Message string `json:"message"`
+	} `json:"related,omitempty"`
+}
+
+// parseVetJSON parses the output of "go vet -json", in which the
+// JSON output of the analyzer tool for each package follows one or
+// more "# package" header lines.
+func parseVetJSON(data []byte) ([]vetTree, error) {
+	var (
+		jsonData bytes.Buffer
+		other    []string
+	)
+	for sc := bufio.NewScanner(bytes.NewReader(data)); sc.Scan(); {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "{"), strings.HasPrefix(line, "}"), strings.HasPrefix(line, "\t"):
+			jsonData.WriteString(line)
+			jsonData.WriteByte('\n')
+		default:
+			other = append(other, line)
+		}
+	}
+	if len(other) > 0 {
+		// e.g. a build failure
+		return nil, fmt.Errorf("%s", strings.Join(other, "\n"))
+	}
+	var trees []vetTree
+	for dec := json.NewDecoder(&jsonData); dec.More(); {
+		var tree vetTree
+		if err := dec.Decode(&tree); err != nil {
+			return nil, err
+		}
+		trees = append(trees, tree)
+	}
+	return trees, nil
+}
+
+// toolDiagnostics converts the diagnostics of an analyzer tool to
+// protocol form, omitting those of the analyzers that the analyses
+// setting disables, and those whose positions can't be mapped.
+//
+// The positions are mapped through the contents of the files on disk,
+// from which the tool computed them, not the snapshot's overlays.
+func toolDiagnostics(ctx context.Context, snapshot *Snapshot, trees []vetTree) []*Diagnostic {
+	mappers := make(map[protocol.DocumentURI]*protocol.Mapper)
+	mapper := func(filename string) (*protocol.Mapper, error) {
+		uri := protocol.URIFromPath(filename)
+		m, ok := mappers[uri]
+		if !ok {
+			fh, err := snapshot.view.fs.delegate.ReadFile(ctx, uri)
+			if err != nil {
+				return nil, err
+			}
+			content, err := fh.Content()
+			if err != nil {
+				return nil, err
+			}
+			m = protocol.NewMapper(uri, content)
+			mappers[uri] = m
+		}
+		return m, nil
+	}
+	location := func(posn string) (protocol.Location, error) {
+		filename, line, col, err := splitPosn(posn)
+		if err != nil {
+			return protocol.Location{}, err
+		}
+		m, err := mapper(filename)
+		if err != nil {
+			return protocol.Location{}, err
+		}
+		pos, err := m.LineCol8Position(line, col)
+		if err != nil {
+			return protocol.Location{}, err
+		}
+		return m.RangeLocation(protocol.Range{Start: pos, End: pos}), nil
+	}
+
+	var diags []*Diagnostic
+	for _, tree := range trees {
+		for _, results := range tree {
+			for analyzer, result := range results {
+				if enabled, ok := snapshot.Options().Analyses[analyzer]; ok && !enabled {
+					continue
+				}
+				var found []vetDiagnostic
+				if err := json.Unmarshal(result, &found); err != nil {
+					// The result is an error, such as a type error
+					// in the package, which gopls reports anyway.
+					continue
+				}
+				for _, d := range found {
+					loc, err := location(d.Posn)
+					if err != nil {
+						event.Error(ctx, "invalid analyzer tool diagnostic", err, label.File.Of(d.Posn))
+						continue
+					}
+					diag := &Diagnostic{
+						URI:      loc.URI,
+						Range:    loc.Range,
+						Severity: protocol.SeverityWarning,
+						Code:     d.Category,
+						Source:   DiagnosticSource(analyzer),
+						Message:  d.Message,
+					}
+					for _, r := range d.Related {
+						loc, err := location(r.Posn)
+						if err != nil {
+							event.Error(ctx, "invalid analyzer tool diagnostic", err, label.File.Of(r.Posn))
+							continue
+						}
+						diag.Related = append(diag.Related, protocol.DiagnosticRelatedInformation{
+							Location: loc,
+							Message:  r.Message,
+						})
+					}
+				nextFix:
+					for _, fix := range d.SuggestedFixes {
+						edits := make(map[protocol.DocumentURI][]protocol.TextEdit)
+						for _, edit := range fix.Edits {
+							m, err := mapper(edit.Filename)
+							if err != nil {
+								event.Error(ctx, "invalid analyzer tool diagnostic", err, label.File.Of(edit.Filename))
+								continue nextFix
+							}
+							rng, err := m.OffsetRange(edit.Start, edit.End)
+							if err != nil {
+								event.Error(ctx, "invalid analyzer tool diagnostic", err, label.File.Of(edit.Filename))
+								continue nextFix
+							}
+							edits[m.URI] = append(edits[m.URI], protocol.TextEdit{Range: rng, NewText: edit.New})
+						}
+						diag.SuggestedFixes = append(diag.SuggestedFixes, SuggestedFix{
+							Title:      fix.Message,
+							Edits:      edits,
+							ActionKind: protocol.QuickFix,
+						})
+					}
+					diags = append(diags, diag)
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// splitPosn splits a position of the form "file:line:col".
+func splitPosn(posn string) (filename string, line, col int, err error) {
+	rest, colStr, ok := cut(posn)
+	if ok {
+		var lineStr string
+		filename, lineStr, ok = cut(rest)
+		if ok {
+			line, err = strconv.Atoi(lineStr)
+			if err == nil {
+				col, err = strconv.Atoi(colStr)
+			}
+			if err == nil {
+				return filename, line, col, nil
+			}
+		}
+	}
+	return "", 0, 0, fmt.Errorf("invalid position %q", posn)
+}
+
+// cut splits s around its last colon.
+func cut(s string) (before, after string, ok bool) {
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		return s[:i], s[i+1:], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/gocommand"
+	"golang.org/x/tools/internal/memoize"
+)
+
+// An APIBaseline holds the types of the packages of a released version
+// of a workspace module, against which the API of the module is
+// checked (see the apiCompatibility setting).
+type APIBaseline struct {
+	Version  string                         // the released version, e.g. "v1.2.3"
+	Fset     *token.FileSet                 // for the positions of the package types
+	Packages map[PackagePath]*types.Package // by package path
+}
+
+// APIBaseline returns the API of the released version of the module of
+// the given go.mod file that is selected by the apiCompatibility
+// setting, or nil if the setting is empty. The released version is
+// read from the module cache, or downloaded, and its packages are
+// loaded from their export data. The result is memoized until the
+// go.mod file changes.
+func (s *Snapshot) APIBaseline(ctx context.Context, modURI protocol.DocumentURI) (*APIBaseline, error) {
+	version := s.Options().APICompatibility
+	if version == "" {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	entry, hit := s.apiBaselineHandles.Get(modURI)
+	s.mu.Unlock()
+
+	type apiBaselineResult struct {
+		baseline *APIBaseline
+		err      error
+	}
+
+	// Cache miss?
+	if !hit {
+		handle := memoize.NewPromise("apiBaseline", func(ctx context.Context, arg interface{}) interface{} {
+			baseline, err := apiBaselineImpl(ctx, arg.(*Snapshot), modURI, version)
+			return apiBaselineResult{baseline, err}
+		})
+
+		entry = handle
+		s.mu.Lock()
+		s.apiBaselineHandles.Set(modURI, entry, nil)
+		s.mu.Unlock()
+	}
+
+	// Await result.
+	v, err := s.awaitPromise(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	res := v.(apiBaselineResult)
+	return res.baseline, res.err
+}
+
+func apiBaselineImpl(ctx context.Context, snapshot *Snapshot, modURI protocol.DocumentURI, version string) (*APIBaseline, error) {
+	fh, err := snapshot.ReadFile(ctx, modURI)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := snapshot.ParseMod(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+	if pm.File.Module == nil {
+		return nil, fmt.Errorf("%s has no module directive", modURI.Path())
+	}
+	modulePath := pm.File.Module.Mod.Path
+
+	// Download the released version, if it is not in the module cache.
+	inv, cleanup, err := snapshot.GoCommandInvocation(true, &gocommand.Invocation{
+		Verb:       "mod",
+		Args:       []string{"download", "-json", modulePath + "@" + version},
+		WorkingDir: filepath.Dir(modURI.Path()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	stdout, err := snapshot.View().GoCommandRunner().Run(ctx, *inv)
+	if err != nil {
+		return nil, err
+	}
+	var mod gocommand.ModuleJSON
+	if err := json.NewDecoder(stdout).Decode(&mod); err != nil {
+		return nil, err
+	}
+	if mod.Dir == "" {
+		return nil, fmt.Errorf("no directory for %s@%s", modulePath, version)
+	}
+
+	// Load the packages of the released version, as the main module.
+	cfg := &packages.Config{
+		Context:    ctx,
+		Fset:       token.NewFileSet(),
+		Mode:       packages.NeedName | packages.NeedTypes,
+		Dir:        mod.Dir,
+		Env:        append(inv.Env, "GOWORK=off", "GOFLAGS=-mod=readonly"),
+		BuildFlags: inv.BuildFlags,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("loading %s@%s: %v", modulePath, mod.Version, err)
+	}
+	baseline := &APIBaseline{
+		Version:  mod.Version,
+		Fset:     cfg.Fset,
+		Packages: make(map[PackagePath]*types.Package),
+	}
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) == 0 && pkg.Types != nil {
+			baseline.Packages[PackagePath(pkg.PkgPath)] = pkg.Types
+		}
+	}
+	return baseline, nil
+}
@@ -64,6 +64,7 @@ type typeCheckBatch struct {
 	post        postTypeCheck
 	handles     map[PackageID]*packageHandle // (immutable)
 	parseCache  *parseCache
+	packages    *sharedPackages
 	fset        *token.FileSet // describes all parsed or imported files
 	cpulimit    chan unit      // concurrency limiter for CPU-bound operations
 
@@ -375,6 +376,7 @@ func (s *Snapshot) forEachPackageInternal(ctx context.Context, importGraph *impo
 		post:               post,
 		handles:            handles,
 		parseCache:         s.view.parseCache,
+		packages:           s.view.packages,
 		fset:               fileSetWithBase(reservedForParsing),
 		syntaxIndex:        make(map[PackageID]int),
 		cpulimit:           make(chan unit, runtime.GOMAXPROCS(0)),
@@ -551,31 +553,41 @@ func (b *typeCheckBatch) handleSyntaxPackage(ctx context.Context, i int, id Pack
 		}
 	}
 
-	// Wait to acquire a CPU token.
-	//
-	// Note: it is important to acquire this token only after awaiting
-	// predecessors, to avoid starvation.
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case b.cpulimit <- unit{}:
-		defer func() {
-			<-b.cpulimit // release CPU token
-		}()
-	}
-
-	// Compute the syntax package.
-	p, err := b.checkPackage(ctx, ph)
+	// Compute the syntax package, unless another view with identical
+	// inputs has done so.
+	syntax, shared, err := b.packages.get(ctx, ph.key, func() (*syntaxPackage, error) {
+		// Wait to acquire a CPU token.
+		//
+		// Note: it is important to acquire this token only after awaiting
+		// predecessors, to avoid starvation.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case b.cpulimit <- unit{}:
+			defer func() {
+				<-b.cpulimit // release CPU token
+			}()
+		}
+		return b.checkPackage(ctx, ph)
+	})
 	if err != nil {
 		return nil, err
 	}
+	p := &Package{ph.mp, ph.loadDiagnostics, syntax}
 
 	// Update caches.
 	b.activePackageCache.setActivePackage(id, p) // store active packages in memory
-	go storePackageResults(ctx, ph, p)           // ...and write all packages to disk
+	if !shared {
+		go storePackageResults(ctx, ph, p) // ...and write all packages to disk
+	}
 
 	b.post(i, p)
 
+	if shared {
+		// The types of a shared package belong to another batch, and so
+		// cannot be used for importing.
+		return nil, nil
+	}
 	return p.pkg.types, nil
 }
 
@@ -585,9 +597,13 @@ func (b *typeCheckBatch) handleSyntaxPackage(ctx context.Context, i int, id Pack
 func storePackageResults(ctx context.Context, ph *packageHandle, p *Package) {
 	toCache := map[string][]byte{
 		xrefsKind:       p.pkg.xrefs(),
-		methodSetsKind:  p.pkg.methodsets().Encode(),
 		diagnosticsKind: encodeDiagnostics(p.pkg.diagnostics),
 	}
+	// The method sets of module dependencies are computed lazily,
+	// and cached by module version (see Snapshot.MethodSets).
+	if _, ok := methodSetsVersionKey(ph); !ok {
+		toCache[methodSetsKind] = p.pkg.methodsets().Encode()
+	}
 
 	if p.metadata.PkgPath != "unsafe" { // unsafe cannot be exported
 		exportData, err := gcimporter.IExportShallow(p.pkg.fset, p.pkg.types, bug.Reportf)
@@ -1351,6 +1367,7 @@ type typeCheckInputs struct {
 	sizes                    types.Sizes
 	depsByImpPath            map[ImportPath]PackageID
 	goVersion                string // packages.Module.GoVersion, e.g. "1.18"
+	modulePath               string // path of the enclosing main module, if any
 
 	// Used for type check diagnostics:
 	// TODO(rfindley): consider storing less data in gobDiagnostics, and
@@ -1385,6 +1402,10 @@ func (s *Snapshot) typeCheckInputs(ctx context.Context, mp *metadata.Package) (t
 	if mp.Module != nil && mp.Module.GoVersion != "" {
 		goVersion = mp.Module.GoVersion
 	}
+	modulePath := ""
+	if mp.Module != nil && mp.Module.Main {
+		modulePath = mp.Module.Path
+	}
 
 	return typeCheckInputs{
 		id:              mp.ID,
@@ -1395,6 +1416,7 @@ func (s *Snapshot) typeCheckInputs(ctx context.Context, mp *metadata.Package) (t
 		sizes:           mp.TypesSizes,
 		depsByImpPath:   mp.DepsByImpPath,
 		goVersion:       goVersion,
+		modulePath:      modulePath,
 
 		supportsRelatedInformation: s.Options().RelatedInformationSupported,
 		linkTarget:                 s.Options().LinkTarget,
@@ -1427,8 +1449,9 @@ func localPackageKey(inputs typeCheckInputs) file.Hash {
 	// package identifiers
 	fmt.Fprintf(hasher, "package: %s %s %s\n", inputs.id, inputs.name, inputs.pkgPath)
 
-	// module Go version
+	// module path and Go version
 	fmt.Fprintf(hasher, "go %s\n", inputs.goVersion)
+	fmt.Fprintf(hasher, "module %s\n", inputs.modulePath)
 
 	// import map
 	importPaths := make([]string, 0, len(inputs.depsByImpPath))
@@ -1467,7 +1490,7 @@ func localPackageKey(inputs typeCheckInputs) file.Hash {
 // checkPackage type checks the parsed source files in compiledGoFiles.
 // (The resulting pkg also holds the parsed but not type-checked goFiles.)
 // deps holds the future results of type-checking the direct dependencies.
-func (b *typeCheckBatch) checkPackage(ctx context.Context, ph *packageHandle) (*Package, error) {
+func (b *typeCheckBatch) checkPackage(ctx context.Context, ph *packageHandle) (*syntaxPackage, error) {
 	inputs := ph.localInputs
 	ctx, done := event.Start(ctx, "cache.typeCheckBatch.checkPackage", label.Package.Of(string(inputs.id)))
 	defer done()
@@ -1595,6 +1618,14 @@ func (b *typeCheckBatch) checkPackage(ctx context.Context, ph *packageHandle) (*
 		}
 	}
 
+	for _, pgf := range pkg.compiledGoFiles {
+		if diag, err := byteOrderMarkDiagnostic(pgf); err != nil {
+			event.Error(ctx, "unable to compute position of byte order mark", err, label.Package.Of(string(inputs.id)))
+		} else if diag != nil {
+			pkg.diagnostics = append(pkg.diagnostics, diag)
+		}
+	}
+
 	diags := typeErrorsToDiagnostics(pkg, inputs, pkg.typeErrors)
 	for _, diag := range diags {
 		// If the file didn't parse cleanly, it is highly likely that type
@@ -1605,7 +1636,7 @@ func (b *typeCheckBatch) checkPackage(ctx context.Context, ph *packageHandle) (*
 		}
 	}
 
-	return &Package{ph.mp, ph.loadDiagnostics, pkg}, nil
+	return pkg, nil
 }
 
 // e.g. "go1" or "go1.2" or "go1.2.3"
@@ -1945,6 +1976,9 @@ func typeErrorsToDiagnostics(pkg *syntaxPackage, inputs typeCheckInputs, errs []
 				continue
 			}
 			msg := related[0].Msg // primary
+			if match := unsupportedFeatureRe.FindStringSubmatch(msg); match != nil && inputs.viewType.usesModules() {
+				msg = explainUnsupportedFeature(msg, match[1], inputs.goVersion, pgf)
+			}
 			if i > 0 {
 				if inputs.supportsRelatedInformation {
 					msg += " (see details)"
@@ -1967,9 +2001,14 @@ func typeErrorsToDiagnostics(pkg *syntaxPackage, inputs typeCheckInputs, errs []
 				diag.Tags = append(diag.Tags, protocol.Unnecessary)
 			}
 			if match := importErrorRe.FindStringSubmatch(e.Msg); match != nil {
-				diag.SuggestedFixes = append(diag.SuggestedFixes, goGetQuickFixes(inputs.viewType.usesModules(), pgf.URI, match[1])...)
+				if inputs.modulePath != "" && strings.HasPrefix(match[1], inputs.modulePath+"/") {
+					// The package would belong to the module: go get can't help.
+					diag.SuggestedFixes = append(diag.SuggestedFixes, createPackageQuickFixes(pgf.URI, match[1])...)
+				} else {
+					diag.SuggestedFixes = append(diag.SuggestedFixes, goGetQuickFixes(inputs.viewType.usesModules(), pgf.URI, match[1])...)
+				}
 			}
-			if match := unsupportedFeatureRe.FindStringSubmatch(e.Msg); match != nil {
+			if match := unsupportedFeatureRe.FindStringSubmatch(e.Msg); match != nil && limitedByGoDirective(match[1], inputs.goVersion, pgf) {
 				diag.SuggestedFixes = append(diag.SuggestedFixes, editGoDirectiveQuickFix(inputs.viewType.usesModules(), pgf.URI, match[1])...)
 			}
 
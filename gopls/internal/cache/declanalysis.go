@@ -0,0 +1,316 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+// This file defines the incremental application of declaration-local
+// analyzers to the function declarations of a package.
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/filecache"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/frob"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/gopls/internal/util/slices"
+	"golang.org/x/tools/internal/event"
+)
+
+// A declUnit is a top-level declaration of a package: the unit of
+// incremental analysis by declaration-local analyzers.
+type declUnit struct {
+	file int            // index of file among analysisPackage.parsed
+	pgf  *parsego.File  // file containing the declaration
+	decl ast.Decl       // the declaration
+	rng  protocol.Range // extent of the declaration, from the start of its first line
+
+	// key is the cache key of the analysis results of a function
+	// declaration. A zero key indicates that they are not cached.
+	key [sha256.Size]byte
+}
+
+// declDiagnostic is a gob-serializable diagnostic of a declaration
+// unit, whose line numbers are relative to the start of the unit.
+type declDiagnostic struct {
+	Analyzer   string // stable name of analyzer
+	Diagnostic gobDiagnostic
+}
+
+var declDiagnosticsCodec = frob.CodecFor[[]declDiagnostic]()
+
+// runLocal applies the declaration-local analyzers to the package,
+// which must be free of errors. Only the function declarations for
+// which no results are cached are analyzed, along with all other
+// declarations; the results of the rest are taken from the cache.
+// The results of the analyzed function declarations are then cached
+// in turn.
+//
+// It returns the summary of each analyzer, keyed by stable name.
+func (an *analysisNode) runLocal(ctx context.Context, pkg *analysisPackage, analyzers []*analysis.Analyzer) map[string]*actionSummary {
+	const cacheKind = "analysis-decl"
+
+	// Consult the cache for each function declaration.
+	var (
+		cached []declDiagnostic // results of cached units, relocated
+		todo   []*declUnit      // units to analyze
+	)
+	for _, u := range an.declUnits(pkg, analyzers) {
+		if u.key != ([sha256.Size]byte{}) {
+			data, err := filecache.Get(cacheKind, u.key)
+			if err == nil {
+				var diags []declDiagnostic
+				declDiagnosticsCodec.Decode(data, &diags)
+				for _, d := range diags {
+					d.Diagnostic = moveDiagnostic(d.Diagnostic, int(u.rng.Start.Line))
+					cached = append(cached, d)
+				}
+				continue
+			}
+			if err != filecache.ErrNotFound {
+				event.Error(ctx, "internal error reading analysis declaration cache", err)
+			}
+		}
+		todo = append(todo, u)
+	}
+
+	// Apply the analyzers to a copy of the package whose files
+	// contain only the declarations to analyze.
+	pruned := *pkg
+	pruned.files = make([]*ast.File, len(pkg.files))
+	for i, f := range pkg.files {
+		prunedFile := *f
+		prunedFile.Decls = nil
+		pruned.files[i] = &prunedFile
+	}
+	for _, u := range todo {
+		f := pruned.files[u.file]
+		f.Decls = append(f.Decls, u.decl)
+	}
+	roots := an.actions(&pruned, analyzers)
+	execActions(ctx, roots)
+
+	// Attribute each diagnostic to the unit that contains it.
+	//
+	// The results of a unit are not cached if any of its diagnostics
+	// extends beyond it; nor are those of any unit if a diagnostic
+	// lies outside all of them, or if an analyzer failed, since the
+	// results of each unit must be complete.
+	results := make(map[*declUnit][]declDiagnostic)
+	cacheable := true
+	for _, root := range roots {
+		if root.summary.Err != "" {
+			cacheable = false
+			continue
+		}
+		for _, diag := range root.summary.Diagnostics {
+			u := containingUnit(todo, diag.Location)
+			if u == nil {
+				cacheable = false
+				continue
+			}
+			if !u.containsAll(diag) {
+				u.key = [sha256.Size]byte{}
+			}
+			results[u] = append(results[u], declDiagnostic{root.stableName, diag})
+		}
+	}
+	if cacheable {
+		for _, u := range todo {
+			if u.key == ([sha256.Size]byte{}) {
+				continue
+			}
+			diags := results[u]
+			for i := range diags {
+				diags[i].Diagnostic = moveDiagnostic(diags[i].Diagnostic, -int(u.rng.Start.Line))
+			}
+			key := u.key
+			go func() {
+				cacheLimit <- unit{}            // acquire token
+				defer func() { <-cacheLimit }() // release token
+
+				data := declDiagnosticsCodec.Encode(diags)
+				if err := filecache.Set(cacheKind, key, data); err != nil {
+					event.Error(ctx, "internal error updating analysis declaration cache", err)
+				}
+			}()
+		}
+	}
+
+	// Combine the new and cached diagnostics of each analyzer.
+	summaries := make(map[string]*actionSummary)
+	for _, root := range roots {
+		summary := *root.summary
+		if summary.Err == "" {
+			var diags []gobDiagnostic
+			diags = append(diags, summary.Diagnostics...)
+			for _, d := range cached {
+				if d.Analyzer == root.stableName {
+					diags = append(diags, d.Diagnostic)
+				}
+			}
+			sort.SliceStable(diags, func(i, j int) bool {
+				return protocol.CompareLocation(diags[i].Location, diags[j].Location) < 0
+			})
+			summary.Diagnostics = diags
+		}
+		summaries[root.stableName] = &summary
+	}
+	return summaries
+}
+
+// declUnits returns the top-level declarations of the package, in
+// order. Each function declaration with a body is keyed by the inputs
+// to the analysis of the package other than its files, by the
+// skeleton of the package, and by the text of the declaration.
+//
+// The skeleton of a package is the text of its files with function
+// bodies elided. It determines the types of the package, so an edit
+// within a function body invalidates only the results for that
+// function.
+func (an *analysisNode) declUnits(pkg *analysisPackage, analyzers []*analysis.Analyzer) []*declUnit {
+	inputs := an.inputsKey(analyzers, nil)
+	skeleton := sha256.New()
+	skeleton.Write(inputs[:])
+	for _, pgf := range pkg.parsed {
+		fmt.Fprintf(skeleton, "file: %s\n", pgf.URI)
+		start := 0
+		for _, decl := range pgf.File.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				lbrace, rbrace, err := safetoken.Offsets(pgf.Tok, fn.Body.Lbrace, fn.Body.Rbrace)
+				if err != nil || lbrace < start {
+					continue // can't happen in a package without parse errors
+				}
+				skeleton.Write(pgf.Src[start:lbrace])
+				skeleton.Write([]byte("{}"))
+				start = rbrace + 1
+			}
+		}
+		skeleton.Write(pgf.Src[start:])
+	}
+	var skeletonHash [sha256.Size]byte
+	skeleton.Sum(skeletonHash[:0])
+
+	var units []*declUnit
+	for i, pgf := range pkg.parsed {
+		var (
+			prev    *declUnit
+			prevEnd int
+		)
+		for _, decl := range pgf.File.Decls {
+			u := &declUnit{file: i, pgf: pgf, decl: decl}
+			units = append(units, u)
+
+			// A unit extends from the start of the first line of
+			// the declaration, including its doc comment.
+			pos := decl.Pos()
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Doc != nil {
+					pos = decl.Doc.Pos()
+				}
+			case *ast.GenDecl:
+				if decl.Doc != nil {
+					pos = decl.Doc.Pos()
+				}
+			}
+			start, end, err := safetoken.Offsets(pgf.Tok, pos, decl.End())
+			if err != nil {
+				continue
+			}
+			start = bytes.LastIndexByte(pgf.Src[:start], '\n') + 1
+			u.rng, err = pgf.Mapper.OffsetRange(start, end)
+			if err != nil {
+				continue
+			}
+
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				h := sha256.New()
+				h.Write(skeletonHash[:])
+				fmt.Fprintf(h, "decl: %s\n", pgf.URI)
+				h.Write(pgf.Src[start:end])
+				h.Sum(u.key[:0])
+			}
+
+			// Declarations that share a line cannot be
+			// relocated independently.
+			if prev != nil && start < prevEnd {
+				prev.key = [sha256.Size]byte{}
+				u.key = [sha256.Size]byte{}
+			}
+			prev, prevEnd = u, end
+		}
+	}
+	return units
+}
+
+// containingUnit returns the unit that contains the location, or nil.
+func containingUnit(units []*declUnit, loc protocol.Location) *declUnit {
+	for _, u := range units {
+		if u.contains(loc) {
+			return u
+		}
+	}
+	return nil
+}
+
+// contains reports whether the unit contains the location.
+func (u *declUnit) contains(loc protocol.Location) bool {
+	return loc.URI == u.pgf.URI &&
+		protocol.ComparePosition(u.rng.Start, loc.Range.Start) <= 0 &&
+		protocol.ComparePosition(loc.Range.End, u.rng.End) <= 0
+}
+
+// containsAll reports whether the unit contains all the locations of
+// the diagnostic, including those of its related information and
+// suggested fixes.
+func (u *declUnit) containsAll(diag gobDiagnostic) bool {
+	if !u.contains(diag.Location) {
+		return false
+	}
+	for _, rel := range diag.Related {
+		if !u.contains(rel.Location) {
+			return false
+		}
+	}
+	for _, fix := range diag.SuggestedFixes {
+		for _, edit := range fix.TextEdits {
+			if !u.contains(edit.Location) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// moveDiagnostic returns a copy of the diagnostic whose locations are
+// displaced by the specified number of lines.
+func moveDiagnostic(diag gobDiagnostic, delta int) gobDiagnostic {
+	move := func(loc protocol.Location) protocol.Location {
+		loc.Range.Start.Line = uint32(int(loc.Range.Start.Line) + delta)
+		loc.Range.End.Line = uint32(int(loc.Range.End.Line) + delta)
+		return loc
+	}
+	diag.Location = move(diag.Location)
+	diag.Related = slices.Clone(diag.Related)
+	for i := range diag.Related {
+		diag.Related[i].Location = move(diag.Related[i].Location)
+	}
+	diag.SuggestedFixes = slices.Clone(diag.SuggestedFixes)
+	for i := range diag.SuggestedFixes {
+		fix := &diag.SuggestedFixes[i]
+		fix.TextEdits = slices.Clone(fix.TextEdits)
+		for j := range fix.TextEdits {
+			fix.TextEdits[j].Location = move(fix.TextEdits[j].Location)
+		}
+	}
+	return diag
+}
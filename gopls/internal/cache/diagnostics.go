@@ -7,6 +7,7 @@ package cache
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/util/bug"
@@ -75,10 +76,18 @@ const (
 	UnknownError             DiagnosticSource = "<Unknown source>"
 	ListError                DiagnosticSource = "go list"
 	ParseError               DiagnosticSource = "syntax"
+	EncodingWarning          DiagnosticSource = "encoding"
 	TypeError                DiagnosticSource = "compiler"
 	ModTidyError             DiagnosticSource = "go mod tidy"
 	OptimizationDetailsError DiagnosticSource = "optimizer details"
+	DeepAnalysis             DiagnosticSource = "deep analysis"
+	DeadCode                 DiagnosticSource = "dead code"
+	Spelling                 DiagnosticSource = "spelling"
+	Clones                   DiagnosticSource = "clones"
+	APICompatibility         DiagnosticSource = "api compatibility"
+	InterfaceChanges         DiagnosticSource = "interface changes"
 	UpgradeNotification      DiagnosticSource = "upgrade available"
+	DependencyUpdate         DiagnosticSource = "dependency update"
 	Vulncheck                DiagnosticSource = "vulncheck imports"
 	Govulncheck              DiagnosticSource = "govulncheck"
 	TemplateError            DiagnosticSource = "template"
@@ -86,6 +95,60 @@ const (
 	ConsistencyInfo          DiagnosticSource = "consistency"
 )
 
+// sourcePaths maps each built-in diagnostic source to its
+// hierarchical name; see [DiagnosticSource.Path].
+var sourcePaths = map[DiagnosticSource]string{
+	UnknownError:             "unknown",
+	ListError:                "go/list",
+	ParseError:               "compiler/syntax",
+	EncodingWarning:          "gopls/encoding",
+	TypeError:                "compiler",
+	ModTidyError:             "go/mod-tidy",
+	OptimizationDetailsError: "compiler/optimizer-details",
+	DeepAnalysis:             "gopls/deep-analysis",
+	DeadCode:                 "gopls/deadcode",
+	Spelling:                 "gopls/spelling",
+	Clones:                   "gopls/clones",
+	APICompatibility:         "gopls/api-compatibility",
+	InterfaceChanges:         "gopls/interface-changes",
+	UpgradeNotification:      "upgrade-advisor/upgrade",
+	DependencyUpdate:         "upgrade-advisor/dependency-update",
+	Vulncheck:                "vulncheck/imports",
+	Govulncheck:              "vulncheck/govulncheck",
+	TemplateError:            "template",
+	WorkFileError:            "go/work",
+	ConsistencyInfo:          "gopls/consistency",
+}
+
+// Path returns the hierarchical name of the diagnostic source, a
+// slash-separated path such as "compiler/syntax" or
+// "analyzer/printf", for use in filters: a filter matches a source
+// if it is the source's path or one of its ancestors, so "analyzer"
+// matches the diagnostics of all analyzers.
+//
+// Any source other than the built-in ones is the name of an analyzer.
+func (src DiagnosticSource) Path() string {
+	if path, ok := sourcePaths[src]; ok {
+		return path
+	}
+	return "analyzer/" + string(src)
+}
+
+// AnalyzerName returns the name of the analyzer whose diagnostics
+// have the specified hierarchical source path (see
+// [DiagnosticSource.Path]), as published to the client. It reports
+// false if the path is not that of an analyzer.
+func AnalyzerName(path string) (string, bool) {
+	return strings.CutPrefix(path, "analyzer/")
+}
+
+// MatchesSourceFilter reports whether the hierarchical source path
+// matches the filter, that is, whether the filter is the path or one
+// of its ancestors.
+func MatchesSourceFilter(path, filter string) bool {
+	return path == filter || strings.HasPrefix(path, filter+"/")
+}
+
 // A SuggestedFix represents a suggested fix (for a diagnostic)
 // produced by analysis, in protocol form.
 //
@@ -9,8 +9,10 @@ package cache
 // golang.Diagnostic form, and suggesting quick fixes.
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"go/build/constraint"
 	"go/parser"
 	"go/scanner"
 	"go/token"
@@ -28,7 +30,9 @@ import (
 	"golang.org/x/tools/gopls/internal/protocol/command"
 	"golang.org/x/tools/gopls/internal/settings"
 	"golang.org/x/tools/gopls/internal/util/bug"
+	"golang.org/x/tools/gopls/internal/util/slices"
 	"golang.org/x/tools/internal/typesinternal"
+	"golang.org/x/tools/internal/versions"
 )
 
 // goPackagesErrorDiagnostics translates the given go/packages Error into a
@@ -126,9 +130,66 @@ func parseErrorDiagnostics(pkg *syntaxPackage, errList scanner.ErrorList) ([]*Di
 	}}, nil
 }
 
+// byteOrderMarkDiagnostic returns a warning, with a fix to remove it,
+// for the byte order mark at the start of a file, if any. The Go
+// toolchain ignores it, but many other tools do not, and editors may
+// hide it, so that the positions of the first line disagree.
+func byteOrderMarkDiagnostic(pgf *parsego.File) (*Diagnostic, error) {
+	const bom = "\uFEFF"
+	if !bytes.HasPrefix(pgf.Src, []byte(bom)) {
+		return nil, nil
+	}
+	rng, err := pgf.Mapper.OffsetRange(0, len(bom))
+	if err != nil {
+		return nil, err
+	}
+	return &Diagnostic{
+		URI:      pgf.URI,
+		Range:    rng,
+		Severity: protocol.SeverityWarning,
+		Source:   EncodingWarning,
+		Message:  "file begins with a byte order mark (BOM)",
+		SuggestedFixes: []SuggestedFix{{
+			Title:      "Remove byte order mark",
+			Edits:      map[protocol.DocumentURI][]protocol.TextEdit{pgf.URI: {{Range: rng}}},
+			ActionKind: protocol.QuickFix,
+		}},
+	}, nil
+}
+
 var importErrorRe = regexp.MustCompile(`could not import ([^\s]+)`)
 var unsupportedFeatureRe = regexp.MustCompile(`.*require.* go(\d+\.\d+) or later`)
 
+// limitedByGoDirective reports whether a language feature requiring the
+// given Go version is unavailable in the file because of the module's go
+// directive, goVersion (if known), rather than a build constraint of the
+// file. Only then can the use be fixed by upgrading the go directive.
+func limitedByGoDirective(required, goVersion string, pgf *parsego.File) bool {
+	if goVersion != "" && !versions.Before("go"+goVersion, "go"+required) {
+		return false
+	}
+	constrained := false
+	walkConstraints(pgf.File, func(c constraint.Expr) bool {
+		if tag, ok := c.(*constraint.TagExpr); ok && strings.HasPrefix(tag.Tag, "go1") {
+			constrained = true
+			return false
+		}
+		return true
+	})
+	return !constrained
+}
+
+// explainUnsupportedFeature returns the message of a type error
+// reporting the use of a language feature that requires a Go version
+// newer than that of the file, with an explanation appended if the
+// version is that of the module's go directive, goVersion.
+func explainUnsupportedFeature(msg, required, goVersion string, pgf *parsego.File) string {
+	if goVersion == "" || !limitedByGoDirective(required, goVersion, pgf) {
+		return msg
+	}
+	return fmt.Sprintf("%s, but go.mod declares go %s", msg, goVersion)
+}
+
 func goGetQuickFixes(haveModule bool, uri protocol.DocumentURI, pkg string) []SuggestedFix {
 	// Go get only supports module mode for now.
 	if !haveModule {
@@ -147,12 +208,25 @@ func goGetQuickFixes(haveModule bool, uri protocol.DocumentURI, pkg string) []Su
 	return []SuggestedFix{SuggestedFixFromCommand(cmd, protocol.QuickFix)}
 }
 
+func createPackageQuickFixes(uri protocol.DocumentURI, pkg string) []SuggestedFix {
+	title := fmt.Sprintf("Create package %v", pkg)
+	cmd, err := command.NewCreatePackageCommand(title, command.CreatePackageArgs{
+		URI: uri,
+		Pkg: pkg,
+	})
+	if err != nil {
+		bug.Reportf("internal error building 'create package' fix: %v", err)
+		return nil
+	}
+	return []SuggestedFix{SuggestedFixFromCommand(cmd, protocol.QuickFix)}
+}
+
 func editGoDirectiveQuickFix(haveModule bool, uri protocol.DocumentURI, version string) []SuggestedFix {
-	// Go mod edit only supports module mode.
+	// The go directive only exists in module mode.
 	if !haveModule {
 		return nil
 	}
-	title := fmt.Sprintf("go mod edit -go=%s", version)
+	title := fmt.Sprintf("Upgrade go directive to %s", version)
 	cmd, err := command.NewEditGoDirectiveCommand(title, command.EditGoDirectiveArgs{
 		URI:     uri,
 		Version: version,
@@ -279,11 +353,23 @@ func toSourceDiagnostic(srcAnalyzer *settings.Analyzer, gobDiag *gobDiagnostic)
 		related = append(related, protocol.DiagnosticRelatedInformation(gobRelated))
 	}
 
+	// The user's configuration of the analyzer takes
+	// precedence over the diagnostic's own hint.
 	severity := srcAnalyzer.Severity()
+	if severity == 0 {
+		severity = gobDiag.Severity
+	}
 	if severity == 0 {
 		severity = protocol.SeverityWarning
 	}
 
+	tags := append([]protocol.DiagnosticTag(nil), srcAnalyzer.Tags()...)
+	for _, tag := range gobDiag.Tags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
 	diag := &Diagnostic{
 		URI:      gobDiag.Location.URI,
 		Range:    gobDiag.Location.Range,
@@ -293,7 +379,7 @@ func toSourceDiagnostic(srcAnalyzer *settings.Analyzer, gobDiag *gobDiagnostic)
 		Source:   DiagnosticSource(gobDiag.Source),
 		Message:  gobDiag.Message,
 		Related:  related,
-		Tags:     srcAnalyzer.Tags(),
+		Tags:     tags,
 	}
 
 	// We cross the set of fixes (whether edit- or command-based)
@@ -333,7 +419,7 @@ func toSourceDiagnostic(srcAnalyzer *settings.Analyzer, gobDiag *gobDiagnostic)
 			//
 			// The analysis.Diagnostic.Category is used as the fix name.
 			cmd, err := command.NewApplyFixCommand(fix.Message, command.ApplyFixArgs{
-				Fix:   diag.Code,
+				Fix:   gobDiag.Category,
 				URI:   gobDiag.Location.URI,
 				Range: gobDiag.Location.Range,
 			})
@@ -348,8 +434,8 @@ func toSourceDiagnostic(srcAnalyzer *settings.Analyzer, gobDiag *gobDiagnostic)
 			// Ensure that the analyzer specifies a category for all its no-edit fixes.
 			// This is asserted by analysistest.RunWithSuggestedFixes, but there
 			// may be gaps in test coverage.
-			if diag.Code == "" || diag.Code == "default" {
-				bug.Reportf("missing Diagnostic.Code: %#v", *diag)
+			if gobDiag.Category == "" || gobDiag.Category == "default" {
+				bug.Reportf("missing Diagnostic.Category: %#v", *diag)
 			}
 		}
 	}
@@ -199,6 +199,7 @@ func (s *importsState) runProcessEnvFunc(ctx context.Context, snapshot *Snapshot
 		TabWidth:    8,
 		Env:         s.processEnv,
 		LocalPrefix: snapshot.Options().Local,
+		Groups:      snapshot.Options().ImportGroups,
 	}
 
 	if err := fn(ctx, opts); err != nil {
@@ -53,6 +53,7 @@ func (s *Snapshot) load(ctx context.Context, allowNetwork bool, scopes ...loadSc
 
 	var query []string
 	var standalone bool // whether this is a load of a standalone file
+	var scratch bool    // whether this is a load of a file in a scratch directory
 
 	// Keep track of module query -> module path so that we can later correlate query
 	// errors with errors.
@@ -83,7 +84,8 @@ func (s *Snapshot) load(ctx context.Context, allowNetwork bool, scopes ...loadSc
 			if err != nil {
 				continue
 			}
-			if isStandaloneFile(contents, s.Options().StandaloneTags) {
+			scratch = s.view.isScratchFile(uri)
+			if scratch || isStandaloneFile(contents, s.Options().StandaloneTags) {
 				standalone = true
 				query = append(query, uri.Path())
 			} else {
@@ -132,7 +134,7 @@ func (s *Snapshot) load(ctx context.Context, allowNetwork bool, scopes ...loadSc
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 	defer cancel()
 
-	cfg := s.config(ctx, inv)
+	cfg := s.config(ctx, inv, s.loadTests(scopes))
 	pkgs, err := packages.Load(cfg, query...)
 
 	// If the context was canceled, return early. Otherwise, we might be
@@ -254,12 +256,14 @@ func (s *Snapshot) load(ctx context.Context, allowNetwork bool, scopes ...loadSc
 			continue
 		}
 		// Skip filtered packages. They may be added anyway if they're
-		// dependencies of non-filtered packages.
+		// dependencies of non-filtered packages. The files of scratch
+		// directories are filtered only from the packages of the
+		// enclosing module.
 		//
 		// TODO(rfindley): why exclude metadata arbitrarily here? It should be safe
 		// to capture all metadata.
 		// TODO(rfindley): what about compiled go files?
-		if allFilesExcluded(pkg.GoFiles, filterFunc) {
+		if allFilesExcluded(pkg.GoFiles, filterFunc) && !(scratch && metadata.IsCommandLineArguments(PackageID(pkg.ID))) {
 			continue
 		}
 		buildMetadata(newMetadata, pkg, cfg.Dir, standalone, s.view.typ != GoPackagesDriverView)
@@ -794,6 +798,46 @@ checkURIs:
 	return true
 }
 
+// loadTests reports whether a load of the given scopes should include the
+// test variants of the loaded packages.
+//
+// Test variants are always loaded unless the DeferTestVariants option is
+// set, in which case they are loaded only for test files, for packages
+// whose test variants are already loaded (so that reloading them does
+// not discard their test variants), or once the test variants of the
+// whole view have been requested by LoadTestVariants.
+func (s *Snapshot) loadTests(scopes []loadScope) bool {
+	if !s.Options().DeferTestVariants || s.view.testsRequested.Load() {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tested map[PackagePath]bool // packages with loaded test variants
+	for _, scope := range scopes {
+		switch scope := scope.(type) {
+		case fileLoadScope:
+			if strings.HasSuffix(protocol.DocumentURI(scope).Path(), "_test.go") {
+				return true
+			}
+		case packageLoadScope:
+			if tested == nil {
+				tested = make(map[PackagePath]bool)
+				for _, mp := range s.meta.Packages {
+					if mp.ForTest != "" {
+						tested[mp.ForTest] = true
+					}
+				}
+			}
+			if tested[PackagePath(scope)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func isTestMain(pkg *packages.Package, gocache string) bool {
 	// Test mains must have an import path that ends with ".test".
 	if !strings.HasSuffix(pkg.PkgPath, ".test") {
@@ -36,6 +36,17 @@ package methodsets
 // Assignability thus reduces to a subset check on bitmasks
 // followed by equality checks on fingerprints.
 //
+// Methods whose types mention type parameters are excluded from the
+// mask; their fingerprints are related by unification (see unify.go),
+// so that, for example, a generic interface Getter[T] with method
+// Get() T is satisfied by a type whose Get method returns int.
+//
+// A constraint interface, whose type set is restricted by terms such
+// as ~int | ~float64, additionally records the fingerprints of those
+// terms, and each named type records its own fingerprint and that of
+// its underlying type, so that the types satisfying a constraint can
+// be found without the type checker.
+//
 // In earlier experiments, using 128-bit masks instead of 64 reduced
 // the number of candidates by about 2x. Using (like a Bloom filter) a
 // different hash function to compute a second 64-bit mask and
@@ -55,10 +66,11 @@ import (
 	"golang.org/x/tools/gopls/internal/util/frob"
 	"golang.org/x/tools/gopls/internal/util/safetoken"
 	"golang.org/x/tools/internal/aliases"
+	"golang.org/x/tools/internal/typeparams"
 )
 
-// An Index records the non-empty method sets of all package-level
-// types in a package in a form that permits assignability queries
+// An Index records the non-empty method sets (and type-set
+// restrictions) of all package-level types in a package in a form that permits assignability queries
 // without the type checker.
 type Index struct {
 	pkg gobPackage
@@ -98,15 +110,25 @@ type Key struct {
 }
 
 // KeyOf returns the search key for the method sets of a given type.
-// It returns false if the type has no methods.
+// It returns false if the type is an interface satisfied by every
+// type, or a type that no interface but 'any' could describe.
 func KeyOf(t types.Type) (Key, bool) {
 	mset := methodSetInfo(t, nil)
-	if mset.Mask == 0 {
-		return Key{}, false // no methods
+	if !mset.nontrivial() {
+		return Key{}, false // no methods or type restrictions
 	}
 	return Key{mset}, true
 }
 
+// Implements reports whether concrete type x implements interface
+// type y, according to their fingerprints. Unlike types.Implements,
+// it accepts uninstantiated generic types, reporting whether some
+// instantiation of one is related to the other. Like the global
+// search, it may report false positives for tricky types.
+func Implements(x, y types.Type) bool {
+	return satisfies(methodSetInfo(x, nil), methodSetInfo(y, nil))
+}
+
 // A Result reports a matching type or method in a method-set search.
 type Result struct {
 	Location Location // location of the type or method
@@ -175,17 +197,57 @@ func (index *Index) Search(key Key, methodID string) []Result {
 
 // satisfies does a fast check for whether x satisfies y.
 func satisfies(x, y gobMethodSet) bool {
-	return y.IsInterface && x.Mask&y.Mask == y.Mask && subset(y, x)
+	if !y.IsInterface || !x.Generic && x.Mask&y.Mask != y.Mask {
+		return false
+	}
+	var u unifier
+	return inTypeSet(&u, x, y) && subset(&u, y, 'y', x)
+}
+
+// inTypeSet reports whether x belongs to the type set of interface y,
+// disregarding methods.
+func inTypeSet(u *unifier, x, y gobMethodSet) bool {
+	if !y.Constraint {
+		return true
+	}
+	for _, term := range y.Terms {
+		fp := x.Type
+		if term.Tilde {
+			fp = x.Underlying
+		}
+		if fp == "" {
+			continue // x is an interface or struct
+		}
+		if fp == term.Type {
+			return true
+		}
+		if (x.Generic || y.Generic) && u.unifyTypes(fp, term.Type) {
+			return true
+		}
+	}
+	return false
 }
 
-// subset reports whether method set x is a subset of y.
-func subset(x, y gobMethodSet) bool {
+// subset reports whether method set x, from the given side of the
+// query, is a subset of y, binding the type parameters of either.
+func subset(u *unifier, x gobMethodSet, side byte, y gobMethodSet) bool {
 outer:
 	for _, mx := range x.Methods {
 		for _, my := range y.Methods {
 			if mx.Sum == my.Sum && mx.Fingerprint == my.Fingerprint {
 				continue outer // found; try next x method
 			}
+			if x.Generic || y.Generic {
+				unified := false
+				if side == 'x' {
+					unified = u.unifyMethods(mx.Fingerprint, my.Fingerprint)
+				} else {
+					unified = u.unifyMethods(my.Fingerprint, mx.Fingerprint)
+				}
+				if unified {
+					continue outer
+				}
+			}
 		}
 		return false // method of x not found in y
 	}
@@ -242,9 +304,10 @@ func (b *indexBuilder) build(fset *token.FileSet, pkg *types.Package) *Index {
 	scope := pkg.Scope()
 	for _, name := range scope.Names() {
 		if tname, ok := scope.Lookup(name).(*types.TypeName); ok && !tname.IsAlias() {
-			if mset := methodSetInfo(tname.Type(), setIndexInfo); mset.Mask != 0 {
+			if mset := methodSetInfo(tname.Type(), setIndexInfo); mset.nontrivial() {
 				mset.Posn = objectPos(tname)
-				// Only record types with non-trivial method sets.
+				// Only record types with non-trivial method sets
+				// or type sets.
 				b.MethodSets = append(b.MethodSets, mset)
 			}
 		}
@@ -279,7 +342,7 @@ func methodSetInfo(t types.Type, setIndexInfo func(*gobMethod, *types.Func)) gob
 
 	// Convert the method set into a compact summary.
 	var mask uint64
-	tricky := false
+	tricky, generic := false, false
 	methods := make([]gobMethod, mset.Len())
 	for i := 0; i < mset.Len(); i++ {
 		m := mset.At(i).Obj().(*types.Func)
@@ -292,14 +355,61 @@ func methodSetInfo(t types.Type, setIndexInfo func(*gobMethod, *types.Func)) gob
 		if setIndexInfo != nil {
 			setIndexInfo(&methods[i], m) // set Position, PkgPath, ObjectPath
 		}
+		if strings.Contains(fp, "?") {
+			// Methods that mention type parameters are
+			// matched by unification, not by the mask.
+			generic = true
+			continue
+		}
 		mask |= 1 << uint64(((sum>>24)^(sum>>16)^(sum>>8)^sum)&0x3f)
 	}
-	return gobMethodSet{
+	result := gobMethodSet{
 		IsInterface: types.IsInterface(t),
 		Tricky:      tricky,
+		Generic:     generic,
 		Mask:        mask,
 		Methods:     methods,
 	}
+
+	if iface, ok := t.Underlying().(*types.Interface); ok {
+		// Record the type restriction of a constraint interface.
+		if !iface.IsMethodSet() {
+			terms, err := typeparams.InterfaceTermSet(iface)
+			switch {
+			case err == typeparams.ErrEmptyTypeSet:
+				result.Constraint = true // satisfied by no type
+			case err != nil:
+				// Too complex to normalize; don't restrict.
+				result.Tricky = true
+			case terms != nil: // nil => all types
+				result.Constraint = true
+				for _, term := range terms {
+					fp, isTricky := typeFingerprint(term.Type())
+					result.Tricky = result.Tricky || isTricky
+					result.Generic = result.Generic || strings.Contains(fp, "?")
+					result.Terms = append(result.Terms, gobTerm{Tilde: term.Tilde(), Type: fp})
+				}
+			}
+		}
+	} else if named, ok := aliases.Unalias(typeparams.Deref(t)).(*types.Named); ok {
+		// Record the fingerprints of a named type and its
+		// underlying type, which may belong to the type set of
+		// a constraint. Structs are rare in type terms, and
+		// would make the index larger, so they are omitted.
+		fp, _ := typeFingerprint(named)
+		result.Type = fp
+		result.Generic = result.Generic || named.TypeParams().Len() > 0
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			result.Underlying, _ = typeFingerprint(named.Underlying())
+		}
+	}
+	return result
+}
+
+// nontrivial reports whether the method set has methods or its type
+// restricts the types that satisfy it or that it satisfies (beyond any).
+func (mset *gobMethodSet) nontrivial() bool {
+	return len(mset.Methods) > 0 || mset.Constraint || mset.Underlying != ""
 }
 
 // EnsurePointer wraps T in a types.Pointer if T is a named, non-interface type.
@@ -320,139 +430,173 @@ func EnsurePointer(T types.Type) types.Type {
 // the result was one of these tricky types.
 //
 // In the standard library, 99.8% of package-level types have a
-// non-tricky method-set.  The most common exceptions are due to type
-// parameters.
+// non-tricky method-set. The exceptions are mostly due to non-empty
+// interfaces in method signatures. Type parameters are not tricky:
+// they are encoded as "?N" and related by unification.
 //
 // The fingerprint string starts with method.Id() + "(".
 func fingerprint(method *types.Func) (string, bool) {
-	var buf strings.Builder
-	tricky := false
-	var fprint func(t types.Type)
-	fprint = func(t types.Type) {
-		switch t := t.(type) {
-		case *aliases.Alias:
-			fprint(aliases.Unalias(t))
-
-		case *types.Named:
-			tname := t.Obj()
-			if tname.Pkg() != nil {
-				buf.WriteString(strconv.Quote(tname.Pkg().Path()))
-				buf.WriteByte('.')
-			} else if tname.Name() != "error" && tname.Name() != "comparable" {
-				panic(tname) // error and comparable the only named types with no package
-			}
-			buf.WriteString(tname.Name())
-
-		case *types.Array:
-			fmt.Fprintf(&buf, "[%d]", t.Len())
-			fprint(t.Elem())
-
-		case *types.Slice:
-			buf.WriteString("[]")
-			fprint(t.Elem())
+	var fp fingerprinter
+	fp.buf.WriteString(method.Id()) // e.g. "pkg.Type"
+	sig := method.Type().(*types.Signature)
+	fp.fprint(sig.Params())
+	fp.fprint(sig.Results())
+	return fp.buf.String(), fp.tricky
+}
 
-		case *types.Pointer:
-			buf.WriteByte('*')
-			fprint(t.Elem())
+// typeFingerprint returns an encoding of a type, like the parameter
+// and result types within the fingerprint of a method.
+func typeFingerprint(t types.Type) (string, bool) {
+	var fp fingerprinter
+	fp.fprint(t)
+	return fp.buf.String(), fp.tricky
+}
 
-		case *types.Map:
-			buf.WriteString("map[")
-			fprint(t.Key())
-			buf.WriteByte(']')
-			fprint(t.Elem())
-
-		case *types.Chan:
-			switch t.Dir() {
-			case types.SendRecv:
-				buf.WriteString("chan ")
-			case types.SendOnly:
-				buf.WriteString("<-chan ")
-			case types.RecvOnly:
-				buf.WriteString("chan<- ")
-			}
-			fprint(t.Elem())
+// A fingerprinter accumulates the encoding of a fingerprint.
+type fingerprinter struct {
+	buf    strings.Builder
+	tricky bool
+}
 
-		case *types.Tuple:
-			buf.WriteByte('(')
-			for i := 0; i < t.Len(); i++ {
+func (fp *fingerprinter) fprint(t types.Type) {
+	buf := &fp.buf
+	switch t := t.(type) {
+	case *aliases.Alias:
+		fp.fprint(aliases.Unalias(t))
+
+	case *types.Named:
+		tname := t.Obj()
+		if tname.Pkg() != nil {
+			buf.WriteString(strconv.Quote(tname.Pkg().Path()))
+			buf.WriteByte('.')
+		} else if tname.Name() != "error" && tname.Name() != "comparable" {
+			panic(tname) // error and comparable the only named types with no package
+		}
+		buf.WriteString(tname.Name())
+		// Record type arguments, or for an uninstantiated
+		// generic type, its type parameters.
+		if targs := t.TypeArgs(); targs.Len() > 0 {
+			buf.WriteByte('[')
+			for i := 0; i < targs.Len(); i++ {
 				if i > 0 {
 					buf.WriteByte(',')
 				}
-				fprint(t.At(i).Type())
+				fp.fprint(targs.At(i))
 			}
-			buf.WriteByte(')')
-
-		case *types.Basic:
-			// Use canonical names for uint8 and int32 aliases.
-			switch t.Kind() {
-			case types.Byte:
-				buf.WriteString("byte")
-			case types.Rune:
-				buf.WriteString("rune")
-			default:
-				buf.WriteString(t.String())
+			buf.WriteByte(']')
+		} else if tparams := t.TypeParams(); tparams.Len() > 0 {
+			buf.WriteByte('[')
+			for i := 0; i < tparams.Len(); i++ {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				fp.fprint(tparams.At(i))
 			}
+			buf.WriteByte(']')
+		}
+
+	case *types.Array:
+		fmt.Fprintf(buf, "[%d]", t.Len())
+		fp.fprint(t.Elem())
+
+	case *types.Slice:
+		buf.WriteString("[]")
+		fp.fprint(t.Elem())
+
+	case *types.Pointer:
+		buf.WriteByte('*')
+		fp.fprint(t.Elem())
+
+	case *types.Map:
+		buf.WriteString("map[")
+		fp.fprint(t.Key())
+		buf.WriteByte(']')
+		fp.fprint(t.Elem())
+
+	case *types.Chan:
+		switch t.Dir() {
+		case types.SendRecv:
+			buf.WriteString("chan ")
+		case types.SendOnly:
+			buf.WriteString("<-chan ")
+		case types.RecvOnly:
+			buf.WriteString("chan<- ")
+		}
+		fp.fprint(t.Elem())
 
-		case *types.Signature:
-			buf.WriteString("func")
-			fprint(t.Params())
-			if t.Variadic() {
-				buf.WriteString("...") // not quite Go syntax
+	case *types.Tuple:
+		buf.WriteByte('(')
+		for i := 0; i < t.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
 			}
-			fprint(t.Results())
+			fp.fprint(t.At(i).Type())
+		}
+		buf.WriteByte(')')
+
+	case *types.Basic:
+		// Use canonical names for uint8 and int32 aliases.
+		switch t.Kind() {
+		case types.Byte:
+			buf.WriteString("byte")
+		case types.Rune:
+			buf.WriteString("rune")
+		default:
+			buf.WriteString(t.String())
+		}
 
-		case *types.Struct:
-			// Non-empty unnamed struct types in method
-			// signatures are vanishingly rare.
-			buf.WriteString("struct{")
-			for i := 0; i < t.NumFields(); i++ {
-				if i > 0 {
-					buf.WriteByte(';')
-				}
-				f := t.Field(i)
-				// This isn't quite right for embedded type aliases.
-				// (See types.TypeString(StructType) and #44410 for context.)
-				// But this is vanishingly rare.
-				if !f.Embedded() {
-					buf.WriteString(f.Id())
-					buf.WriteByte(' ')
-				}
-				fprint(f.Type())
-				if tag := t.Tag(i); tag != "" {
-					buf.WriteByte(' ')
-					buf.WriteString(strconv.Quote(tag))
-				}
+	case *types.Signature:
+		buf.WriteString("func")
+		fp.fprint(t.Params())
+		if t.Variadic() {
+			buf.WriteString("...") // not quite Go syntax
+		}
+		fp.fprint(t.Results())
+
+	case *types.Struct:
+		// Non-empty unnamed struct types in method
+		// signatures are vanishingly rare.
+		buf.WriteString("struct{")
+		for i := 0; i < t.NumFields(); i++ {
+			if i > 0 {
+				buf.WriteByte(';')
 			}
-			buf.WriteString("}")
-
-		case *types.Interface:
-			if t.NumMethods() == 0 {
-				buf.WriteString("any") // common case
-			} else {
-				// Interface assignability is particularly
-				// tricky due to the possibility of recursion.
-				tricky = true
-				// We could still give more disambiguating precision
-				// than "..." if we wanted to.
-				buf.WriteString("interface{...}")
+			f := t.Field(i)
+			// This isn't quite right for embedded type aliases.
+			// (See types.TypeString(StructType) and #44410 for context.)
+			// But this is vanishingly rare.
+			if !f.Embedded() {
+				buf.WriteString(f.Id())
+				buf.WriteByte(' ')
 			}
+			fp.fprint(f.Type())
+			if tag := t.Tag(i); tag != "" {
+				buf.WriteByte(' ')
+				buf.WriteString(strconv.Quote(tag))
+			}
+		}
+		buf.WriteString("}")
 
-		case *types.TypeParam:
-			tricky = true
-			// TODO(adonovan): refine this by adding a numeric suffix
-			// indicating the index among the receiver type's parameters.
-			buf.WriteByte('?')
-
-		default: // incl. *types.Union
-			panic(t)
+	case *types.Interface:
+		if t.NumMethods() == 0 {
+			buf.WriteString("any") // common case
+		} else {
+			// Interface assignability is particularly
+			// tricky due to the possibility of recursion.
+			fp.tricky = true
+			// We could still give more disambiguating precision
+			// than "..." if we wanted to.
+			buf.WriteString("interface{...}")
 		}
-	}
 
-	buf.WriteString(method.Id()) // e.g. "pkg.Type"
-	sig := method.Type().(*types.Signature)
-	fprint(sig.Params())
-	fprint(sig.Results())
-	return buf.String(), tricky
+	case *types.TypeParam:
+		// The index among the receiver type's parameters
+		// distinguishes them, for unification.
+		fmt.Fprintf(buf, "?%d", t.Index())
+
+	default: // incl. *types.Union
+		panic(t)
+	}
 }
 
 // -- serial format of index --
@@ -471,8 +615,23 @@ type gobMethodSet struct {
 	Posn        gobPosition
 	IsInterface bool
 	Tricky      bool   // at least one method is tricky; assignability requires go/types
-	Mask        uint64 // mask with 1 bit from each of methods[*].sum
+	Generic     bool   // the type or some method mentions type parameters
+	Mask        uint64 // mask with 1 bit from each of methods[*].sum, except generic ones
 	Methods     []gobMethod
+
+	// named non-interface types only:
+	Type       string // fingerprint of the type
+	Underlying string // fingerprint of the underlying type, unless a struct
+
+	// constraint interfaces only:
+	Constraint bool      // type set is restricted to the types of Terms
+	Terms      []gobTerm // normalized terms of the type set
+}
+
+// A gobTerm records a term of the type set of a constraint interface.
+type gobTerm struct {
+	Tilde bool   // the term is ~Type
+	Type  string // fingerprint of the term's type
 }
 
 // A gobMethod records the name, type, and position of a single method.
@@ -0,0 +1,332 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package methodsets
+
+// This file defines the unification of fingerprints that mention type
+// parameters, which lets the index relate generic types to their
+// instantiations (e.g. List[T] to a type with methods of List[int]).
+//
+// A type parameter appears in a fingerprint as "?N", where N is its
+// index among the type parameters of the receiver type. Each side of
+// a query has its own type parameters, so variables are qualified by
+// side. A single substitution is shared by all the methods and terms
+// of a query: the type parameters of a generic type must be
+// instantiated the same way throughout.
+//
+// Structs and non-empty interfaces within fingerprints are treated as
+// opaque atoms, compared textually.
+
+import (
+	"strconv"
+	"strings"
+)
+
+// A fpTerm is a parsed type fingerprint.
+type fpTerm struct {
+	head  string    // constructor, e.g. `"p".List`, "[]", "*", "int", or a variable name
+	isVar bool      // head names a type parameter
+	args  []*fpTerm // operands, e.g. type arguments or element type
+}
+
+// parseType parses a type fingerprint, qualifying its type parameters
+// by side. It returns nil if the fingerprint is malformed.
+func parseType(fp string, side byte) *fpTerm {
+	p := fpParser{s: fp, side: side}
+	t := p.typ()
+	if p.err || p.i != len(p.s) {
+		return nil
+	}
+	return t
+}
+
+// parseMethod parses a method fingerprint, of the form
+// "methodID(params...)(results)", returning the method ID and
+// the term for the method type. It returns a nil term if the
+// fingerprint is malformed.
+func parseMethod(fp string, side byte) (string, *fpTerm) {
+	paren := strings.IndexByte(fp, '(')
+	if paren < 0 {
+		return fp, nil
+	}
+	p := fpParser{s: fp, i: paren, side: side}
+	params := p.tuple()
+	results := p.tuple()
+	if p.err || p.i != len(p.s) {
+		return fp[:paren], nil
+	}
+	return fp[:paren], &fpTerm{head: "method", args: []*fpTerm{params, results}}
+}
+
+// A fpParser parses the fingerprint syntax produced by fingerprint.
+type fpParser struct {
+	s    string
+	i    int
+	side byte
+	err  bool
+}
+
+func (p *fpParser) fail() *fpTerm {
+	p.err = true
+	return &fpTerm{}
+}
+
+func (p *fpParser) consume(prefix string) bool {
+	if strings.HasPrefix(p.s[p.i:], prefix) {
+		p.i += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *fpParser) typ() *fpTerm {
+	if p.err || p.i >= len(p.s) {
+		return p.fail()
+	}
+	switch {
+	case p.s[p.i] == '?':
+		p.i++
+		return &fpTerm{head: string(p.side) + "?" + p.digits(), isVar: true}
+
+	case p.s[p.i] == '"':
+		// qualified named type, with optional type arguments
+		path, err := strconv.QuotedPrefix(p.s[p.i:])
+		if err != nil {
+			return p.fail()
+		}
+		p.i += len(path)
+		if !p.consume(".") {
+			return p.fail()
+		}
+		t := &fpTerm{head: path + "." + p.ident()}
+		if p.consume("[") {
+			t.args = p.list(']')
+		}
+		return t
+
+	case p.consume("[]"):
+		return &fpTerm{head: "[]", args: []*fpTerm{p.typ()}}
+
+	case p.consume("["):
+		n := p.digits()
+		if !p.consume("]") {
+			return p.fail()
+		}
+		return &fpTerm{head: "[" + n + "]", args: []*fpTerm{p.typ()}}
+
+	case p.consume("*"):
+		return &fpTerm{head: "*", args: []*fpTerm{p.typ()}}
+
+	case p.consume("map["):
+		key := p.typ()
+		if !p.consume("]") {
+			return p.fail()
+		}
+		return &fpTerm{head: "map", args: []*fpTerm{key, p.typ()}}
+
+	case p.consume("chan "):
+		return &fpTerm{head: "chan", args: []*fpTerm{p.typ()}}
+
+	case p.consume("<-chan "):
+		return &fpTerm{head: "<-chan", args: []*fpTerm{p.typ()}}
+
+	case p.consume("chan<- "):
+		return &fpTerm{head: "chan<-", args: []*fpTerm{p.typ()}}
+
+	case p.s[p.i] == '(':
+		return p.tuple()
+
+	case p.consume("func"):
+		params := p.tuple()
+		head := "func"
+		if p.consume("...") {
+			head = "func..."
+		}
+		return &fpTerm{head: head, args: []*fpTerm{params, p.tuple()}}
+
+	case strings.HasPrefix(p.s[p.i:], "struct{"):
+		return &fpTerm{head: p.braced()}
+
+	case p.consume("interface{...}"):
+		return &fpTerm{head: "interface{...}"}
+
+	default:
+		// unqualified named or basic type, e.g. error, int, unsafe.Pointer
+		if name := p.ident(); name != "" {
+			return &fpTerm{head: name}
+		}
+		return p.fail()
+	}
+}
+
+// tuple parses a parenthesized list of types.
+func (p *fpParser) tuple() *fpTerm {
+	if !p.consume("(") {
+		return p.fail()
+	}
+	return &fpTerm{head: "()", args: p.list(')')}
+}
+
+// list parses a comma-separated list of types up to and including
+// the closing delimiter.
+func (p *fpParser) list(close byte) []*fpTerm {
+	var elems []*fpTerm
+	if p.consume(string(close)) {
+		return elems
+	}
+	for !p.err {
+		elems = append(elems, p.typ())
+		if p.consume(string(close)) {
+			break
+		}
+		if !p.consume(",") {
+			p.fail()
+		}
+	}
+	return elems
+}
+
+func (p *fpParser) digits() string {
+	start := p.i
+	for p.i < len(p.s) && '0' <= p.s[p.i] && p.s[p.i] <= '9' {
+		p.i++
+	}
+	if p.i == start {
+		p.fail()
+	}
+	return p.s[start:p.i]
+}
+
+func (p *fpParser) ident() string {
+	start := p.i
+	for p.i < len(p.s) && !strings.ContainsRune("[](),;{}* ", rune(p.s[p.i])) {
+		p.i++
+	}
+	return p.s[start:p.i]
+}
+
+// braced returns the text of a struct type, up to its matching brace.
+func (p *fpParser) braced() string {
+	start, depth := p.i, 0
+	for p.i < len(p.s) {
+		switch p.s[p.i] {
+		case '"':
+			tag, err := strconv.QuotedPrefix(p.s[p.i:])
+			if err != nil {
+				p.fail()
+				return ""
+			}
+			p.i += len(tag)
+			continue
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p.i++
+				return p.s[start:p.i]
+			}
+		}
+		p.i++
+	}
+	p.fail()
+	return ""
+}
+
+// A unifier accumulates the bindings of type parameters made while
+// relating two method sets.
+type unifier struct {
+	subst map[string]*fpTerm
+}
+
+// try calls f, discarding any bindings it made if it fails.
+func (u *unifier) try(f func() bool) bool {
+	saved := make(map[string]*fpTerm, len(u.subst))
+	for k, v := range u.subst {
+		saved[k] = v
+	}
+	if f() {
+		return true
+	}
+	u.subst = saved
+	return false
+}
+
+// unifyMethods reports whether methods x and y, of sides 'x' and 'y',
+// have the same ID and unifiable types, binding type parameters.
+func (u *unifier) unifyMethods(x, y string) bool {
+	idx, tx := parseMethod(x, 'x')
+	idy, ty := parseMethod(y, 'y')
+	if idx != idy || tx == nil || ty == nil {
+		return false
+	}
+	return u.try(func() bool { return u.unify(tx, ty) })
+}
+
+// unifyTypes is like unifyMethods, for type fingerprints.
+func (u *unifier) unifyTypes(x, y string) bool {
+	tx, ty := parseType(x, 'x'), parseType(y, 'y')
+	if tx == nil || ty == nil {
+		return false
+	}
+	return u.try(func() bool { return u.unify(tx, ty) })
+}
+
+func (u *unifier) unify(x, y *fpTerm) bool {
+	x, y = u.resolve(x), u.resolve(y)
+	switch {
+	case x.isVar && y.isVar && x.head == y.head:
+		return true
+	case x.isVar:
+		return u.bind(x.head, y)
+	case y.isVar:
+		return u.bind(y.head, x)
+	}
+	if x.head != y.head || len(x.args) != len(y.args) {
+		return false
+	}
+	for i := range x.args {
+		if !u.unify(x.args[i], y.args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve returns the term bound to t, if t is a bound variable.
+func (u *unifier) resolve(t *fpTerm) *fpTerm {
+	for t.isVar {
+		bound, ok := u.subst[t.head]
+		if !ok {
+			break
+		}
+		t = bound
+	}
+	return t
+}
+
+// bind binds variable v to t, unless t mentions v.
+func (u *unifier) bind(v string, t *fpTerm) bool {
+	if u.occurs(v, t) {
+		return false
+	}
+	if u.subst == nil {
+		u.subst = make(map[string]*fpTerm)
+	}
+	u.subst[v] = t
+	return true
+}
+
+func (u *unifier) occurs(v string, t *fpTerm) bool {
+	t = u.resolve(t)
+	if t.isVar {
+		return t.head == v
+	}
+	for _, arg := range t.args {
+		if u.occurs(v, arg) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+)
+
+func TestMethodSetsVersionKey(t *testing.T) {
+	// handle returns a package handle for the package of the given path,
+	// module, and file, whose contents are identified by content.
+	handle := func(pkgPath string, mod *packages.Module, filename, content string) *packageHandle {
+		uri := protocol.URIFromPath(filename)
+		fh := &diskFile{uri: uri, content: []byte(content), hash: file.HashOf([]byte(content))}
+		return &packageHandle{
+			mp: &metadata.Package{
+				ID:              PackageID(pkgPath),
+				PkgPath:         PackagePath(pkgPath),
+				Module:          mod,
+				CompiledGoFiles: []protocol.DocumentURI{uri},
+			},
+			localInputs: typeCheckInputs{compiledGoFiles: []file.Handle{fh}},
+		}
+	}
+	v1 := &packages.Module{Path: "example.com/m", Version: "v1.0.0"}
+	v2 := &packages.Module{Path: "example.com/m", Version: "v2.0.0"}
+	workspace := &packages.Module{Path: "example.com/w", Main: true}
+	localReplace := &packages.Module{Path: "example.com/m", Version: "v1.0.0", Replace: &packages.Module{Path: "../m"}}
+	replace := &packages.Module{Path: "example.com/m", Version: "v1.0.0", Replace: &packages.Module{Path: "example.com/fork", Version: "v1.0.1"}}
+
+	for _, test := range []struct {
+		name string
+		ph   *packageHandle
+		ok   bool
+	}{
+		{"dependency", handle("example.com/m/p", v1, "/mod/example.com/m@v1.0.0/p/p.go", "package p"), true},
+		{"replaced", handle("example.com/m/p", replace, "/mod/example.com/fork@v1.0.1/p/p.go", "package p"), true},
+		{"workspace", handle("example.com/w/p", workspace, "/w/p/p.go", "package p"), false},
+		{"local replacement", handle("example.com/m/p", localReplace, "/m/p/p.go", "package p"), false},
+		{"standard", handle("io", nil, "/goroot/src/io/io.go", "package io"), true},
+		{"gopath", handle("example.com/p", nil, "/gopath/src/example.com/p/p.go", "package p"), false},
+	} {
+		if _, ok := methodSetsVersionKey(test.ph); ok != test.ok {
+			t.Errorf("%s: methodSetsVersionKey returned ok=%t, want %t", test.name, ok, test.ok)
+		}
+	}
+
+	// The key identifies the version and the contents of the files.
+	key := func(ph *packageHandle) file.Hash {
+		k, _ := methodSetsVersionKey(ph)
+		return k
+	}
+	base := key(handle("example.com/m/p", v1, "/mod/p/p.go", "package p"))
+	if base == key(handle("example.com/m/p", v2, "/mod/p/p.go", "package p")) {
+		t.Errorf("keys of different versions are equal")
+	}
+	if base == key(handle("example.com/m/p", v1, "/mod/p/p.go", "package p\n\nfunc F()")) {
+		t.Errorf("keys of different file contents are equal")
+	}
+	if base != key(handle("example.com/m/p", v1, "/mod/p/p.go", "package p")) {
+		t.Errorf("keys of the same package version are not equal")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/gocommand"
+	"golang.org/x/tools/internal/memoize"
+)
+
+// A ModuleUpdate describes what the go command reports about a module
+// required by a go.mod file: its newest version with the same major
+// version, whether its current version is retracted, and whether the
+// module is deprecated.
+type ModuleUpdate struct {
+	Version    string   // the required version
+	Update     string   // the newest version, if newer than Version
+	Retracted  []string // the rationale for the retraction of Version, if retracted
+	Latest     string   // the newest version that is not retracted, if Version is retracted and older than it
+	Deprecated string   // the deprecation message of the module, if deprecated
+}
+
+// ModUpdates returns the available updates, retractions, and
+// deprecations of the modules required by the given go.mod file, as
+// reported by "go list -m -u -retracted", keyed by module path.
+// Replaced modules are omitted. Concurrent requests are combined into
+// a single command.
+func (s *Snapshot) ModUpdates(ctx context.Context, modURI protocol.DocumentURI) (map[string]*ModuleUpdate, error) {
+	s.mu.Lock()
+	entry, hit := s.modUpdateHandles.Get(modURI)
+	s.mu.Unlock()
+
+	type modUpdates struct {
+		result map[string]*ModuleUpdate
+		err    error
+	}
+
+	// Cache miss?
+	if !hit {
+		handle := memoize.NewPromise("modUpdates", func(ctx context.Context, arg interface{}) interface{} {
+			result, err := modUpdatesImpl(ctx, arg.(*Snapshot), modURI)
+			return modUpdates{result, err}
+		})
+
+		entry = handle
+		s.mu.Lock()
+		s.modUpdateHandles.Set(modURI, entry, nil)
+		s.mu.Unlock()
+	}
+
+	// Await result.
+	v, err := s.awaitPromise(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	res := v.(modUpdates)
+	return res.result, res.err
+}
+
+func modUpdatesImpl(ctx context.Context, snapshot *Snapshot, modURI protocol.DocumentURI) (map[string]*ModuleUpdate, error) {
+	fh, err := snapshot.ReadFile(ctx, modURI)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := snapshot.ParseMod(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+	if len(pm.File.Require) == 0 {
+		return nil, nil
+	}
+	args := []string{"-mod=readonly", "-m", "-u", "-retracted", "-json"}
+	for _, req := range pm.File.Require {
+		args = append(args, req.Mod.Path)
+	}
+	inv, cleanup, err := snapshot.GoCommandInvocation(true, &gocommand.Invocation{
+		Verb:       "list",
+		Args:       args,
+		WorkingDir: filepath.Dir(modURI.Path()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	stdout, err := snapshot.View().GoCommandRunner().Run(ctx, *inv)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]*ModuleUpdate)
+	var stranded []string // retracted modules with no newer version
+	for dec := json.NewDecoder(stdout); dec.More(); {
+		var mod struct {
+			gocommand.ModuleJSON
+			Retracted  []string // with -retracted
+			Deprecated string   // with -u or -retracted
+		}
+		if err := dec.Decode(&mod); err != nil {
+			return nil, err
+		}
+		if mod.Replace != nil {
+			continue
+		}
+		update := &ModuleUpdate{
+			Version:    mod.Version,
+			Retracted:  mod.Retracted,
+			Deprecated: mod.Deprecated,
+		}
+		if mod.Update != nil {
+			update.Update = mod.Update.Version
+		}
+		if update.Retracted != nil && update.Update == "" {
+			stranded = append(stranded, mod.Path)
+		}
+		if update.Update != "" || update.Retracted != nil || update.Deprecated != "" {
+			updates[mod.Path] = update
+		}
+	}
+
+	// The only way off a retracted version with no newer version is
+	// down, to the newest version that is not retracted.
+	if len(stranded) > 0 {
+		args := []string{"-mod=readonly", "-m", "-e", "-json"}
+		for _, path := range stranded {
+			args = append(args, path+"@latest")
+		}
+		inv, cleanup, err := snapshot.GoCommandInvocation(true, &gocommand.Invocation{
+			Verb:       "list",
+			Args:       args,
+			WorkingDir: filepath.Dir(modURI.Path()),
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		stdout, err := snapshot.View().GoCommandRunner().Run(ctx, *inv)
+		if err != nil {
+			return nil, err
+		}
+		for dec := json.NewDecoder(stdout); dec.More(); {
+			var mod gocommand.ModuleJSON
+			if err := dec.Decode(&mod); err != nil {
+				return nil, err
+			}
+			if update, ok := updates[mod.Path]; ok && mod.Version != "" && semver.Compare(mod.Version, update.Version) < 0 {
+				update.Latest = mod.Version
+			}
+		}
+	}
+	return updates, nil
+}
+
+// SnoozedUpdate returns the version of the given module up to which
+// update notices are snoozed (see [StateChange.SnoozedUpdates]).
+func (s *Snapshot) SnoozedUpdate(modulePath string) (version string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	version, ok = s.snoozedUpdates[modulePath]
+	return version, ok
+}
@@ -39,6 +39,11 @@ type File struct {
 	fixedAST bool
 	Mapper   *protocol.Mapper // may map fixed Src, not file content
 	ParseErr scanner.ErrorList
+
+	// Generated reports whether the file has a "generated file"
+	// comment, such as "// Code generated by stringer; DO NOT EDIT.",
+	// as described at https://golang.org/s/generatedcode.
+	Generated bool
 }
 
 func (pgf File) String() string { return string(pgf.URI) }
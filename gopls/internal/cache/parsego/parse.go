@@ -13,6 +13,8 @@ import (
 	"go/scanner"
 	"go/token"
 	"reflect"
+	"regexp"
+	"unicode/utf8"
 
 	"golang.org/x/tools/gopls/internal/label"
 	"golang.org/x/tools/gopls/internal/protocol"
@@ -45,6 +47,18 @@ func Parse(ctx context.Context, fset *token.FileSet, uri protocol.DocumentURI, s
 	ctx, done := event.Start(ctx, "cache.ParseGoSrc", label.File.Of(uri.Path()))
 	defer done()
 
+	// Go source must be UTF-8 encoded. Rather than report an error
+	// for every invalid byte, parse a copy in which they are replaced
+	// by placeholders of the same length, and report only the first.
+	fixedSrc := false
+	encodingErr := -1 // offset of first invalid byte
+	if newSrc, offset := fixEncoding(src); newSrc != nil {
+		src = newSrc
+		encodingErr = offset
+		fixes = append(fixes, fixedEncoding)
+		fixedSrc = true
+	}
+
 	file, err := parser.ParseFile(fset, uri.Path(), src, mode)
 	var parseErr scanner.ErrorList
 	if err != nil {
@@ -61,7 +75,16 @@ func Parse(ctx context.Context, fset *token.FileSet, uri protocol.DocumentURI, s
 		tok.SetLinesForContent(src)
 	}
 
-	fixedSrc := false
+	if encodingErr >= 0 {
+		// The error must be reported even if the fixed file parses,
+		// both for the user and so that we don't try formatting it.
+		posn := safetoken.StartPosition(fset, tok.Pos(encodingErr))
+		parseErr = append(scanner.ErrorList{{
+			Pos: posn,
+			Msg: "invalid UTF-8 encoding: Go source files must be UTF-8 encoded",
+		}}, parseErr...)
+	}
+
 	fixedAST := false
 	// If there were parse errors, attempt to fix them up.
 	if parseErr != nil {
@@ -116,18 +139,37 @@ func Parse(ctx context.Context, fset *token.FileSet, uri protocol.DocumentURI, s
 	}
 
 	return &File{
-		URI:      uri,
-		Mode:     mode,
-		Src:      src,
-		fixedSrc: fixedSrc,
-		fixedAST: fixedAST,
-		File:     file,
-		Tok:      tok,
-		Mapper:   protocol.NewMapper(uri, src),
-		ParseErr: parseErr,
+		URI:       uri,
+		Mode:      mode,
+		Src:       src,
+		fixedSrc:  fixedSrc,
+		fixedAST:  fixedAST,
+		File:      file,
+		Tok:       tok,
+		Mapper:    protocol.NewMapper(uri, src),
+		ParseErr:  parseErr,
+		Generated: isGenerated(file, tok),
 	}, fixes
 }
 
+// Matches cgo generated comment as well as the proposed standard:
+//
+//	https://golang.org/s/generatedcode
+var generatedRx = regexp.MustCompile(`// .*DO NOT EDIT\.?`)
+
+// isGenerated reports whether the file has a "generated file" comment
+// at the beginning of a line.
+func isGenerated(file *ast.File, tok *token.File) bool {
+	for _, commentGroup := range file.Comments {
+		for _, comment := range commentGroup.List {
+			if generatedRx.MatchString(comment.Text) && safetoken.Position(tok, comment.Slash).Column == 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // fixAST inspects the AST and potentially modifies any *ast.BadStmts so that it can be
 // type-checked more effectively.
 //
@@ -232,8 +274,49 @@ const (
 	fixedInit
 	fixedPhantomSelector
 	fixedEmptySwitch
+	fixedEncoding
 )
 
+// fixEncoding returns a copy of src in which each byte that is not
+// part of a valid UTF-8 encoding is replaced by '?', and each byte
+// order mark after the start of the file by spaces, along with the
+// offset of the first replacement. It returns nil if src needs no
+// replacement.
+//
+// A byte order mark at the start of the file is permitted, and
+// ignored by the parser.
+func fixEncoding(src []byte) (newSrc []byte, offset int) {
+	start := 0
+	if bytes.HasPrefix(src, bom) {
+		start = len(bom)
+	}
+	if utf8.Valid(src[start:]) && !bytes.Contains(src[start:], bom) {
+		return nil, -1
+	}
+	newSrc = append([]byte(nil), src...)
+	offset = -1
+	for i := start; i < len(newSrc); {
+		r, size := utf8.DecodeRune(newSrc[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			newSrc[i] = '?'
+		case r == '\uFEFF':
+			copy(newSrc[i:], "   ")
+		default:
+			i += size
+			continue
+		}
+		if offset < 0 {
+			offset = i
+		}
+		i += size
+	}
+	return newSrc, offset
+}
+
+// bom is the UTF-8 encoding of the byte order mark, U+FEFF.
+var bom = []byte("\uFEFF")
+
 // fixSrc attempts to modify the file's source code to fix certain
 // syntax errors that leave the rest of the file unparsed.
 //
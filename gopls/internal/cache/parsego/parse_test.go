@@ -8,6 +8,7 @@ import (
 	"context"
 	"go/ast"
 	"go/token"
+	"strings"
 	"testing"
 
 	"golang.org/x/tools/gopls/internal/cache/parsego"
@@ -44,3 +45,26 @@ func _() {
 		return true
 	})
 }
+
+func TestInvalidEncoding(t *testing.T) {
+	// Invalid UTF-8 cannot appear in the content of open files, which
+	// clients send as JSON strings, but may appear in files on disk.
+	const src = "\uFEFFpackage p\n\n// caf\xe9 cr\xe8me\nconst s = \"\xff\uFEFF\"\n\nfunc F() {}\n"
+
+	pgf, _ := parsego.Parse(context.Background(), token.NewFileSet(), "file://p.go", []byte(src), parsego.Full, false)
+	if len(pgf.ParseErr) != 1 {
+		t.Fatalf("got parse errors %v, want exactly one", pgf.ParseErr)
+	}
+	if got, want := pgf.ParseErr[0].Pos.Offset, strings.Index(src, "\xe9"); got != want {
+		t.Errorf("parse error at offset %d, want %d", got, want)
+	}
+	if !strings.Contains(pgf.ParseErr[0].Msg, "invalid UTF-8") {
+		t.Errorf("parse error %q does not mention invalid UTF-8", pgf.ParseErr[0].Msg)
+	}
+	if len(pgf.Src) != len(src) {
+		t.Errorf("fixed source has length %d, want %d", len(pgf.Src), len(src))
+	}
+	if len(pgf.File.Decls) != 2 {
+		t.Errorf("got %d declarations, want 2", len(pgf.File.Decls))
+	}
+}
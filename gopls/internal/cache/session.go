@@ -43,6 +43,7 @@ func NewSession(ctx context.Context, c *Cache) *Session {
 		gocmdRunner: &gocommand.Runner{},
 		overlayFS:   newOverlayFS(c),
 		parseCache:  newParseCache(1 * time.Minute), // keep recently parsed files for a minute, to optimize typing CPU
+		packages:    newSharedPackages(sharedPackageCapacity),
 		viewMap:     make(map[protocol.DocumentURI]*View),
 	}
 	event.Log(ctx, "New session", KeyCreateSession.Of(s))
@@ -71,6 +72,7 @@ type Session struct {
 	snapshotWG sync.WaitGroup
 
 	parseCache *parseCache
+	packages   *sharedPackages // syntax packages shared across views
 
 	*overlayFS
 }
@@ -231,6 +233,7 @@ func (s *Session) createView(ctx context.Context, def *viewDefinition) (*View, *
 		initializationSema:   make(chan struct{}, 1),
 		baseCtx:              baseCtx,
 		parseCache:           s.parseCache,
+		packages:             s.packages,
 		ignoreFilter:         ignoreFilter,
 		fs:                   s.overlayFS,
 		viewDefinition:       def,
@@ -239,27 +242,30 @@ func (s *Session) createView(ctx context.Context, def *viewDefinition) (*View, *
 
 	s.snapshotWG.Add(1)
 	v.snapshot = &Snapshot{
-		view:             v,
-		backgroundCtx:    backgroundCtx,
-		cancel:           cancel,
-		store:            s.cache.store,
-		refcount:         1, // Snapshots are born referenced.
-		done:             s.snapshotWG.Done,
-		packages:         new(persistent.Map[PackageID, *packageHandle]),
-		meta:             new(metadata.Graph),
-		files:            newFileMap(),
-		activePackages:   new(persistent.Map[PackageID, *Package]),
-		symbolizeHandles: new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
-		shouldLoad:       new(persistent.Map[PackageID, []PackagePath]),
-		unloadableFiles:  new(persistent.Set[protocol.DocumentURI]),
-		parseModHandles:  new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
-		parseWorkHandles: new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
-		modTidyHandles:   new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
-		modVulnHandles:   new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
-		modWhyHandles:    new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
-		pkgIndex:         typerefs.NewPackageIndex(),
-		moduleUpgrades:   new(persistent.Map[protocol.DocumentURI, map[string]string]),
-		vulns:            new(persistent.Map[protocol.DocumentURI, *vulncheck.Result]),
+		view:                v,
+		backgroundCtx:       backgroundCtx,
+		cancel:              cancel,
+		store:               s.cache.store,
+		refcount:            1, // Snapshots are born referenced.
+		done:                s.snapshotWG.Done,
+		packages:            new(persistent.Map[PackageID, *packageHandle]),
+		meta:                new(metadata.Graph),
+		files:               newFileMap(),
+		activePackages:      new(persistent.Map[PackageID, *Package]),
+		symbolizeHandles:    new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
+		shouldLoad:          new(persistent.Map[PackageID, []PackagePath]),
+		unloadableFiles:     new(persistent.Set[protocol.DocumentURI]),
+		parseModHandles:     new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
+		parseWorkHandles:    new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
+		modTidyHandles:      new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
+		modVulnHandles:      new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
+		modUpdateHandles:    new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
+		apiBaselineHandles:  new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
+		analyzerToolHandles: new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
+		modWhyHandles:       new(persistent.Map[protocol.DocumentURI, *memoize.Promise]),
+		pkgIndex:            typerefs.NewPackageIndex(),
+		moduleUpgrades:      new(persistent.Map[protocol.DocumentURI, map[string]string]),
+		vulns:               new(persistent.Map[protocol.DocumentURI, *vulncheck.Result]),
 	}
 
 	// Snapshots must observe all open files, as there are some caching
@@ -0,0 +1,115 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/util/lru"
+)
+
+// sharedPackageCapacity is the capacity of the cache of recently
+// type-checked syntax packages, measured in bytes of Go source.
+//
+// Syntax packages are much larger than their source, so this is
+// deliberately small: the cache exists to let concurrent or successive
+// type-checking operations in different views share their work, not to
+// hold packages that no view is using.
+const sharedPackageCapacity = 4 << 20
+
+// A sharedPackages cache holds the syntax packages that are being, or
+// were recently, type-checked by the views of a session, keyed by
+// package handle key.
+//
+// The key of a package handle is a hash of all inputs to type-checking
+// the package and its dependencies: file contents, metadata, and
+// configuration such as the Go version, type sizes, and view type. So
+// two views that type-check identical package versions with identical
+// configuration (for example, two workspace folders that depend on the
+// same module, or GOOS variants of a package without platform-specific
+// files) compute the same key, and may share a single syntax package
+// rather than duplicating both the work and the memory.
+//
+// Only syntax packages may be shared: the types.Package of a shared
+// syntax package belongs to the type-checking batch that produced it,
+// so other batches must not use it for importing.
+type sharedPackages struct {
+	mu      sync.Mutex
+	pending map[file.Hash]*sharedPackage // type-checking in progress
+	recent  *lru.Cache                   // file.Hash -> *syntaxPackage
+}
+
+// A sharedPackage is the future result of type-checking a syntax package.
+type sharedPackage struct {
+	done chan unit
+	pkg  *syntaxPackage // nil if type-checking failed
+}
+
+func newSharedPackages(capacity int) *sharedPackages {
+	return &sharedPackages{
+		pending: make(map[file.Hash]*sharedPackage),
+		recent:  lru.New(capacity),
+	}
+}
+
+// get returns the syntax package for the package handle key. If the
+// package is in the cache, or another caller is type-checking it, get
+// returns (or awaits) that result; otherwise it calls check to
+// type-check it. The shared result reports whether the package was
+// type-checked by another caller.
+//
+// If another caller fails to type-check the package (for example,
+// because its context was cancelled), get calls check instead.
+func (c *sharedPackages) get(ctx context.Context, key file.Hash, check func() (*syntaxPackage, error)) (pkg *syntaxPackage, shared bool, err error) {
+	c.mu.Lock()
+	for {
+		if v := c.recent.Get(key); v != nil {
+			c.mu.Unlock()
+			return v.(*syntaxPackage), true, nil
+		}
+		f, ok := c.pending[key]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-f.done:
+		}
+		if f.pkg != nil {
+			return f.pkg, true, nil
+		}
+		c.mu.Lock() // type-checking failed: try again
+	}
+	f := &sharedPackage{done: make(chan unit)}
+	c.pending[key] = f
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, key)
+		if err == nil {
+			f.pkg = pkg
+			c.recent.Set(key, pkg, sourceSize(pkg))
+		}
+		c.mu.Unlock()
+		close(f.done)
+	}()
+	pkg, err = check()
+	return pkg, false, err
+}
+
+// sourceSize returns the size of the source of the compiled Go files of
+// pkg, the unit of the sharedPackages capacity.
+func sourceSize(pkg *syntaxPackage) int {
+	size := 0
+	for _, pgf := range pkg.compiledGoFiles {
+		size += len(pgf.Src)
+	}
+	return size
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+)
+
+func TestSharedPackages(t *testing.T) {
+	ctx := context.Background()
+	key := file.HashOf([]byte("key"))
+	newPackage := func() *syntaxPackage {
+		return &syntaxPackage{compiledGoFiles: []*parsego.File{{Src: []byte("package p")}}}
+	}
+
+	// Concurrent requests for the same key type-check it once.
+	c := newSharedPackages(1 << 10)
+	var (
+		checks  int32
+		release = make(chan unit)
+		wg      sync.WaitGroup
+		results [4]*syntaxPackage
+		shared  int32
+	)
+	for i := range results {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pkg, sh, err := c.get(ctx, key, func() (*syntaxPackage, error) {
+				atomic.AddInt32(&checks, 1)
+				<-release
+				return newPackage(), nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+			if sh {
+				atomic.AddInt32(&shared, 1)
+			}
+			results[i] = pkg
+		}()
+	}
+	close(release)
+	wg.Wait()
+	if checks != 1 {
+		t.Errorf("got %d type checks, want 1", checks)
+	}
+	if shared != int32(len(results)-1) {
+		t.Errorf("got %d shared results, want %d", shared, len(results)-1)
+	}
+	for _, pkg := range results[1:] {
+		if pkg != results[0] {
+			t.Errorf("requests for the same key returned different packages")
+		}
+	}
+
+	// Later requests are served from the cache.
+	pkg, sh, _ := c.get(ctx, key, func() (*syntaxPackage, error) {
+		t.Error("unexpected type check of cached package")
+		return newPackage(), nil
+	})
+	if !sh || pkg != results[0] {
+		t.Errorf("get of cached package returned (%p, %t), want (%p, true)", pkg, sh, results[0])
+	}
+
+	// Failures are not cached.
+	c = newSharedPackages(1 << 10)
+	errFail := errors.New("fail")
+	if _, _, err := c.get(ctx, key, func() (*syntaxPackage, error) { return nil, errFail }); err != errFail {
+		t.Errorf("get returned error %v, want %v", err, errFail)
+	}
+	if _, sh, err := c.get(ctx, key, func() (*syntaxPackage, error) { return newPackage(), nil }); err != nil || sh {
+		t.Errorf("get after failure returned (shared=%t, err=%v), want a new type check", sh, err)
+	}
+}
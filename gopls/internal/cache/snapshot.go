@@ -7,6 +7,7 @@ package cache
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"go/ast"
@@ -182,6 +183,20 @@ type Snapshot struct {
 	modWhyHandles  *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[modWhyResult]
 	modVulnHandles *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[modVulnResult]
 
+	// modUpdateHandles holds the available updates of the modules
+	// required by each go.mod file, if the dependencyUpdates setting is
+	// enabled.
+	modUpdateHandles *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[modUpdates]
+
+	// apiBaselineHandles holds the API of the released version of the
+	// module of each go.mod file, if the apiCompatibility setting is
+	// not empty.
+	apiBaselineHandles *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[apiBaselineResult]
+
+	// analyzerToolHandles holds the diagnostics of the analyzerTools
+	// setting for the packages in each directory.
+	analyzerToolHandles *persistent.Map[protocol.DocumentURI, *memoize.Promise] // *memoize.Promise[toolResult]
+
 	// importGraph holds a shared import graph to use for type-checking. Adding
 	// more packages to this import graph can speed up type checking, at the
 	// expense of in-use memory.
@@ -200,9 +215,23 @@ type Snapshot struct {
 	// vulns maps each go.mod file's URI to its known vulnerabilities.
 	vulns *persistent.Map[protocol.DocumentURI, *vulncheck.Result]
 
+	// snoozedUpdates maps the path of each module whose update notices
+	// are snoozed to the newest version for which they are snoozed.
+	snoozedUpdates map[string]string
+
 	// gcOptimizationDetails describes the packages for which we want
 	// optimization details to be included in the diagnostics.
 	gcOptimizationDetails map[metadata.PackageID]unit
+
+	// deepAnalysis holds the diagnostics of the most recent deep
+	// analysis of each package (see golang.DeepAnalyze). They are
+	// discarded by the next edit to any file.
+	deepAnalysis map[metadata.PackageID]map[protocol.DocumentURI][]*Diagnostic
+
+	// deadCode holds the diagnostics of the most recent dead code
+	// detection in the workspace (see golang.FindDeadCode), or nil.
+	// They are discarded by the next edit to any file.
+	deadCode map[protocol.DocumentURI][]*Diagnostic
 }
 
 var _ memoize.RefCounted = (*Snapshot)(nil) // snapshots are reference-counted
@@ -247,6 +276,9 @@ func (s *Snapshot) decref() {
 		s.parseWorkHandles.Destroy()
 		s.modTidyHandles.Destroy()
 		s.modVulnHandles.Destroy()
+		s.modUpdateHandles.Destroy()
+		s.apiBaselineHandles.Destroy()
+		s.analyzerToolHandles.Destroy()
 		s.modWhyHandles.Destroy()
 		s.unloadableFiles.Destroy()
 		s.moduleUpgrades.Destroy()
@@ -280,6 +312,17 @@ func (s *Snapshot) Folder() protocol.DocumentURI {
 	return s.view.folder.Dir
 }
 
+// SpellingDictionaryFile returns the name of the spelling dictionary
+// file for the workspace folder, according to the spellingDictionary
+// setting.
+func (s *Snapshot) SpellingDictionaryFile() string {
+	filename := s.Options().SpellingDictionary
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(s.view.folder.Dir.Path(), filename)
+	}
+	return filename
+}
+
 // View returns the View associated with this snapshot.
 func (s *Snapshot) View() *View {
 	return s.view
@@ -364,12 +407,13 @@ func (s *Snapshot) Templates() map[protocol.DocumentURI]file.Handle {
 }
 
 // config returns the configuration used for the snapshot's interaction with
-// the go/packages API. It uses the given working directory.
+// the go/packages API. It uses the given working directory. If tests is set,
+// the test variants of the loaded packages are included.
 //
 // TODO(rstambler): go/packages requires that we do not provide overlays for
 // multiple modules in one config, so buildOverlay needs to filter overlays by
 // module.
-func (s *Snapshot) config(ctx context.Context, inv *gocommand.Invocation) *packages.Config {
+func (s *Snapshot) config(ctx context.Context, inv *gocommand.Invocation, tests bool) *packages.Config {
 
 	cfg := &packages.Config{
 		Context:    ctx,
@@ -396,7 +440,7 @@ func (s *Snapshot) config(ctx context.Context, inv *gocommand.Invocation) *packa
 				event.Log(ctx, fmt.Sprintf(format, args...))
 			}
 		},
-		Tests: true,
+		Tests: tests,
 	}
 	packagesinternal.SetModFile(cfg, inv.ModFile)
 	packagesinternal.SetModFlag(cfg, inv.ModFlag)
@@ -657,8 +701,14 @@ func (s *Snapshot) MethodSets(ctx context.Context, ids ...PackageID) ([]*methods
 	defer done()
 
 	indexes := make([]*methodsets.Index, len(ids))
+	versionKeys := make([]*file.Hash, len(ids)) // non-nil for module dependencies
 	pre := func(i int, ph *packageHandle) bool {
-		data, err := filecache.Get(methodSetsKind, ph.key)
+		key := ph.key
+		if vkey, ok := methodSetsVersionKey(ph); ok {
+			key = vkey
+			versionKeys[i] = &vkey
+		}
+		data, err := filecache.Get(methodSetsKind, key)
 		if err == nil { // hit
 			indexes[i] = methodsets.Decode(data)
 			return false
@@ -669,10 +719,76 @@ func (s *Snapshot) MethodSets(ctx context.Context, ids ...PackageID) ([]*methods
 	}
 	post := func(i int, pkg *Package) {
 		indexes[i] = pkg.pkg.methodsets()
+		// The index of a module dependency is not stored when the
+		// package is type-checked (see storePackageResults), but
+		// only once it is first needed.
+		if key := versionKeys[i]; key != nil {
+			data := indexes[i].Encode()
+			go func() {
+				if err := filecache.Set(methodSetsKind, *key, data); err != nil {
+					event.Error(ctx, fmt.Sprintf("storing methodsets data for %s", pkg.metadata.ID), err)
+				}
+			}()
+		}
 	}
 	return indexes, s.forEachPackage(ctx, ids, pre, post)
 }
 
+// methodSetsVersionKey returns the file cache key for the method-set
+// index of a package that belongs to a specific version of a module
+// outside the workspace, such as a dependency in the module cache, or
+// to the standard library.
+//
+// The index of a package depends only on its files, whose contents the
+// key identifies. So, unlike the package key, this key is unaffected by
+// changes to the package's dependencies or to the configuration of the
+// session, and the index is shared by all sessions and views that use
+// the same version. (Strictly, the index also depends on the types of
+// dependencies named through aliases in method signatures; but method
+// set fingerprints are based on the names of types, so this rarely
+// matters.)
+func methodSetsVersionKey(ph *packageHandle) (file.Hash, bool) {
+	version := "std"
+	if mod := ph.mp.Module; mod != nil {
+		if mod.Replace != nil {
+			mod = mod.Replace
+		}
+		if mod.Version == "" {
+			return file.Hash{}, false // a workspace module, or a local replacement
+		}
+		version = mod.Path + "@" + mod.Version
+	} else if !isStandardPackage(ph.mp) {
+		return file.Hash{}, false
+	}
+
+	hasher := sha256.New()
+	fmt.Fprintf(hasher, "%s: %s %s\n", methodSetsKind, ph.mp.PkgPath, version)
+	fmt.Fprintf(hasher, "go %s\n", ph.localInputs.goVersion)
+	fmt.Fprintf(hasher, "compiledGoFiles: %d\n", len(ph.localInputs.compiledGoFiles))
+	for _, fh := range ph.localInputs.compiledGoFiles {
+		fmt.Fprintln(hasher, fh.Identity())
+	}
+	var hash [sha256.Size]byte
+	hasher.Sum(hash[:0])
+	return hash, true
+}
+
+// isStandardPackage reports whether the package, which belongs to no
+// module, appears to be part of the standard library: its path has no
+// dot in its first element, and its files are in the corresponding
+// directory of GOROOT/src.
+//
+// (A GOPATH package may be mistaken for a standard one; the only
+// consequence is that its method sets are computed lazily.)
+func isStandardPackage(mp *metadata.Package) bool {
+	first, _, _ := strings.Cut(string(mp.PkgPath), "/")
+	if strings.Contains(first, ".") || len(mp.CompiledGoFiles) == 0 {
+		return false
+	}
+	dir := filepath.ToSlash(mp.CompiledGoFiles[0].Dir().Path())
+	return strings.HasSuffix(dir, "/src/"+string(mp.PkgPath))
+}
+
 // MetadataForFile returns a new slice containing metadata for each
 // package containing the Go file identified by uri, ordered by the
 // number of CompiledGoFiles (i.e. "narrowest" to "widest" package),
@@ -871,6 +987,12 @@ func (s *Snapshot) fileWatchingGlobPatterns() map[protocol.RelativePattern]unit
 		patterns[workPattern] = unit{}
 	}
 
+	// Watch the spelling dictionary, which affects diagnostics.
+	if s.Options().Spelling {
+		dict := protocol.URIFromPath(s.SpellingDictionaryFile())
+		patterns[protocol.RelativePattern{BaseURI: dict.Dir(), Pattern: path.Base(string(dict))}] = unit{}
+	}
+
 	extensions := "go,mod,sum,work"
 	for _, ext := range s.Options().TemplateExtensions {
 		extensions += "," + ext
@@ -1119,6 +1241,20 @@ func (s *Snapshot) GoModForFile(uri protocol.DocumentURI) protocol.DocumentURI {
 	return moduleForURI(s.view.workspaceModFiles, uri)
 }
 
+// ModulePathForFile returns the path of the module of a package
+// containing the given URI, according to the metadata of the most
+// recent load, or "" if it is not known. It does not load metadata.
+func (s *Snapshot) ModulePathForFile(uri protocol.DocumentURI) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range s.meta.IDs[uri] {
+		if mp := s.meta.Packages[id]; mp != nil && mp.Module != nil {
+			return mp.Module.Path
+		}
+	}
+	return ""
+}
+
 func moduleForURI(modFiles map[protocol.DocumentURI]struct{}, uri protocol.DocumentURI) protocol.DocumentURI {
 	var match protocol.DocumentURI
 	for modURI := range modFiles {
@@ -1349,6 +1485,46 @@ func (s *Snapshot) reloadWorkspace(ctx context.Context) {
 	}
 }
 
+// LoadTestVariants ensures that the test variants of all workspace packages
+// are loaded. It is a no-op unless the DeferTestVariants option is set, in
+// which case test variants are omitted from the initial workspace load.
+// Once LoadTestVariants has succeeded, all subsequent loads in the view
+// include test variants.
+func (s *Snapshot) LoadTestVariants(ctx context.Context) error {
+	if !s.Options().DeferTestVariants || s.view.testsRequested.Swap(true) {
+		return nil
+	}
+	if err := s.awaitLoaded(ctx); err != nil {
+		s.view.testsRequested.Store(false)
+		return err
+	}
+
+	var scopes []loadScope
+	if s.view.typ == AdHocView {
+		// For an ad-hoc view, we cannot load by package path.
+		scopes = []loadScope{viewLoadScope{}}
+	} else {
+		seen := make(map[PackagePath]bool)
+		s.mu.Lock()
+		s.workspacePackages.Range(func(id PackageID, pkgPath PackagePath) {
+			if mp := s.meta.Packages[id]; mp != nil && mp.ForTest == "" && !seen[pkgPath] {
+				seen[pkgPath] = true
+				scopes = append(scopes, packageLoadScope(pkgPath))
+			}
+		})
+		s.mu.Unlock()
+	}
+	if len(scopes) == 0 {
+		return nil
+	}
+	if err := s.load(ctx, false, scopes...); err != nil && !errors.Is(err, errNoPackages) {
+		// Allow a later call to retry.
+		s.view.testsRequested.Store(false)
+		return fmt.Errorf("loading test variants: %w", err)
+	}
+	return nil
+}
+
 func (s *Snapshot) orphanedFileDiagnostics(ctx context.Context, overlays []*overlay) ([]*Diagnostic, error) {
 	if err := s.awaitLoaded(ctx); err != nil {
 		return nil, err
@@ -1627,36 +1803,49 @@ func (s *Snapshot) clone(ctx, bgCtx context.Context, changed StateChange, done f
 
 	// TODO(rfindley): reorganize this function to make the derivation of
 	// needsDiagnosis clearer.
-	needsDiagnosis := len(changed.GCDetails) > 0 || len(changed.ModuleUpgrades) > 0 || len(changed.Vulns) > 0
+	needsDiagnosis := len(changed.GCDetails) > 0 || len(changed.ModuleUpgrades) > 0 || len(changed.Vulns) > 0 || len(changed.DeepAnalysis) > 0 || len(changed.SnoozedUpdates) > 0
 
 	bgCtx, cancel := context.WithCancel(bgCtx)
 	result := &Snapshot{
-		sequenceID:        s.sequenceID + 1,
-		store:             s.store,
-		refcount:          1, // Snapshots are born referenced.
-		done:              done,
-		view:              s.view,
-		backgroundCtx:     bgCtx,
-		cancel:            cancel,
-		builtin:           s.builtin,
-		initialized:       s.initialized,
-		initialErr:        s.initialErr,
-		packages:          s.packages.Clone(),
-		activePackages:    s.activePackages.Clone(),
-		files:             s.files.clone(changedFiles),
-		symbolizeHandles:  cloneWithout(s.symbolizeHandles, changedFiles, nil),
-		workspacePackages: s.workspacePackages,
-		shouldLoad:        s.shouldLoad.Clone(),      // not cloneWithout: shouldLoad is cleared on loads
-		unloadableFiles:   s.unloadableFiles.Clone(), // not cloneWithout: typing in a file doesn't necessarily make it loadable
-		parseModHandles:   cloneWithout(s.parseModHandles, changedFiles, &needsDiagnosis),
-		parseWorkHandles:  cloneWithout(s.parseWorkHandles, changedFiles, &needsDiagnosis),
-		modTidyHandles:    cloneWithout(s.modTidyHandles, changedFiles, &needsDiagnosis),
-		modWhyHandles:     cloneWithout(s.modWhyHandles, changedFiles, &needsDiagnosis),
-		modVulnHandles:    cloneWithout(s.modVulnHandles, changedFiles, &needsDiagnosis),
-		importGraph:       s.importGraph,
-		pkgIndex:          s.pkgIndex,
-		moduleUpgrades:    cloneWith(s.moduleUpgrades, changed.ModuleUpgrades),
-		vulns:             cloneWith(s.vulns, changed.Vulns),
+		sequenceID:          s.sequenceID + 1,
+		store:               s.store,
+		refcount:            1, // Snapshots are born referenced.
+		done:                done,
+		view:                s.view,
+		backgroundCtx:       bgCtx,
+		cancel:              cancel,
+		builtin:             s.builtin,
+		initialized:         s.initialized,
+		initialErr:          s.initialErr,
+		packages:            s.packages.Clone(),
+		activePackages:      s.activePackages.Clone(),
+		files:               s.files.clone(changedFiles),
+		symbolizeHandles:    cloneWithout(s.symbolizeHandles, changedFiles, nil),
+		workspacePackages:   s.workspacePackages,
+		shouldLoad:          s.shouldLoad.Clone(),      // not cloneWithout: shouldLoad is cleared on loads
+		unloadableFiles:     s.unloadableFiles.Clone(), // not cloneWithout: typing in a file doesn't necessarily make it loadable
+		parseModHandles:     cloneWithout(s.parseModHandles, changedFiles, &needsDiagnosis),
+		parseWorkHandles:    cloneWithout(s.parseWorkHandles, changedFiles, &needsDiagnosis),
+		modTidyHandles:      cloneWithout(s.modTidyHandles, changedFiles, &needsDiagnosis),
+		modWhyHandles:       cloneWithout(s.modWhyHandles, changedFiles, &needsDiagnosis),
+		modVulnHandles:      cloneWithout(s.modVulnHandles, changedFiles, &needsDiagnosis),
+		modUpdateHandles:    cloneWithout(s.modUpdateHandles, changedFiles, &needsDiagnosis),
+		apiBaselineHandles:  cloneWithout(s.apiBaselineHandles, changedFiles, &needsDiagnosis),
+		analyzerToolHandles: s.analyzerToolHandles.Clone(),
+		importGraph:         s.importGraph,
+		pkgIndex:            s.pkgIndex,
+		moduleUpgrades:      cloneWith(s.moduleUpgrades, changed.ModuleUpgrades),
+		vulns:               cloneWith(s.vulns, changed.Vulns),
+	}
+
+	if len(s.snoozedUpdates) > 0 || len(changed.SnoozedUpdates) > 0 {
+		result.snoozedUpdates = make(map[string]string)
+		for path, version := range s.snoozedUpdates {
+			result.snoozedUpdates[path] = version
+		}
+		for path, version := range changed.SnoozedUpdates {
+			result.snoozedUpdates[path] = version
+		}
 	}
 
 	// Compute the new set of packages for which we want gc details, after
@@ -1678,6 +1867,54 @@ func (s *Snapshot) clone(ctx, bgCtx context.Context, changed StateChange, done f
 		}
 	}
 
+	// Deep analysis and dead code results are retained until the
+	// content of any file changes, as they are too costly to recompute.
+	edited := false
+	for uri, fh := range changedFiles {
+		if old, ok := s.files.get(uri); !ok || old.Identity().Hash != fh.Identity().Hash {
+			edited = true
+			break
+		}
+	}
+	// Results computed from an earlier snapshot are installed
+	// only if no file has changed since.
+	fresh := changed.ResultFiles == nil || s.filesUnchangedLocked(changed.ResultFiles)
+	if len(s.deepAnalysis) > 0 || len(changed.DeepAnalysis) > 0 {
+		newDeepAnalysis := make(map[metadata.PackageID]map[protocol.DocumentURI][]*Diagnostic)
+		if !edited {
+			for id, diags := range s.deepAnalysis {
+				newDeepAnalysis[id] = diags
+			}
+		} else if len(s.deepAnalysis) > 0 {
+			needsDiagnosis = true // clear the discarded diagnostics
+		}
+		if fresh {
+			for id, diags := range changed.DeepAnalysis {
+				newDeepAnalysis[id] = diags
+			}
+		}
+		if len(newDeepAnalysis) > 0 {
+			result.deepAnalysis = newDeepAnalysis
+		}
+	}
+	if changed.DeadCode != nil && fresh {
+		result.deadCode = changed.DeadCode
+	} else if s.deadCode != nil {
+		if !edited {
+			result.deadCode = s.deadCode
+		} else {
+			needsDiagnosis = true // clear the discarded diagnostics
+		}
+	}
+
+	// A change to the spelling dictionary affects the diagnostics of
+	// open files, although the dictionary is not part of any package.
+	if s.Options().Spelling {
+		if _, ok := changedFiles[protocol.URIFromPath(s.SpellingDictionaryFile())]; ok {
+			needsDiagnosis = true
+		}
+	}
+
 	reinit := false
 
 	// Changes to vendor tree may require reinitialization,
@@ -1932,6 +2169,13 @@ func (s *Snapshot) clone(ctx, bgCtx context.Context, changed StateChange, done f
 		if result.activePackages.Delete(id) {
 			needsDiagnosis = true
 		}
+		if mp := s.meta.Packages[id]; mp != nil {
+			for _, uri := range mp.CompiledGoFiles {
+				if result.analyzerToolHandles.Delete(uri.Dir()) {
+					needsDiagnosis = true
+				}
+			}
+		}
 	}
 
 	// Compute which metadata updates are required. We only need to invalidate
@@ -2295,6 +2539,60 @@ func (s *Snapshot) WantGCDetails(id metadata.PackageID) bool {
 	return ok
 }
 
+// FileIdentities returns the identities of the files of the snapshot,
+// which identify its state when recording the results of a costly
+// operation such as deep analysis (see [StateChange.ResultFiles]).
+func (s *Snapshot) FileIdentities() map[protocol.DocumentURI]file.Identity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make(map[protocol.DocumentURI]file.Identity)
+	s.files.foreach(func(uri protocol.DocumentURI, fh file.Handle) {
+		ids[uri] = fh.Identity()
+	})
+	return ids
+}
+
+// filesUnchangedLocked reports whether the content of the files of s
+// is unchanged from the file identities of an earlier snapshot: no
+// file has been edited or deleted, and no unsaved buffer has been
+// opened. (Files read only since then are not changes.)
+//
+// s.mu must be held.
+func (s *Snapshot) filesUnchangedLocked(ids map[protocol.DocumentURI]file.Identity) bool {
+	for uri, id := range ids {
+		fh, ok := s.files.get(uri)
+		if !ok || fh.Identity().Hash != id.Hash {
+			return false
+		}
+	}
+	unchanged := true
+	s.files.foreach(func(uri protocol.DocumentURI, fh file.Handle) {
+		if _, ok := ids[uri]; !ok && !fh.SameContentsOnDisk() {
+			unchanged = false
+		}
+	})
+	return unchanged
+}
+
+// DeepAnalysisDiagnostics returns the diagnostics of the most recent
+// deep analysis of each package, if no file has been edited since.
+func (s *Snapshot) DeepAnalysisDiagnostics() map[protocol.DocumentURI][]*Diagnostic {
+	diags := make(map[protocol.DocumentURI][]*Diagnostic)
+	for _, byURI := range s.deepAnalysis {
+		for uri, list := range byURI {
+			diags[uri] = append(diags[uri], list...)
+		}
+	}
+	return diags
+}
+
+// DeadCodeDiagnostics returns the diagnostics of the most recent dead
+// code detection in the workspace, if no file has been edited since.
+func (s *Snapshot) DeadCodeDiagnostics() map[protocol.DocumentURI][]*Diagnostic {
+	return s.deadCode
+}
+
 // A CodeLensSourceFunc is a function that reports CodeLenses (range-associated
 // commands) for a given file.
 type CodeLensSourceFunc func(context.Context, *Snapshot, file.Handle) ([]protocol.CodeLens, error)
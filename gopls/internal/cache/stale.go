@@ -0,0 +1,105 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"context"
+	"os"
+
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+)
+
+// StaleFiles returns modifications for the workspace files read by the
+// snapshot whose state on disk has diverged from the snapshot, although
+// no change was reported for them: files that have been modified,
+// created, or deleted. Open files are not considered, as their content
+// is that of the editor, nor are files outside the workspace, such as
+// those of GOROOT or the module cache, which seldom change.
+//
+// Such divergences arise when the client misses file events (for example,
+// for changes made while the editor was suspended, or by tools that
+// replace whole directories), and previously left gopls with stale
+// state until it was restarted. Reporting the resulting modifications to
+// the session recovers from them.
+//
+// To keep the check cheap, the content of a file is read only if its
+// modification time has changed.
+func (s *Snapshot) StaleFiles(ctx context.Context) []file.Modification {
+	var files []*diskFile
+	s.mu.Lock()
+	s.files.foreach(func(uri protocol.DocumentURI, fh file.Handle) {
+		if fh, ok := fh.(*diskFile); ok && s.inWorkspace(uri) {
+			files = append(files, fh)
+		}
+	})
+	s.mu.Unlock()
+
+	var mods []file.Modification
+	for _, fh := range files {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if action := staleAction(fh); action != file.UnknownAction {
+			mods = append(mods, file.Modification{
+				URI:    fh.uri,
+				Action: action,
+				OnDisk: true,
+			})
+		}
+	}
+	return mods
+}
+
+// inWorkspace reports whether uri is within the root of the view or
+// the directory of one of its workspace modules, and not excluded by
+// the directory filters.
+func (s *Snapshot) inWorkspace(uri protocol.DocumentURI) bool {
+	if s.view.filterFunc()(uri) {
+		return false
+	}
+	if s.view.root.Encloses(uri) {
+		return true
+	}
+	for modURI := range s.view.workspaceModFiles {
+		if modURI.Dir().Encloses(uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// staleAction returns the action by which the file on disk differs from
+// fh, or UnknownAction if it does not.
+func staleAction(fh *diskFile) file.Action {
+	info, err := os.Stat(fh.uri.Path())
+	switch {
+	case err != nil && fh.err == nil:
+		if os.IsNotExist(err) {
+			return file.Delete
+		}
+		return file.UnknownAction // e.g. permission denied
+	case err != nil:
+		return file.UnknownAction // still missing
+	case fh.err != nil:
+		if info.Mode().IsRegular() {
+			return file.Create
+		}
+		return file.UnknownAction // e.g. a directory
+	case info.ModTime().Equal(fh.modTime):
+		return file.UnknownAction
+	}
+
+	// The modification time changed; compare the content, since the file
+	// may merely have been touched.
+	content, err := os.ReadFile(fh.uri.Path())
+	if err != nil {
+		return file.UnknownAction // e.g. deleted since stat; check again later
+	}
+	if file.HashOf(content) != fh.hash {
+		return file.Change
+	}
+	return file.UnknownAction
+}
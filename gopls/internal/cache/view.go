@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/tools/gopls/internal/cache/metadata"
@@ -108,6 +109,9 @@ type View struct {
 	// parseCache holds an LRU cache of recently parsed files.
 	parseCache *parseCache
 
+	// packages holds the syntax packages shared by the views of the session.
+	packages *sharedPackages
+
 	// fs is the file source used to populate this view.
 	fs *overlayFS
 
@@ -135,6 +139,11 @@ type View struct {
 	// accordingly.
 	initializationSema chan struct{}
 
+	// testsRequested records whether the test variants of all workspace
+	// packages have been requested by Snapshot.LoadTestVariants. It is only
+	// meaningful when the DeferTestVariants option is set.
+	testsRequested atomic.Bool
+
 	// Document filters are constructed once, in View.filterFunc.
 	filterFuncOnce sync.Once
 	_filterFunc    func(protocol.DocumentURI) bool // only accessed by View.filterFunc
@@ -451,6 +460,11 @@ func (v *View) filterFunc() func(protocol.DocumentURI) bool {
 		gomodcache := v.folder.Env.GOMODCACHE
 		var filters []string
 		filters = append(filters, v.folder.Options.DirectoryFilters...)
+		// Scratch directories are excluded from packages:
+		// their files are loaded one at a time (see isScratchFile).
+		for _, dir := range v.folder.Options.ScratchDirectories {
+			filters = append(filters, "-"+dir)
+		}
 		if pref := strings.TrimPrefix(gomodcache, folderDir); pref != gomodcache {
 			modcacheFilter := "-" + strings.TrimPrefix(filepath.ToSlash(pref), "/")
 			filters = append(filters, modcacheFilter)
@@ -467,6 +481,22 @@ func (v *View) filterFunc() func(protocol.DocumentURI) bool {
 	return v._filterFunc
 }
 
+// isScratchFile reports whether uri denotes a Go file, other than a
+// test, in one of the scratchDirectories of the view's folder. Each
+// such file is loaded as a standalone package.
+func (v *View) isScratchFile(uri protocol.DocumentURI) bool {
+	if strings.HasSuffix(uri.Path(), "_test.go") {
+		return false
+	}
+	folderDir := v.folder.Dir.Path()
+	for _, dir := range v.folder.Options.ScratchDirectories {
+		if pathutil.InDir(filepath.Join(folderDir, filepath.FromSlash(dir)), uri.Path()) {
+			return true
+		}
+	}
+	return false
+}
+
 // shutdown releases resources associated with the view.
 func (v *View) shutdown() {
 	// Cancel the initial workspace load if it is still running.
@@ -726,6 +756,23 @@ type StateChange struct {
 	ModuleUpgrades map[protocol.DocumentURI]map[string]string
 	Vulns          map[protocol.DocumentURI]*vulncheck.Result
 	GCDetails      map[metadata.PackageID]bool // package -> whether or not we want details
+
+	// SnoozedUpdates maps module paths to the newest version of each
+	// for which update notices are to be suppressed.
+	SnoozedUpdates map[string]string
+
+	// DeepAnalysis holds the results of golang.DeepAnalyze, by package.
+	DeepAnalysis map[metadata.PackageID]map[protocol.DocumentURI][]*Diagnostic
+
+	// DeadCode holds the results of golang.FindDeadCode, if non-nil.
+	DeadCode map[protocol.DocumentURI][]*Diagnostic
+
+	// ResultFiles holds the file identities (see
+	// [Snapshot.FileIdentities]) of the snapshot from which
+	// DeepAnalysis and DeadCode were computed. If any file has
+	// changed since, the results are discarded, as their positions
+	// may no longer be valid.
+	ResultFiles map[protocol.DocumentURI]file.Identity
 }
 
 // InvalidateView processes the provided state change, invalidating any derived
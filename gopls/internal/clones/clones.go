@@ -0,0 +1,139 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package clones detects duplicated functions: those whose signatures
+// and bodies are identical, or nearly so, differing only by a
+// consistent renaming of identifiers and by the values of literals.
+//
+// Each function is summarized by a fingerprint, a hash of its
+// normalized token sequence, in which the nth distinct identifier is
+// replaced by a placeholder for n, and each literal by a placeholder
+// for its kind. Functions with equal fingerprints are clones.
+// Fingerprints depend only on the syntax of a single file, so they may
+// be computed, and cached, file by file.
+package clones
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+)
+
+// MinTokens is the minimum number of tokens in the signature and body
+// of a function for it to be reported as a clone. Small functions,
+// such as accessors, are often alike by necessity.
+const MinTokens = 50
+
+// A Func is the fingerprint of a function declaration.
+type Func struct {
+	Name        string            // "F", or "T.M" for a method
+	NameStart   int               // offset of the function name
+	NameEnd     int               // end offset of the function name
+	Tokens      int               // number of tokens in the signature and body
+	Fingerprint [sha256.Size]byte // hash of the normalized tokens
+}
+
+// Fingerprints returns the fingerprints of the declarations of
+// functions in the file whose signatures and bodies have at least
+// MinTokens tokens, in order. The file must have been parsed from src.
+func Fingerprints(tok *token.File, file *ast.File, src []byte) []Func {
+	var funcs []Func
+	for _, decl := range file.Decls {
+		decl, ok := decl.(*ast.FuncDecl)
+		if !ok || decl.Body == nil || decl.Name.Name == "_" {
+			continue
+		}
+		// The signature and body, but not the receiver or name,
+		// which are expected to differ among clones.
+		start, end, err := safetoken.Offsets(tok, decl.Type.Params.Pos(), decl.Body.End())
+		if err != nil {
+			continue
+		}
+		nameStart, nameEnd, err := safetoken.Offsets(tok, decl.Name.Pos(), decl.Name.End())
+		if err != nil {
+			continue
+		}
+		n, fingerprint := fingerprint(src[start:end])
+		if n < MinTokens {
+			continue
+		}
+		name := decl.Name.Name
+		if recv := recvTypeName(decl); recv != "" {
+			name = recv + "." + name
+		}
+		funcs = append(funcs, Func{
+			Name:        name,
+			NameStart:   nameStart,
+			NameEnd:     nameEnd,
+			Tokens:      n,
+			Fingerprint: fingerprint,
+		})
+	}
+	return funcs
+}
+
+// fingerprint returns the number of tokens in src, Go source text,
+// and the hash of their normalized sequence.
+func fingerprint(src []byte) (int, [sha256.Size]byte) {
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", -1, len(src)), src, nil, 0) // errors are reported by the parser
+
+	h := sha256.New()
+	ids := make(map[string]int)
+	n := 0
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		n++
+		switch {
+		case tok == token.IDENT:
+			id, ok := ids[lit]
+			if !ok {
+				id = len(ids)
+				ids[lit] = id
+			}
+			fmt.Fprintf(h, "$%d ", id)
+		case tok.IsLiteral():
+			fmt.Fprintf(h, "%s ", tok)
+		default:
+			// Implicit and explicit semicolons are equivalent.
+			h.Write([]byte(tok.String() + " "))
+		}
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return n, sum
+}
+
+// recvTypeName returns the name of the receiver type of a method
+// declaration, or "" for a function.
+func recvTypeName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+	t := decl.Recv.List[0].Type
+	for {
+		switch x := t.(type) {
+		case *ast.StarExpr:
+			t = x.X
+		case *ast.ParenExpr:
+			t = x.X
+		case *ast.IndexExpr:
+			t = x.X
+		case *ast.IndexListExpr:
+			t = x.X
+		case *ast.Ident:
+			return x.Name
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package clones
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestFingerprints(t *testing.T) {
+	const src = `package p
+
+func Sum(xs []int) (total int) {
+	for i, x := range xs {
+		if i%2 == 0 && x > 0 {
+			total += x * 2
+		} else {
+			total -= x
+		}
+	}
+	return total
+}
+
+// Product is Sum with different names and literals.
+func (t *T[E]) Product(values []int) (acc int) {
+	for j, v := range values {
+		if j%3 == 0 && v > 1 {
+			acc += v * 5
+		} else {
+			acc -= v
+		}
+	}
+	return acc
+}
+
+// Mixed differs from Sum by the consistency of its names.
+func Mixed(xs []int) (total int) {
+	for i, x := range xs {
+		if i%2 == 0 && x > 0 {
+			total += x * 2
+		} else {
+			total -= i
+		}
+	}
+	return total
+}
+
+func small(x int) int { return x + 1 }
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs := Fingerprints(fset.File(file.Pos()), file, []byte(src))
+
+	var names []string
+	for _, f := range funcs {
+		names = append(names, f.Name)
+		if got := src[f.NameStart:f.NameEnd]; f.Name[len(f.Name)-len(got):] != got {
+			t.Errorf("%s: name range contains %q", f.Name, got)
+		}
+	}
+	if len(funcs) != 3 {
+		t.Fatalf("got fingerprints for %v, want Sum, T.Product, and Mixed", names)
+	}
+	sum, product, mixed := funcs[0], funcs[1], funcs[2]
+	if product.Name != "T.Product" {
+		t.Errorf("method name is %q, want T.Product", product.Name)
+	}
+	if sum.Fingerprint != product.Fingerprint {
+		t.Errorf("Sum and T.Product have different fingerprints")
+	}
+	if sum.Fingerprint == mixed.Fingerprint {
+		t.Errorf("Sum and Mixed have the same fingerprint")
+	}
+}
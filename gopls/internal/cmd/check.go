@@ -6,39 +6,141 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
+	"golang.org/x/tools/gopls/internal/baseline"
 	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
 	"golang.org/x/tools/gopls/internal/settings"
 	"golang.org/x/tools/gopls/internal/util/slices"
+	"golang.org/x/tools/internal/tool"
 )
 
 // check implements the check verb for gopls.
 type check struct {
+	Output        string `flag:"o,output" help:"write a JSON report of the diagnostics to this file (- for stdout)"`
+	Severity      string `flag:"severity" help:"report only diagnostics at least this severe: error, warning, info, or hint"`
+	Baseline      string `flag:"baseline" help:"suppress the analysis findings recorded in this baseline file"`
+	WriteBaseline string `flag:"write-baseline" help:"record the fingerprints of the reported diagnostics in this baseline file"`
+
 	app *Application
 }
 
 func (c *check) Name() string      { return "check" }
 func (c *check) Parent() string    { return c.app.Name() }
-func (c *check) Usage() string     { return "<filename>" }
-func (c *check) ShortHelp() string { return "show diagnostic results for the specified file" }
+func (c *check) Usage() string     { return "[check-flags] <filename or pattern>..." }
+func (c *check) ShortHelp() string { return "show diagnostic results for the specified files" }
 func (c *check) DetailedHelp(f *flag.FlagSet) {
 	fmt.Fprint(f.Output(), `
 Example: show the diagnostic results of this file:
 
 	$ gopls check internal/cmd/check.go
+
+Each argument may be a file, a directory (denoting its Go files), or a
+pattern of the form dir/... (denoting the Go files in dir and its
+subdirectories, excluding testdata and vendor directories).
+
+Example: write a JSON report of all warnings and errors in the module:
+
+	$ gopls check -o diagnostics.json -severity=warning ./...
+
+The report is stable across runs: its diagnostics are sorted by
+position, and each has a fingerprint that does not depend on its line
+number, so that tools such as code-review bots can identify the
+findings that are new since a previous run.
+
+Example: record the existing findings in a baseline file, and later
+report only the analysis findings that are not in the baseline:
+
+	$ gopls check -write-baseline=gopls-baseline.json ./...
+	$ gopls check -baseline=gopls-baseline.json ./...
+
+The same file may be named by the diagnosticsBaseline setting so that
+the baseline findings are suppressed in the editor too.
+
+check-flags:
 `)
 	printFlagDefaults(f)
 }
 
+// A checkReport is the JSON form of the output of "gopls check -o".
+type checkReport struct {
+	Diagnostics []checkDiagnostic `json:"diagnostics"`
+}
+
+// A checkDiagnostic is a single diagnostic in a checkReport.
+type checkDiagnostic struct {
+	File        string         `json:"file"`   // slash-separated, relative to the workspace folder of the file's view
+	Line        int            `json:"line"`   // 1-based
+	Column      int            `json:"column"` // 1-based, in bytes
+	EndLine     int            `json:"endLine"`
+	EndColumn   int            `json:"endColumn"`
+	Severity    string         `json:"severity"`
+	Source      string         `json:"source,omitempty"`
+	Code        string         `json:"code,omitempty"`
+	Message     string         `json:"message"`
+	Fingerprint string         `json:"fingerprint"` // see package baseline
+	Related     []checkRelated `json:"related,omitempty"`
+}
+
+// A checkRelated is related information about a checkDiagnostic.
+type checkRelated struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// checkSeverities maps -severity flag values to their protocol form.
+var checkSeverities = map[string]protocol.DiagnosticSeverity{
+	"error":       protocol.SeverityError,
+	"warning":     protocol.SeverityWarning,
+	"info":        protocol.SeverityInformation,
+	"information": protocol.SeverityInformation,
+	"hint":        protocol.SeverityHint,
+}
+
 // Run performs the check on the files specified by args and prints the
 // results to stdout.
 func (c *check) Run(ctx context.Context, args ...string) error {
-	if len(args) == 0 {
+	minSeverity := protocol.SeverityHint
+	if c.Severity != "" {
+		sev, ok := checkSeverities[strings.ToLower(c.Severity)]
+		if !ok {
+			return tool.CommandLineErrorf("invalid -severity %q (want error, warning, info, or hint)", c.Severity)
+		}
+		minSeverity = sev
+	}
+
+	filenames, err := expandCheckArgs(args)
+	if err != nil {
+		return err
+	}
+	if len(filenames) == 0 && c.Output == "" && c.WriteBaseline == "" {
 		return nil
 	}
 
+	// The baseline file name is made absolute, as the server resolves
+	// relative names against the workspace folder.
+	var baselineFile string
+	if c.Baseline != "" {
+		baselineFile, err = filepath.Abs(c.Baseline)
+		if err != nil {
+			return err
+		}
+		// Report a missing file, which the server silently ignores.
+		if _, err := os.Stat(baselineFile); err != nil {
+			return err
+		}
+	}
+
 	// TODO(adonovan): formally, we are required to set this
 	// option if we want RelatedInformation, but it appears to
 	// have no effect on the server, even though the default is
@@ -49,6 +151,9 @@ func (c *check) Run(ctx context.Context, args ...string) error {
 			origOptions(opts)
 		}
 		opts.RelatedInformationSupported = true
+		if c.Baseline != "" {
+			opts.DiagnosticsBaseline = baselineFile
+		}
 	}
 
 	conn, err := c.app.connect(ctx)
@@ -62,8 +167,8 @@ func (c *check) Run(ctx context.Context, args ...string) error {
 		uris     []protocol.DocumentURI
 		checking = make(map[protocol.DocumentURI]*cmdFile)
 	)
-	for _, arg := range args {
-		uri := protocol.URIFromPath(arg)
+	for _, filename := range filenames {
+		uri := protocol.URIFromPath(filename)
 		uris = append(uris, uri)
 		file, err := conn.openFile(ctx, uri)
 		if err != nil {
@@ -75,6 +180,24 @@ func (c *check) Run(ctx context.Context, args ...string) error {
 		return err
 	}
 
+	// Visit the files in a deterministic order.
+	sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+
+	if c.Output != "" || c.WriteBaseline != "" {
+		report, err := c.report(ctx, conn, uris, checking, minSeverity)
+		if err != nil {
+			return err
+		}
+		if c.WriteBaseline != "" {
+			if err := writeBaseline(c.WriteBaseline, report); err != nil {
+				return err
+			}
+		}
+		if c.Output != "" {
+			return writeReport(c.Output, report)
+		}
+	}
+
 	// print prints a single element of a diagnostic.
 	print := func(uri protocol.DocumentURI, rng protocol.Range, message string) error {
 		file, err := conn.openFile(ctx, uri)
@@ -89,12 +212,16 @@ func (c *check) Run(ctx context.Context, args ...string) error {
 		return nil
 	}
 
-	for _, file := range checking {
+	for _, uri := range uris {
+		file := checking[uri]
 		file.diagnosticsMu.Lock()
 		diags := slices.Clone(file.diagnostics)
 		file.diagnosticsMu.Unlock()
 
 		for _, diag := range diags {
+			if !reportSeverity(diag, minSeverity) {
+				continue
+			}
 			if err := print(file.uri, diag.Range, diag.Message); err != nil {
 				return err
 			}
@@ -108,3 +235,233 @@ func (c *check) Run(ctx context.Context, args ...string) error {
 	}
 	return nil
 }
+
+// report returns a report of the diagnostics of the checked files that
+// are at least as severe as minSeverity.
+func (c *check) report(ctx context.Context, conn *connection, uris []protocol.DocumentURI, checking map[protocol.DocumentURI]*cmdFile, minSeverity protocol.DiagnosticSeverity) (*checkReport, error) {
+	// folderOf returns the workspace folder of the view of a file,
+	// relative to which the server computes the paths that it
+	// matches against the analysisExclusions and the baseline.
+	folders := make(map[protocol.DocumentURI]string)
+	folderOf := func(uri protocol.DocumentURI) (string, error) {
+		if folder, ok := folders[uri]; ok {
+			return folder, nil
+		}
+		args, err := command.MarshalArgs(command.ViewInfoArgs{URI: uri})
+		if err != nil {
+			return "", err
+		}
+		res, err := conn.executeCommand(ctx, &protocol.Command{
+			Command:   command.ViewInfo.String(),
+			Arguments: args,
+		})
+		if err != nil {
+			return "", err
+		}
+		// Round-trip the result through JSON, as it is not
+		// a []command.ViewDescription if the server is remote.
+		data, err := json.Marshal(res)
+		if err != nil {
+			return "", err
+		}
+		var views []command.ViewDescription
+		if err := json.Unmarshal(data, &views); err != nil {
+			return "", err
+		}
+		if len(views) == 0 {
+			return "", fmt.Errorf("no view for %s", uri)
+		}
+		folder := views[0].Folder.Path()
+		folders[uri] = folder
+		return folder, nil
+	}
+
+	// position returns the file name, relative to folder, and the
+	// UTF-8 span of a range.
+	position := func(folder string, uri protocol.DocumentURI, rng protocol.Range) (string, span, error) {
+		file, err := conn.openFile(ctx, uri)
+		if err != nil {
+			return "", span{}, err
+		}
+		spn, err := file.rangeSpan(rng)
+		if err != nil {
+			return "", span{}, fmt.Errorf("could not convert position %v in %s", rng, uri)
+		}
+		return baseline.RelPath(folder, uri.Path()), spn, nil
+	}
+
+	report := &checkReport{Diagnostics: []checkDiagnostic{}}
+	for _, uri := range uris {
+		file := checking[uri]
+		file.diagnosticsMu.Lock()
+		diags := slices.Clone(file.diagnostics)
+		file.diagnosticsMu.Unlock()
+
+		var lines []string
+		if file.mapper != nil {
+			lines = strings.Split(string(file.mapper.Content), "\n")
+		}
+
+		var fileDiags []checkDiagnostic
+		for _, diag := range diags {
+			if !reportSeverity(diag, minSeverity) {
+				continue
+			}
+			folder, err := folderOf(uri)
+			if err != nil {
+				return nil, err
+			}
+			name, spn, err := position(folder, uri, diag.Range)
+			if err != nil {
+				return nil, err
+			}
+			var code string
+			if diag.Code != nil {
+				code = fmt.Sprint(diag.Code)
+			}
+			var line string
+			if l := int(diag.Range.Start.Line); l < len(lines) {
+				line = lines[l]
+			}
+			cd := checkDiagnostic{
+				File:        name,
+				Line:        spn.Start().Line(),
+				Column:      spn.Start().Column(),
+				EndLine:     spn.End().Line(),
+				EndColumn:   spn.End().Column(),
+				Severity:    severityName(diag.Severity),
+				Source:      diag.Source,
+				Code:        code,
+				Message:     strings.TrimSpace(diag.Message),
+				Fingerprint: baseline.Fingerprint(name, diag.Source, code, diag.Message, line),
+			}
+			for _, rel := range diag.RelatedInformation {
+				relName, relSpan, err := position(folder, rel.Location.URI, rel.Location.Range)
+				if err != nil {
+					return nil, err
+				}
+				cd.Related = append(cd.Related, checkRelated{
+					File:    relName,
+					Line:    relSpan.Start().Line(),
+					Column:  relSpan.Start().Column(),
+					Message: rel.Message,
+				})
+			}
+			fileDiags = append(fileDiags, cd)
+		}
+		sort.SliceStable(fileDiags, func(i, j int) bool {
+			x, y := fileDiags[i], fileDiags[j]
+			if x.Line != y.Line {
+				return x.Line < y.Line
+			}
+			if x.Column != y.Column {
+				return x.Column < y.Column
+			}
+			return x.Message < y.Message
+		})
+		report.Diagnostics = append(report.Diagnostics, fileDiags...)
+	}
+	return report, nil
+}
+
+// writeReport writes report in JSON form to the named file, or to
+// stdout if the name is "-".
+func writeReport(filename string, report *checkReport) error {
+	data, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if filename == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(filename, data, 0666)
+}
+
+// writeBaseline writes the fingerprints of the diagnostics in report
+// to the named baseline file, replacing its previous contents.
+func writeBaseline(filename string, report *checkReport) error {
+	set := make(baseline.Set)
+	for _, diag := range report.Diagnostics {
+		set[diag.Fingerprint] = true
+	}
+	data, err := baseline.Format(set)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0666)
+}
+
+// reportSeverity reports whether diag is at least as severe as min.
+// (Lower values of DiagnosticSeverity are more severe; zero means
+// unspecified, which is treated as an error.)
+func reportSeverity(diag protocol.Diagnostic, min protocol.DiagnosticSeverity) bool {
+	return diag.Severity == 0 || diag.Severity <= min
+}
+
+// severityName returns the name of a severity as it appears in a
+// checkReport.
+func severityName(sev protocol.DiagnosticSeverity) string {
+	switch sev {
+	case protocol.SeverityWarning:
+		return "warning"
+	case protocol.SeverityInformation:
+		return "info"
+	case protocol.SeverityHint:
+		return "hint"
+	default:
+		return "error"
+	}
+}
+
+// expandCheckArgs expands the arguments of the check command into a
+// list of file names. A directory denotes the Go files it contains; a
+// pattern of the form "dir/..." additionally denotes those of its
+// subdirectories, except for testdata, vendor, and those whose names
+// begin with "." or "_", following the conventions of the go command.
+func expandCheckArgs(args []string) ([]string, error) {
+	var filenames []string
+	for _, arg := range args {
+		dir := filepath.ToSlash(arg)
+		recursive := dir == "..." || strings.HasSuffix(dir, "/...")
+		if recursive {
+			dir = strings.TrimSuffix(strings.TrimSuffix(dir, "..."), "/")
+		}
+		if !recursive {
+			info, err := os.Stat(arg)
+			if err != nil || !info.IsDir() {
+				filenames = append(filenames, arg) // a file (or error reported later)
+				continue
+			}
+			dir = arg
+		}
+		if dir == "" {
+			dir = "."
+		}
+		dir = filepath.FromSlash(dir)
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path == dir {
+					return nil
+				}
+				name := d.Name()
+				if !recursive || name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, ".go") {
+				filenames = append(filenames, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filenames, nil
+}
@@ -65,6 +65,13 @@ type Application struct {
 	// Control ocagent export of telemetry
 	OCAgent string `flag:"ocagent" help:"the address of the ocagent (e.g. http://localhost:55678), or off"`
 
+	// DisplayColumns causes column numbers in positions to be
+	// display columns rather than byte offsets within the line.
+	DisplayColumns bool `flag:"display-columns" help:"interpret and report column numbers as display columns, in which a tab extends to the next tab stop and a combining character has no width, rather than as bytes"`
+
+	// TabWidth is the distance between tab stops for display columns.
+	TabWidth int `flag:"tabwidth" help:"distance between tab stops, for -display-columns"`
+
 	// PrepareOptions is called to update the options when a new view is built.
 	// It is primarily to allow the behavior of gopls to be modified by hooks.
 	PrepareOptions func(*settings.Options)
@@ -102,6 +109,7 @@ func New() *Application {
 		Serve: Serve{
 			RemoteListenTimeout: 1 * time.Minute,
 		},
+		TabWidth: 8,
 	}
 	app.Serve.app = app
 	return app
@@ -416,6 +424,7 @@ type cmdClient struct {
 type cmdFile struct {
 	uri           protocol.DocumentURI
 	mapper        *protocol.Mapper
+	tabWidth      int // if positive, columns are display columns with this tab width
 	err           error
 	diagnosticsMu sync.Mutex
 	diagnostics   []protocol.Diagnostic
@@ -764,6 +773,12 @@ func (c *cmdClient) getFile(uri protocol.DocumentURI) *cmdFile {
 		file = &cmdFile{
 			uri: uri,
 		}
+		if c.app.DisplayColumns {
+			file.tabWidth = c.app.TabWidth
+			if file.tabWidth < 1 {
+				file.tabWidth = 1
+			}
+		}
 		c.files[uri] = file
 	}
 	if file.mapper == nil {
@@ -865,11 +880,11 @@ func (f *cmdFile) offsetSpan(start, end int) (span, error) {
 	if start > end {
 		return span{}, fmt.Errorf("start offset (%d) > end (%d)", start, end)
 	}
-	startPoint, err := offsetPoint(f.mapper, start)
+	startPoint, err := f.offsetPoint(start)
 	if err != nil {
 		return span{}, fmt.Errorf("start: %v", err)
 	}
-	endPoint, err := offsetPoint(f.mapper, end)
+	endPoint, err := f.offsetPoint(end)
 	if err != nil {
 		return span{}, fmt.Errorf("end: %v", err)
 	}
@@ -878,10 +893,16 @@ func (f *cmdFile) offsetSpan(start, end int) (span, error) {
 
 // offsetPoint converts a byte offset to a span (UTF-8) point.
 // The resulting point contains line, column, and offset information.
-func offsetPoint(m *protocol.Mapper, offset int) (point, error) {
+// Its column is a display column if f.tabWidth is positive.
+func (f *cmdFile) offsetPoint(offset int) (point, error) {
+	m := f.mapper
 	if !(0 <= offset && offset <= len(m.Content)) {
 		return point{}, fmt.Errorf("invalid offset %d (want 0-%d)", offset, len(m.Content))
 	}
+	if f.tabWidth > 0 {
+		line, col := m.OffsetLineColDisplay(offset, f.tabWidth)
+		return newPoint(line, col, offset), nil
+	}
 	line, col8 := m.OffsetLineCol8(offset)
 	return newPoint(line, col8, offset), nil
 }
@@ -910,11 +931,11 @@ func (f *cmdFile) spanRange(s span) (protocol.Range, error) {
 	if !strings.EqualFold(filepath.Base(string(f.mapper.URI)), filepath.Base(string(s.URI()))) {
 		return protocol.Range{}, bugpkg.Errorf("mapper is for file %q instead of %q", f.mapper.URI, s.URI())
 	}
-	start, err := pointPosition(f.mapper, s.Start())
+	start, err := f.pointPosition(s.Start())
 	if err != nil {
 		return protocol.Range{}, fmt.Errorf("start: %w", err)
 	}
-	end, err := pointPosition(f.mapper, s.End())
+	end, err := f.pointPosition(s.End())
 	if err != nil {
 		return protocol.Range{}, fmt.Errorf("end: %w", err)
 	}
@@ -922,8 +943,13 @@ func (f *cmdFile) spanRange(s span) (protocol.Range, error) {
 }
 
 // pointPosition converts a valid span (UTF-8) point to a protocol (UTF-16) position.
-func pointPosition(m *protocol.Mapper, p point) (protocol.Position, error) {
+// Its column is a display column if f.tabWidth is positive.
+func (f *cmdFile) pointPosition(p point) (protocol.Position, error) {
+	m := f.mapper
 	if p.HasPosition() {
+		if f.tabWidth > 0 {
+			return m.LineColDisplayPosition(p.Line(), p.Column(), f.tabWidth)
+		}
 		return m.LineCol8Position(p.Line(), p.Column())
 	}
 	if p.HasOffset() {
@@ -34,10 +34,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
 
+	"golang.org/x/tools/gopls/internal/baseline"
 	"golang.org/x/tools/gopls/internal/cmd"
 	"golang.org/x/tools/gopls/internal/debug"
 	"golang.org/x/tools/gopls/internal/protocol"
@@ -143,6 +145,138 @@ var C int
 	}
 }
 
+// TestCheckReport tests the -o and -severity flags of the 'check' subcommand.
+func TestCheckReport(t *testing.T) {
+	t.Parallel()
+
+	tree := writeTree(t, `
+-- go.mod --
+module example.com
+go 1.18
+
+-- a/a.go --
+package a
+import "fmt"
+var _ = fmt.Sprintf("%s", 123)
+
+-- a/b/b.go --
+package b
+func _() {
+	for x := range []int{} {}
+}
+
+-- testdata/t.go --
+package t
+var x undefined
+`)
+
+	type report struct {
+		Diagnostics []struct {
+			File, Source, Message, Severity, Fingerprint string
+			Line, Column                                 int
+		}
+	}
+	readReport := func(res *result) report {
+		res.checkExit(true)
+		var r report
+		res.toJSON(&r)
+		return r
+	}
+
+	// A recursive pattern expands to all non-testdata files.
+	r := readReport(gopls(t, tree, "check", "-o", "-", "./..."))
+	var files []string
+	for _, d := range r.Diagnostics {
+		files = append(files, d.File)
+		if d.Fingerprint == "" {
+			t.Errorf("diagnostic %s:%d has no fingerprint", d.File, d.Line)
+		}
+	}
+	if want := []string{"a/a.go", "a/b/b.go"}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("check ./... reported diagnostics in %v, want %v", files, want)
+	}
+
+	// The fingerprint is independent of the line number.
+	fp := r.Diagnostics[0].Fingerprint
+	filename := filepath.Join(tree, "a/a.go")
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(content), "package a\n", "package a\n\n", 1)
+	if err := os.WriteFile(filename, []byte(edited), 0666); err != nil {
+		t.Fatal(err)
+	}
+	r = readReport(gopls(t, tree, "check", "-o", "-", "a"))
+	if len(r.Diagnostics) != 1 || r.Diagnostics[0].Line != 4 || r.Diagnostics[0].Fingerprint != fp {
+		t.Errorf("after edit, got %+v, want a single diagnostic on line 4 with fingerprint %s", r.Diagnostics, fp)
+	}
+
+	// The -severity flag excludes less severe diagnostics.
+	r = readReport(gopls(t, tree, "check", "-o", "-", "-severity=error", "./..."))
+	for _, d := range r.Diagnostics {
+		if d.Severity != "error" {
+			t.Errorf("check -severity=error reported %s diagnostic: %s", d.Severity, d.Message)
+		}
+	}
+}
+
+// TestCheckBaseline tests the -baseline and -write-baseline flags of
+// the 'check' subcommand.
+func TestCheckBaseline(t *testing.T) {
+	t.Parallel()
+
+	tree := writeTree(t, `
+-- go.mod --
+module example.com
+go 1.18
+
+-- a.go --
+package a
+import "fmt"
+var _ = fmt.Sprintf("%s", 123)
+`)
+
+	// Record the existing finding.
+	{
+		res := gopls(t, tree, "check", "-write-baseline=baseline.json", "a.go")
+		res.checkExit(true)
+		res.checkStdout(`a.go:3:9-31: fmt.Sprintf format %s has arg 123 of wrong type int`)
+		data, err := os.ReadFile(filepath.Join(tree, "baseline.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		set, err := baseline.Parse(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(set) != 1 {
+			t.Errorf("baseline contains %d fingerprints, want 1", len(set))
+		}
+	}
+
+	// Add a second finding; only it is reported.
+	{
+		content := "package a\nimport \"fmt\"\nvar _ = fmt.Sprintf(\"%s\", 123)\nvar _ = fmt.Sprintf(\"%d\", \"x\")\n"
+		if err := os.WriteFile(filepath.Join(tree, "a.go"), []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		res := gopls(t, tree, "check", "-baseline=baseline.json", "a.go")
+		res.checkExit(true)
+		res.checkStdout(`a.go:4:9-31: fmt.Sprintf format %d has arg "x" of wrong type string`)
+		if strings.Contains(res.stdout, "a.go:3:") {
+			t.Errorf("check -baseline reported a baseline finding: %s", res.stdout)
+		}
+	}
+
+	// A missing baseline file is an error.
+	{
+		res := gopls(t, tree, "check", "-baseline=missing.json", "a.go")
+		res.checkExit(false)
+		res.checkStderr("missing.json")
+	}
+}
+
 // TestCallHierarchy tests the 'call_hierarchy' subcommand (../call_hierarchy.go).
 func TestCallHierarchy(t *testing.T) {
 	t.Parallel()
@@ -276,6 +410,17 @@ func g() {
 		res.checkStdout("print.go.* defined here as func fmt.Println")
 		res.checkStdout("Println formats using the default formats for its operands")
 	}
+	// display columns: the tab before "f()" occupies 8 or 4 cells
+	{
+		res := gopls(t, tree, "-display-columns", "definition", "a.go:7:9") // "f()"
+		res.checkExit(true)
+		res.checkStdout("a.go:3:6-7: defined here as func f")
+	}
+	{
+		res := gopls(t, tree, "-display-columns", "-tabwidth=4", "definition", "a.go:7:5") // "f()"
+		res.checkExit(true)
+		res.checkStdout("a.go:3:6-7: defined here as func f")
+	}
 	// -json and -markdown
 	{
 		res := gopls(t, tree, "definition", "-json", "-markdown", "a.go:4:7")
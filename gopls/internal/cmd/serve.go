@@ -32,6 +32,9 @@ type Serve struct {
 	Address     string        `flag:"listen" help:"address on which to listen for remote connections. If prefixed by 'unix;', the subsequent address is assumed to be a unix domain socket. Otherwise, TCP is used."`
 	IdleTimeout time.Duration `flag:"listen.timeout" help:"when used with -listen, shut down the server when there are no connected clients for this duration"`
 	Trace       bool          `flag:"rpc.trace" help:"print the full rpc trace in lsp inspector format"`
+	TraceJSON   bool          `flag:"rpc.trace.json" help:"when used with -rpc.trace, print the trace as one JSON object per message"`
+	TraceRedact bool          `flag:"rpc.trace.redact" help:"when used with -rpc.trace, replace file paths, file contents, and other source-derived strings by hashes"`
+	TraceSample string        `flag:"rpc.trace.sample" help:"when used with -rpc.trace, a comma-separated list of method=rate pairs, such as 'textDocument/hover=0.1,*=1', giving the fraction of the messages of each method to trace"`
 	Debug       string        `flag:"debug" help:"serve debug information on the supplied address"`
 
 	RemoteListenTimeout time.Duration `flag:"remote.listen.timeout" help:"when used with -remote=auto, the -listen.timeout value used to start the daemon"`
@@ -81,6 +84,11 @@ func (s *Serve) Run(ctx context.Context, args ...string) error {
 		return tool.CommandLineErrorf("server does not take arguments, got %v", args)
 	}
 
+	sampling, err := protocol.ParseSampling(s.TraceSample)
+	if err != nil {
+		return tool.CommandLineErrorf("invalid -rpc.trace.sample: %v", err)
+	}
+
 	di := debug.GetInstance(ctx)
 	isDaemon := s.Address != "" || s.Port != 0
 	if di != nil {
@@ -94,7 +102,6 @@ func (s *Serve) Run(ctx context.Context, args ...string) error {
 	}
 	var ss jsonrpc2.StreamServer
 	if s.app.Remote != "" {
-		var err error
 		ss, err = lsprpc.NewForwarder(s.app.Remote, s.remoteArgs)
 		if err != nil {
 			return fmt.Errorf("creating forwarder: %w", err)
@@ -135,10 +142,14 @@ func (s *Serve) Run(ctx context.Context, args ...string) error {
 	}
 	stream := jsonrpc2.NewHeaderStream(fakenet.NewConn("stdio", os.Stdin, os.Stdout))
 	if s.Trace && di != nil {
-		stream = protocol.LoggingStream(stream, di.LogWriter)
+		stream = protocol.TracingStream(stream, di.LogWriter, protocol.TraceOptions{
+			JSON:     s.TraceJSON,
+			Redact:   s.TraceRedact,
+			Sampling: sampling,
+		})
 	}
 	conn := jsonrpc2.NewConn(stream)
-	err := ss.ServeStream(ctx, conn)
+	err = ss.ServeStream(ctx, conn)
 	if errors.Is(err, io.EOF) {
 		return nil
 	}
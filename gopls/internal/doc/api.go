@@ -75,6 +75,15 @@ type Analyzer struct {
 	Doc     string // from analysis.Analyzer.Doc ("title: summary\ndescription"; not Markdown)
 	URL     string
 	Default bool
+	Example *AnalyzerExample `json:",omitempty"`
+}
+
+// An AnalyzerExample is an excerpt of a test of an analyzer's fixes:
+// a fragment of a test file before and after the fixes are applied.
+type AnalyzerExample struct {
+	File   string // slash-separated test file name, relative to the x/tools module
+	Before string
+	After  string
 }
 
 type Hint struct {
@@ -0,0 +1,131 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/protocol"
+)
+
+// maxAliasChain bounds the length of the alias chains that are
+// resolved, as ill-typed code may contain cycles.
+const maxAliasChain = 32
+
+// An aliasLink is one of the type names traversed while resolving an
+// alias: the alias itself, the aliases denoted by the right-hand side
+// of each declaration, and finally the defined type, if any.
+type aliasLink struct {
+	name string            // qualified name, e.g. "b.B"
+	loc  protocol.Location // location of the declaring identifier; zero for built-ins
+}
+
+// aliasChain returns the chain of type names traversed while
+// resolving the alias tname of pkg, which starts with tname itself.
+//
+// The chain is computed from the syntax of each declaration, so that
+// it is complete even when the type checker does not materialize
+// aliases (gotypesalias=0). Resolving an alias declared in another
+// package requires type-checking that package.
+func aliasChain(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, tname *types.TypeName) ([]aliasLink, error) {
+	from := pkg.Types().Path()
+	var chain []aliasLink
+	for tname != nil {
+		name := tname.Name()
+		if tname.Pkg() == nil {
+			chain = append(chain, aliasLink{name: name})
+			break
+		}
+		if tname.Pkg().Path() != from {
+			name = tname.Pkg().Name() + "." + name
+		}
+		loc, err := mapPosition(ctx, pkg.FileSet(), snapshot, tname.Pos(), tname.Pos()+token.Pos(len(tname.Name())))
+		if err != nil {
+			return chain, err
+		}
+		chain = append(chain, aliasLink{name: name, loc: loc})
+		if !tname.IsAlias() || len(chain) == maxAliasChain {
+			break
+		}
+
+		// Resolve the right-hand side of the declaration using the
+		// type information of the declaring package.
+		if tname.Pkg() != pkg.Types() {
+			pkg, _, err = NarrowestPackageForFile(ctx, snapshot, loc.URI)
+			if err != nil {
+				return chain, err
+			}
+			tname, _ = pkg.Types().Scope().Lookup(tname.Name()).(*types.TypeName)
+			if tname == nil {
+				return chain, fmt.Errorf("no type %s in package %s", name, pkg.Types().Path())
+			}
+		}
+		pgf, err := pkg.File(loc.URI)
+		if err != nil {
+			return chain, err
+		}
+		path, _ := astutil.PathEnclosingInterval(pgf.File, tname.Pos(), tname.Pos())
+		var rhs ast.Expr
+		for _, n := range path {
+			if spec, ok := n.(*ast.TypeSpec); ok && spec.Name.Pos() == tname.Pos() {
+				rhs = spec.Type
+				break
+			}
+		}
+		tname = nil
+		if id := typeNameIdent(rhs); id != nil {
+			tname, _ = pkg.TypesInfo().Uses[id].(*types.TypeName)
+		}
+	}
+	return chain, nil
+}
+
+// typeNameIdent returns the identifier of the (possibly qualified or
+// instantiated) type name denoted by the type expression e, or nil if
+// e is a type literal.
+func typeNameIdent(e ast.Expr) *ast.Ident {
+	switch e := astutil.Unparen(e).(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	case *ast.IndexExpr:
+		return typeNameIdent(e.X)
+	case *ast.IndexListExpr:
+		return typeNameIdent(e.X)
+	}
+	return nil
+}
+
+// formatAliasChain returns the description of the alias chain shown
+// by hover, followed by the underlying type of the alias if it is not
+// obvious from the chain, or "" if the chain has a single link.
+//
+// Example:
+//
+//	// Alias chain: A = b.B = c.C
+//	// Underlying type: struct{x int}
+func formatAliasChain(chain []aliasLink, tname *types.TypeName, qf types.Qualifier) string {
+	if len(chain) < 2 {
+		return ""
+	}
+	names := make([]string, len(chain))
+	for i, link := range chain {
+		names[i] = link.name
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Alias chain: %s\n", strings.Join(names, " = "))
+	if under := types.TypeString(tname.Type().Underlying(), qf); under != names[len(names)-1] {
+		fmt.Fprintf(&b, "// Underlying type: %s\n", under)
+	}
+	return b.String()
+}
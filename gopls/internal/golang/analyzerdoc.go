@@ -0,0 +1,75 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/doc"
+	"golang.org/x/tools/gopls/internal/util/bug"
+)
+
+// AnalyzerDoc returns the documentation of the named analyzer as
+// Markdown: its title, the description from its Doc if full, an
+// example of its fixes taken from its tests, if any, and a link to its
+// package documentation, if any. The examples are extracted from the
+// golden files of the tests by the doc generator (see api.json).
+func AnalyzerDoc(snapshot *cache.Snapshot, name string, full bool) (string, error) {
+	a, err := lookupAnalyzer(snapshot, name)
+	if err != nil {
+		return "", err
+	}
+	title, description, _ := strings.Cut(a.Analyzer().Doc, "\n")
+	title = strings.TrimPrefix(title, name+": ")
+
+	var buf strings.Builder
+	if full {
+		fmt.Fprintf(&buf, "## `%s`: %s\n\n", name, title)
+		if description = strings.TrimSpace(description); description != "" {
+			fmt.Fprintf(&buf, "%s\n\n", description)
+		}
+	} else {
+		fmt.Fprintf(&buf, "**`%s`**: %s\n\n", name, title)
+	}
+	if example := analyzerExample(name); example != nil {
+		if full {
+			fmt.Fprintf(&buf, "### Example\n\n")
+		}
+		fmt.Fprintf(&buf, "The fixes of `%s` change this code from `%s`:\n\n", name, example.File)
+		fmt.Fprintf(&buf, "```go\n%s```\n\nto:\n\n```go\n%s```\n\n", example.Before, example.After)
+	}
+	if url := a.Analyzer().URL; url != "" {
+		fmt.Fprintf(&buf, "Package documentation: [%s](%s)\n", name, url)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+var (
+	analyzerExamplesOnce sync.Once
+	analyzerExamples     map[string]*doc.AnalyzerExample // keyed by analyzer name
+)
+
+// analyzerExample returns the example of the fixes of the named
+// analyzer recorded in api.json, or nil if there is none.
+func analyzerExample(name string) *doc.AnalyzerExample {
+	analyzerExamplesOnce.Do(func() {
+		var api doc.API
+		if err := json.Unmarshal([]byte(doc.JSON), &api); err != nil {
+			bug.Reportf("invalid api.json: %v", err)
+			return
+		}
+		analyzerExamples = make(map[string]*doc.AnalyzerExample)
+		for _, a := range api.Analyzers {
+			if a.Example != nil {
+				analyzerExamples[a.Name] = a.Example
+			}
+		}
+	})
+	return analyzerExamples[name]
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the checking of the API of the workspace packages
+// against a released version of their module (see the
+// apiCompatibility setting).
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/types/objectpath"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/apidiff"
+)
+
+// APICompatibilityDiagnostics returns a "breaking change" warning for
+// each incompatible change to the exported API of the workspace
+// packages since the released version of their module selected by the
+// apiCompatibility setting. Main packages, packages whose path
+// contains an "internal" element, and packages with errors, whose API
+// may be incomplete, are not checked.
+func APICompatibilityDiagnostics(ctx context.Context, snapshot *cache.Snapshot) (map[protocol.DocumentURI][]*cache.Diagnostic, error) {
+	if snapshot.Options().APICompatibility == "" {
+		return nil, nil
+	}
+	mps, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group the packages to check by go.mod file.
+	byModule := make(map[protocol.DocumentURI][]*metadata.Package)
+	for _, mp := range mps {
+		if mp.Module == nil || mp.Module.GoMod == "" || mp.ForTest != "" || mp.Name == "main" ||
+			metadata.IsCommandLineArguments(mp.ID) || isInternalPath(string(mp.PkgPath)) {
+			continue
+		}
+		modURI := protocol.URIFromPath(mp.Module.GoMod)
+		byModule[modURI] = append(byModule[modURI], mp)
+	}
+
+	reports := make(map[protocol.DocumentURI][]*cache.Diagnostic)
+	var firstErr error
+	for modURI, mps := range byModule {
+		baseline, err := snapshot.APIBaseline(ctx, modURI)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if baseline == nil {
+			continue
+		}
+		for _, mp := range mps {
+			old, ok := baseline.Packages[mp.PkgPath]
+			if !ok {
+				continue // a new package
+			}
+			pkgs, err := snapshot.TypeCheck(ctx, mp.ID)
+			if err != nil {
+				return nil, err
+			}
+			pkg := pkgs[0]
+			if len(pkg.ParseErrors()) > 0 || len(pkg.TypeErrors()) > 0 {
+				continue
+			}
+			for _, change := range apidiff.Changes(old, pkg.Types()).Changes {
+				if change.Compatible {
+					continue
+				}
+				loc, err := apiChangeLocation(pkg, baseline, change)
+				if err != nil {
+					return nil, err
+				}
+				reports[loc.URI] = append(reports[loc.URI], &cache.Diagnostic{
+					URI:      loc.URI,
+					Range:    loc.Range,
+					Severity: protocol.SeverityWarning,
+					Source:   cache.APICompatibility,
+					Message:  fmt.Sprintf("breaking change since %s: %s", baseline.Version, change.Message),
+				})
+			}
+		}
+	}
+	return reports, firstErr
+}
+
+// isInternalPath reports whether the package path contains an
+// "internal" element.
+func isInternalPath(path string) bool {
+	for _, elem := range strings.Split(path, "/") {
+		if elem == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+// apiChangeLocation returns the location in pkg at which to report an
+// API change: the name of the changed declaration, or of its changed
+// field or method, if it still exists; otherwise that of the enclosing
+// package-level declaration; otherwise the package clause of the file
+// that declared the object in the released version, or, if there is
+// no such file, of the first file of the package.
+func apiChangeLocation(pkg *cache.Package, baseline *cache.APIBaseline, change apidiff.Change) (protocol.Location, error) {
+	obj := change.Object
+	var newObj types.Object
+	if obj.Pkg() == pkg.Types() {
+		newObj = obj // an addition
+	} else if path, err := objectpath.For(obj); err == nil {
+		newObj, _ = objectpath.Object(pkg.Types(), path)
+		if newObj == nil {
+			// The path begins with the name of the
+			// package-level object that encloses obj.
+			name := string(path)
+			if i := strings.IndexByte(name, '.'); i >= 0 {
+				name = name[:i]
+			}
+			newObj = pkg.Types().Scope().Lookup(name)
+		}
+	}
+	if tname, ok := newObj.(*types.TypeName); ok && token.IsIdentifier(change.Part) {
+		if member, _, _ := types.LookupFieldOrMethod(tname.Type(), true, pkg.Types(), change.Part); member != nil && member.Pkg() == pkg.Types() {
+			newObj = member
+		}
+	}
+
+	if newObj != nil && newObj.Pos().IsValid() {
+		if tokFile := pkg.FileSet().File(newObj.Pos()); tokFile != nil {
+			if pgf, err := pkg.File(protocol.URIFromPath(tokFile.Name())); err == nil {
+				return pgf.PosLocation(newObj.Pos(), newObj.Pos()+token.Pos(len(newObj.Name())))
+			}
+		}
+	}
+
+	// The object was removed.
+	var pgf *parsego.File
+	if obj.Pos().IsValid() {
+		base := filepath.Base(baseline.Fset.Position(obj.Pos()).Filename)
+		for _, f := range pkg.CompiledGoFiles() {
+			if filepath.Base(f.URI.Path()) == base {
+				pgf = f
+				break
+			}
+		}
+	}
+	if pgf == nil {
+		if len(pkg.CompiledGoFiles()) == 0 {
+			return protocol.Location{}, fmt.Errorf("package %s has no files", pkg.Metadata().PkgPath)
+		}
+		pgf = pkg.CompiledGoFiles()[0]
+	}
+	return pgf.NodeLocation(pgf.File.Name)
+}
@@ -0,0 +1,200 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/event"
+)
+
+// This file defines the definitions of Go functions that have no body
+// because they are implemented in assembly or by a //go:linkname
+// directive.
+
+// bodylessDefinitions returns the implementations of the function fn,
+// whose declaration is at loc, if the declaration has no body: the
+// target of a //go:linkname directive for fn in the same file, and
+// the TEXT directives for fn in the assembly files of the workspace
+// and of fn's package.
+func bodylessDefinitions(ctx context.Context, snapshot *cache.Snapshot, fn *types.Func, loc protocol.Location) ([]protocol.Location, error) {
+	if fn.Pkg() == nil || fn.Type().(*types.Signature).Recv() != nil {
+		return nil, nil // built-in, or a method
+	}
+	fh, err := snapshot.ReadFile(ctx, loc.URI)
+	if err != nil {
+		return nil, err
+	}
+	pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := pgf.RangePos(loc.Range)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	if len(path) < 2 {
+		return nil, nil
+	}
+	if decl, ok := path[1].(*ast.FuncDecl); !ok || decl.Name != path[0] || decl.Body != nil {
+		return nil, nil
+	}
+
+	var locs []protocol.Location
+
+	// A //go:linkname directive that pulls in the implementation,
+	// as in "//go:linkname fn pkg.name".
+	for _, cg := range pgf.File.Comments {
+		for _, c := range cg.List {
+			fields := strings.Fields(c.Text)
+			if len(fields) != 3 || fields[0] != "//go:linkname" || fields[1] != fn.Name() {
+				continue
+			}
+			dot := strings.LastIndexByte(fields[2], '.')
+			if dot < 0 {
+				continue
+			}
+			pkgPath, name := fields[2][:dot], fields[2][dot+1:]
+			_, pgf, pos, err := findLinkname(ctx, snapshot, PackagePath(pkgPath), name)
+			if err != nil {
+				// The target may belong to a package not linked
+				// with this one, or be implemented in assembly.
+				event.Error(ctx, "finding linkname target", err)
+				continue
+			}
+			loc, err := pgf.PosLocation(pos, pos+token.Pos(len(name)))
+			if err != nil {
+				return nil, err
+			}
+			locs = append(locs, loc)
+		}
+	}
+
+	// TEXT directives in assembly files.
+	symbols, err := asmSymbols(ctx, snapshot, loc.URI.Dir(), fn.Pkg().Path())
+	if err != nil {
+		return nil, err
+	}
+	asmLocs := symbols[fn.Pkg().Path()+"."+fn.Name()]
+	sort.Slice(asmLocs, func(i, j int) bool {
+		return protocol.CompareLocation(asmLocs[i], asmLocs[j]) < 0
+	})
+	return append(locs, asmLocs...), nil
+}
+
+// asmSymbols returns an index of the functions defined by the TEXT
+// directives of the assembly files in the directories of the workspace
+// packages and in the specified directory of package pkgPath. The index
+// maps the linker name of each function, such as "math.Sqrt", to the
+// locations of its definitions, of which there may be one per
+// architecture.
+func asmSymbols(ctx context.Context, snapshot *cache.Snapshot, dir protocol.DocumentURI, pkgPath string) (map[string][]protocol.Location, error) {
+	mps, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The assembly files of a directory belong to its non-test package.
+	dirs := make(map[protocol.DocumentURI]PackagePath)
+	for _, mp := range mps {
+		if mp.ForTest == "" && !metadata.IsCommandLineArguments(mp.ID) && len(mp.GoFiles) > 0 {
+			dirs[mp.GoFiles[0].Dir()] = mp.PkgPath
+		}
+	}
+	dirs[dir] = PackagePath(pkgPath)
+
+	index := make(map[string][]protocol.Location)
+	for dir, pkgPath := range dirs {
+		entries, err := os.ReadDir(dir.Path())
+		if err != nil {
+			continue // e.g. deleted
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".s" {
+				continue
+			}
+			fh, err := snapshot.ReadFile(ctx, protocol.URIFromPath(filepath.Join(dir.Path(), entry.Name())))
+			if err != nil {
+				return nil, err
+			}
+			content, err := fh.Content()
+			if err != nil {
+				continue // e.g. deleted
+			}
+			if err := indexAsmFile(fh.URI(), content, string(pkgPath), index); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return index, nil
+}
+
+// indexAsmFile adds the functions defined by the TEXT directives of
+// the assembly file of package pkgPath to the index.
+//
+// The symbol of a directive is the linker name of the function, in
+// which the package path, if any, is separated from the name by a
+// middle dot (U+00B7) and slashes are written as division slashes
+// (U+2215), as in "TEXT math∕bits·Len(SB)". An empty package path
+// denotes the package of the file, as in "TEXT ·Sqrt(SB),NOSPLIT,$0".
+func indexAsmFile(uri protocol.DocumentURI, content []byte, pkgPath string, index map[string][]protocol.Location) error {
+	m := protocol.NewMapper(uri, content)
+	for offset := 0; offset < len(content); {
+		line := content[offset:]
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i+1]
+		}
+		lineStart := offset
+		offset += len(line)
+
+		// Find the symbol in "TEXT symbol<ABI>(SB), flags, $frame".
+		trimmed := bytes.TrimLeft(line, " \t")
+		if !bytes.HasPrefix(trimmed, []byte("TEXT")) {
+			continue
+		}
+		rest := trimmed[len("TEXT"):]
+		if len(rest) == 0 || (rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		symStart := lineStart + (len(line) - len(trimmed)) + len("TEXT")
+		for symStart < offset && (content[symStart] == ' ' || content[symStart] == '\t') {
+			symStart++
+		}
+		n := bytes.IndexAny(content[symStart:offset], "<(")
+		if n <= 0 {
+			continue
+		}
+		symEnd := symStart + n
+		symbol := string(content[symStart:symEnd])
+		dot := strings.LastIndex(symbol, "·")
+		if dot < 0 {
+			continue // not a Go function
+		}
+		pkg, name := strings.ReplaceAll(symbol[:dot], "∕", "/"), symbol[dot+len("·"):]
+		if pkg == "" {
+			pkg = pkgPath
+		}
+		loc, err := m.OffsetLocation(symStart, symEnd)
+		if err != nil {
+			return fmt.Errorf("TEXT directive in %s: %v", uri, err)
+		}
+		index[pkg+"."+name] = append(index[pkg+"."+name], loc)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/event"
+)
+
+// MatchingBracket returns the location of the bracket that matches
+// the one adjacent to the specified position in a Go file, or nil if
+// there is none. See [matchingBrackets] for the brackets it considers.
+func MatchingBracket(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, position protocol.Position) (*protocol.Location, error) {
+	ctx, done := event.Start(ctx, "golang.MatchingBracket")
+	defer done()
+
+	pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+	if err != nil {
+		return nil, err
+	}
+	pos, err := pgf.PositionPos(position)
+	if err != nil {
+		return nil, err
+	}
+	pair, at := matchingBrackets(pgf, pos)
+	if at == token.NoPos {
+		return nil, nil
+	}
+	other := pair.open
+	if at == pair.open {
+		other = pair.close
+	}
+	loc, err := pgf.PosLocation(other, other+1)
+	if err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
+
+// A bracketPair holds the positions of an opening bracket and of the
+// closing bracket that matches it.
+type bracketPair struct{ open, close token.Pos }
+
+// matchingBrackets returns the pair of brackets of which one is
+// adjacent to pos, preferring the one that follows pos, and the
+// position of that bracket, or NoPos if there is none.
+//
+// Brackets are matched according to the syntax tree, not the text, so
+// that brackets within string literals and comments are ignored. They
+// include the braces of blocks, composite literals, and struct and
+// interface types; the parentheses of calls, conversions, parameter
+// lists, and grouped declarations; and the square brackets of index
+// and slice expressions, type parameter lists, and instantiations.
+// The closing brackets of array and map types are not recorded in the
+// syntax tree, so those types are not matched.
+func matchingBrackets(pgf *parsego.File, pos token.Pos) (bracketPair, token.Pos) {
+	var pairs []bracketPair
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		if n == nil || pos < n.Pos() || n.End() < pos {
+			return false
+		}
+		var pair bracketPair
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			pair = bracketPair{n.Lbrace, n.Rbrace}
+		case *ast.CompositeLit:
+			pair = bracketPair{n.Lbrace, n.Rbrace}
+		case *ast.FieldList:
+			pair = bracketPair{n.Opening, n.Closing}
+		case *ast.CallExpr:
+			pair = bracketPair{n.Lparen, n.Rparen}
+		case *ast.ParenExpr:
+			pair = bracketPair{n.Lparen, n.Rparen}
+		case *ast.TypeAssertExpr:
+			pair = bracketPair{n.Lparen, n.Rparen}
+		case *ast.GenDecl:
+			pair = bracketPair{n.Lparen, n.Rparen}
+		case *ast.IndexExpr:
+			pair = bracketPair{n.Lbrack, n.Rbrack}
+		case *ast.IndexListExpr:
+			pair = bracketPair{n.Lbrack, n.Rbrack}
+		case *ast.SliceExpr:
+			pair = bracketPair{n.Lbrack, n.Rbrack}
+		default:
+			return true
+		}
+		// In a file with syntax errors, the parser may record a
+		// closing bracket that is missing at the position of
+		// whatever token follows, so check the source.
+		if isBracket(pgf, pair.open, "{([") && isBracket(pgf, pair.close, "})]") {
+			pairs = append(pairs, pair)
+		}
+		return true
+	})
+
+	for _, at := range []token.Pos{pos, pos - 1} {
+		for _, pair := range pairs {
+			if pair.open == at || pair.close == at {
+				return pair, at
+			}
+		}
+	}
+	return bracketPair{}, token.NoPos
+}
+
+// isBracket reports whether the source of the file at pos is one of
+// the specified brackets.
+func isBracket(pgf *parsego.File, pos token.Pos, brackets string) bool {
+	if !pos.IsValid() {
+		return false
+	}
+	offset, err := safetoken.Offset(pgf.Tok, pos)
+	if err != nil || offset >= len(pgf.Src) {
+		return false
+	}
+	return strings.IndexByte(brackets, pgf.Src[offset]) >= 0
+}
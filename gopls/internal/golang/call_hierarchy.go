@@ -69,7 +69,7 @@ func IncomingCalls(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle
 	ctx, done := event.Start(ctx, "golang.IncomingCalls")
 	defer done()
 
-	refs, err := references(ctx, snapshot, fh, pos, false)
+	refs, err := references(ctx, snapshot, fh, pos, false, nil)
 	if err != nil {
 		if errors.Is(err, ErrNoIdentFound) || errors.Is(err, errNoObjectFound) {
 			return nil, nil
@@ -270,6 +270,18 @@ func OutgoingCalls(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle
 		return true
 	})
 
+	// With the dynamicCalls option, also report the possible callees
+	// of each call through an interface or function value.
+	var dyn *dynamicCallees
+	if snapshot.Options().DynamicCalls {
+		dyn = &dynamicCallees{
+			snapshot: snapshot,
+			pkg:      declPkg,
+			pgf:      declPGF,
+			calls:    make(map[protocol.Location]*protocol.CallHierarchyOutgoingCall),
+		}
+	}
+
 	outgoingCalls := map[token.Pos]*protocol.CallHierarchyOutgoingCall{}
 	for _, callRange := range callRanges {
 		_, obj, _ := referencedObject(declPkg, declPGF, callRange.start)
@@ -279,6 +291,11 @@ func OutgoingCalls(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle
 		if isBuiltin(obj) {
 			continue // built-ins have no position
 		}
+		if dyn != nil {
+			if err := dyn.add(ctx, obj, callRange.start, callRange.end); err != nil {
+				return nil, err
+			}
+		}
 
 		outgoingCall, ok := outgoingCalls[obj.Pos()]
 		if !ok {
@@ -311,5 +328,189 @@ func OutgoingCalls(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle
 	for _, callItem := range outgoingCalls {
 		outgoingCallItems = append(outgoingCallItems, *callItem)
 	}
+	if dyn != nil {
+		// A dynamic callee that is also called statically
+		// is reported once, as a static callee.
+		static := make(map[protocol.Location]*protocol.CallHierarchyOutgoingCall)
+		for i := range outgoingCallItems {
+			call := &outgoingCallItems[i]
+			static[protocol.Location{URI: call.To.URI, Range: call.To.Range}] = call
+		}
+		for loc, call := range dyn.calls {
+			if s, ok := static[loc]; ok {
+				s.FromRanges = append(s.FromRanges, call.FromRanges...)
+			} else {
+				outgoingCallItems = append(outgoingCallItems, *call)
+			}
+		}
+	}
 	return outgoingCallItems, nil
 }
+
+// dynamicCallees computes a cheap, type-based approximation of the
+// callees of the dynamic calls in a function. The callees of a call of
+// an interface method are the concrete methods that implement it, as
+// for an implementation query. The callees of a call of a
+// function-typed variable or field are the functions and methods of
+// identical type that are used as values, not just called, in the
+// caller's package.
+type dynamicCallees struct {
+	snapshot *cache.Snapshot
+	pkg      *cache.Package
+	pgf      *parsego.File // file of the caller
+
+	methods map[*types.Func][]protocol.Location // implementations of interface methods
+	values  []funcValue                         // functions used as values in pkg; computed lazily
+
+	calls map[protocol.Location]*protocol.CallHierarchyOutgoingCall
+}
+
+// A funcValue is a function or method used as a value.
+type funcValue struct {
+	fn *types.Func
+	t  types.Type // type of the function value (e.g. sans receiver, for a method value)
+}
+
+// add records the dynamic callees of the call of obj whose callee
+// expression spans [start, end).
+func (d *dynamicCallees) add(ctx context.Context, obj types.Object, start, end token.Pos) error {
+	rng, err := d.pgf.PosRange(start, end)
+	if err != nil {
+		return err
+	}
+	switch obj := obj.(type) {
+	case *types.Func:
+		recv := obj.Type().(*types.Signature).Recv()
+		if recv == nil || !types.IsInterface(recv.Type()) {
+			return nil // a static call
+		}
+		locs, ok := d.methods[obj]
+		if !ok {
+			fh, err := d.snapshot.ReadFile(ctx, d.pgf.URI)
+			if err != nil {
+				return err
+			}
+			locs, err = implementations(ctx, d.snapshot, fh, rng.Start, nil)
+			if err != nil {
+				return err
+			}
+			if d.methods == nil {
+				d.methods = make(map[*types.Func][]protocol.Location)
+			}
+			d.methods[obj] = locs
+		}
+		for _, loc := range locs {
+			if d.snapshot.IsBuiltin(loc.URI) {
+				continue // the error type (see localImplementations)
+			}
+			pkgPath := "" // unknown
+			if mp, err := NarrowestMetadataForFile(ctx, d.snapshot, loc.URI); err == nil {
+				pkgPath = string(mp.PkgPath)
+			}
+			d.record(loc, obj.Name(), protocol.Method, pkgPath, rng)
+		}
+
+	case *types.Var:
+		sig, ok := obj.Type().Underlying().(*types.Signature)
+		if !ok {
+			return nil
+		}
+		if d.values == nil {
+			d.values = funcValues(d.pkg)
+		}
+		for _, v := range d.values {
+			if !types.Identical(v.t.Underlying(), sig) {
+				continue
+			}
+			fn := v.fn
+			loc, err := mapPosition(ctx, d.pkg.FileSet(), d.snapshot, fn.Pos(), fn.Pos()+token.Pos(len(fn.Name())))
+			if err != nil {
+				return err
+			}
+			kind := protocol.Function
+			if fn.Type().(*types.Signature).Recv() != nil {
+				kind = protocol.Method
+			}
+			d.record(loc, fn.Name(), kind, fn.Pkg().Path(), rng)
+		}
+	}
+	return nil
+}
+
+// record records a dynamic call, at rng, of the function declared at loc.
+func (d *dynamicCallees) record(loc protocol.Location, name string, kind protocol.SymbolKind, pkgPath string, rng protocol.Range) {
+	call, ok := d.calls[loc]
+	if !ok {
+		call = &protocol.CallHierarchyOutgoingCall{
+			To: protocol.CallHierarchyItem{
+				Name:           name,
+				Kind:           kind,
+				Tags:           []protocol.SymbolTag{},
+				Detail:         fmt.Sprintf("dynamic • %s • %s", pkgPath, filepath.Base(loc.URI.Path())),
+				URI:            loc.URI,
+				Range:          loc.Range,
+				SelectionRange: loc.Range,
+			},
+		}
+		d.calls[loc] = call
+	}
+	call.FromRanges = append(call.FromRanges, rng)
+}
+
+// funcValues returns the functions and methods, declared in pkg or
+// its dependencies, that are used as values in pkg, other than as
+// the operand of a call.
+func funcValues(pkg *cache.Package) []funcValue {
+	info := pkg.TypesInfo()
+	var (
+		values  = []funcValue{}
+		seen    = make(map[types.Object]map[types.Type]bool)
+		callees = make(map[ast.Expr]bool)
+		sels    = make(map[*ast.Ident]bool)
+	)
+	add := func(e ast.Expr, id *ast.Ident) {
+		fn, ok := info.Uses[id].(*types.Func)
+		if !ok || callees[e] || fn.Pkg() == nil {
+			return
+		}
+		t := info.TypeOf(e)
+		if t == nil {
+			return
+		}
+		for prev := range seen[fn] {
+			if types.Identical(prev, t) {
+				return
+			}
+		}
+		if seen[fn] == nil {
+			seen[fn] = make(map[types.Type]bool)
+		}
+		seen[fn][t] = true
+		values = append(values, funcValue{fn, t})
+	}
+	for _, pgf := range pkg.CompiledGoFiles() {
+		ast.Inspect(pgf.File, func(n ast.Node) bool {
+			// (Nodes are visited before their children.)
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				fun := astutil.Unparen(n.Fun)
+				switch x := fun.(type) {
+				case *ast.IndexExpr: // f[T]()
+					fun = x.X
+				case *ast.IndexListExpr: // f[K, V]()
+					fun = x.X
+				}
+				callees[fun] = true
+			case *ast.SelectorExpr:
+				sels[n.Sel] = true
+				add(n, n.Sel)
+			case *ast.Ident:
+				if !sels[n] {
+					add(n, n)
+				}
+			}
+			return true
+		})
+	}
+	return values
+}
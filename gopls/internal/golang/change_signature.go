@@ -9,17 +9,18 @@ import (
 	"context"
 	"fmt"
 	"go/ast"
-	"go/format"
 	"go/parser"
 	"go/token"
 	"go/types"
 	"regexp"
+	"strings"
 
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/cache/parsego"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
 	"golang.org/x/tools/gopls/internal/util/bug"
 	"golang.org/x/tools/gopls/internal/util/safetoken"
 	"golang.org/x/tools/imports"
@@ -46,16 +47,8 @@ func RemoveUnusedParameter(ctx context.Context, fh file.Handle, rng protocol.Ran
 		return nil, err
 	}
 
-	// Changes to our heuristics for whether we can remove a parameter must also
-	// be reflected in the canRemoveParameter helper.
-	if perrors, terrors := pkg.ParseErrors(), pkg.TypeErrors(); len(perrors) > 0 || len(terrors) > 0 {
-		var sample string
-		if len(perrors) > 0 {
-			sample = perrors[0].Error()
-		} else {
-			sample = terrors[0].Error()
-		}
-		return nil, fmt.Errorf("can't change signatures for packages with parse or type errors: (e.g. %s)", sample)
+	if err := checkSignatureErrors(pkg); err != nil {
+		return nil, err
 	}
 
 	info, err := findParam(pgf, rng)
@@ -120,17 +113,343 @@ func RemoveUnusedParameter(ctx context.Context, fh file.Handle, rng protocol.Ran
 		}
 	}
 
-	// Rewrite all referring calls.
-	newContent, err := rewriteCalls(ctx, signatureRewrite{
-		snapshot: snapshot,
-		pkg:      pkg,
-		pgf:      pgf,
-		origDecl: info.decl,
-		newDecl:  newDecl,
-		params:   params,
-		callArgs: args,
-		variadic: variadic,
+	newParams, err := formatParams(tokeninternal.FileSetFor(pgf.Tok), newDecl.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	return changeSignature(ctx, signatureRewrite{
+		snapshot:  snapshot,
+		pkg:       pkg,
+		pgf:       pgf,
+		origDecl:  info.decl,
+		newParams: newParams,
+		params:    params,
+		callArgs:  args,
+		variadic:  variadic,
+	})
+}
+
+// ChangeSignature computes a refactoring to change the parameters of the
+// function declaration whose name or parameter list contains the given
+// range to newParams, which may reorder, remove, or add parameters. Every
+// call to the function in the workspace is rewritten, passing the default
+// expression of each new parameter.
+func ChangeSignature(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range, newParams []command.ChangeSignatureParam) ([]protocol.DocumentChange, error) {
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSignatureErrors(pkg); err != nil {
+		return nil, err
+	}
+	decl, err := findSignatureDecl(pgf, rng)
+	if err != nil {
+		return nil, err
+	}
+	scope := pkg.TypesInfo().Scopes[decl.Type]
+	if scope == nil {
+		return nil, bug.Errorf("missing function scope for %v", decl.Name.Name)
+	}
+
+	old := flattenParams(decl.Type.Params)
+	variadic := -1 // index of the old variadic parameter, if any
+	if n := len(old); n > 0 {
+		if _, ok := old[n-1].field.Type.(*ast.Ellipsis); ok {
+			variadic = n - 1
+		}
+	}
+	named := len(old) > 0 && old[0].name != nil
+	if len(old) == 0 && len(newParams) > 0 {
+		named = newParams[0].Name != ""
+	}
+
+	// Check the new parameters.
+	var (
+		kept     = make(map[int]bool)    // indices of kept parameters
+		declared = make(map[string]bool) // names of new parameters
+		changed  = len(newParams) != len(old)
+	)
+	for i, p := range newParams {
+		if p.Type == "" {
+			if p.OldIndex < 0 || p.OldIndex >= len(old) {
+				return nil, fmt.Errorf("invalid parameter index %d", p.OldIndex)
+			}
+			if kept[p.OldIndex] {
+				return nil, fmt.Errorf("parameter %s appears more than once", old[p.OldIndex].String(pgf))
+			}
+			kept[p.OldIndex] = true
+			if p.OldIndex == variadic && i != len(newParams)-1 {
+				return nil, fmt.Errorf("variadic parameter %s must remain last", old[p.OldIndex].String(pgf))
+			}
+			if p.OldIndex != i {
+				changed = true
+			}
+			continue
+		}
+		changed = true
+
+		// A new parameter.
+		if named && p.Name == "" {
+			return nil, fmt.Errorf("new parameter of type %s needs a name", p.Type)
+		}
+		if !named && p.Name != "" {
+			return nil, fmt.Errorf("new parameter %s must be unnamed, as are the parameters of %s", p.Name, decl.Name.Name)
+		}
+		if p.Name != "" && p.Name != "_" {
+			if !token.IsIdentifier(p.Name) {
+				return nil, fmt.Errorf("invalid parameter name %q", p.Name)
+			}
+			if declared[p.Name] || scope.Lookup(p.Name) != nil {
+				return nil, fmt.Errorf("%s is already declared in %s", p.Name, decl.Name.Name)
+			}
+			declared[p.Name] = true
+			if id := shadowedRef(pkg.TypesInfo(), decl, p.Name); id != nil {
+				return nil, fmt.Errorf("new parameter %s would shadow the %s referenced at %s",
+					p.Name, p.Name, safetoken.StartPosition(pkg.FileSet(), id.Pos()))
+			}
+		}
+		typ, err := parser.ParseExpr(p.Type)
+		if err != nil {
+			return nil, fmt.Errorf("invalid type %q for new parameter: %v", p.Type, err)
+		}
+		if _, ok := typ.(*ast.Ellipsis); ok {
+			return nil, fmt.Errorf("new parameter of type %s cannot be variadic", p.Type)
+		}
+		if p.Default == "" {
+			return nil, fmt.Errorf("missing default value for new parameter of type %s", p.Type)
+		}
+		if _, err := parser.ParseExpr(p.Default); err != nil {
+			return nil, fmt.Errorf("invalid default value %q for new parameter: %v", p.Default, err)
+		}
+	}
+	if !changed {
+		return nil, fmt.Errorf("the signature of %s is unchanged", decl.Name.Name)
+	}
+
+	// Format the new parameter list, keeping together adjacent parameters
+	// of the same group.
+	var groups []string
+	for i := 0; i < len(newParams); {
+		p := newParams[i]
+		if p.Type != "" {
+			if p.Name != "" {
+				groups = append(groups, p.Name+" "+p.Type)
+			} else {
+				groups = append(groups, p.Type)
+			}
+			i++
+			continue
+		}
+		field := old[p.OldIndex].field
+		typ, err := nodeText(pgf, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		if !named {
+			groups = append(groups, typ)
+			i++
+			continue
+		}
+		names := []string{old[p.OldIndex].name.Name}
+		for i++; i < len(newParams); i++ {
+			next := newParams[i]
+			if next.Type != "" || next.OldIndex != newParams[i-1].OldIndex+1 || old[next.OldIndex].field != field {
+				break
+			}
+			names = append(names, old[next.OldIndex].name.Name)
+		}
+		groups = append(groups, strings.Join(names, ", ")+" "+typ)
+	}
+
+	// Compute the wrapper's parameters, all named so that they can be
+	// delegated, and the arguments of its call to the new function.
+	params := internalastutil.CloneNode(decl.Type.Params)
+	argNames := nameParams(params, scope)
+	var args []ast.Expr
+	for _, p := range newParams {
+		if p.Type == "" {
+			args = append(args, &ast.Ident{Name: argNames[p.OldIndex]})
+		} else {
+			// The wrapper is only formatted and parsed again, so an
+			// identifier serves to hold the text of the expression.
+			args = append(args, &ast.Ident{Name: p.Default})
+		}
+	}
+
+	return changeSignature(ctx, signatureRewrite{
+		snapshot:  snapshot,
+		pkg:       pkg,
+		pgf:       pgf,
+		origDecl:  decl,
+		newParams: "(" + strings.Join(groups, ", ") + ")",
+		params:    params,
+		callArgs:  args,
+		variadic:  len(newParams) > 0 && newParams[len(newParams)-1].Type == "" && newParams[len(newParams)-1].OldIndex == variadic,
+	})
+}
+
+// SignatureParams returns the name of the function whose name or
+// parameter list contains the given range, and a description of each of
+// its parameters, such as "x int", counting each name of a parameter
+// group.
+func SignatureParams(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) (string, []string, error) {
+	_, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return "", nil, err
+	}
+	decl, err := findSignatureDecl(pgf, rng)
+	if err != nil {
+		return "", nil, err
+	}
+	var params []string
+	for _, p := range flattenParams(decl.Type.Params) {
+		params = append(params, p.String(pgf))
+	}
+	return decl.Name.Name, params, nil
+}
+
+// checkSignatureErrors returns an error if pkg has parse or type errors,
+// which prevent changing signatures.
+//
+// Changes to our heuristics for whether we can change a signature must
+// also be reflected in the canRemoveParameter and canChangeSignature
+// helpers.
+func checkSignatureErrors(pkg *cache.Package) error {
+	if perrors, terrors := pkg.ParseErrors(), pkg.TypeErrors(); len(perrors) > 0 || len(terrors) > 0 {
+		var sample string
+		if len(perrors) > 0 {
+			sample = perrors[0].Error()
+		} else {
+			sample = terrors[0].Error()
+		}
+		return fmt.Errorf("can't change signatures for packages with parse or type errors: (e.g. %s)", sample)
+	}
+	return nil
+}
+
+// findSignatureDecl finds the function declaration whose name or
+// parameter list contains the given range.
+func findSignatureDecl(pgf *parsego.File, rng protocol.Range) (*ast.FuncDecl, error) {
+	info, err := findParam(pgf, rng)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	decl := info.decl
+	if start < decl.Name.Pos() || end > decl.Type.Params.End() {
+		return nil, fmt.Errorf("range is not within the name or parameters of %s", decl.Name.Name)
+	}
+	if decl.Body == nil {
+		return nil, fmt.Errorf("cannot change the signature of %s, which has no body", decl.Name.Name)
+	}
+	return decl, nil
+}
+
+// A flatParam is a single parameter of a function declaration.
+type flatParam struct {
+	field *ast.Field
+	name  *ast.Ident // nil if unnamed
+}
+
+// String returns a description of the parameter, such as "x int".
+func (p flatParam) String(pgf *parsego.File) string {
+	typ, err := nodeText(pgf, p.field.Type)
+	if err != nil {
+		typ = types.ExprString(p.field.Type)
+	}
+	if p.name == nil {
+		return typ
+	}
+	return p.name.Name + " " + typ
+}
+
+// nodeText returns the source text of node n in the file pgf.
+func nodeText(pgf *parsego.File, n ast.Node) (string, error) {
+	start, end, err := safetoken.Offsets(pgf.Tok, n.Pos(), n.End())
+	if err != nil {
+		return "", err
+	}
+	return string(pgf.Src[start:end]), nil
+}
+
+// flattenParams returns the parameters of list, counting each name of a
+// parameter group.
+func flattenParams(list *ast.FieldList) []flatParam {
+	var params []flatParam
+	for _, field := range list.List {
+		if len(field.Names) == 0 {
+			params = append(params, flatParam{field: field})
+		}
+		for _, name := range field.Names {
+			params = append(params, flatParam{field: field, name: name})
+		}
+	}
+	return params
+}
+
+// nameParams names each blank or unnamed parameter of params, avoiding
+// the names of scope and its parents, and returns the name of each
+// parameter as counted by flattenParams.
+func nameParams(params *ast.FieldList, scope *types.Scope) []string {
+	used := make(map[string]bool)
+	for _, field := range params.List {
+		for _, name := range field.Names {
+			used[name.Name] = true
+		}
+	}
+	fresh := func(prefix string) string {
+		for i := 0; ; i++ {
+			name := fmt.Sprintf("%s%d", prefix, i)
+			if _, obj := scope.LookupParent(name, token.NoPos); obj == nil && !used[name] {
+				used[name] = true
+				return name
+			}
+		}
+	}
+	var names []string
+	for _, field := range params.List {
+		if len(field.Names) == 0 {
+			field.Names = []*ast.Ident{{Name: fresh("p")}}
+		}
+		for _, name := range field.Names {
+			if name.Name == "_" {
+				name.Name = fresh("blank")
+			}
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// shadowedRef returns a reference within the body of decl to an object
+// named name that is declared outside decl, and so would be shadowed by a
+// new parameter of that name, or nil if there is none.
+func shadowedRef(info *types.Info, decl *ast.FuncDecl, name string) *ast.Ident {
+	var found *ast.Ident
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && found == nil && id.Name == name {
+			if obj := info.Uses[id]; obj != nil && obj.Parent() != nil &&
+				!(decl.Pos() <= obj.Pos() && obj.Pos() < decl.End()) {
+				found = id
+			}
+		}
+		return found == nil
 	})
+	return found
+}
+
+// changeSignature rewrites all calls to the function as described by rw,
+// and then the declaration itself, returning the resulting document
+// changes.
+func changeSignature(ctx context.Context, rw signatureRewrite) ([]protocol.DocumentChange, error) {
+	snapshot, pgf := rw.snapshot, rw.pgf
+
+	// Rewrite all referring calls.
+	newContent, err := rewriteCalls(ctx, rw)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +459,7 @@ func RemoveUnusedParameter(ctx context.Context, fh file.Handle, rng protocol.Ran
 	// of the inlining should have changed the location of the original
 	// declaration.
 	{
-		idx := findDecl(pgf.File, info.decl)
+		idx := findDecl(pgf.File, rw.origDecl)
 		if idx < 0 {
 			return nil, bug.Errorf("didn't find original decl")
 		}
@@ -150,7 +469,7 @@ func RemoveUnusedParameter(ctx context.Context, fh file.Handle, rng protocol.Ran
 			src = pgf.Src
 		}
 		fset := tokeninternal.FileSetFor(pgf.Tok)
-		src, err := rewriteSignature(fset, idx, src, newDecl)
+		src, err := rewriteSignature(fset, idx, src, rw.origDecl.Name.Name, rw.newParams)
 		if err != nil {
 			return nil, err
 		}
@@ -180,8 +499,9 @@ func RemoveUnusedParameter(ctx context.Context, fh file.Handle, rng protocol.Ran
 	return changes, nil
 }
 
-// rewriteSignature rewrites the signature of the declIdx'th declaration in src
-// to use the signature of newDecl (described by fset).
+// rewriteSignature rewrites the parameters of the declIdx'th declaration in
+// src, a function named name, to newParams (the source of a parameter list,
+// including parentheses).
 //
 // TODO(rfindley): I think this operation could be generalized, for example by
 // using a concept of a 'nodepath' to correlate nodes between two related
@@ -190,7 +510,7 @@ func RemoveUnusedParameter(ctx context.Context, fh file.Handle, rng protocol.Ran
 // Note that with its current application, rewriteSignature is expected to
 // succeed. Separate bug.Errorf calls are used below (rather than one call at
 // the callsite) in order to have greater precision.
-func rewriteSignature(fset *token.FileSet, declIdx int, src0 []byte, newDecl *ast.FuncDecl) ([]byte, error) {
+func rewriteSignature(fset *token.FileSet, declIdx int, src0 []byte, name, newParams string) ([]byte, error) {
 	// Parse the new file0 content, to locate the original params.
 	file0, err := parser.ParseFile(fset, "", src0, parser.ParseComments|parser.SkipObjectResolution)
 	if err != nil {
@@ -199,29 +519,15 @@ func rewriteSignature(fset *token.FileSet, declIdx int, src0 []byte, newDecl *as
 	decl0, _ := file0.Decls[declIdx].(*ast.FuncDecl)
 	// Inlining shouldn't have changed the location of any declarations, but do
 	// a sanity check.
-	if decl0 == nil || decl0.Name.Name != newDecl.Name.Name {
-		return nil, bug.Errorf("inlining affected declaration order: found %v, not func %s", decl0, newDecl.Name.Name)
+	if decl0 == nil || decl0.Name.Name != name {
+		return nil, bug.Errorf("inlining affected declaration order: found %v, not func %s", decl0, name)
 	}
 	opening0, closing0, err := safetoken.Offsets(fset.File(decl0.Pos()), decl0.Type.Params.Opening, decl0.Type.Params.Closing)
 	if err != nil {
 		return nil, bug.Errorf("can't find params: %v", err)
 	}
 
-	// Format the modified signature and apply a textual replacement. This
-	// minimizes comment disruption.
-	formattedType := FormatNode(fset, newDecl.Type)
-	expr, err := parser.ParseExprFrom(fset, "", []byte(formattedType), 0)
-	if err != nil {
-		return nil, bug.Errorf("parsing modified signature: %v", err)
-	}
-	newType := expr.(*ast.FuncType)
-	opening1, closing1, err := safetoken.Offsets(fset.File(newType.Pos()), newType.Params.Opening, newType.Params.Closing)
-	if err != nil {
-		return nil, bug.Errorf("param offsets: %v", err)
-	}
-	newParams := formattedType[opening1 : closing1+1]
-
-	// Splice.
+	// Apply a textual replacement. This minimizes comment disruption.
 	var buf bytes.Buffer
 	buf.Write(src0[:opening0])
 	buf.WriteString(newParams)
@@ -237,6 +543,22 @@ func rewriteSignature(fset *token.FileSet, declIdx int, src0 []byte, newDecl *as
 	return newSrc, nil
 }
 
+// formatParams returns the formatted parameter list of ftype, including
+// parentheses.
+func formatParams(fset *token.FileSet, ftype *ast.FuncType) (string, error) {
+	formattedType := FormatNode(fset, ftype)
+	expr, err := parser.ParseExprFrom(fset, "", []byte(formattedType), 0)
+	if err != nil {
+		return "", bug.Errorf("parsing modified signature: %v", err)
+	}
+	newType := expr.(*ast.FuncType)
+	opening, closing, err := safetoken.Offsets(fset.File(newType.Pos()), newType.Params.Opening, newType.Params.Closing)
+	if err != nil {
+		return "", bug.Errorf("param offsets: %v", err)
+	}
+	return formattedType[opening : closing+1], nil
+}
+
 // paramInfo records information about a param identified by a position.
 type paramInfo struct {
 	decl       *ast.FuncDecl // enclosing func decl (non-nil)
@@ -305,17 +627,18 @@ func findParam(pgf *parsego.File, rng protocol.Range) (*paramInfo, error) {
 //
 // See rewriteCalls for more details.
 type signatureRewrite struct {
-	snapshot          *cache.Snapshot
-	pkg               *cache.Package
-	pgf               *parsego.File
-	origDecl, newDecl *ast.FuncDecl
-	params            *ast.FieldList
-	callArgs          []ast.Expr
-	variadic          bool
+	snapshot  *cache.Snapshot
+	pkg       *cache.Package
+	pgf       *parsego.File
+	origDecl  *ast.FuncDecl
+	newParams string // source of the new parameter list, including parens
+	params    *ast.FieldList
+	callArgs  []ast.Expr
+	variadic  bool
 }
 
 // rewriteCalls returns the document changes required to rewrite the
-// signature of origDecl to that of newDecl.
+// parameters of origDecl to newParams.
 //
 // This is a rather complicated factoring of the rewrite operation, but is able
 // to describe arbitrary rewrites. Specifically, rewriteCalls creates a
@@ -340,8 +663,8 @@ type signatureRewrite struct {
 //
 // In this case, rewriteCalls is parameterized as follows:
 //   - origDecl is the original declaration
-//   - newDecl is the new declaration, which is a copy of origDecl less the 'b'
-//     parameter.
+//   - newParams is the new parameter list, which is a copy of that of
+//     origDecl less the 'b' parameter.
 //   - params is a new parameter list (a, b, c, blank0 int) to be used for the
 //     new wrapper.
 //   - callArgs is the argument list (a, c, blank0), to be used to call the new
@@ -350,10 +673,6 @@ type signatureRewrite struct {
 // rewriting is expressed this way so that rewriteCalls can own the details
 // of *how* this rewriting is performed. For example, as of writing it names
 // the synthetic delegate G_o_p_l_s_foo, but the caller need not know this.
-//
-// By passing an entirely new declaration, rewriteCalls may be used for
-// signature refactorings that may affect the function body, such as removing
-// or adding return values.
 func rewriteCalls(ctx context.Context, rw signatureRewrite) (map[protocol.DocumentURI][]byte, error) {
 	// tag is a unique prefix that is added to the delegated declaration.
 	//
@@ -366,10 +685,9 @@ func rewriteCalls(ctx context.Context, rw signatureRewrite) (map[protocol.Docume
 		modifiedDecl *ast.FuncDecl
 	)
 	{
-		delegate := internalastutil.CloneNode(rw.newDecl) // clone before modifying
-		delegate.Name.Name = tag + delegate.Name.Name
-		if obj := rw.pkg.Types().Scope().Lookup(delegate.Name.Name); obj != nil {
-			return nil, fmt.Errorf("synthetic name %q conflicts with an existing declaration", delegate.Name.Name)
+		delegateName := tag + rw.origDecl.Name.Name
+		if obj := rw.pkg.Types().Scope().Lookup(delegateName); obj != nil {
+			return nil, fmt.Errorf("synthetic name %q conflicts with an existing declaration", delegateName)
 		}
 
 		wrapper := internalastutil.CloneNode(rw.origDecl)
@@ -408,7 +726,7 @@ func rewriteCalls(ctx context.Context, rw signatureRewrite) (map[protocol.Docume
 			}
 		}
 
-		name := &ast.Ident{Name: delegate.Name.Name}
+		name := &ast.Ident{Name: delegateName}
 		var fun ast.Expr = name
 		if recv != "" {
 			fun = &ast.SelectorExpr{
@@ -425,7 +743,7 @@ func rewriteCalls(ctx context.Context, rw signatureRewrite) (map[protocol.Docume
 		}
 
 		var stmt ast.Stmt
-		if delegate.Type.Results.NumFields() > 0 {
+		if rw.origDecl.Type.Results.NumFields() > 0 {
 			stmt = &ast.ReturnStmt{
 				Results: []ast.Expr{call},
 			}
@@ -440,13 +758,10 @@ func rewriteCalls(ctx context.Context, rw signatureRewrite) (map[protocol.Docume
 
 		fset := tokeninternal.FileSetFor(rw.pgf.Tok)
 		var err error
-		modifiedSrc, err = replaceFileDecl(rw.pgf, rw.origDecl, delegate)
+		modifiedSrc, err = replaceFuncSignature(rw.pgf, rw.origDecl, delegateName, rw.newParams)
 		if err != nil {
 			return nil, err
 		}
-		// TODO(rfindley): we can probably get away with one fewer parse operations
-		// by returning the modified AST from replaceDecl. Investigate if that is
-		// accurate.
 		modifiedSrc = append(modifiedSrc, []byte("\n\n"+FormatNode(fset, wrapper))...)
 		modifiedFile, err = parser.ParseFile(rw.pkg.FileSet(), rw.pgf.URI.Path(), modifiedSrc, parser.ParseComments|parser.SkipObjectResolution)
 		if err != nil {
@@ -582,25 +897,24 @@ func remove[T any](s []T, i int) []T {
 	return append(s[:i], s[i+1:]...)
 }
 
-// replaceFileDecl replaces old with new in the file described by pgf.
-//
-// TODO(rfindley): generalize, and combine with rewriteSignature.
-func replaceFileDecl(pgf *parsego.File, old, new ast.Decl) ([]byte, error) {
-	i := findDecl(pgf.File, old)
-	if i == -1 {
-		return nil, bug.Errorf("didn't find old declaration")
+// replaceFuncSignature returns the content of the file described by pgf,
+// with the name and parameters of the declaration decl replaced by name and
+// params (the source of a parameter list, including parentheses).
+func replaceFuncSignature(pgf *parsego.File, decl *ast.FuncDecl, name, params string) ([]byte, error) {
+	nameStart, nameEnd, err := safetoken.Offsets(pgf.Tok, decl.Name.Pos(), decl.Name.End())
+	if err != nil {
+		return nil, err
 	}
-	start, end, err := safetoken.Offsets(pgf.Tok, old.Pos(), old.End())
+	opening, closing, err := safetoken.Offsets(pgf.Tok, decl.Type.Params.Opening, decl.Type.Params.Closing)
 	if err != nil {
 		return nil, err
 	}
 	var out bytes.Buffer
-	out.Write(pgf.Src[:start])
-	fset := tokeninternal.FileSetFor(pgf.Tok)
-	if err := format.Node(&out, fset, new); err != nil {
-		return nil, bug.Errorf("formatting new node: %v", err)
-	}
-	out.Write(pgf.Src[end:])
+	out.Write(pgf.Src[:nameStart])
+	out.WriteString(name)
+	out.Write(pgf.Src[nameEnd:opening])
+	out.WriteString(params)
+	out.Write(pgf.Src[closing+1:])
 	return out.Bytes(), nil
 }
 
@@ -0,0 +1,196 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the detection of duplicated functions in the
+// workspace (see the clones package).
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/clones"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/filecache"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/frob"
+	"golang.org/x/tools/internal/event"
+)
+
+// A Clone is a function that belongs to a cluster of duplicated
+// functions.
+type Clone struct {
+	Name     string            // "F", or "T.M" for a method
+	Location protocol.Location // location of the function name
+}
+
+// A CloneCluster is a set of two or more duplicated functions.
+type CloneCluster struct {
+	Tokens int     // number of tokens in the signature and body of each function
+	Clones []Clone // in order of location
+}
+
+// FindClones returns the clusters of duplicated functions declared in
+// the non-generated files of the workspace packages, largest functions
+// first.
+//
+// The fingerprints of the functions of each file are cached, so only
+// the files that have changed since the previous call are parsed.
+func FindClones(ctx context.Context, snapshot *cache.Snapshot) ([]*CloneCluster, error) {
+	mps, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uris := make(map[protocol.DocumentURI]bool)
+	for _, mp := range mps {
+		if metadata.IsCommandLineArguments(mp.ID) {
+			continue
+		}
+		for _, uri := range mp.CompiledGoFiles {
+			uris[uri] = true
+		}
+	}
+
+	type member struct {
+		fh file.Handle
+		fn clones.Func
+	}
+	byFingerprint := make(map[[sha256.Size]byte][]member)
+	for uri := range uris {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		fh, err := snapshot.ReadFile(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		funcs, err := fileFingerprints(ctx, snapshot, fh)
+		if err != nil {
+			return nil, err
+		}
+		for _, fn := range funcs {
+			byFingerprint[fn.Fingerprint] = append(byFingerprint[fn.Fingerprint], member{fh, fn})
+		}
+	}
+
+	mappers := make(map[protocol.DocumentURI]*protocol.Mapper)
+	var clusters []*CloneCluster
+	for _, members := range byFingerprint {
+		if len(members) < 2 {
+			continue
+		}
+		cluster := &CloneCluster{Tokens: members[0].fn.Tokens}
+		for _, m := range members {
+			uri := m.fh.URI()
+			mapper, ok := mappers[uri]
+			if !ok {
+				content, err := m.fh.Content()
+				if err != nil {
+					return nil, err
+				}
+				mapper = protocol.NewMapper(uri, content)
+				mappers[uri] = mapper
+			}
+			loc, err := mapper.OffsetLocation(m.fn.NameStart, m.fn.NameEnd)
+			if err != nil {
+				return nil, err
+			}
+			cluster.Clones = append(cluster.Clones, Clone{m.fn.Name, loc})
+		}
+		sort.Slice(cluster.Clones, func(i, j int) bool {
+			return protocol.CompareLocation(cluster.Clones[i].Location, cluster.Clones[j].Location) < 0
+		})
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		x, y := clusters[i], clusters[j]
+		if x.Tokens != y.Tokens {
+			return x.Tokens > y.Tokens
+		}
+		return protocol.CompareLocation(x.Clones[0].Location, y.Clones[0].Location) < 0
+	})
+	return clusters, nil
+}
+
+var fingerprintsCodec = frob.CodecFor[[]clones.Func]()
+
+// fileFingerprints returns the fingerprints of the functions of a Go
+// file, or none if the file is generated, using a cache keyed by the
+// content of the file.
+func fileFingerprints(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]clones.Func, error) {
+	const cacheKind = "clones"
+	key := fh.Identity().Hash
+	if data, err := filecache.Get(cacheKind, key); err == nil {
+		var funcs []clones.Func
+		fingerprintsCodec.Decode(data, &funcs)
+		return funcs, nil
+	} else if err != filecache.ErrNotFound {
+		event.Error(ctx, "internal error reading clones cache", err)
+	}
+
+	var funcs []clones.Func
+	if !IsGenerated(ctx, snapshot, fh.URI()) {
+		pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+		if err != nil {
+			return nil, err
+		}
+		funcs = clones.Fingerprints(pgf.Tok, pgf.File, pgf.Src)
+	}
+	if err := filecache.Set(cacheKind, key, fingerprintsCodec.Encode(funcs)); err != nil {
+		event.Error(ctx, "internal error updating clones cache", err)
+	}
+	return funcs, nil
+}
+
+// CloneDiagnostics returns a hint for each duplicated function in the
+// workspace, if the clones option is enabled. The related information
+// of each hint holds the locations of the function's duplicates.
+func CloneDiagnostics(ctx context.Context, snapshot *cache.Snapshot) (map[protocol.DocumentURI][]*cache.Diagnostic, error) {
+	if !snapshot.Options().Clones {
+		return nil, nil
+	}
+	clusters, err := FindClones(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	reports := make(map[protocol.DocumentURI][]*cache.Diagnostic)
+	for _, cluster := range clusters {
+		for i, clone := range cluster.Clones {
+			var (
+				others  []string
+				related []protocol.DiagnosticRelatedInformation
+			)
+			for j, other := range cluster.Clones {
+				if j != i {
+					others = append(others, other.Name)
+					related = append(related, protocol.DiagnosticRelatedInformation{
+						Location: other.Location,
+						Message:  "duplicate " + other.Name,
+					})
+				}
+			}
+			msg := fmt.Sprintf("%s duplicates %s", clone.Name, others[0])
+			if len(others) > 1 {
+				msg = fmt.Sprintf("%s duplicates %d functions: %s", clone.Name, len(others), strings.Join(others, ", "))
+			}
+			uri := clone.Location.URI
+			reports[uri] = append(reports[uri], &cache.Diagnostic{
+				URI:      uri,
+				Range:    clone.Location.Range,
+				Severity: protocol.SeverityHint,
+				Source:   cache.Clones,
+				Message:  msg,
+				Related:  related,
+			})
+		}
+	}
+	return reports, nil
+}
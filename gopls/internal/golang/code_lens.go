@@ -24,9 +24,11 @@ import (
 func CodeLensSources() map[settings.CodeLensSource]cache.CodeLensSourceFunc {
 	return map[settings.CodeLensSource]cache.CodeLensSourceFunc{
 		settings.CodeLensGenerate:      goGenerateCodeLens,    // commands: Generate
+		settings.CodeLensRun:           runMainCodeLens,       // commands: RunMain
 		settings.CodeLensTest:          runTestCodeLens,       // commands: Test
 		settings.CodeLensRegenerateCgo: regenerateCgoLens,     // commands: RegenerateCgo
 		settings.CodeLensGCDetails:     toggleDetailsCodeLens, // commands: GCDetails
+		settings.CodeLensImporters:     importersCodeLens,     // commands: Importers
 	}
 }
 
@@ -9,13 +9,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/gopls/internal/analysis/fillstruct"
 	"golang.org/x/tools/gopls/internal/analysis/fillswitch"
 	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
 	"golang.org/x/tools/gopls/internal/cache/parsego"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/label"
@@ -48,6 +53,7 @@ func CodeActions(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 	if wantQuickFixes ||
 		want[protocol.SourceOrganizeImports] ||
 		want[protocol.RefactorExtract] ||
+		want[protocol.RefactorRewrite] ||
 		want[protocol.GoFreeSymbols] {
 
 		pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
@@ -84,7 +90,7 @@ func CodeActions(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 
 			// Send all of the import edits as one code action if the file is
 			// being organized.
-			if want[protocol.SourceOrganizeImports] && len(importEdits) > 0 {
+			if want[protocol.SourceOrganizeImports] && len(importEdits) > 0 && !FormatExcluded(snapshot, pgf) {
 				actions = append(actions, protocol.CodeAction{
 					Title: "Organize Imports",
 					Kind:  protocol.SourceOrganizeImports,
@@ -102,6 +108,14 @@ func CodeActions(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 			actions = append(actions, extractions...)
 		}
 
+		if want[protocol.RefactorRewrite] {
+			rewrites, err := getStructTagCodeActions(fh, pgf, rng, snapshot.Options(), !FormatExcluded(snapshot, pgf))
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, rewrites...)
+		}
+
 		if want[protocol.GoFreeSymbols] && rng.End != rng.Start {
 			loc := protocol.Location{URI: pgf.URI, Range: rng}
 			cmd, err := command.NewFreeSymbolsCommand("Browse free symbols", snapshot.View().ID(), loc)
@@ -119,7 +133,9 @@ func CodeActions(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 
 	// Code actions requiring type information.
 	if want[protocol.RefactorRewrite] ||
+		want[protocol.RefactorExtract] ||
 		want[protocol.RefactorInline] ||
+		want[protocol.RefactorMove] ||
 		want[protocol.GoAssembly] ||
 		want[protocol.GoDoc] ||
 		want[protocol.GoTest] {
@@ -150,6 +166,67 @@ func CodeActions(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 			actions = append(actions, rewrites...)
 		}
 
+		// Like "inline", "move" is offered only on request.
+		if want[protocol.RefactorMove] && (trigger != protocol.CodeActionAutomatic || rng.Start != rng.End) {
+			moves, err := getMoveCodeActions(pkg, pgf, rng, snapshot.Options())
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, moves...)
+		}
+
+		// Like "move", "extract interface" is offered only on request.
+		if want[protocol.RefactorExtract] && (trigger != protocol.CodeActionAutomatic || rng.Start != rng.End) {
+			extractions, err := getExtractInterfaceCodeActions(pkg, pgf, rng)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, extractions...)
+		}
+
+		if want[protocol.RefactorExtract] && rng.Start != rng.End {
+			extractions, err := getExtractConstantCodeActions(pkg, pgf, rng, snapshot.Options())
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, extractions...)
+		}
+
+		// Like "move", "generate constructor", "generate methods",
+		// "generate test", "safe delete", and "convert to method" are
+		// offered only on request.
+		if want[protocol.RefactorRewrite] && (trigger != protocol.CodeActionAutomatic || rng.Start != rng.End) {
+			constructors, err := getConstructorCodeActions(pkg, pgf, rng)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, constructors...)
+
+			methods, err := getGenerateMethodsCodeActions(pkg, pgf, rng, snapshot.Options())
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, methods...)
+
+			tests, err := getAddTestCodeActions(pkg, pgf, rng, snapshot.Options())
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, tests...)
+
+			deletions, err := getSafeDeleteCodeActions(pkg, pgf, rng, snapshot.Options())
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, deletions...)
+
+			conversions, err := getConvertMethodCodeActions(pkg, pgf, rng, snapshot.Options())
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, conversions...)
+		}
+
 		if want[protocol.GoTest] {
 			fixes, err := getGoTestCodeActions(pkg, pgf, rng)
 			if err != nil {
@@ -181,9 +258,138 @@ func CodeActions(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle,
 			actions = append(actions, fixes...)
 		}
 	}
+
+	if want[protocol.GoDeepAnalysis] {
+		cmd, err := command.NewDeepAnalysisCommand("Run deep analysis on this package", command.URIArg{URI: fh.URI()})
+		if err != nil {
+			return nil, err
+		}
+		// For handler, see commandHandler.DeepAnalysis.
+		actions = append(actions, protocol.CodeAction{
+			Title:   cmd.Title,
+			Kind:    protocol.GoDeepAnalysis,
+			Command: &cmd,
+		})
+	}
+
+	if want[protocol.GoDeadCode] {
+		cmd, err := command.NewFindDeadCodeCommand("Find dead code in the workspace", command.URIArg{URI: fh.URI()})
+		if err != nil {
+			return nil, err
+		}
+		// For handler, see commandHandler.FindDeadCode.
+		actions = append(actions, protocol.CodeAction{
+			Title:   cmd.Title,
+			Kind:    protocol.GoDeadCode,
+			Command: &cmd,
+		})
+	}
+
+	if want[protocol.GoClones] {
+		for _, diag := range diagnostics {
+			if diag.Source != cache.Clones.Path() {
+				continue
+			}
+			loc := protocol.Location{URI: fh.URI(), Range: diag.Range}
+			cmd, err := command.NewShowNextCloneCommand("Jump to next duplicate", loc)
+			if err != nil {
+				return nil, err
+			}
+			// For handler, see commandHandler.ShowNextClone.
+			actions = append(actions, protocol.CodeAction{
+				Title:       cmd.Title,
+				Kind:        protocol.GoClones,
+				Command:     &cmd,
+				Diagnostics: []protocol.Diagnostic{diag},
+			})
+		}
+	}
+
+	if want[protocol.GoAnalyzerDoc] {
+		seen := make(map[string]bool)
+		for _, diag := range diagnostics {
+			if seen[diag.Source] {
+				continue
+			}
+			seen[diag.Source] = true
+			name, ok := cache.AnalyzerName(diag.Source)
+			if !ok {
+				continue // not an analyzer diagnostic
+			}
+			if _, err := lookupAnalyzer(snapshot, name); err != nil {
+				continue // not a known analyzer
+			}
+			cmd, err := command.NewAnalyzerDocCommand("Show documentation of analyzer "+name, command.AnalyzerDocArgs{
+				URI:      fh.URI(),
+				Analyzer: name,
+			})
+			if err != nil {
+				return nil, err
+			}
+			// For handler, see commandHandler.AnalyzerDoc.
+			actions = append(actions, protocol.CodeAction{
+				Title:       cmd.Title,
+				Kind:        protocol.GoAnalyzerDoc,
+				Command:     &cmd,
+				Diagnostics: []protocol.Diagnostic{diag},
+			})
+		}
+	}
+
+	if want[protocol.GoRegenerate] {
+		regenerate, err := getRegenerateCodeActions(ctx, snapshot, fh)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, regenerate...)
+	}
 	return actions, nil
 }
 
+// getRegenerateCodeActions returns a "Regenerate" code action for a
+// generated file whose package has a //go:generate directive, which
+// runs go generate in the package directory.
+func getRegenerateCodeActions(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]protocol.CodeAction, error) {
+	if !IsGenerated(ctx, snapshot, fh.URI()) {
+		return nil, nil
+	}
+	mp, err := NarrowestMetadataForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	for _, uri := range mp.CompiledGoFiles {
+		if uri == fh.URI() {
+			continue
+		}
+		fh, err := snapshot.ReadFile(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+		if err != nil {
+			return nil, err
+		}
+		for _, cg := range pgf.File.Comments {
+			for _, c := range cg.List {
+				if !strings.HasPrefix(c.Text, "//go:generate ") {
+					continue
+				}
+				cmd, err := command.NewGenerateCommand("Regenerate (go generate)", command.GenerateArgs{Dir: uri.Dir()})
+				if err != nil {
+					return nil, err
+				}
+				// For handler, see commandHandler.Generate.
+				return []protocol.CodeAction{{
+					Title:   cmd.Title,
+					Kind:    protocol.GoRegenerate,
+					Command: &cmd,
+				}}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
 func supportsResolveEdits(options *settings.Options) bool {
 	return options.CodeActionResolveOptions != nil && slices.Contains(options.CodeActionResolveOptions, "edit")
 }
@@ -286,6 +492,18 @@ func getExtractCodeActions(pgf *parsego.File, rng protocol.Range, options *setti
 		}
 		commands = append(commands, cmd)
 	}
+	if _, ok := canExtractConstraint(pgf.File, start, end); ok {
+		cmd, err := command.NewApplyFixCommand("Extract constraint to interface", command.ApplyFixArgs{
+			Fix:          fixExtractConstraint,
+			URI:          puri,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
 	var actions []protocol.CodeAction
 	for i := range commands {
 		actions = append(actions, newCodeAction(commands[i].Title, protocol.RefactorExtract, &commands[i], nil, options))
@@ -340,6 +558,25 @@ func getRewriteCodeActions(ctx context.Context, pkg *cache.Package, snapshot *ca
 		actions = append(actions, newCodeAction("Refactor: remove unused parameter", protocol.RefactorRewrite, &cmd, nil, options))
 	}
 
+	if canChangeSignature(pkg, pgf, rng) {
+		// The new parameters are chosen when the command is executed,
+		// so the edits cannot be resolved in advance.
+		cmd, err := command.NewChangeSignatureCommand("change signature", command.ChangeSignatureArgs{
+			Function: protocol.Location{
+				URI:   pgf.URI,
+				Range: rng,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, protocol.CodeAction{
+			Title:   "Refactor: change signature...",
+			Kind:    protocol.RefactorRewrite,
+			Command: &cmd,
+		})
+	}
+
 	start, end, err := pgf.RangePos(rng)
 	if err != nil {
 		return nil, err
@@ -349,6 +586,14 @@ func getRewriteCodeActions(ctx context.Context, pkg *cache.Package, snapshot *ca
 		actions = append(actions, action)
 	}
 
+	actions = append(actions, numberLiteralCodeActions(pkg, pgf, fh, start, end)...)
+
+	dotImportActions, err := getDotImportCodeActions(ctx, snapshot, pkg, pgf, start, end, options)
+	if err != nil {
+		return nil, err
+	}
+	actions = append(actions, dotImportActions...)
+
 	var commands []protocol.Command
 	if _, ok, _ := canInvertIfCondition(pgf.File, start, end); ok {
 		cmd, err := command.NewApplyFixCommand("Invert 'if' condition", command.ApplyFixArgs{
@@ -363,6 +608,98 @@ func getRewriteCodeActions(ctx context.Context, pkg *cache.Package, snapshot *ca
 		commands = append(commands, cmd)
 	}
 
+	if _, ok := canConvertIfToSwitch(pgf.File, pkg.TypesInfo(), start, end); ok {
+		cmd, err := command.NewApplyFixCommand("Convert if/else chain to switch", command.ApplyFixArgs{
+			Fix:          fixIfToSwitch,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	if _, ok := canConvertSwitchToIf(pgf.File, pkg.TypesInfo(), start, end); ok {
+		cmd, err := command.NewApplyFixCommand("Convert switch to if/else chain", command.ApplyFixArgs{
+			Fix:          fixSwitchToIf,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	if _, ok := canConvertToRange(pgf.File, pkg.TypesInfo(), start, end); ok {
+		cmd, err := command.NewApplyFixCommand("Convert to range loop", command.ApplyFixArgs{
+			Fix:          fixIndexToRange,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	if _, ok := canConvertToIndex(pgf.File, pkg.TypesInfo(), start, end); ok {
+		cmd, err := command.NewApplyFixCommand("Convert to loop over indices", command.ApplyFixArgs{
+			Fix:          fixRangeToIndex,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	if _, _, ok := canConvertConcatToSprintf(pgf.File, pkg.TypesInfo(), start, end); ok {
+		cmd, err := command.NewApplyFixCommand("Convert string concatenation to fmt.Sprintf", command.ApplyFixArgs{
+			Fix:          fixConcatToSprintf,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	if _, _, ok := canConvertSprintfToConcat(pgf.File, pkg.TypesInfo(), start, end); ok {
+		cmd, err := command.NewApplyFixCommand("Convert fmt.Sprintf to string concatenation (avoids formatting at run time)", command.ApplyFixArgs{
+			Fix:          fixSprintfToConcat,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	if loop, ok := canUseStringBuilder(pgf.File, pkg.TypesInfo(), start, end); ok {
+		title := fmt.Sprintf("Build %s with strings.Builder (avoids copying the string on each iteration)", loop.v.Name())
+		cmd, err := command.NewApplyFixCommand(title, command.ApplyFixArgs{
+			Fix:          fixStringBuilder,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
 	if msg, ok, _ := canSplitLines(pgf.File, pkg.FileSet(), start, end); ok {
 		cmd, err := command.NewApplyFixCommand(msg, command.ApplyFixArgs{
 			Fix:          fixSplitLines,
@@ -389,10 +726,48 @@ func getRewriteCodeActions(ctx context.Context, pkg *cache.Package, snapshot *ca
 		commands = append(commands, cmd)
 	}
 
+	if _, _, ok := canPromoteFuncLit(pgf.File, start, end); ok {
+		cmd, err := command.NewApplyFixCommand("Promote function literal to named function", command.ApplyFixArgs{
+			Fix:          fixPromoteFuncLit,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	if id, _, err := funcToInlineAsLiteral(pgf.File, pkg.Types(), pkg.TypesInfo(), start, end); err == nil {
+		cmd, err := command.NewApplyFixCommand(fmt.Sprintf("Inline function %s as literal", id.Name), command.ApplyFixArgs{
+			Fix:          fixInlineFuncAsLit,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	errorCommands, err := getErrorCodeActions(pkg, pgf, rng, start, end, options)
+	if err != nil {
+		return nil, err
+	}
+	commands = append(commands, errorCommands...)
+
 	// fillstruct.Diagnose is a lazy analyzer: all it gives us is
 	// the (start, end, message) of each SuggestedFix; the actual
 	// edit is computed only later by ApplyFix, which calls fillstruct.SuggestedFix.
+	// Variants of the fix that fill the literal differently follow it.
+	var fillDiags []analysis.Diagnostic
 	for _, diag := range fillstruct.Diagnose(pgf.File, start, end, pkg.Types(), pkg.TypesInfo()) {
+		fillDiags = append(fillDiags, diag)
+		fillDiags = append(fillDiags, fillstruct.Variants(pgf.File, diag, pkg.Types(), pkg.TypesInfo())...)
+	}
+	for _, diag := range fillDiags {
 		rng, err := pgf.Mapper.PosRange(pgf.Tok, diag.Pos, diag.End)
 		if err != nil {
 			return nil, err
@@ -411,8 +786,15 @@ func getRewriteCodeActions(ctx context.Context, pkg *cache.Package, snapshot *ca
 		}
 	}
 
-	for _, diag := range fillswitch.Diagnose(pgf.File, start, end, pkg.Types(), pkg.TypesInfo()) {
-		changes, err := suggestedFixToDocumentChange(ctx, snapshot, pkg.FileSet(), &diag.SuggestedFixes[0])
+	// Fill in switch cases, looking for implementations of
+	// interfaces among the workspace dependencies.
+	impls := workspaceDeps(ctx, snapshot, pkg)
+	for _, diag := range fillswitch.Diagnose(pgf.File, start, end, pkg.Types(), pkg.TypesInfo(), impls, nil) {
+		fix, err := fillSwitchFix(snapshot, pkg, pgf, diag.Pos, impls)
+		if err != nil {
+			return nil, err
+		}
+		changes, err := suggestedFixToDocumentChange(ctx, snapshot, pkg.FileSet(), fix)
 		if err != nil {
 			return nil, err
 		}
@@ -429,6 +811,82 @@ func getRewriteCodeActions(ctx context.Context, pkg *cache.Package, snapshot *ca
 	return actions, nil
 }
 
+// fillSwitchFix returns the fix that fills in the cases of the switch
+// statement at pos, including edits to add the imports it needs.
+func fillSwitchFix(snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, pos token.Pos, impls []*types.Package) (*analysis.SuggestedFix, error) {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, pos, pos)
+	if len(path) == 0 {
+		return nil, bug.Errorf("no switch statement at %d", pos)
+	}
+	adder := newImportAdder(snapshot, pkg, pgf)
+	fix := fillswitch.SuggestedFix(path[0], pkg.Types(), pkg.TypesInfo(), impls, adder.qualifier)
+	if fix == nil {
+		return nil, bug.Errorf("no fix for %T at %d", path[0], pos)
+	}
+	edits, err := adder.edits(snapshot, pgf)
+	if err != nil {
+		return nil, err
+	}
+	fix.TextEdits = append(fix.TextEdits, edits...)
+	return fix, nil
+}
+
+// workspaceDeps returns the types of the workspace packages on which
+// pkg transitively depends, in order of package path, excluding any
+// whose type information is not reachable from pkg.
+func workspaceDeps(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package) []*types.Package {
+	want := make(map[PackagePath]bool)
+	var visitMeta func(mp *metadata.Package)
+	visitMeta = func(mp *metadata.Package) {
+		for _, id := range mp.DepsByPkgPath {
+			dep := snapshot.Metadata(id)
+			if dep != nil && !want[dep.PkgPath] && snapshot.IsWorkspacePackage(ctx, id) {
+				want[dep.PkgPath] = true
+				visitMeta(dep)
+			}
+		}
+	}
+	visitMeta(pkg.Metadata())
+
+	var deps []*types.Package
+	seen := make(map[*types.Package]bool)
+	var visitTypes func(p *types.Package)
+	visitTypes = func(p *types.Package) {
+		for _, imp := range p.Imports() {
+			if !seen[imp] && want[PackagePath(imp.Path())] {
+				seen[imp] = true
+				deps = append(deps, imp)
+				visitTypes(imp)
+			}
+		}
+	}
+	visitTypes(pkg.Types())
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Path() < deps[j].Path() })
+	return deps
+}
+
+// canChangeSignature reports whether we can change the parameters of the
+// function whose name contains the given range.
+//
+// This is true if there are no parse or type errors, and the function
+// has a body and at least one parameter.
+func canChangeSignature(pkg *cache.Package, pgf *parsego.File, rng protocol.Range) bool {
+	if checkSignatureErrors(pkg) != nil {
+		return false
+	}
+	decl, err := findSignatureDecl(pgf, rng)
+	if err != nil {
+		return false
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return false
+	}
+	// Offering the action on the parameters too would make it compete
+	// with the removal of an unused parameter.
+	return decl.Name.Pos() <= start && end <= decl.Name.End() && decl.Type.Params.NumFields() > 0
+}
+
 // canRemoveParameter reports whether we can remove the function parameter
 // indicated by the given [start, end) range.
 //
@@ -508,6 +966,178 @@ func getInlineCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Ra
 	return actions, nil
 }
 
+// getExtractInterfaceCodeActions returns the refactor.extract action
+// to extract an interface from the type named at the specified range.
+func getExtractInterfaceCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Range) ([]protocol.CodeAction, error) {
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	_, spec := typeSpecAt(pgf, start, end)
+	if spec == nil {
+		return nil, nil
+	}
+	tname, _, err := extractableType(pkg, spec)
+	if err != nil {
+		return nil, nil // not a type with methods
+	}
+	// The methods are chosen when the command is executed,
+	// so the edits cannot be resolved in advance.
+	cmd, err := command.NewExtractInterfaceCommand(fmt.Sprintf("Extract interface from %s...", tname.Name()), command.ExtractInterfaceArgs{
+		Location: protocol.Location{URI: pgf.URI, Range: rng},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeAction{{
+		Title:   cmd.Title,
+		Kind:    protocol.RefactorExtract,
+		Command: &cmd,
+	}}, nil
+}
+
+// getConstructorCodeActions returns the refactor.rewrite actions to
+// generate a constructor, with or without functional options, for the
+// struct type named at the specified range.
+func getConstructorCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Range) ([]protocol.CodeAction, error) {
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	_, spec := typeSpecAt(pgf, start, end)
+	if spec == nil {
+		return nil, nil
+	}
+	tname, st, err := constructibleType(pkg, spec)
+	if err != nil {
+		return nil, nil // not a struct type
+	}
+	if pkg.Types().Scope().Lookup(constructorName(tname)) != nil {
+		return nil, nil // already has a constructor
+	}
+	// The fields are chosen when the command is executed,
+	// so the edits cannot be resolved in advance.
+	var actions []protocol.CodeAction
+	for _, options := range []bool{false, true} {
+		title := fmt.Sprintf("Generate constructor for %s...", tname.Name())
+		if options {
+			if st.Fields.NumFields() == 0 {
+				break
+			}
+			title = fmt.Sprintf("Generate constructor with options for %s...", tname.Name())
+		}
+		cmd, err := command.NewGenerateConstructorCommand(title, command.GenerateConstructorArgs{
+			Location: protocol.Location{URI: pgf.URI, Range: rng},
+			Options:  options,
+		})
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, protocol.CodeAction{
+			Title:   cmd.Title,
+			Kind:    protocol.RefactorRewrite,
+			Command: &cmd,
+		})
+	}
+	return actions, nil
+}
+
+// getMoveCodeActions returns refactor.move actions available at the specified range.
+func getMoveCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Range, options *settings.Options) ([]protocol.CodeAction, error) {
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	d, err := movableDecl(pkg, pgf, start, end)
+	if err != nil {
+		return nil, nil // no declaration is named at the selection
+	}
+	name := declName(d)
+	loc := protocol.Location{URI: pgf.URI, Range: rng}
+
+	var actions []protocol.CodeAction
+	base := strings.ToLower(name) + ".go"
+	if isTestFile(pgf.URI) {
+		base = strings.ToLower(name) + "_test.go"
+	}
+	if dest := filepath.Join(filepath.Dir(pgf.URI.Path()), base); dest != pgf.URI.Path() {
+		cmd, err := command.NewMoveDeclarationCommand(fmt.Sprintf("Move %s to file %s", name, base), command.MoveDeclarationArgs{
+			Location:     loc,
+			DestFile:     protocol.URIFromPath(dest),
+			MoveTests:    true,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, newCodeAction(cmd.Title, protocol.RefactorMove, &cmd, nil, options))
+	}
+	if fn, ok := d.node.(*ast.FuncDecl); !isTestFile(pgf.URI) && !(ok && fn.Recv != nil) {
+		// The destination is chosen when the command is executed,
+		// so the edits cannot be resolved in advance.
+		cmd, err := command.NewMoveDeclarationCommand(fmt.Sprintf("Move %s to package...", name), command.MoveDeclarationArgs{
+			Location:  loc,
+			MoveTests: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, protocol.CodeAction{
+			Title:   cmd.Title,
+			Kind:    protocol.RefactorMove,
+			Command: &cmd,
+		})
+	}
+	return actions, nil
+}
+
+// getSafeDeleteCodeActions returns the "safe delete" action for the
+// top-level declaration named at the specified range, if any.
+func getSafeDeleteCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Range, options *settings.Options) ([]protocol.CodeAction, error) {
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	d, err := deletableDecl(pkg, pgf, start, end)
+	if err != nil {
+		return nil, nil // no deletable declaration is named at the selection
+	}
+	// Whether the declaration is referenced is determined when the
+	// command is executed, as it requires a search of the workspace.
+	cmd, err := command.NewSafeDeleteCommand(fmt.Sprintf("Safe delete %s", declName(d)), command.SafeDeleteArgs{
+		Location:     protocol.Location{URI: pgf.URI, Range: rng},
+		ResolveEdits: supportsResolveEdits(options),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeAction{newCodeAction(cmd.Title, protocol.RefactorRewrite, &cmd, nil, options)}, nil
+}
+
+// getConvertMethodCodeActions returns the action that converts the
+// function named at the specified range to a method, or the method
+// to a function, if any.
+func getConvertMethodCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Range, options *settings.Options) ([]protocol.CodeAction, error) {
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	c, err := convertibleFunc(pkg, pgf, start, end)
+	if err != nil {
+		return nil, nil // no convertible function is named at the selection
+	}
+	// Whether every reference can be rewritten is determined when
+	// the command is executed, as it requires a search of the workspace.
+	cmd, err := command.NewConvertMethodCommand(c.title(), command.ConvertMethodArgs{
+		Location:     protocol.Location{URI: pgf.URI, Range: rng},
+		ResolveEdits: supportsResolveEdits(options),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeAction{newCodeAction(cmd.Title, protocol.RefactorRewrite, &cmd, nil, options)}, nil
+}
+
 // getGoTestCodeActions returns any "run this test/benchmark" code actions for the selection.
 func getGoTestCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Range) ([]protocol.CodeAction, error) {
 	testFuncs, benchFuncs, err := testsAndBenchmarks(pkg.TypesInfo(), pgf)
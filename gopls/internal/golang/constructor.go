@@ -0,0 +1,388 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the "generate constructor" refactoring, which
+// declares a NewT function for a struct type T, optionally with
+// functional options (an Option type and a WithX function for each
+// field that is not a parameter of the constructor).
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/diff"
+)
+
+// ConstructorFields returns the name of the constructor of the struct
+// type named at rng and the names of the fields it may set.
+func ConstructorFields(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) (string, []string, error) {
+	c, err := newConstructorGen(ctx, snapshot, fh, rng)
+	if err != nil {
+		return "", nil, err
+	}
+	names := make([]string, len(c.fields))
+	for i, f := range c.fields {
+		names[i] = f.name
+	}
+	return constructorName(c.tname), names, nil
+}
+
+// GenerateConstructor returns the edits that declare a constructor
+// for the struct type named at rng, just after its declaration, whose
+// parameters set the specified fields. If options is set, it also
+// declares a functional option type and, for each other field, a
+// function returning an option that sets it, and the constructor
+// takes a final variadic parameter of options. It also returns the
+// location of the name of the constructor after the edits.
+func GenerateConstructor(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range, fields []string, options bool) ([]protocol.DocumentChange, *protocol.Location, error) {
+	c, err := newConstructorGen(ctx, snapshot, fh, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	required := make(map[string]bool)
+	for _, name := range fields {
+		found := false
+		for _, f := range c.fields {
+			if f.name == name {
+				found = true
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("%s has no field %s", c.tname.Name(), name)
+		}
+		required[name] = true
+	}
+	text, err := c.declarations(required, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	offset, err := safetoken.Offset(c.pgf.Tok, c.decl.End())
+	if err != nil {
+		return nil, nil, err
+	}
+	edits := []diff.Edit{{Start: offset, End: offset, New: "\n\n" + text}}
+	textedits, err := protocol.EditsFromDiffEdits(c.pgf.Mapper, edits)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Select the name of the constructor.
+	name := constructorName(c.tname)
+	from := len("\n\n") + strings.Index(text, "func "+name) + len("func ")
+	sel, err := editedLocation(c.pgf.URI, c.pgf.Src, edits, 0, from, from+len(name))
+	if err != nil {
+		return nil, nil, err
+	}
+	return []protocol.DocumentChange{protocol.DocumentChangeEdit(fh, textedits)}, &sel, nil
+}
+
+// A constructorGen holds the state of a constructor generation.
+type constructorGen struct {
+	pkg    *cache.Package
+	pgf    *parsego.File
+	decl   *ast.GenDecl
+	spec   *ast.TypeSpec
+	tname  *types.TypeName
+	fields []constructorField
+}
+
+// A constructorField is a field that a constructor may set.
+type constructorField struct {
+	name string // name of the field
+	typ  string // source text of its type
+}
+
+func newConstructorGen(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) (*constructorGen, error) {
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	decl, spec := typeSpecAt(pgf, start, end)
+	if spec == nil {
+		return nil, fmt.Errorf("no type declaration at %s", fh.URI().Path())
+	}
+	tname, st, err := constructibleType(pkg, spec)
+	if err != nil {
+		return nil, err
+	}
+	c := &constructorGen{pkg: pkg, pgf: pgf, decl: decl, spec: spec, tname: tname}
+	for _, field := range st.Fields.List {
+		typ := FormatNode(pkg.FileSet(), field.Type)
+		if len(field.Names) == 0 {
+			if id := embeddedIdent(field.Type); id != nil {
+				c.fields = append(c.fields, constructorField{id.Name, typ})
+			}
+			continue
+		}
+		for _, id := range field.Names {
+			if id.Name != "_" {
+				c.fields = append(c.fields, constructorField{id.Name, typ})
+			}
+		}
+	}
+	return c, nil
+}
+
+// constructibleType returns the type name and syntax of the struct
+// type declared by spec, or an error if no constructor can be
+// generated for it.
+func constructibleType(pkg *cache.Package, spec *ast.TypeSpec) (*types.TypeName, *ast.StructType, error) {
+	tname, ok := pkg.TypesInfo().Defs[spec.Name].(*types.TypeName)
+	if !ok || tname.IsAlias() {
+		return nil, nil, fmt.Errorf("%s is not a defined type", spec.Name.Name)
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a struct type", tname.Name())
+	}
+	return tname, st, nil
+}
+
+// declarations returns the text of the declarations of the
+// constructor, and if options is set, of the functional options.
+func (c *constructorGen) declarations(required map[string]bool, options bool) (string, error) {
+	names := c.names()
+	scope := c.pkg.Types().Scope()
+	if scope.Lookup(names.constructor) != nil {
+		return "", fmt.Errorf("%s is already declared", names.constructor)
+	}
+
+	// Type parameters, as declared and as type arguments.
+	var tparams, targs string
+	if list := c.spec.TypeParams; list != nil {
+		var decls, args []string
+		for _, field := range list.List {
+			var names []string
+			for _, id := range field.Names {
+				names = append(names, id.Name)
+			}
+			decls = append(decls, strings.Join(names, ", ")+" "+FormatNode(c.pkg.FileSet(), field.Type))
+			args = append(args, names...)
+		}
+		tparams = "[" + strings.Join(decls, ", ") + "]"
+		targs = "[" + strings.Join(args, ", ") + "]"
+	}
+	typ := c.tname.Name() + targs
+
+	// Choose the names of the parameters.
+	params := make(map[string]string) // field name -> parameter name
+	used := map[string]bool{"opts": options, "opt": options}
+	for _, f := range c.fields {
+		name := lowerFirst(f.name)
+		if token.IsKeyword(name) {
+			name = f.name
+		}
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s%d", lowerFirst(f.name), i)
+		}
+		used[name] = true
+		params[f.name] = name
+	}
+	r, _ := utf8.DecodeRuneInString(c.tname.Name())
+	recv := string(unicode.ToLower(r))
+	for i := 0; used[recv]; i++ {
+		recv = fmt.Sprintf("x%d", i)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// %s returns a new %s", names.constructor, c.tname.Name())
+	if options {
+		fmt.Fprintf(&buf, " configured by opts")
+	}
+	fmt.Fprintf(&buf, ".\nfunc %s%s(", names.constructor, tparams)
+	sep := ""
+	for _, f := range c.fields {
+		if required[f.name] {
+			fmt.Fprintf(&buf, "%s%s %s", sep, params[f.name], f.typ)
+			sep = ", "
+		}
+	}
+	if options {
+		fmt.Fprintf(&buf, "%sopts ...%s%s", sep, names.option, targs)
+	}
+	fmt.Fprintf(&buf, ") *%s {\n", typ)
+	if options {
+		fmt.Fprintf(&buf, "%s := ", recv)
+	} else {
+		fmt.Fprintf(&buf, "return ")
+	}
+	fmt.Fprintf(&buf, "&%s{", typ)
+	if len(required) > 0 {
+		buf.WriteString("\n")
+		for _, f := range c.fields {
+			if required[f.name] {
+				fmt.Fprintf(&buf, "%s: %s,\n", f.name, params[f.name])
+			}
+		}
+	}
+	buf.WriteString("}\n")
+	if options {
+		fmt.Fprintf(&buf, "for _, opt := range opts {\nopt(%s)\n}\nreturn %s\n", recv, recv)
+	}
+	buf.WriteString("}\n")
+
+	if options {
+		if scope.Lookup(names.option) != nil {
+			return "", fmt.Errorf("%s is already declared", names.option)
+		}
+		fmt.Fprintf(&buf, "\n// %s %s configures a %s created by %s.\n", article(names.option), names.option, c.tname.Name(), names.constructor)
+		fmt.Fprintf(&buf, "type %s%s func(*%s)\n", names.option, tparams, typ)
+		for _, f := range c.fields {
+			if required[f.name] {
+				continue
+			}
+			with := names.with[f.name]
+			if scope.Lookup(with) != nil {
+				return "", fmt.Errorf("%s is already declared", with)
+			}
+			fmt.Fprintf(&buf, "\n// %s returns an option that sets the %s field of a %s.\n", with, f.name, c.tname.Name())
+			fmt.Fprintf(&buf, "func %s%s(%s %s) %s%s {\n", with, tparams, params[f.name], f.typ, names.option, targs)
+			fmt.Fprintf(&buf, "return func(%s *%s) {\n%s.%s = %s\n}\n}\n", recv, typ, recv, f.name, params[f.name])
+		}
+	}
+
+	const header = "package p\n\n"
+	src, err := format.Source([]byte(header + buf.String()))
+	if err != nil {
+		return "", fmt.Errorf("formatting constructor: %v", err)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(string(src), header), "\n"), nil
+}
+
+// constructorNames holds the names of the declarations of a
+// constructor and its functional options.
+type constructorNames struct {
+	constructor string            // e.g. NewT
+	option      string            // e.g. Option or TOption
+	with        map[string]string // field name -> option function, e.g. WithX or WithTX
+}
+
+// names returns the names of the constructor of the type and its
+// functional options. They are exported if the type is, and follow
+// the pattern of any functional options already declared in the
+// package: if their types are named after the struct types they
+// configure, such as ServerOption, or their functions are, such as
+// WithServerTimeout, so are the new ones. Otherwise the option type is
+// named Option, and its functions WithX, if those names are free.
+func (c *constructorGen) names() constructorNames {
+	exported := c.tname.Exported()
+	export := func(name string) string {
+		if exported {
+			return upperFirst(name)
+		}
+		return lowerFirst(name)
+	}
+	tname := upperFirst(c.tname.Name())
+	scope := c.pkg.Types().Scope()
+
+	// Find the functional option types of the package, and the
+	// functions that return them.
+	var namedOptions, qualifiedWiths bool
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		switch obj := obj.(type) {
+		case *types.TypeName:
+			if target := optionTarget(obj.Type()); target != nil &&
+				strings.EqualFold(obj.Name(), target.Obj().Name()+"Option") {
+				namedOptions = true
+			}
+		case *types.Func:
+			sig := obj.Type().(*types.Signature)
+			if sig.Recv() != nil || sig.Results().Len() != 1 {
+				continue
+			}
+			if target := optionTarget(sig.Results().At(0).Type()); target != nil &&
+				strings.HasPrefix(strings.ToLower(obj.Name()), strings.ToLower("With"+target.Obj().Name())) {
+				qualifiedWiths = true
+			}
+		}
+	}
+
+	names := constructorNames{
+		constructor: constructorName(c.tname),
+		option:      export("Option"),
+		with:        make(map[string]string),
+	}
+	if namedOptions || scope.Lookup(names.option) != nil {
+		names.option = export(tname + "Option")
+	}
+	for _, f := range c.fields {
+		with := export("With" + upperFirst(f.name))
+		if qualifiedWiths || scope.Lookup(with) != nil {
+			with = export("With" + tname + upperFirst(f.name))
+		}
+		names.with[f.name] = with
+	}
+	return names
+}
+
+// constructorName returns the name of the constructor of the type,
+// NewT, or newT if the type is unexported.
+func constructorName(tname *types.TypeName) string {
+	if tname.Exported() {
+		return "New" + tname.Name()
+	}
+	return "new" + upperFirst(tname.Name())
+}
+
+// optionTarget returns the struct type configured by t, if t is a
+// named functional option type such as func(*T), or nil otherwise.
+func optionTarget(t types.Type) *types.Named {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil
+	}
+	sig, ok := named.Underlying().(*types.Signature)
+	if !ok || sig.Params().Len() != 1 {
+		return nil
+	}
+	ptr, ok := sig.Params().At(0).Type().(*types.Pointer)
+	if !ok {
+		return nil
+	}
+	target, ok := ptr.Elem().(*types.Named)
+	if !ok {
+		return nil
+	}
+	if _, ok := target.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	return target
+}
+
+// article returns the indefinite article for name.
+func article(name string) string {
+	if strings.ContainsRune("AEIOUaeiou", rune(name[0])) {
+		return "An"
+	}
+	return "A"
+}
+
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
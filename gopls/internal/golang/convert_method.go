@@ -0,0 +1,652 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the "convert function to method" refactoring and
+// its inverse, "convert method to function".
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/methodsets"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/diff"
+	"golang.org/x/tools/internal/imports"
+	"golang.org/x/tools/internal/typesinternal"
+)
+
+// A methodConversion describes the conversion of a function to a
+// method of the named type of its first parameter, or of a method to
+// a function whose first parameter is the receiver.
+type methodConversion struct {
+	decl     *ast.FuncDecl
+	fn       *types.Func
+	tname    *types.TypeName // the receiver's named type
+	toMethod bool
+}
+
+// title returns the title of the code action for the conversion.
+func (c *methodConversion) title() string {
+	if c.toMethod {
+		return fmt.Sprintf("Convert %s to method of %s", c.fn.Name(), c.tname.Name())
+	}
+	return fmt.Sprintf("Convert %s.%s to function", c.tname.Name(), c.fn.Name())
+}
+
+// convertibleFunc returns the conversion of the function or method
+// whose name encloses the range [start, end).
+//
+// A function may become a method if its first parameter is of a
+// (pointer to a) named non-interface type of the same package that has
+// no field or method of the function's name. A method may become a
+// function if no package-level object has its name. Neither may be
+// generic.
+func convertibleFunc(pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*methodConversion, error) {
+	var decl *ast.FuncDecl
+	for _, d := range pgf.File.Decls {
+		if fd, ok := d.(*ast.FuncDecl); ok && fd.Name.Pos() <= start && end <= fd.Name.End() {
+			decl = fd
+			break
+		}
+	}
+	if decl == nil {
+		return nil, fmt.Errorf("no function or method is named at the selection")
+	}
+	fn, ok := pkg.TypesInfo().Defs[decl.Name].(*types.Func)
+	if !ok || decl.Body == nil || decl.Name.Name == "_" {
+		return nil, fmt.Errorf("%s cannot be converted", decl.Name.Name)
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.TypeParams().Len() > 0 || sig.RecvTypeParams().Len() > 0 {
+		return nil, fmt.Errorf("%s is generic", fn.Name())
+	}
+
+	if recv := sig.Recv(); recv != nil {
+		_, named := typesinternal.ReceiverNamed(recv)
+		if named == nil {
+			return nil, fmt.Errorf("invalid receiver of %s", fn.Name())
+		}
+		if obj := pkg.Types().Scope().Lookup(fn.Name()); obj != nil {
+			return nil, fmt.Errorf("package %s already declares %s", pkg.Types().Name(), fn.Name())
+		}
+		for _, f := range pkg.CompiledGoFiles() {
+			if obj := pkg.TypesInfo().Scopes[f.File].Lookup(fn.Name()); obj != nil {
+				return nil, fmt.Errorf("%s imports a package named %s", filepath.Base(f.URI.Path()), fn.Name())
+			}
+		}
+		return &methodConversion{decl: decl, fn: fn, tname: named.Obj()}, nil
+	}
+
+	if decl.Name.Name == "init" || decl.Name.Name == "main" && pkg.Types().Name() == "main" {
+		return nil, fmt.Errorf("%s cannot be converted", decl.Name.Name)
+	}
+	if sig.Params().Len() == 0 {
+		return nil, fmt.Errorf("%s has no parameters", fn.Name())
+	}
+	if sig.Variadic() && sig.Params().Len() == 1 {
+		return nil, fmt.Errorf("the first parameter of %s is variadic", fn.Name())
+	}
+	t := sig.Params().At(0).Type()
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() != pkg.Types() || named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("the first parameter of %s is not of a named type of package %s", fn.Name(), pkg.Types().Name())
+	}
+	switch named.Underlying().(type) {
+	case *types.Interface, *types.Pointer:
+		return nil, fmt.Errorf("%s cannot have methods", named.Obj().Name())
+	}
+	if obj, _, _ := types.LookupFieldOrMethod(named, true, pkg.Types(), fn.Name()); obj != nil {
+		return nil, fmt.Errorf("%s already has a field or method named %s", named.Obj().Name(), fn.Name())
+	}
+	return &methodConversion{decl: decl, fn: fn, tname: named.Obj(), toMethod: true}, nil
+}
+
+// ConvertMethod returns the changes that convert the function whose
+// name is indicated by rng to a method of the named type of its first
+// parameter, or the method whose name is indicated by rng to a
+// function whose first parameter is the receiver. Every reference to
+// the function or method in the workspace is rewritten.
+func ConvertMethod(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]protocol.DocumentChange, error) {
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	if perrors, terrors := pkg.ParseErrors(), pkg.TypeErrors(); len(perrors) > 0 || len(terrors) > 0 {
+		return nil, fmt.Errorf("cannot convert functions of package %s, which has errors", pkg.Types().Name())
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	c, err := convertibleFunc(pkg, pgf, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if !c.toMethod {
+		if err := checkInterfaceMethod(ctx, snapshot, pkg, c); err != nil {
+			return nil, err
+		}
+	}
+
+	m := &mover{
+		snapshot: snapshot,
+		pkg:      pkg,
+		dst:      pkg,
+		files:    make(map[protocol.DocumentURI]*fileChange),
+	}
+
+	// Rewrite the references in each package. Calls to a method may
+	// appear in packages that do not import its package.
+	pkgs, err := typeCheckReverseDependencies(ctx, snapshot, pgf.URI, !c.toMethod)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[protocol.DocumentURI]bool)
+	for _, rpkg := range pkgs {
+		obj := c.lookup(rpkg)
+		if obj == nil {
+			continue
+		}
+		for _, rpgf := range rpkg.CompiledGoFiles() {
+			if seen[rpgf.URI] {
+				continue
+			}
+			seen[rpgf.URI] = true
+			if err := c.rewriteReferences(m, rpkg, rpgf, obj); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Rewrite the declaration.
+	edits, err := c.rewriteDecl(pgf)
+	if err != nil {
+		return nil, err
+	}
+	fc := m.file(pgf)
+	fc.edits = append(fc.edits, edits...)
+	return m.changes(ctx)
+}
+
+// lookup returns the object of the converted function or method as
+// seen from pkg, or nil if pkg cannot refer to it.
+func (c *methodConversion) lookup(pkg *cache.Package) *types.Func {
+	path := c.fn.Pkg().Path()
+	declPkg := pkg.Types()
+	if declPkg.Path() != path {
+		declPkg = nil
+		for _, imp := range pkg.Types().Imports() {
+			if imp.Path() == path {
+				declPkg = imp
+			}
+		}
+		if declPkg == nil && !c.toMethod {
+			// The package may refer to the type without importing it.
+			for _, tv := range pkg.TypesInfo().Types {
+				if named, ok := typesinternal.Unpointer(tv.Type).(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == path {
+					declPkg = named.Obj().Pkg()
+					break
+				}
+			}
+		}
+		if declPkg == nil {
+			return nil
+		}
+	}
+	if c.toMethod {
+		fn, _ := declPkg.Scope().Lookup(c.fn.Name()).(*types.Func)
+		return fn
+	}
+	tname, ok := declPkg.Scope().Lookup(c.tname.Name()).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(tname.Type(), true, declPkg, c.fn.Name())
+	fn, _ := obj.(*types.Func)
+	return fn
+}
+
+// checkInterfaceMethod reports an error if the type of the method to
+// be converted satisfies, thanks to the method, an interface declared
+// in the workspace or in a direct dependency of its package, or the
+// error interface.
+func checkInterfaceMethod(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, c *methodConversion) error {
+	if c.fn.Name() == "Error" {
+		errorType := types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+		if types.Implements(types.NewPointer(c.tname.Type()), errorType) {
+			return fmt.Errorf("cannot convert %s.%s to a function: it implements the error interface", c.tname.Name(), c.fn.Name())
+		}
+	}
+	key, ok := methodsets.KeyOf(types.NewPointer(c.tname.Type()))
+	if !ok {
+		return nil
+	}
+	ids := make(map[PackageID]bool)
+	for _, id := range pkg.Metadata().DepsByPkgPath {
+		ids[id] = true
+	}
+	workspace, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	for _, mp := range workspace {
+		ids[mp.ID] = true
+	}
+	var idList []PackageID
+	for id := range ids {
+		idList = append(idList, id)
+	}
+	indexes, err := snapshot.MethodSets(ctx, idList...)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		for _, res := range index.Search(key, c.fn.Id()) {
+			return fmt.Errorf("cannot convert %s.%s to a function: it implements a method of an interface declared in package %s", c.tname.Name(), c.fn.Name(), res.PkgPath)
+		}
+	}
+	return nil
+}
+
+// rewriteReferences rewrites the references to obj, the converted
+// function or method, in the file pgf of package pkg.
+func (c *methodConversion) rewriteReferences(m *mover, pkg *cache.Package, pgf *parsego.File, obj *types.Func) error {
+	info := pkg.TypesInfo()
+	declPath := obj.Pkg().Path()
+	inDecl := pkg.Types().Path() == declPath
+
+	var (
+		edits    []diff.Edit
+		err      error
+		removed  = make(map[*types.PkgName]int) // number of uses of each import removed
+		needsPkg bool                           // the file must import the declaring package
+	)
+	edit := func(start, end token.Pos, new string) {
+		if err != nil {
+			return
+		}
+		var e diff.Edit
+		e, err = posEdit(pgf.Tok, start, end, new)
+		edits = append(edits, e)
+	}
+	text := func(n ast.Node) string {
+		start, end, e := safetoken.Offsets(pgf.Tok, n.Pos(), n.End())
+		if e != nil {
+			err = e
+			return ""
+		}
+		return string(pgf.Src[start:end])
+	}
+	errorf := func(pos token.Pos, format string, args ...interface{}) {
+		if err == nil {
+			posn := safetoken.StartPosition(pkg.FileSet(), pos)
+			err = fmt.Errorf("%s:%d:%d: %s", filepath.Base(posn.Filename), posn.Line, posn.Column, fmt.Sprintf(format, args...))
+		}
+	}
+
+	// declQual returns the qualifier for the declaring package.
+	declQual := func(pos token.Pos) string {
+		if inDecl {
+			return ""
+		}
+		name := c.fn.Pkg().Name()
+		for _, spec := range pgf.File.Imports {
+			if strings.Trim(spec.Path.Value, `"`) == declPath {
+				if spec.Name != nil {
+					name = spec.Name.Name
+				}
+				return name + "."
+			}
+		}
+		needsPkg = true
+		return name + "."
+	}
+	// checkName reports an error if name does not denote want at pos.
+	checkName := func(name string, pos token.Pos, want types.Object) {
+		if scope := pkg.Types().Scope().Innermost(pos); scope != nil {
+			if _, obj := scope.LookupParent(name, pos); obj != want {
+				errorf(pos, "%s would refer to %s", name, obj)
+			}
+		}
+	}
+
+	var stack []ast.Node
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		stack = append(stack, n)
+		id, ok := n.(*ast.Ident)
+		if !ok || info.Uses[id] != obj || err != nil {
+			return true
+		}
+
+		// Find the referring expression, and the enclosing call.
+		var (
+			expr ast.Expr = id
+			call *ast.CallExpr
+		)
+		parents := stack[:len(stack)-1]
+		parent := func(i int) ast.Node {
+			if i < len(parents) {
+				return parents[len(parents)-1-i]
+			}
+			return nil
+		}
+		var sel *ast.SelectorExpr
+		if s, ok := parent(0).(*ast.SelectorExpr); ok && s.Sel == id {
+			sel = s
+			expr = s
+		}
+		p := parent(0)
+		if sel != nil {
+			p = parent(1)
+		}
+		if ce, ok := p.(*ast.CallExpr); ok && ce.Fun == expr {
+			call = ce
+		}
+
+		if c.toMethod {
+			c.rewriteFuncRef(pkg, pgf, id, sel, call, edit, text, errorf, checkName, removed)
+		} else {
+			c.rewriteMethodRef(pkg, id, sel, call, edit, errorf, checkName, declQual)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+
+	fc := m.file(pgf)
+	fc.edits = append(fc.edits, edits...)
+	if needsPkg {
+		fc.fixes = append(fc.fixes, &imports.ImportFix{
+			StmtInfo:  imports.ImportInfo{ImportPath: declPath},
+			IdentName: c.fn.Pkg().Name(),
+			FixType:   imports.AddImport,
+		})
+	}
+
+	// Delete the imports that are no longer used.
+	if len(removed) > 0 {
+		uses := make(map[*types.PkgName]int)
+		ast.Inspect(pgf.File, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				if pkgName, ok := info.Uses[id].(*types.PkgName); ok {
+					uses[pkgName]++
+				}
+			}
+			return true
+		})
+		for pkgName, n := range removed {
+			if uses[pkgName] > n {
+				continue
+			}
+			name := ""
+			for _, spec := range pgf.File.Imports {
+				if spec.Name != nil && info.Defs[spec.Name] == pkgName {
+					name = spec.Name.Name
+				}
+			}
+			fc.fixes = append(fc.fixes, &imports.ImportFix{
+				StmtInfo:  imports.ImportInfo{ImportPath: pkgName.Imported().Path(), Name: name},
+				IdentName: pkgName.Name(),
+				FixType:   imports.DeleteImport,
+			})
+		}
+	}
+	return nil
+}
+
+// rewriteFuncRef rewrites a reference id to the function being
+// converted to a method: a call F(x, args) becomes x.F(args), and any
+// other reference F becomes the method expression T.F.
+func (c *methodConversion) rewriteFuncRef(pkg *cache.Package, pgf *parsego.File, id *ast.Ident, sel *ast.SelectorExpr, call *ast.CallExpr,
+	edit func(start, end token.Pos, new string),
+	text func(ast.Node) string,
+	errorf func(pos token.Pos, format string, args ...interface{}),
+	checkName func(name string, pos token.Pos, want types.Object),
+	removed map[*types.PkgName]int) {
+
+	info := pkg.TypesInfo()
+	var expr ast.Expr = id
+	if sel != nil {
+		expr = sel
+	}
+
+	if call == nil {
+		// A method expression: T.F or (*T).F.
+		qual := ""
+		if sel != nil {
+			if !c.tname.Exported() {
+				errorf(id.Pos(), "cannot refer to method %s of unexported type %s", id.Name, c.tname.Name())
+				return
+			}
+			qual = text(sel.X) + "."
+		} else {
+			checkName(c.tname.Name(), id.Pos(), c.tname)
+		}
+		typ := qual + c.tname.Name()
+		if _, ok := c.fn.Type().(*types.Signature).Params().At(0).Type().(*types.Pointer); ok {
+			typ = "(*" + typ + ")"
+		}
+		edit(expr.Pos(), expr.End(), typ+"."+id.Name)
+		return
+	}
+
+	if len(call.Args) == 0 {
+		errorf(call.Pos(), "call of %s has no arguments", id.Name)
+		return
+	}
+	if _, ok := info.TypeOf(call.Args[0]).(*types.Tuple); ok {
+		errorf(call.Pos(), "call of %s has a multi-valued argument", id.Name)
+		return
+	}
+	recvType := c.fn.Type().(*types.Signature).Params().At(0).Type()
+	if tv := info.Types[call.Args[0]]; tv.Value != nil {
+		// An untyped constant argument has no methods.
+		var name *ast.Ident
+		switch arg := ast.Unparen(call.Args[0]).(type) {
+		case *ast.Ident:
+			name = arg
+		case *ast.SelectorExpr:
+			name = arg.Sel
+		}
+		if name == nil || !types.Identical(info.TypeOf(name), info.ObjectOf(name).Type()) {
+			errorf(call.Args[0].Pos(), "argument %s of %s is an untyped constant", text(call.Args[0]), id.Name)
+			return
+		}
+	}
+	if !types.Identical(info.TypeOf(call.Args[0]), recvType) {
+		errorf(call.Args[0].Pos(), "argument %s of %s is not of type %s", text(call.Args[0]), id.Name, types.TypeString(recvType, types.RelativeTo(pkg.Types())))
+		return
+	}
+	if sel != nil {
+		if pkgName := importedPkgName(info, sel.X); pkgName != nil {
+			removed[pkgName]++
+		}
+	}
+
+	// F(&x, args) becomes x.F(args), as x.F takes the address of x.
+	recv := call.Args[0]
+	if u, ok := recv.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		if _, ok := ast.Unparen(u.X).(*ast.CompositeLit); !ok {
+			edit(u.Pos(), u.X.Pos(), "")
+			recv = u.X
+		}
+	}
+	open, close := "", ""
+	switch ast.Unparen(recv).(type) {
+	case *ast.Ident, *ast.SelectorExpr, *ast.CallExpr, *ast.IndexExpr, *ast.IndexListExpr:
+	default:
+		if _, ok := recv.(*ast.ParenExpr); !ok {
+			open, close = "(", ")"
+		}
+	}
+	edit(call.Fun.Pos(), call.Args[0].Pos(), open)
+	if len(call.Args) > 1 {
+		edit(recv.End(), call.Args[1].Pos(), close+"."+id.Name+"(")
+	} else {
+		edit(recv.End(), call.Rparen, close+"."+id.Name+"(")
+	}
+}
+
+// rewriteMethodRef rewrites a reference id to the method being
+// converted to a function: a call x.M(args) becomes M(x, args), and a
+// method expression T.M becomes M.
+func (c *methodConversion) rewriteMethodRef(pkg *cache.Package, id *ast.Ident, sel *ast.SelectorExpr, call *ast.CallExpr,
+	edit func(start, end token.Pos, new string),
+	errorf func(pos token.Pos, format string, args ...interface{}),
+	checkName func(name string, pos token.Pos, want types.Object),
+	declQual func(pos token.Pos) string) {
+
+	info := pkg.TypesInfo()
+	if sel == nil {
+		errorf(id.Pos(), "unexpected reference to %s", id.Name)
+		return
+	}
+	selection := info.Selections[sel]
+	if selection == nil {
+		errorf(id.Pos(), "unexpected reference to %s", id.Name)
+		return
+	}
+	ptrRecv, _ := typesinternal.ReceiverNamed(c.fn.Type().(*types.Signature).Recv())
+	qual := declQual(id.Pos())
+	if qual == "" {
+		checkName(id.Name, sel.Pos(), nil)
+	}
+
+	switch selection.Kind() {
+	case types.MethodExpr:
+		// T.M becomes M, unless the receiver would differ.
+		if _, isPtr := selection.Recv().(*types.Pointer); isPtr != ptrRecv || len(selection.Index()) > 1 {
+			errorf(sel.Pos(), "cannot convert method expression %s.%s", types.TypeString(selection.Recv(), types.RelativeTo(pkg.Types())), id.Name)
+			return
+		}
+		edit(sel.Pos(), sel.End(), qual+id.Name)
+
+	case types.MethodVal:
+		if call == nil {
+			errorf(sel.Pos(), "cannot convert method value %s", id.Name)
+			return
+		}
+		// Make explicit the selection of embedded fields,
+		// and the implicit address or indirection of x.
+		var path strings.Builder
+		t := selection.Recv()
+		for _, index := range selection.Index()[:len(selection.Index())-1] {
+			field := typesinternal.Unpointer(t).Underlying().(*types.Struct).Field(index)
+			if !field.Exported() && field.Pkg() != pkg.Types() {
+				errorf(sel.Pos(), "cannot refer to unexported field %s of package %s", field.Name(), field.Pkg().Name())
+				return
+			}
+			path.WriteString("." + field.Name())
+			t = field.Type()
+		}
+		_, isPtr := t.(*types.Pointer)
+		prefix := ""
+		if ptrRecv && !isPtr {
+			prefix = "&"
+		} else if !ptrRecv && isPtr {
+			prefix = "*"
+		}
+		edit(sel.X.Pos(), sel.X.Pos(), qual+id.Name+"("+prefix)
+		sep := ""
+		if len(call.Args) > 0 {
+			sep = ", "
+		}
+		edit(sel.X.End(), call.Lparen+1, path.String()+sep)
+
+	default:
+		errorf(sel.Pos(), "unexpected reference to %s", id.Name)
+	}
+}
+
+// rewriteDecl returns the edits to the declaration of the converted
+// function or method.
+func (c *methodConversion) rewriteDecl(pgf *parsego.File) ([]diff.Edit, error) {
+	decl := c.decl
+	text := func(n ast.Node) (string, error) { return nodeText(pgf, n) }
+
+	var edits []diff.Edit
+	add := func(start, end token.Pos, new string) error {
+		e, err := posEdit(pgf.Tok, start, end, new)
+		edits = append(edits, e)
+		return err
+	}
+
+	if c.toMethod {
+		// func F(t T, args) becomes func (t T) F(args).
+		params := decl.Type.Params.List
+		first := params[0]
+		typ, err := text(first.Type)
+		if err != nil {
+			return nil, err
+		}
+		recv := typ
+		if len(first.Names) > 0 {
+			recv = first.Names[0].Name + " " + typ
+		}
+		if err := add(decl.Name.Pos(), decl.Name.Pos(), "("+recv+") "); err != nil {
+			return nil, err
+		}
+		switch {
+		case len(first.Names) > 1:
+			err = add(first.Names[0].Pos(), first.Names[1].Pos(), "")
+		case len(params) > 1:
+			err = add(first.Pos(), params[1].Pos(), "")
+		default:
+			err = add(first.Pos(), decl.Type.Params.Closing, "")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return edits, nil
+	}
+
+	// func (t T) M(args) becomes func M(t T, args).
+	field := decl.Recv.List[0]
+	typ, err := text(field.Type)
+	if err != nil {
+		return nil, err
+	}
+	params := decl.Type.Params.List
+	named := len(params) > 0 && len(params[0].Names) > 0
+	param := typ
+	if len(params) == 0 || named {
+		if len(field.Names) > 0 {
+			param = field.Names[0].Name + " " + typ
+		} else if named {
+			param = "_ " + typ
+		}
+	}
+	if len(params) > 0 {
+		param += ", "
+	}
+	if err := add(decl.Recv.Opening, decl.Name.Pos(), ""); err != nil {
+		return nil, err
+	}
+	if err := add(decl.Type.Params.Opening+1, decl.Type.Params.Opening+1, param); err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
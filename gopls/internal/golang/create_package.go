@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the "create package" quick fix for an import of a
+// package of the main module that does not exist yet.
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/imports"
+)
+
+// CreatePackage returns the changes that create the package with
+// the specified import path, which belongs to the main module of the
+// importing file uri: a doc.go file that declares the package, in
+// the directory of the package, which need not exist. It also
+// returns the location of the placeholder of the package's doc
+// comment in the new file.
+func CreatePackage(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI, pkgPath string) ([]protocol.DocumentChange, protocol.Location, error) {
+	mp, err := NarrowestMetadataForFile(ctx, snapshot, uri)
+	if err != nil {
+		return nil, protocol.Location{}, err
+	}
+	if mp.Module == nil || !mp.Module.Main || mp.Module.Dir == "" {
+		return nil, protocol.Location{}, fmt.Errorf("%s does not belong to a workspace module", uri.Path())
+	}
+	rel := strings.TrimPrefix(pkgPath, mp.Module.Path+"/")
+	if rel == pkgPath {
+		return nil, protocol.Location{}, fmt.Errorf("package %s does not belong to module %s", pkgPath, mp.Module.Path)
+	}
+	name := imports.ImportPathToAssumedName(pkgPath)
+	if !token.IsIdentifier(name) {
+		return nil, protocol.Location{}, fmt.Errorf("cannot derive a package name from %q", pkgPath)
+	}
+	dir := filepath.Join(mp.Module.Dir, filepath.FromSlash(rel))
+
+	// Refuse to add a package clause to a directory that already has
+	// Go files, which may declare another package.
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, protocol.Location{}, err
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".go") {
+			return nil, protocol.Location{}, fmt.Errorf("directory %s already contains Go files", dir)
+		}
+	}
+
+	docURI := protocol.URIFromPath(filepath.Join(dir, "doc.go"))
+	fh, err := snapshot.ReadFile(ctx, docURI)
+	if err != nil {
+		return nil, protocol.Location{}, err
+	}
+	comment := fmt.Sprintf("// Package %s ", name)
+	content := comment + "...\npackage " + name + "\n"
+	placeholder := protocol.Location{
+		URI: docURI,
+		Range: protocol.Range{
+			Start: protocol.Position{Character: uint32(len(comment))},
+			End:   protocol.Position{Character: uint32(len(comment) + len("..."))},
+		},
+	}
+	return []protocol.DocumentChange{
+		protocol.DocumentChangeCreate(docURI),
+		protocol.DocumentChangeEdit(fh, []protocol.TextEdit{{NewText: content}}),
+	}, placeholder, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the on-demand detection of dead code in the
+// workspace, which builds SSA for the entire program in order to
+// compute the functions that are reachable from its entry points.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/typesinternal"
+)
+
+// keepDirective, in the doc comment of a function or of the receiver
+// type of methods, indicates that they are not to be reported as dead
+// code although they are unreachable, as for intentionally unused API.
+const keepDirective = "//gopls:keep"
+
+// FindDeadCode reports the functions and methods declared in the
+// workspace packages that are unreachable from the main functions of
+// the workspace's main packages and from its tests, using Rapid Type
+// Analysis of the SSA form of the program. Functions declared in
+// generated files are not reported, nor are those whose doc comment,
+// or whose receiver type's doc comment, contains a //gopls:keep
+// directive.
+func FindDeadCode(ctx context.Context, snapshot *cache.Snapshot) (map[protocol.DocumentURI][]*cache.Diagnostic, error) {
+	initial, workspace, err := loadWorkspace(ctx, snapshot, true)
+	if err != nil || len(initial) == 0 {
+		return nil, err
+	}
+
+	prog, pkgs := ssautil.AllPackages(initial, ssa.InstantiateGenerics)
+	prog.Build()
+
+	// The entry points are the main and init functions of the main
+	// packages, including those generated for tests.
+	var roots []*ssa.Function
+	for _, main := range ssautil.MainPackages(pkgs) {
+		roots = append(roots, main.Func("init"), main.Func("main"))
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("the workspace has no main packages or tests")
+	}
+	res := rta.Analyze(roots, false)
+
+	// A function is declared in each variant of its package, such as
+	// "p" and "p [p.test]", so if any of its variants is reachable,
+	// all of them are. (We use Position, not Pos, in case files
+	// common to variants were parsed more than once.)
+	reachable := make(map[token.Position]bool)
+	for fn := range res.Reachable {
+		if fn.Pos().IsValid() {
+			reachable[prog.Fset.Position(fn.Pos())] = true
+		}
+	}
+
+	location := locator(ctx, snapshot, prog.Fset)
+	diags := make(map[protocol.DocumentURI][]*cache.Diagnostic)
+	for _, pkg := range initial {
+		if !workspace[pkg.PkgPath] {
+			continue // e.g. a generated test main package
+		}
+
+		// Find the types whose methods are all kept.
+		keptTypes := make(map[types.Object]bool)
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.TYPE {
+					for _, spec := range decl.Specs {
+						spec := spec.(*ast.TypeSpec)
+						doc := spec.Doc
+						if doc == nil && len(decl.Specs) == 1 {
+							doc = decl.Doc
+						}
+						if hasKeepDirective(doc) {
+							keptTypes[pkg.TypesInfo.Defs[spec.Name]] = true
+						}
+					}
+				}
+			}
+		}
+
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				decl, ok := decl.(*ast.FuncDecl)
+				if !ok || decl.Name.Name == "_" || decl.Name.Name == "init" && decl.Recv == nil || hasKeepDirective(decl.Doc) {
+					continue
+				}
+				obj, ok := pkg.TypesInfo.Defs[decl.Name].(*types.Func)
+				if !ok {
+					continue
+				}
+				kind, name := "function", obj.Name()
+				if recv := obj.Type().(*types.Signature).Recv(); recv != nil {
+					kind = "method"
+					if _, named := typesinternal.ReceiverNamed(recv); named != nil {
+						if keptTypes[named.Obj()] {
+							continue
+						}
+						name = named.Obj().Name() + "." + name
+					}
+				}
+				posn := prog.Fset.Position(decl.Name.Pos())
+				if reachable[posn] {
+					continue
+				}
+				reachable[posn] = true // report each declaration once
+
+				loc, err := location(decl.Name.Pos(), decl.Name.End())
+				if err != nil {
+					return nil, err
+				}
+				if IsGenerated(ctx, snapshot, loc.URI) {
+					continue
+				}
+
+				// The quick fix inserts a directive before the
+				// declaration, at the end of its doc comment.
+				funcLoc, err := location(decl.Pos(), decl.Pos())
+				if err != nil {
+					return nil, err
+				}
+				insert := protocol.Position{Line: funcLoc.Range.Start.Line}
+				diags[loc.URI] = append(diags[loc.URI], &cache.Diagnostic{
+					URI:      loc.URI,
+					Range:    loc.Range,
+					Severity: protocol.SeverityInformation,
+					Source:   cache.DeadCode,
+					Message:  fmt.Sprintf("%s %s is unreachable from main functions and tests", kind, name),
+					SuggestedFixes: []cache.SuggestedFix{{
+						Title: fmt.Sprintf("Keep %s (add %s directive)", name, keepDirective),
+						Edits: map[protocol.DocumentURI][]protocol.TextEdit{
+							loc.URI: {{
+								Range:   protocol.Range{Start: insert, End: insert},
+								NewText: keepDirective + "\n",
+							}},
+						},
+						ActionKind: protocol.QuickFix,
+					}},
+				})
+			}
+		}
+	}
+	return diags, nil
+}
+
+// hasKeepDirective reports whether the doc comment contains a
+// //gopls:keep directive.
+func hasKeepDirective(doc *ast.CommentGroup) bool {
+	if doc != nil {
+		for _, c := range doc.List {
+			if c.Text == keepDirective || strings.HasPrefix(c.Text, keepDirective+" ") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,431 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the on-demand "deep analysis" of a package, which
+// builds SSA for the package and all its dependencies in order to
+// report interprocedural findings that are too costly to compute on
+// every keystroke.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/protocol"
+)
+
+// maxExplanation bounds the number of steps of a call path reported
+// as the related information of a deep analysis diagnostic.
+const maxExplanation = 10
+
+// DeepAnalyze builds SSA for the specified package and its
+// dependencies, and reports two kinds of potential problems in the
+// package:
+//
+//   - calls that pass nil for a pointer parameter that the callee
+//     (or a function to which it passes the parameter) dereferences
+//     without first comparing it with nil; and
+//   - calls whose error result is ignored although the callee (or a
+//     function whose error it returns) may return a non-nil error.
+//
+// The related information of each diagnostic explains the path of
+// calls that leads to the problem.
+func DeepAnalyze(ctx context.Context, snapshot *cache.Snapshot, mp *metadata.Package) (map[protocol.DocumentURI][]*cache.Diagnostic, error) {
+	if len(mp.CompiledGoFiles) == 0 {
+		return nil, nil
+	}
+	pkgDir := filepath.Dir(mp.CompiledGoFiles[0].Path())
+	pkgs, err := loadSyntax(ctx, snapshot, pkgDir, mp.ForTest != "", string(mp.PkgPath))
+	if err != nil {
+		return nil, err
+	}
+	var target *packages.Package
+	for _, pkg := range pkgs {
+		if pkg.ID == string(mp.ID) {
+			target = pkg
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("package %s not found", mp.ID)
+	}
+
+	prog, _ := ssautil.AllPackages([]*packages.Package{target}, ssa.InstantiateGenerics)
+	prog.Build()
+
+	// Index the calls of the target package by the position of their
+	// left parenthesis, which is the position of the SSA call.
+	calls := make(map[token.Pos]*ast.CallExpr)
+	for _, file := range target.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				calls[call.Lparen] = call
+			}
+			return true
+		})
+	}
+
+	location := locator(ctx, snapshot, prog.Fset)
+
+	a := &deepAnalysis{
+		derefs:   make(map[*ssa.Function][]*derefStep),
+		failures: make(map[*ssa.Function]*failStep),
+	}
+	diags := make(map[protocol.DocumentURI][]*cache.Diagnostic)
+	report := func(pos token.Pos, msg string, explain func(relate func(pos token.Pos, msg string))) error {
+		start, end := pos, pos
+		if call, ok := calls[pos]; ok {
+			start, end = call.Pos(), call.End()
+		}
+		loc, err := location(start, end)
+		if err != nil {
+			return err
+		}
+		diag := &cache.Diagnostic{
+			URI:      loc.URI,
+			Range:    loc.Range,
+			Severity: protocol.SeverityWarning,
+			Source:   cache.DeepAnalysis,
+			Message:  msg,
+		}
+		explain(func(pos token.Pos, msg string) {
+			if !pos.IsValid() || len(diag.Related) == maxExplanation {
+				return
+			}
+			if loc, err := location(pos, pos); err == nil {
+				diag.Related = append(diag.Related, protocol.DiagnosticRelatedInformation{
+					Location: loc,
+					Message:  msg,
+				})
+			}
+		})
+		diags[loc.URI] = append(diags[loc.URI], diag)
+		return nil
+	}
+
+	name := func(fn *ssa.Function) string { return fn.RelString(target.Types) }
+
+	// Gather the functions declared in the target package, including
+	// blank functions, which are not package members.
+	var funcs []*ssa.Function
+	var addFunc func(fn *ssa.Function)
+	addFunc = func(fn *ssa.Function) {
+		funcs = append(funcs, fn)
+		for _, anon := range fn.AnonFuncs {
+			addFunc(anon)
+		}
+	}
+	for _, file := range target.Syntax {
+		for _, decl := range file.Decls {
+			if decl, ok := decl.(*ast.FuncDecl); ok {
+				if obj, ok := target.TypesInfo.Defs[decl.Name].(*types.Func); ok {
+					if fn := prog.FuncValue(obj); fn != nil {
+						addFunc(fn)
+					}
+				}
+			}
+		}
+	}
+
+	for _, fn := range funcs {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok {
+					continue
+				}
+				callee := call.Common().StaticCallee()
+				if callee == nil {
+					continue
+				}
+
+				// Is nil passed for a parameter that the callee dereferences?
+				steps := a.derefSteps(callee)
+				for i, arg := range call.Common().Args {
+					if c, ok := arg.(*ssa.Const); !ok || !c.IsNil() || steps[i] == nil {
+						continue
+					}
+					param := callee.Params[i].Name()
+					msg := fmt.Sprintf("nil passed for parameter %s of %s, which may dereference it", param, name(callee))
+					err := report(call.Pos(), msg, func(relate func(token.Pos, string)) {
+						fn, step := callee, steps[i]
+						for n := 0; step != nil && n < maxExplanation; n++ {
+							if step.callee == nil {
+								relate(step.pos, fmt.Sprintf("%s dereferences %s", name(fn), param))
+								break
+							}
+							next := step.callee.Params[step.param].Name()
+							relate(step.pos, fmt.Sprintf("%s passes %s to parameter %s of %s", name(fn), param, next, name(step.callee)))
+							fn, param, step = step.callee, next, a.derefSteps(step.callee)[step.param]
+						}
+					})
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				// Is a possibly non-nil error result ignored?
+				if call, ok := call.(*ssa.Call); ok && returnsError(callee) && !ignoredErrorCallee(callee) && errorIgnored(call) {
+					if step := a.failure(callee); step != nil {
+						msg := fmt.Sprintf("error result of %s is ignored", name(callee))
+						err := report(call.Pos(), msg, func(relate func(token.Pos, string)) {
+							fn := callee
+							for n := 0; step != nil && n < maxExplanation; n++ {
+								if step.callee == nil {
+									relate(step.pos, fmt.Sprintf("%s returns a non-nil error", name(fn)))
+									break
+								}
+								relate(step.pos, fmt.Sprintf("%s returns the error of %s", name(fn), name(step.callee)))
+								fn, step = step.callee, a.failure(step.callee)
+							}
+						})
+						if err != nil {
+							return nil, err
+						}
+					}
+				}
+			}
+		}
+	}
+	return diags, nil
+}
+
+// locator returns a function that returns the protocol location of a
+// source range [start, end) of packages loaded by loadSyntax using the
+// given file set.
+func locator(ctx context.Context, snapshot *cache.Snapshot, fset *token.FileSet) func(start, end token.Pos) (protocol.Location, error) {
+	mappers := make(map[protocol.DocumentURI]*protocol.Mapper)
+	return func(start, end token.Pos) (protocol.Location, error) {
+		tokFile := fset.File(start)
+		if tokFile == nil {
+			return protocol.Location{}, fmt.Errorf("no file for position %v", start)
+		}
+		uri := protocol.URIFromPath(tokFile.Name())
+		m, ok := mappers[uri]
+		if !ok {
+			fh, err := snapshot.ReadFile(ctx, uri)
+			if err != nil {
+				return protocol.Location{}, err
+			}
+			content, err := fh.Content()
+			if err != nil {
+				return protocol.Location{}, err
+			}
+			m = protocol.NewMapper(uri, content)
+			mappers[uri] = m
+		}
+		return m.PosLocation(tokFile, start, end)
+	}
+}
+
+// deepAnalysis holds the memoized interprocedural facts computed by
+// DeepAnalyze.
+type deepAnalysis struct {
+	derefs   map[*ssa.Function][]*derefStep // indexed by parameter
+	failures map[*ssa.Function]*failStep    // nil => never returns a non-nil error
+}
+
+// A derefStep explains why a function dereferences one of its pointer
+// parameters: either directly, at pos, or by passing it at pos to a
+// parameter of a callee that dereferences it.
+type derefStep struct {
+	pos    token.Pos
+	callee *ssa.Function // nil => direct dereference
+	param  int           // index of callee parameter
+}
+
+// A failStep explains why a function may return a non-nil error:
+// either because the error originates at the return statement at pos,
+// or because that statement returns the error result of callee.
+type failStep struct {
+	pos    token.Pos
+	callee *ssa.Function // nil => the error originates at pos
+}
+
+// derefSteps returns, for each parameter of fn, the step that explains
+// why fn dereferences it without comparing it with nil, or nil if it
+// does not. Parameters compared with nil anywhere in fn are assumed to
+// be checked.
+func (a *deepAnalysis) derefSteps(fn *ssa.Function) []*derefStep {
+	if steps, ok := a.derefs[fn]; ok {
+		return steps
+	}
+	steps := make([]*derefStep, len(fn.Params))
+	a.derefs[fn] = steps // provisional result, for recursive calls
+
+	index := make(map[ssa.Value]int) // maps each pointer parameter to its index
+	for i, param := range fn.Params {
+		if _, ok := param.Type().Underlying().(*types.Pointer); ok {
+			index[param] = i
+		}
+	}
+	if len(index) == 0 {
+		return steps
+	}
+
+	checked := make(map[int]bool)
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			if binop, ok := instr.(*ssa.BinOp); ok && (binop.Op == token.EQL || binop.Op == token.NEQ) {
+				for _, x := range [...]ssa.Value{binop.X, binop.Y} {
+					if i, ok := index[x]; ok {
+						checked[i] = true
+					}
+				}
+			}
+		}
+	}
+
+	record := func(x ssa.Value, step *derefStep) {
+		if i, ok := index[x]; ok && !checked[i] && steps[i] == nil && step.pos.IsValid() {
+			steps[i] = step
+		}
+	}
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			switch instr := instr.(type) {
+			case *ssa.FieldAddr:
+				record(instr.X, &derefStep{pos: instr.Pos()})
+			case *ssa.IndexAddr:
+				record(instr.X, &derefStep{pos: instr.Pos()})
+			case *ssa.Store:
+				record(instr.Addr, &derefStep{pos: instr.Pos()})
+			case *ssa.UnOp:
+				if instr.Op == token.MUL {
+					record(instr.X, &derefStep{pos: instr.Pos()})
+				}
+			case ssa.CallInstruction:
+				callee := instr.Common().StaticCallee()
+				if callee == nil {
+					continue
+				}
+				for j, arg := range instr.Common().Args {
+					if _, ok := index[arg]; ok && a.derefSteps(callee)[j] != nil {
+						record(arg, &derefStep{pos: instr.Pos(), callee: callee, param: j})
+					}
+				}
+			}
+		}
+	}
+	return steps
+}
+
+// failure returns the step that explains why fn, whose last result is
+// an error, may return a non-nil error, or nil if it always returns a
+// nil error.
+func (a *deepAnalysis) failure(fn *ssa.Function) *failStep {
+	if step, ok := a.failures[fn]; ok {
+		return step
+	}
+	a.failures[fn] = nil // provisional result, for recursive calls
+
+	var step *failStep
+	if fn.Blocks == nil {
+		// A function without a body (e.g. in assembly) may fail.
+		step = &failStep{pos: fn.Pos()}
+	}
+	for _, b := range fn.Blocks {
+		if ret, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return); ok && step == nil {
+			step = a.errorValueFailure(ret.Results[len(ret.Results)-1], ret.Pos(), make(map[*ssa.Phi]bool))
+		}
+	}
+	a.failures[fn] = step
+	return step
+}
+
+// errorValueFailure returns the step that explains why the error
+// value v, returned at pos, may be non-nil, or nil if it is always nil.
+func (a *deepAnalysis) errorValueFailure(v ssa.Value, pos token.Pos, seen map[*ssa.Phi]bool) *failStep {
+	// callFailure explains the error result of a call.
+	callFailure := func(call *ssa.Call) *failStep {
+		callee := call.Call.StaticCallee()
+		if callee == nil || !returnsError(callee) {
+			return &failStep{pos: pos}
+		}
+		if a.failure(callee) == nil {
+			return nil
+		}
+		return &failStep{pos: pos, callee: callee}
+	}
+
+	switch v := v.(type) {
+	case *ssa.Const:
+		if v.IsNil() {
+			return nil
+		}
+	case *ssa.Phi:
+		if seen[v] {
+			return nil
+		}
+		seen[v] = true
+		for _, edge := range v.Edges {
+			if step := a.errorValueFailure(edge, pos, seen); step != nil {
+				return step
+			}
+		}
+		return nil
+	case *ssa.Call:
+		return callFailure(v)
+	case *ssa.Extract:
+		if call, ok := v.Tuple.(*ssa.Call); ok && v.Index == v.Tuple.Type().(*types.Tuple).Len()-1 {
+			return callFailure(call)
+		}
+	}
+	return &failStep{pos: pos}
+}
+
+// returnsError reports whether the last result of fn is an error.
+func returnsError(fn *ssa.Function) bool {
+	results := fn.Signature.Results()
+	return results.Len() > 0 && types.Identical(results.At(results.Len()-1).Type(), types.Universe.Lookup("error").Type())
+}
+
+// errorIgnored reports whether the error result of call is unused.
+func errorIgnored(call *ssa.Call) bool {
+	results := call.Call.Signature().Results()
+	if results.Len() == 1 {
+		refs := call.Referrers()
+		return refs != nil && len(*refs) == 0
+	}
+	for _, ref := range *call.Referrers() {
+		if extract, ok := ref.(*ssa.Extract); ok && extract.Index == results.Len()-1 {
+			return false
+		}
+	}
+	return true
+}
+
+// ignoredErrorCallee reports whether the error result of fn is
+// conventionally ignored, as for fmt.Println or bytes.Buffer.Write.
+func ignoredErrorCallee(fn *ssa.Function) bool {
+	if fn.Pkg == nil {
+		fn = fn.Origin()
+		if fn == nil || fn.Pkg == nil {
+			return false
+		}
+	}
+	switch fn.Pkg.Pkg.Path() {
+	case "fmt":
+		return true
+	case "bytes", "strings":
+		if recv := fn.Signature.Recv(); recv != nil {
+			if ptr, ok := recv.Type().(*types.Pointer); ok {
+				if named, ok := ptr.Elem().(*types.Named); ok {
+					name := named.Obj().Name()
+					return name == "Buffer" || name == "Builder"
+				}
+			}
+		}
+	}
+	return false
+}
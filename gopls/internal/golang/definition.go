@@ -75,6 +75,12 @@ func Definition(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, p
 		return locations, err // may be success or failure
 	}
 
+	// Handle the case where the cursor is in a go:generate directive.
+	locations, err = generateDefinition(ctx, snapshot, pkg, pgf, pos)
+	if !errors.Is(err, errNoGenerateRef) {
+		return locations, err // may be success or failure
+	}
+
 	// Handle the case where the cursor is in a doc link.
 	locations, err = docLinkDefinition(ctx, snapshot, pkg, pgf, pos)
 	if !errors.Is(err, errNoCommentReference) {
@@ -95,9 +101,42 @@ func Definition(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, p
 	// Finally, map the object position.
 	loc, err := mapPosition(ctx, pkg.FileSet(), snapshot, obj.Pos(), adjustedObjEnd(obj))
 	if err != nil {
-		return nil, err
+		// The source of the declaring package may be unavailable,
+		// as when it is known only from export data.
+		if obj.Pkg() == nil || obj.Pkg() == pkg.Types() {
+			return nil, err
+		}
+		loc, err = exportDataLocation(obj)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return []protocol.Location{loc}, nil
+	locations = []protocol.Location{loc}
+
+	// For a function without a body, offer its implementation in
+	// assembly or through a //go:linkname directive first.
+	if fn, ok := obj.(*types.Func); ok {
+		impls, err := bodylessDefinitions(ctx, snapshot, fn, loc)
+		if err != nil {
+			event.Error(ctx, "finding implementation of bodyless function", err)
+		}
+		locations = append(impls, locations...)
+	}
+
+	// For an alias, also offer the declaration of the aliased type,
+	// at the end of the chain of aliases.
+	if tname, ok := obj.(*types.TypeName); ok && tname.IsAlias() {
+		chain, err := aliasChain(ctx, snapshot, pkg, tname)
+		if err != nil {
+			event.Error(ctx, "resolving alias chain", err)
+		}
+		if len(chain) > 1 {
+			if last := chain[len(chain)-1]; last.loc.URI != "" && last.loc != loc {
+				locations = append(locations, last.loc)
+			}
+		}
+	}
+	return locations, nil
 }
 
 // builtinDefinition returns the location of the fake source
@@ -269,9 +308,13 @@ func importDefinition(ctx context.Context, s *cache.Snapshot, pkg *cache.Package
 	if impMetadata == nil {
 		return nil, fmt.Errorf("missing information for package %q", impID)
 	}
+	return packageLocations(ctx, s, impMetadata)
+}
 
+// packageLocations returns the locations of the files of a package.
+func packageLocations(ctx context.Context, s *cache.Snapshot, mp *metadata.Package) ([]protocol.Location, error) {
 	var locs []protocol.Location
-	for _, f := range impMetadata.CompiledGoFiles {
+	for _, f := range mp.CompiledGoFiles {
 		fh, err := s.ReadFile(ctx, f)
 		if err != nil {
 			if ctx.Err() != nil {
@@ -294,7 +337,7 @@ func importDefinition(ctx context.Context, s *cache.Snapshot, pkg *cache.Package
 	}
 
 	if len(locs) == 0 {
-		return nil, fmt.Errorf("package %q has no readable files", impID) // incl. unsafe
+		return nil, fmt.Errorf("package %q has no readable files", mp.ID) // incl. unsafe
 	}
 
 	return locs, nil
@@ -304,6 +347,9 @@ func importDefinition(ctx context.Context, s *cache.Snapshot, pkg *cache.Package
 // column mapper with each file handle.
 func mapPosition(ctx context.Context, fset *token.FileSet, s file.Source, start, end token.Pos) (protocol.Location, error) {
 	file := fset.File(start)
+	if file == nil {
+		return protocol.Location{}, fmt.Errorf("no file for position %d", start)
+	}
 	uri := protocol.URIFromPath(file.Name())
 	fh, err := s.ReadFile(ctx, uri)
 	if err != nil {
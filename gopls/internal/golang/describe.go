@@ -0,0 +1,144 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/types/typeutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/gopls/internal/util/typesutil"
+	"golang.org/x/tools/internal/event"
+)
+
+// Describe describes the innermost syntax node enclosing the
+// specified range, in the manner of the former "guru describe" query.
+func Describe(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) (command.DescribeResult, error) {
+	ctx, done := event.Start(ctx, "golang.Describe")
+	defer done()
+
+	var result command.DescribeResult
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return result, err
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return result, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	if len(path) == 0 {
+		return result, fmt.Errorf("no syntax at selection")
+	}
+	node := path[0]
+	result.Desc = astutil.NodeDescription(node)
+	result.Location, err = pgf.NodeLocation(node)
+	if err != nil {
+		return result, err
+	}
+
+	info := pkg.TypesInfo()
+	qual := typesutil.FileQualifier(pgf.File, pkg.Types(), info)
+
+	// describeObject describes the object denoted by an identifier.
+	describeObject := func(id *ast.Ident, obj types.Object) error {
+		if info.Defs[id] == obj {
+			result.Desc = "definition of " + types.ObjectString(obj, qual)
+		} else {
+			result.Desc = "reference to " + types.ObjectString(obj, qual)
+		}
+		if obj.Pkg() != nil && obj.Pos().IsValid() {
+			loc, err := mapPosition(ctx, pkg.FileSet(), snapshot, obj.Pos(), adjustedObjEnd(obj))
+			if err != nil {
+				return err
+			}
+			result.Object = &loc
+		}
+		switch obj := obj.(type) {
+		case *types.PkgName:
+			result.Detail = "package"
+			result.Members = packageMembers(obj.Imported())
+		case *types.TypeName:
+			result.Detail = "type"
+			result.Type = types.TypeString(obj.Type(), qual)
+			result.Methods = methodSet(obj.Type(), qual)
+		case *types.Const:
+			result.Detail = "value"
+			result.Type = types.TypeString(obj.Type(), qual)
+			result.Value = obj.Val().String()
+		case *types.Var, *types.Func:
+			result.Detail = "value"
+			result.Type = types.TypeString(obj.Type(), qual)
+		}
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *ast.Ident:
+		if pgf.File.Name == n {
+			result.Desc = "package " + n.Name
+			result.Detail = "package"
+			result.Members = packageMembers(pkg.Types())
+			return result, nil
+		}
+		if obj := info.ObjectOf(n); obj != nil {
+			return result, describeObject(n, obj)
+		}
+
+	case *ast.SelectorExpr:
+		// A qualified identifier is described by its object.
+		if obj := info.Uses[n.Sel]; obj != nil {
+			if _, ok := info.Selections[n]; !ok {
+				return result, describeObject(n.Sel, obj)
+			}
+		}
+	}
+
+	if e, ok := node.(ast.Expr); ok {
+		if tv, ok := info.Types[e]; ok {
+			result.Type = types.TypeString(tv.Type, qual)
+			switch {
+			case tv.IsType():
+				result.Detail = "type"
+				result.Methods = methodSet(tv.Type, qual)
+			case tv.IsValue():
+				result.Detail = "value"
+				if tv.Value != nil {
+					result.Value = tv.Value.String()
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// methodSet returns the methods of the type T and, if it is not an
+// interface, of *T.
+func methodSet(T types.Type, qual types.Qualifier) []string {
+	var methods []string
+	for _, sel := range typeutil.IntuitiveMethodSet(T, nil) {
+		methods = append(methods, types.ObjectString(sel.Obj(), qual))
+	}
+	return methods
+}
+
+// packageMembers returns the exported members of pkg, in order of name.
+func packageMembers(pkg *types.Package) []string {
+	var members []string
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if obj := scope.Lookup(name); obj.Exported() {
+			members = append(members, types.ObjectString(obj, types.RelativeTo(pkg)))
+		}
+	}
+	return members
+}
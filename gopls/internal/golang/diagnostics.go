@@ -6,13 +6,19 @@ package golang
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"golang.org/x/tools/gopls/internal/baseline"
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/cache/metadata"
 	"golang.org/x/tools/gopls/internal/progress"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/settings"
 	"golang.org/x/tools/gopls/internal/util/maps"
+	"golang.org/x/tools/internal/event"
 )
 
 // Analyze reports go/analysis-framework diagnostics in the specified package.
@@ -20,8 +26,11 @@ import (
 // If the provided tracker is non-nil, it may be used to provide notifications
 // of the ongoing analysis pass.
 //
+// If include is non-nil, only the analyzers whose names it accepts
+// run, and only their diagnostics are reported.
+//
 // TODO(rfindley): merge this with snapshot.Analyze.
-func Analyze(ctx context.Context, snapshot *cache.Snapshot, pkgIDs map[PackageID]*metadata.Package, tracker *progress.Tracker) (map[protocol.DocumentURI][]*cache.Diagnostic, error) {
+func Analyze(ctx context.Context, snapshot *cache.Snapshot, pkgIDs map[PackageID]*metadata.Package, tracker *progress.Tracker, include func(analyzer string) bool) (map[protocol.DocumentURI][]*cache.Diagnostic, error) {
 	// Exit early if the context has been canceled. This also protects us
 	// from a race on Options, see golang/go#36699.
 	if ctx.Err() != nil {
@@ -29,14 +38,253 @@ func Analyze(ctx context.Context, snapshot *cache.Snapshot, pkgIDs map[PackageID
 	}
 
 	analyzers := maps.Values(settings.DefaultAnalyzers)
+	var staticcheck *staticcheckConfig
 	if snapshot.Options().Staticcheck {
-		analyzers = append(analyzers, maps.Values(settings.StaticcheckAnalyzers)...)
+		// Run each check enabled by the staticcheck.conf files
+		// of any package; filter the diagnostics per directory below.
+		staticcheck = newStaticcheckConfig(ctx)
+		for name, a := range settings.StaticcheckAnalyzers {
+			enabled := false
+			for _, mp := range pkgIDs {
+				if len(mp.CompiledGoFiles) > 0 && staticcheck.enabled(mp.CompiledGoFiles[0].Dir().Path(), name) {
+					enabled = true
+					break
+				}
+			}
+			analyzers = append(analyzers, a.WithEnabledByDefault(enabled))
+		}
+	}
+	// Plugin load errors were reported when the setting was applied.
+	plugins, _ := settings.PluginAnalyzers(snapshot.Options().AnalyzerPlugins)
+	analyzers = append(analyzers, plugins...)
+
+	release, err := settings.UseAnalyzerFlags(snapshot.Options().AnalyzerFlags)
+	if err != nil {
+		event.Error(ctx, "applying analyzerFlags setting", err)
+	}
+	defer release()
+
+	// Analyzers that are too costly to run on every edit, and external
+	// analyzer tools, run only on the packages whose files are all saved.
+	var onSave []*settings.Analyzer
+	{
+		n := 0
+		for _, a := range analyzers {
+			if include != nil && !include(a.Analyzer().Name) {
+				continue
+			}
+			if a.OnSave() {
+				if a.Enabled(snapshot.Options()) {
+					onSave = append(onSave, a)
+				}
+			} else {
+				analyzers[n] = a
+				n++
+			}
+		}
+		analyzers = analyzers[:n]
 	}
 
 	analysisDiagnostics, err := snapshot.Analyze(ctx, pkgIDs, analyzers, tracker)
 	if err != nil {
 		return nil, err
 	}
+	if len(onSave) > 0 || len(snapshot.Options().AnalyzerTools) > 0 {
+		if saved := savedPackages(snapshot, pkgIDs); len(saved) > 0 {
+			if len(onSave) > 0 {
+				diags, err := snapshot.Analyze(ctx, saved, onSave, tracker)
+				if err != nil {
+					return nil, err
+				}
+				analysisDiagnostics = append(analysisDiagnostics, diags...)
+			}
+			diags, err := snapshot.AnalyzerToolDiagnostics(ctx, saved)
+			if err != nil {
+				return nil, err
+			}
+			for _, diag := range diags {
+				if include == nil || include(string(diag.Source)) {
+					analysisDiagnostics = append(analysisDiagnostics, diag)
+				}
+			}
+		}
+	}
+	if staticcheck != nil {
+		analysisDiagnostics = staticcheck.filter(snapshot.Options(), analysisDiagnostics)
+	}
+	analysisDiagnostics, err = applyDiagnosticOptions(ctx, snapshot, analysisDiagnostics)
+	if err != nil {
+		return nil, err
+	}
 	byURI := func(d *cache.Diagnostic) protocol.DocumentURI { return d.URI }
 	return maps.Group(analysisDiagnostics, byURI), nil
 }
+
+// savedPackages returns the subset of the specified packages none of
+// whose files have unsaved changes.
+func savedPackages(snapshot *cache.Snapshot, pkgIDs map[PackageID]*metadata.Package) map[PackageID]*metadata.Package {
+	unsaved := make(map[protocol.DocumentURI]bool)
+	for _, o := range snapshot.Overlays() {
+		if !o.SameContentsOnDisk() {
+			unsaved[o.URI()] = true
+		}
+	}
+	saved := make(map[PackageID]*metadata.Package)
+outer:
+	for id, mp := range pkgIDs {
+		for _, uri := range mp.CompiledGoFiles {
+			if unsaved[uri] {
+				continue outer
+			}
+		}
+		saved[id] = mp
+	}
+	return saved
+}
+
+// RunAnalyzer runs the named analyzer, even if the analyses setting
+// disables it, over the package of the specified Go file or, if uri
+// denotes a directory, over the workspace packages in that directory
+// and its subdirectories. The diagnostic options (analysisExclusions
+// and so on) do not apply to its results.
+func RunAnalyzer(ctx context.Context, snapshot *cache.Snapshot, name string, uri protocol.DocumentURI) ([]*cache.Diagnostic, error) {
+	a, err := lookupAnalyzer(snapshot, name)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgIDs := make(map[PackageID]*metadata.Package)
+	if mps, err := snapshot.MetadataForFile(ctx, uri); err != nil {
+		return nil, err
+	} else if len(mps) > 0 {
+		mp, err := NarrowestMetadataForFile(ctx, snapshot, uri)
+		if err != nil {
+			return nil, err
+		}
+		pkgIDs[mp.ID] = mp
+	} else {
+		mps, err := snapshot.WorkspaceMetadata(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, mp := range mps {
+			for _, f := range mp.CompiledGoFiles {
+				if uri.Encloses(f) {
+					pkgIDs[mp.ID] = mp
+					break
+				}
+			}
+		}
+		if len(pkgIDs) == 0 {
+			return nil, fmt.Errorf("no workspace packages in %s", uri.Path())
+		}
+	}
+
+	release, err := settings.UseAnalyzerFlags(snapshot.Options().AnalyzerFlags)
+	if err != nil {
+		event.Error(ctx, "applying analyzerFlags setting", err)
+	}
+	defer release()
+
+	return snapshot.Analyze(ctx, pkgIDs, []*settings.Analyzer{a.Forced()}, nil)
+}
+
+// lookupAnalyzer returns the built-in, staticcheck, or plugin analyzer
+// of the given name.
+func lookupAnalyzer(snapshot *cache.Snapshot, name string) (*settings.Analyzer, error) {
+	if a, ok := settings.DefaultAnalyzers[name]; ok {
+		return a, nil
+	}
+	if a, ok := settings.StaticcheckAnalyzers[name]; ok {
+		return a, nil
+	}
+	plugins, _ := settings.PluginAnalyzers(snapshot.Options().AnalyzerPlugins)
+	for _, a := range plugins {
+		if a.Analyzer().Name == name {
+			return a, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown analyzer %q", name)
+}
+
+// applyDiagnosticOptions applies the user's analysisExclusions,
+// analysisSeverities, diagnosticsBaseline, and
+// styleDiagnosticsInGeneratedFiles settings to a list of analysis
+// diagnostics, returning a new list.
+func applyDiagnosticOptions(ctx context.Context, snapshot *cache.Snapshot, diags []*cache.Diagnostic) ([]*cache.Diagnostic, error) {
+	opts := snapshot.Options()
+	if len(opts.AnalysisExclusions) == 0 && len(opts.AnalysisSeverities) == 0 && opts.DiagnosticsBaseline == "" && opts.StyleDiagnosticsInGeneratedFiles {
+		return diags, nil // fast path
+	}
+
+	folder := snapshot.Folder().Path()
+
+	// The baseline file is read directly (not through the snapshot),
+	// as it is typically rewritten by "gopls check" without any
+	// notification to the server.
+	var suppressed baseline.Set
+	if filename := opts.DiagnosticsBaseline; filename != "" {
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(folder, filename)
+		}
+		// A missing or malformed baseline file suppresses
+		// nothing, rather than preventing all analysis.
+		data, err := os.ReadFile(filename)
+		if err == nil {
+			suppressed, err = baseline.Parse(data)
+		}
+		if err != nil {
+			event.Log(ctx, fmt.Sprintf("reading diagnostics baseline %s: %v", filename, err))
+		}
+	}
+
+	fileLines := make(map[protocol.DocumentURI][]string)
+	generated := make(map[protocol.DocumentURI]bool)
+	var result []*cache.Diagnostic
+	for _, diag := range diags {
+		analyzer := string(diag.Source)
+		relPath := baseline.RelPath(folder, diag.URI.Path())
+		if opts.AnalysisExcluded(analyzer, relPath) {
+			continue
+		}
+		if a := settings.DefaultAnalyzers[analyzer]; a != nil && a.Style() && !opts.StyleDiagnosticsInGeneratedFiles {
+			isGenerated, ok := generated[diag.URI]
+			if !ok {
+				isGenerated = IsGenerated(ctx, snapshot, diag.URI)
+				generated[diag.URI] = isGenerated
+			}
+			if isGenerated {
+				continue
+			}
+		}
+		if len(suppressed) > 0 {
+			lines, ok := fileLines[diag.URI]
+			if !ok {
+				fh, err := snapshot.ReadFile(ctx, diag.URI)
+				if err != nil {
+					return nil, err
+				}
+				content, err := fh.Content()
+				if err != nil {
+					return nil, err
+				}
+				lines = strings.Split(string(content), "\n")
+				fileLines[diag.URI] = lines
+			}
+			var line string
+			if l := int(diag.Range.Start.Line); l < len(lines) {
+				line = lines[l]
+			}
+			if suppressed[baseline.Fingerprint(relPath, diag.Source.Path(), diag.Code, diag.Message, line)] {
+				continue
+			}
+		}
+		if severity := opts.AnalysisSeverity(analyzer); severity != 0 && severity != diag.Severity {
+			copy := *diag
+			copy.Severity = severity
+			diag = &copy
+		}
+		result = append(result, diag)
+	}
+	return result, nil
+}
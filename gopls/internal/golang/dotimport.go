@@ -0,0 +1,80 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the code actions that convert a dot-import to an
+// ordinary import and, for the packages of the dotImportPaths
+// setting, an ordinary import to a dot-import.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/analysis/dotimport"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/gopls/internal/util/slices"
+)
+
+// getDotImportCodeActions returns the refactor.rewrite actions for
+// the import spec at [start, end): one that qualifies the identifiers
+// imported by a dot-import, or one that converts the import of a
+// package of the dotImportPaths setting to a dot-import.
+func getDotImportCodeActions(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos, options *settings.Options) ([]protocol.CodeAction, error) {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	var spec *ast.ImportSpec
+	for _, n := range path {
+		if n, ok := n.(*ast.ImportSpec); ok {
+			spec = n
+			break
+		}
+	}
+	if spec == nil {
+		return nil, nil
+	}
+	importPath, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return nil, nil
+	}
+
+	var (
+		title string
+		edits []analysis.TextEdit
+	)
+	switch {
+	case spec.Name != nil && spec.Name.Name == ".":
+		title = fmt.Sprintf("Qualify identifiers imported from %q", importPath)
+		edits, err = dotimport.Qualify(pgf.File, pkg.Types(), pkg.TypesInfo(), spec)
+	case spec.Name != nil && spec.Name.Name == "_":
+		return nil, nil
+	case slices.Contains(options.DotImportPaths, importPath):
+		title = "Convert to dot-import"
+		edits, err = dotimport.Dot(pgf.File, pkg.Types(), pkg.TypesInfo(), spec)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, nil // not applicable
+	}
+	changes, err := suggestedFixToDocumentChange(ctx, snapshot, pkg.FileSet(), &analysis.SuggestedFix{
+		Message:   title,
+		TextEdits: edits,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeAction{{
+		Title: title,
+		Kind:  protocol.RefactorRewrite,
+		Edit:  protocol.NewWorkspaceEdit(changes...),
+	}}, nil
+}
@@ -0,0 +1,921 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the code actions that help to handle errors:
+// wrapping a returned error with fmt.Errorf, checking an error that a
+// statement discards, and adding an error result to a function that
+// discards an error, so that it can return the error to its callers.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/types/typeutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/analysisinternal"
+	"golang.org/x/tools/internal/diff"
+)
+
+// errorType is the predeclared error type.
+var errorType = types.Universe.Lookup("error").Type()
+
+// getErrorCodeActions returns the commands of the refactor.rewrite
+// actions that handle the error at [start, end): the error returned
+// by a return statement, or the error discarded by a call statement.
+func getErrorCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Range, start, end token.Pos, options *settings.Options) ([]protocol.Command, error) {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	info := pkg.TypesInfo()
+
+	var (
+		title string
+		fix   string
+	)
+	if returnedError(path, info) != nil {
+		title, fix = "Wrap error with fmt.Errorf", fixWrapError
+	} else if ignored := findIgnoredError(path, info); ignored != nil {
+		if _, sig := enclosingFunc(ignored.enclosing, info); sig != nil && endsWithError(sig.Results()) {
+			title, fix = "Handle error", fixHandleError
+			if name := calleeName(ignored.call); name != "" {
+				title = fmt.Sprintf("Handle error returned by %s", name)
+			}
+		} else if decl := canAddErrorResult(pgf, ignored, info); decl != nil {
+			title, fix = fmt.Sprintf("Add error result to %s", decl.Name.Name), fixAddErrorResult
+		}
+	}
+	if fix == "" {
+		return nil, nil
+	}
+	cmd, err := command.NewApplyFixCommand(title, command.ApplyFixArgs{
+		Fix:          fix,
+		URI:          pgf.URI,
+		Range:        rng,
+		ResolveEdits: supportsResolveEdits(options),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.Command{cmd}, nil
+}
+
+// wrapError is a fixer that wraps the error returned by the return
+// statement at [start, end) with fmt.Errorf, using the %w verb.
+func wrapError(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	info := pkg.TypesInfo()
+	expr := returnedError(path, info)
+	if expr == nil {
+		return nil, nil, fmt.Errorf("no returned error to wrap")
+	}
+	errorf, edits, err := fmtErrorf(snapshot, pkg, pgf, expr.Pos())
+	if err != nil {
+		return nil, nil, err
+	}
+	text, err := nodeText(pgf, expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	msg := errorContext(path, info, expr)
+	edits = append(edits, analysis.TextEdit{
+		Pos:     expr.Pos(),
+		End:     expr.End(),
+		NewText: []byte(fmt.Sprintf("%s(%q, %s)", errorf, msg+": %w", text)),
+	})
+	return pkg.FileSet(), &analysis.SuggestedFix{TextEdits: edits}, nil
+}
+
+// handleError is a fixer that checks the error discarded by the
+// statement at [start, end), returning it from the enclosing
+// function, wrapped with fmt.Errorf, if it is not nil.
+func handleError(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	info := pkg.TypesInfo()
+	ignored := findIgnoredError(path, info)
+	if ignored == nil {
+		return nil, nil, fmt.Errorf("no discarded error")
+	}
+	_, sig := enclosingFunc(ignored.enclosing, info)
+	if sig == nil || !endsWithError(sig.Results()) {
+		return nil, nil, fmt.Errorf("the enclosing function does not return an error")
+	}
+	zeros, err := zeroValues(pkg.FileSet(), pgf.File, pkg.Types(), sig.Results(), sig.Results().Len()-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	errorf, edits, err := fmtErrorf(snapshot, pkg, pgf, ignored.stmt.Pos())
+	if err != nil {
+		return nil, nil, err
+	}
+	msg := calleeName(ignored.call)
+	if msg == "" {
+		msg = errorContext(path, info, nil)
+	}
+	onError := "return " + strings.Join(append(zeros, fmt.Sprintf("%s(%q, err)", errorf, msg+": %w")), ", ")
+	checks, err := checkErrorEdits(pkg.Types(), info, pgf, ignored.list, ignored.stmt, ignored.call, ignored.results, onError)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pkg.FileSet(), &analysis.SuggestedFix{TextEdits: append(edits, checks...)}, nil
+}
+
+// selectErrorMessage selects the message of the error that a fix
+// wraps with fmt.Errorf, so that typing replaces it.
+func selectErrorMessage(edits []diff.Edit, _, _ int) (int, int, int, bool) {
+	for i, edit := range edits {
+		if j := strings.Index(edit.New, `Errorf("`); j >= 0 {
+			from := j + len(`Errorf("`)
+			if n := strings.Index(edit.New[from:], `: %w"`); n >= 0 {
+				return i, from, from + n, true
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// AddErrorResult adds an error result to the function that encloses
+// the statement at the specified range, which discards the error
+// result of a call, so that the function returns that error if it is
+// not nil, and nil otherwise. Every call to the function in the
+// workspace is updated: callers that return an error return the new
+// error too, tests report it with t.Fatal, and other callers discard
+// it, as the function did before.
+func AddErrorResult(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]protocol.DocumentChange, error) {
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSignatureErrors(pkg); err != nil {
+		return nil, err
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	info := pkg.TypesInfo()
+	ignored := findIgnoredError(path, info)
+	if ignored == nil {
+		return nil, fmt.Errorf("no discarded error")
+	}
+	decl := canAddErrorResult(pgf, ignored, info)
+	if decl == nil {
+		return nil, fmt.Errorf("cannot add an error result to the enclosing function")
+	}
+	sig := info.Defs[decl.Name].Type().(*types.Signature)
+	results := sig.Results()
+	named := results.Len() > 0 && results.At(0).Name() != ""
+	if named {
+		if info.Scopes[decl.Type].Lookup("err") != nil {
+			return nil, fmt.Errorf("err is already declared in %s", decl.Name.Name)
+		}
+		if id := shadowedRef(info, decl, "err"); id != nil {
+			return nil, fmt.Errorf("the new result err would shadow the err referenced at %s",
+				safetoken.StartPosition(pkg.FileSet(), id.Pos()))
+		}
+	}
+
+	// Edit the declaration.
+	var edits []analysis.TextEdit
+	switch fields := decl.Type.Results; {
+	case fields == nil:
+		edits = append(edits, analysis.TextEdit{Pos: decl.Type.Params.End(), End: decl.Type.Params.End(), NewText: []byte(" error")})
+	case !fields.Opening.IsValid():
+		text, err := nodeText(pgf, fields.List[0].Type)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, analysis.TextEdit{Pos: fields.Pos(), End: fields.End(), NewText: []byte("(" + text + ", error)")})
+	case named:
+		edits = append(edits, analysis.TextEdit{Pos: fields.Closing, End: fields.Closing, NewText: []byte(", err error")})
+	default:
+		edits = append(edits, analysis.TextEdit{Pos: fields.Closing, End: fields.Closing, NewText: []byte(", error")})
+	}
+	var returnErr error
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			switch {
+			case len(n.Results) == 0 && named:
+				// A bare return returns the new result err, which is nil.
+			case len(n.Results) == 0:
+				pos := n.Return + token.Pos(len("return"))
+				edits = append(edits, analysis.TextEdit{Pos: pos, End: pos, NewText: []byte(" nil")})
+			case len(n.Results) == results.Len():
+				edits = append(edits, analysis.TextEdit{Pos: n.End(), End: n.End(), NewText: []byte(", nil")})
+			default:
+				if returnErr == nil {
+					returnErr = fmt.Errorf("cannot add an error to the results of the call at %s",
+						safetoken.StartPosition(pkg.FileSet(), n.Pos()))
+				}
+			}
+		}
+		return true
+	})
+	if returnErr != nil {
+		return nil, returnErr
+	}
+	zeros, err := zeroValues(pkg.FileSet(), pgf.File, pkg.Types(), results, results.Len())
+	if err != nil {
+		return nil, err
+	}
+	onError := "return " + strings.Join(append(zeros, "err"), ", ")
+	checks, err := checkErrorEdits(pkg.Types(), info, pgf, ignored.list, ignored.stmt, ignored.call, ignored.results, onError)
+	if err != nil {
+		return nil, err
+	}
+	edits = append(edits, checks...)
+	if results.Len() == 0 {
+		// The function may fall off the end of its body.
+		list := decl.Body.List
+		if _, ok := list[len(list)-1].(*ast.ReturnStmt); !ok {
+			last := list[len(list)-1]
+			if safetoken.Line(pgf.Tok, last.End()) == safetoken.Line(pgf.Tok, decl.Body.Rbrace) {
+				edits = append(edits, analysis.TextEdit{Pos: last.End(), End: last.End(), NewText: []byte("; return nil")})
+			} else {
+				indent, err := calculateIndentation(pgf.Src, pgf.Tok, decl)
+				if err != nil {
+					return nil, err
+				}
+				pos := pgf.Tok.LineStart(safetoken.Line(pgf.Tok, decl.Body.Rbrace))
+				edits = append(edits, analysis.TextEdit{Pos: pos, End: pos, NewText: []byte(indent + "\treturn nil\n")})
+			}
+		}
+	}
+	files := newEditedFiles()
+	if err := files.add(pgf, edits); err != nil {
+		return nil, err
+	}
+
+	// Update the callers.
+	funcPos, err := pgf.Mapper.PosPosition(pgf.Tok, decl.Name.Pos())
+	if err != nil {
+		return nil, err
+	}
+	refs, err := References(ctx, snapshot, fh, funcPos, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("finding references to update: %v", err)
+	}
+	refPkgs, err := typeCheckReferences(ctx, snapshot, refs)
+	if err != nil {
+		return nil, err
+	}
+	declOffset, err := safetoken.Offset(pgf.Tok, decl.Name.Pos())
+	if err != nil {
+		return nil, err
+	}
+	var conflicts rewriteConflicts
+	for _, ref := range refs {
+		refPkg := refPkgs[ref.URI]
+		refPgf, err := refPkg.File(ref.URI)
+		if err != nil {
+			return nil, err
+		}
+		edits, msg, err := addErrorResultToCall(refPkg, refPgf, ref.Range, pgf.URI, declOffset, results.Len())
+		if err != nil {
+			return nil, err
+		}
+		if msg != "" {
+			conflicts.add(ref, msg)
+			continue
+		}
+		if err := files.add(refPgf, edits); err != nil {
+			return nil, err
+		}
+	}
+	if len(conflicts) > 0 {
+		conflicts.sort()
+		return nil, conflicts
+	}
+	return files.changes(ctx, snapshot)
+}
+
+// addErrorResultToCall returns the edits that update the call of the
+// function at rng in the file refPgf, after the addition of an error
+// result to the function, which has nresults other results and is
+// declared at declOffset in the file declURI. If the call cannot be
+// updated, it returns a message that explains why.
+func addErrorResultToCall(refPkg *cache.Package, refPgf *parsego.File, rng protocol.Range, declURI protocol.DocumentURI, declOffset, nresults int) ([]analysis.TextEdit, string, error) {
+	start, end, err := refPgf.RangePos(rng)
+	if err != nil {
+		return nil, "", err
+	}
+	path, _ := astutil.PathEnclosingInterval(refPgf.File, start, end)
+	id, _ := path[0].(*ast.Ident)
+	var fun ast.Expr = id
+	i := 1
+	if sel, ok := path[1].(*ast.SelectorExpr); ok && sel.Sel == id {
+		fun, i = sel, 2
+	}
+	call, _ := path[i].(*ast.CallExpr)
+	if id == nil || call == nil || call.Fun != fun {
+		return nil, "found non-call function reference", nil
+	}
+	var stmt ast.Stmt
+	switch parent := path[i+1].(type) {
+	case *ast.GoStmt, *ast.DeferStmt:
+		return nil, "", nil // the results are discarded
+	case *ast.ExprStmt:
+		stmt = parent
+	case *ast.AssignStmt:
+		if len(parent.Rhs) == 1 && len(parent.Lhs) == nresults {
+			stmt = parent
+		}
+	}
+	var list []ast.Stmt
+	if stmt != nil {
+		list = stmtList(path[i+2])
+	}
+	if list == nil {
+		return nil, "cannot handle the error of a call in an expression", nil
+	}
+
+	// Return the error from functions that return errors, and from
+	// the updated function itself; report it from tests; and discard
+	// it elsewhere.
+	info := refPkg.TypesInfo()
+	var onError string
+	encl, sig := enclosingFunc(path[i+1:], info)
+	if sig == nil {
+		return nil, "call outside a function", nil
+	}
+	results := sig.Results()
+	if decl, ok := encl.(*ast.FuncDecl); ok && refPgf.URI == declURI {
+		if offset, err := safetoken.Offset(refPgf.Tok, decl.Name.Pos()); err == nil && offset == declOffset {
+			results = types.NewTuple(append(tupleVars(results), types.NewVar(token.NoPos, nil, "", errorType))...)
+		}
+	}
+	if endsWithError(results) {
+		zeros, err := zeroValues(refPkg.FileSet(), refPgf.File, refPkg.Types(), results, results.Len()-1)
+		if err != nil {
+			return nil, err.Error(), nil
+		}
+		onError = "return " + strings.Join(append(zeros, "err"), ", ")
+	} else if t := testingParam(sig); t != "" {
+		onError = t + ".Fatal(err)"
+	}
+	if onError == "" {
+		if assign, ok := stmt.(*ast.AssignStmt); ok {
+			last := assign.Lhs[len(assign.Lhs)-1]
+			return []analysis.TextEdit{{Pos: last.End(), End: last.End(), NewText: []byte(", _")}}, "", nil
+		}
+		return nil, "", nil // the call statement discards all results
+	}
+	edits, err := checkErrorEdits(refPkg.Types(), info, refPgf, list, stmt, call, nresults+1, onError)
+	if err != nil {
+		return nil, err.Error(), nil
+	}
+	return edits, "", nil
+}
+
+// typeCheckReferences type-checks the narrowest package of the file
+// of each reference, returning the packages by file.
+func typeCheckReferences(ctx context.Context, snapshot *cache.Snapshot, refs []protocol.Location) (map[protocol.DocumentURI]*cache.Package, error) {
+	ids := make(map[protocol.DocumentURI]PackageID)
+	var pkgIDs []PackageID
+	for _, ref := range refs {
+		if _, ok := ids[ref.URI]; ok {
+			continue
+		}
+		mp, err := NarrowestMetadataForFile(ctx, snapshot, ref.URI)
+		if err != nil {
+			return nil, fmt.Errorf("finding ref metadata: %v", err)
+		}
+		ids[ref.URI] = mp.ID
+		pkgIDs = append(pkgIDs, mp.ID)
+	}
+	pkgs, err := snapshot.TypeCheck(ctx, pkgIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("type checking reference packages: %v", err)
+	}
+	byID := make(map[PackageID]*cache.Package)
+	for _, pkg := range pkgs {
+		byID[pkg.Metadata().ID] = pkg
+	}
+	result := make(map[protocol.DocumentURI]*cache.Package)
+	for uri, id := range ids {
+		result[uri] = byID[id]
+	}
+	return result, nil
+}
+
+// editedFiles accumulates the edits of a refactoring to several files.
+type editedFiles struct {
+	pgfs  map[protocol.DocumentURI]*parsego.File
+	edits map[protocol.DocumentURI][]diff.Edit
+}
+
+func newEditedFiles() *editedFiles {
+	return &editedFiles{
+		pgfs:  make(map[protocol.DocumentURI]*parsego.File),
+		edits: make(map[protocol.DocumentURI][]diff.Edit),
+	}
+}
+
+// add records edits of the file pgf. Different parses of the same
+// file may contribute edits.
+func (f *editedFiles) add(pgf *parsego.File, edits []analysis.TextEdit) error {
+	for _, edit := range edits {
+		end := edit.End
+		if !end.IsValid() {
+			end = edit.Pos
+		}
+		startOffset, endOffset, err := safetoken.Offsets(pgf.Tok, edit.Pos, end)
+		if err != nil {
+			return err
+		}
+		f.edits[pgf.URI] = append(f.edits[pgf.URI], diff.Edit{Start: startOffset, End: endOffset, New: string(edit.NewText)})
+	}
+	if _, ok := f.pgfs[pgf.URI]; !ok {
+		f.pgfs[pgf.URI] = pgf
+	}
+	return nil
+}
+
+// changes returns the document changes of the edited files, in order
+// of URI.
+func (f *editedFiles) changes(ctx context.Context, snapshot *cache.Snapshot) ([]protocol.DocumentChange, error) {
+	var uris []protocol.DocumentURI
+	for uri := range f.edits {
+		uris = append(uris, uri)
+	}
+	sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+	var changes []protocol.DocumentChange
+	for _, uri := range uris {
+		edits := f.edits[uri]
+		if len(edits) == 0 {
+			continue
+		}
+		diff.SortEdits(edits)
+		for i := 1; i < len(edits); i++ {
+			if edits[i].Start < edits[i-1].End {
+				return nil, fmt.Errorf("overlapping edits to %s", uri.Path())
+			}
+		}
+		fh, err := snapshot.ReadFile(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		textEdits, err := protocol.EditsFromDiffEdits(f.pgfs[uri].Mapper, edits)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, protocol.DocumentChangeEdit(fh, textEdits))
+	}
+	return changes, nil
+}
+
+// returnedError returns the error result of the return statement of
+// path, if it is a variable that is checked not to be nil.
+func returnedError(path []ast.Node, info *types.Info) ast.Expr {
+	for i, n := range path {
+		switch n.(type) {
+		case *ast.FuncLit:
+			return nil
+		case ast.Stmt:
+			ret, ok := n.(*ast.ReturnStmt)
+			if !ok {
+				return nil
+			}
+			_, sig := enclosingFunc(path[i+1:], info)
+			if sig == nil || !endsWithError(sig.Results()) || len(ret.Results) != sig.Results().Len() {
+				return nil
+			}
+			// Wrapping a nil error would return a non-nil one, so
+			// the error must be a variable known not to be nil.
+			id, ok := ret.Results[len(ret.Results)-1].(*ast.Ident)
+			if !ok {
+				return nil
+			}
+			v, ok := info.Uses[id].(*types.Var)
+			if !ok || !types.Identical(v.Type(), errorType) || !checkedNotNil(path[i+1:], info, v) {
+				return nil
+			}
+			return id
+		}
+	}
+	return nil
+}
+
+// checkedNotNil reports whether path is within the body of an if
+// statement whose condition is v != nil, in the same function.
+func checkedNotNil(path []ast.Node, info *types.Info, v *types.Var) bool {
+	for i, n := range path {
+		switch n := n.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return false
+		case *ast.IfStmt:
+			if i == 0 || path[i-1] != n.Body {
+				continue
+			}
+			if cond, ok := astutil.Unparen(n.Cond).(*ast.BinaryExpr); ok && cond.Op == token.NEQ {
+				for _, pair := range [][2]ast.Expr{{cond.X, cond.Y}, {cond.Y, cond.X}} {
+					x, ok := astutil.Unparen(pair[0]).(*ast.Ident)
+					if ok && info.Uses[x] == v && info.Types[pair[1]].IsNil() {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// An ignoredError is a statement that discards the error result of a
+// call, such as f() or x, _ := f().
+type ignoredError struct {
+	stmt      ast.Stmt // an *ast.ExprStmt or *ast.AssignStmt
+	call      *ast.CallExpr
+	results   int        // number of results of the call
+	list      []ast.Stmt // statement list that contains stmt
+	enclosing []ast.Node // path enclosing stmt
+}
+
+// findIgnoredError returns the innermost statement of path if it
+// discards the error result of a call, or nil.
+func findIgnoredError(path []ast.Node, info *types.Info) *ignoredError {
+	for i, n := range path {
+		stmt, ok := n.(ast.Stmt)
+		if !ok {
+			continue
+		}
+		var call *ast.CallExpr
+		switch stmt := stmt.(type) {
+		case *ast.ExprStmt:
+			call, _ = astutil.Unparen(stmt.X).(*ast.CallExpr)
+		case *ast.AssignStmt:
+			if len(stmt.Rhs) == 1 && (stmt.Tok == token.DEFINE || stmt.Tok == token.ASSIGN) && isBlank(stmt.Lhs[len(stmt.Lhs)-1]) {
+				call, _ = astutil.Unparen(stmt.Rhs[0]).(*ast.CallExpr)
+			}
+		}
+		if call == nil {
+			return nil
+		}
+		if tv, ok := info.Types[call.Fun]; !ok || tv.IsType() || tv.IsBuiltin() {
+			return nil
+		}
+		sig, ok := info.TypeOf(call.Fun).Underlying().(*types.Signature)
+		if !ok || !endsWithError(sig.Results()) || errorConventionallyIgnored(info, call) {
+			return nil
+		}
+		list := stmtList(path[i+1])
+		if list == nil {
+			return nil
+		}
+		return &ignoredError{
+			stmt:      stmt,
+			call:      call,
+			results:   sig.Results().Len(),
+			list:      list,
+			enclosing: path[i+1:],
+		}
+	}
+	return nil
+}
+
+// errorConventionallyIgnored reports whether call is a call of a
+// function whose error result is conventionally ignored, either
+// because it prints to standard output, or because it never fails.
+func errorConventionallyIgnored(info *types.Info, call *ast.CallExpr) bool {
+	fn := typeutil.StaticCallee(info, call)
+	if fn == nil || fn.Pkg() == nil {
+		return false
+	}
+	switch fn.Pkg().Path() {
+	case "fmt":
+		return strings.HasPrefix(fn.Name(), "Print")
+	case "bytes", "strings":
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			if ptr, ok := recv.Type().(*types.Pointer); ok {
+				if named, ok := ptr.Elem().(*types.Named); ok {
+					name := named.Obj().Name()
+					return (name == "Buffer" || name == "Builder") && strings.HasPrefix(fn.Name(), "Write")
+				}
+			}
+		}
+	}
+	return false
+}
+
+// canAddErrorResult returns the declaration of the function that
+// directly encloses the ignored error, if an error result may be added
+// to it: it is an ordinary function, not a method, a test, or one
+// whose results end with an error.
+func canAddErrorResult(pgf *parsego.File, ignored *ignoredError, info *types.Info) *ast.FuncDecl {
+	encl, sig := enclosingFunc(ignored.enclosing, info)
+	decl, ok := encl.(*ast.FuncDecl)
+	if !ok || decl.Recv != nil || decl.Body == nil || endsWithError(sig.Results()) {
+		return nil
+	}
+	switch name := decl.Name.Name; {
+	case name == "main" || name == "init" || name == "_":
+		return nil
+	case strings.HasSuffix(pgf.URI.Path(), "_test.go"):
+		for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+			if strings.HasPrefix(name, prefix) {
+				return nil
+			}
+		}
+	}
+	return decl
+}
+
+// checkErrorEdits returns the edits that check the error result of
+// the call of stmt, an element of list, which has nresults results
+// and either discards all of them, or assigns all of them but the
+// error, which it discards or does not receive. onError is the
+// statement to execute if the error is not nil.
+func checkErrorEdits(pkg *types.Package, info *types.Info, pgf *parsego.File, list []ast.Stmt, stmt ast.Stmt, call *ast.CallExpr, nresults int, onError string) ([]analysis.TextEdit, error) {
+	indent, err := calculateIndentation(pgf.Src, pgf.Tok, stmt)
+	if err != nil {
+		return nil, err
+	}
+	callText, err := nodeText(pgf, call)
+	if err != nil {
+		return nil, err
+	}
+	cond := fmt.Sprintf("err != nil {\n%s\t%s\n%s}", indent, onError, indent)
+
+	assign, _ := stmt.(*ast.AssignStmt)
+	if assign == nil || allBlank(assign.Lhs) {
+		// Check the error in the scope of an if statement.
+		names := make([]string, nresults)
+		for i := range names {
+			names[i] = "_"
+		}
+		names[nresults-1] = "err"
+		return []analysis.TextEdit{{
+			Pos:     stmt.Pos(),
+			End:     stmt.End(),
+			NewText: []byte(fmt.Sprintf("if %s := %s; %s", strings.Join(names, ", "), callText, cond)),
+		}}, nil
+	}
+	if err := checkErrVar(pkg, info, list, assign); err != nil {
+		return nil, err
+	}
+	last := assign.Lhs[len(assign.Lhs)-1]
+	edit := analysis.TextEdit{Pos: last.End(), End: last.End(), NewText: []byte(", err")}
+	if len(assign.Lhs) == nresults {
+		edit = analysis.TextEdit{Pos: last.Pos(), End: last.End(), NewText: []byte("err")}
+	}
+	return []analysis.TextEdit{
+		edit,
+		{Pos: stmt.End(), End: stmt.End(), NewText: []byte("\n" + indent + "if " + cond)},
+	}, nil
+}
+
+// checkErrVar reports an error unless the assignment, an element of
+// list, may declare or assign a variable err of type error without
+// changing the meaning of other references to err.
+func checkErrVar(pkg *types.Package, info *types.Info, list []ast.Stmt, assign *ast.AssignStmt) error {
+	scope := pkg.Scope().Innermost(assign.Pos())
+	if scope == nil {
+		return fmt.Errorf("no scope for the assignment")
+	}
+	_, obj := scope.LookupParent("err", assign.Pos())
+	if assign.Tok == token.ASSIGN {
+		if v, ok := obj.(*types.Var); !ok || !types.Identical(v.Type(), errorType) {
+			return fmt.Errorf("no variable err of type error is in scope")
+		}
+		return nil
+	}
+	if obj != nil && obj.Parent() == scope {
+		if !types.Identical(obj.Type(), errorType) {
+			return fmt.Errorf("err is already declared with type %s", obj.Type())
+		}
+		return nil // the assignment reuses err
+	}
+
+	// The assignment declares a new variable err, which must not be
+	// declared again in the same block, nor shadow a variable err used
+	// by the rest of the block.
+	after := false
+	for _, stmt := range list {
+		if stmt == assign {
+			after = true
+			continue
+		}
+		if !after {
+			continue
+		}
+		var conflict error
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Name == "err" && conflict == nil {
+				if obj != nil && info.Uses[id] == obj {
+					conflict = fmt.Errorf("a new variable err would shadow the err used later in the block")
+				} else if def := info.Defs[id]; def != nil && def.Parent() == scope && !redeclares(info, stmt, id) {
+					conflict = fmt.Errorf("err is declared again in the same block")
+				}
+			}
+			return conflict == nil
+		})
+		if conflict != nil {
+			return conflict
+		}
+	}
+	return nil
+}
+
+// redeclares reports whether stmt is a short variable declaration
+// that may redeclare the variable err that it declares as id, because
+// it declares other variables, and err has type error.
+func redeclares(info *types.Info, stmt ast.Stmt, id *ast.Ident) bool {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE || !types.Identical(info.Defs[id].Type(), errorType) {
+		return false
+	}
+	for _, lhs := range assign.Lhs {
+		if lhs, ok := lhs.(*ast.Ident); ok && lhs != id && info.Defs[lhs] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// enclosingFunc returns the innermost function declaration or
+// literal of path, and its signature.
+func enclosingFunc(path []ast.Node, info *types.Info) (ast.Node, *types.Signature) {
+	for _, n := range path {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if fn, ok := info.Defs[n.Name].(*types.Func); ok {
+				return n, fn.Type().(*types.Signature)
+			}
+			return nil, nil
+		case *ast.FuncLit:
+			if sig, ok := info.TypeOf(n).(*types.Signature); ok {
+				return n, sig
+			}
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+// endsWithError reports whether the type of the last variable of the
+// tuple is error.
+func endsWithError(tuple *types.Tuple) bool {
+	return tuple.Len() > 0 && types.Identical(tuple.At(tuple.Len()-1).Type(), errorType)
+}
+
+// tupleVars returns the variables of the tuple.
+func tupleVars(tuple *types.Tuple) []*types.Var {
+	vars := make([]*types.Var, tuple.Len())
+	for i := range vars {
+		vars[i] = tuple.At(i)
+	}
+	return vars
+}
+
+// stmtList returns the statement list of a block or clause, or nil.
+func stmtList(n ast.Node) []ast.Stmt {
+	switch n := n.(type) {
+	case *ast.BlockStmt:
+		return n.List
+	case *ast.CaseClause:
+		return n.Body
+	case *ast.CommClause:
+		return n.Body
+	}
+	return nil
+}
+
+func isBlank(e ast.Expr) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == "_"
+}
+
+func allBlank(exprs []ast.Expr) bool {
+	for _, e := range exprs {
+		if !isBlank(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// calleeName returns the name of the function called by call, such
+// as "f" or "os.Open", or "" if it is not a simple name.
+func calleeName(call *ast.CallExpr) string {
+	switch fun := astutil.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		if x, ok := fun.X.(*ast.Ident); ok {
+			return x.Name + "." + fun.Sel.Name
+		}
+		return fun.Sel.Name
+	}
+	return ""
+}
+
+// errorContext returns a description of the operation that produced
+// the error expr of the function enclosing path: the function whose
+// call produced it, if known, or else the enclosing function.
+func errorContext(path []ast.Node, info *types.Info, expr ast.Expr) string {
+	switch e := astutil.Unparen(expr).(type) {
+	case *ast.CallExpr:
+		if name := calleeName(e); name != "" {
+			return name
+		}
+	case *ast.Ident:
+		// Find the last call assigned to the variable before expr.
+		if obj := info.Uses[e]; obj != nil {
+			if encl, _ := enclosingFunc(path, info); encl != nil {
+				var name string
+				ast.Inspect(encl, func(n ast.Node) bool {
+					if assign, ok := n.(*ast.AssignStmt); ok && assign.End() < e.Pos() && len(assign.Rhs) == 1 {
+						if call, ok := astutil.Unparen(assign.Rhs[0]).(*ast.CallExpr); ok {
+							for _, lhs := range assign.Lhs {
+								if id, ok := lhs.(*ast.Ident); ok && info.ObjectOf(id) == obj {
+									if callee := calleeName(call); callee != "" {
+										name = callee
+									}
+								}
+							}
+						}
+					}
+					return true
+				})
+				if name != "" {
+					return name
+				}
+			}
+		}
+	}
+	for _, n := range path {
+		if decl, ok := n.(*ast.FuncDecl); ok {
+			return decl.Name.Name
+		}
+	}
+	return "error"
+}
+
+// fmtErrorf returns the name by which the file pgf may refer to
+// fmt.Errorf at pos, and the edits that import fmt, if necessary.
+func fmtErrorf(snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, pos token.Pos) (string, []analysis.TextEdit, error) {
+	adder := newImportAdder(snapshot, pkg, pgf)
+	errorf, err := adder.member(pos, "fmt", "Errorf")
+	if err != nil {
+		return "", nil, err
+	}
+	edits, err := adder.edits(snapshot, pgf)
+	if err != nil {
+		return "", nil, err
+	}
+	return errorf, edits, nil
+}
+
+// zeroValues returns the source of the zero values of the types of
+// the first n variables of tuple, in the specified file.
+func zeroValues(fset *token.FileSet, file *ast.File, pkg *types.Package, tuple *types.Tuple, n int) ([]string, error) {
+	var zeros []string
+	for i := 0; i < n; i++ {
+		typ := tuple.At(i).Type()
+		if basic, ok := typ.Underlying().(*types.Basic); ok && basic.Kind() == types.UnsafePointer {
+			zeros = append(zeros, "nil")
+			continue
+		}
+		zero := analysisinternal.ZeroValue(file, pkg, typ)
+		if zero == nil {
+			return nil, fmt.Errorf("cannot express the zero value of %s", types.TypeString(typ, types.RelativeTo(pkg)))
+		}
+		zeros = append(zeros, FormatNode(fset, zero))
+	}
+	return zeros, nil
+}
+
+// testingParam returns the name of the first parameter of type
+// *testing.T, *testing.B, or *testing.F of the signature, or "".
+func testingParam(sig *types.Signature) string {
+	for i := 0; i < sig.Params().Len(); i++ {
+		param := sig.Params().At(i)
+		if ptr, ok := param.Type().(*types.Pointer); ok {
+			if named, ok := ptr.Elem().(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "testing" {
+				switch named.Obj().Name() {
+				case "T", "B", "F":
+					if param.Name() != "" && param.Name() != "_" {
+						return param.Name()
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
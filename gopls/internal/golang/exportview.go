@@ -0,0 +1,298 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file synthesizes a view of a package from its type information,
+// for navigating into packages whose source is not available.
+
+import (
+	"bytes"
+	"fmt"
+	"go/constant"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/aliases"
+)
+
+// exportDataLocation returns the location of the declaration of obj in
+// a Go file synthesized by [exportDataView] from the type information
+// of its package. It is used when the source of the package is not
+// available, such as when it is known only from export data.
+//
+// The file is written to a temporary directory named after a hash of
+// its content, so that each view is written once.
+func exportDataLocation(obj types.Object) (protocol.Location, error) {
+	obj = origin(obj)
+	pkg := obj.Pkg()
+	if pkg == nil {
+		return protocol.Location{}, fmt.Errorf("%s has no package", obj.Name())
+	}
+	src, offsets := exportDataView(pkg)
+	offset, ok := offsets[obj]
+	if !ok {
+		return protocol.Location{}, fmt.Errorf("%s is not declared at package level in %s", obj.Name(), pkg.Path())
+	}
+
+	dir := filepath.Join(os.TempDir(), "gopls-export-data", file.HashOf(src).String()[:16])
+	filename := filepath.Join(dir, pkg.Name()+".go")
+	if _, err := os.Stat(filename); err != nil {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return protocol.Location{}, err
+		}
+		if err := os.WriteFile(filename, src, 0666); err != nil {
+			return protocol.Location{}, err
+		}
+	}
+	m := protocol.NewMapper(protocol.URIFromPath(filename), src)
+	return m.OffsetLocation(offset, offset+len(obj.Name()))
+}
+
+// exportDataView returns the source of a Go file that declares the
+// package-level constants, variables, types, methods, and functions of
+// pkg, as they appear in its type information, like the output of
+// godex. Functions have no bodies, and there are no comments other
+// than a header. It also returns the offset of the name of each
+// declared object, including struct fields and interface methods.
+//
+// The view is heuristic: imported packages with the same name are not
+// disambiguated, and floating-point constants may be rounded.
+func exportDataView(pkg *types.Package) ([]byte, map[types.Object]int) {
+	var (
+		body    bytes.Buffer
+		offsets = make(map[types.Object]int)
+		imports = make(map[*types.Package]bool)
+	)
+	qual := func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		imports[p] = true
+		return p.Name()
+	}
+	name := func(obj types.Object) {
+		offsets[obj] = body.Len()
+		body.WriteString(obj.Name())
+	}
+	typ := func(t types.Type) {
+		types.WriteType(&body, t, qual)
+	}
+	sig := func(fn *types.Func) {
+		types.WriteSignature(&body, fn.Type().(*types.Signature), qual)
+	}
+	tparams := func(list *types.TypeParamList) {
+		if list.Len() == 0 {
+			return
+		}
+		body.WriteByte('[')
+		for i := 0; i < list.Len(); i++ {
+			if i > 0 {
+				body.WriteString(", ")
+			}
+			tparam := list.At(i)
+			body.WriteString(tparam.Obj().Name() + " ")
+			typ(tparam.Constraint())
+		}
+		body.WriteByte(']')
+	}
+
+	// Group the objects by kind, each in order of name.
+	var consts, vars, typenames, funcs []types.Object
+	scope := pkg.Scope()
+	for _, n := range scope.Names() {
+		switch obj := scope.Lookup(n); obj.(type) {
+		case *types.Const:
+			consts = append(consts, obj)
+		case *types.Var:
+			vars = append(vars, obj)
+		case *types.TypeName:
+			typenames = append(typenames, obj)
+		case *types.Func:
+			funcs = append(funcs, obj)
+		}
+	}
+
+	if len(consts) > 0 {
+		body.WriteString("const (\n")
+		for _, obj := range consts {
+			obj := obj.(*types.Const)
+			body.WriteByte('\t')
+			name(obj)
+			if basic, ok := obj.Type().(*types.Basic); !ok || basic.Info()&types.IsUntyped == 0 {
+				body.WriteByte(' ')
+				typ(obj.Type())
+			}
+			body.WriteString(" = ")
+			switch val := obj.Val(); val.Kind() {
+			case constant.Float, constant.Complex:
+				body.WriteString(val.String())
+			default:
+				body.WriteString(val.ExactString())
+			}
+			body.WriteByte('\n')
+		}
+		body.WriteString(")\n\n")
+	}
+
+	if len(vars) > 0 {
+		body.WriteString("var (\n")
+		for _, obj := range vars {
+			body.WriteByte('\t')
+			name(obj)
+			body.WriteByte(' ')
+			typ(obj.Type())
+			body.WriteByte('\n')
+		}
+		body.WriteString(")\n\n")
+	}
+
+	for _, obj := range typenames {
+		tname := obj.(*types.TypeName)
+		body.WriteString("type ")
+		name(tname)
+		if tname.IsAlias() {
+			body.WriteString(" = ")
+			if alias, ok := tname.Type().(*aliases.Alias); ok {
+				typ(aliases.Rhs(alias))
+			} else {
+				typ(tname.Type())
+			}
+			body.WriteString("\n\n")
+			continue
+		}
+		named, ok := tname.Type().(*types.Named)
+		if !ok {
+			body.WriteString(" ")
+			typ(tname.Type())
+			body.WriteString("\n\n")
+			continue
+		}
+		tparams(named.TypeParams())
+		body.WriteByte(' ')
+
+		// Lay out the fields of a struct and the methods of an
+		// interface one per line, so that each can be navigated to.
+		switch under := named.Underlying().(type) {
+		case *types.Struct:
+			body.WriteString("struct {\n")
+			for i := 0; i < under.NumFields(); i++ {
+				field := under.Field(i)
+				body.WriteByte('\t')
+				if field.Embedded() {
+					t := field.Type()
+					if ptr, ok := t.(*types.Pointer); ok {
+						body.WriteByte('*')
+						t = ptr.Elem()
+					}
+					// The name of an embedded field is that of its
+					// type, after any qualifier and before any type
+					// arguments.
+					start := body.Len()
+					typ(t)
+					text := body.Bytes()[start:]
+					if i := bytes.IndexByte(text, '['); i >= 0 {
+						text = text[:i]
+					}
+					offsets[field] = start + bytes.LastIndexByte(text, '.') + 1
+				} else {
+					name(field)
+					body.WriteByte(' ')
+					typ(field.Type())
+				}
+				if tag := under.Tag(i); tag != "" {
+					body.WriteString(" " + strconv.Quote(tag))
+				}
+				body.WriteByte('\n')
+			}
+			body.WriteString("}")
+		case *types.Interface:
+			body.WriteString("interface {\n")
+			for i := 0; i < under.NumEmbeddeds(); i++ {
+				body.WriteByte('\t')
+				typ(under.EmbeddedType(i))
+				body.WriteByte('\n')
+			}
+			for i := 0; i < under.NumExplicitMethods(); i++ {
+				method := under.ExplicitMethod(i)
+				body.WriteByte('\t')
+				name(method)
+				sig(method)
+				body.WriteByte('\n')
+			}
+			body.WriteString("}")
+		default:
+			typ(under)
+		}
+		body.WriteString("\n\n")
+
+		methods := make([]*types.Func, named.NumMethods())
+		for i := range methods {
+			methods[i] = named.Method(i)
+		}
+		sort.Slice(methods, func(i, j int) bool { return methods[i].Name() < methods[j].Name() })
+		for _, method := range methods {
+			recv := method.Type().(*types.Signature).Recv()
+			body.WriteString("func (")
+			if recv.Name() != "" && recv.Name() != "_" {
+				body.WriteString(recv.Name() + " ")
+			}
+			if _, ok := recv.Type().(*types.Pointer); ok {
+				body.WriteByte('*')
+			}
+			body.WriteString(tname.Name())
+			if list := named.TypeParams(); list.Len() > 0 {
+				body.WriteByte('[')
+				for i := 0; i < list.Len(); i++ {
+					if i > 0 {
+						body.WriteString(", ")
+					}
+					body.WriteString(list.At(i).Obj().Name())
+				}
+				body.WriteByte(']')
+			}
+			body.WriteString(") ")
+			name(method)
+			sig(method)
+			body.WriteString("\n\n")
+		}
+	}
+
+	for _, obj := range funcs {
+		body.WriteString("func ")
+		name(obj)
+		sig(obj.(*types.Func))
+		body.WriteString("\n\n")
+	}
+
+	// Now that the imports are known, prepend the header.
+	var header bytes.Buffer
+	fmt.Fprintf(&header, "// Code generated by gopls from the type information of package %q; DO NOT EDIT.\n\n", pkg.Path())
+	header.WriteString("// The source of this package is not available, so this file shows its\n")
+	header.WriteString("// declarations as recorded in its type information.\n\n")
+	fmt.Fprintf(&header, "package %s\n\n", pkg.Name())
+	if len(imports) > 0 {
+		var paths []string
+		for p := range imports {
+			paths = append(paths, p.Path())
+		}
+		sort.Strings(paths)
+		header.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&header, "\t%q\n", path)
+		}
+		header.WriteString(")\n\n")
+	}
+
+	for obj := range offsets {
+		offsets[obj] += header.Len()
+	}
+	src := append(header.Bytes(), bytes.TrimRight(body.Bytes(), "\n")...)
+	return append(src, '\n'), offsets
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestExportDataView is a unit test of the synthesis of a Go file
+// from the type information of a package.
+func TestExportDataView(t *testing.T) {
+	if runtime.GOOS == "js" {
+		t.Skip("some test imports are unsupported on js")
+	}
+
+	const src = `package p
+
+import "io"
+
+const (
+	Answer      = 42
+	Name        = "p"
+	Size   uint = 1 << 3
+)
+
+var Default *Buffer
+
+type Buffer struct {
+	io.Reader
+	data []byte ` + "`json:\"data\"`" + `
+}
+
+func (b *Buffer) Len() int { return len(b.data) }
+
+type Pair[K comparable, V any] struct{ Key K; Value V }
+
+func (p Pair[K, V]) Get() (K, V) { return p.Key, p.Value }
+
+type Getter interface {
+	io.Closer
+	Get(key string) (value []byte, ok bool)
+}
+
+type Bytes = []byte
+
+func New(r io.Reader, size ...int) *Buffer { return nil }
+
+func Map[T, U any](s []T, f func(T) U) []U { return nil }
+`
+	const want = `// Code generated by gopls from the type information of package "p"; DO NOT EDIT.
+
+// The source of this package is not available, so this file shows its
+// declarations as recorded in its type information.
+
+package p
+
+import (
+	"io"
+)
+
+const (
+	Answer = 42
+	Name = "p"
+	Size uint = 8
+)
+
+var (
+	Default *Buffer
+)
+
+type Buffer struct {
+	io.Reader
+	data []byte "json:\"data\""
+}
+
+func (b *Buffer) Len() int
+
+type Bytes = []byte
+
+type Getter interface {
+	io.Closer
+	Get(key string) (value []byte, ok bool)
+}
+
+type Pair[K comparable, V any] struct {
+	Key K
+	Value V
+}
+
+func (p Pair[K, V]) Get() (K, V)
+
+func Map[T, U any](s []T, f func(T) U) []U
+
+func New(r io.Reader, size ...int) *Buffer
+`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, offsets := exportDataView(pkg)
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("unexpected view (-want +got):\n%s", diff)
+	}
+
+	// The view must be valid Go syntax.
+	if _, err := parser.ParseFile(token.NewFileSet(), "view.go", got, 0); err != nil {
+		t.Errorf("view does not parse: %v", err)
+	}
+
+	// Each declared object, field, and method maps to its name.
+	buffer := pkg.Scope().Lookup("Buffer").(*types.TypeName)
+	objects := []types.Object{
+		pkg.Scope().Lookup("Answer"),
+		pkg.Scope().Lookup("Default"),
+		buffer,
+		buffer.Type().Underlying().(*types.Struct).Field(0), // embedded io.Reader
+		buffer.Type().Underlying().(*types.Struct).Field(1),
+		buffer.Type().(*types.Named).Method(0),
+		pkg.Scope().Lookup("Getter").Type().Underlying().(*types.Interface).ExplicitMethod(0),
+		pkg.Scope().Lookup("Pair").Type().(*types.Named).Method(0),
+		pkg.Scope().Lookup("Map"),
+	}
+	for _, obj := range objects {
+		offset, ok := offsets[obj]
+		if !ok {
+			t.Errorf("no offset for %v", obj)
+			continue
+		}
+		if name := string(got[offset : offset+len(obj.Name())]); name != obj.Name() {
+			t.Errorf("offset of %v is that of %q", obj, name)
+		}
+	}
+}
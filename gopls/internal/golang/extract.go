@@ -13,6 +13,7 @@ import (
 	"go/token"
 	"go/types"
 	"sort"
+	"strconv"
 	"strings"
 	"text/scanner"
 
@@ -20,6 +21,7 @@ import (
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/gopls/internal/util/bug"
 	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/gopls/internal/util/slices"
 	"golang.org/x/tools/internal/analysisinternal"
 )
 
@@ -120,6 +122,9 @@ func canExtractVariable(start, end token.Pos, file *ast.File) (ast.Expr, []ast.N
 			return nil, nil, false, fmt.Errorf("cannot extract variable in an import block")
 		}
 	}
+	if field, _ := typeParamField(path); field != nil {
+		return nil, nil, false, fmt.Errorf("cannot extract variable in a type parameter list")
+	}
 	node := path[0]
 	if start != node.Pos() || end != node.End() {
 		return nil, nil, false, fmt.Errorf("range does not map to an AST node")
@@ -246,18 +251,90 @@ func extractFunctionMethod(fset *token.FileSet, start, end token.Pos, src []byte
 		if n.Pos() < start || n.End() > end {
 			return n.Pos() <= end
 		}
-		ret, ok := n.(*ast.ReturnStmt)
-		if !ok {
-			return true
-		}
-		if findParent(outer, n) == startParent {
-			hasNonNestedReturn = true
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Return statements of a function literal
+			// are unaffected by the extraction.
+			return false
+		case *ast.ReturnStmt:
+			if findParent(outer, n) == startParent {
+				hasNonNestedReturn = true
+			}
+			retStmts = append(retStmts, n)
+			return false
 		}
-		retStmts = append(retStmts, ret)
-		return false
+		return true
 	})
 	containsReturnStatement := len(retStmts) > 0
 
+	// A label declared in the selection cannot be the target
+	// of a branch statement outside the selection.
+	ast.Inspect(outer, func(n ast.Node) bool {
+		if br, ok := n.(*ast.BranchStmt); ok && br.Label != nil && (br.Pos() < start || br.End() > end) {
+			if obj := info.Uses[br.Label]; obj != nil && start <= obj.Pos() && obj.Pos() < end {
+				err = fmt.Errorf("label %s is used outside the selection", br.Label.Name)
+			}
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", errorPrefix, err)
+	}
+
+	// We put the selection in a constructed file. We can then traverse and edit
+	// the extracted selection without modifying the original AST.
+	startOffset, endOffset, err := safetoken.Offsets(tok, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	selection := src[startOffset:endOffset]
+	extractedBlock, err := parseBlockStmt(fset, selection)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Branch statements in the selection whose targets lie outside of it
+	// (such as a break from an enclosing loop) cannot be executed by the
+	// extracted function. Instead, they become return statements, and
+	// their branch is taken after the call (see generateBranchInfo).
+	// The extracted function must then report whether the enclosing
+	// function should return, even if the selection ends in a return.
+	branches, err := freeBranches(extractedBlock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", errorPrefix, err)
+	}
+	if len(branches) > 0 {
+		hasNonNestedReturn = false
+	}
+
+	// Find the function literal that encloses the selection. The enclosing function literal
+	// may not be the enclosing function declaration (i.e. 'outer'). For example, in the
+	// following block:
+	//
+	// func main() {
+	//     ast.Inspect(node, func(n ast.Node) bool {
+	//         v := 1 // this line extracted
+	//         return true
+	//     })
+	// }
+	//
+	// 'outer' is main(). However, the extracted selection most directly belongs to
+	// the anonymous function literal, the second argument of ast.Inspect(). We use the
+	// enclosing function literal to determine the proper return types for return statements
+	// within the selection. We still need the enclosing function declaration because this is
+	// the top-level declaration. We inspect the top-level declaration to look for variables
+	// as well as for code replacement.
+	enclosing := outer.Type
+	for _, p := range path {
+		if p == enclosing {
+			break
+		}
+		if fl, ok := p.(*ast.FuncLit); ok {
+			enclosing = fl.Type
+			break
+		}
+	}
+
 	// Now that we have determined the correct range for the selection block,
 	// we must determine the signature of the extracted function. We will then replace
 	// the block with an assignment statement that calls the extracted function with
@@ -267,6 +344,26 @@ func extractFunctionMethod(fset *token.FileSet, start, end token.Pos, src []byte
 		return nil, nil, err
 	}
 
+	// A naked return in the selection implicitly uses the named results of
+	// the enclosing function, so they must be parameters of the extracted
+	// function, whose return statements name them explicitly.
+	var namedResults []types.Object
+	if enclosing.Results != nil {
+		for _, field := range enclosing.Results.List {
+			for _, name := range field.Names {
+				if obj := info.Defs[name]; obj != nil && name.Name != "_" {
+					namedResults = append(namedResults, obj)
+				}
+			}
+		}
+	}
+	if slices.ContainsFunc(retStmts, func(ret *ast.ReturnStmt) bool { return len(ret.Results) == 0 }) {
+		for _, obj := range namedResults {
+			// Actual uses in the selection, which come first, take precedence.
+			variables = append(variables, &variable{obj: obj, free: true})
+		}
+	}
+
 	var (
 		receiverUsed bool
 		receiver     *ast.Field
@@ -347,6 +444,11 @@ func extractFunctionMethod(fset *token.FileSet, start, end token.Pos, src []byte
 			return nil, nil, fmt.Errorf("parent nil")
 		}
 		isUsed, firstUseAfter := objUsed(info, end, vscope.End(), v.obj)
+		if !hasNonNestedReturn && slices.ContainsFunc(namedResults, func(obj types.Object) bool { return obj == v.obj }) {
+			// A named result may be used by a later naked
+			// return, or by a deferred function.
+			isUsed = true
+		}
 		if v.assigned && isUsed && !varOverridden(info, firstUseAfter, v.obj, v.free, outer) {
 			returnTypes = append(returnTypes, &ast.Field{Type: typ})
 			returns = append(returns, identifier)
@@ -384,44 +486,27 @@ func extractFunctionMethod(fset *token.FileSet, start, end token.Pos, src []byte
 
 	reorderParams(params, paramTypes)
 
-	// Find the function literal that encloses the selection. The enclosing function literal
-	// may not be the enclosing function declaration (i.e. 'outer'). For example, in the
-	// following block:
-	//
-	// func main() {
-	//     ast.Inspect(node, func(n ast.Node) bool {
-	//         v := 1 // this line extracted
-	//         return true
-	//     })
-	// }
-	//
-	// 'outer' is main(). However, the extracted selection most directly belongs to
-	// the anonymous function literal, the second argument of ast.Inspect(). We use the
-	// enclosing function literal to determine the proper return types for return statements
-	// within the selection. We still need the enclosing function declaration because this is
-	// the top-level declaration. We inspect the top-level declaration to look for variables
-	// as well as for code replacement.
-	enclosing := outer.Type
-	for _, p := range path {
-		if p == enclosing {
-			break
-		}
-		if fl, ok := p.(*ast.FuncLit); ok {
-			enclosing = fl.Type
-			break
-		}
-	}
+	// The returns are used in several statements of the extracted
+	// function and its call; don't let appends to them interfere.
+	returns = returns[:len(returns):len(returns)]
 
-	// We put the selection in a constructed file. We can then traverse and edit
-	// the extracted selection without modifying the original AST.
-	startOffset, endOffset, err := safetoken.Offsets(tok, start, end)
-	if err != nil {
-		return nil, nil, err
-	}
-	selection := src[startOffset:endOffset]
-	extractedBlock, err := parseBlockStmt(fset, selection)
-	if err != nil {
-		return nil, nil, err
+	// Expand each naked return in the selection to name the results of
+	// the enclosing function, since the extracted function's are unnamed.
+	if enclosing.Results != nil && len(namedResults) > 0 {
+		for _, ret := range returnStmts(extractedBlock) {
+			if len(ret.Results) > 0 {
+				continue
+			}
+			for _, field := range enclosing.Results.List {
+				for _, name := range field.Names {
+					var result ast.Expr = ast.NewIdent(name.Name)
+					if name.Name == "_" {
+						result = analysisinternal.ZeroValue(file, pkg, info.TypeOf(field.Type))
+					}
+					ret.Results = append(ret.Results, result)
+				}
+			}
+		}
 	}
 
 	// We need to account for return statements in the selected block, as they will complicate
@@ -518,13 +603,78 @@ func extractFunctionMethod(fset *token.FileSet, start, end token.Pos, src []byte
 		}
 	}
 
+	// Branch statements whose targets lie outside the selection complicate the
+	// logical flow in the same way. See the following example, where ** denotes
+	// the range to be extracted.
+	//
+	// Before:
+	//
+	// for _, x := range xs {
+	//     **if x < 0 {
+	//         continue
+	//     }
+	//     if x == 0 {
+	//         break
+	//     }**
+	//     ...
+	// }
+	//
+	// After:
+	//
+	// for _, x := range xs {
+	//     ctrl := newFunction(x)
+	//     if ctrl == 1 {
+	//         continue
+	//     } else if ctrl == 2 {
+	//         break
+	//     }
+	//     ...
+	// }
+	//
+	// func newFunction(x int) int {
+	//     if x < 0 {
+	//         return 1
+	//     }
+	//     if x == 0 {
+	//         return 2
+	//     }
+	//     return 0
+	// }
+	//
+	// We replace each such branch statement with a return statement, and add an
+	// additional return value to the extracted function that identifies the branch
+	// taken, if any. We then add a statement below the call that takes the branch.
+	// (With only one kind of branch, the value is a bool, as for return statements.)
+	var dispatch ast.Stmt
+	if len(branches) > 0 {
+		ctrl, values, stmt := generateBranchInfo(branches, pkg, path, info, start)
+		dispatch = stmt
+		// The existing return statements take no branch.
+		for _, ret := range returnStmts(extractedBlock) {
+			ret.Results = append(ret.Results, ctrl.zeroVal)
+		}
+		astutil.Apply(extractedBlock, func(c *astutil.Cursor) bool {
+			switch n := c.Node().(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.BranchStmt:
+				if value, ok := values[n]; ok {
+					results := append(append(returns, getZeroVals(retVars)...), value)
+					c.Replace(&ast.ReturnStmt{Return: n.Pos(), Results: results})
+				}
+			}
+			return true
+		}, nil)
+		retVars = append(retVars, ctrl)
+	}
+
 	// Add a return statement to the end of the new function. This return statement must include
 	// the values for the types of the original extracted function signature and (if a return
 	// statement is present in the selection) enclosing function signature.
 	// This only needs to be done if the selections does not have a non-nested return, otherwise
 	// it already terminates with a return statement.
 	hasReturnValues := len(returns)+len(retVars) > 0
-	if hasReturnValues && !hasNonNestedReturn {
+	if hasReturnValues && !hasNonNestedReturn && !endsInReturn(extractedBlock) {
 		extractedBlock.List = append(extractedBlock.List, &ast.ReturnStmt{
 			Results: append(returns, getZeroVals(retVars)...),
 		})
@@ -582,7 +732,7 @@ func extractFunctionMethod(fset *token.FileSet, start, end token.Pos, src []byte
 		declarations = initializeVars(uninitialized, retVars, seenUninitialized, seenVars)
 	}
 
-	var declBuf, replaceBuf, newFuncBuf, ifBuf, commentBuf bytes.Buffer
+	var declBuf, replaceBuf, newFuncBuf, ifBuf, dispatchBuf, commentBuf bytes.Buffer
 	if err := format.Node(&declBuf, fset, declarations); err != nil {
 		return nil, nil, err
 	}
@@ -594,6 +744,11 @@ func extractFunctionMethod(fset *token.FileSet, start, end token.Pos, src []byte
 			return nil, nil, err
 		}
 	}
+	if dispatch != nil {
+		if err := format.Node(&dispatchBuf, fset, dispatch); err != nil {
+			return nil, nil, err
+		}
+	}
 	if err := format.Node(&newFuncBuf, fset, newFunc); err != nil {
 		return nil, nil, err
 	}
@@ -638,6 +793,10 @@ func extractFunctionMethod(fset *token.FileSet, start, end token.Pos, src []byte
 			strings.ReplaceAll(ifBuf.String(), "\n", newLineIndent)
 		fullReplacement.WriteString(ifstatement)
 	}
+	if dispatchBuf.Len() > 0 { // add the branch statements below the function call, if needed
+		fullReplacement.WriteString(newLineIndent +
+			strings.ReplaceAll(dispatchBuf.String(), "\n", newLineIndent))
+	}
 	fullReplacement.Write(after)
 	fullReplacement.WriteString("\n\n")       // add newlines after the enclosing function
 	fullReplacement.Write(newFuncBuf.Bytes()) // insert the extracted function
@@ -807,6 +966,9 @@ func collectFreeVars(info *types.Info, file *ast.File, fileScope, pkgScope *type
 		if _, ok := obj.(*types.PkgName); ok {
 			return nil, false // imported package
 		}
+		if _, ok := obj.(*types.Label); ok {
+			return nil, false // label of a branch statement
+		}
 		if !(file.Pos() <= obj.Pos() && obj.Pos() <= file.End()) {
 			return nil, false // not defined in this file
 		}
@@ -1247,17 +1409,180 @@ func adjustReturnStatements(returnTypes []*ast.Field, seenVars map[types.Object]
 	// execute, the extracted function terminates early, and the enclosing function must
 	// return as well.
 	zeroVals = append(zeroVals, ast.NewIdent("true"))
-	ast.Inspect(extractedBlock, func(n ast.Node) bool {
-		if n == nil {
+	for _, ret := range returnStmts(extractedBlock) {
+		ret.Results = append(zeroVals[:len(zeroVals):len(zeroVals)], ret.Results...)
+	}
+	return nil
+}
+
+// returnStmts returns the return statements of the given block,
+// excluding those of nested function literals.
+func returnStmts(block *ast.BlockStmt) []*ast.ReturnStmt {
+	var rets []*ast.ReturnStmt
+	ast.Inspect(block, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			rets = append(rets, n)
 			return false
 		}
-		if n, ok := n.(*ast.ReturnStmt); ok {
-			n.Results = append(zeroVals, n.Results...)
+		return true
+	})
+	return rets
+}
+
+// endsInReturn reports whether the last statement of the given block
+// is a return statement.
+func endsInReturn(block *ast.BlockStmt) bool {
+	if len(block.List) == 0 {
+		return false
+	}
+	_, ok := block.List[len(block.List)-1].(*ast.ReturnStmt)
+	return ok
+}
+
+// freeBranches returns the branch statements of the given block whose
+// targets lie outside of it, excluding those of nested function
+// literals. It returns an error if the block contains a fallthrough
+// statement outside of a case clause, since there is no way to execute
+// it after a call.
+func freeBranches(block *ast.BlockStmt) ([]*ast.BranchStmt, error) {
+	// Find the labels declared in the block.
+	labels := make(map[string]bool)
+	ast.Inspect(block, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
 			return false
+		case *ast.LabeledStmt:
+			labels[n.Label.Name] = true
 		}
 		return true
 	})
-	return nil
+
+	var (
+		branches []*ast.BranchStmt
+		err      error
+		stack    []ast.Node // the enclosing nodes within the block
+	)
+	// enclosedBy reports whether an element of the stack
+	// satisfies the given predicate.
+	enclosedBy := func(pred func(ast.Node) bool) bool {
+		return slices.ContainsFunc(stack, pred)
+	}
+	isLoop := func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			return true
+		}
+		return false
+	}
+	isBreakable := func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			return true
+		}
+		return isLoop(n)
+	}
+	ast.Inspect(block, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.BranchStmt:
+			var free bool
+			switch {
+			case n.Tok == token.FALLTHROUGH:
+				if !enclosedBy(func(n ast.Node) bool { _, ok := n.(*ast.CaseClause); return ok }) {
+					err = fmt.Errorf("cannot extract fallthrough statement")
+				}
+			case n.Label != nil:
+				free = !labels[n.Label.Name]
+			case n.Tok == token.BREAK:
+				free = !enclosedBy(isBreakable)
+			case n.Tok == token.CONTINUE:
+				free = !enclosedBy(isLoop)
+			}
+			if free {
+				branches = append(branches, n)
+			}
+			return false
+		}
+		stack = append(stack, n)
+		return true
+	})
+	return branches, err
+}
+
+// generateBranchInfo generates the information we need to replace the given branch
+// statements, whose targets lie outside the extracted function, with return statements.
+// It returns the variable that identifies the branch to take, the value of that variable
+// for each branch statement, and the statement that takes the branch, which is inserted
+// below the call to the extracted function.
+func generateBranchInfo(branches []*ast.BranchStmt, pkg *types.Package, path []ast.Node, info *types.Info, pos token.Pos) (*returnVariable, map[*ast.BranchStmt]ast.Expr, ast.Stmt) {
+	// Number the distinct branches in order of appearance, from 1.
+	key := func(br *ast.BranchStmt) string {
+		if br.Label != nil {
+			return br.Tok.String() + " " + br.Label.Name
+		}
+		return br.Tok.String()
+	}
+	var distinct []*ast.BranchStmt
+	index := make(map[string]int)
+	for _, br := range branches {
+		if _, ok := index[key(br)]; !ok {
+			distinct = append(distinct, br)
+			index[key(br)] = len(distinct)
+		}
+	}
+	// takeBranch returns a block containing a copy of the given branch statement.
+	takeBranch := func(br *ast.BranchStmt) *ast.BlockStmt {
+		stmt := &ast.BranchStmt{Tok: br.Tok}
+		if br.Label != nil {
+			stmt.Label = ast.NewIdent(br.Label.Name)
+		}
+		return &ast.BlockStmt{List: []ast.Stmt{stmt}}
+	}
+
+	values := make(map[*ast.BranchStmt]ast.Expr)
+	if len(distinct) == 1 {
+		tok := distinct[0].Tok.String()
+		name, _ := generateAvailableIdentifier(pos, path, pkg, info, "should"+strings.ToUpper(tok[:1])+tok[1:], 0)
+		for _, br := range branches {
+			values[br] = ast.NewIdent("true")
+		}
+		ctrl := &returnVariable{
+			name:    ast.NewIdent(name),
+			decl:    &ast.Field{Type: ast.NewIdent("bool")},
+			zeroVal: ast.NewIdent("false"),
+		}
+		return ctrl, values, &ast.IfStmt{Cond: ast.NewIdent(name), Body: takeBranch(distinct[0])}
+	}
+
+	name, _ := generateAvailableIdentifier(pos, path, pkg, info, "ctrl", 0)
+	intLit := func(i int) ast.Expr {
+		return &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(i)}
+	}
+	for _, br := range branches {
+		values[br] = intLit(index[key(br)])
+	}
+	var dispatch ast.Stmt // an if/else-if chain, built from the end
+	for i := len(distinct) - 1; i >= 0; i-- {
+		dispatch = &ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: ast.NewIdent(name), Op: token.EQL, Y: intLit(i + 1)},
+			Body: takeBranch(distinct[i]),
+			Else: dispatch,
+		}
+	}
+	ctrl := &returnVariable{
+		name:    ast.NewIdent(name),
+		decl:    &ast.Field{Type: ast.NewIdent("int")},
+		zeroVal: intLit(0),
+	}
+	return ctrl, values, dispatch
 }
 
 // generateFuncCall constructs a call expression for the extracted function, described by the
@@ -0,0 +1,251 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the "Extract constant" code actions, which
+// declare a named constant for a literal.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/analysis/naming"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/internal/analysisinternal"
+)
+
+// getExtractConstantCodeActions returns the actions to extract the
+// literal selected by rng to a constant, and if it appears elsewhere
+// in the package, to replace all its occurrences too.
+func getExtractConstantCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Range, options *settings.Options) ([]protocol.CodeAction, error) {
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	lit, _, ok := canExtractConstant(pgf.File, start, end)
+	if !ok {
+		return nil, nil
+	}
+	var occurrences int
+	for _, pgf := range pkg.CompiledGoFiles() {
+		occurrences += len(identicalLiterals(pgf.File, lit))
+	}
+	var actions []protocol.CodeAction
+	for _, all := range []bool{false, true} {
+		title, fix := "Extract constant", fixExtractConstant
+		if all {
+			if occurrences < 2 {
+				break
+			}
+			title, fix = fmt.Sprintf("Extract constant (replace all %d occurrences)", occurrences), fixExtractConstantAll
+		}
+		cmd, err := command.NewApplyFixCommand(title, command.ApplyFixArgs{
+			Fix:          fix,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, newCodeAction(cmd.Title, protocol.RefactorExtract, &cmd, nil, options))
+	}
+	return actions, nil
+}
+
+// canExtractConstant reports whether the range [start, end) is a
+// literal that may be extracted to a named constant, and returns it
+// and the path to it.
+func canExtractConstant(file *ast.File, start, end token.Pos) (*ast.BasicLit, []ast.Node, bool) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	if len(path) < 3 {
+		return nil, nil, false
+	}
+	lit, ok := path[0].(*ast.BasicLit)
+	if !ok || lit.Pos() != start || lit.End() != end || !extractableLiteral(lit, path[1], path[2]) {
+		return nil, nil, false
+	}
+	for _, n := range path {
+		if _, ok := n.(*ast.ImportSpec); ok {
+			return nil, nil, false
+		}
+	}
+	return lit, path, true
+}
+
+// extractableLiteral reports whether the literal lit, whose parent
+// and grandparent are the specified nodes, may be replaced by a named
+// constant: it is not a struct tag, an import path, or the value of a
+// constant.
+func extractableLiteral(lit *ast.BasicLit, parent, grandparent ast.Node) bool {
+	switch parent := parent.(type) {
+	case *ast.Field:
+		return parent.Tag != lit
+	case *ast.ImportSpec:
+		return false
+	case *ast.ValueSpec:
+		if decl, ok := grandparent.(*ast.GenDecl); ok && decl.Tok == token.CONST {
+			for _, value := range parent.Values {
+				if value == lit {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// identicalLiterals returns the literals of the file that denote the
+// same constant as lit and may be replaced by a named constant.
+func identicalLiterals(file *ast.File, lit *ast.BasicLit) []*ast.BasicLit {
+	value := constant.MakeFromLiteral(lit.Value, lit.Kind, 0)
+	if value.Kind() == constant.Unknown {
+		return nil
+	}
+	var lits []*ast.BasicLit
+	var stack []ast.Node
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		if other, ok := n.(*ast.BasicLit); ok && other.Kind == lit.Kind && extractableLiteral(other, stack[len(stack)-1], stack[len(stack)-2]) {
+			if v := constant.MakeFromLiteral(other.Value, other.Kind, 0); v.Kind() != constant.Unknown && constant.Compare(v, token.EQL, value) {
+				lits = append(lits, other)
+			}
+		}
+		if _, ok := n.(*ast.ImportSpec); ok {
+			return false
+		}
+		stack = append(stack, n)
+		return true
+	})
+	return lits
+}
+
+// extractConstant is a fixer that declares a constant whose value is
+// the selected literal, just before the statement that contains it,
+// or at package level, and replaces the literal by its name.
+func extractConstant(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+	return extractConstantFix(pkg, pgf, start, end, false)
+}
+
+// extractConstantAll is a fixer that declares a constant whose value
+// is the selected literal at package level, and replaces all the
+// identical literals of the package by its name.
+func extractConstantAll(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+	return extractConstantFix(pkg, pgf, start, end, true)
+}
+
+func extractConstantFix(pkg *cache.Package, pgf *parsego.File, start, end token.Pos, all bool) (*token.FileSet, *analysis.SuggestedFix, error) {
+	lit, path, ok := canExtractConstant(pgf.File, start, end)
+	if !ok {
+		return nil, nil, fmt.Errorf("cannot extract a constant from the selection")
+	}
+	info := pkg.TypesInfo()
+
+	// Find the literals to replace and the scopes in which the name
+	// of the constant must be free.
+	occurrences := []*ast.BasicLit{lit}
+	scopes := CollectScopes(info, path, lit.Pos())
+	if all {
+		occurrences = nil
+		for _, pgf := range pkg.CompiledGoFiles() {
+			for _, lit := range identicalLiterals(pgf.File, lit) {
+				occurrences = append(occurrences, lit)
+				path, _ := astutil.PathEnclosingInterval(pgf.File, lit.Pos(), lit.End())
+				scopes = append(scopes, CollectScopes(info, path, lit.Pos())...)
+			}
+		}
+	}
+	scopes = append(scopes, pkg.Types().Scope())
+	name, _ := generateIdentifier(0, constantName(lit), func(name string) bool {
+		for _, scope := range scopes {
+			if scope != nil && scope.Lookup(name) != nil {
+				return true
+			}
+		}
+		return false
+	})
+
+	// Declare the constant before the enclosing statement, or if all
+	// literals are replaced or there is none, before the enclosing
+	// package-level declaration.
+	var (
+		pos  token.Pos
+		decl string
+	)
+	if stmt := analysisinternal.StmtToInsertVarBefore(path); stmt != nil && !all {
+		indent, err := calculateIndentation(pgf.Src, pgf.Tok, stmt)
+		if err != nil {
+			return nil, nil, err
+		}
+		pos, decl = stmt.Pos(), fmt.Sprintf("const %s = %s\n%s", name, lit.Value, indent)
+	} else {
+		pos, decl = topLevelDeclStart(path), fmt.Sprintf("const %s = %s\n\n", name, lit.Value)
+	}
+
+	edits := []analysis.TextEdit{{Pos: pos, End: pos, NewText: []byte(decl)}}
+	for _, lit := range occurrences {
+		edits = append(edits, analysis.TextEdit{Pos: lit.Pos(), End: lit.End(), NewText: []byte(name)})
+	}
+	return pkg.FileSet(), &analysis.SuggestedFix{TextEdits: edits}, nil
+}
+
+// topLevelDeclStart returns the start of the package-level declaration
+// that encloses path, including its doc comment.
+func topLevelDeclStart(path []ast.Node) token.Pos {
+	switch decl := path[len(path)-2].(type) {
+	case *ast.FuncDecl:
+		if decl.Doc != nil {
+			return decl.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if decl.Doc != nil {
+			return decl.Doc.Pos()
+		}
+	}
+	return path[len(path)-2].Pos()
+}
+
+// constantName returns a name for a constant whose value is that of
+// lit: for a string, one made of its first words, as in
+// "applicationJSON" for "application/json"; otherwise, "c".
+func constantName(lit *ast.BasicLit) string {
+	if lit.Kind == token.STRING {
+		if s := constant.StringVal(constant.MakeFromLiteral(lit.Value, lit.Kind, 0)); s != "" {
+			words := strings.FieldsFunc(s, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+			})
+			if len(words) > 3 {
+				words = words[:3]
+			}
+			for i, word := range words {
+				word = strings.ToLower(word)
+				if i > 0 {
+					word = capitalize(word)
+				}
+				words[i] = word
+			}
+			name := naming.Conventional(strings.Join(words, ""))
+			if token.IsIdentifier(name) && types.Universe.Lookup(name) == nil {
+				return name
+			}
+		}
+	}
+	return "c"
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the "Extract constraint" code action, which
+// replaces an inline type constraint, such as ~int | ~string, by a
+// new named interface.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+)
+
+// canExtractConstraint reports whether the range [start, end) is
+// within an inline constraint of a type parameter, which may be
+// extracted to a named interface. It returns the field of the type
+// parameter list that declares the constrained type parameters.
+func canExtractConstraint(file *ast.File, start, end token.Pos) (*ast.Field, bool) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	field, _ := typeParamField(path)
+	if field == nil || !(field.Type.Pos() <= start && end <= field.Type.End()) {
+		return nil, false
+	}
+	switch t := astutil.Unparen(field.Type).(type) {
+	case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr, *ast.IndexListExpr:
+		return nil, false // already named
+	case *ast.InterfaceType:
+		if t.Methods.NumFields() == 0 {
+			return nil, false // interface{} is any
+		}
+	}
+	return field, true
+}
+
+// typeParamField returns the innermost field of path that belongs to
+// a type parameter list, and that list, or nil if there is none.
+func typeParamField(path []ast.Node) (*ast.Field, *ast.FieldList) {
+	for i, n := range path {
+		field, ok := n.(*ast.Field)
+		if !ok || i+2 >= len(path) {
+			continue
+		}
+		list, _ := path[i+1].(*ast.FieldList)
+		switch parent := path[i+2].(type) {
+		case *ast.FuncDecl:
+			if list != nil && list == parent.Type.TypeParams {
+				return field, list
+			}
+		case *ast.TypeSpec:
+			if list != nil && list == parent.TypeParams {
+				return field, list
+			}
+		}
+	}
+	return nil, nil
+}
+
+// extractConstraint is a singleFileFixer that replaces the inline
+// constraint at [start, end) by a new interface type, declared before
+// the declaration of the type parameters. The interface has its own
+// type parameters if the constraint refers to the type parameters of
+// the declaration.
+func extractConstraint(fset *token.FileSet, start, end token.Pos, src []byte, file *ast.File, pkg *types.Package, info *types.Info) (*token.FileSet, *analysis.SuggestedFix, error) {
+	field, ok := canExtractConstraint(file, start, end)
+	if !ok {
+		return nil, nil, fmt.Errorf("no inline constraint to extract")
+	}
+	path, _ := astutil.PathEnclosingInterval(file, field.Pos(), field.End())
+	_, list := typeParamField(path)
+	tok := fset.File(file.Pos())
+	text := func(n ast.Node) (string, error) {
+		start, end, err := safetoken.Offsets(tok, n.Pos(), n.End())
+		if err != nil {
+			return "", err
+		}
+		return string(src[start:end]), nil
+	}
+
+	// Find the type parameters of the list to which the constraint
+	// refers, and, transitively, those to which their constraints
+	// refer. The parameters constrained by the extracted constraint
+	// are constrained by any in the new interface.
+	used := make(map[types.Object]bool)
+	var visit func(n ast.Node)
+	visit = func(n ast.Node) {
+		ast.Inspect(n, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				if obj, ok := info.Uses[id].(*types.TypeName); ok && !used[obj] && list.Pos() <= obj.Pos() && obj.Pos() < list.End() {
+					used[obj] = true
+					for _, f := range list.List {
+						for _, name := range f.Names {
+							if info.Defs[name] == obj && f != field {
+								visit(f.Type)
+							}
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	visit(field.Type)
+	var params, args []string
+	for _, f := range list.List {
+		for _, name := range f.Names {
+			if !used[info.Defs[name]] {
+				continue
+			}
+			constraint := "any"
+			if f != field {
+				var err error
+				if constraint, err = text(f.Type); err != nil {
+					return nil, nil, err
+				}
+			}
+			params = append(params, name.Name+" "+constraint)
+			args = append(args, name.Name)
+		}
+	}
+
+	// Choose a name that is not in use where the constraint is.
+	path, _ = astutil.PathEnclosingInterval(file, field.Type.Pos(), field.Type.End())
+	name, _ := generateAvailableIdentifier(field.Type.Pos(), path, pkg, info, "constraint", 0)
+
+	// Format the declaration of the interface, one element per line.
+	var elems []string
+	if iface, ok := astutil.Unparen(field.Type).(*ast.InterfaceType); ok {
+		for _, elem := range iface.Methods.List {
+			t, err := text(elem)
+			if err != nil {
+				return nil, nil, err
+			}
+			elems = append(elems, t)
+		}
+	} else {
+		t, err := text(field.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		elems = append(elems, t)
+	}
+	decl := "type " + name
+	if len(params) > 0 {
+		decl += "[" + strings.Join(params, ", ") + "]"
+	}
+	decl += " interface {\n" + strings.Join(elems, "\n") + "\n}"
+	formatted, err := format.Source([]byte(decl))
+	if err != nil {
+		return nil, nil, fmt.Errorf("formatting the new interface: %v", err)
+	}
+
+	var before ast.Node = path[len(path)-2] // the top-level declaration
+	switch d := before.(type) {
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			before = d.Doc
+		}
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			before = d.Doc
+		}
+	}
+	edits := []analysis.TextEdit{
+		{Pos: before.Pos(), End: before.Pos(), NewText: append(formatted, "\n\n"...)},
+		{Pos: field.Type.Pos(), End: field.Type.End(), NewText: []byte(name)},
+	}
+	if len(args) > 0 {
+		edits = append(edits, analysis.TextEdit{Pos: field.Type.End(), End: field.Type.End(), NewText: []byte("[" + strings.Join(args, ", ") + "]")})
+	}
+	return fset, &analysis.SuggestedFix{TextEdits: edits}, nil
+}
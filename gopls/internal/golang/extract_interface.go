@@ -0,0 +1,509 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the "extract interface" refactoring, which
+// declares an interface with some of the methods of a concrete type,
+// and optionally changes the type of function parameters from the
+// concrete type to the new interface.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/diff"
+	"golang.org/x/tools/internal/imports"
+)
+
+// An InterfaceParam is a group of function parameters of a concrete
+// type whose type may be changed to an interface extracted from it.
+type InterfaceParam struct {
+	Desc     string            // description, such as "F(a, b)"
+	Location protocol.Location // of the type of the parameters
+}
+
+// An interfaceExtraction holds the state of the extract interface
+// refactoring of a named type.
+type interfaceExtraction struct {
+	snapshot *cache.Snapshot
+	pkg      *cache.Package
+	pgf      *parsego.File // declaring the type
+	decl     *ast.GenDecl  // declaring the type
+	tname    *types.TypeName
+	named    *types.Named
+}
+
+// ExtractInterfaceMethods returns the name of the type named at rng,
+// and the names of the methods from which an interface can be
+// extracted, in order of declaration.
+func ExtractInterfaceMethods(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) (string, []string, error) {
+	x, err := newInterfaceExtraction(ctx, snapshot, fh, rng)
+	if err != nil {
+		return "", nil, err
+	}
+	var names []string
+	for _, m := range x.methods() {
+		names = append(names, m.Name())
+	}
+	return x.tname.Name(), names, nil
+}
+
+// ExtractInterfaceParams returns the groups of parameters of the
+// functions of the package of the type named at rng whose type could
+// be changed to an interface with the specified methods of the type:
+// the parameters of a function that is only ever called, whose uses
+// in the function body are all selections of those methods.
+func ExtractInterfaceParams(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range, methods []string) ([]InterfaceParam, error) {
+	x, err := newInterfaceExtraction(ctx, snapshot, fh, rng)
+	if err != nil {
+		return nil, err
+	}
+	chosen, err := x.choose(methods)
+	if err != nil {
+		return nil, err
+	}
+	var params []InterfaceParam
+	for _, p := range x.params(chosen) {
+		params = append(params, p.InterfaceParam)
+	}
+	return params, nil
+}
+
+// ExtractInterface returns the changes that declare an interface with
+// the specified methods of the type named at rng, just before the
+// declaration of the type, and that change the type of the specified
+// parameters (see [ExtractInterfaceParams]) to the interface. If name
+// is empty, the interface is named after its single method, as in
+// "Reader", or else after the type, as in "ServerInterface". It also
+// returns the location of the name of the interface after the changes.
+func ExtractInterface(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range, name string, methods []string, params []protocol.Location) ([]protocol.DocumentChange, *protocol.Location, error) {
+	x, err := newInterfaceExtraction(ctx, snapshot, fh, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	chosen, err := x.choose(methods)
+	if err != nil {
+		return nil, nil, err
+	}
+	scope := x.pkg.Types().Scope()
+	if name == "" {
+		name = defaultInterfaceName(scope, x.tname, chosen)
+	} else if !token.IsIdentifier(name) {
+		return nil, nil, fmt.Errorf("invalid interface name %q", name)
+	} else if scope.Lookup(name) != nil {
+		return nil, nil, fmt.Errorf("package %s already declares %s", x.pkg.Types().Name(), name)
+	}
+
+	edits := make(map[*parsego.File][]diff.Edit)
+
+	// Change the types of the parameters.
+	candidates := x.params(chosen)
+	for _, loc := range params {
+		found := false
+		for _, p := range candidates {
+			if p.Location.URI == loc.URI && protocol.Intersect(p.Location.Range, loc.Range) {
+				start, end, err := safetoken.Offsets(p.pgf.Tok, p.field.Type.Pos(), p.field.Type.End())
+				if err != nil {
+					return nil, nil, err
+				}
+				edits[p.pgf] = append(edits[p.pgf], diff.Edit{Start: start, End: end, New: name})
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("the parameter at %s:%d cannot have type %s", loc.URI.Path(), loc.Range.Start.Line+1, name)
+		}
+	}
+
+	// Declare the interface, adding any imports it needs.
+	text, fixes := x.declaration(name, chosen)
+	pos := x.decl.Pos()
+	if x.decl.Doc != nil {
+		pos = x.decl.Doc.Pos()
+	}
+	offset, err := safetoken.Offset(x.pgf.Tok, pos)
+	if err != nil {
+		return nil, nil, err
+	}
+	declIndex := len(edits[x.pgf])
+	edits[x.pgf] = append(edits[x.pgf], diff.Edit{Start: offset, End: offset, New: text})
+	if len(fixes) > 0 {
+		textedits, err := ComputeImportFixEdits(snapshot, x.pgf, fixes...)
+		if err != nil {
+			return nil, nil, err
+		}
+		importEdits, err := protocol.EditsToDiffEdits(x.pgf.Mapper, textedits)
+		if err != nil {
+			return nil, nil, err
+		}
+		edits[x.pgf] = append(edits[x.pgf], importEdits...)
+	}
+
+	// Select the name of the interface.
+	from := strings.Index(text, "type "+name+" ") + len("type ")
+	sel, err := editedLocation(x.pgf.URI, x.pgf.Src, edits[x.pgf], declIndex, from, from+len(name))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pgfs := make([]*parsego.File, 0, len(edits))
+	for pgf := range edits {
+		pgfs = append(pgfs, pgf)
+	}
+	sort.Slice(pgfs, func(i, j int) bool { return pgfs[i].URI < pgfs[j].URI })
+	var changes []protocol.DocumentChange
+	for _, pgf := range pgfs {
+		fh, err := snapshot.ReadFile(ctx, pgf.URI)
+		if err != nil {
+			return nil, nil, err
+		}
+		diff.SortEdits(edits[pgf])
+		textedits, err := protocol.EditsFromDiffEdits(pgf.Mapper, edits[pgf])
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, protocol.DocumentChangeEdit(fh, textedits))
+	}
+	return changes, &sel, nil
+}
+
+// newInterfaceExtraction returns the extraction of an interface from
+// the type named at rng.
+func newInterfaceExtraction(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) (*interfaceExtraction, error) {
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	if perrors, terrors := pkg.ParseErrors(), pkg.TypeErrors(); len(perrors) > 0 || len(terrors) > 0 {
+		return nil, fmt.Errorf("cannot extract an interface in package %s, which has errors", pkg.Types().Name())
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	decl, spec := typeSpecAt(pgf, start, end)
+	if spec == nil {
+		return nil, fmt.Errorf("no type is named at the selection")
+	}
+	tname, named, err := extractableType(pkg, spec)
+	if err != nil {
+		return nil, err
+	}
+	return &interfaceExtraction{
+		snapshot: snapshot,
+		pkg:      pkg,
+		pgf:      pgf,
+		decl:     decl,
+		tname:    tname,
+		named:    named,
+	}, nil
+}
+
+// typeSpecAt returns the top-level type declaration, and its spec,
+// whose name contains the range [start, end), if any.
+func typeSpecAt(pgf *parsego.File, start, end token.Pos) (*ast.GenDecl, *ast.TypeSpec) {
+	for _, decl := range pgf.File.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.TYPE {
+			for _, spec := range decl.Specs {
+				spec := spec.(*ast.TypeSpec)
+				if spec.Name.Pos() <= start && end <= spec.Name.End() {
+					return decl, spec
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// extractableType returns the named type declared by spec, or an
+// error if no interface can be extracted from it.
+func extractableType(pkg *cache.Package, spec *ast.TypeSpec) (*types.TypeName, *types.Named, error) {
+	tname, ok := pkg.TypesInfo().Defs[spec.Name].(*types.TypeName)
+	if !ok || tname.IsAlias() {
+		return nil, nil, fmt.Errorf("%s is not a defined type", spec.Name.Name)
+	}
+	named, ok := tname.Type().(*types.Named)
+	if !ok || types.IsInterface(named) {
+		return nil, nil, fmt.Errorf("%s is not a concrete type", tname.Name())
+	}
+	if named.TypeParams().Len() > 0 {
+		return nil, nil, fmt.Errorf("cannot extract an interface from generic type %s", tname.Name())
+	}
+	if types.NewMethodSet(types.NewPointer(named)).Len() == 0 {
+		return nil, nil, fmt.Errorf("%s has no methods", tname.Name())
+	}
+	return tname, named, nil
+}
+
+// methods returns the methods of the type, or of a pointer to it,
+// that an interface declared in the type's package can have, in
+// order of declaration.
+func (x *interfaceExtraction) methods() []*types.Func {
+	mset := types.NewMethodSet(types.NewPointer(x.named))
+	var methods []*types.Func
+	for i := 0; i < mset.Len(); i++ {
+		m := mset.At(i).Obj().(*types.Func)
+		if !m.Exported() && m.Pkg() != x.pkg.Types() {
+			continue // promoted from another package, so not nameable
+		}
+		methods = append(methods, m)
+	}
+	sort.SliceStable(methods, func(i, j int) bool { return methods[i].Pos() < methods[j].Pos() })
+	return methods
+}
+
+// choose returns the methods with the specified names, in order of
+// declaration.
+func (x *interfaceExtraction) choose(names []string) ([]*types.Func, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no methods were chosen for the interface")
+	}
+	want := make(map[string]bool)
+	for _, name := range names {
+		want[name] = true
+	}
+	var chosen []*types.Func
+	for _, m := range x.methods() {
+		if want[m.Name()] {
+			chosen = append(chosen, m)
+			delete(want, m.Name())
+		}
+	}
+	for _, name := range names {
+		if want[name] {
+			return nil, fmt.Errorf("%s has no method %s", x.tname.Name(), name)
+		}
+	}
+	return chosen, nil
+}
+
+// inValueMethodSet reports whether all the methods are in the method
+// set of the type itself, as opposed to a pointer to it.
+func (x *interfaceExtraction) inValueMethodSet(methods []*types.Func) bool {
+	mset := types.NewMethodSet(x.named)
+	for _, m := range methods {
+		if mset.Lookup(m.Pkg(), m.Name()) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// declaration returns the text of the declaration of the interface
+// with the specified name and methods, followed by a blank line, and
+// the imports that the file declaring the type needs for it.
+func (x *interfaceExtraction) declaration(name string, methods []*types.Func) (string, []*imports.ImportFix) {
+	// Qualify types relative to the imports of the declaring file,
+	// adding imports as needed.
+	adder := newImportAdder(x.snapshot, x.pkg, x.pgf)
+	qual := adder.qualifier
+
+	// Find the doc comments of the methods declared in the package.
+	docs := make(map[*types.Func]*ast.CommentGroup)
+	for _, pgf := range x.pkg.CompiledGoFiles() {
+		for _, decl := range pgf.File.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil && fn.Doc != nil {
+				if m, ok := x.pkg.TypesInfo().Defs[fn.Name].(*types.Func); ok {
+					docs[m] = fn.Doc
+				}
+			}
+		}
+	}
+
+	impl := x.tname.Name()
+	if !x.inValueMethodSet(methods) {
+		impl = "*" + impl
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "// %s is implemented by %s.\n", name, impl)
+	fmt.Fprintf(&buf, "type %s interface {\n", name)
+	for _, m := range methods {
+		if doc := docs[m]; doc != nil {
+			for _, line := range strings.Split(strings.TrimSuffix(doc.Text(), "\n"), "\n") {
+				if line == "" {
+					buf.WriteString("\t//\n")
+				} else {
+					fmt.Fprintf(&buf, "\t// %s\n", line)
+				}
+			}
+		}
+		sig := types.TypeString(m.Type(), qual)
+		fmt.Fprintf(&buf, "\t%s%s\n", m.Name(), strings.TrimPrefix(sig, "func"))
+	}
+	buf.WriteString("}\n\n")
+	return buf.String(), adder.fixes
+}
+
+// An interfaceParam is a group of parameters, declared by one field
+// of a function's parameter list, whose type may be changed.
+type interfaceParam struct {
+	InterfaceParam
+	pgf   *parsego.File
+	field *ast.Field
+}
+
+// params returns the groups of parameters of type T or *T, for the
+// type T, whose type may be changed to an interface with the
+// specified methods: those of functions that are only ever called,
+// whose uses in the body of the function are all selections of the
+// methods.
+//
+// TODO: references to exported functions in other packages
+// are not checked, so a function used as a value in another package
+// may become inconsistent with its use.
+func (x *interfaceExtraction) params(methods []*types.Func) []interfaceParam {
+	info := x.pkg.TypesInfo()
+	names := make(map[string]bool)
+	for _, m := range methods {
+		names[m.Name()] = true
+	}
+	valueOK := x.inValueMethodSet(methods)
+
+	// Find the functions that are used other than by being called.
+	called := make(map[*ast.Ident]bool)
+	for _, pgf := range x.pkg.CompiledGoFiles() {
+		ast.Inspect(pgf.File, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if id, ok := astutil.Unparen(call.Fun).(*ast.Ident); ok {
+					called[id] = true
+				}
+			}
+			return true
+		})
+	}
+	values := make(map[types.Object]bool)
+	for id, obj := range info.Uses {
+		if _, ok := obj.(*types.Func); ok && !called[id] {
+			values[obj] = true
+		}
+	}
+
+	var params []interfaceParam
+	for _, pgf := range x.pkg.CompiledGoFiles() {
+		for _, decl := range pgf.File.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil || fn.Type.TypeParams != nil {
+				continue
+			}
+			if values[info.Defs[fn.Name]] {
+				continue
+			}
+
+			// Record the method selected by each selection in the body.
+			selected := make(map[*ast.Ident]string)
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok {
+					if id, ok := sel.X.(*ast.Ident); ok {
+						selected[id] = sel.Sel.Name
+					}
+				}
+				return true
+			})
+
+			for _, field := range fn.Type.Params.List {
+				t := info.TypeOf(field.Type)
+				if ptr, ok := t.(*types.Pointer); ok {
+					t = ptr.Elem()
+				} else if !valueOK {
+					continue // a T would not implement the interface
+				}
+				if t != x.named || len(field.Names) == 0 {
+					continue
+				}
+				ok := true
+				var descs []string
+				for _, name := range field.Names {
+					obj := info.Defs[name]
+					if obj == nil {
+						ok = false
+						break
+					}
+					descs = append(descs, name.Name)
+					ast.Inspect(fn.Body, func(n ast.Node) bool {
+						if id, isIdent := n.(*ast.Ident); isIdent && info.Uses[id] == obj && !names[selected[id]] {
+							ok = false
+						}
+						return ok
+					})
+				}
+				if !ok {
+					continue
+				}
+				loc, err := pgf.NodeLocation(field.Type)
+				if err != nil {
+					continue
+				}
+				params = append(params, interfaceParam{
+					InterfaceParam: InterfaceParam{
+						Desc:     fmt.Sprintf("%s(%s)", fn.Name.Name, strings.Join(descs, ", ")),
+						Location: loc,
+					},
+					pgf:   pgf,
+					field: field,
+				})
+			}
+		}
+	}
+	return params
+}
+
+// agentNoun returns the name of the method with the suffix "er", as
+// in "Reader", "Closer", or "Getter".
+func agentNoun(name string) string {
+	if strings.HasSuffix(name, "e") {
+		return name + "r"
+	}
+	// Double the final consonant of a word of one syllable that
+	// ends with a single vowel and consonant, as in "Getter".
+	isVowel := func(b byte) bool { return strings.IndexByte("aeiouAEIOU", b) >= 0 }
+	syllables := 0
+	for i := 0; i < len(name); i++ {
+		if isVowel(name[i]) && (i == 0 || !isVowel(name[i-1])) {
+			syllables++
+		}
+	}
+	if n := len(name); syllables == 1 && n >= 3 &&
+		!isVowel(name[n-1]) && strings.IndexByte("wxy", name[n-1]) < 0 &&
+		isVowel(name[n-2]) && !isVowel(name[n-3]) {
+		name += name[n-1:]
+	}
+	return name + "er"
+}
+
+// defaultInterfaceName returns a name for an interface of the type
+// with the specified methods that is not declared in scope: the agent
+// noun of its single method, or else the name of the type plus
+// "Interface", exported only if the type is.
+func defaultInterfaceName(scope *types.Scope, tname *types.TypeName, methods []*types.Func) string {
+	base := tname.Name() + "Interface"
+	if len(methods) == 1 {
+		base = agentNoun(methods[0].Name())
+	}
+	r, size := utf8.DecodeRuneInString(base)
+	if tname.Exported() {
+		base = string(unicode.ToUpper(r)) + base[size:]
+	} else {
+		base = string(unicode.ToLower(r)) + base[size:]
+	}
+	name := base
+	for i := 2; scope.Lookup(name) != nil; i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+	return name
+}
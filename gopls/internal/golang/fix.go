@@ -10,10 +10,13 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"regexp"
+	"strings"
 
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/gopls/internal/analysis/embeddirective"
 	"golang.org/x/tools/gopls/internal/analysis/fillstruct"
+	"golang.org/x/tools/gopls/internal/analysis/naming"
 	"golang.org/x/tools/gopls/internal/analysis/stubmethods"
 	"golang.org/x/tools/gopls/internal/analysis/undeclaredname"
 	"golang.org/x/tools/gopls/internal/analysis/unusedparams"
@@ -22,6 +25,8 @@ import (
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/util/bug"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/diff"
 	"golang.org/x/tools/internal/imports"
 )
 
@@ -57,15 +62,42 @@ func singleFile(fixer1 singleFileFixer) fixer {
 	}
 }
 
+// fillStruct returns a fixer that fills a struct literal as configured
+// by opts, to the depth of nesting given by the fillStructDepth setting.
+func fillStruct(opts fillstruct.Options) fixer {
+	return func(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+		opts.Depth = snapshot.Options().FillStructDepth
+		return fillstruct.Fill(pkg.FileSet(), start, pgf.Src, pgf.File, pkg.Types(), pkg.TypesInfo(), opts)
+	}
+}
+
 // Names of ApplyFix.Fix created directly by the CodeAction handler.
 const (
-	fixExtractVariable   = "extract_variable"
-	fixExtractFunction   = "extract_function"
-	fixExtractMethod     = "extract_method"
-	fixInlineCall        = "inline_call"
-	fixInvertIfCondition = "invert_if_condition"
-	fixSplitLines        = "split_lines"
-	fixJoinLines         = "join_lines"
+	fixExtractVariable    = "extract_variable"
+	fixExtractFunction    = "extract_function"
+	fixExtractMethod      = "extract_method"
+	fixExtractConstraint  = "extract_constraint"
+	fixExtractConstant    = "extract_constant"
+	fixExtractConstantAll = "extract_constant_all"
+	fixInlineCall         = "inline_call"
+	fixInvertIfCondition  = "invert_if_condition"
+	fixIfToSwitch         = "if_to_switch"
+	fixSwitchToIf         = "switch_to_if"
+	fixIndexToRange       = "index_to_range"
+	fixRangeToIndex       = "range_to_index"
+	fixConcatToSprintf    = "concat_to_sprintf"
+	fixSprintfToConcat    = "sprintf_to_concat"
+	fixStringBuilder      = "string_builder"
+	fixSplitLines         = "split_lines"
+	fixJoinLines          = "join_lines"
+	fixPromoteFuncLit     = "promote_func_lit"
+	fixInlineFuncAsLit    = "inline_func_as_lit"
+	fixWrapError          = "wrap_error"
+	fixHandleError        = "handle_error"
+	fixAddErrorResult     = "add_error_result"
+	fixAddTest            = "add_test"
+	fixGenerateString     = "generate_string"
+	fixGenerateJSON       = "generate_json"
 )
 
 // ApplyFix applies the specified kind of suggested fix to the given
@@ -83,54 +115,243 @@ const (
 // impossible to distinguish. It would more precise if there was a
 // SuggestedFix.Category field, or some other way to squirrel metadata
 // in the fix.
-func ApplyFix(ctx context.Context, fix string, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]protocol.DocumentChange, error) {
+//
+// ApplyFix also returns the suggested selection in the fixed file after
+// the changes are applied, if any, such as the name of an extracted
+// function, so that clients need not leave the cursor where the
+// edits happen to end.
+func ApplyFix(ctx context.Context, fix string, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]protocol.DocumentChange, *protocol.Location, error) {
 	// This can't be expressed as an entry in the fixer table below
 	// because it operates in the protocol (not go/{token,ast}) domain.
 	// (Sigh; perhaps it was a mistake to factor out the
 	// NarrowestPackageForFile/RangePos/suggestedFixToEdits
 	// steps.)
 	if fix == unusedparams.FixCategory {
-		return RemoveUnusedParameter(ctx, fh, rng, snapshot)
+		changes, err := RemoveUnusedParameter(ctx, fh, rng, snapshot)
+		return changes, nil, err
+	}
+	if fix == naming.FixCategory {
+		changes, err := RenameToConvention(ctx, snapshot, fh, rng)
+		return changes, nil, err
+	}
+	if fix == fixAddErrorResult {
+		changes, err := AddErrorResult(ctx, snapshot, fh, rng)
+		return changes, nil, err
+	}
+	if fix == fixAddTest {
+		return AddTest(ctx, snapshot, fh, rng)
 	}
 
 	fixers := map[string]fixer{
 		// Fixes for analyzer-provided diagnostics.
 		// These match the Diagnostic.Category.
-		embeddirective.FixCategory: addEmbedImport,
-		fillstruct.FixCategory:     singleFile(fillstruct.SuggestedFix),
-		stubmethods.FixCategory:    stubMethodsFixer,
-		undeclaredname.FixCategory: singleFile(undeclaredname.SuggestedFix),
+		embeddirective.FixCategory:     addEmbedImport,
+		fillstruct.FixCategory:         fillStruct(fillstruct.Options{}),
+		fillstruct.FixCategoryExported: fillStruct(fillstruct.Options{ExportedOnly: true}),
+		fillstruct.FixCategoryInScope:  fillStruct(fillstruct.Options{InScope: true}),
+		stubmethods.FixCategory:        stubMethodsFixer,
+		undeclaredname.FixCategory:     singleFile(undeclaredname.SuggestedFix),
 
 		// Ad-hoc fixers: these are used when the command is
 		// constructed directly by logic in server/code_action.
-		fixExtractFunction:   singleFile(extractFunction),
-		fixExtractMethod:     singleFile(extractMethod),
-		fixExtractVariable:   singleFile(extractVariable),
-		fixInlineCall:        inlineCall,
-		fixInvertIfCondition: singleFile(invertIfCondition),
-		fixSplitLines:        singleFile(splitLines),
-		fixJoinLines:         singleFile(joinLines),
+		fixExtractFunction:    singleFile(extractFunction),
+		fixExtractMethod:      singleFile(extractMethod),
+		fixExtractVariable:    singleFile(extractVariable),
+		fixExtractConstraint:  singleFile(extractConstraint),
+		fixExtractConstant:    extractConstant,
+		fixExtractConstantAll: extractConstantAll,
+		fixInlineCall:         inlineCall,
+		fixInvertIfCondition:  singleFile(invertIfCondition),
+		fixIfToSwitch:         singleFile(ifToSwitch),
+		fixSwitchToIf:         singleFile(switchToIf),
+		fixIndexToRange:       singleFile(indexToRange),
+		fixRangeToIndex:       singleFile(rangeToIndex),
+		fixConcatToSprintf:    concatToSprintf,
+		fixSprintfToConcat:    sprintfToConcat,
+		fixStringBuilder:      useStringBuilder,
+		fixSplitLines:         singleFile(splitLines),
+		fixJoinLines:          singleFile(joinLines),
+		fixPromoteFuncLit:     singleFile(promoteFuncLit),
+		fixInlineFuncAsLit:    inlineFuncAsLiteral,
+		fixWrapError:          wrapError,
+		fixHandleError:        handleError,
+		fixGenerateString:     generateString,
+		fixGenerateJSON:       generateJSON,
 	}
 	fixer, ok := fixers[fix]
 	if !ok {
-		return nil, fmt.Errorf("no suggested fix function for %s", fix)
+		return nil, nil, fmt.Errorf("no suggested fix function for %s", fix)
 	}
 	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	start, end, err := pgf.RangePos(rng)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	fixFset, suggestion, err := fixer(ctx, snapshot, pkg, pgf, start, end)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if suggestion == nil {
+		return nil, nil, nil
+	}
+	changes, err := suggestedFixToDocumentChange(ctx, snapshot, fixFset, suggestion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Fixes that suggest a selection after they are applied.
+	selectors := map[string]selector{
+		fillstruct.FixCategory:         selectFirstFieldValue,
+		fillstruct.FixCategoryExported: selectFirstFieldValue,
+		fillstruct.FixCategoryInScope:  selectFirstFieldValue,
+		fixExtractFunction:             selectExtractedName,
+		fixExtractMethod:               selectExtractedName,
+		fixExtractVariable:             selectExtractedName,
+		fixExtractConstraint:           selectExtractedName,
+		fixExtractConstant:             selectExtractedName,
+		fixExtractConstantAll:          selectExtractedName,
+		fixPromoteFuncLit:              selectExtractedName,
+		fixWrapError:                   selectErrorMessage,
+		fixHandleError:                 selectErrorMessage,
+	}
+	var sel *protocol.Location
+	if selector, ok := selectors[fix]; ok {
+		sel, err = fixSelection(pgf, fixFset, suggestion, start, end, selector)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return changes, sel, nil
+}
+
+// fixSelection returns the selection chosen by the selector in the
+// file pgf after the edits of the suggested fix, which was applied
+// to the range [start, end) of the file, or nil if there is none.
+func fixSelection(pgf *parsego.File, fset *token.FileSet, suggestion *analysis.SuggestedFix, start, end token.Pos, selector selector) (*protocol.Location, error) {
+	var edits []diff.Edit
+	for _, edit := range suggestion.TextEdits {
+		tokFile := fset.File(edit.Pos)
+		if tokFile == nil || protocol.URIFromPath(tokFile.Name()) != pgf.URI {
+			continue // edit of another file
+		}
+		end := edit.End
+		if !end.IsValid() {
+			end = edit.Pos
+		}
+		startOffset, endOffset, err := safetoken.Offsets(tokFile, edit.Pos, end)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, diff.Edit{Start: startOffset, End: endOffset, New: string(edit.NewText)})
+	}
+	startOffset, endOffset, err := safetoken.Offsets(pgf.Tok, start, end)
+	if err != nil {
+		return nil, err
+	}
+	i, from, to, ok := selector(edits, startOffset, endOffset)
+	if !ok {
 		return nil, nil
 	}
-	return suggestedFixToDocumentChange(ctx, snapshot, fixFset, suggestion)
+	loc, err := editedLocation(pgf.URI, pgf.Src, edits, i, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
+
+// A selector chooses the text to select after a fix is applied,
+// given its edits to a file and the offsets of the range to which it
+// was applied. It returns the index of an edit and the range of its
+// new text to select, or ok=false if there is no such text.
+type selector func(edits []diff.Edit, start, end int) (i, from, to int, ok bool)
+
+// selectFirstFieldValue selects the value of the first field of a
+// composite literal filled in by fillstruct, so that typing replaces
+// the zero value. The values of fields filled with nested literals
+// span several lines; the first field of the innermost is selected.
+func selectFirstFieldValue(edits []diff.Edit, _, _ int) (int, int, int, bool) {
+	for i, edit := range edits {
+		for start := 0; ; {
+			colon := strings.Index(edit.New[start:], ": ")
+			if colon < 0 {
+				break
+			}
+			from := start + colon + len(": ")
+			for from < len(edit.New) && edit.New[from] == ' ' {
+				from++ // values are aligned
+			}
+			n := strings.IndexByte(edit.New[from:], '\n')
+			if n < 0 {
+				break
+			}
+			if n > 0 && edit.New[from+n-1] == ',' {
+				return i, from, from + n - 1, true
+			}
+			start = from + n // a nested literal
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// funcName matches the name of a function or method declaration.
+var funcName = regexp.MustCompile(`(?m)^func (?:\([^)]*\) )?(\w+)`)
+
+// selectExtractedName selects the first occurrence of the name that
+// replaces extracted code, that of the new variable, or that of the
+// function or method last declared by the edits, so that it can be
+// renamed immediately.
+func selectExtractedName(edits []diff.Edit, start, end int) (int, int, int, bool) {
+	for i, edit := range edits {
+		if !(edit.Start <= start && end <= edit.End) {
+			continue // not the edit of the extracted code
+		}
+		name := edit.New
+		if !token.IsIdentifier(name) {
+			name = ""
+			for _, edit := range edits {
+				if matches := funcName.FindAllStringSubmatch(edit.New, -1); len(matches) > 0 {
+					name = matches[len(matches)-1][1]
+				}
+			}
+			if name == "" {
+				break
+			}
+		}
+		word := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+		if loc := word.FindStringIndex(edit.New); loc != nil {
+			return i, loc[0], loc[1], true
+		}
+		break
+	}
+	return 0, 0, 0, false
+}
+
+// editedLocation returns the location, in the content that results
+// from applying the edits to the file, of the text new[from:to]
+// inserted by edits[i].
+func editedLocation(uri protocol.DocumentURI, content []byte, edits []diff.Edit, i, from, to int) (protocol.Location, error) {
+	edited, err := diff.Apply(string(content), edits)
+	if err != nil {
+		return protocol.Location{}, err
+	}
+	// Account for the edits that precede edits[i] in the order of
+	// diff.SortEdits, which is stable.
+	target := edits[i]
+	offset := target.Start
+	for j, edit := range edits {
+		if edit.Start < target.Start ||
+			edit.Start == target.Start && (edit.End < target.End || edit.End == target.End && j < i) {
+			offset += len(edit.New) - (edit.End - edit.Start)
+		}
+	}
+	rng, err := protocol.NewMapper(uri, []byte(edited)).OffsetRange(offset+from, offset+to)
+	if err != nil {
+		return protocol.Location{}, err
+	}
+	return protocol.Location{URI: uri, Range: rng}, nil
 }
 
 // suggestedFixToDocumentChange converts the suggestion's edits from analysis form into protocol form.
@@ -0,0 +1,202 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+)
+
+// FixAll runs the named analyzer over the package of the specified Go
+// file or, if uri denotes a directory, over the workspace packages in
+// that directory and its subdirectories, like RunAnalyzer, and returns
+// the combined edits of the quick fixes of its diagnostics, along with
+// the number of diagnostics they fix.
+//
+// Unlike RunAnalyzer, it respects the diagnostic options, so that it
+// fixes only what would be reported, and it does not edit generated
+// files. A fix whose edits overlap those of a fix of an earlier
+// diagnostic is skipped, so that all the edits can be applied at once;
+// its diagnostic remains, to be fixed by a later application.
+func FixAll(ctx context.Context, snapshot *cache.Snapshot, analyzer string, uri protocol.DocumentURI) ([]protocol.DocumentChange, int, error) {
+	diags, err := RunAnalyzer(ctx, snapshot, analyzer, uri)
+	if err != nil {
+		return nil, 0, err
+	}
+	diags, err = applyDiagnosticOptions(ctx, snapshot, diags)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// A file may belong to several of the analyzed packages (such as
+	// a package and its test variant), so visit each diagnostic once,
+	// in order of position.
+	sort.Slice(diags, func(i, j int) bool {
+		x, y := diags[i], diags[j]
+		if x.URI != y.URI {
+			return x.URI < y.URI
+		}
+		if c := protocol.CompareRange(x.Range, y.Range); c != 0 {
+			return c < 0
+		}
+		return x.Message < y.Message
+	})
+
+	var (
+		edits     = make(map[protocol.DocumentURI][]protocol.TextEdit)
+		generated = make(map[protocol.DocumentURI]bool)
+		fixed     = 0
+	)
+	for i, diag := range diags {
+		if i > 0 && sameDiagnostic(diags[i-1], diag) {
+			continue
+		}
+		fix := QuickFixEdits(diag)
+		if fix == nil {
+			continue
+		}
+		ok := true
+		for uri, fileEdits := range fix {
+			isGenerated, seen := generated[uri]
+			if !seen {
+				isGenerated = IsGenerated(ctx, snapshot, uri)
+				generated[uri] = isGenerated
+			}
+			if isGenerated || !compatibleEdits(edits[uri], fileEdits) {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		for uri, fileEdits := range fix {
+			edits[uri] = addEdits(edits[uri], fileEdits)
+		}
+		fixed++
+	}
+
+	var changes []protocol.DocumentChange
+	for uri, fileEdits := range edits {
+		fh, err := snapshot.ReadFile(ctx, uri)
+		if err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, protocol.DocumentChangeEdit(fh, fileEdits))
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].TextDocumentEdit.TextDocument.URI < changes[j].TextDocumentEdit.TextDocument.URI
+	})
+	return changes, fixed, nil
+}
+
+// FixAllCodeActions returns the code actions that fix all the
+// diagnostics of the analyzer that reported pd, a diagnostic of the
+// specified file with a quick fix, in the package of the file and in
+// the workspace. The caller provides the numbers of diagnostics with
+// quick fixes known in each scope; an action is offered only if its
+// scope has others than pd. Analyzers run only on the packages of open
+// files, so the number in the workspace is a lower bound, and unlike
+// the number in the package it is not shown in the title.
+func FixAllCodeActions(snapshot *cache.Snapshot, uri protocol.DocumentURI, pd protocol.Diagnostic, inPackage, inWorkspace int) ([]protocol.CodeAction, error) {
+	name, ok := cache.AnalyzerName(pd.Source)
+	if !ok {
+		return nil, nil // not an analyzer diagnostic
+	}
+	if _, err := lookupAnalyzer(snapshot, name); err != nil {
+		return nil, nil // not a known analyzer
+	}
+	options := snapshot.Options()
+	var actions []protocol.CodeAction
+	add := func(title string, uri protocol.DocumentURI) error {
+		cmd, err := command.NewFixAllCommand(title, command.FixAllArgs{
+			Analyzer:     name,
+			URI:          uri,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return err
+		}
+		actions = append(actions, newCodeAction(cmd.Title, protocol.QuickFix, &cmd, []protocol.Diagnostic{pd}, options))
+		return nil
+	}
+	if inPackage > 1 {
+		if err := add(fmt.Sprintf("Fix all %d %s diagnostics in package", inPackage, name), uri); err != nil {
+			return nil, err
+		}
+	}
+	if inWorkspace > 1 {
+		if err := add(fmt.Sprintf("Fix all %s diagnostics in workspace", name), snapshot.Folder()); err != nil {
+			return nil, err
+		}
+	}
+	return actions, nil
+}
+
+// QuickFixEdits returns the edits of the first quick fix of diag that
+// consists only of edits, or nil if it has none.
+func QuickFixEdits(diag *cache.Diagnostic) map[protocol.DocumentURI][]protocol.TextEdit {
+	for _, fix := range diag.SuggestedFixes {
+		if fix.ActionKind == protocol.QuickFix && fix.Command == nil && len(fix.Edits) > 0 {
+			return fix.Edits
+		}
+	}
+	return nil
+}
+
+// sameDiagnostic reports whether x and y are the same diagnostic,
+// reported for different packages containing its file.
+func sameDiagnostic(x, y *cache.Diagnostic) bool {
+	return x.URI == y.URI && x.Range == y.Range && x.Message == y.Message
+}
+
+// compatibleEdits reports whether the edits of a fix may be added to
+// the accepted edits of a file: each must be identical to an accepted
+// edit, or must not overlap any. Insertions at the same position are
+// compatible, as the fixes of several diagnostics may each add an
+// import, for example.
+func compatibleEdits(accepted, edits []protocol.TextEdit) bool {
+	for _, edit := range edits {
+		for _, prev := range accepted {
+			if edit == prev {
+				break
+			}
+			if overlaps(edit.Range, prev.Range) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// overlaps reports whether the ranges of two edits overlap, treating
+// an insertion at the start or end of a replaced range as not
+// overlapping it.
+func overlaps(x, y protocol.Range) bool {
+	if x == y {
+		return x.Start != x.End // two insertions at the same position do not overlap
+	}
+	return protocol.ComparePosition(x.Start, y.End) < 0 && protocol.ComparePosition(y.Start, x.End) < 0
+}
+
+// addEdits adds the edits of a fix to the accepted edits of a file,
+// omitting those that were already accepted.
+func addEdits(accepted, edits []protocol.TextEdit) []protocol.TextEdit {
+outer:
+	for _, edit := range edits {
+		for _, prev := range accepted {
+			if edit == prev {
+				continue outer
+			}
+		}
+		accepted = append(accepted, edit)
+	}
+	return accepted
+}
@@ -17,6 +17,7 @@ import (
 	"strings"
 	"text/scanner"
 
+	"golang.org/x/tools/gopls/internal/baseline"
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/cache/parsego"
 	"golang.org/x/tools/gopls/internal/file"
@@ -29,6 +30,30 @@ import (
 	"golang.org/x/tools/internal/tokeninternal"
 )
 
+// FormatExcluded reports whether formatting and the organization of
+// imports are disabled for the file by the formatExclusions setting,
+// or by the directive of the formatSkipDirective setting before its
+// package clause.
+func FormatExcluded(snapshot *cache.Snapshot, pgf *parsego.File) bool {
+	opts := snapshot.Options()
+	if opts.FormatExcluded(baseline.RelPath(snapshot.Folder().Path(), pgf.URI.Path())) {
+		return true
+	}
+	if directive := opts.FormatSkipDirective; directive != "" {
+		for _, cg := range pgf.File.Comments {
+			if cg.Pos() >= pgf.File.Package {
+				break
+			}
+			for _, c := range cg.List {
+				if strings.TrimSpace(c.Text) == directive {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // Format formats a file with a given range.
 func Format(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]protocol.TextEdit, error) {
 	ctx, done := event.Start(ctx, "golang.Format")
@@ -43,6 +68,10 @@ func Format(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]pr
 	if err != nil {
 		return nil, err
 	}
+	if FormatExcluded(snapshot, pgf) {
+		event.Log(ctx, fmt.Sprintf("not formatting %s: excluded by settings", fh.URI().Path()))
+		return nil, nil
+	}
 	// Even if this file has parse errors, it might still be possible to format it.
 	// Using format.Node on an AST with errors may result in code being modified.
 	// Attempt to format the source of this file instead.
@@ -118,6 +147,7 @@ func allImportsFixes(ctx context.Context, snapshot *cache.Snapshot, pgf *parsego
 	defer done()
 
 	if err := snapshot.RunProcessEnvFunc(ctx, func(ctx context.Context, opts *imports.Options) error {
+		opts.ModulePath = snapshot.ModulePathForFile(pgf.URI)
 		allFixEdits, editsPerFix, err = computeImportEdits(ctx, pgf, opts)
 		return err
 	}); err != nil {
@@ -159,8 +189,15 @@ func computeImportEdits(ctx context.Context, pgf *parsego.File, options *imports
 
 // ComputeOneImportFixEdits returns text edits for a single import fix.
 func ComputeOneImportFixEdits(snapshot *cache.Snapshot, pgf *parsego.File, fix *imports.ImportFix) ([]protocol.TextEdit, error) {
+	return ComputeImportFixEdits(snapshot, pgf, fix)
+}
+
+// ComputeImportFixEdits returns text edits for a set of import fixes.
+func ComputeImportFixEdits(snapshot *cache.Snapshot, pgf *parsego.File, fixes ...*imports.ImportFix) ([]protocol.TextEdit, error) {
 	options := &imports.Options{
 		LocalPrefix: snapshot.Options().Local,
+		Groups:      snapshot.Options().ImportGroups,
+		ModulePath:  snapshot.ModulePathForFile(pgf.URI),
 		// Defaults.
 		AllErrors:  true,
 		Comments:   true,
@@ -169,7 +206,7 @@ func ComputeOneImportFixEdits(snapshot *cache.Snapshot, pgf *parsego.File, fix *
 		TabIndent:  true,
 		TabWidth:   8,
 	}
-	return computeFixEdits(pgf, options, []*imports.ImportFix{fix})
+	return computeFixEdits(pgf, options, fixes)
 }
 
 func computeFixEdits(pgf *parsego.File, options *imports.Options, fixes []*imports.ImportFix) ([]protocol.TextEdit, error) {
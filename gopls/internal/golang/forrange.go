@@ -0,0 +1,466 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the code actions that convert a three-clause for
+// loop over the indices of a slice, or over the integers up to n, into
+// a range loop, and back.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/versions"
+)
+
+// indexLoop is a loop "for i := 0; i < len(s); i++" or, if s is nil,
+// "for i := 0; i < n; i++", that may be converted to a range loop.
+type indexLoop struct {
+	stmt  *ast.ForStmt
+	index *types.Var // i
+	s     ast.Expr   // the ranged-over slice or array, or nil
+	n     ast.Expr   // the bound n, or len(s)
+	elems []ast.Expr // the elements s[i], replaced by the value variable
+	value string     // the name of the value variable, if elems is non-empty
+	used  bool       // whether i has uses other than elems
+}
+
+// canConvertToRange reports whether the range [start, end) is on the
+// header of a for loop over indices that may be converted to a range
+// loop.
+func canConvertToRange(file *ast.File, info *types.Info, start, end token.Pos) (*indexLoop, bool) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	var stmt *ast.ForStmt
+	for _, n := range path {
+		if s, ok := n.(*ast.ForStmt); ok {
+			stmt = s
+			break
+		}
+	}
+	if stmt == nil || within(stmt.Body, start, end) {
+		return nil, false
+	}
+
+	// for i := 0; i < n; i++
+	init, ok := stmt.Init.(*ast.AssignStmt)
+	if !ok || init.Tok != token.DEFINE || len(init.Lhs) != 1 || len(init.Rhs) != 1 {
+		return nil, false
+	}
+	if tv := info.Types[init.Rhs[0]]; tv.Value == nil || tv.Value.String() != "0" {
+		return nil, false
+	}
+	id, ok := init.Lhs[0].(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+	index, ok := info.Defs[id].(*types.Var)
+	if !ok || !types.Identical(index.Type(), types.Typ[types.Int]) {
+		return nil, false
+	}
+	cond, ok := stmt.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.LSS || !isVar(info, cond.X, index) {
+		return nil, false
+	}
+	switch post := stmt.Post.(type) {
+	case *ast.IncDecStmt:
+		if post.Tok != token.INC || !isVar(info, post.X, index) {
+			return nil, false
+		}
+	case *ast.AssignStmt:
+		if post.Tok != token.ADD_ASSIGN || !isVar(info, post.Lhs[0], index) {
+			return nil, false
+		}
+		if tv := info.Types[post.Rhs[0]]; tv.Value == nil || tv.Value.String() != "1" {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	if assigned(info, stmt.Body, index) || !invariant(info, stmt.Body, cond.Y) {
+		return nil, false
+	}
+
+	loop := &indexLoop{stmt: stmt, index: index, n: cond.Y}
+
+	// Range over s if the bound is len(s), for a slice or array s.
+	if call, ok := cond.Y.(*ast.CallExpr); ok && len(call.Args) == 1 && isBuiltinFunc(info, call.Fun, "len") && indexable(info.TypeOf(call.Args[0])) {
+		loop.s = call.Args[0]
+	} else if v := versions.FileVersion(info, file); versions.Before(v, versions.Go1_22) {
+		return nil, false // range over int needs go1.22
+	} else if tv := info.Types[cond.Y]; !types.Identical(tv.Type, types.Typ[types.Int]) && !(tv.Value != nil && types.Identical(types.Default(tv.Type), types.Typ[types.Int])) {
+		return nil, false
+	}
+
+	// Find the uses of i, and the elements s[i] that may be replaced by
+	// the value variable if s is not written by the loop.
+	var elems []ast.Expr
+	ast.Inspect(stmt.Body, func(n ast.Node) bool {
+		if loop.s != nil {
+			if e, ok := n.(*ast.IndexExpr); ok && types.ExprString(e.X) == types.ExprString(loop.s) && isVar(info, e.Index, index) {
+				elems = append(elems, e)
+				return false
+			}
+		}
+		if id, ok := n.(*ast.Ident); ok && info.Uses[id] == index {
+			loop.used = true
+		}
+		return true
+	})
+	if len(elems) > 0 {
+		if elemWritten(info, stmt.Body, loop.s) {
+			loop.used = true // keep s[i]
+		} else {
+			loop.elems = elems
+			loop.value = freshLoopVar(info, stmt, "v")
+		}
+	}
+	return loop, true
+}
+
+// indexToRange is a singleFileFixer that converts a for loop over
+// indices to a range loop.
+func indexToRange(fset *token.FileSet, start, end token.Pos, src []byte, file *ast.File, _ *types.Package, info *types.Info) (*token.FileSet, *analysis.SuggestedFix, error) {
+	loop, ok := canConvertToRange(file, info, start, end)
+	if !ok {
+		return nil, nil, fmt.Errorf("no loop over indices to convert")
+	}
+	tok := fset.File(file.Pos())
+	x := loop.n
+	if loop.s != nil {
+		x = loop.s
+	}
+	xtext, err := nodeSource(tok, src, x)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var vars string
+	switch {
+	case loop.used && loop.elems != nil:
+		vars = loop.index.Name() + ", " + loop.value + " := "
+	case loop.used:
+		vars = loop.index.Name() + " := "
+	case loop.elems != nil:
+		vars = "_, " + loop.value + " := "
+	}
+	edits := []analysis.TextEdit{{
+		Pos:     loop.stmt.For,
+		End:     loop.stmt.Body.Lbrace,
+		NewText: []byte("for " + vars + "range " + xtext + " "),
+	}}
+	for _, e := range loop.elems {
+		edits = append(edits, analysis.TextEdit{Pos: e.Pos(), End: e.End(), NewText: []byte(loop.value)})
+	}
+	return fset, &analysis.SuggestedFix{TextEdits: edits}, nil
+}
+
+// canConvertToIndex reports whether the range [start, end) is on the
+// header of a range loop over a slice, an array, or an int, that may be
+// converted to a for loop over indices.
+func canConvertToIndex(file *ast.File, info *types.Info, start, end token.Pos) (*ast.RangeStmt, bool) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	var stmt *ast.RangeStmt
+	for _, n := range path {
+		if s, ok := n.(*ast.RangeStmt); ok {
+			stmt = s
+			break
+		}
+	}
+	if stmt == nil || within(stmt.Body, start, end) {
+		return nil, false
+	}
+	if stmt.Tok == token.ASSIGN {
+		return nil, false // the variables outlive the loop
+	}
+	for _, e := range []ast.Expr{stmt.Key, stmt.Value} {
+		if v, ok := rangeVar(info, e); ok && v != nil && assigned(info, stmt.Body, v) {
+			return nil, false
+		}
+	}
+	// The ranged-over expression is evaluated once by a range loop, but
+	// on each iteration by the condition of a for loop.
+	if !invariant(info, stmt.Body, stmt.X) {
+		return nil, false
+	}
+	t := info.TypeOf(stmt.X)
+	if t == nil {
+		return nil, false
+	}
+	if basic, ok := t.Underlying().(*types.Basic); ok && basic.Info()&types.IsInteger != 0 {
+		// for i := range n
+		return stmt, types.Identical(types.Default(t), types.Typ[types.Int])
+	}
+	if !indexable(t) {
+		return nil, false
+	}
+	// A range loop over an array value ranges over a copy of it.
+	if _, ok := t.Underlying().(*types.Array); ok && stmt.Value != nil && elemWritten(info, stmt.Body, stmt.X) {
+		return nil, false
+	}
+	return stmt, true
+}
+
+// rangeToIndex is a singleFileFixer that converts a range loop to a
+// for loop over indices.
+func rangeToIndex(fset *token.FileSet, start, end token.Pos, src []byte, file *ast.File, _ *types.Package, info *types.Info) (*token.FileSet, *analysis.SuggestedFix, error) {
+	stmt, ok := canConvertToIndex(file, info, start, end)
+	if !ok {
+		return nil, nil, fmt.Errorf("no range loop to convert")
+	}
+	tok := fset.File(file.Pos())
+	xtext, err := nodeSource(tok, src, stmt.X)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	index := ""
+	if v, _ := rangeVar(info, stmt.Key); v != nil {
+		index = v.Name()
+	} else {
+		index = freshLoopVar(info, stmt, "i")
+	}
+	bound := xtext
+	if basic, ok := info.TypeOf(stmt.X).Underlying().(*types.Basic); !ok || basic.Info()&types.IsInteger == 0 {
+		bound = "len(" + xtext + ")"
+	}
+	edits := []analysis.TextEdit{{
+		Pos:     stmt.For,
+		End:     stmt.Body.Lbrace,
+		NewText: []byte(fmt.Sprintf("for %[1]s := 0; %[1]s < %s; %[1]s++ ", index, bound)),
+	}}
+	if v, _ := rangeVar(info, stmt.Value); v != nil {
+		indent, err := calculateIndentation(src, tok, stmt)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Declare the value variable on the line after the brace,
+		// leaving any comment that follows the brace in place.
+		decl := fmt.Sprintf("%s := %s[%s]", v.Name(), xtext, index)
+		edit := analysis.TextEdit{
+			Pos:     stmt.Body.Lbrace + 1,
+			End:     stmt.Body.Lbrace + 1,
+			NewText: []byte("\n" + indent + "\t" + decl),
+		}
+		if line := safetoken.Line(tok, stmt.Body.Lbrace); safetoken.Line(tok, stmt.Body.Rbrace) > line {
+			edit.Pos = tok.LineStart(line + 1)
+			edit.End = edit.Pos
+			edit.NewText = []byte(indent + "\t" + decl + "\n")
+		}
+		edits = append(edits, edit)
+	}
+	return fset, &analysis.SuggestedFix{TextEdits: edits}, nil
+}
+
+// rangeVar returns the variable declared by the key or value e of a
+// range statement, or nil if e is absent or blank. It reports false if
+// e is not an identifier.
+func rangeVar(info *types.Info, e ast.Expr) (*types.Var, bool) {
+	if e == nil {
+		return nil, true
+	}
+	id, ok := e.(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+	if id.Name == "_" {
+		return nil, true
+	}
+	v, _ := info.Defs[id].(*types.Var)
+	return v, true
+}
+
+// nodeSource returns the source text of n.
+func nodeSource(tok *token.File, src []byte, n ast.Node) (string, error) {
+	start, end, err := safetoken.Offsets(tok, n.Pos(), n.End())
+	if err != nil {
+		return "", err
+	}
+	return string(src[start:end]), nil
+}
+
+// isVar reports whether e is an identifier that refers to v.
+func isVar(info *types.Info, e ast.Expr, v *types.Var) bool {
+	id, ok := astutil.Unparen(e).(*ast.Ident)
+	return ok && info.Uses[id] == v
+}
+
+// isBuiltinFunc reports whether e refers to the named built-in function.
+func isBuiltinFunc(info *types.Info, e ast.Expr, name string) bool {
+	id, ok := astutil.Unparen(e).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	b, ok := info.Uses[id].(*types.Builtin)
+	return ok && b.Name() == name
+}
+
+// indexable reports whether t is a slice, an array, or a pointer to an
+// array, whose range loops and for loops over indices agree.
+func indexable(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+		_, ok := t.Underlying().(*types.Array)
+		return ok
+	}
+	switch t.Underlying().(type) {
+	case *types.Slice, *types.Array:
+		return true
+	}
+	return false
+}
+
+// invariant reports whether the value of e cannot be changed by the
+// statements of body: e consists of constants, local variables that
+// body does not assign, and calls to len.
+func invariant(info *types.Info, body ast.Node, e ast.Expr) bool {
+	ok := true
+	ast.Inspect(e, func(n ast.Node) bool {
+		if !ok {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.Ident:
+			switch obj := info.Uses[n].(type) {
+			case *types.Const, *types.Nil, *types.Builtin:
+			case *types.Var:
+				if obj.IsField() || obj.Parent() == nil || obj.Parent() == obj.Pkg().Scope() || assigned(info, body, obj) {
+					ok = false
+				}
+			default:
+				ok = false
+			}
+		case *ast.CallExpr:
+			if !isBuiltinFunc(info, n.Fun, "len") {
+				ok = false
+			}
+		case nil, *ast.BasicLit, *ast.ParenExpr, *ast.BinaryExpr:
+		default:
+			ok = false
+		}
+		return ok
+	})
+	return ok
+}
+
+// assigned reports whether body assigns to v, or takes its address.
+func assigned(info *types.Info, body ast.Node, v *types.Var) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE {
+				for _, lhs := range n.Lhs {
+					found = found || isVar(info, lhs, v)
+				}
+			}
+		case *ast.IncDecStmt:
+			found = found || isVar(info, n.X, v)
+		case *ast.UnaryExpr:
+			found = found || n.Op == token.AND && isVar(info, n.X, v)
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN {
+				found = found || n.Key != nil && isVar(info, n.Key, v) || n.Value != nil && isVar(info, n.Value, v)
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// elemWritten reports whether body may change an element of the slice
+// or array s by an assignment, or by taking its address.
+func elemWritten(info *types.Info, body ast.Node, s ast.Expr) bool {
+	str := types.ExprString(s)
+	// written reports whether e is, or is a part of, an element of s.
+	written := func(e ast.Expr) bool {
+		for {
+			switch x := e.(type) {
+			case *ast.ParenExpr:
+				e = x.X
+			case *ast.SelectorExpr:
+				e = x.X
+			case *ast.IndexExpr:
+				if types.ExprString(x.X) == str {
+					return true
+				}
+				e = x.X
+			case *ast.SliceExpr:
+				if types.ExprString(x.X) == str {
+					return true
+				}
+				e = x.X
+			default:
+				return false
+			}
+		}
+	}
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				found = found || written(lhs)
+			}
+		case *ast.IncDecStmt:
+			found = found || written(n.X)
+		case *ast.UnaryExpr:
+			found = found || n.Op == token.AND && written(n.X)
+		case *ast.SliceExpr:
+			// s[i:j] aliases the elements of s.
+			found = found || written(n.X)
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN {
+				found = found || n.Key != nil && written(n.Key) || n.Value != nil && written(n.Value)
+			}
+		case *ast.SelectorExpr:
+			// A call of a method with a pointer receiver takes the
+			// address of its operand.
+			if sel, ok := info.Selections[n]; ok && sel.Kind() == types.MethodVal {
+				if _, ptr := sel.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer); ptr && !sel.Indirect() {
+					found = found || written(n.X)
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// freshLoopVar returns a name, based on base, for a new variable of
+// the loop stmt, that is neither in scope at the loop nor used in it.
+func freshLoopVar(info *types.Info, stmt ast.Stmt, base string) string {
+	used := make(map[string]bool)
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			used[id.Name] = true
+		}
+		return true
+	})
+	scope := info.Scopes[stmt]
+	for i := 0; ; i++ {
+		name := base
+		if i > 0 {
+			name += strconv.Itoa(i)
+		}
+		if used[name] {
+			continue
+		}
+		if scope != nil {
+			if _, obj := scope.LookupParent(name, stmt.Pos()); obj != nil {
+				continue
+			}
+		}
+		return name
+	}
+}
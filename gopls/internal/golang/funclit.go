@@ -0,0 +1,542 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the refactorings that promote a function literal
+// to a named function, and that inline a named function referenced
+// only once as a function literal.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/analysisinternal"
+)
+
+// canPromoteFuncLit reports whether the range [start, end) is within
+// the signature of a function literal, which may be promoted to a
+// named function, and returns the literal and its enclosing path.
+func canPromoteFuncLit(file *ast.File, start, end token.Pos) ([]ast.Node, *ast.FuncLit, bool) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	if len(path) < 2 {
+		return nil, nil, false
+	}
+	for i, n := range path {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			return path[i:], lit, lit.Pos() <= start && end <= lit.Type.End()
+		}
+	}
+	return nil, nil, false
+}
+
+// promoteFuncLit declares a package-level function with the body of
+// the function literal whose signature is selected, after the
+// enclosing declaration, and replaces the literal by a reference to
+// it. The variables of the enclosing function that the literal
+// captures become the leading parameters of the new function, in
+// which case the literal is replaced by a call to the new function,
+// if it was called immediately, or else by a smaller function literal
+// that calls it. The captured variables must not be assigned by the
+// literal, so that passing their values preserves the behavior.
+func promoteFuncLit(fset *token.FileSet, start, end token.Pos, src []byte, file *ast.File, pkg *types.Package, info *types.Info) (*token.FileSet, *analysis.SuggestedFix, error) {
+	const errorPrefix = "cannot promote function literal"
+
+	path, lit, ok := canPromoteFuncLit(file, start, end)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: no function literal signature selected", errorPrefix)
+	}
+	decl := path[len(path)-2]
+	declStart, declEnd := decl.Pos(), decl.End()
+	isLocal := func(obj types.Object) bool {
+		pos := obj.Pos()
+		return declStart <= pos && pos < declEnd && !(lit.Pos() <= pos && pos < lit.End())
+	}
+
+	// Find the captured variables, in order of first use.
+	var (
+		captured   []*types.Var
+		isCaptured = make(map[types.Object]bool)
+		err        error
+		fail       = func(format string, args ...any) {
+			if err == nil {
+				err = fmt.Errorf("%s: %s", errorPrefix, fmt.Sprintf(format, args...))
+			}
+		}
+	)
+	ast.Inspect(lit, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		obj := info.Uses[id]
+		if obj == nil || !isLocal(obj) || isCaptured[obj] {
+			return true
+		}
+		switch obj := obj.(type) {
+		case *types.Var:
+			if obj.IsField() {
+				return true
+			}
+			if dependsOnLocalTypes(obj.Type(), declStart, declEnd) {
+				fail("the type of %s depends on the enclosing function", obj.Name())
+			}
+			isCaptured[obj] = true
+			captured = append(captured, obj)
+		case *types.TypeName:
+			fail("it refers to the local type %s", obj.Name())
+		default:
+			fail("it refers to the local declaration %s", obj.Name())
+		}
+		return true
+	})
+
+	// The literal must not assign the captured variables, directly
+	// or through pointers to them.
+	var assigned func(e ast.Expr) types.Object
+	assigned = func(e ast.Expr) types.Object {
+		switch e := e.(type) {
+		case *ast.Ident:
+			return info.Uses[e]
+		case *ast.ParenExpr:
+			return assigned(e.X)
+		case *ast.SelectorExpr:
+			if _, ok := info.Selections[e]; ok && !isPointer(info.TypeOf(e.X)) {
+				return assigned(e.X) // field of a struct variable
+			}
+		case *ast.IndexExpr:
+			if t := info.TypeOf(e.X); t != nil {
+				if _, ok := t.Underlying().(*types.Array); ok {
+					return assigned(e.X) // element of an array variable
+				}
+			}
+		}
+		return nil
+	}
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		var targets []ast.Expr
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE {
+				targets = n.Lhs
+			}
+		case *ast.IncDecStmt:
+			targets = []ast.Expr{n.X}
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN {
+				targets = []ast.Expr{n.Key, n.Value}
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				targets = []ast.Expr{n.X}
+			}
+		case *ast.SelectorExpr:
+			// A call of a method with a pointer receiver
+			// takes the address of its operand.
+			if sel, ok := info.Selections[n]; ok && sel.Kind() == types.MethodVal {
+				recv := sel.Obj().Type().(*types.Signature).Recv()
+				if recv != nil && isPointer(recv.Type()) && !isPointer(info.TypeOf(n.X)) {
+					targets = []ast.Expr{n.X}
+				}
+			}
+		}
+		for _, target := range targets {
+			if target == nil {
+				continue
+			}
+			if obj := assigned(target); obj != nil && isCaptured[obj] {
+				fail("it assigns the captured variable %s", obj.Name())
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	text := func(n ast.Node) (string, error) {
+		start, end, err := safetoken.Offsets(fset.File(n.Pos()), n.Pos(), n.End())
+		if err != nil {
+			return "", err
+		}
+		return string(src[start:end]), nil
+	}
+
+	// Declare the captured variables, then the literal's own
+	// parameters. The wrapper literal forwards its parameters,
+	// so it needs names for those that have none.
+	var (
+		params, capturedNames      []string
+		wrapperParams, wrapperArgs []string
+		names                      = make(map[string]bool)
+		argIndex                   int
+	)
+	for _, v := range captured {
+		expr := analysisinternal.TypeExpr(file, pkg, v.Type())
+		if expr == nil {
+			return nil, nil, fmt.Errorf("%s: cannot express the type of %s", errorPrefix, v.Name())
+		}
+		params = append(params, v.Name()+" "+FormatNode(fset, expr))
+		capturedNames = append(capturedNames, v.Name())
+		names[v.Name()] = true
+	}
+	for _, field := range lit.Type.Params.List {
+		for _, id := range field.Names {
+			names[id.Name] = true
+		}
+	}
+	for _, field := range lit.Type.Params.List {
+		typ, err := text(field.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		var fieldNames, argNames []string
+		for _, id := range field.Names {
+			fieldNames = append(fieldNames, id.Name)
+		}
+		if len(fieldNames) == 0 {
+			fieldNames = []string{"_"}
+		}
+		for _, name := range fieldNames {
+			if name == "_" {
+				name, argIndex = generateIdentifier(argIndex, "arg", func(name string) bool { return names[name] })
+			}
+			argNames = append(argNames, name)
+			arg := name
+			if _, ok := field.Type.(*ast.Ellipsis); ok {
+				arg += "..."
+			}
+			wrapperArgs = append(wrapperArgs, arg)
+		}
+		params = append(params, strings.Join(fieldNames, ", ")+" "+typ)
+		wrapperParams = append(wrapperParams, strings.Join(argNames, ", ")+" "+typ)
+	}
+	var results string
+	if lit.Type.Results != nil {
+		text, err := text(lit.Type.Results)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = " " + text
+	}
+	body, err := text(lit.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name, _ := generateAvailableIdentifier(lit.Pos(), path, pkg, info, "newFunction", 0)
+	newDecl := fmt.Sprintf("func %s(%s)%s %s", name, strings.Join(params, ", "), results, body)
+	if formatted, err := format.Source([]byte("package p\n\n" + newDecl)); err == nil {
+		newDecl = strings.TrimSpace(strings.TrimPrefix(string(formatted), "package p\n"))
+	}
+
+	// Replace the literal.
+	var edit analysis.TextEdit
+	call, _ := path[1].(*ast.CallExpr)
+	switch {
+	case len(captured) == 0:
+		edit = analysis.TextEdit{Pos: lit.Pos(), End: lit.End(), NewText: []byte(name)}
+	case call != nil && call.Fun == lit:
+		// Pass the captured variables to the immediate call.
+		args := strings.Join(capturedNames, ", ")
+		if len(call.Args) > 0 {
+			args += ", "
+		}
+		edit = analysis.TextEdit{Pos: lit.Pos(), End: call.Lparen + 1, NewText: []byte(name + "(" + args)}
+	default:
+		// Bind the captured variables in a literal that calls the new function.
+		callText := name + "(" + strings.Join(append(capturedNames, wrapperArgs...), ", ") + ")"
+		if results != "" {
+			callText = "return " + callText
+		}
+		wrapper := fmt.Sprintf("func(%s)%s { %s }", strings.Join(wrapperParams, ", "), results, callText)
+		edit = analysis.TextEdit{Pos: lit.Pos(), End: lit.End(), NewText: []byte(wrapper)}
+	}
+
+	return fset, &analysis.SuggestedFix{
+		TextEdits: []analysis.TextEdit{
+			edit,
+			{Pos: declEnd, End: declEnd, NewText: []byte("\n\n" + newDecl)},
+		},
+	}, nil
+}
+
+// isPointer reports whether t is a pointer type.
+func isPointer(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := t.Underlying().(*types.Pointer)
+	return ok
+}
+
+// dependsOnLocalTypes reports whether the type t refers to a type
+// parameter, or to a named type declared within [start, end).
+func dependsOnLocalTypes(t types.Type, start, end token.Pos) bool {
+	depends := func(t types.Type) bool { return dependsOnLocalTypes(t, start, end) }
+	tuple := func(tuple *types.Tuple) bool {
+		for i := 0; i < tuple.Len(); i++ {
+			if depends(tuple.At(i).Type()) {
+				return true
+			}
+		}
+		return false
+	}
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		if pos := t.Obj().Pos(); start <= pos && pos < end {
+			return true
+		}
+		args := t.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			if depends(args.At(i)) {
+				return true
+			}
+		}
+	case *types.Pointer:
+		return depends(t.Elem())
+	case *types.Slice:
+		return depends(t.Elem())
+	case *types.Array:
+		return depends(t.Elem())
+	case *types.Chan:
+		return depends(t.Elem())
+	case *types.Map:
+		return depends(t.Key()) || depends(t.Elem())
+	case *types.Signature:
+		return tuple(t.Params()) || tuple(t.Results())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if depends(t.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumMethods(); i++ {
+			if depends(t.Method(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// funcToInlineAsLiteral returns the identifier at [start, end) that
+// refers to an unexported package-level function declared in file,
+// and the declaration of the function, if the identifier is the only
+// reference to the function in the package, and the function may be
+// converted to a function literal there.
+func funcToInlineAsLiteral(file *ast.File, pkg *types.Package, info *types.Info, start, end token.Pos) (*ast.Ident, *ast.FuncDecl, error) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("no function reference selected")
+	}
+	id, ok := path[0].(*ast.Ident)
+	if !ok {
+		return nil, nil, fmt.Errorf("no function reference selected")
+	}
+	fn, ok := info.Uses[id].(*types.Func)
+	if !ok || fn.Pkg() != pkg || fn.Parent() != pkg.Scope() {
+		return nil, nil, fmt.Errorf("%s is not a function of this package", id.Name)
+	}
+	if fn.Exported() || fn.Name() == "main" && pkg.Name() == "main" {
+		return nil, nil, fmt.Errorf("function %s may be used elsewhere", fn.Name())
+	}
+	if fn.Type().(*types.Signature).TypeParams().Len() > 0 {
+		return nil, nil, fmt.Errorf("function %s is generic", fn.Name())
+	}
+	var decl *ast.FuncDecl
+	for _, d := range file.Decls {
+		if d, ok := d.(*ast.FuncDecl); ok && d.Recv == nil && info.Defs[d.Name] == fn {
+			decl = d
+		}
+	}
+	if decl == nil || decl.Body == nil {
+		return nil, nil, fmt.Errorf("function %s is not declared with a body in this file", fn.Name())
+	}
+	if decl.Pos() <= id.Pos() && id.Pos() < decl.End() {
+		return nil, nil, fmt.Errorf("function %s is recursive", fn.Name())
+	}
+	if decl.Doc != nil {
+		for _, c := range decl.Doc.List {
+			if strings.HasPrefix(c.Text, "//go:") || strings.HasPrefix(c.Text, "//export ") {
+				return nil, nil, fmt.Errorf("function %s has a %s directive", fn.Name(), strings.Fields(c.Text)[0])
+			}
+		}
+	}
+	for use, obj := range info.Uses {
+		if obj == fn && use != id {
+			return nil, nil, fmt.Errorf("function %s is referenced more than once", fn.Name())
+		}
+	}
+
+	// The names that the function refers to must denote the same
+	// objects at the reference.
+	scope := pkg.Scope().Innermost(id.Pos())
+	if scope == nil {
+		return nil, nil, fmt.Errorf("no scope at reference to %s", fn.Name())
+	}
+	var err error
+	check := func(ref *ast.Ident) {
+		obj := info.Uses[ref]
+		if obj == nil || obj.Parent() == nil || decl.Pos() <= obj.Pos() && obj.Pos() < decl.End() {
+			return // field, method, or local to the function
+		}
+		if _, found := scope.LookupParent(ref.Name, id.Pos()); found != obj && err == nil {
+			err = fmt.Errorf("%s refers to another declaration at the reference to %s", ref.Name, fn.Name())
+		}
+	}
+	for _, n := range []ast.Node{decl.Type, decl.Body} {
+		ast.Inspect(n, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if x, ok := n.X.(*ast.Ident); ok {
+					if _, ok := info.Uses[x].(*types.PkgName); ok {
+						check(x)
+						return false // qualified identifier
+					}
+				}
+			case *ast.Ident:
+				check(n)
+			}
+			return err == nil
+		})
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return id, decl, nil
+}
+
+// inlineFuncAsLiteral replaces the selected reference to a function
+// by a function literal with its signature and body, and deletes its
+// declaration. See funcToInlineAsLiteral for the conditions.
+func inlineFuncAsLiteral(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+	const errorPrefix = "cannot inline function as literal"
+
+	id, decl, err := funcToInlineAsLiteral(pgf.File, pkg.Types(), pkg.TypesInfo(), start, end)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", errorPrefix, err)
+	}
+
+	// Other variants of the package, such as the one that
+	// includes its in-package tests, must not refer to the function.
+	mps, err := snapshot.MetadataForFile(ctx, pgf.URI)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ids []PackageID
+	for _, mp := range mps {
+		if mp.ID != pkg.Metadata().ID {
+			ids = append(ids, mp.ID)
+		}
+	}
+	variants, err := snapshot.TypeCheck(ctx, ids...)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, variant := range variants {
+		fn := variant.Types().Scope().Lookup(id.Name)
+		refs := 0
+		for _, obj := range variant.TypesInfo().Uses {
+			if obj == fn {
+				refs++
+			}
+		}
+		if refs > 1 {
+			return nil, nil, fmt.Errorf("%s: function %s is also referenced by %s", errorPrefix, id.Name, variant.Metadata().ID)
+		}
+	}
+
+	// The literal has the signature and body of the function,
+	// indented as the line of the reference.
+	src := pgf.Src
+	litStart, litEnd, err := safetoken.Offsets(pgf.Tok, decl.Type.Params.Pos(), decl.Body.End())
+	if err != nil {
+		return nil, nil, err
+	}
+	idOffset, err := safetoken.Offset(pgf.Tok, id.Pos())
+	if err != nil {
+		return nil, nil, err
+	}
+	lineStart := bytes.LastIndexByte(src[:idOffset], '\n') + 1
+	indent := src[lineStart:idOffset]
+	indent = indent[:len(indent)-len(bytes.TrimLeft(indent, " \t"))]
+	var lit bytes.Buffer
+	lit.WriteString("func")
+	rawStrings := rawStringRanges(pgf, decl.Body)
+	for i := litStart; i < litEnd; i++ {
+		lit.WriteByte(src[i])
+		if src[i] == '\n' && !inRanges(rawStrings, i) {
+			lit.Write(indent)
+		}
+	}
+
+	// Delete the declaration and its doc comment as whole lines,
+	// along with a blank line that separates it from its neighbor.
+	declPos := decl.Pos()
+	if decl.Doc != nil {
+		declPos = decl.Doc.Pos()
+	}
+	delStart, delEnd, err := safetoken.Offsets(pgf.Tok, declPos, decl.End())
+	if err != nil {
+		return nil, nil, err
+	}
+	delStart = bytes.LastIndexByte(src[:delStart], '\n') + 1
+	if i := bytes.IndexByte(src[delEnd:], '\n'); i >= 0 {
+		delEnd += i + 1
+	} else {
+		delEnd = len(src)
+	}
+	if delEnd < len(src) && src[delEnd] == '\n' {
+		delEnd++
+	} else if delStart >= 2 && src[delStart-2] == '\n' {
+		delStart--
+	}
+
+	return pkg.FileSet(), &analysis.SuggestedFix{
+		TextEdits: []analysis.TextEdit{
+			{Pos: id.Pos(), End: id.End(), NewText: lit.Bytes()},
+			{Pos: pgf.Tok.Pos(delStart), End: pgf.Tok.Pos(delEnd)},
+		},
+	}, nil
+}
+
+// rawStringRanges returns the offset ranges of the raw string
+// literals within n, whose content must not be reindented.
+func rawStringRanges(pgf *parsego.File, n ast.Node) [][2]int {
+	var ranges [][2]int
+	ast.Inspect(n, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING && strings.HasPrefix(lit.Value, "`") {
+			if start, end, err := safetoken.Offsets(pgf.Tok, lit.Pos(), lit.End()); err == nil {
+				ranges = append(ranges, [2]int{start, end})
+			}
+		}
+		return true
+	})
+	return ranges
+}
+
+// inRanges reports whether offset is within one of the ranges.
+func inRanges(ranges [][2]int, offset int) bool {
+	for _, r := range ranges {
+		if r[0] <= offset && offset < r[1] {
+			return true
+		}
+	}
+	return false
+}
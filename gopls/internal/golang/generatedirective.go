@@ -0,0 +1,108 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/gopls/internal/analysis/generatedirective"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+)
+
+// errNoGenerateRef is returned by generateDefinition when there is no
+// reference to a type or package in a //go:generate directive at a
+// particular position.
+// As such it indicates that other definitions could be worth checking.
+var errNoGenerateRef = errors.New("no go:generate reference found")
+
+// generateDefinition returns the location of the type or package
+// referenced by the //go:generate directive at pos.
+// If there is no such reference at pos, returns errNoGenerateRef.
+func generateDefinition(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, pos token.Pos) ([]protocol.Location, error) {
+	ref, ok := generateRefAt(pgf, pos)
+	if !ok {
+		return nil, errNoGenerateRef
+	}
+	if ref.IsPackage {
+		mp, err := generateRefPackage(ctx, snapshot, ref)
+		if err != nil {
+			return nil, err
+		}
+		return packageLocations(ctx, snapshot, mp)
+	}
+	obj := generateRefType(pkg, ref)
+	if obj == nil {
+		return nil, nil
+	}
+	loc, err := mapPosition(ctx, pkg.FileSet(), snapshot, obj.Pos(), adjustedObjEnd(obj))
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.Location{loc}, nil
+}
+
+// generateRefAt returns the reference in a //go:generate directive of
+// pgf that contains pos, if any.
+func generateRefAt(pgf *parsego.File, pos token.Pos) (generatedirective.Ref, bool) {
+	// We use "<= End" to accept a query immediately after the reference.
+	for _, ref := range generatedirective.Refs(pgf.File) {
+		if ref.Pos <= pos && pos <= ref.End {
+			return ref, true
+		}
+	}
+	return generatedirective.Ref{}, false
+}
+
+// generateRefType returns the type declared by pkg that is named by
+// the type reference ref, or nil if there is none.
+func generateRefType(pkg *cache.Package, ref generatedirective.Ref) *types.TypeName {
+	obj, _ := pkg.Types().Scope().Lookup(ref.Name).(*types.TypeName)
+	return obj
+}
+
+// generateRefDecl returns the file and position of the declaration of
+// the type named by the //go:generate directive reference at pos, if
+// any, so that operations on the reference may be treated as
+// operations on the declaration.
+func generateRefDecl(pkg *cache.Package, pgf *parsego.File, pos token.Pos) (generatedirective.Ref, *parsego.File, token.Pos, bool) {
+	ref, ok := generateRefAt(pgf, pos)
+	if !ok || ref.IsPackage {
+		return generatedirective.Ref{}, nil, token.NoPos, false
+	}
+	obj := generateRefType(pkg, ref)
+	if obj == nil {
+		return generatedirective.Ref{}, nil, token.NoPos, false
+	}
+	declURI := safetoken.StartPosition(pkg.FileSet(), obj.Pos())
+	declPGF, err := pkg.File(protocol.URIFromPath(declURI.Filename))
+	if err != nil {
+		return generatedirective.Ref{}, nil, token.NoPos, false
+	}
+	return ref, declPGF, obj.Pos(), true
+}
+
+// generateRefPackage returns the metadata for the package run by the
+// "go run" command of a //go:generate directive.
+func generateRefPackage(ctx context.Context, snapshot *cache.Snapshot, ref generatedirective.Ref) (*metadata.Package, error) {
+	mps, err := snapshot.AllMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata.RemoveIntermediateTestVariants(&mps)
+	for _, mp := range mps {
+		if string(mp.PkgPath) == ref.Name && mp.ForTest == "" {
+			return mp, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot find package %q", ref.Name)
+}
@@ -0,0 +1,411 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the code actions that generate, just after the
+// declaration of a type, a String method for an enum type (an integer
+// type with constants), and MarshalJSON and UnmarshalJSON methods for a
+// struct type.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/format"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+)
+
+// getGenerateMethodsCodeActions returns the actions to generate a
+// String method, or JSON methods, for the type named at rng.
+func getGenerateMethodsCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Range, options *settings.Options) ([]protocol.CodeAction, error) {
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	_, spec := typeSpecAt(pgf, start, end)
+	if spec == nil {
+		return nil, nil
+	}
+	var actions []protocol.CodeAction
+	add := func(title, fix string) error {
+		cmd, err := command.NewApplyFixCommand(title, command.ApplyFixArgs{
+			Fix:          fix,
+			URI:          pgf.URI,
+			Range:        rng,
+			ResolveEdits: supportsResolveEdits(options),
+		})
+		if err != nil {
+			return err
+		}
+		actions = append(actions, newCodeAction(cmd.Title, protocol.RefactorRewrite, &cmd, nil, options))
+		return nil
+	}
+	if named, _, err := stringerType(pkg, spec); err == nil {
+		if err := add(fmt.Sprintf("Generate String method for %s", named.Obj().Name()), fixGenerateString); err != nil {
+			return nil, err
+		}
+	}
+	if named, _, err := jsonType(pkg, spec); err == nil {
+		if err := add(fmt.Sprintf("Generate MarshalJSON and UnmarshalJSON methods for %s", named.Obj().Name()), fixGenerateJSON); err != nil {
+			return nil, err
+		}
+	}
+	return actions, nil
+}
+
+// stringerType returns the enum type declared by spec, and its
+// constants, or an error if no String method may be generated for it.
+func stringerType(pkg *cache.Package, spec *ast.TypeSpec) (*types.Named, []*types.Const, error) {
+	named, err := methodsType(pkg, spec, "String")
+	if err != nil {
+		return nil, nil, err
+	}
+	consts := enumConstants(pkg.Types(), named)
+	if len(consts) == 0 {
+		return nil, nil, fmt.Errorf("%s has no constants", named.Obj().Name())
+	}
+	return named, consts, nil
+}
+
+// jsonType returns the struct type declared by spec, or an error if no
+// JSON methods may be generated for it, such as when it has embedded
+// fields, whose promotion is difficult to reproduce.
+func jsonType(pkg *cache.Package, spec *ast.TypeSpec) (*types.Named, *ast.StructType, error) {
+	named, err := methodsType(pkg, spec, "MarshalJSON", "UnmarshalJSON")
+	if err != nil {
+		return nil, nil, err
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not a struct type", named.Obj().Name())
+	}
+	exported := false
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			return nil, nil, fmt.Errorf("%s has embedded fields", named.Obj().Name())
+		}
+		for _, id := range field.Names {
+			exported = exported || id.IsExported()
+		}
+	}
+	if !exported {
+		return nil, nil, fmt.Errorf("%s has no exported fields", named.Obj().Name())
+	}
+	return named, st, nil
+}
+
+// methodsType returns the non-generic defined type declared by spec,
+// or an error if it is an interface or already has one of the
+// specified methods.
+func methodsType(pkg *cache.Package, spec *ast.TypeSpec, methods ...string) (*types.Named, error) {
+	tname, ok := pkg.TypesInfo().Defs[spec.Name].(*types.TypeName)
+	if !ok || tname.IsAlias() {
+		return nil, fmt.Errorf("%s is not a defined type", spec.Name.Name)
+	}
+	named, ok := tname.Type().(*types.Named)
+	if !ok || types.IsInterface(named) || named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("%s is not a concrete, non-generic type", tname.Name())
+	}
+	for _, name := range methods {
+		if obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), false, pkg.Types(), name); obj != nil {
+			return nil, fmt.Errorf("%s already has a %s field or method", tname.Name(), name)
+		}
+	}
+	return named, nil
+}
+
+// enumConstants returns the package-level constants of the integer
+// type named that are accessible from pkg, in order of declaration,
+// omitting any whose value is that of an earlier one.
+func enumConstants(pkg *types.Package, named *types.Named) []*types.Const {
+	basic, ok := named.Underlying().(*types.Basic)
+	if !ok || basic.Info()&types.IsInteger == 0 || named.Obj().Pkg() == nil {
+		return nil
+	}
+	scope := named.Obj().Pkg().Scope()
+	var consts []*types.Const
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if ok && types.Identical(c.Type(), named) && (c.Exported() || c.Pkg() == pkg) {
+			consts = append(consts, c)
+		}
+	}
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+	var unique []*types.Const
+outer:
+	for _, c := range consts {
+		for _, prev := range unique {
+			if constant.Compare(c.Val(), token.EQL, prev.Val()) {
+				continue outer
+			}
+		}
+		unique = append(unique, c)
+	}
+	return unique
+}
+
+// receiverName returns the name of the receiver of the methods of
+// named, or the first letter of its name in lower case if none has a
+// named receiver.
+func receiverName(named *types.Named) string {
+	for i := 0; i < named.NumMethods(); i++ {
+		if recv := named.Method(i).Type().(*types.Signature).Recv(); recv.Name() != "" && recv.Name() != "_" {
+			return recv.Name()
+		}
+	}
+	return strings.ToLower(named.Obj().Name()[:1])
+}
+
+// generateString returns a fix that declares a String method for the
+// enum type named at start, which returns the name of the constant
+// with the receiver's value, or the type and value for other values.
+func generateString(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+	decl, spec := typeSpecAt(pgf, start, end)
+	if spec == nil {
+		return nil, nil, fmt.Errorf("no type declaration is named at the selection")
+	}
+	named, consts, err := stringerType(pkg, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	imports := newImportAdder(snapshot, pkg, pgf)
+	fn, conv := "FormatInt", "int64"
+	if named.Underlying().(*types.Basic).Info()&types.IsUnsigned != 0 {
+		fn, conv = "FormatUint", "uint64"
+	}
+	formatFunc, err := imports.member(decl.End(), "strconv", fn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name := named.Obj().Name()
+	recv := receiverName(named)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// String returns the name of the %s constant with the value of %s.\n", name, recv)
+	fmt.Fprintf(&buf, "func (%s %s) String() string {\n", recv, name)
+	fmt.Fprintf(&buf, "switch %s {\n", recv)
+	for _, c := range consts {
+		fmt.Fprintf(&buf, "case %s:\nreturn %q\n", c.Name(), c.Name())
+	}
+	fmt.Fprintf(&buf, "}\n")
+	fmt.Fprintf(&buf, "return %q + %s(%s(%s), 10) + \")\"\n", name+"(", formatFunc, conv, recv)
+	fmt.Fprintf(&buf, "}\n")
+	return insertDecls(snapshot, pkg, pgf, decl, buf.Bytes(), imports)
+}
+
+// A jsonField describes a field of a struct type for which JSON
+// methods are generated.
+type jsonField struct {
+	name   string         // name of the field
+	typ    string         // source text of the type of the field in the JSON encoding
+	tag    string         // source text of the struct tag, if any
+	kind   jsonFieldKind  // how the field is encoded
+	enum   *types.Named   // the enum type, for enumField
+	consts []*types.Const // the constants of the enum type, for enumField
+}
+
+// A jsonFieldKind describes how a field is encoded by the generated
+// JSON methods.
+type jsonFieldKind int
+
+const (
+	plainField    jsonFieldKind = iota // encoded as by encoding/json
+	durationField                      // a time.Duration, encoded as a string such as "1m30s"
+	enumField                          // an enum type with a String method, encoded as a constant's name
+)
+
+// generateJSON returns a fix that declares MarshalJSON and
+// UnmarshalJSON methods for the struct type named at start, and a type
+// of the same fields that is encoded in their stead, which the methods
+// convert to and from.
+//
+// The methods encode each exported field as encoding/json would, using
+// the name and options of its struct tag, except for time.Duration
+// fields, which are encoded as strings such as "1m30s" rather than
+// numbers of nanoseconds, and fields of enum types with a String
+// method, which are encoded as the names of their constants.
+// (time.Time fields keep their own encoding as RFC 3339 strings.)
+func generateJSON(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+	decl, spec := typeSpecAt(pgf, start, end)
+	if spec == nil {
+		return nil, nil, fmt.Errorf("no type declaration is named at the selection")
+	}
+	named, st, err := jsonType(pkg, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	imports := newImportAdder(snapshot, pkg, pgf)
+	pos := decl.End()
+	qual := func(path, name string) string {
+		if err == nil {
+			var s string
+			s, err = imports.member(pos, path, name)
+			return s
+		}
+		return name
+	}
+
+	// Describe the exported fields.
+	var fields []jsonField
+	for _, field := range st.Fields.List {
+		var tag string
+		if field.Tag != nil {
+			tag = field.Tag.Value
+			if unquoted, err := strconv.Unquote(tag); err == nil {
+				if t, ok := reflect.StructTag(unquoted).Lookup("json"); ok && t == "-" {
+					continue // not encoded
+				}
+			}
+		}
+		for _, id := range field.Names {
+			if !id.IsExported() {
+				continue
+			}
+			f := jsonField{name: id.Name, typ: FormatNode(pkg.FileSet(), field.Type), tag: tag}
+			t := pkg.TypesInfo().TypeOf(field.Type)
+			if isDuration(t) {
+				f.kind, f.typ = durationField, "string"
+			} else if enum, ok := t.(*types.Named); ok && implementsStringer(enum) {
+				if consts := enumConstants(pkg.Types(), enum); len(consts) > 0 {
+					f.kind, f.typ, f.enum, f.consts = enumField, "string", enum, consts
+				}
+			}
+			fields = append(fields, f)
+		}
+	}
+
+	name := named.Obj().Name()
+	jsonName := strings.ToLower(name[:1]) + name[1:] + "JSON"
+	for i := 2; pkg.Types().Scope().Lookup(jsonName) != nil; i++ {
+		jsonName = fmt.Sprintf("%s%sJSON%d", strings.ToLower(name[:1]), name[1:], i)
+	}
+	recv := receiverName(named)
+	v, data := "v", "data"
+	if recv == v {
+		v = "x"
+	}
+	if recv == data {
+		data = "b"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// %s is the JSON encoding of %s.\n", jsonName, name)
+	fmt.Fprintf(&buf, "type %s struct {\n", jsonName)
+	for _, f := range fields {
+		fmt.Fprintf(&buf, "%s %s %s\n", f.name, f.typ, f.tag)
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+
+	// MarshalJSON
+	fmt.Fprintf(&buf, "// MarshalJSON implements json.Marshaler.\n")
+	fmt.Fprintf(&buf, "func (%s %s) MarshalJSON() ([]byte, error) {\n", recv, name)
+	fmt.Fprintf(&buf, "return %s(%s{\n", qual("encoding/json", "Marshal"), jsonName)
+	for _, f := range fields {
+		switch f.kind {
+		case plainField:
+			fmt.Fprintf(&buf, "%s: %s.%s,\n", f.name, recv, f.name)
+		case durationField, enumField:
+			fmt.Fprintf(&buf, "%s: %s.%s.String(),\n", f.name, recv, f.name)
+		}
+	}
+	fmt.Fprintf(&buf, "})\n}\n\n")
+
+	// UnmarshalJSON
+	fmt.Fprintf(&buf, "// UnmarshalJSON implements json.Unmarshaler.\n")
+	fmt.Fprintf(&buf, "func (%s *%s) UnmarshalJSON(%s []byte) error {\n", recv, name, data)
+	fmt.Fprintf(&buf, "var %s %s\n", v, jsonName)
+	fmt.Fprintf(&buf, "if err := %s(%s, &%s); err != nil {\nreturn err\n}\n", qual("encoding/json", "Unmarshal"), data, v)
+	for _, f := range fields {
+		switch f.kind {
+		case plainField:
+			fmt.Fprintf(&buf, "%s.%s = %s.%s\n", recv, f.name, v, f.name)
+		case durationField:
+			fmt.Fprintf(&buf, "if %s.%s != \"\" {\n", v, f.name)
+			fmt.Fprintf(&buf, "d, err := %s(%s.%s)\n", qual("time", "ParseDuration"), v, f.name)
+			fmt.Fprintf(&buf, "if err != nil {\nreturn err\n}\n")
+			fmt.Fprintf(&buf, "%s.%s = d\n}\n", recv, f.name)
+		case enumField:
+			fmt.Fprintf(&buf, "switch %s.%s {\ncase \"\":\n", v, f.name)
+			for _, c := range f.consts {
+				cname := c.Name()
+				if c.Pkg() != pkg.Types() {
+					cname = qual(c.Pkg().Path(), c.Name())
+				}
+				fmt.Fprintf(&buf, "case %s.String():\n%s.%s = %s\n", cname, recv, f.name, cname)
+			}
+			fmt.Fprintf(&buf, "default:\nreturn %s(\"invalid %s %%q\", %s.%s)\n}\n",
+				qual("fmt", "Errorf"), types.TypeString(f.enum, imports.qualifier), v, f.name)
+		}
+	}
+	fmt.Fprintf(&buf, "return nil\n}\n")
+	if err != nil {
+		return nil, nil, err
+	}
+	return insertDecls(snapshot, pkg, pgf, decl, buf.Bytes(), imports)
+}
+
+// isDuration reports whether t is time.Duration.
+func isDuration(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Duration"
+}
+
+// implementsStringer reports whether values of type t have a String
+// method returning a string.
+func implementsStringer(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, "String")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), types.Typ[types.String])
+}
+
+// insertDecls returns a fix that inserts the declarations src, after
+// formatting, on the lines following decl, and adds the imports they
+// need.
+func insertDecls(snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, decl *ast.GenDecl, src []byte, imports *importAdder) (*token.FileSet, *analysis.SuggestedFix, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	importEdits, err := imports.edits(snapshot, pgf)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Insert after any comment on the last line of decl.
+	offset, err := safetoken.Offset(pgf.Tok, decl.End())
+	if err != nil {
+		return nil, nil, err
+	}
+	if nl := bytes.IndexByte(pgf.Src[offset:], '\n'); nl >= 0 {
+		offset += nl
+	} else {
+		offset = len(pgf.Src)
+	}
+	pos := pgf.Tok.Pos(offset)
+	edits := append(importEdits, analysis.TextEdit{
+		Pos:     pos,
+		End:     pos,
+		NewText: append([]byte("\n\n"), bytes.TrimSuffix(formatted, []byte("\n"))...),
+	})
+	return pkg.FileSet(), &analysis.SuggestedFix{TextEdits: edits}, nil
+}
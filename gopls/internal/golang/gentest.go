@@ -0,0 +1,404 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the "Generate test" code action, which declares a
+// table-driven test of a function or method in the corresponding
+// _test.go file.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/internal/diff"
+	"golang.org/x/tools/internal/typeparams"
+	"golang.org/x/tools/internal/versions"
+)
+
+// testCasesTODO is the comment of the generated table of test cases,
+// which is selected after the edits are applied.
+const testCasesTODO = "// TODO: add test cases."
+
+// getAddTestCodeActions returns the "Generate test" code action for
+// the function or method declaration named at rng, if any.
+func getAddTestCodeActions(pkg *cache.Package, pgf *parsego.File, rng protocol.Range, options *settings.Options) ([]protocol.CodeAction, error) {
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	fn, err := testableFunc(pkg, pgf, start, end)
+	if err != nil {
+		return nil, nil // no function to test
+	}
+	cmd, err := command.NewApplyFixCommand(fmt.Sprintf("Generate test for %s", funcDisplayName(fn)), command.ApplyFixArgs{
+		Fix:          fixAddTest,
+		URI:          pgf.URI,
+		Range:        rng,
+		ResolveEdits: supportsResolveEdits(options),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeAction{newCodeAction(cmd.Title, protocol.RefactorRewrite, &cmd, nil, options)}, nil
+}
+
+// testableFunc returns the function or method whose declaration is
+// named at [start, end), if a test may be generated for it.
+func testableFunc(pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*types.Func, error) {
+	if isTestFile(pgf.URI) {
+		return nil, fmt.Errorf("%s is a test file", filepath.Base(pgf.URI.Path()))
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	var decl *ast.FuncDecl
+	for _, n := range path {
+		if d, ok := n.(*ast.FuncDecl); ok && d.Name.Pos() <= start && end <= d.Name.End() {
+			decl = d
+			break
+		}
+	}
+	if decl == nil {
+		return nil, fmt.Errorf("no function declaration is named at the selection")
+	}
+	fn, ok := pkg.TypesInfo().Defs[decl.Name].(*types.Func)
+	if !ok {
+		return nil, fmt.Errorf("no function %s", decl.Name.Name)
+	}
+	sig := fn.Type().(*types.Signature)
+	switch {
+	case fn.Name() == "_", fn.Name() == "init" && sig.Recv() == nil:
+		return nil, fmt.Errorf("cannot test %s", fn.Name())
+	case fn.Name() == "main" && sig.Recv() == nil && pkg.Types().Name() == "main":
+		return nil, fmt.Errorf("cannot test the main function")
+	case sig.TypeParams().Len() > 0 || sig.Recv() != nil && sig.RecvTypeParams().Len() > 0:
+		return nil, fmt.Errorf("cannot test generic function %s", fn.Name())
+	}
+	if sig.Recv() != nil {
+		if _, ok := typeparams.Deref(sig.Recv().Type()).(*types.Named); !ok {
+			return nil, fmt.Errorf("cannot construct the receiver of %s", fn.Name())
+		}
+	}
+	return fn, nil
+}
+
+// funcDisplayName returns the name of fn, qualified by the name of
+// its receiver type if it is a method, as in T.M.
+func funcDisplayName(fn *types.Func) string {
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		if named, ok := typeparams.Deref(recv.Type()).(*types.Named); ok {
+			return named.Obj().Name() + "." + fn.Name()
+		}
+	}
+	return fn.Name()
+}
+
+// AddTest returns the changes that declare a table-driven test of the
+// function or method named at rng, appended to the corresponding
+// _test.go file, which is created if it does not exist. It also
+// returns the location of the comment that stands for the test cases.
+func AddTest(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]protocol.DocumentChange, *protocol.Location, error) {
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, nil, err
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	fn, err := testableFunc(pkg, pgf, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	testURI := protocol.URIFromPath(strings.TrimSuffix(pgf.URI.Path(), ".go") + "_test.go")
+	testFH, err := snapshot.ReadFile(ctx, testURI)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := testFH.Content()
+	exists := err == nil
+
+	// Names of the test file are qualified relative to its imports.
+	var (
+		testPGF  *parsego.File
+		adder    = &importAdder{pkg: pkg, imported: make(map[string]string)}
+		external = false
+		taken    = make(map[string]bool)
+	)
+	if exists {
+		testPGF, err = snapshot.ParseGo(ctx, testFH, parsego.Full)
+		if err != nil {
+			return nil, nil, err
+		}
+		adder = newImportAdder(snapshot, pkg, testPGF)
+		external = testPGF.File.Name.Name != pkg.Types().Name()
+		for _, d := range testPGF.File.Decls {
+			if d, ok := d.(*ast.FuncDecl); ok && d.Recv == nil {
+				taken[d.Name.Name] = true
+			}
+		}
+	}
+	qual := adder.qualifier
+	if external {
+		if !fn.Exported() || !exportedRecv(fn) {
+			return nil, nil, fmt.Errorf("cannot test unexported %s from external test package %s", funcDisplayName(fn), testPGF.File.Name.Name)
+		}
+		qual = adder.add // the package under test is imported too
+	}
+
+	// Choose a name not declared by the test file or the package.
+	base := "Test" + strings.ReplaceAll(funcDisplayName(fn), ".", "_")
+	name := base
+	for i := 1; taken[name] || !external && pkg.Types().Scope().Lookup(name) != nil; i++ {
+		name = base + strconv.Itoa(i)
+	}
+
+	oldLoopVar := true
+	if mod := pkg.Metadata().Module; mod != nil && mod.GoVersion != "" {
+		oldLoopVar = versions.Before("go"+mod.GoVersion, "go1.22")
+	}
+	text, err := testFuncText(fn, name, qual, oldLoopVar)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !exists {
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "package %s\n\nimport ", pkg.Types().Name())
+		if len(adder.fixes) > 1 {
+			buf.WriteString("(\n")
+		}
+		for _, fix := range adder.fixes {
+			if fix.StmtInfo.Name != "" {
+				fmt.Fprintf(&buf, "%s ", fix.StmtInfo.Name)
+			}
+			fmt.Fprintf(&buf, "%q\n", fix.StmtInfo.ImportPath)
+		}
+		if len(adder.fixes) > 1 {
+			buf.WriteString(")\n")
+		}
+		fmt.Fprintf(&buf, "\n%s", text)
+		content, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, nil, fmt.Errorf("formatting the new test file: %v", err)
+		}
+		offset := bytes.Index(content, []byte(testCasesTODO))
+		sel, err := protocol.NewMapper(testURI, content).OffsetLocation(offset, offset+len(testCasesTODO))
+		if err != nil {
+			return nil, nil, err
+		}
+		return []protocol.DocumentChange{
+			protocol.DocumentChangeCreate(testURI),
+			protocol.DocumentChangeEdit(testFH, []protocol.TextEdit{{NewText: string(content)}}),
+		}, &sel, nil
+	}
+
+	// Append the test to the existing file.
+	insert := "\n" + text
+	if !bytes.HasSuffix(content, []byte("\n")) {
+		insert = "\n" + insert
+	}
+	edits := []diff.Edit{{Start: len(content), End: len(content), New: insert}}
+	if len(adder.fixes) > 0 {
+		textedits, err := ComputeImportFixEdits(snapshot, testPGF, adder.fixes...)
+		if err != nil {
+			return nil, nil, err
+		}
+		importEdits, err := protocol.EditsToDiffEdits(testPGF.Mapper, textedits)
+		if err != nil {
+			return nil, nil, err
+		}
+		edits = append(edits, importEdits...)
+	}
+	from := strings.Index(insert, testCasesTODO)
+	sel, err := editedLocation(testURI, content, edits, 0, from, from+len(testCasesTODO))
+	if err != nil {
+		return nil, nil, err
+	}
+	diff.SortEdits(edits)
+	textedits, err := protocol.EditsFromDiffEdits(testPGF.Mapper, edits)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []protocol.DocumentChange{protocol.DocumentChangeEdit(testFH, textedits)}, &sel, nil
+}
+
+// exportedRecv reports whether fn is a function, or a method whose
+// receiver type is exported.
+func exportedRecv(fn *types.Func) bool {
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return true
+	}
+	named, ok := typeparams.Deref(recv.Type()).(*types.Named)
+	return ok && named.Obj().Exported()
+}
+
+// testFuncText returns the formatted declaration of the test function
+// name for fn, which tests each case of a table of inputs and wanted
+// outputs in parallel. Names of other packages are qualified by qual.
+func testFuncText(fn *types.Func, name string, qual types.Qualifier, oldLoopVar bool) (string, error) {
+	var (
+		sig     = fn.Type().(*types.Signature)
+		testing = qual(types.NewPackage("testing", "testing"))
+		typ     = func(t types.Type) string { return types.TypeString(t, qual) }
+	)
+
+	// fields and taken hold the fields of the test case struct and
+	// their names, which must be distinct.
+	type field struct{ name, typ string }
+	var fields []field
+	taken := map[string]bool{"name": true}
+	addField := func(base, typ string) string {
+		name := base
+		for i := 1; taken[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+		taken[name] = true
+		fields = append(fields, field{name, typ})
+		return name
+	}
+
+	results := sig.Results()
+	hasErr := endsWithError(results)
+	if hasErr {
+		taken["wantErr"] = true
+	}
+
+	// The call of fn, whose receiver and arguments are taken from tt.
+	var call strings.Builder
+	if recv := sig.Recv(); recv != nil {
+		recvName := recv.Name()
+		if recvName == "" || recvName == "_" {
+			recvName = "recv"
+		}
+		fmt.Fprintf(&call, "tt.%s.", addField(recvName, typ(recv.Type())))
+	} else if q := qual(fn.Pkg()); q != "" {
+		fmt.Fprintf(&call, "%s.", q)
+	}
+	fmt.Fprintf(&call, "%s(", fn.Name())
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		if i > 0 {
+			call.WriteString(", ")
+		}
+		param := params.At(i)
+		if isContext(param.Type()) {
+			fmt.Fprintf(&call, "%s.Background()", qual(param.Type().(*types.Named).Obj().Pkg()))
+			continue
+		}
+		paramName := param.Name()
+		if paramName == "" || paramName == "_" {
+			paramName = "arg"
+		}
+		fmt.Fprintf(&call, "tt.%s", addField(paramName, typ(param.Type())))
+		if sig.Variadic() && i == params.Len()-1 {
+			call.WriteString("...")
+		}
+	}
+	call.WriteString(")")
+
+	// The results of the call, and the fields of their wanted values.
+	var gots, wants []string
+	var comparable []bool
+	for i := 0; i < results.Len(); i++ {
+		if hasErr && i == results.Len()-1 {
+			break
+		}
+		res := results.At(i)
+		got, want := "got", "want"
+		if res.Name() != "" && res.Name() != "_" {
+			got, want = "got"+capitalize(res.Name()), "want"+capitalize(res.Name())
+		} else if i > 0 {
+			got, want = got+strconv.Itoa(i), want+strconv.Itoa(i)
+		}
+		basic, _ := res.Type().Underlying().(*types.Basic)
+		gots = append(gots, got)
+		wants = append(wants, addField(want, typ(res.Type())))
+		comparable = append(comparable, basic != nil)
+	}
+	if hasErr {
+		fields = append(fields, field{"wantErr", "bool"})
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "func %s(t *%s.T) {\n", name, testing)
+	fmt.Fprintf(&buf, "t.Parallel()\n\n")
+	fmt.Fprintf(&buf, "tests := []struct {\nname string\n")
+	for _, f := range fields {
+		fmt.Fprintf(&buf, "%s %s\n", f.name, f.typ)
+	}
+	fmt.Fprintf(&buf, "}{\n%s\n}\n", testCasesTODO)
+	fmt.Fprintf(&buf, "for _, tt := range tests {\n")
+	if oldLoopVar {
+		fmt.Fprintf(&buf, "tt := tt\n")
+	}
+	fmt.Fprintf(&buf, "t.Run(tt.name, func(t *%s.T) {\n", testing)
+	fmt.Fprintf(&buf, "t.Parallel()\n\n")
+	lhs := gots
+	if hasErr {
+		lhs = append(lhs, "err")
+	}
+	if len(lhs) > 0 {
+		fmt.Fprintf(&buf, "%s := ", strings.Join(lhs, ", "))
+	}
+	fmt.Fprintf(&buf, "%s\n", call.String())
+	display := funcDisplayName(fn) + "()"
+	if hasErr {
+		fmt.Fprintf(&buf, "if (err != nil) != tt.wantErr {\n")
+		fmt.Fprintf(&buf, "t.Fatalf(%q, err, tt.wantErr)\n}\n", display+" error = %v, wantErr %v")
+		if len(gots) > 0 {
+			fmt.Fprintf(&buf, "if tt.wantErr {\nreturn\n}\n")
+		}
+	}
+	for i, got := range gots {
+		format := display + " = %v, want %v"
+		if len(gots) > 1 {
+			format = display + " " + got + " = %v, want %v"
+		}
+		if comparable[i] {
+			fmt.Fprintf(&buf, "if %s != tt.%s {\n", got, wants[i])
+		} else {
+			reflect := qual(types.NewPackage("reflect", "reflect"))
+			fmt.Fprintf(&buf, "if !%s.DeepEqual(%s, tt.%s) {\n", reflect, got, wants[i])
+		}
+		fmt.Fprintf(&buf, "t.Errorf(%q, %s, tt.%s)\n}\n", format, got, wants[i])
+	}
+	fmt.Fprintf(&buf, "})\n}\n}\n")
+
+	text, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("formatting the test of %s: %v", funcDisplayName(fn), err)
+	}
+	return string(text), nil
+}
+
+// isContext reports whether t is context.Context.
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// capitalize returns s with its first letter in upper case.
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -38,6 +38,7 @@ func Highlight(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, po
 	if len(path) == 0 {
 		return nil, fmt.Errorf("no enclosing position found for %v:%v", position.Line, position.Character)
 	}
+	_, atIdent := path[0].(*ast.Ident)
 	// If start == end for astutil.PathEnclosingInterval, the 1-char interval
 	// following start is used instead. As a result, we might not get an exact
 	// match so we should check the 1-char interval to the left of the passed
@@ -54,6 +55,15 @@ func Highlight(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, po
 	if err != nil {
 		return nil, err
 	}
+	// Also highlight the brackets of a pair adjacent to the position,
+	// unless the bracket precedes an identifier at the position.
+	if pair, at := matchingBrackets(pgf, pos); at == pos || at != token.NoPos && !atIdent {
+		if result == nil {
+			result = make(map[posRange]struct{})
+		}
+		result[posRange{start: pair.open, end: pair.open + 1}] = struct{}{}
+		result[posRange{start: pair.close, end: pair.close + 1}] = struct{}{}
+	}
 	var ranges []protocol.Range
 	for rng := range result {
 		rng, err := pgf.PosRange(rng.start, rng.end)
@@ -95,6 +95,11 @@ type hoverJSON struct {
 	// fields of a (struct) type that were promoted through an
 	// embedded field.
 	promotedFields string
+
+	// aliasChain describes the chain of aliases traversed to
+	// resolve an alias type, and its underlying type.
+	// It is "" for a non-alias.
+	aliasChain string
 }
 
 // Hover implements the "textDocument/hover" RPC for Go files.
@@ -171,6 +176,27 @@ func hover(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp pro
 		}
 	}
 
+	// Handle hovering over a type or package named in a go:generate
+	// directive, by hovering over the type declaration instead.
+	if ref, ok := generateRefAt(pgf, pos); ok {
+		rng, err := pgf.PosRange(ref.Pos, ref.End)
+		if err != nil {
+			return protocol.Range{}, nil, err
+		}
+		if ref.IsPackage {
+			mp, err := generateRefPackage(ctx, snapshot, ref)
+			if err != nil {
+				return protocol.Range{}, nil, err
+			}
+			h, err := hoverPackageDoc(ctx, snapshot, mp)
+			return rng, h, err
+		}
+		if _, declPGF, declPos, ok := generateRefDecl(pkg, pgf, pos); ok {
+			hoverRange = &rng
+			pgf, pos = declPGF, declPos
+		}
+	}
+
 	// Handle hovering over a doc link
 	if obj, rng, _ := parseDocLink(pkg, pgf, pos); obj != nil {
 		// Built-ins have no position.
@@ -332,7 +358,7 @@ func hover(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp pro
 		sizeOffset = buf.String()
 	}
 
-	var typeDecl, methods, fields string
+	var typeDecl, methods, fields, chain string
 
 	// For "objects defined by a type spec", the signature produced by
 	// objectString is insufficient:
@@ -384,6 +410,18 @@ func hover(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp pro
 			}
 		}
 
+		// Alias chain
+		//
+		// For an alias, show each alias traversed to reach the
+		// aliased type, which may be declared in other packages.
+		if tname := obj.(*types.TypeName); spec.Assign.IsValid() {
+			links, err := aliasChain(ctx, snapshot, pkg, tname)
+			if err != nil {
+				event.Error(ctx, "resolving alias chain", err)
+			}
+			chain = formatAliasChain(links, tname, qf)
+		}
+
 		// Promoted fields
 		//
 		// Show a table of accessible fields of the (struct)
@@ -569,6 +607,7 @@ func hover(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp pro
 		typeDecl:          typeDecl,
 		methods:           methods,
 		promotedFields:    fields,
+		aliasChain:        chain,
 	}, nil
 }
 
@@ -654,21 +693,30 @@ func hoverImport(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Packa
 	if impMetadata == nil {
 		return protocol.Range{}, nil, bug.Errorf("failed to resolve import ID %q", impID)
 	}
+	h, err := hoverPackageDoc(ctx, snapshot, impMetadata)
+	if err != nil {
+		return protocol.Range{}, nil, err
+	}
+	return rng, h, nil
+}
 
+// hoverPackageDoc computes hover information for a package from its
+// package doc comment.
+func hoverPackageDoc(ctx context.Context, snapshot *cache.Snapshot, mp *metadata.Package) (*hoverJSON, error) {
 	// Find the first file with a package doc comment.
 	var comment *ast.CommentGroup
-	for _, f := range impMetadata.CompiledGoFiles {
+	for _, f := range mp.CompiledGoFiles {
 		fh, err := snapshot.ReadFile(ctx, f)
 		if err != nil {
 			if ctx.Err() != nil {
-				return protocol.Range{}, nil, ctx.Err()
+				return nil, ctx.Err()
 			}
 			continue
 		}
 		pgf, err := snapshot.ParseGo(ctx, fh, parsego.Header)
 		if err != nil {
 			if ctx.Err() != nil {
-				return protocol.Range{}, nil, ctx.Err()
+				return nil, ctx.Err()
 			}
 			continue
 		}
@@ -679,7 +727,7 @@ func hoverImport(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Packa
 	}
 
 	docText := comment.Text()
-	return rng, &hoverJSON{
+	return &hoverJSON{
 		Synopsis:          doc.Synopsis(docText),
 		FullDocumentation: docText,
 	}, nil
@@ -1123,6 +1171,7 @@ func formatHover(h *hoverJSON, options *settings.Options, pkgURL func(path Packa
 		parts := []string{
 			maybeMarkdown(h.Signature),
 			maybeMarkdown(h.typeDecl),
+			maybeMarkdown(h.aliasChain),
 			formatDoc(h, options),
 			maybeMarkdown(h.promotedFields),
 			maybeMarkdown(h.methods),
@@ -0,0 +1,436 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the code actions that convert a chain of if/else
+// statements that compare the same expression into a switch
+// statement, and back.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+)
+
+// ifChain is a chain of if/else-if statements that compare the same
+// expression, the tag, with a list of values each.
+type ifChain struct {
+	stmt    *ast.IfStmt      // the first if statement
+	tag     ast.Expr         // the compared expression
+	values  [][]ast.Expr     // the values of each condition
+	bodies  []*ast.BlockStmt // the body of each condition
+	elseBlk *ast.BlockStmt   // the final else block, or nil
+}
+
+// canConvertIfToSwitch reports whether the range [start, end) is on
+// the conditions of an if/else-if chain that may be converted to a
+// switch statement.
+func canConvertIfToSwitch(file *ast.File, info *types.Info, start, end token.Pos) (*ifChain, bool) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	var (
+		stmt *ast.IfStmt
+		i    int
+	)
+	for i = range path {
+		if s, ok := path[i].(*ast.IfStmt); ok {
+			stmt = s
+			break
+		}
+	}
+	if stmt == nil || within(stmt.Body, start, end) || within(stmt.Else, start, end) && !is[*ast.IfStmt](stmt.Else) {
+		return nil, false // not an if statement, or in a body
+	}
+	// Find the first statement of the chain.
+	for ; i+1 < len(path); i++ {
+		parent, ok := path[i+1].(*ast.IfStmt)
+		if !ok || parent.Else != path[i] {
+			break
+		}
+		stmt = parent
+	}
+
+	chain := &ifChain{stmt: stmt}
+	for s := stmt; ; {
+		if s != stmt && s.Init != nil {
+			return nil, false // an else-if with an init statement
+		}
+		values, ok := chain.compared(info, s.Cond)
+		if !ok {
+			return nil, false
+		}
+		chain.values = append(chain.values, values)
+		chain.bodies = append(chain.bodies, s.Body)
+		if next, ok := s.Else.(*ast.IfStmt); ok {
+			s = next
+			continue
+		}
+		chain.elseBlk, _ = s.Else.(*ast.BlockStmt)
+		break
+	}
+	if len(chain.values) < 2 {
+		return nil, false // a single if statement is not a chain
+	}
+
+	// A break statement in a body would break out of the switch.
+	for _, body := range chain.bodies {
+		if breaks(body) {
+			return nil, false
+		}
+	}
+	if chain.elseBlk != nil && breaks(chain.elseBlk) {
+		return nil, false
+	}
+
+	// The switch tag is evaluated once, and case values must be unique.
+	if !pureExpr(info, chain.tag) {
+		return nil, false
+	}
+	var consts []constant.Value
+	for _, values := range chain.values {
+		for _, v := range values {
+			if tv, ok := info.Types[v]; ok && tv.Value != nil {
+				for _, c := range consts {
+					if c.Kind() == tv.Value.Kind() && constant.Compare(c, token.EQL, tv.Value) {
+						return nil, false
+					}
+				}
+				consts = append(consts, tv.Value)
+			}
+		}
+	}
+	return chain, true
+}
+
+// compared returns the values with which cond compares the tag of the
+// chain, a disjunction of equality comparisons. It sets the tag from
+// the first comparison.
+func (chain *ifChain) compared(info *types.Info, cond ast.Expr) ([]ast.Expr, bool) {
+	switch cond := astutil.Unparen(cond).(type) {
+	case *ast.BinaryExpr:
+		switch cond.Op {
+		case token.LOR:
+			x, ok := chain.compared(info, cond.X)
+			if !ok {
+				return nil, false
+			}
+			y, ok := chain.compared(info, cond.Y)
+			if !ok {
+				return nil, false
+			}
+			return append(x, y...), true
+
+		case token.EQL:
+			if chain.tag == nil {
+				// The tag is the operand that is not a constant.
+				if info.Types[cond.X].Value == nil {
+					chain.tag = cond.X
+				} else if info.Types[cond.Y].Value == nil {
+					chain.tag = cond.Y
+				} else {
+					return nil, false
+				}
+			}
+			tag := types.ExprString(chain.tag)
+			if types.ExprString(cond.X) == tag {
+				return []ast.Expr{cond.Y}, true
+			}
+			if types.ExprString(cond.Y) == tag {
+				return []ast.Expr{cond.X}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ifToSwitch is a singleFileFixer that converts an if/else-if chain
+// to a switch statement.
+func ifToSwitch(fset *token.FileSet, start, end token.Pos, src []byte, file *ast.File, _ *types.Package, info *types.Info) (*token.FileSet, *analysis.SuggestedFix, error) {
+	chain, ok := canConvertIfToSwitch(file, info, start, end)
+	if !ok {
+		return nil, nil, fmt.Errorf("no if/else chain to convert")
+	}
+	tok := fset.File(file.Pos())
+	text := func(from, to token.Pos) (string, error) {
+		start, end, err := safetoken.Offsets(tok, from, to)
+		if err != nil {
+			return "", err
+		}
+		return string(src[start:end]), nil
+	}
+	indent, err := calculateIndentation(src, tok, chain.stmt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf strings.Builder
+	buf.WriteString("switch ")
+	if chain.stmt.Init != nil {
+		init, err := text(chain.stmt.Init.Pos(), chain.stmt.Init.End())
+		if err != nil {
+			return nil, nil, err
+		}
+		buf.WriteString(init + "; ")
+	}
+	tag, err := text(chain.tag.Pos(), chain.tag.End())
+	if err != nil {
+		return nil, nil, err
+	}
+	buf.WriteString(tag + " {\n")
+	clause := func(head string, body *ast.BlockStmt) error {
+		stmts, err := text(body.Lbrace+1, body.Rbrace)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(indent + head + ":" + strings.TrimRight(stmts, " \t\n") + "\n")
+		return nil
+	}
+	for i, values := range chain.values {
+		var list []string
+		for _, v := range values {
+			t, err := text(v.Pos(), v.End())
+			if err != nil {
+				return nil, nil, err
+			}
+			list = append(list, t)
+		}
+		if err := clause("case "+strings.Join(list, ", "), chain.bodies[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	if chain.elseBlk != nil {
+		if err := clause("default", chain.elseBlk); err != nil {
+			return nil, nil, err
+		}
+	}
+	buf.WriteString(indent + "}")
+
+	return fset, &analysis.SuggestedFix{
+		TextEdits: []analysis.TextEdit{{
+			Pos:     chain.stmt.Pos(),
+			End:     chain.stmt.End(),
+			NewText: []byte(buf.String()),
+		}},
+	}, nil
+}
+
+// canConvertSwitchToIf reports whether the range [start, end) is on
+// the header of an expression switch statement that may be converted
+// to an if/else-if chain.
+func canConvertSwitchToIf(file *ast.File, info *types.Info, start, end token.Pos) (*ast.SwitchStmt, bool) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	for i, n := range path {
+		stmt, ok := n.(*ast.SwitchStmt)
+		if !ok {
+			continue
+		}
+		if within(stmt.Body, start, end) {
+			return nil, false
+		}
+		if i+1 < len(path) && is[*ast.LabeledStmt](path[i+1]) {
+			return nil, false // break or continue may refer to the label
+		}
+		if stmt.Tag != nil && !pureExpr(info, stmt.Tag) {
+			return nil, false // the tag would be evaluated more than once
+		}
+		cases := 0
+		for _, clause := range stmt.Body.List {
+			clause := clause.(*ast.CaseClause)
+			if clause.List != nil {
+				cases++
+			}
+			for _, s := range clause.Body {
+				if breaks(s) {
+					return nil, false
+				}
+			}
+			if len(clause.Body) > 0 {
+				if b, ok := clause.Body[len(clause.Body)-1].(*ast.BranchStmt); ok && b.Tok == token.FALLTHROUGH {
+					return nil, false
+				}
+			}
+		}
+		if cases == 0 {
+			return nil, false
+		}
+		return stmt, true
+	}
+	return nil, false
+}
+
+// switchToIf is a singleFileFixer that converts an expression switch
+// statement to an if/else-if chain.
+func switchToIf(fset *token.FileSet, start, end token.Pos, src []byte, file *ast.File, _ *types.Package, info *types.Info) (*token.FileSet, *analysis.SuggestedFix, error) {
+	stmt, ok := canConvertSwitchToIf(file, info, start, end)
+	if !ok {
+		return nil, nil, fmt.Errorf("no switch statement to convert")
+	}
+	tok := fset.File(file.Pos())
+	text := func(from, to token.Pos) (string, error) {
+		start, end, err := safetoken.Offsets(tok, from, to)
+		if err != nil {
+			return "", err
+		}
+		return string(src[start:end]), nil
+	}
+	indent, err := calculateIndentation(src, tok, stmt)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tag string
+	if stmt.Tag != nil {
+		if tag, err = text(stmt.Tag.Pos(), stmt.Tag.End()); err != nil {
+			return nil, nil, err
+		}
+		if b, ok := stmt.Tag.(*ast.BinaryExpr); ok && b.Op.Precedence() <= token.EQL.Precedence() {
+			tag = "(" + tag + ")"
+		}
+	}
+
+	// body returns the statements of the ith clause, and the
+	// comments that follow them, followed by the closing brace.
+	body := func(i int) (string, error) {
+		clause := stmt.Body.List[i].(*ast.CaseClause)
+		end := stmt.Body.Rbrace
+		if i+1 < len(stmt.Body.List) {
+			end = stmt.Body.List[i+1].Pos()
+		}
+		stmts, err := text(clause.Colon+1, end)
+		if err != nil {
+			return "", err
+		}
+		stmts = strings.TrimRight(stmts, " \t\n")
+		if strings.TrimSpace(stmts) == "" {
+			return "", nil
+		}
+		return stmts + "\n" + indent + "}", nil
+	}
+
+	// Keep any comments that precede the first clause.
+	comment, err := text(stmt.Body.Lbrace+1, stmt.Body.List[0].Pos())
+	if err != nil {
+		return nil, nil, err
+	}
+	comment = strings.TrimRight(comment, " \t\n")
+
+	var buf strings.Builder
+	deflt := -1
+	for i, clause := range stmt.Body.List {
+		clause := clause.(*ast.CaseClause)
+		if clause.List == nil {
+			deflt = i
+			continue
+		}
+		first := buf.Len() == 0
+		if first {
+			buf.WriteString("if ")
+			if stmt.Init != nil {
+				init, err := text(stmt.Init.Pos(), stmt.Init.End())
+				if err != nil {
+					return nil, nil, err
+				}
+				buf.WriteString(init + "; ")
+			}
+		} else {
+			buf.WriteString(" else if ")
+		}
+		var conds []string
+		for _, v := range clause.List {
+			value, err := text(v.Pos(), v.End())
+			if err != nil {
+				return nil, nil, err
+			}
+			if stmt.Tag == nil {
+				conds = append(conds, value)
+				continue
+			}
+			if b, ok := v.(*ast.BinaryExpr); ok && b.Op.Precedence() <= token.EQL.Precedence() {
+				value = "(" + value + ")"
+			}
+			conds = append(conds, tag+" == "+value)
+		}
+		buf.WriteString(strings.Join(conds, " || ") + " {")
+		if first {
+			buf.WriteString(comment)
+		}
+		b, err := body(i)
+		if err != nil {
+			return nil, nil, err
+		}
+		if b == "" {
+			b = "\n" + indent + "}"
+		}
+		buf.WriteString(b)
+	}
+	if deflt >= 0 {
+		b, err := body(deflt)
+		if err != nil {
+			return nil, nil, err
+		}
+		if b != "" {
+			buf.WriteString(" else {" + b)
+		}
+	}
+
+	return fset, &analysis.SuggestedFix{
+		TextEdits: []analysis.TextEdit{{
+			Pos:     stmt.Pos(),
+			End:     stmt.End(),
+			NewText: []byte(buf.String()),
+		}},
+	}, nil
+}
+
+// within reports whether n is non-nil and contains [start, end).
+func within(n ast.Node, start, end token.Pos) bool {
+	return n != nil && n.Pos() <= start && end <= n.End()
+}
+
+// breaks reports whether n contains an unlabeled break statement that
+// refers to an enclosing statement.
+func breaks(n ast.Node) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit, *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			return false
+		case *ast.BranchStmt:
+			if n.Tok == token.BREAK && n.Label == nil {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// pureExpr reports whether e may be evaluated any number of times
+// without changing the behavior of the program: it contains no
+// function calls or channel receives.
+func pureExpr(info *types.Info, e ast.Expr) bool {
+	pure := true
+	ast.Inspect(e, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if tv, ok := info.Types[n.Fun]; !ok || !tv.IsType() {
+				pure = false // not a conversion
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				pure = false
+			}
+		}
+		return pure
+	})
+	return pure
+}
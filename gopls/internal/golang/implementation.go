@@ -24,7 +24,9 @@ import (
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/util/bug"
 	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/aliases"
 	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/typeparams"
 )
 
 // This file defines the new implementation of the 'implementation'
@@ -48,11 +50,34 @@ import (
 //
 // If the position denotes a method, the computation is applied to its
 // receiver type and then its corresponding methods are returned.
-func Implementation(ctx context.Context, snapshot *cache.Snapshot, f file.Handle, pp protocol.Position) ([]protocol.Location, error) {
+//
+// If report is non-nil, it is called with each batch of locations, in
+// no particular order, as the search finds them, so that they can be
+// streamed to the client. Each location is reported exactly once.
+func Implementation(ctx context.Context, snapshot *cache.Snapshot, f file.Handle, pp protocol.Position, report func([]protocol.Location)) ([]protocol.Location, error) {
 	ctx, done := event.Start(ctx, "golang.Implementation")
 	defer done()
 
-	locs, err := implementations(ctx, snapshot, f, pp)
+	// If streaming, de-duplicate the batches as below.
+	// (The calls to stream are serialized.)
+	var stream func([]protocol.Location)
+	if report != nil {
+		seen := make(map[protocol.Location]bool)
+		stream = func(batch []protocol.Location) {
+			var locs []protocol.Location
+			for _, loc := range batch {
+				if !seen[loc] {
+					seen[loc] = true
+					locs = append(locs, loc)
+				}
+			}
+			if len(locs) > 0 {
+				report(locs)
+			}
+		}
+	}
+
+	locs, err := implementations(ctx, snapshot, f, pp, stream)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +97,7 @@ func Implementation(ctx context.Context, snapshot *cache.Snapshot, f file.Handle
 	return locs, nil
 }
 
-func implementations(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position) ([]protocol.Location, error) {
+func implementations(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position, stream func([]protocol.Location)) ([]protocol.Location, error) {
 	// First, find the object referenced at the cursor by type checking the
 	// current package.
 	obj, pkg, err := implementsObj(ctx, snapshot, fh.URI(), pp)
@@ -123,6 +148,11 @@ func implementations(ctx context.Context, snapshot *cache.Snapshot, fh file.Hand
 	typeOrMethod := func(obj types.Object) (types.Type, string) {
 		switch obj := obj.(type) {
 		case *types.TypeName:
+			// For type parameters, use the constraint,
+			// to find the types that satisfy it.
+			if tparam, ok := obj.Type().(*types.TypeParam); ok {
+				return tparam.Constraint(), ""
+			}
 			return obj.Type(), ""
 		case *types.Func:
 			// For methods, use the receiver type, which may be anonymous.
@@ -138,9 +168,10 @@ func implementations(ctx context.Context, snapshot *cache.Snapshot, fh file.Hand
 	}
 
 	// Compute the method-set fingerprint used as a key to the global search.
-	key, hasMethods := methodsets.KeyOf(queryType)
-	if !hasMethods {
-		// A type with no methods yields an empty result.
+	key, nontrivial := methodsets.KeyOf(queryType)
+	if !nontrivial {
+		// A type with no methods yields an empty result,
+		// unless it may belong to the type set of a constraint.
 		// (No point reporting that every type satisfies 'any'.)
 		return nil, nil
 	}
@@ -182,6 +213,16 @@ func implementations(ctx context.Context, snapshot *cache.Snapshot, fh file.Hand
 		locsMu sync.Mutex
 		locs   []protocol.Location
 	)
+	// add adds the locations found in a package to the result,
+	// and streams them.
+	add := func(batch []protocol.Location) {
+		locsMu.Lock()
+		defer locsMu.Unlock()
+		locs = append(locs, batch...)
+		if stream != nil {
+			stream(batch)
+		}
+	}
 	// local search
 	for _, localPkg := range localPkgs {
 		// The localImplementations algorithm assumes needle and haystack
@@ -221,9 +262,7 @@ func implementations(ctx context.Context, snapshot *cache.Snapshot, fh file.Hand
 			if err != nil {
 				return fmt.Errorf("querying local implementations %q: %v", pkgID, err)
 			}
-			locsMu.Lock()
-			locs = append(locs, localLocs...)
-			locsMu.Unlock()
+			add(localLocs)
 			return nil
 		})
 	}
@@ -231,20 +270,22 @@ func implementations(ctx context.Context, snapshot *cache.Snapshot, fh file.Hand
 	for _, index := range indexes {
 		index := index
 		group.Go(func() error {
-			for _, res := range index.Search(key, queryMethodID) {
-				loc := res.Location
+			results := index.Search(key, queryMethodID)
+			batch := make([]protocol.Location, len(results))
+			var g errgroup.Group
+			for i, res := range results {
+				i, loc := i, res.Location
 				// Map offsets to protocol.Locations in parallel (may involve I/O).
-				group.Go(func() error {
+				g.Go(func() error {
 					ploc, err := offsetToLocation(ctx, snapshot, loc.Filename, loc.Start, loc.End)
-					if err != nil {
-						return err
-					}
-					locsMu.Lock()
-					locs = append(locs, ploc)
-					locsMu.Unlock()
-					return nil
+					batch[i] = ploc
+					return err
 				})
 			}
+			if err := g.Wait(); err != nil {
+				return err
+			}
+			add(batch)
 			return nil
 		})
 	}
@@ -373,10 +414,11 @@ func localImplementations(ctx context.Context, snapshot *cache.Snapshot, pkg *ca
 				return true // not assignable
 			}
 
-			// Ignore types with empty method sets.
+			// Ignore types with empty method sets,
+			// unless one type is a constraint.
 			// (No point reporting that every type satisfies 'any'.)
 			mset := types.NewMethodSet(candidateType)
-			if mset.Len() == 0 {
+			if mset.Len() == 0 && !isConstraint(candidateType) && !isConstraint(queryType) {
 				return true
 			}
 
@@ -464,15 +506,40 @@ func concreteImplementsIntf(a, b types.Type) bool {
 		a, b = b, a
 	}
 
-	// TODO(adonovan): this should really use GenericAssignableTo
-	// to report (e.g.) "ArrayList[T] implements List[T]", but
-	// GenericAssignableTo doesn't work correctly on pointers to
-	// generic named types. Thus the legacy implementation and the
-	// "local" part of implementations fail to report generics.
-	// The global algorithm based on subsets does the right thing.
+	// A constraint restricts the type itself, not a pointer to it,
+	// so undo methodsets.EnsurePointer.
+	if isConstraint(b) {
+		if ptr, ok := a.(*types.Pointer); ok {
+			if _, ok := aliases.Unalias(ptr.Elem()).(*types.Named); ok {
+				a = ptr.Elem()
+			}
+		}
+	}
+
+	// go/types cannot relate uninstantiated generic types
+	// (e.g. to report that ArrayList[T] implements List[T]),
+	// so use the fingerprint algorithm of the global search,
+	// which unifies their type parameters.
+	if isGeneric(a) || isGeneric(b) {
+		return methodsets.Implements(a, b)
+	}
 	return types.AssignableTo(a, b)
 }
 
+// isConstraint reports whether t is an interface whose type set is
+// restricted by type terms, such as interface{ ~int | ~float64 }.
+func isConstraint(t types.Type) bool {
+	iface, ok := t.Underlying().(*types.Interface)
+	return ok && !iface.IsMethodSet()
+}
+
+// isGeneric reports whether t, or the type it points to, is a generic
+// named type that has not been instantiated.
+func isGeneric(t types.Type) bool {
+	named, ok := aliases.Unalias(typeparams.Deref(t)).(*types.Named)
+	return ok && named.TypeParams().Len() > 0 && named.TypeArgs().Len() == 0
+}
+
 var (
 	// TODO(adonovan): why do various RPC handlers related to
 	// IncomingCalls return (nil, nil) on the protocol in response
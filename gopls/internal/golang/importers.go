@@ -0,0 +1,176 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/internal/event"
+)
+
+// Importers returns the packages that import the package of the
+// specified file, directly or, up to the specified depth (0 for no
+// limit), transitively, according to the metadata graph of the
+// snapshot. Each importer has the locations of its imports of the
+// packages one step closer to the package of the file.
+func Importers(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI, depth int) ([]command.Importer, error) {
+	ctx, done := event.Start(ctx, "golang.Importers")
+	defer done()
+
+	dist, importers, err := reverseImports(ctx, snapshot, uri, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Variants of the same package, such as a package and its
+	// in-package test, are reported as one importer.
+	byPath := make(map[PackagePath]*command.Importer)
+	seen := make(map[protocol.Location]bool)
+	for _, mp := range importers {
+		d := dist[mp.ID]
+		imp := byPath[mp.PkgPath]
+		if imp == nil {
+			imp = &command.Importer{PkgPath: string(mp.PkgPath), Depth: d}
+			byPath[mp.PkgPath] = imp
+		} else if d > imp.Depth {
+			continue // reached more directly by another variant
+		}
+		for _, uri := range mp.GoFiles {
+			fh, err := snapshot.ReadFile(ctx, uri)
+			if err != nil {
+				return nil, err
+			}
+			pgf, err := snapshot.ParseGo(ctx, fh, parsego.Header)
+			if err != nil {
+				return nil, err
+			}
+			for _, spec := range pgf.File.Imports {
+				id, ok := mp.DepsByImpPath[metadata.UnquoteImportPath(spec)]
+				if !ok {
+					continue
+				}
+				if depDist, ok := dist[id]; !ok || depDist != d-1 {
+					continue
+				}
+				loc, err := pgf.NodeLocation(spec.Path)
+				if err != nil {
+					return nil, err
+				}
+				if !seen[loc] {
+					seen[loc] = true
+					imp.Locations = append(imp.Locations, loc)
+				}
+			}
+		}
+	}
+
+	result := make([]command.Importer, 0, len(byPath))
+	for _, imp := range byPath {
+		sort.Slice(imp.Locations, func(i, j int) bool {
+			return protocol.CompareLocation(imp.Locations[i], imp.Locations[j]) < 0
+		})
+		result = append(result, *imp)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		x, y := result[i], result[j]
+		if x.Depth != y.Depth {
+			return x.Depth < y.Depth
+		}
+		return x.PkgPath < y.PkgPath
+	})
+	return result, nil
+}
+
+// reverseImports searches the reverse import graph breadth first from
+// the packages of the specified file, up to the specified depth (0 for
+// no limit). It returns the distance from the file's packages of each
+// package it reaches, and the importers among them, excluding
+// intermediate test variants and the variants of the file's packages.
+func reverseImports(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI, depth int) (map[PackageID]int, []*metadata.Package, error) {
+	mps, err := snapshot.MetadataForFile(ctx, uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	metadata.RemoveIntermediateTestVariants(&mps)
+	if len(mps) == 0 {
+		return nil, nil, fmt.Errorf("no package metadata for file %s", uri)
+	}
+
+	g := snapshot.MetadataGraph()
+	dist := make(map[PackageID]int)
+	self := make(map[PackagePath]bool)
+	var frontier []PackageID
+	for _, mp := range mps {
+		dist[mp.ID] = 0
+		self[mp.PkgPath] = true
+		frontier = append(frontier, mp.ID)
+	}
+	var importers []*metadata.Package
+	for d := 1; len(frontier) > 0 && (depth <= 0 || d <= depth); d++ {
+		var next []PackageID
+		for _, id := range frontier {
+			for _, importerID := range g.ImportedBy[id] {
+				if _, ok := dist[importerID]; ok {
+					continue
+				}
+				mp := g.Packages[importerID]
+				if mp == nil {
+					continue
+				}
+				dist[importerID] = d
+				next = append(next, importerID)
+				// Intermediate test variants are traversed, as
+				// they may lead to test packages, but not reported.
+				if !mp.IsIntermediateTestVariant() && !self[mp.PkgPath] {
+					importers = append(importers, mp)
+				}
+			}
+		}
+		frontier = next
+	}
+	return dist, importers, nil
+}
+
+// importersCodeLens returns a code lens on the package clause of a Go
+// file that shows the number of direct importers of its package and
+// lists them.
+func importersCodeLens(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]protocol.CodeLens, error) {
+	_, importers, err := reverseImports(ctx, snapshot, fh.URI(), 1)
+	if err != nil {
+		return nil, err
+	}
+	paths := make(map[PackagePath]bool)
+	for _, mp := range importers {
+		paths[mp.PkgPath] = true
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	title := "1 importer"
+	if len(paths) > 1 {
+		title = fmt.Sprintf("%d importers", len(paths))
+	}
+	pgf, err := snapshot.ParseGo(ctx, fh, parsego.Header)
+	if err != nil {
+		return nil, err
+	}
+	rng, err := pgf.PosRange(pgf.File.Package, pgf.File.Package)
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := command.NewImportersCommand(title, command.ImportersArgs{URI: fh.URI(), Depth: 1})
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.CodeLens{{Range: rng, Command: &cmd}}, nil
+}
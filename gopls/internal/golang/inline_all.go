@@ -10,6 +10,8 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/types"
+	"sort"
+	"strings"
 
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/types/typeutil"
@@ -56,7 +58,7 @@ func inlineAllCalls(ctx context.Context, logf func(string, ...any), snapshot *ca
 		if err != nil {
 			return nil, err
 		}
-		refs, err = References(ctx, snapshot, fh, funcPos, false)
+		refs, err = References(ctx, snapshot, fh, funcPos, false, nil)
 		if err != nil {
 			return nil, fmt.Errorf("finding references to rewrite: %v", err)
 		}
@@ -94,6 +96,10 @@ func inlineAllCalls(ctx context.Context, logf func(string, ...any), snapshot *ca
 		}
 	}
 
+	// References that cannot be rewritten are reported together, once all
+	// have been examined.
+	var conflicts rewriteConflicts
+
 	// Organize calls by top file declaration. Calls within a single file may
 	// affect each other, as the inlining edit may affect the surrounding scope
 	// or imports Therefore, when inlining subsequent calls in the same
@@ -135,7 +141,8 @@ func inlineAllCalls(ctx context.Context, logf func(string, ...any), snapshot *ca
 			//    use(f)
 			// is replaced by
 			//    use(func(...) { f(...) })
-			return nil, fmt.Errorf("cannot inline: found non-call function reference %v", ref)
+			conflicts.add(ref, "found non-call function reference")
+			continue
 		}
 		// Sanity check.
 		if obj := refpkg.TypesInfo().ObjectOf(name); obj == nil ||
@@ -177,13 +184,20 @@ func inlineAllCalls(ctx context.Context, logf func(string, ...any), snapshot *ca
 		// because inlining may change the source order of the inner call with
 		// respect to the inlined outer call, and so the heuristic we use to find
 		// the next call (counting from top-to-bottom) does not work.
+		overlapping := false
 		for i := range calls {
 			if i > 0 && calls[i-1].End() > calls[i].Pos() {
-				return nil, fmt.Errorf("%s: can't inline overlapping call %s", uri, types.ExprString(calls[i-1]))
+				conflicts.addNode(callInfo.pgf, calls[i-1], fmt.Sprintf("can't inline overlapping call %s", types.ExprString(calls[i-1])))
+				overlapping = true
 			}
 		}
+		if overlapping {
+			continue
+		}
 
 		currentCall := 0
+		rewritten := false // whether content differs from the original file
+	inlineCalls:
 		for currentCall < len(calls) {
 			caller := &inline.Caller{
 				Fset:    fset,
@@ -195,9 +209,17 @@ func inlineAllCalls(ctx context.Context, logf func(string, ...any), snapshot *ca
 			}
 			res, err := inline.Inline(caller, callee, &inline.Options{Logf: logf})
 			if err != nil {
-				return nil, fmt.Errorf("inlining failed: %v", err)
+				if !rewritten {
+					conflicts.addNode(callInfo.pgf, calls[currentCall], fmt.Sprintf("inlining failed: %v", err))
+				} else {
+					// Positions in the rewritten file are not meaningful to the user.
+					conflicts.add(protocol.Location{URI: uri}, fmt.Sprintf("inlining failed: %v", err))
+				}
+				content = nil
+				break inlineCalls
 			}
 			content = res.Content
+			rewritten = true
 			if post != nil {
 				content = post(content)
 			}
@@ -270,7 +292,59 @@ func inlineAllCalls(ctx context.Context, logf func(string, ...any), snapshot *ca
 			}
 		}
 
-		result[callInfo.pgf.URI] = content
+		if content != nil {
+			result[callInfo.pgf.URI] = content
+		}
+	}
+	if len(conflicts) > 0 {
+		conflicts.sort()
+		return nil, conflicts
 	}
 	return result, nil
 }
+
+// rewriteConflicts is an error reporting all the references that a
+// rewrite of calls could not update, in order of location.
+type rewriteConflicts []rewriteConflict
+
+// A rewriteConflict is a reference that a rewrite of calls could not update.
+type rewriteConflict struct {
+	loc protocol.Location
+	msg string
+}
+
+func (c *rewriteConflicts) add(loc protocol.Location, msg string) {
+	*c = append(*c, rewriteConflict{loc, msg})
+}
+
+// addNode records a conflict at the start of node n in the file pgf.
+func (c *rewriteConflicts) addNode(pgf *parsego.File, n ast.Node, msg string) {
+	loc, err := pgf.PosLocation(n.Pos(), n.Pos())
+	if err != nil {
+		loc = protocol.Location{URI: pgf.URI}
+	}
+	c.add(loc, msg)
+}
+
+// sort sorts the conflicts by location.
+func (c rewriteConflicts) sort() {
+	sort.Slice(c, func(i, j int) bool {
+		x, y := c[i].loc, c[j].loc
+		if x.URI != y.URI {
+			return x.URI < y.URI
+		}
+		return protocol.ComparePosition(x.Range.Start, y.Range.Start) < 0
+	})
+}
+
+func (c rewriteConflicts) Error() string {
+	var buf strings.Builder
+	for i, conflict := range c {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		start := conflict.loc.Range.Start
+		fmt.Fprintf(&buf, "%s:%d:%d: %s", conflict.loc.URI.Path(), start.Line+1, start.Character+1, conflict.msg)
+	}
+	return buf.String()
+}
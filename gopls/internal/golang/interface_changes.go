@@ -0,0 +1,316 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the reporting of changes to the interfaces
+// satisfied by the types of open files (see the interfaceChanges
+// setting).
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/methodsets"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/typesinternal"
+)
+
+// An InterfaceTracker reports the changes to the set of interfaces
+// satisfied by the types declared (or given methods) in open files.
+//
+// For each package with open files, it records as a baseline the
+// interfaces satisfied by those types when the files were last saved,
+// and forgets it once the package has no open files.
+// While any of them has unsaved edits, each interface that a type has
+// newly come to satisfy, or no longer satisfies, is reported as an
+// informational diagnostic, so that an accidental change to a method
+// signature is noticed as soon as it is typed.
+//
+// The relation is computed from the method-set indexes of the package
+// and of its direct dependencies, which are cached by the snapshot, so
+// only the edited package is re-indexed after each change.
+type InterfaceTracker struct {
+	mu       sync.Mutex
+	baseline map[string]map[string]map[string]interfaceRef // package key -> type name -> interface key -> interface
+}
+
+// An interfaceRef describes an interface satisfied by a type.
+type interfaceRef struct {
+	name string            // name relative to the type's package, e.g. "io.Writer"
+	loc  protocol.Location // location of the interface's name
+}
+
+// Diagnostics returns the changes to the interfaces satisfied by the
+// types of the open files of snapshot, if the interfaceChanges setting
+// is enabled.
+func (t *InterfaceTracker) Diagnostics(ctx context.Context, snapshot *cache.Snapshot) (map[protocol.DocumentURI][]*cache.Diagnostic, error) {
+	if !snapshot.Options().InterfaceChanges {
+		return nil, nil
+	}
+
+	// Group the open Go files by workspace package.
+	type openPackage struct {
+		mp    *metadata.Package
+		files map[protocol.DocumentURI]bool
+		saved bool // all open files of the package are saved
+	}
+	open := make(map[PackageID]*openPackage)
+	for _, fh := range snapshot.Overlays() {
+		if snapshot.FileKind(fh) != file.Go {
+			continue
+		}
+		mp, err := NarrowestMetadataForFile(ctx, snapshot, fh.URI())
+		if err != nil || !snapshot.IsWorkspacePackage(ctx, mp.ID) {
+			continue
+		}
+		op, ok := open[mp.ID]
+		if !ok {
+			op = &openPackage{mp: mp, files: make(map[protocol.DocumentURI]bool), saved: true}
+			open[mp.ID] = op
+		}
+		op.files[fh.URI()] = true
+		op.saved = op.saved && fh.SameContentsOnDisk()
+	}
+
+	// Forget the baselines of the view's packages that no longer have
+	// open files: they are recorded again when a file is reopened.
+	viewPrefix := snapshot.View().ID() + " "
+	t.mu.Lock()
+	for pkgKey := range t.baseline {
+		if id, ok := strings.CutPrefix(pkgKey, viewPrefix); ok && open[PackageID(id)] == nil {
+			delete(t.baseline, pkgKey)
+		}
+	}
+	t.mu.Unlock()
+
+	reports := make(map[protocol.DocumentURI][]*cache.Diagnostic)
+	for id, op := range open {
+		pkgs, err := snapshot.TypeCheck(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		pkg := pkgs[0]
+		if len(pkg.ParseErrors()) > 0 {
+			continue // don't report the transient effects of incomplete edits
+		}
+		tnames := openTypeNames(pkg, op.files)
+		current, err := satisfiedInterfaces(ctx, snapshot, pkg, tnames)
+		if err != nil {
+			return nil, err
+		}
+
+		// Record the baseline from the saved files, or compare
+		// against it. A package whose files have had unsaved edits
+		// since they were opened has no baseline until they are saved.
+		pkgKey := viewPrefix + string(id)
+		t.mu.Lock()
+		if op.saved {
+			if t.baseline == nil {
+				t.baseline = make(map[string]map[string]map[string]interfaceRef)
+			}
+			baseline := make(map[string]map[string]interfaceRef)
+			for _, tname := range tnames {
+				baseline[tname.Name()] = current[tname]
+			}
+			t.baseline[pkgKey] = baseline
+			t.mu.Unlock()
+			continue
+		}
+		baseline := t.baseline[pkgKey]
+		var changes []*cache.Diagnostic
+		for _, tname := range tnames {
+			cur := current[tname]
+			base, ok := baseline[tname.Name()]
+			if !ok {
+				continue // a type declared since the files were saved
+			}
+			diags, err := interfaceChanges(ctx, snapshot, pkg, tname, base, cur)
+			if err != nil {
+				t.mu.Unlock()
+				return nil, err
+			}
+			changes = append(changes, diags...)
+		}
+		t.mu.Unlock()
+
+		for _, diag := range changes {
+			reports[diag.URI] = append(reports[diag.URI], diag)
+		}
+	}
+	return reports, nil
+}
+
+// openTypeNames returns the concrete, non-generic package-level types
+// of pkg that are declared in, or have methods declared in, the
+// specified files.
+func openTypeNames(pkg *cache.Package, files map[protocol.DocumentURI]bool) []*types.TypeName {
+	var (
+		info   = pkg.TypesInfo()
+		seen   = make(map[*types.TypeName]bool)
+		tnames []*types.TypeName
+	)
+	add := func(obj types.Object) {
+		tname, ok := obj.(*types.TypeName)
+		if !ok || seen[tname] || tname.IsAlias() || tname.Parent() != pkg.Types().Scope() {
+			return
+		}
+		named, ok := tname.Type().(*types.Named)
+		if !ok || types.IsInterface(named) || named.TypeParams().Len() > 0 {
+			return
+		}
+		seen[tname] = true
+		tnames = append(tnames, tname)
+	}
+	for _, pgf := range pkg.CompiledGoFiles() {
+		if !files[pgf.URI] {
+			continue
+		}
+		for _, decl := range pgf.File.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok == token.TYPE {
+					for _, spec := range decl.Specs {
+						add(info.Defs[spec.(*ast.TypeSpec).Name])
+					}
+				}
+			case *ast.FuncDecl:
+				if fn, ok := info.Defs[decl.Name].(*types.Func); ok {
+					if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+						if _, named := typesinternal.ReceiverNamed(recv); named != nil {
+							add(named.Obj())
+						}
+					}
+				}
+			}
+		}
+	}
+	return tnames
+}
+
+// satisfiedInterfaces returns, for each of the types tnames of pkg,
+// the interfaces it satisfies that are declared in pkg, or exported
+// by one of its direct dependencies, keyed by package path and name.
+func satisfiedInterfaces(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, tnames []*types.TypeName) (map[*types.TypeName]map[string]interfaceRef, error) {
+	mp := pkg.Metadata()
+	mps := []*metadata.Package{mp}
+	ids := []PackageID{mp.ID}
+	for _, id := range mp.DepsByPkgPath {
+		if dep := snapshot.Metadata(id); dep != nil {
+			mps = append(mps, dep)
+			ids = append(ids, id)
+		}
+	}
+	indexes, err := snapshot.MethodSets(ctx, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("querying method sets: %v", err)
+	}
+
+	result := make(map[*types.TypeName]map[string]interfaceRef)
+	for _, tname := range tnames {
+		ifaces := make(map[string]interfaceRef)
+		result[tname] = ifaces
+		key, ok := methodsets.KeyOf(tname.Type())
+		if !ok {
+			continue // no methods
+		}
+		for i, index := range indexes {
+			for _, res := range index.Search(key, "") {
+				uri := protocol.URIFromPath(res.Location.Filename)
+				fh, err := snapshot.ReadFile(ctx, uri)
+				if err != nil {
+					return nil, err
+				}
+				content, err := fh.Content()
+				if err != nil {
+					return nil, err
+				}
+				start, end := res.Location.Start, res.Location.End
+				if end > len(content) {
+					continue // stale index
+				}
+				name := string(content[start:end])
+				if i > 0 {
+					if !token.IsExported(name) {
+						continue
+					}
+					name = string(mps[i].Name) + "." + name
+				}
+				loc, err := protocol.NewMapper(uri, content).OffsetLocation(start, end)
+				if err != nil {
+					return nil, err
+				}
+				ifaces[string(mps[i].PkgPath)+"."+name] = interfaceRef{name: name, loc: loc}
+			}
+		}
+	}
+	return result, nil
+}
+
+// interfaceChanges returns a diagnostic at the declaration of tname for
+// each interface in cur but not base, and each in base but not cur.
+func interfaceChanges(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, tname *types.TypeName, base, cur map[string]interfaceRef) ([]*cache.Diagnostic, error) {
+	var (
+		added, removed []string
+		refs           = make(map[string]interfaceRef)
+	)
+	for k, ref := range cur {
+		if _, ok := base[k]; !ok {
+			added = append(added, k)
+			refs[k] = ref
+		}
+	}
+	for k, ref := range base {
+		if _, ok := cur[k]; !ok {
+			removed = append(removed, k)
+			// The location of an interface of this package may have
+			// moved since the baseline was recorded.
+			if obj, ok := pkg.Types().Scope().Lookup(ref.name).(*types.TypeName); ok {
+				if loc, err := mapPosition(ctx, pkg.FileSet(), snapshot, obj.Pos(), obj.Pos()+token.Pos(len(obj.Name()))); err == nil {
+					ref.loc = loc
+				}
+			}
+			refs[k] = ref
+		}
+	}
+	if len(added)+len(removed) == 0 {
+		return nil, nil
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	loc, err := mapPosition(ctx, pkg.FileSet(), snapshot, tname.Pos(), tname.Pos()+token.Pos(len(tname.Name())))
+	if err != nil {
+		return nil, err
+	}
+	var diags []*cache.Diagnostic
+	report := func(keys []string, format string) {
+		for _, k := range keys {
+			ref := refs[k]
+			diags = append(diags, &cache.Diagnostic{
+				URI:      loc.URI,
+				Range:    loc.Range,
+				Severity: protocol.SeverityInformation,
+				Source:   cache.InterfaceChanges,
+				Message:  fmt.Sprintf(format, tname.Name(), ref.name),
+				Related: []protocol.DiagnosticRelatedInformation{{
+					Location: ref.loc,
+					Message:  "interface " + ref.name,
+				}},
+			})
+		}
+	}
+	report(added, "%s now implements %s")
+	report(removed, "%s no longer implements %s")
+	return diags, nil
+}
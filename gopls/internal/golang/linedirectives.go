@@ -0,0 +1,269 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/event"
+)
+
+// This file defines the mapping of locations through //line
+// directives, in both directions: from a generated Go file to the
+// original source named by its directives (for the lineDirectives
+// option), and from the original source to the generated files (for
+// the gopls.generated_location command).
+//
+// Elsewhere, gopls deliberately ignores line directives: positions
+// within Go files are always those of the file itself.
+
+// A DirectiveMapper maps locations in Go files through their //line
+// directives. It caches the files it parses, so a single mapper
+// should be used for a batch of related locations, such as the
+// diagnostics of a package.
+type DirectiveMapper struct {
+	ctx      context.Context
+	snapshot *cache.Snapshot
+	files    map[protocol.DocumentURI]*parsego.File    // nil => no directives
+	targets  map[protocol.DocumentURI]*protocol.Mapper // nil => unreadable
+}
+
+// NewDirectiveMapper returns a mapper of the Go files of the snapshot.
+func NewDirectiveMapper(ctx context.Context, snapshot *cache.Snapshot) *DirectiveMapper {
+	return &DirectiveMapper{
+		ctx:      ctx,
+		snapshot: snapshot,
+		files:    make(map[protocol.DocumentURI]*parsego.File),
+		targets:  make(map[protocol.DocumentURI]*protocol.Mapper),
+	}
+}
+
+// Original returns the location in the original source to which the
+// line directives of a generated Go file map the given location, and
+// reports whether it differs from loc. It returns loc unchanged if the
+// location is not subject to a directive, or if the original source
+// does not exist.
+//
+// If the directive specifies only a line, the result spans that whole
+// line.
+func (m *DirectiveMapper) Original(loc protocol.Location) (protocol.Location, bool) {
+	pgf := m.parse(loc.URI)
+	if pgf == nil {
+		return loc, false
+	}
+	start, end, err := pgf.RangePos(loc.Range)
+	if err != nil {
+		return loc, false
+	}
+	posn := pgf.Tok.PositionFor(start, true)
+	if posn == pgf.Tok.PositionFor(start, false) {
+		return loc, false // not subject to a directive
+	}
+	uri := protocol.URIFromPath(posn.Filename)
+	mapper := m.target(uri)
+	if mapper == nil {
+		return loc, false
+	}
+
+	startOffset, ok := lineColOffset(mapper, posn.Line, posn.Column)
+	if !ok {
+		return loc, false
+	}
+	var endOffset int
+	if posn.Column == 0 {
+		// Column unknown: use the whole line.
+		endOffset = startOffset + lineLength(mapper.Content[startOffset:])
+	} else {
+		endOffset = startOffset
+		if endPosn := pgf.Tok.PositionFor(end, true); endPosn.Filename == posn.Filename && endPosn.Column > 0 {
+			if offset, ok := lineColOffset(mapper, endPosn.Line, endPosn.Column); ok && offset >= startOffset {
+				endOffset = offset
+			}
+		}
+	}
+	orig, err := mapper.OffsetLocation(startOffset, endOffset)
+	if err != nil {
+		return loc, false
+	}
+	return orig, true
+}
+
+// OriginalLocations returns the locations obtained by mapping each of
+// locs through line directives, as by [DirectiveMapper.Original],
+// without duplicates.
+func OriginalLocations(ctx context.Context, snapshot *cache.Snapshot, locs []protocol.Location) []protocol.Location {
+	m := NewDirectiveMapper(ctx, snapshot)
+	seen := make(map[protocol.Location]bool)
+	result := make([]protocol.Location, 0, len(locs))
+	for _, loc := range locs {
+		loc, _ = m.Original(loc)
+		if !seen[loc] {
+			seen[loc] = true
+			result = append(result, loc)
+		}
+	}
+	return result
+}
+
+// parse returns the parsed Go file denoted by uri, or nil if it is not
+// a Go file or contains no line directives.
+func (m *DirectiveMapper) parse(uri protocol.DocumentURI) *parsego.File {
+	pgf, ok := m.files[uri]
+	if !ok {
+		pgf = parseDirectives(m.ctx, m.snapshot, uri)
+		m.files[uri] = pgf
+	}
+	return pgf
+}
+
+// target returns a mapper for the content of the original source file
+// denoted by uri, or nil if it cannot be read.
+func (m *DirectiveMapper) target(uri protocol.DocumentURI) *protocol.Mapper {
+	mapper, ok := m.targets[uri]
+	if !ok {
+		if fh, err := m.snapshot.ReadFile(m.ctx, uri); err == nil {
+			if content, err := fh.Content(); err == nil {
+				mapper = protocol.NewMapper(uri, content)
+			}
+		}
+		m.targets[uri] = mapper
+	}
+	return mapper
+}
+
+// parseDirectives returns the parsed Go file denoted by uri, or nil if
+// it is not a Go file, cannot be parsed, or contains no line directives.
+func parseDirectives(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI) *parsego.File {
+	if !strings.HasSuffix(string(uri), ".go") {
+		return nil
+	}
+	fh, err := snapshot.ReadFile(ctx, uri)
+	if err != nil {
+		return nil
+	}
+	content, err := fh.Content()
+	if err != nil || !hasLineDirective(content) {
+		return nil
+	}
+	// Line directives are recorded by the scanner, so the
+	// whole file must be parsed.
+	pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+	if err != nil {
+		return nil
+	}
+	return pgf
+}
+
+// hasLineDirective reports whether content may contain a line directive.
+func hasLineDirective(content []byte) bool {
+	return bytes.Contains(content, []byte("//line ")) || bytes.Contains(content, []byte("/*line "))
+}
+
+// GeneratedLocations returns the locations in the compiled Go files of
+// the workspace packages that line directives map to the line of the
+// given location in an original source file. Where the directive
+// specifies a column, the location is the point that corresponds to
+// the given position; otherwise it spans the generated line.
+func GeneratedLocations(ctx context.Context, snapshot *cache.Snapshot, loc protocol.Location) ([]protocol.Location, error) {
+	ctx, done := event.Start(ctx, "golang.GeneratedLocations")
+	defer done()
+
+	// Compute the UTF-8 column of the position in the original source.
+	fh, err := snapshot.ReadFile(ctx, loc.URI)
+	if err != nil {
+		return nil, err
+	}
+	content, err := fh.Content()
+	if err != nil {
+		return nil, err
+	}
+	mapper := protocol.NewMapper(loc.URI, content)
+	offset, err := mapper.PositionOffset(loc.Range.Start)
+	if err != nil {
+		return nil, err
+	}
+	line, col := mapper.OffsetLineCol8(offset)
+	filename := filepath.Clean(loc.URI.Path())
+
+	workspace, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[protocol.DocumentURI]bool)
+	var locs []protocol.Location
+	for _, mp := range workspace {
+		for _, uri := range mp.CompiledGoFiles {
+			if seen[uri] {
+				continue
+			}
+			seen[uri] = true
+
+			pgf := parseDirectives(ctx, snapshot, uri)
+			if pgf == nil {
+				continue
+			}
+			tok := pgf.Tok
+			for l := 1; l <= tok.LineCount(); l++ {
+				start := tok.LineStart(l)
+				posn := tok.PositionFor(start, true)
+				if posn.Filename != filename || posn.Line != line {
+					continue
+				}
+				startOffset := tok.Offset(start)
+				text := pgf.Src[startOffset:]
+				text = text[:lineLength(text)]
+				if trimmed := bytes.TrimSpace(text); bytes.HasPrefix(trimmed, []byte("//line ")) || bytes.HasPrefix(trimmed, []byte("/*line ")) {
+					continue // the directive itself
+				}
+				endOffset := startOffset + len(text)
+				if posn.Column > 0 && col >= posn.Column && col-posn.Column <= len(text) {
+					startOffset += col - posn.Column
+					endOffset = startOffset
+				}
+				loc, err := pgf.Mapper.OffsetLocation(startOffset, endOffset)
+				if err != nil {
+					return nil, err
+				}
+				locs = append(locs, loc)
+			}
+		}
+	}
+	return locs, nil
+}
+
+// lineColOffset returns the byte offset of the given 1-based line and
+// UTF-8 column; a zero column denotes the start of the line.
+func lineColOffset(mapper *protocol.Mapper, line, col int) (int, bool) {
+	if line < 1 {
+		return 0, false
+	}
+	if col < 1 {
+		col = 1
+	}
+	pos, err := mapper.LineCol8Position(line, col)
+	if err != nil {
+		return 0, false
+	}
+	offset, err := mapper.PositionOffset(pos)
+	if err != nil {
+		return 0, false
+	}
+	return offset, true
+}
+
+// lineLength returns the length of the first line of text, excluding
+// its newline.
+func lineLength(text []byte) int {
+	if i := bytes.IndexByte(text, '\n'); i >= 0 {
+		return i
+	}
+	return len(text)
+}
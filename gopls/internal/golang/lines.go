@@ -169,6 +169,11 @@ func findSplitJoinTarget(fset *token.FileSet, file *ast.File, src []byte, start,
 			case *ast.FuncDecl:
 				// target struct method declarations.
 				//   function (...) someMethod(a int, b int, c int) (d int, e, int) {}
+				tparams := node.Type.TypeParams
+				if tparams != nil && isCursorInside(tparams.Opening, tparams.Closing) {
+					return "type parameters", tparams, tparams.Opening, tparams.Closing
+				}
+
 				params := node.Type.Params
 				if isCursorInside(params.Opening, params.Closing) {
 					return "parameters", params, params.Opening, params.Closing
@@ -190,11 +195,18 @@ func findSplitJoinTarget(fset *token.FileSet, file *ast.File, src []byte, start,
 				if results != nil && isCursorInside(results.Opening, results.Closing) {
 					return "return values", results, results.Opening, results.Closing
 				}
+			case *ast.TypeSpec:
+				// target generic type declarations.
+				//   type someType[K comparable, V any] map[K]V
+				tparams := node.TypeParams
+				if tparams != nil && isCursorInside(tparams.Opening, tparams.Closing) {
+					return "type parameters", tparams, tparams.Opening, tparams.Closing
+				}
 			case *ast.CallExpr:
 				// target function calls.
 				//   someFunction(a, b, c)
 				if isCursorInside(node.Lparen, node.Rparen) {
-					return "parameters", node, node.Lparen, node.Rparen
+					return "arguments", node, node.Lparen, node.Rparen
 				}
 			case *ast.CompositeLit:
 				// target composite lit instantiation (structs, maps, arrays).
@@ -222,6 +234,10 @@ func findSplitJoinTarget(fset *token.FileSet, file *ast.File, src []byte, start,
 		for _, arg := range node.Args {
 			items = append(items, arg)
 		}
+		if node.Ellipsis.IsValid() && len(items) > 0 {
+			// The "..." of a variadic call follows the last argument.
+			items[len(items)-1] = variadicArg{node.Args[len(node.Args)-1], node.Ellipsis}
+		}
 	case *ast.CompositeLit:
 		for _, arg := range node.Elts {
 			items = append(items, arg)
@@ -260,3 +276,12 @@ func findSplitJoinTarget(fset *token.FileSet, file *ast.File, src []byte, start,
 
 	return targetType, items, comments, indent, open, close
 }
+
+// A variadicArg is the last argument of a call with an ellipsis,
+// which ends after the "...".
+type variadicArg struct {
+	ast.Expr
+	ellipsis token.Pos
+}
+
+func (arg variadicArg) End() token.Pos { return arg.ellipsis + token.Pos(len("...")) }
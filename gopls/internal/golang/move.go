@@ -0,0 +1,1121 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the "move declaration" refactoring, which moves a
+// top-level declaration to another file of the same package, or to
+// another package of the workspace.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/gopls/internal/util/slices"
+	"golang.org/x/tools/internal/diff"
+	"golang.org/x/tools/internal/imports"
+)
+
+// A movedDecl is a declaration being moved: either a whole top-level
+// declaration, or a single spec of a grouped type or var declaration.
+type movedDecl struct {
+	pkg        *cache.Package
+	pgf        *parsego.File
+	node       ast.Node    // *ast.FuncDecl, *ast.GenDecl, or (if tok is valid) an ast.Spec
+	tok        token.Token // for a spec, the keyword of its enclosing GenDecl
+	start, end int         // offsets of the text to delete from pgf; whole lines
+	test       bool        // a test or example named after a moved declaration
+}
+
+// MoveDestination describes a package to which a declaration may be
+// moved, and the file of that package that would receive it.
+type MoveDestination struct {
+	PkgPath PackagePath
+	File    protocol.DocumentURI
+}
+
+// MoveDeclaration returns the changes that move the top-level
+// declaration whose name is indicated by rng to the file dest,
+// along with, if moveTests, the tests, benchmarks and examples that
+// are named after it. The file dest is created if it does not exist.
+//
+// If dest is in the directory of the declaration, the move is within
+// its package. Otherwise dest must be in the directory of another
+// workspace package; the methods of a moved type move along with it,
+// and every reference to the declaration in the workspace is updated
+// to refer to its new package.
+func MoveDeclaration(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range, dest protocol.DocumentURI, moveTests bool) ([]protocol.DocumentChange, error) {
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	if perrors, terrors := pkg.ParseErrors(), pkg.TypeErrors(); len(perrors) > 0 || len(terrors) > 0 {
+		return nil, fmt.Errorf("cannot move declarations of package %s, which has errors", pkg.Types().Name())
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	d, err := movableDecl(pkg, pgf, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(dest.Path(), ".go") {
+		return nil, fmt.Errorf("destination %s is not a Go file", filepath.Base(dest.Path()))
+	}
+	if dest == pgf.URI {
+		return nil, fmt.Errorf("%s is already declared in %s", declName(d), filepath.Base(dest.Path()))
+	}
+	if isTestFile(dest) != isTestFile(pgf.URI) {
+		return nil, fmt.Errorf("cannot move a declaration between test and non-test files")
+	}
+
+	m := &mover{
+		snapshot: snapshot,
+		pkg:      pkg,
+		files:    make(map[protocol.DocumentURI]*fileChange),
+	}
+	if filepath.Dir(dest.Path()) == filepath.Dir(pgf.URI.Path()) {
+		m.dst = pkg
+	} else {
+		if m.dst, err = packageInDir(ctx, snapshot, filepath.Dir(dest.Path())); err != nil {
+			return nil, err
+		}
+		if d.tok == token.ILLEGAL {
+			if fn, ok := d.node.(*ast.FuncDecl); ok && fn.Recv != nil {
+				return nil, fmt.Errorf("cannot move method %s to another package; move its receiver type instead", fn.Name.Name)
+			}
+		}
+	}
+	m.decls = append(m.decls, d)
+	if m.crossPackage() {
+		for _, obj := range d.objects() {
+			if tname, ok := obj.(*types.TypeName); ok {
+				m.decls = append(m.decls, methodsOf(pkg, tname)...)
+			}
+		}
+	}
+	destTest := strings.TrimSuffix(dest.Path(), ".go") + "_test.go"
+	if moveTests && !isTestFile(pgf.URI) {
+		tests, err := m.testsOf(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		m.decls = append(m.decls, slices.DeleteFunc(tests, func(t *movedDecl) bool {
+			return t.pgf.URI.Path() == destTest // already in the right file
+		})...)
+	}
+
+	m.names = make(map[string]bool)
+	for _, d := range m.decls {
+		for _, obj := range d.objects() {
+			m.names[obj.Name()] = true
+		}
+	}
+	if m.crossPackage() {
+		if err := m.checkCrossPackage(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Insert the moved text, sans references to the destination package.
+	var (
+		mainText strings.Builder
+		testText strings.Builder
+	)
+	mainImports := make(map[string]*types.PkgName) // keyed by path
+	testImports := make(map[string]*types.PkgName)
+	for _, d := range m.decls {
+		text, err := m.text(d)
+		if err != nil {
+			return nil, err
+		}
+		buf, imps := &mainText, mainImports
+		if d.test {
+			buf, imps = &testText, testImports
+		}
+		buf.WriteString("\n")
+		buf.WriteString(text)
+		if err := m.importsOf(d, imps); err != nil {
+			return nil, err
+		}
+		m.file(d.pgf).edits = append(m.file(d.pgf).edits, diff.Edit{Start: d.start, End: d.end})
+	}
+	if err := m.insert(ctx, dest, mainText.String(), mainImports); err != nil {
+		return nil, err
+	}
+	if testText.Len() > 0 {
+		if err := m.insert(ctx, protocol.URIFromPath(destTest), testText.String(), testImports); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.crossPackage() {
+		if err := m.updateReferences(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for _, d := range m.decls {
+		if err := m.removeUnusedImports(d.pkg, d.pgf); err != nil {
+			return nil, err
+		}
+	}
+	return m.changes(ctx)
+}
+
+// MoveDestinations returns the workspace packages to which the
+// top-level declaration at rng in the specified file could be moved,
+// ordered by package path.
+//
+// The candidates are the other packages of the same module that do
+// not import the declaring package, directly or indirectly. Each
+// candidate's file is the one with the same name as the declaring
+// file.
+func MoveDestinations(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI) ([]MoveDestination, error) {
+	mp, err := NarrowestMetadataForFile(ctx, snapshot, uri)
+	if err != nil {
+		return nil, err
+	}
+	mps, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var dests []MoveDestination
+	for _, other := range mps {
+		if other.ForTest != "" || other.Name == "main" || other.PkgPath == mp.PkgPath ||
+			len(other.CompiledGoFiles) == 0 || metadata.IsCommandLineArguments(other.ID) {
+			continue
+		}
+		if mp.Module == nil || other.Module == nil || mp.Module.Path != other.Module.Path {
+			continue
+		}
+		if dependsOn(snapshot, other, mp.PkgPath) {
+			continue
+		}
+		dir := filepath.Dir(other.CompiledGoFiles[0].Path())
+		dests = append(dests, MoveDestination{
+			PkgPath: other.PkgPath,
+			File:    protocol.URIFromPath(filepath.Join(dir, filepath.Base(uri.Path()))),
+		})
+	}
+	sort.Slice(dests, func(i, j int) bool { return dests[i].PkgPath < dests[j].PkgPath })
+	return dests, nil
+}
+
+// A mover holds the state of a "move declaration" operation.
+type mover struct {
+	snapshot *cache.Snapshot
+	pkg      *cache.Package // package of the declaration
+	dst      *cache.Package // destination package; == pkg for a move within a package
+	decls    []*movedDecl
+	names    map[string]bool // names of the moved package-level objects
+	files    map[protocol.DocumentURI]*fileChange
+}
+
+// A fileChange accumulates the changes to a single file.
+// If pgf is nil, the file is created with the given content.
+type fileChange struct {
+	pgf      *parsego.File
+	edits    []diff.Edit
+	fixes    []*imports.ImportFix
+	content  string
+	needsDst bool // the file refers to the destination package
+	cleaned  bool // unused imports have been removed
+}
+
+func (m *mover) crossPackage() bool { return m.dst != m.pkg }
+
+// file returns the accumulated changes to the specified file.
+func (m *mover) file(pgf *parsego.File) *fileChange {
+	fc, ok := m.files[pgf.URI]
+	if !ok {
+		fc = &fileChange{pgf: pgf}
+		m.files[pgf.URI] = fc
+	}
+	return fc
+}
+
+// moved reports whether pos lies within the text of a moved declaration.
+func (m *mover) moved(fset *token.FileSet, pos token.Pos) bool {
+	posn := safetoken.StartPosition(fset, pos)
+	for _, d := range m.decls {
+		if posn.Filename == d.pgf.Tok.Name() && d.start <= posn.Offset && posn.Offset < d.end {
+			return true
+		}
+	}
+	return false
+}
+
+// movableDecl returns the top-level declaration whose name encloses
+// the range [start, end).
+func movableDecl(pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*movedDecl, error) {
+	within := func(id *ast.Ident) bool {
+		return id.Pos() <= start && end <= id.End() && id.Name != "_"
+	}
+	for _, decl := range pgf.File.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if within(decl.Name) {
+				return newMovedDecl(pkg, pgf, decl, token.ILLEGAL)
+			}
+		case *ast.GenDecl:
+			if decl.Tok == token.IMPORT {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				var names []*ast.Ident
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names = []*ast.Ident{spec.Name}
+				case *ast.ValueSpec:
+					names = spec.Names
+				}
+				for _, name := range names {
+					if !within(name) {
+						continue
+					}
+					if len(decl.Specs) == 1 {
+						return newMovedDecl(pkg, pgf, decl, token.ILLEGAL)
+					}
+					if decl.Tok == token.CONST {
+						return nil, fmt.Errorf("%s belongs to a grouped constant declaration", name.Name)
+					}
+					return newMovedDecl(pkg, pgf, spec, decl.Tok)
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("no top-level declaration is named at the selection")
+}
+
+// newMovedDecl returns the movedDecl for a top-level declaration,
+// or a spec of a grouped declaration, whose text must occupy whole
+// lines. The text includes the doc comment and trailing comment, and
+// a blank line on one side.
+func newMovedDecl(pkg *cache.Package, pgf *parsego.File, node ast.Node, tok token.Token) (*movedDecl, error) {
+	pos := node.Pos()
+	var doc *ast.CommentGroup
+	switch node := node.(type) {
+	case *ast.FuncDecl:
+		doc = node.Doc
+	case *ast.GenDecl:
+		doc = node.Doc
+	case *ast.TypeSpec:
+		doc = node.Doc
+	case *ast.ValueSpec:
+		doc = node.Doc
+	}
+	if doc != nil {
+		pos = doc.Pos()
+	}
+	start, end, err := safetoken.Offsets(pgf.Tok, pos, node.End())
+	if err != nil {
+		return nil, err
+	}
+	src := pgf.Src
+	for start > 0 && (src[start-1] == ' ' || src[start-1] == '\t') {
+		start--
+	}
+	if start > 0 && src[start-1] != '\n' {
+		return nil, fmt.Errorf("declaration does not begin a line")
+	}
+	if i := strings.IndexByte(string(src[end:]), '\n'); i >= 0 {
+		rest := strings.TrimSpace(string(src[end : end+i]))
+		if rest != "" && !strings.HasPrefix(rest, "//") {
+			return nil, fmt.Errorf("declaration does not end a line")
+		}
+		end += i + 1
+	} else {
+		end = len(src)
+	}
+	// Absorb an adjacent blank line, preferably the following one.
+	if blank := blankLineAt(src, end); blank > 0 {
+		end += blank
+	} else if start > 0 {
+		prev := strings.LastIndexByte(string(src[:start-1]), '\n') + 1
+		if blankLineAt(src, prev) > 0 {
+			start = prev
+		}
+	}
+	return &movedDecl{
+		pkg:   pkg,
+		pgf:   pgf,
+		node:  node,
+		tok:   tok,
+		start: start,
+		end:   end,
+	}, nil
+}
+
+// blankLineAt returns the length of the blank line at offset in src,
+// including its newline, or zero if there is none.
+func blankLineAt(src []byte, offset int) int {
+	i := strings.IndexByte(string(src[offset:]), '\n')
+	if i < 0 || strings.TrimSpace(string(src[offset:offset+i])) != "" {
+		return 0
+	}
+	return i + 1
+}
+
+// objects returns the package-level objects declared by d.
+func (d *movedDecl) objects() []types.Object {
+	var ids []*ast.Ident
+	switch node := d.node.(type) {
+	case *ast.FuncDecl:
+		if node.Recv == nil {
+			ids = append(ids, node.Name)
+		}
+	case *ast.GenDecl:
+		for _, spec := range node.Specs {
+			ids = append(ids, specNames(spec)...)
+		}
+	case ast.Spec:
+		ids = specNames(node)
+	}
+	var objs []types.Object
+	for _, id := range ids {
+		if obj := d.pkg.TypesInfo().Defs[id]; obj != nil && id.Name != "_" {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+func specNames(spec ast.Spec) []*ast.Ident {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return []*ast.Ident{spec.Name}
+	case *ast.ValueSpec:
+		return spec.Names
+	}
+	return nil
+}
+
+// declName returns the name of the (first) object declared by d.
+func declName(d *movedDecl) string {
+	if fn, ok := d.node.(*ast.FuncDecl); ok {
+		return fn.Name.Name
+	}
+	if objs := d.objects(); len(objs) > 0 {
+		return objs[0].Name()
+	}
+	return "declaration"
+}
+
+// methodsOf returns the method declarations of the named type in the
+// non-test files of pkg.
+func methodsOf(pkg *cache.Package, tname *types.TypeName) []*movedDecl {
+	var methods []*movedDecl
+	for _, pgf := range pkg.CompiledGoFiles() {
+		if isTestFile(pgf.URI) {
+			continue
+		}
+		for _, decl := range pgf.File.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+				continue
+			}
+			if recvTypeName(pkg.TypesInfo(), fn.Recv.List[0].Type) != tname {
+				continue
+			}
+			if d, err := newMovedDecl(pkg, pgf, fn, token.ILLEGAL); err == nil {
+				methods = append(methods, d)
+			}
+		}
+	}
+	return methods
+}
+
+// recvTypeName returns the named type of a method receiver expression.
+func recvTypeName(info *types.Info, expr ast.Expr) types.Object {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return info.Uses[e]
+		default:
+			return nil
+		}
+	}
+}
+
+// testsOf returns the tests, benchmarks, fuzz targets and examples in
+// the in-package test files of the declaring package whose names
+// refer to an object declared by d, such as TestF, TestF_Empty, or
+// ExampleT_Method. Tests that also refer to other declarations of the
+// package cannot be moved to another package, and are left in place.
+func (m *mover) testsOf(ctx context.Context, d *movedDecl) ([]*movedDecl, error) {
+	var names []string
+	for _, obj := range d.objects() {
+		names = append(names, obj.Name())
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	mps, err := m.snapshot.MetadataForFile(ctx, d.pgf.URI)
+	if err != nil {
+		return nil, err
+	}
+	var testID PackageID
+	for _, mp := range mps {
+		if mp.ForTest == m.pkg.Metadata().PkgPath && mp.PkgPath == m.pkg.Metadata().PkgPath {
+			testID = mp.ID
+		}
+	}
+	if testID == "" {
+		return nil, nil // no in-package tests
+	}
+	pkgs, err := m.snapshot.TypeCheck(ctx, testID)
+	if err != nil {
+		return nil, err
+	}
+	tpkg := pkgs[0]
+
+	var tests []*movedDecl
+	for _, pgf := range tpkg.CompiledGoFiles() {
+		if !isTestFile(pgf.URI) {
+			continue
+		}
+		for _, decl := range pgf.File.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !isTestOf(fn.Name.Name, names) {
+				continue
+			}
+			t, err := newMovedDecl(tpkg, pgf, fn, token.ILLEGAL)
+			if err != nil {
+				continue
+			}
+			t.test = true
+			tests = append(tests, t)
+		}
+	}
+	if m.crossPackage() {
+		// Keep only the tests that can move along with d.
+		saved := m.decls
+		m.decls = append(append([]*movedDecl(nil), m.decls...), tests...)
+		tests = slices.DeleteFunc(tests, func(t *movedDecl) bool {
+			return m.checkFreeRefs(t) != nil
+		})
+		m.decls = saved
+	}
+	return tests, nil
+}
+
+// isTestOf reports whether name is the name of a test, benchmark, fuzz
+// target or example for one of the specified declarations.
+func isTestOf(name string, decls []string) bool {
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(strings.TrimPrefix(name, prefix), "_")
+		for _, decl := range decls {
+			if strings.HasPrefix(rest, decl) {
+				if after := rest[len(decl):]; after == "" || after[0] == '_' {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// checkCrossPackage reports an error if the declarations cannot be
+// moved to another package.
+func (m *mover) checkCrossPackage() error {
+	dstName := m.dst.Types().Name()
+	for _, d := range m.decls {
+		if err := m.checkFreeRefs(d); err != nil {
+			return err
+		}
+		for _, obj := range d.objects() {
+			if obj.Name() == "init" || obj.Name() == "main" {
+				return fmt.Errorf("cannot move function %s to another package", obj.Name())
+			}
+			if m.dst.Types().Scope().Lookup(obj.Name()) != nil {
+				return fmt.Errorf("package %s already declares %s", dstName, obj.Name())
+			}
+		}
+	}
+	return nil
+}
+
+// checkFreeRefs reports an error if d refers to a declaration of its
+// package that is not being moved.
+func (m *mover) checkFreeRefs(d *movedDecl) error {
+	var err error
+	ast.Inspect(d.node, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || err != nil {
+			return err == nil
+		}
+		obj := d.pkg.TypesInfo().Uses[id]
+		if obj == nil || obj.Pkg() != d.pkg.Types() || m.moved(d.pkg.FileSet(), obj.Pos()) {
+			return true
+		}
+		if obj.Parent() == obj.Pkg().Scope() {
+			err = fmt.Errorf("%s refers to %s, which would remain in package %s", declName(d), obj.Name(), obj.Pkg().Name())
+		} else if !obj.Exported() {
+			if _, ok := obj.(*types.Var); ok || isMethod(obj) {
+				err = fmt.Errorf("%s refers to unexported %s of package %s", declName(d), obj.Name(), obj.Pkg().Name())
+			}
+		}
+		return true
+	})
+	return err
+}
+
+func isMethod(obj types.Object) bool {
+	fn, ok := obj.(*types.Func)
+	return ok && fn.Type().(*types.Signature).Recv() != nil
+}
+
+// text returns the text of d as it appears in its new location.
+// References to the destination package lose their qualifier.
+func (m *mover) text(d *movedDecl) (string, error) {
+	var edits []diff.Edit
+	if m.crossPackage() {
+		var err error
+		ast.Inspect(d.node, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok || err != nil {
+				return err == nil
+			}
+			if pkgName := importedPkgName(d.pkg.TypesInfo(), sel.X); pkgName != nil && pkgName.Imported().Path() == m.dst.Types().Path() {
+				var start, end int
+				start, end, err = safetoken.Offsets(d.pgf.Tok, sel.X.Pos(), sel.Sel.Pos())
+				edits = append(edits, diff.Edit{Start: start - d.start, End: end - d.start})
+			}
+			return true
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	text, err := diff.Apply(string(d.pgf.Src[d.start:d.end]), edits)
+	if err != nil {
+		return "", err
+	}
+	if d.tok != token.ILLEGAL {
+		// Turn the spec of a grouped declaration into a declaration,
+		// placing the keyword after its doc comment.
+		split, err := safetoken.Offset(d.pgf.Tok, d.node.Pos())
+		if err != nil {
+			return "", err
+		}
+		split -= d.start
+		text = dedent(text[:split]) + d.tok.String() + " " + dedent(text[split:])
+	}
+	return strings.Trim(text, "\n") + "\n", nil
+}
+
+// dedent removes one level of indentation from each line of text.
+func dedent(text string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(text, "\t"), "\n\t", "\n")
+}
+
+func importedPkgName(info *types.Info, expr ast.Expr) *types.PkgName {
+	id, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	pkgName, _ := info.Uses[id].(*types.PkgName)
+	return pkgName
+}
+
+// importsOf adds to imps the imports needed by the text of d at its
+// new location, keyed by path.
+func (m *mover) importsOf(d *movedDecl, imps map[string]*types.PkgName) error {
+	var err error
+	ast.Inspect(d.node, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || err != nil {
+			return err == nil
+		}
+		pkgName, ok := d.pkg.TypesInfo().Uses[id].(*types.PkgName)
+		if !ok {
+			return true
+		}
+		path := pkgName.Imported().Path()
+		if m.crossPackage() && path == m.dst.Types().Path() {
+			return true
+		}
+		if prev, ok := imps[path]; ok && prev.Name() != pkgName.Name() {
+			err = fmt.Errorf("package %s is imported as both %s and %s", path, prev.Name(), pkgName.Name())
+		}
+		imps[path] = pkgName
+		return true
+	})
+	return err
+}
+
+// explicitName returns the name of an import spec for pkgName,
+// or "" if the package's own name suffices.
+func explicitName(pkgName *types.PkgName) string {
+	if pkgName.Name() != pkgName.Imported().Name() {
+		return pkgName.Name()
+	}
+	return ""
+}
+
+// insert arranges to append text, which needs the specified imports,
+// to the file dest, creating it if necessary.
+func (m *mover) insert(ctx context.Context, dest protocol.DocumentURI, text string, imps map[string]*types.PkgName) error {
+	paths := make([]string, 0, len(imps))
+	for path := range imps {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	fh, err := m.snapshot.ReadFile(ctx, dest)
+	if err != nil {
+		return err
+	}
+	if _, err := fh.Content(); err != nil {
+		// dest does not exist: create it.
+		var buf strings.Builder
+		buf.WriteString(m.header())
+		fmt.Fprintf(&buf, "package %s\n", m.dst.Types().Name())
+		if len(paths) > 0 {
+			buf.WriteString("\nimport (\n")
+			for _, path := range paths {
+				if name := explicitName(imps[path]); name != "" {
+					fmt.Fprintf(&buf, "\t%s %q\n", name, path)
+				} else {
+					fmt.Fprintf(&buf, "\t%q\n", path)
+				}
+			}
+			buf.WriteString(")\n")
+		}
+		m.files[dest] = &fileChange{content: buf.String() + text}
+		return nil
+	}
+
+	pgf, err := m.snapshot.ParseGo(ctx, fh, parsego.Full)
+	if err != nil {
+		return err
+	}
+	if pgf.File.Name.Name != m.dst.Types().Name() {
+		return fmt.Errorf("%s belongs to package %s, not %s", filepath.Base(dest.Path()), pgf.File.Name.Name, m.dst.Types().Name())
+	}
+	fc := m.file(pgf)
+
+	// Add any missing imports, rejecting conflicting ones.
+	existing := make(map[string]string) // local name -> path
+	for _, spec := range pgf.File.Imports {
+		path := strings.Trim(spec.Path.Value, `"`)
+		name := importName(m.snapshot, m.dst, path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		existing[name] = path
+	}
+	for _, path := range paths {
+		name := imps[path].Name()
+		if other, ok := existing[name]; ok {
+			if other != path {
+				return fmt.Errorf("%s already imports %s as %s", filepath.Base(dest.Path()), other, name)
+			}
+			continue
+		}
+		if m.dst.Types().Scope().Lookup(name) != nil {
+			return fmt.Errorf("cannot import %s as %s: package %s already declares %s", path, name, m.dst.Types().Name(), name)
+		}
+		fc.fixes = append(fc.fixes, &imports.ImportFix{
+			StmtInfo:  imports.ImportInfo{ImportPath: path, Name: explicitName(imps[path])},
+			IdentName: name,
+			FixType:   imports.AddImport,
+		})
+	}
+
+	src := pgf.Src
+	if len(src) > 0 && src[len(src)-1] != '\n' {
+		text = "\n" + text
+	}
+	fc.edits = append(fc.edits, diff.Edit{Start: len(src), End: len(src), New: text})
+	return nil
+}
+
+// importName returns the name of the package with the specified path,
+// as seen from pkg, or the last segment of the path if it is unknown.
+func importName(snapshot *cache.Snapshot, pkg *cache.Package, path string) string {
+	if id, ok := pkg.Metadata().DepsByPkgPath[PackagePath(path)]; ok {
+		if mp := snapshot.Metadata(id); mp != nil {
+			return string(mp.Name)
+		}
+	}
+	if path == pkg.Types().Path() {
+		return pkg.Types().Name()
+	}
+	for _, imp := range pkg.Types().Imports() {
+		if imp.Path() == path {
+			return imp.Name()
+		}
+	}
+	return filepath.Base(path)
+}
+
+// header returns the comments that precede the package clause of the
+// declaring file, other than the package doc comment, such as a
+// copyright notice or build constraints.
+func (m *mover) header() string {
+	pgf := m.decls[0].pgf
+	var buf strings.Builder
+	for _, cg := range pgf.File.Comments {
+		if cg.Pos() >= pgf.File.Package {
+			break
+		}
+		if cg == pgf.File.Doc {
+			continue
+		}
+		start, end, err := safetoken.Offsets(pgf.Tok, cg.Pos(), cg.End())
+		if err != nil {
+			continue
+		}
+		buf.Write(pgf.Src[start:end])
+		buf.WriteString("\n\n")
+	}
+	return buf.String()
+}
+
+// updateReferences qualifies or re-qualifies the references to the
+// moved declarations throughout the workspace so that they refer to
+// the destination package.
+func (m *mover) updateReferences(ctx context.Context) error {
+	pkgs, err := typeCheckReverseDependencies(ctx, m.snapshot, m.decls[0].pgf.URI, false)
+	if err != nil {
+		return err
+	}
+	srcPath := m.pkg.Types().Path()
+	dstPath := m.dst.Types().Path()
+
+	seen := make(map[protocol.DocumentURI]bool)
+	for _, pkg := range pkgs {
+		var srcTypes *types.Package
+		if pkg.Types().Path() == srcPath {
+			srcTypes = pkg.Types()
+		} else {
+			for _, imp := range pkg.Types().Imports() {
+				if imp.Path() == srcPath {
+					srcTypes = imp
+				}
+			}
+		}
+		if srcTypes == nil {
+			continue
+		}
+		objs := make(map[types.Object]bool)
+		for name := range m.names {
+			if obj := srcTypes.Scope().Lookup(name); obj != nil {
+				objs[obj] = true
+			}
+		}
+		inSrc := pkg.Types().Path() == srcPath
+		inDst := pkg.Types().Path() == dstPath
+
+		for _, pgf := range pkg.CompiledGoFiles() {
+			if seen[pgf.URI] {
+				continue
+			}
+			seen[pgf.URI] = true
+			if err := m.updateFileReferences(pkg, pgf, objs, inSrc, inDst); err != nil {
+				return err
+			}
+			if err := m.removeUnusedImports(pkg, pgf); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// updateFileReferences updates the references in a single file.
+func (m *mover) updateFileReferences(pkg *cache.Package, pgf *parsego.File, objs map[types.Object]bool, inSrc, inDst bool) error {
+	info := pkg.TypesInfo()
+	dstPath := m.dst.Types().Path()
+
+	// Find the name by which the file refers to the destination package.
+	dstName := m.dst.Types().Name()
+	for _, spec := range pgf.File.Imports {
+		if strings.Trim(spec.Path.Value, `"`) == dstPath && spec.Name != nil {
+			dstName = spec.Name.Name
+		}
+	}
+
+	var (
+		edits     []diff.Edit
+		needsDst  bool
+		err       error
+		qualified = make(map[*ast.Ident]bool) // selectors of references in other packages
+	)
+	edit := func(start, end token.Pos, new string) {
+		if err != nil {
+			return
+		}
+		var e diff.Edit
+		e, err = posEdit(pgf.Tok, start, end, new)
+		edits = append(edits, e)
+	}
+	// requalify replaces the qualifier (if any) of a reference at id.
+	requalify := func(id *ast.Ident, start token.Pos) {
+		if inDst {
+			edit(start, id.Pos(), "") // now declared in the same package
+			return
+		}
+		scope := pkg.Types().Scope().Innermost(id.Pos())
+		if scope != nil {
+			if _, obj := scope.LookupParent(dstName, id.Pos()); obj != nil {
+				if pkgName, ok := obj.(*types.PkgName); !ok || pkgName.Imported().Path() != dstPath {
+					err = fmt.Errorf("cannot refer to package %s as %s in %s: the name is already in use", dstPath, dstName, filepath.Base(pgf.URI.Path()))
+					return
+				}
+			}
+		}
+		needsDst = true
+		edit(start, id.Pos(), dstName+".")
+	}
+
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		if n == nil || err != nil {
+			return err == nil
+		}
+		if m.moved(pkg.FileSet(), n.Pos()) {
+			return false // moved along with the declarations
+		}
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if pkgName := importedPkgName(info, n.X); pkgName != nil && objs[info.Uses[n.Sel]] {
+				qualified[n.Sel] = true
+				requalify(n.Sel, n.X.Pos())
+			}
+		case *ast.Ident:
+			obj := info.Uses[n]
+			if obj == nil {
+				break
+			}
+			if inSrc && m.moved(pkg.FileSet(), obj.Pos()) && !obj.Exported() {
+				err = fmt.Errorf("cannot move %s: package %s uses its unexported %s", declName(m.decls[0]), pkg.Types().Name(), obj.Name())
+			} else if objs[obj] && !qualified[n] {
+				requalify(n, n.Pos())
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if len(edits) == 0 {
+		return nil
+	}
+
+	if needsDst {
+		if m.dst.Types().Name() == "main" {
+			return fmt.Errorf("cannot move to package main: it is used by package %s", pkg.Types().Name())
+		}
+		if dependsOn(m.snapshot, m.dst.Metadata(), pkg.Metadata().PkgPath) {
+			return fmt.Errorf("cannot move to package %s: it would create an import cycle with package %s", m.dst.Types().Name(), pkg.Types().Name())
+		}
+	}
+	fc := m.file(pgf)
+	fc.edits = append(fc.edits, edits...)
+	fc.needsDst = fc.needsDst || needsDst
+	if needsDst {
+		imported := false
+		for _, spec := range pgf.File.Imports {
+			if strings.Trim(spec.Path.Value, `"`) == dstPath {
+				imported = true
+			}
+		}
+		if !imported {
+			fc.fixes = append(fc.fixes, &imports.ImportFix{
+				StmtInfo:  imports.ImportInfo{ImportPath: dstPath},
+				IdentName: dstName,
+				FixType:   imports.AddImport,
+			})
+		}
+	}
+	return nil
+}
+
+// removeUnusedImports arranges to delete the imports of the file
+// that are no longer used once the moved declarations are removed
+// and the references are updated.
+func (m *mover) removeUnusedImports(pkg *cache.Package, pgf *parsego.File) error {
+	fc, ok := m.files[pgf.URI]
+	if !ok || fc.pgf == nil || fc.cleaned {
+		return nil
+	}
+	fc.cleaned = true
+	info := pkg.TypesInfo()
+	srcPath := m.pkg.Types().Path()
+
+	// Find the imports still in use.
+	used := make(map[*types.PkgName]bool)
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		if n == nil || m.moved(pkg.FileSet(), n.Pos()) {
+			return false
+		}
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if pkgName := importedPkgName(info, sel.X); pkgName != nil {
+				if m.crossPackage() && pkgName.Imported().Path() == srcPath {
+					// References to moved declarations are requalified.
+					if obj := info.Uses[sel.Sel]; obj != nil && obj.Parent() == obj.Pkg().Scope() && m.names[obj.Name()] {
+						return false
+					}
+				}
+				used[pkgName] = true
+				return false
+			}
+		}
+		if id, ok := n.(*ast.Ident); ok {
+			if pkgName, ok := info.Uses[id].(*types.PkgName); ok {
+				used[pkgName] = true
+			}
+		}
+		return true
+	})
+
+	for _, spec := range pgf.File.Imports {
+		var obj types.Object
+		if spec.Name != nil {
+			obj = info.Defs[spec.Name]
+		} else {
+			obj = info.Implicits[spec]
+		}
+		pkgName, ok := obj.(*types.PkgName)
+		if !ok || pkgName.Name() == "_" || pkgName.Name() == "." || used[pkgName] {
+			continue
+		}
+		if fc.needsDst && pkgName.Imported().Path() == m.dst.Types().Path() {
+			continue // still needed by requalified references
+		}
+		name := ""
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		fc.fixes = append(fc.fixes, &imports.ImportFix{
+			StmtInfo:  imports.ImportInfo{ImportPath: pkgName.Imported().Path(), Name: name},
+			IdentName: pkgName.Name(),
+			FixType:   imports.DeleteImport,
+		})
+	}
+	return nil
+}
+
+// changes returns the document changes for all the modified files.
+func (m *mover) changes(ctx context.Context) ([]protocol.DocumentChange, error) {
+	uris := make([]protocol.DocumentURI, 0, len(m.files))
+	for uri := range m.files {
+		uris = append(uris, uri)
+	}
+	sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+
+	var changes []protocol.DocumentChange
+	for _, uri := range uris {
+		fc := m.files[uri]
+		fh, err := m.snapshot.ReadFile(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		if fc.pgf == nil {
+			content, err := format.Source([]byte(fc.content))
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes,
+				protocol.DocumentChangeCreate(uri),
+				protocol.DocumentChangeEdit(fh, []protocol.TextEdit{{NewText: string(content)}}))
+			continue
+		}
+		edits := fc.edits
+		if len(fc.fixes) > 0 {
+			textedits, err := ComputeImportFixEdits(m.snapshot, fc.pgf, fc.fixes...)
+			if err != nil {
+				return nil, err
+			}
+			importEdits, err := protocol.EditsToDiffEdits(fc.pgf.Mapper, textedits)
+			if err != nil {
+				return nil, err
+			}
+			edits = append(edits, importEdits...)
+		}
+		diff.SortEdits(edits)
+		textedits, err := protocol.EditsFromDiffEdits(fc.pgf.Mapper, edits)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, protocol.DocumentChangeEdit(fh, textedits))
+	}
+	return changes, nil
+}
+
+// packageInDir returns the type-checked workspace package whose
+// non-test files are in the specified directory.
+func packageInDir(ctx context.Context, snapshot *cache.Snapshot, dir string) (*cache.Package, error) {
+	mps, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, mp := range mps {
+		if mp.ForTest != "" || len(mp.CompiledGoFiles) == 0 || metadata.IsCommandLineArguments(mp.ID) {
+			continue
+		}
+		if filepath.Dir(mp.CompiledGoFiles[0].Path()) == dir {
+			pkgs, err := snapshot.TypeCheck(ctx, mp.ID)
+			if err != nil {
+				return nil, err
+			}
+			if perrors, terrors := pkgs[0].ParseErrors(), pkgs[0].TypeErrors(); len(perrors) > 0 || len(terrors) > 0 {
+				return nil, fmt.Errorf("cannot move declarations to package %s, which has errors", mp.Name)
+			}
+			return pkgs[0], nil
+		}
+	}
+	return nil, fmt.Errorf("no workspace package in directory %s", dir)
+}
+
+// dependsOn reports whether the package mp imports the package with
+// the specified path, directly or indirectly.
+func dependsOn(snapshot *cache.Snapshot, mp *metadata.Package, path PackagePath) bool {
+	seen := make(map[PackageID]bool)
+	var visit func(mp *metadata.Package) bool
+	visit = func(mp *metadata.Package) bool {
+		for _, id := range mp.DepsByPkgPath {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if dep := snapshot.Metadata(id); dep != nil && (dep.PkgPath == path || visit(dep)) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(mp)
+}
+
+func isTestFile(uri protocol.DocumentURI) bool {
+	return strings.HasSuffix(uri.Path(), "_test.go")
+}
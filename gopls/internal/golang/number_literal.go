@@ -0,0 +1,289 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/bug"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/gopls/internal/util/typesutil"
+	"golang.org/x/tools/internal/aliases"
+	"golang.org/x/tools/internal/diff"
+	"golang.org/x/tools/internal/versions"
+)
+
+// numberLiteralCodeActions returns the code actions that rewrite the
+// integer literal or the bitwise-or of constants enclosing [start, end):
+//   - conversions of an integer literal to decimal, hexadecimal, binary,
+//     or octal notation;
+//   - replacement of an integer literal of a named type by the
+//     bitwise-or of the flag constants of that type whose bits it sets,
+//     for example 0x5 by FlagA|FlagC; and
+//   - the converse replacement of a bitwise-or of constants by a
+//     hexadecimal literal.
+func numberLiteralCodeActions(pkg *cache.Package, pgf *parsego.File, fh file.Handle, start, end token.Pos) []protocol.CodeAction {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	if len(path) == 0 {
+		return nil
+	}
+	info := pkg.TypesInfo()
+	qual := typesutil.FileQualifier(pgf.File, pkg.Types(), info)
+
+	var actions []protocol.CodeAction
+	add := func(title string, n ast.Node, newText string) {
+		start, end, err := safetoken.Offsets(pgf.Tok, n.Pos(), n.End())
+		if err != nil {
+			bug.Reportf("failed to get literal offset by token.Pos:%v", err)
+			return
+		}
+		edits := []diff.Edit{{Start: start, End: end, New: newText}}
+		textedits, err := protocol.EditsFromDiffEdits(pgf.Mapper, edits)
+		if err != nil {
+			bug.Reportf("failed to convert diff.Edit to protocol.TextEdit:%v", err)
+			return
+		}
+		actions = append(actions, protocol.CodeAction{
+			Title: title,
+			Kind:  protocol.RefactorRewrite,
+			Edit:  protocol.NewWorkspaceEdit(protocol.DocumentChangeEdit(fh, textedits)),
+		})
+	}
+
+	if lit, ok := path[0].(*ast.BasicLit); ok && lit.Kind == token.INT {
+		v, err := strconv.ParseUint(lit.Value, 0, 64)
+		if err != nil {
+			return nil // too large
+		}
+
+		// Binary and 0o-prefixed octal literals require go1.13.
+		// (The notations of 0 and 1 differ only by prefix.)
+		modern := versions.AtLeast(versions.FileVersion(info, pgf.File), "go1.13")
+		for _, b := range []struct {
+			name, prefix string
+			base         int
+		}{
+			{"decimal", "", 10},
+			{"hexadecimal", "0x", 16},
+			{"binary", "0b", 2},
+			{"octal", "0o", 8},
+		} {
+			if v < 2 || b.base == literalBase(lit.Value) {
+				continue
+			}
+			prefix := b.prefix
+			if !modern {
+				switch b.base {
+				case 2:
+					continue
+				case 8:
+					prefix = "0"
+				}
+			}
+			text := prefix + strconv.FormatUint(v, b.base)
+			add(fmt.Sprintf("Convert to %s literal (%s)", b.name, text), lit, text)
+		}
+
+		if flags := flagExpression(pgf.File, pkg.Types(), info, lit, v, qual); flags != "" {
+			add("Replace with "+flags, lit, flags)
+		}
+	}
+
+	if expr, v := enclosingFlagExpression(info, path); expr != nil {
+		text := "0x" + strconv.FormatUint(v, 16)
+		if named, ok := aliases.Unalias(info.TypeOf(expr)).(*types.Named); ok && needsConversion(info, path, expr) {
+			text = types.TypeString(named, qual) + "(" + text + ")"
+		}
+		add("Replace with "+text, expr, text)
+	}
+
+	return actions
+}
+
+// literalBase returns the base of the integer literal lit.
+func literalBase(lit string) int {
+	if len(lit) < 2 || lit[0] != '0' {
+		return 10
+	}
+	switch lit[1] {
+	case 'x', 'X':
+		return 16
+	case 'b', 'B':
+		return 2
+	default: // 0o or legacy 0 prefix
+		return 8
+	}
+}
+
+// flagExpression returns the bitwise-or of the flag constants that
+// sets the bits of value v of the literal lit, or "" if there is no
+// such expression. The flag constants are the package-level constants
+// of the literal's named type that have a single bit set.
+func flagExpression(file *ast.File, pkg *types.Package, info *types.Info, lit *ast.BasicLit, v uint64, qual types.Qualifier) string {
+	named, ok := aliases.Unalias(info.TypeOf(lit)).(*types.Named)
+	if !ok || v == 0 {
+		return ""
+	}
+	if basic, ok := named.Underlying().(*types.Basic); !ok || basic.Info()&types.IsInteger == 0 {
+		return ""
+	}
+	declPkg := named.Obj().Pkg()
+	if declPkg == nil || declPkg != pkg && !fileImports(file, declPkg.Path()) {
+		return "" // constants are inaccessible
+	}
+
+	type flag struct {
+		name string
+		bit  uint64
+	}
+	var flags []flag
+	scope := declPkg.Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if !ok || !types.Identical(c.Type(), named) || declPkg != pkg && !c.Exported() {
+			continue
+		}
+		bit, exact := constant.Uint64Val(constant.ToInt(c.Val()))
+		if !exact || bit == 0 || bit&(bit-1) != 0 {
+			continue // not a single bit
+		}
+		if q := qual(declPkg); q != "" {
+			name = q + "." + name
+		}
+		flags = append(flags, flag{name, bit})
+	}
+	sort.SliceStable(flags, func(i, j int) bool { return flags[i].bit < flags[j].bit })
+
+	var names []string
+	rest := v
+	for _, f := range flags {
+		if rest&f.bit != 0 { // (the first of several constants for a bit)
+			names = append(names, f.name)
+			rest &^= f.bit
+		}
+	}
+	if rest != 0 {
+		return "" // some bit has no constant
+	}
+	return strings.Join(names, "|")
+}
+
+// fileImports reports whether file imports the package of the given path.
+func fileImports(file *ast.File, path string) bool {
+	for _, imp := range file.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err == nil && p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// enclosingFlagExpression returns the outermost bitwise-or expression
+// in path whose operands are all named integer constants, and its
+// value, or nil if there is none.
+func enclosingFlagExpression(info *types.Info, path []ast.Node) (ast.Expr, uint64) {
+	var (
+		outer ast.Expr
+		value uint64
+	)
+	for _, n := range path {
+		switch n := n.(type) {
+		case *ast.ParenExpr, *ast.Ident, *ast.SelectorExpr:
+			continue
+		case *ast.BinaryExpr:
+			if n.Op != token.OR {
+				return outer, value
+			}
+			tv, ok := info.Types[n]
+			if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int || !constantOperands(info, n) {
+				continue
+			}
+			if v, exact := constant.Uint64Val(tv.Value); exact {
+				outer, value = n, v
+			}
+		default:
+			return outer, value
+		}
+	}
+	return outer, value
+}
+
+// constantOperands reports whether the operands of the bitwise-or
+// expression e are all named constants.
+func constantOperands(info *types.Info, e ast.Expr) bool {
+	switch e := astutil.Unparen(e).(type) {
+	case *ast.BinaryExpr:
+		return e.Op == token.OR && constantOperands(info, e.X) && constantOperands(info, e.Y)
+	case *ast.Ident:
+		_, ok := info.Uses[e].(*types.Const)
+		return ok
+	case *ast.SelectorExpr:
+		_, ok := info.Uses[e.Sel].(*types.Const)
+		return ok
+	}
+	return false
+}
+
+// needsConversion reports whether replacing the typed constant
+// expression expr, whose enclosing nodes are given by path, by an
+// untyped constant would change its type: that is, whether the
+// constant would assume its default type (int) in its context.
+func needsConversion(info *types.Info, path []ast.Node, expr ast.Expr) bool {
+	// Find the context of expr, skipping parentheses.
+	var parent ast.Node
+	for i, n := range path {
+		if n == expr {
+			for _, p := range path[i+1:] {
+				if _, ok := p.(*ast.ParenExpr); !ok {
+					parent = p
+					break
+				}
+			}
+			break
+		}
+	}
+	switch parent := parent.(type) {
+	case *ast.AssignStmt:
+		return parent.Tok == token.DEFINE
+	case *ast.ValueSpec:
+		return parent.Type == nil
+	case *ast.SwitchStmt:
+		return true // tag
+	case *ast.CallExpr:
+		sig, ok := info.TypeOf(parent.Fun).Underlying().(*types.Signature)
+		if !ok {
+			return false // a conversion
+		}
+		params := sig.Params()
+		for i, arg := range parent.Args {
+			if astutil.Unparen(arg) != expr {
+				continue
+			}
+			var t types.Type
+			if sig.Variadic() && i >= params.Len()-1 {
+				t = params.At(params.Len() - 1).Type()
+				if parent.Ellipsis == token.NoPos {
+					t = t.(*types.Slice).Elem()
+				}
+			} else if i < params.Len() {
+				t = params.At(i).Type()
+			}
+			return t == nil || types.IsInterface(t)
+		}
+	}
+	return false
+}
@@ -0,0 +1,877 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the "points-to", "peers", and "whicherrs" queries,
+// which formerly belonged to the guru tool, atop the SSA form of the
+// workspace.
+//
+// The queries share a demand-driven analysis that computes the
+// "labels" of an SSA value: the allocations, functions, globals, and
+// interface conversions to which it may refer. The search proceeds
+// backwards from the value along the flow of values through
+// assignments, calls, returns, and memory. It is flow- and
+// context-insensitive; it treats all instances of a struct field as a
+// single variable; and it resolves dynamic calls by class hierarchy
+// analysis. So, unlike guru's whole-program pointer analysis, it is
+// cheap enough to compute for a single value on demand, at the cost
+// of precision: its results are an over-approximation, and a cycle of
+// flow through memory may be truncated.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/typeparams"
+)
+
+// PointsTo computes the labels of the pointer-like expression at the
+// specified range.
+func PointsTo(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) (command.PointsToResult, error) {
+	ctx, done := event.Start(ctx, "golang.PointsTo")
+	defer done()
+
+	var result command.PointsToResult
+	q, err := newSSAQuery(ctx, snapshot, fh, rng)
+	if err != nil {
+		return result, err
+	}
+	e, ok := q.path[0].(ast.Expr)
+	if !ok {
+		return result, fmt.Errorf("no expression selected")
+	}
+	T := q.pkg.TypesInfo.TypeOf(e)
+	if T == nil || !isPointerLike(T) {
+		return result, fmt.Errorf("points-to query requires an expression of pointer, map, channel, slice, function, or interface type")
+	}
+	labels, err := q.selectionLabels(q.path)
+	if err != nil {
+		return result, err
+	}
+
+	result.Type = types.TypeString(T, q.qual)
+	result.Labels = []command.PointsToLabel{}
+	for _, l := range labels {
+		pos := labelPos(l)
+		if !pos.IsValid() {
+			continue // e.g. a synthetic wrapper function
+		}
+		desc, typ := q.describeLabel(l)
+		loc, err := q.location(pos)
+		if err != nil {
+			return result, err
+		}
+		result.Labels = append(result.Labels, command.PointsToLabel{
+			Desc:     desc,
+			Type:     types.TypeString(typ, q.qual),
+			Location: loc,
+		})
+	}
+	sort.SliceStable(result.Labels, func(i, j int) bool {
+		return protocol.CompareLocation(result.Labels[i].Location, result.Labels[j].Location) < 0
+	})
+	return result, nil
+}
+
+// Peers computes the channels of the send, receive, or close
+// operation at the specified range, and the operations on them.
+func Peers(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) (command.PeersResult, error) {
+	ctx, done := event.Start(ctx, "golang.Peers")
+	defer done()
+
+	var result command.PeersResult
+	q, err := newSSAQuery(ctx, snapshot, fh, rng)
+	if err != nil {
+		return result, err
+	}
+
+	// Find the channel operand of the enclosing channel operation.
+	var ch ast.Expr
+	for _, n := range q.path {
+		switch n := n.(type) {
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				ch = n.X
+			}
+		case *ast.SendStmt:
+			ch = n.Chan
+		case *ast.CallExpr:
+			if id, ok := astutil.Unparen(n.Fun).(*ast.Ident); ok && len(n.Args) == 1 {
+				if b, ok := q.pkg.TypesInfo.Uses[id].(*types.Builtin); ok && b.Name() == "close" {
+					ch = n.Args[0]
+				}
+			}
+		}
+		if ch != nil {
+			break
+		}
+	}
+	if ch == nil {
+		return result, fmt.Errorf("no send, receive, or close operation selected")
+	}
+	chanType := q.pkg.TypesInfo.TypeOf(ch)
+	elem := chanElem(chanType)
+	if elem == nil {
+		return result, fmt.Errorf("not a channel operation") // e.g. close of a type parameter
+	}
+	path, _ := astutil.PathEnclosingInterval(q.file, ch.Pos(), ch.End())
+	labels, err := q.selectionLabels(path)
+	if err != nil {
+		return result, err
+	}
+	allocs := make(map[ssa.Value]bool)
+	for _, l := range labels {
+		if mc, ok := l.(*ssa.MakeChan); ok {
+			allocs[mc] = true
+			loc, err := q.location(labelPos(mc))
+			if err != nil {
+				return result, err
+			}
+			result.Allocs = append(result.Allocs, loc)
+		}
+	}
+
+	result.Type = types.TypeString(chanType, q.qual)
+	for _, op := range q.chanOps {
+		if e := chanElem(op.ch.Type()); e == nil || !types.Identical(e, elem) {
+			continue
+		}
+		peer := false
+		for _, l := range q.pointsTo(op.ch) {
+			if allocs[l] {
+				peer = true
+				break
+			}
+		}
+		if !peer {
+			continue
+		}
+		loc, err := q.location(op.pos)
+		if err != nil {
+			return result, err
+		}
+		switch op.dir {
+		case types.SendOnly:
+			result.Sends = append(result.Sends, loc)
+		case types.RecvOnly:
+			result.Receives = append(result.Receives, loc)
+		default:
+			result.Closes = append(result.Closes, loc)
+		}
+	}
+	for _, locs := range [][]protocol.Location{result.Allocs, result.Sends, result.Receives, result.Closes} {
+		sort.Slice(locs, func(i, j int) bool {
+			return protocol.CompareLocation(locs[i], locs[j]) < 0
+		})
+	}
+	return result, nil
+}
+
+// WhichErrs computes the globals, constants, and dynamic types of the
+// values that the error expression at the specified range may hold.
+func WhichErrs(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) (command.WhichErrsResult, error) {
+	ctx, done := event.Start(ctx, "golang.WhichErrs")
+	defer done()
+
+	var result command.WhichErrsResult
+	q, err := newSSAQuery(ctx, snapshot, fh, rng)
+	if err != nil {
+		return result, err
+	}
+	e, ok := q.path[0].(ast.Expr)
+	if !ok {
+		return result, fmt.Errorf("no expression selected")
+	}
+	if T := q.pkg.TypesInfo.TypeOf(e); T == nil || !types.Identical(T, errorType) {
+		return result, fmt.Errorf("whicherrs query requires an expression of type error")
+	}
+
+	// A load of a global error variable, such as io.EOF,
+	// identifies the error well enough.
+	q.globalErrors = true
+	labels, err := q.selectionLabels(q.path)
+	if err != nil {
+		return result, err
+	}
+
+	seen := make(map[string]bool)
+	for _, l := range labels {
+		switch l := l.(type) {
+		case *ssa.UnOp:
+			g := l.X.(*ssa.Global)
+			name := g.Name()
+			if g.Pkg.Pkg != q.pkg.Types {
+				name = g.Pkg.Pkg.Name() + "." + name
+			}
+			if !seen[name] {
+				seen[name] = true
+				result.Globals = append(result.Globals, name)
+			}
+
+		case *ssa.MakeInterface:
+			if c, ok := l.X.(*ssa.Const); ok {
+				if c.Value != nil {
+					name := fmt.Sprintf("%s(%s)", types.TypeString(c.Type(), q.qual), c.Value)
+					if !seen[name] {
+						seen[name] = true
+						result.Constants = append(result.Constants, name)
+					}
+				}
+				continue
+			}
+			T := l.X.Type()
+			name := types.TypeString(T, q.qual)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			pos := l.Pos()
+			if named, ok := typeparams.Deref(T).(*types.Named); ok {
+				pos = named.Obj().Pos()
+			}
+			loc, err := q.location(pos)
+			if err != nil {
+				return result, err
+			}
+			result.Types = append(result.Types, command.WhichErrsType{
+				Type:     name,
+				Location: loc,
+			})
+		}
+	}
+	sort.Strings(result.Globals)
+	sort.Strings(result.Constants)
+	sort.Slice(result.Types, func(i, j int) bool {
+		return result.Types[i].Type < result.Types[j].Type
+	})
+	return result, nil
+}
+
+// isPointerLike reports whether values of type T refer to variables
+// or functions.
+func isPointerLike(T types.Type) bool {
+	switch T.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Chan, *types.Slice, *types.Signature, *types.Interface:
+		return true
+	}
+	return false
+}
+
+// chanElem returns the element type of channel type T, or nil.
+func chanElem(T types.Type) types.Type {
+	if ch, ok := T.Underlying().(*types.Chan); ok {
+		return ch.Elem()
+	}
+	return nil
+}
+
+// An ssaQuery holds the SSA form of the workspace, built for a query
+// about the syntax at a selected range, and the memoized state of the
+// analysis of its values.
+type ssaQuery struct {
+	prog *ssa.Program
+	pkg  *packages.Package // package of the selection
+	file *ast.File         // file of the selection
+	path []ast.Node        // path from the selection to the root of file
+	qual types.Qualifier
+	loc  func(start, end token.Pos) (protocol.Location, error)
+
+	// Index of the program.
+	callees  map[ssa.CallInstruction][]*ssa.Function
+	callers  map[*ssa.Function][]ssa.CallInstruction
+	closures map[*ssa.Function][]*ssa.MakeClosure
+	returns  map[*ssa.Function][]*ssa.Return
+	stores   []*ssa.Store
+	updates  []*ssa.MapUpdate
+	chanOps  []chanOp
+
+	// globalErrors causes a load of a global of type
+	// error to be a label (see WhichErrs).
+	globalErrors bool
+
+	labels     map[ssa.Value][]ssa.Value // memoized results of pointsTo
+	inProgress map[ssa.Value]bool
+}
+
+// A chanOp is a send, receive, or close (dir == 0) operation on ch.
+type chanOp struct {
+	ch  ssa.Value
+	dir types.ChanDir
+	pos token.Pos
+}
+
+// newSSAQuery loads the workspace packages, builds their SSA form,
+// and indexes it for a query about the syntax at the specified range.
+func newSSAQuery(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) (*ssaQuery, error) {
+	mp, err := NarrowestMetadataForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	initial, _, err := loadWorkspace(ctx, snapshot, mp.ForTest != "")
+	if err != nil {
+		return nil, err
+	}
+	var target *packages.Package
+	for _, pkg := range initial {
+		if pkg.ID == string(mp.ID) {
+			target = pkg
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("package %s not found", mp.ID)
+	}
+
+	// Find the selected syntax.
+	var file *ast.File
+	for i, name := range target.CompiledGoFiles {
+		if protocol.URIFromPath(name) == fh.URI() && i < len(target.Syntax) {
+			file = target.Syntax[i]
+		}
+	}
+	if file == nil {
+		return nil, fmt.Errorf("file %s not found in package %s", fh.URI(), mp.ID)
+	}
+	content, err := fh.Content()
+	if err != nil {
+		return nil, err
+	}
+	startOffset, endOffset, err := protocol.NewMapper(fh.URI(), content).RangeOffsets(rng)
+	if err != nil {
+		return nil, err
+	}
+	tokFile := target.Fset.File(file.Pos())
+	start, err := safetoken.Pos(tokFile, startOffset)
+	if err != nil {
+		return nil, err
+	}
+	end, err := safetoken.Pos(tokFile, endOffset)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	if len(path) == 0 {
+		return nil, fmt.Errorf("no syntax at selection")
+	}
+
+	// Debug mode records the correspondence of
+	// expressions and values (see ValueForExpr).
+	prog, _ := ssautil.AllPackages(initial, ssa.InstantiateGenerics|ssa.GlobalDebug)
+	prog.Build()
+
+	q := &ssaQuery{
+		prog:       prog,
+		pkg:        target,
+		file:       file,
+		path:       path,
+		qual:       types.RelativeTo(target.Types),
+		loc:        locator(ctx, snapshot, prog.Fset),
+		callees:    make(map[ssa.CallInstruction][]*ssa.Function),
+		callers:    make(map[*ssa.Function][]ssa.CallInstruction),
+		closures:   make(map[*ssa.Function][]*ssa.MakeClosure),
+		returns:    make(map[*ssa.Function][]*ssa.Return),
+		labels:     make(map[ssa.Value][]ssa.Value),
+		inProgress: make(map[ssa.Value]bool),
+	}
+
+	for _, edges := range cha.CallGraph(prog).Nodes {
+		for _, edge := range edges.Out {
+			q.callees[edge.Site] = append(q.callees[edge.Site], edge.Callee.Func)
+			q.callers[edge.Callee.Func] = append(q.callers[edge.Callee.Func], edge.Site)
+		}
+	}
+	for fn := range ssautil.AllFunctions(prog) {
+		for _, b := range fn.Blocks {
+			for _, instr := range b.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.Store:
+					q.stores = append(q.stores, instr)
+				case *ssa.MapUpdate:
+					q.updates = append(q.updates, instr)
+				case *ssa.Return:
+					q.returns[fn] = append(q.returns[fn], instr)
+				case *ssa.MakeClosure:
+					closure := instr.Fn.(*ssa.Function)
+					q.closures[closure] = append(q.closures[closure], instr)
+				case *ssa.Send:
+					q.chanOps = append(q.chanOps, chanOp{instr.Chan, types.SendOnly, instr.Pos()})
+				case *ssa.UnOp:
+					if instr.Op == token.ARROW {
+						q.chanOps = append(q.chanOps, chanOp{instr.X, types.RecvOnly, instr.Pos()})
+					}
+				case *ssa.Select:
+					for _, state := range instr.States {
+						q.chanOps = append(q.chanOps, chanOp{state.Chan, state.Dir, state.Pos})
+					}
+				case *ssa.Call:
+					if b, ok := instr.Call.Value.(*ssa.Builtin); ok && b.Name() == "close" {
+						q.chanOps = append(q.chanOps, chanOp{instr.Call.Args[0], 0, instr.Pos()})
+					}
+				}
+			}
+		}
+	}
+	return q, nil
+}
+
+// location returns the location of the position pos.
+func (q *ssaQuery) location(pos token.Pos) (protocol.Location, error) {
+	return q.loc(pos, pos)
+}
+
+// selectionLabels returns the labels of the value of the expression
+// path[0], whose enclosing nodes are path[1:].
+func (q *ssaQuery) selectionLabels(path []ast.Node) ([]ssa.Value, error) {
+	e, ok := path[0].(ast.Expr)
+	if !ok {
+		return nil, fmt.Errorf("no expression selected")
+	}
+	e = astutil.Unparen(e)
+	pkg := q.prog.Package(q.pkg.Types)
+
+	var (
+		v      ssa.Value
+		isAddr bool
+	)
+	if id, ok := e.(*ast.Ident); ok {
+		switch obj := q.pkg.TypesInfo.ObjectOf(id).(type) {
+		case *types.Func:
+			if fn := q.prog.FuncValue(obj); fn != nil {
+				return []ssa.Value{fn}, nil
+			}
+		case *types.Var:
+			v, isAddr = q.prog.VarValue(obj, pkg, path)
+		}
+	}
+	if v == nil {
+		fn := ssa.EnclosingFunction(pkg, path)
+		if fn == nil {
+			return nil, fmt.Errorf("no function encloses the selected expression")
+		}
+		v, isAddr = fn.ValueForExpr(e)
+	}
+	if v == nil {
+		return nil, fmt.Errorf("no SSA value for the selected expression (is it dead code?)")
+	}
+	if !isAddr {
+		return q.pointsTo(v), nil
+	}
+
+	// The value is the address of the variable;
+	// we want the labels of its contents.
+	set := make(map[ssa.Value]bool)
+	for _, stored := range q.loadedValues(v) {
+		for _, l := range q.pointsTo(stored) {
+			set[l] = true
+		}
+	}
+	return sortedLabels(set), nil
+}
+
+// pointsTo returns the labels of the value v, in order of position.
+func (q *ssaQuery) pointsTo(v ssa.Value) []ssa.Value {
+	if labels, ok := q.labels[v]; ok {
+		return labels
+	}
+	if q.inProgress[v] {
+		return nil // a cycle of flow through memory
+	}
+	q.inProgress[v] = true
+	defer delete(q.inProgress, v)
+
+	set := make(map[ssa.Value]bool)
+	seen := make(map[ssa.Value]bool)
+	var stack []ssa.Value
+	push := func(vs ...ssa.Value) {
+		for _, v := range vs {
+			if v != nil && !seen[v] {
+				seen[v] = true
+				stack = append(stack, v)
+			}
+		}
+	}
+	push(v)
+	for len(stack) > 0 {
+		x := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		switch x := x.(type) {
+		case *ssa.Alloc, *ssa.MakeMap, *ssa.MakeChan, *ssa.MakeSlice, *ssa.MakeClosure,
+			*ssa.Function, *ssa.Global, *ssa.MakeInterface, *ssa.FieldAddr, *ssa.IndexAddr:
+			set[x] = true
+
+		case *ssa.Phi:
+			push(x.Edges...)
+
+		case *ssa.ChangeType:
+			push(x.X)
+		case *ssa.Convert:
+			push(x.X)
+		case *ssa.ChangeInterface:
+			push(x.X)
+		case *ssa.MultiConvert:
+			push(x.X)
+		case *ssa.SliceToArrayPointer:
+			push(x.X)
+		case *ssa.Slice:
+			push(x.X)
+		case *ssa.TypeAssert:
+			push(x.X)
+
+		case *ssa.UnOp:
+			switch x.Op {
+			case token.MUL:
+				if _, ok := x.X.(*ssa.Global); ok && q.globalErrors && types.Identical(x.Type(), errorType) {
+					set[x] = true // a global error variable (see WhichErrs)
+					break
+				}
+				push(q.loadedValues(x.X)...)
+			case token.ARROW:
+				push(q.receivedValues(x.X)...)
+			}
+
+		case *ssa.Lookup:
+			if _, ok := x.X.Type().Underlying().(*types.Map); ok {
+				push(q.mapValues(x.X, false)...)
+			}
+
+		case *ssa.Field:
+			push(q.fieldValues(fieldVar(x.X.Type(), x.Field))...)
+
+		case *ssa.Extract:
+			switch t := x.Tuple.(type) {
+			case *ssa.Call:
+				for _, callee := range q.callees[t] {
+					for _, ret := range q.returns[callee] {
+						if x.Index < len(ret.Results) {
+							push(ret.Results[x.Index])
+						}
+					}
+				}
+			case *ssa.TypeAssert:
+				if x.Index == 0 {
+					push(t.X)
+				}
+			case *ssa.UnOp: // v, ok := <-ch
+				if x.Index == 0 {
+					push(q.receivedValues(t.X)...)
+				}
+			case *ssa.Lookup: // v, ok := m[k]
+				if x.Index == 0 {
+					push(q.mapValues(t.X, false)...)
+				}
+			case *ssa.Select:
+				// The tuple holds the received
+				// values, after (index, recvOk).
+				i := 2
+				for _, state := range t.States {
+					if state.Dir == types.RecvOnly {
+						if i == x.Index {
+							push(q.receivedValues(state.Chan)...)
+						}
+						i++
+					}
+				}
+			case *ssa.Next: // range over a map
+				if rng, ok := t.Iter.(*ssa.Range); ok && !t.IsString && x.Index > 0 {
+					push(q.mapValues(rng.X, x.Index == 1)...)
+				}
+			}
+
+		case *ssa.Call:
+			if b, ok := x.Call.Value.(*ssa.Builtin); ok {
+				if b.Name() == "append" {
+					set[x] = true // a new array
+					push(x.Call.Args...)
+				}
+				break
+			}
+			for _, callee := range q.callees[x] {
+				if callee.Blocks == nil {
+					set[x] = true // a call to an external function
+				}
+				for _, ret := range q.returns[callee] {
+					if len(ret.Results) > 0 {
+						push(ret.Results[0])
+					}
+				}
+			}
+
+		case *ssa.Parameter:
+			fn := x.Parent()
+			i := paramIndex(fn, x)
+			for _, site := range q.callers[fn] {
+				if i < 0 {
+					break
+				}
+				common := site.Common()
+				args := common.Args
+				if common.IsInvoke() {
+					args = append([]ssa.Value{common.Value}, args...)
+				}
+				if i < len(args) {
+					push(args[i])
+				}
+			}
+
+		case *ssa.FreeVar:
+			fn := x.Parent()
+			for i, fv := range fn.FreeVars {
+				if fv == x {
+					for _, mc := range q.closures[fn] {
+						push(mc.Bindings[i])
+					}
+				}
+			}
+		}
+	}
+
+	// A value of concrete type that flowed from an interface, as the
+	// operand of a type assertion or the receiver of a dynamic call,
+	// refers to the labels of the converted values.
+	if !types.IsInterface(v.Type()) {
+		for l := range set {
+			if mi, ok := l.(*ssa.MakeInterface); ok {
+				delete(set, l)
+				if types.Identical(mi.X.Type(), v.Type()) {
+					for _, l := range q.pointsTo(mi.X) {
+						set[l] = true
+					}
+				}
+			}
+		}
+	}
+
+	labels := sortedLabels(set)
+	q.labels[v] = labels
+	return labels
+}
+
+// loadedValues returns the values that may be stored at the address p.
+func (q *ssaQuery) loadedValues(p ssa.Value) []ssa.Value {
+	keys := make(map[any]bool)
+	for _, key := range q.addrKeys(p) {
+		keys[key] = true
+	}
+	var values []ssa.Value
+	for _, store := range q.stores {
+		if !types.Identical(store.Addr.Type(), p.Type()) {
+			continue
+		}
+		for _, key := range q.addrKeys(store.Addr) {
+			if keys[key] {
+				values = append(values, store.Val)
+				break
+			}
+		}
+	}
+	return values
+}
+
+// An elemKey identifies the elements of an array or slice.
+type elemKey struct{ array ssa.Value }
+
+// addrKeys returns the variables to which the address p may refer:
+// fields (all instances of a field are one variable), the elements of
+// arrays and slices, and other labels.
+func (q *ssaQuery) addrKeys(p ssa.Value) []any {
+	switch p := p.(type) {
+	case *ssa.FieldAddr:
+		return []any{fieldVar(p.X.Type(), p.Field)}
+	case *ssa.IndexAddr:
+		var keys []any
+		for _, l := range q.pointsTo(p.X) {
+			keys = append(keys, elemKey{l})
+		}
+		return keys
+	case *ssa.Global, *ssa.Alloc:
+		return []any{p}
+	}
+	var keys []any
+	for _, l := range q.pointsTo(p) {
+		switch l.(type) {
+		case *ssa.FieldAddr, *ssa.IndexAddr:
+			keys = append(keys, q.addrKeys(l)...)
+		default:
+			keys = append(keys, l)
+		}
+	}
+	return keys
+}
+
+// receivedValues returns the values that may be sent on the channel ch.
+func (q *ssaQuery) receivedValues(ch ssa.Value) []ssa.Value {
+	chans := make(map[ssa.Value]bool)
+	for _, l := range q.pointsTo(ch) {
+		chans[l] = true
+	}
+	var values []ssa.Value
+	for _, op := range q.chanOps {
+		if op.dir != types.SendOnly {
+			continue
+		}
+		for _, l := range q.pointsTo(op.ch) {
+			if chans[l] {
+				values = append(values, q.sentValue(op))
+				break
+			}
+		}
+	}
+	return values
+}
+
+// sentValue returns the value sent by the send operation op.
+func (q *ssaQuery) sentValue(op chanOp) ssa.Value {
+	for _, instr := range *op.ch.Referrers() {
+		switch instr := instr.(type) {
+		case *ssa.Send:
+			if instr.Pos() == op.pos {
+				return instr.X
+			}
+		case *ssa.Select:
+			for _, state := range instr.States {
+				if state.Pos == op.pos && state.Dir == types.SendOnly {
+					return state.Send
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mapValues returns the values (or keys) that may be stored in the map m.
+func (q *ssaQuery) mapValues(m ssa.Value, keys bool) []ssa.Value {
+	maps := make(map[ssa.Value]bool)
+	for _, l := range q.pointsTo(m) {
+		maps[l] = true
+	}
+	var values []ssa.Value
+	for _, update := range q.updates {
+		for _, l := range q.pointsTo(update.Map) {
+			if maps[l] {
+				if keys {
+					values = append(values, update.Key)
+				} else {
+					values = append(values, update.Value)
+				}
+				break
+			}
+		}
+	}
+	return values
+}
+
+// fieldValues returns the values that may be stored in the field.
+func (q *ssaQuery) fieldValues(field *types.Var) []ssa.Value {
+	var values []ssa.Value
+	for _, store := range q.stores {
+		if fa, ok := store.Addr.(*ssa.FieldAddr); ok && fieldVar(fa.X.Type(), fa.Field) == field {
+			values = append(values, store.Val)
+		}
+	}
+	return values
+}
+
+// fieldVar returns the ith field of the struct type T, or of the
+// struct type to which T points.
+func fieldVar(T types.Type, i int) *types.Var {
+	if ptr, ok := typeparams.CoreType(T).(*types.Pointer); ok {
+		T = ptr.Elem()
+	}
+	return typeparams.CoreType(T).(*types.Struct).Field(i)
+}
+
+func paramIndex(fn *ssa.Function, p *ssa.Parameter) int {
+	for i, param := range fn.Params {
+		if param == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortedLabels returns the elements of set in order of position.
+func sortedLabels(set map[ssa.Value]bool) []ssa.Value {
+	labels := make([]ssa.Value, 0, len(set))
+	for l := range set {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labelPos(labels[i]) < labelPos(labels[j])
+	})
+	return labels
+}
+
+// labelPos returns the position of the label l, or of the nearest
+// syntax if it has none, as for an implicit conversion.
+func labelPos(l ssa.Value) token.Pos {
+	if pos := l.Pos(); pos.IsValid() {
+		return pos
+	}
+	if mi, ok := l.(*ssa.MakeInterface); ok && mi.X.Pos().IsValid() {
+		return mi.X.Pos()
+	}
+	if fn := l.Parent(); fn != nil {
+		return fn.Pos()
+	}
+	return token.NoPos
+}
+
+// describeLabel returns a description of the label l and the type of
+// the object to which it refers.
+func (q *ssaQuery) describeLabel(l ssa.Value) (string, types.Type) {
+	switch l := l.(type) {
+	case *ssa.Alloc:
+		T := typeparams.Deref(l.Type())
+		switch l.Comment {
+		case "new":
+			return "new", T
+		case "complit", "slicelit":
+			return "composite literal", T
+		case "varargs":
+			return "variadic arguments", T
+		}
+		return "variable " + l.Comment, T
+	case *ssa.MakeMap:
+		return "makemap", l.Type()
+	case *ssa.MakeChan:
+		return "makechan", l.Type()
+	case *ssa.MakeSlice:
+		return "makeslice", l.Type()
+	case *ssa.MakeClosure:
+		return "function literal", l.Type()
+	case *ssa.Function:
+		return "function " + l.RelString(q.pkg.Types), l.Type()
+	case *ssa.Global:
+		return "global " + l.RelString(q.pkg.Types), typeparams.Deref(l.Type())
+	case *ssa.MakeInterface:
+		return "conversion to interface", l.X.Type()
+	case *ssa.FieldAddr:
+		field := fieldVar(l.X.Type(), l.Field)
+		return "field " + field.Name(), field.Type()
+	case *ssa.IndexAddr:
+		return "element", typeparams.Deref(l.Type())
+	case *ssa.Call:
+		if callee := l.Call.StaticCallee(); callee != nil {
+			return "result of " + callee.RelString(q.pkg.Types), l.Type()
+		}
+		return "result of " + l.Call.Value.Name(), l.Type()
+	}
+	return l.Name(), l.Type()
+}
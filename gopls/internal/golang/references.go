@@ -39,18 +39,72 @@ import (
 // References returns a list of all references (sorted with
 // definitions before uses) to the object denoted by the identifier at
 // the given file/position, searching the entire workspace.
-func References(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position, includeDeclaration bool) ([]protocol.Location, error) {
-	references, err := references(ctx, snapshot, fh, pp, includeDeclaration)
+//
+// If report is non-nil, it is called with each batch of references,
+// in no particular order, as the search finds them, so that they can
+// be streamed to the client. Each reference is reported exactly once.
+func References(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position, includeDeclaration bool, report func([]protocol.Location)) ([]protocol.Location, error) {
+	references, err := references(ctx, snapshot, fh, pp, includeDeclaration, report)
 	if err != nil {
+		// With the registrationReferences option, a template
+		// name in a string literal has references too.
+		if snapshot.Options().RegistrationReferences {
+			if textual, err := RegistrationReferences(ctx, snapshot, fh, pp); err == nil && len(textual) > 0 {
+				if !includeDeclaration {
+					uses := textual[:0]
+					for _, ref := range textual {
+						if !ref.Definition {
+							uses = append(uses, ref)
+						}
+					}
+					textual = uses
+				}
+				return reportTextual(textual, report), nil
+			}
+		}
 		return nil, err
 	}
 	locations := make([]protocol.Location, len(references))
 	for i, ref := range references {
 		locations[i] = ref.location
 	}
+
+	// With the tagReferences option, the references to a
+	// field also include the textual matches of its tag.
+	if snapshot.Options().TagReferences {
+		textual, err := TextualReferences(ctx, snapshot, fh, pp)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, reportTextual(textual, report)...)
+	}
+
+	// With the registrationReferences option, the references to a
+	// function also include the strings under which it is registered.
+	if snapshot.Options().RegistrationReferences {
+		textual, err := RegistrationReferences(ctx, snapshot, fh, pp)
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, reportTextual(textual, report)...)
+	}
+
 	return locations, nil
 }
 
+// reportTextual returns the locations of the textual references,
+// reporting them as a batch if report is non-nil.
+func reportTextual(textual []TextualReference, report func([]protocol.Location)) []protocol.Location {
+	var batch []protocol.Location
+	for _, ref := range textual {
+		batch = append(batch, ref.Location)
+	}
+	if report != nil && len(batch) > 0 {
+		report(batch)
+	}
+	return batch
+}
+
 // A reference describes an identifier that refers to the same
 // object as the subject of a References query.
 type reference struct {
@@ -62,7 +116,8 @@ type reference struct {
 // references returns a list of all references (sorted with
 // definitions before uses) to the object denoted by the identifier at
 // the given file/position, searching the entire workspace.
-func references(ctx context.Context, snapshot *cache.Snapshot, f file.Handle, pp protocol.Position, includeDeclaration bool) ([]reference, error) {
+// The optional report function is as for [References].
+func references(ctx context.Context, snapshot *cache.Snapshot, f file.Handle, pp protocol.Position, includeDeclaration bool, report func([]protocol.Location)) ([]reference, error) {
 	ctx, done := event.Start(ctx, "golang.references")
 	defer done()
 
@@ -72,11 +127,37 @@ func references(ctx context.Context, snapshot *cache.Snapshot, f file.Handle, pp
 		return nil, err
 	}
 
+	// If streaming, report each batch of references that the search
+	// finds, applying the same filtering and de-duplication as below.
+	// (The calls to stream are serialized.)
+	var stream func([]reference)
+	if report != nil {
+		seen := make(map[protocol.Location]bool)
+		stream = func(batch []reference) {
+			var locs []protocol.Location
+			for _, ref := range batch {
+				if !includeDeclaration && ref.isDeclaration {
+					continue
+				}
+				if !seen[ref.location] {
+					seen[ref.location] = true
+					locs = append(locs, ref.location)
+				}
+			}
+			if len(locs) > 0 {
+				report(locs)
+			}
+		}
+	}
+
 	var refs []reference
 	if inPackageName {
 		refs, err = packageReferences(ctx, snapshot, f.URI())
+		if err == nil && stream != nil {
+			stream(refs)
+		}
 	} else {
-		refs, err = ordinaryReferences(ctx, snapshot, f.URI(), pp)
+		refs, err = ordinaryReferences(ctx, snapshot, f.URI(), pp, stream)
 	}
 	if err != nil {
 		return nil, err
@@ -212,7 +293,7 @@ func packageReferences(ctx context.Context, snapshot *cache.Snapshot, uri protoc
 }
 
 // ordinaryReferences computes references for all ordinary objects (not package declarations).
-func ordinaryReferences(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI, pp protocol.Position) ([]reference, error) {
+func ordinaryReferences(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI, pp protocol.Position, stream func([]reference)) ([]reference, error) {
 	// Strategy: use the reference information computed by the
 	// type checker to find the declaration. First type-check this
 	// package to find the declaration, then type check the
@@ -356,29 +437,37 @@ func ordinaryReferences(ctx context.Context, snapshot *cache.Snapshot, uri proto
 	}
 
 	// The search functions will call report(loc) for each hit.
+	// Each task of the search accumulates its hits in a batch,
+	// which it adds to refs, and streams, once it is complete.
 	var (
 		refsMu sync.Mutex
 		refs   []reference
 	)
-	report := func(loc protocol.Location, isDecl bool) {
-		ref := reference{
-			isDeclaration: isDecl,
-			location:      loc,
-			pkgPath:       pkg.Metadata().PkgPath,
+	newBatch := func() (report func(loc protocol.Location, isDecl bool), flush func()) {
+		var batch []reference
+		report = func(loc protocol.Location, isDecl bool) {
+			batch = append(batch, reference{
+				isDeclaration: isDecl,
+				location:      loc,
+				pkgPath:       pkg.Metadata().PkgPath,
+			})
+		}
+		flush = func() {
+			refsMu.Lock()
+			defer refsMu.Unlock()
+			refs = append(refs, batch...)
+			if stream != nil {
+				stream(batch)
+			}
+			batch = nil
 		}
-		refsMu.Lock()
-		refs = append(refs, ref)
-		refsMu.Unlock()
+		return report, flush
 	}
 
 	// Loop over the variants of the declaring package,
 	// and perform both the local (in-package) and global
 	// (cross-package) searches, in parallel.
 	//
-	// TODO(adonovan): opt: support LSP reference streaming. See:
-	// - https://github.com/microsoft/vscode-languageserver-node/pull/164
-	// - https://github.com/microsoft/language-server-protocol/pull/182
-	//
 	// Careful: this goroutine must not return before group.Wait.
 	var group errgroup.Group
 
@@ -399,6 +488,9 @@ func ordinaryReferences(ctx context.Context, snapshot *cache.Snapshot, uri proto
 		}
 		mp := mp
 		group.Go(func() error {
+			report, flush := newBatch()
+			defer flush()
+
 			// TODO(adonovan): opt: batch these TypeChecks.
 			pkgs, err := snapshot.TypeCheck(ctx, mp.ID)
 			if err != nil {
@@ -443,6 +535,9 @@ func ordinaryReferences(ctx context.Context, snapshot *cache.Snapshot, uri proto
 	for id := range expansions {
 		id := id
 		group.Go(func() error {
+			report, flush := newBatch()
+			defer flush()
+
 			// TODO(adonovan): opt: batch these TypeChecks.
 			pkgs, err := snapshot.TypeCheck(ctx, id)
 			if err != nil {
@@ -480,9 +575,11 @@ func ordinaryReferences(ctx context.Context, snapshot *cache.Snapshot, uri proto
 			return err
 		}
 		for _, index := range indexes {
+			report, flush := newBatch()
 			for _, loc := range index.Lookup(globalTargets) {
 				report(loc, false)
 			}
+			flush()
 		}
 		return nil
 	})
@@ -0,0 +1,498 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/types/typeutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/diff"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/typeparams"
+)
+
+// This file defines the references through string-keyed registries,
+// which relate a function, method, or template to the string literals
+// by which other code refers to it:
+//
+//   - the pattern under which a function is registered as an HTTP
+//     handler, as in http.HandleFunc("/path", f), or by any other
+//     router method named Handle or HandleFunc;
+//   - the "Service.Method" strings by which net/rpc clients call a
+//     method of a type registered with net/rpc, as in
+//     client.Call("Arith.Multiply", args, &reply);
+//   - the names of text/template and html/template templates, in
+//     calls such as t.New("name"), t.Lookup("name"), and
+//     t.ExecuteTemplate(w, "name", data), and in actions such as
+//     {{define "name"}} and {{template "name"}} in template text.
+//
+// These relations are found by syntactic patterns, so some may be
+// coincidental, and some missed.
+
+// RegistrationReferences returns the string literals, in order of
+// location, that refer through a string-keyed registry to the
+// function or method denoted by the identifier at the given position,
+// or to the template named by the string literal at that position,
+// searching the files of all workspace packages.
+func RegistrationReferences(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position) ([]TextualReference, error) {
+	ctx, done := event.Start(ctx, "golang.RegistrationReferences")
+	defer done()
+
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	pos, err := pgf.PositionPos(pp)
+	if err != nil {
+		return nil, err
+	}
+
+	// A template name?
+	if name, ok := templateNameAt(pgf, pos); ok {
+		return templateReferences(ctx, snapshot, name)
+	}
+
+	fn := funcAt(pkg, pgf, pos)
+	if fn == nil {
+		return nil, nil
+	}
+	registry, err := findRegistrations(ctx, snapshot, pkg.FileSet(), fn)
+	if err != nil {
+		return nil, err
+	}
+	refs := registry.routes
+	rpcRefs, err := rpcReferences(ctx, snapshot, fn.Name(), registry.services)
+	if err != nil {
+		return nil, err
+	}
+	refs = append(refs, rpcRefs...)
+	sortTextualReferences(refs)
+	return refs, nil
+}
+
+// registrationRenameEdits returns the edits that rename the "Service.Method"
+// strings of the net/rpc method denoted by the identifier at the given
+// position, if any, to the new name.
+func registrationRenameEdits(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position, newName string) (map[protocol.DocumentURI][]diff.Edit, error) {
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	pos, err := pgf.PositionPos(pp)
+	if err != nil {
+		return nil, err
+	}
+	fn := funcAt(pkg, pgf, pos)
+	if fn == nil || fn.Type().(*types.Signature).Recv() == nil {
+		return nil, nil
+	}
+	registry, err := findRegistrations(ctx, snapshot, pkg.FileSet(), fn)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := rpcReferences(ctx, snapshot, fn.Name(), registry.services)
+	if err != nil {
+		return nil, err
+	}
+
+	edits := make(map[protocol.DocumentURI][]diff.Edit)
+	for _, ref := range refs {
+		fh, err := snapshot.ReadFile(ctx, ref.Location.URI)
+		if err != nil {
+			return nil, err
+		}
+		pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+		if err != nil {
+			return nil, err
+		}
+		start, end, err := pgf.Mapper.RangeOffsets(ref.Location.Range)
+		if err != nil {
+			return nil, err
+		}
+		edits[ref.Location.URI] = append(edits[ref.Location.URI], diff.Edit{Start: start, End: end, New: newName})
+	}
+	return edits, nil
+}
+
+// funcAt returns the function or method, if any, denoted by the
+// identifier at pos.
+func funcAt(pkg *cache.Package, pgf *parsego.File, pos token.Pos) *types.Func {
+	objects, _, err := objectsAt(pkg.TypesInfo(), pgf.File, pos)
+	if err != nil {
+		return nil
+	}
+	for obj := range objects {
+		if fn, ok := obj.(*types.Func); ok {
+			return fn.Origin()
+		}
+	}
+	return nil
+}
+
+// A registry holds the registrations of a function or method.
+type registry struct {
+	routes   []TextualReference // patterns of HTTP handler registrations
+	services map[string]bool    // names of net/rpc services of the method's receiver
+}
+
+// findRegistrations returns the registrations of fn, whose positions
+// are relative to fset, in the workspace packages: the patterns under
+// which it is registered as an HTTP handler and, if it is a method,
+// the names under which its receiver type is registered as a net/rpc
+// service.
+func findRegistrations(ctx context.Context, snapshot *cache.Snapshot, fset *token.FileSet, fn *types.Func) (*registry, error) {
+	result := &registry{services: make(map[string]bool)}
+
+	// Type-check the workspace packages that may register something.
+	workspace, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata.RemoveIntermediateTestVariants(&workspace)
+	var ids []PackageID
+	for _, mp := range workspace {
+		for _, uri := range mp.CompiledGoFiles {
+			fh, err := snapshot.ReadFile(ctx, uri)
+			if err != nil {
+				return nil, err
+			}
+			content, err := fh.Content()
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Contains(content, []byte("Handle")) || bytes.Contains(content, []byte("Register")) {
+				ids = append(ids, mp.ID)
+				break
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	pkgs, err := snapshot.TypeCheck(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Objects from distinct packages are compared by declaration.
+	type declKey struct {
+		filename string
+		offset   int
+	}
+	keyOf := func(fset *token.FileSet, obj types.Object) declKey {
+		posn := safetoken.StartPosition(fset, obj.Pos())
+		return declKey{posn.Filename, posn.Offset}
+	}
+	fnKey := keyOf(fset, fn)
+	var recvKey *declKey
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		if named, ok := typeparams.Deref(recv.Type()).(*types.Named); ok {
+			key := keyOf(fset, named.Obj())
+			recvKey = &key
+		}
+	}
+
+	seen := make(map[protocol.Location]bool)
+	for _, pkg := range pkgs {
+		info := pkg.TypesInfo()
+		pkgFset := pkg.FileSet()
+		for _, pgf := range pkg.CompiledGoFiles() {
+			var inspectErr error
+			ast.Inspect(pgf.File, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || inspectErr != nil {
+					return inspectErr == nil
+				}
+				callee := typeutil.Callee(info, call)
+				if callee == nil {
+					return true
+				}
+				switch name := callee.Name(); {
+				case (name == "Handle" || name == "HandleFunc") && len(call.Args) == 2:
+					// A handler registration, as in mux.HandleFunc("/path", f).
+					lit, ok := astutil.Unparen(call.Args[0]).(*ast.BasicLit)
+					if !ok || lit.Kind != token.STRING {
+						break
+					}
+					obj := handlerObject(info, call.Args[1])
+					if obj == nil || keyOf(pkgFset, obj) != fnKey {
+						break
+					}
+					pattern, err := strconv.Unquote(lit.Value)
+					if err != nil {
+						break
+					}
+					loc, err := pgf.PosLocation(lit.Pos()+1, lit.End()-1)
+					if err != nil {
+						inspectErr = err
+						return false
+					}
+					if !seen[loc] {
+						seen[loc] = true
+						result.routes = append(result.routes, TextualReference{Location: loc, Key: pattern})
+					}
+
+				case (name == "Register" || name == "RegisterName") && callee.Pkg() != nil && callee.Pkg().Path() == "net/rpc":
+					// A service registration, as in rpc.RegisterName("Arith", new(Arith)).
+					if recvKey == nil || len(call.Args) == 0 {
+						break
+					}
+					rcvr := call.Args[len(call.Args)-1]
+					named, ok := typeparams.Deref(info.TypeOf(rcvr)).(*types.Named)
+					if !ok || keyOf(pkgFset, named.Obj()) != *recvKey {
+						break
+					}
+					service := named.Obj().Name()
+					if name == "RegisterName" {
+						tv := info.Types[call.Args[0]]
+						if tv.Value == nil {
+							break // non-constant name
+						}
+						service, _ = strconv.Unquote(tv.Value.ExactString())
+					}
+					result.services[service] = true
+				}
+				return true
+			})
+			if inspectErr != nil {
+				return nil, inspectErr
+			}
+		}
+	}
+	return result, nil
+}
+
+// handlerObject returns the function or method, if any, denoted by the
+// handler argument of a registration, which may be converted to a
+// handler type, as in http.HandlerFunc(f).
+func handlerObject(info *types.Info, arg ast.Expr) types.Object {
+	arg = astutil.Unparen(arg)
+	if call, ok := arg.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		if tv, ok := info.Types[call.Fun]; ok && tv.IsType() {
+			arg = astutil.Unparen(call.Args[0])
+		}
+	}
+	var id *ast.Ident
+	switch arg := arg.(type) {
+	case *ast.Ident:
+		id = arg
+	case *ast.SelectorExpr:
+		id = arg.Sel
+	default:
+		return nil
+	}
+	if fn, ok := info.Uses[id].(*types.Func); ok {
+		return fn.Origin()
+	}
+	return nil
+}
+
+// rpcReferences returns the string literals of the form
+// "Service.Method" that name the method of one of the services.
+// The location of each is that of the method name.
+func rpcReferences(ctx context.Context, snapshot *cache.Snapshot, method string, services map[string]bool) ([]TextualReference, error) {
+	if len(services) == 0 {
+		return nil, nil
+	}
+	suffix := "." + method
+	return searchStringLiterals(ctx, snapshot, []byte(suffix), func(pgf *parsego.File, lit *ast.BasicLit) ([]TextualReference, error) {
+		value, err := strconv.Unquote(lit.Value)
+		if err != nil || !strings.HasSuffix(value, suffix) || !services[strings.TrimSuffix(value, suffix)] {
+			return nil, nil
+		}
+		if strings.Contains(lit.Value, `\`) {
+			return nil, nil // offsets of value and literal differ
+		}
+		end := lit.End() - 1 // before the closing quote
+		loc, err := pgf.PosLocation(end-token.Pos(len(method)), end)
+		if err != nil {
+			return nil, err
+		}
+		return []TextualReference{{Location: loc, Key: value}}, nil
+	})
+}
+
+// templateActionRx matches a template action that names a template
+// within the source text of a Go string literal.
+var templateActionRx = regexp.MustCompile(`\{\{-?\s*(define|template|block)\s+\\?"([^"\\]*)\\?"`)
+
+// templateNameArgs maps the names of the methods and functions that
+// accept a template name to the index of that parameter.
+var templateNameArgs = map[string]int{
+	"New":             0,
+	"Lookup":          0,
+	"ExecuteTemplate": 1,
+}
+
+// templateNameAt returns the template name at pos, if pos is within a
+// string literal that names a template.
+func templateNameAt(pgf *parsego.File, pos token.Pos) (string, bool) {
+	path, _ := astutil.PathEnclosingInterval(pgf.File, pos, pos)
+	if len(path) < 2 {
+		return "", false
+	}
+	lit, ok := path[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	if call, ok := path[1].(*ast.CallExpr); ok && isTemplateNameArg(call, lit) {
+		value, err := strconv.Unquote(lit.Value)
+		return value, err == nil
+	}
+	offset := int(pos - lit.Pos())
+	for _, sub := range templateActionRx.FindAllStringSubmatchIndex(lit.Value, -1) {
+		if sub[4] <= offset && offset <= sub[5] {
+			return lit.Value[sub[4]:sub[5]], true
+		}
+	}
+	return "", false
+}
+
+// isTemplateNameArg reports whether lit is the template name argument
+// of the call.
+func isTemplateNameArg(call *ast.CallExpr, lit *ast.BasicLit) bool {
+	i, ok := templateNameArgs[callName(call)]
+	return ok && i < len(call.Args) && call.Args[i] == lit
+}
+
+// callName returns the name of the function or method called by
+// call, if it is an identifier or selection.
+func callName(call *ast.CallExpr) string {
+	switch fun := astutil.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		return fun.Sel.Name
+	}
+	return ""
+}
+
+// templateReferences returns the string literals that name the
+// template, as arguments of template calls or within template actions.
+func templateReferences(ctx context.Context, snapshot *cache.Snapshot, name string) ([]TextualReference, error) {
+	refs, err := searchStringLiterals(ctx, snapshot, []byte(name), func(pgf *parsego.File, lit *ast.BasicLit) ([]TextualReference, error) {
+		var refs []TextualReference
+		add := func(start, end int, definition bool) error {
+			loc, err := pgf.PosLocation(lit.Pos()+token.Pos(start), lit.Pos()+token.Pos(end))
+			if err != nil {
+				return err
+			}
+			refs = append(refs, TextualReference{Location: loc, Key: name, Definition: definition})
+			return nil
+		}
+
+		// A template name argument, as in t.New(name)?
+		path, _ := astutil.PathEnclosingInterval(pgf.File, lit.Pos(), lit.End())
+		if len(path) > 1 {
+			if call, ok := path[1].(*ast.CallExpr); ok && isTemplateNameArg(call, lit) {
+				if value, err := strconv.Unquote(lit.Value); err == nil && value == name {
+					return refs, add(1, len(lit.Value)-1, callName(call) == "New")
+				}
+			}
+		}
+
+		// Template actions, as in {{define name}}?
+		for _, sub := range templateActionRx.FindAllStringSubmatchIndex(lit.Value, -1) {
+			if lit.Value[sub[4]:sub[5]] == name {
+				action := lit.Value[sub[2]:sub[3]]
+				if err := add(sub[4], sub[5], action != "template"); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return refs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortTextualReferences(refs)
+	return refs, nil
+}
+
+// searchStringLiterals calls match for each string literal, other than
+// import paths and struct tags, in the files of the workspace packages
+// that contain substr, and returns the references it reports.
+func searchStringLiterals(ctx context.Context, snapshot *cache.Snapshot, substr []byte, match func(*parsego.File, *ast.BasicLit) ([]TextualReference, error)) ([]TextualReference, error) {
+	workspace, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[protocol.DocumentURI]bool)
+	var refs []TextualReference
+	for _, mp := range workspace {
+		for _, uri := range mp.CompiledGoFiles {
+			if seen[uri] {
+				continue
+			}
+			seen[uri] = true
+
+			fh, err := snapshot.ReadFile(ctx, uri)
+			if err != nil {
+				return nil, err
+			}
+			content, err := fh.Content()
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Contains(content, substr) {
+				continue // cheap test to avoid parsing
+			}
+			pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+			if err != nil {
+				return nil, err
+			}
+			var (
+				matchErr error
+				tags     = make(map[*ast.BasicLit]bool)
+			)
+			ast.Inspect(pgf.File, func(n ast.Node) bool {
+				if matchErr != nil {
+					return false
+				}
+				switch n := n.(type) {
+				case *ast.ImportSpec:
+					return false
+				case *ast.Field:
+					if n.Tag != nil {
+						tags[n.Tag] = true
+					}
+				case *ast.BasicLit:
+					if n.Kind == token.STRING && !tags[n] {
+						var fileRefs []TextualReference
+						fileRefs, matchErr = match(pgf, n)
+						refs = append(refs, fileRefs...)
+					}
+				}
+				return true
+			})
+			if matchErr != nil {
+				return nil, matchErr
+			}
+		}
+	}
+	return refs, nil
+}
+
+// sortTextualReferences sorts the references by location.
+func sortTextualReferences(refs []TextualReference) {
+	sort.Slice(refs, func(i, j int) bool {
+		return protocol.CompareLocation(refs[i].Location, refs[j].Location) < 0
+	})
+}
@@ -59,11 +59,14 @@ import (
 	"golang.org/x/tools/go/ast/astutil"
 	"golang.org/x/tools/go/types/objectpath"
 	"golang.org/x/tools/go/types/typeutil"
+	"golang.org/x/tools/gopls/internal/analysis/generatedirective"
+	"golang.org/x/tools/gopls/internal/analysis/naming"
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/cache/metadata"
 	"golang.org/x/tools/gopls/internal/cache/parsego"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/settings"
 	"golang.org/x/tools/gopls/internal/util/bug"
 	"golang.org/x/tools/gopls/internal/util/safetoken"
 	"golang.org/x/tools/internal/aliases"
@@ -127,6 +130,16 @@ func PrepareRename(ctx context.Context, snapshot *cache.Snapshot, f file.Handle,
 	if err != nil {
 		return nil, nil, err
 	}
+	// A type named in a go:generate directive is renamed at its declaration.
+	var generateRng *protocol.Range
+	if ref, declPGF, declPos, ok := generateRefDecl(pkg, pgf, pos); ok {
+		rng, err := pgf.PosRange(ref.Pos, ref.End)
+		if err != nil {
+			return nil, nil, err
+		}
+		generateRng = &rng
+		pgf, pos = declPGF, declPos
+	}
 	targets, node, err := objectsAt(pkg.TypesInfo(), pgf.File, pos)
 	if err != nil {
 		return nil, nil, err
@@ -142,6 +155,9 @@ func PrepareRename(ctx context.Context, snapshot *cache.Snapshot, f file.Handle,
 	if err != nil {
 		return nil, nil, err
 	}
+	if generateRng != nil {
+		rng = *generateRng
+	}
 	if _, isImport := node.(*ast.ImportSpec); isImport {
 		// We're not really renaming the import path.
 		rng.End = rng.Start
@@ -213,6 +229,50 @@ func checkRenamable(obj types.Object) error {
 	return nil
 }
 
+// RenameToConvention renames the declaration whose name is at rng to
+// the name that follows Go naming conventions, as suggested by the
+// naming analyzer, returning the resulting changes.
+func RenameToConvention(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]protocol.DocumentChange, error) {
+	pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	enclosing, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	id, ok := enclosing[0].(*ast.Ident)
+	if !ok {
+		return nil, fmt.Errorf("no identifier at %v", rng)
+	}
+
+	// The conventions depend on the flags of the analyzer.
+	release, err := settings.UseAnalyzerFlags(snapshot.Options().AnalyzerFlags)
+	if err != nil {
+		return nil, err
+	}
+	newName := naming.Conventional(id.Name)
+	release()
+	if newName == id.Name {
+		return nil, fmt.Errorf("%s already follows the naming conventions", id.Name)
+	}
+
+	edits, _, err := Rename(ctx, snapshot, fh, rng.Start, newName)
+	if err != nil {
+		return nil, err
+	}
+	var changes []protocol.DocumentChange
+	for uri, e := range edits {
+		fh, err := snapshot.ReadFile(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, protocol.DocumentChangeEdit(fh, e))
+	}
+	return changes, nil
+}
+
 // Rename returns a map of TextEdits for each file modified when renaming a
 // given identifier within a package and a boolean value of true for renaming
 // package and false otherwise.
@@ -240,6 +300,19 @@ func Rename(ctx context.Context, snapshot *cache.Snapshot, f file.Handle, pp pro
 		return nil, false, err
 	}
 
+	// With the registrationReferences option, renaming a method
+	// of a net/rpc service also renames it in the strings by
+	// which clients call it.
+	if !inPackageName && snapshot.Options().RegistrationReferences {
+		rpcEdits, err := registrationRenameEdits(ctx, snapshot, f, pp, newName)
+		if err != nil {
+			return nil, false, err
+		}
+		for uri, edits := range rpcEdits {
+			editMap[uri] = append(editMap[uri], edits...)
+		}
+	}
+
 	// Convert edits to protocol form.
 	result := make(map[protocol.DocumentURI][]protocol.TextEdit)
 	for uri, edits := range editMap {
@@ -323,6 +396,9 @@ func renameOrdinary(ctx context.Context, snapshot *cache.Snapshot, f file.Handle
 		if err != nil {
 			return nil, err
 		}
+		if _, declPGF, declPos, ok := generateRefDecl(pkg, pgf, pos); ok {
+			pgf, pos = declPGF, declPos
+		}
 		objects, _, err := objectsAt(pkg.TypesInfo(), pgf.File, pos)
 		if err != nil {
 			return nil, err
@@ -1132,6 +1208,22 @@ func (r *renamer) update() (map[protocol.DocumentURI][]diff.Edit, error) {
 		result[uri] = append(result[uri], edits...)
 	}
 
+	// Update the names of renamed types in go:generate directives.
+	for _, pgf := range r.pkg.CompiledGoFiles() {
+		for _, ref := range generatedirective.Refs(pgf.File) {
+			if ref.IsPackage {
+				continue
+			}
+			if obj := generateRefType(r.pkg, ref); obj != nil && r.objsToUpdate[obj] {
+				edit, err := posEdit(pgf.Tok, ref.Pos, ref.End, r.to)
+				if err != nil {
+					return nil, err
+				}
+				result[pgf.URI] = append(result[pgf.URI], edit)
+			}
+		}
+	}
+
 	return result, nil
 }
 
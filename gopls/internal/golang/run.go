@@ -0,0 +1,111 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"encoding/json"
+	"go/ast"
+	"path/filepath"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/filecache"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/internal/event"
+)
+
+// runMainCodeLens returns code lenses on the main function of a file of
+// a main package that run the package, or only the file. A standalone
+// file, such as one in a scratch directory, can only be run alone.
+func runMainCodeLens(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]protocol.CodeLens, error) {
+	pgf, err := snapshot.ParseGo(ctx, fh, parsego.Header)
+	if err != nil || pgf.File.Name.Name != "main" {
+		return nil, err
+	}
+	pgf, err = snapshot.ParseGo(ctx, fh, parsego.Full)
+	if err != nil {
+		return nil, err
+	}
+	for _, decl := range pgf.File.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Name.Name != "main" || fn.Type.TypeParams != nil {
+			continue
+		}
+		rng, err := pgf.PosRange(fn.Pos(), fn.Pos())
+		if err != nil {
+			return nil, err
+		}
+		var lenses []protocol.CodeLens
+		if mp, err := NarrowestMetadataForFile(ctx, snapshot, fh.URI()); err != nil || !mp.Standalone {
+			runPackage, err := command.NewRunMainCommand("run main package", command.RunMainArgs{URI: fh.URI()})
+			if err != nil {
+				return nil, err
+			}
+			lenses = append(lenses, protocol.CodeLens{Range: rng, Command: &runPackage})
+		}
+		runFile, err := command.NewRunMainCommand("run file", command.RunMainArgs{URI: fh.URI(), File: true})
+		if err != nil {
+			return nil, err
+		}
+		return append(lenses, protocol.CodeLens{Range: rng, Command: &runFile}), nil
+	}
+	return nil, nil
+}
+
+// A RunConfig holds the command-line arguments and additional
+// environment variables with which a main package is run.
+type RunConfig struct {
+	Args []string
+	Env  []string
+}
+
+// runConfigKey returns the file cache key of the run configuration of
+// the main package in the directory of the specified file, or if
+// alone, of the file itself.
+func runConfigKey(uri protocol.DocumentURI, alone bool) [32]byte {
+	if alone {
+		return file.HashOf([]byte(uri.Path()))
+	}
+	return file.HashOf([]byte(filepath.Dir(uri.Path())))
+}
+
+const runConfigKind = "runconfig"
+
+// LoadRunConfig returns the configuration of the previous run of the
+// main package of the specified file, or if alone, of the file itself,
+// or the zero configuration if there is none. Configurations are
+// stored in the file cache, so they persist across sessions but may be
+// evicted at any time.
+func LoadRunConfig(ctx context.Context, uri protocol.DocumentURI, alone bool) RunConfig {
+	var config RunConfig
+	data, err := filecache.Get(runConfigKind, runConfigKey(uri, alone))
+	if err != nil {
+		if err != filecache.ErrNotFound {
+			event.Error(ctx, "internal error reading run configuration", err)
+		}
+		return config
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		event.Error(ctx, "internal error decoding run configuration", err)
+	}
+	return config
+}
+
+// SaveRunConfig records the configuration of a run of the main package
+// of the specified file, or if alone, of the file itself, for use by
+// [LoadRunConfig].
+func SaveRunConfig(ctx context.Context, uri protocol.DocumentURI, alone bool, config RunConfig) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		event.Error(ctx, "internal error encoding run configuration", err)
+		return
+	}
+	if err := filecache.Set(runConfigKind, runConfigKey(uri, alone), data); err != nil {
+		event.Error(ctx, "internal error updating run configuration", err)
+	}
+}
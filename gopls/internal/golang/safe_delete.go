@@ -0,0 +1,155 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the "safe delete" refactoring, which deletes an
+// unreferenced top-level declaration.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/diff"
+)
+
+// maxDeleteConflicts is the maximum number of references listed in
+// the error reported when a declaration cannot be deleted.
+const maxDeleteConflicts = 10
+
+// SafeDelete returns the changes that delete the top-level declaration
+// whose name is indicated by rng, along with its doc comment, the
+// methods of a deleted type, and the imports that become unused in
+// the files of the deleted declarations.
+//
+// If any deleted declaration is referenced elsewhere in the workspace,
+// nothing is deleted, and the error lists the conflicting references.
+func SafeDelete(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]protocol.DocumentChange, error) {
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	if perrors, terrors := pkg.ParseErrors(), pkg.TypeErrors(); len(perrors) > 0 || len(terrors) > 0 {
+		return nil, fmt.Errorf("cannot delete declarations of package %s, which has errors", pkg.Types().Name())
+	}
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	d, err := deletableDecl(pkg, pgf, start, end)
+	if err != nil {
+		return nil, err
+	}
+	name := declName(d)
+
+	m := &mover{
+		snapshot: snapshot,
+		pkg:      pkg,
+		dst:      pkg,
+		decls:    []*movedDecl{d},
+		files:    make(map[protocol.DocumentURI]*fileChange),
+	}
+	for _, obj := range d.objects() {
+		if tname, ok := obj.(*types.TypeName); ok {
+			m.decls = append(m.decls, methodsOf(pkg, tname)...)
+		}
+	}
+
+	// Find the references to the declaration that lie outside the
+	// deleted text. (The methods of a deleted type need no check:
+	// they cannot be called without a reference to the type.)
+	objs := d.objects()
+	if fn, ok := d.node.(*ast.FuncDecl); ok && fn.Recv != nil {
+		objs = append(objs, pkg.TypesInfo().Defs[fn.Name])
+	}
+	var conflicts []protocol.Location
+	for _, obj := range objs {
+		pos, err := pgf.Mapper.PosPosition(pgf.Tok, obj.Pos())
+		if err != nil {
+			return nil, err
+		}
+		refs, err := references(ctx, snapshot, fh, pos, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			deleted, err := m.deletes(ref.location)
+			if err != nil {
+				return nil, err
+			}
+			if !deleted {
+				conflicts = append(conflicts, ref.location)
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		var b strings.Builder
+		fmt.Fprintf(&b, "cannot delete %s, which is referenced at:", name)
+		dir := filepath.Dir(pgf.URI.Path())
+		for i, loc := range conflicts {
+			if i == maxDeleteConflicts {
+				fmt.Fprintf(&b, "\n\t(and %d more)", len(conflicts)-i)
+				break
+			}
+			path := loc.URI.Path()
+			if rel, err := filepath.Rel(dir, path); err == nil {
+				path = rel
+			}
+			fmt.Fprintf(&b, "\n\t%s:%d:%d", path, loc.Range.Start.Line+1, loc.Range.Start.Character+1)
+		}
+		return nil, fmt.Errorf("%s", b.String())
+	}
+
+	for _, d := range m.decls {
+		m.file(d.pgf).edits = append(m.file(d.pgf).edits, diff.Edit{Start: d.start, End: d.end})
+	}
+	for _, d := range m.decls {
+		if err := m.removeUnusedImports(d.pkg, d.pgf); err != nil {
+			return nil, err
+		}
+	}
+	return m.changes(ctx)
+}
+
+// deletableDecl returns the top-level declaration whose name encloses
+// the range [start, end), if it may be deleted.
+func deletableDecl(pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*movedDecl, error) {
+	d, err := movableDecl(pkg, pgf, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if fn, ok := d.node.(*ast.FuncDecl); ok && fn.Recv == nil {
+		if name := fn.Name.Name; name == "init" || name == "main" && pkg.Types().Name() == "main" {
+			return nil, fmt.Errorf("cannot delete function %s", name)
+		}
+	}
+	return d, nil
+}
+
+// deletes reports whether loc lies within the text of a declaration
+// being deleted.
+func (m *mover) deletes(loc protocol.Location) (bool, error) {
+	for _, d := range m.decls {
+		if d.pgf.URI != loc.URI {
+			continue
+		}
+		offset, err := d.pgf.Mapper.PositionOffset(loc.Range.Start)
+		if err != nil {
+			return false, err
+		}
+		if d.start <= offset && offset < d.end {
+			return true, nil
+		}
+	}
+	return false, nil
+}
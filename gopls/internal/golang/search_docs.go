@@ -0,0 +1,275 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/internal/event"
+)
+
+// defaultDocMatchLimit is the maximum number of matches of
+// SearchDocs if the request does not specify one.
+const defaultDocMatchLimit = 100
+
+// SearchDocs returns the declarations of the workspace packages of the
+// snapshots, and the exported declarations of their direct
+// dependencies, whose doc comments contain all the words of the
+// query, ignoring case.
+func SearchDocs(ctx context.Context, snapshots []*cache.Snapshot, query string, limit int) (command.SearchDocsResult, error) {
+	ctx, done := event.Start(ctx, "golang.SearchDocs")
+	defer done()
+
+	var result command.SearchDocsResult
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return result, nil
+	}
+	if limit <= 0 {
+		limit = defaultDocMatchLimit
+	}
+
+	// Gather the packages to search, workspace packages first, each
+	// with the snapshot from which to read its files.
+	type searchPkg struct {
+		snapshot  *cache.Snapshot
+		mp        *metadata.Package
+		workspace bool
+	}
+	var pkgs []searchPkg
+	seen := make(map[PackagePath]bool)
+	for _, workspace := range []bool{true, false} {
+		var batch []searchPkg
+		for _, snapshot := range snapshots {
+			mps, err := snapshot.WorkspaceMetadata(ctx)
+			if err != nil {
+				return result, err
+			}
+			for _, mp := range mps {
+				if workspace {
+					batch = append(batch, searchPkg{snapshot, mp, true})
+					continue
+				}
+				for _, id := range mp.DepsByPkgPath {
+					if dep := snapshot.Metadata(id); dep != nil {
+						batch = append(batch, searchPkg{snapshot, dep, false})
+					}
+				}
+			}
+		}
+		sort.SliceStable(batch, func(i, j int) bool {
+			return batch[i].mp.PkgPath < batch[j].mp.PkgPath
+		})
+		for _, p := range batch {
+			// Test variants and packages loaded by several views
+			// have the same files, so search each path once.
+			if !seen[p.mp.PkgPath] {
+				seen[p.mp.PkgPath] = true
+				pkgs = append(pkgs, p)
+			}
+		}
+	}
+
+	for _, p := range pkgs {
+		for _, uri := range p.mp.GoFiles {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			fh, err := p.snapshot.ReadFile(ctx, uri)
+			if err != nil {
+				return result, err
+			}
+			pgf, err := p.snapshot.ParseGo(ctx, fh, parsego.Full)
+			if err != nil {
+				continue // e.g. the file does not exist
+			}
+			matches := searchFileDocs(pgf, string(p.mp.PkgPath), words, p.workspace)
+			if len(result.Matches)+len(matches) > limit {
+				result.Matches = append(result.Matches, matches[:limit-len(result.Matches)]...)
+				result.Truncated = true
+				return result, nil
+			}
+			result.Matches = append(result.Matches, matches...)
+		}
+	}
+	return result, nil
+}
+
+// searchFileDocs returns the declarations of the file whose doc
+// comments contain all the (lower case) words. Unexported
+// declarations are included only if all is set.
+func searchFileDocs(pgf *parsego.File, pkgPath string, words []string, all bool) []command.DocMatch {
+	var matches []command.DocMatch
+	add := func(doc *ast.CommentGroup, name string, kind protocol.SymbolKind, node ast.Node) {
+		if doc == nil {
+			return
+		}
+		snippet, ok := matchDoc(doc.Text(), words)
+		if !ok {
+			return
+		}
+		loc, err := pgf.NodeLocation(node)
+		if err != nil {
+			return // e.g. an invalid node
+		}
+		matches = append(matches, command.DocMatch{
+			PkgPath:  pkgPath,
+			Name:     name,
+			Kind:     kind,
+			Location: loc,
+			Snippet:  snippet,
+		})
+	}
+	visible := func(id *ast.Ident) bool {
+		return all || id.IsExported()
+	}
+
+	add(pgf.File.Doc, "", protocol.Package, pgf.File.Name)
+	for _, decl := range pgf.File.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if !visible(decl.Name) {
+				continue
+			}
+			if decl.Recv == nil {
+				add(decl.Doc, decl.Name.Name, protocol.Function, decl.Name)
+			} else if recv := recvIdent(decl.Recv); recv != nil && visible(recv) {
+				add(decl.Doc, recv.Name+"."+decl.Name.Name, protocol.Method, decl.Name)
+			}
+
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if !visible(spec.Name) {
+						continue
+					}
+					add(specDoc(decl, spec.Doc), spec.Name.Name, typeSpecKind(spec), spec.Name)
+					searchMemberDocs(spec, visible, add)
+
+				case *ast.ValueSpec:
+					kind := protocol.Variable
+					if decl.Tok == token.CONST {
+						kind = protocol.Constant
+					}
+					for _, id := range spec.Names {
+						if visible(id) {
+							add(specDoc(decl, spec.Doc), id.Name, kind, id)
+						}
+					}
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// searchMemberDocs calls add for each visible field or method of the
+// struct or interface type declared by spec.
+func searchMemberDocs(spec *ast.TypeSpec, visible func(*ast.Ident) bool, add func(*ast.CommentGroup, string, protocol.SymbolKind, ast.Node)) {
+	var (
+		fields *ast.FieldList
+		kind   protocol.SymbolKind
+	)
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		fields, kind = t.Fields, protocol.Field
+	case *ast.InterfaceType:
+		fields, kind = t.Methods, protocol.Method
+	default:
+		return
+	}
+	for _, field := range fields.List {
+		for _, id := range field.Names {
+			if visible(id) {
+				add(field.Doc, spec.Name.Name+"."+id.Name, kind, id)
+			}
+		}
+	}
+}
+
+// specDoc returns the doc comment of a spec of decl, which is that of
+// decl itself if the spec is the only one and has none of its own.
+func specDoc(decl *ast.GenDecl, doc *ast.CommentGroup) *ast.CommentGroup {
+	if doc == nil && len(decl.Specs) == 1 {
+		return decl.Doc
+	}
+	return doc
+}
+
+// recvIdent returns the identifier of the named type of a method
+// receiver, or nil if it is ill-formed.
+func recvIdent(recv *ast.FieldList) *ast.Ident {
+	if len(recv.List) == 0 {
+		return nil
+	}
+	expr := recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e
+		default:
+			return nil
+		}
+	}
+}
+
+// typeSpecKind returns the symbol kind of the type declared by spec.
+func typeSpecKind(spec *ast.TypeSpec) protocol.SymbolKind {
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		return protocol.Struct
+	case *ast.InterfaceType:
+		return protocol.Interface
+	}
+	return protocol.Class
+}
+
+// matchDoc reports whether the text of a doc comment contains all
+// the (lower case) words, ignoring case, and if so returns the line
+// that contains the first occurrence of any of them.
+func matchDoc(text string, words []string) (string, bool) {
+	lower := strings.ToLower(text)
+	first := len(lower)
+	for _, word := range words {
+		i := strings.Index(lower, word)
+		if i < 0 {
+			return "", false
+		}
+		if i < first {
+			first = i
+		}
+	}
+	// Lowering the case may change the length of the text, in
+	// which case the line is approximate.
+	if first > len(text) {
+		first = len(text)
+	}
+	start := strings.LastIndexByte(text[:first], '\n') + 1
+	end := strings.IndexByte(text[first:], '\n')
+	if end < 0 {
+		end = len(text)
+	} else {
+		end += first
+	}
+	return strings.TrimSpace(text[start:end]), true
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/event"
+)
+
+// SelectionRanges returns, for each of the given positions in a Go
+// file, the chain of ranges of the syntax that encloses it, innermost
+// first, for the "expand selection" feature of editors.
+//
+// The chain follows the syntax tree rather than brackets: expression,
+// statement, block, function, and file. Each step strictly enlarges
+// the previous one, so nodes with the same extent as their child are
+// omitted. In addition to the nodes themselves, the chain includes the
+// contents of a string literal or comment without their delimiters,
+// the comment group of a comment, a declaration together with its doc
+// comment, and the whole file including its leading comments.
+func SelectionRanges(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, positions []protocol.Position) ([]protocol.SelectionRange, error) {
+	ctx, done := event.Start(ctx, "golang.SelectionRanges")
+	defer done()
+
+	pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]protocol.SelectionRange, len(positions))
+	for i, protocolPos := range positions {
+		pos, err := pgf.PositionPos(protocolPos)
+		if err != nil {
+			return nil, err
+		}
+
+		var spans []span // innermost first
+		add := func(start, end token.Pos) {
+			if n := len(spans); n > 0 {
+				last := spans[n-1]
+				if start > last.start || end < last.end || start == last.start && end == last.end {
+					return // not strictly enclosing
+				}
+			}
+			spans = append(spans, span{start, end})
+		}
+
+		// A position within a comment selects the comment first.
+		if cg, c := enclosingComment(pgf.File, pos); c != nil {
+			if text := c.Text; len(text) >= 4 && text[1] == '*' {
+				add(c.Pos()+2, c.End()-2)
+			} else {
+				add(c.Pos()+2, c.End())
+			}
+			add(c.Pos(), c.End())
+			add(cg.Pos(), cg.End())
+			if decl := docOwner(pgf.File, cg); decl != nil {
+				add(cg.Pos(), decl.End())
+			}
+		}
+
+		path, _ := astutil.PathEnclosingInterval(pgf.File, pos, pos)
+		for _, node := range path {
+			switch node := node.(type) {
+			case *ast.BasicLit:
+				// Select the contents of a string before its quotes.
+				if node.Kind == token.STRING && len(node.Value) >= 2 && node.Pos() < pos && pos < node.End() {
+					add(node.Pos()+1, node.End()-1)
+				}
+			case *ast.File:
+				add(node.Pos(), node.End())
+				// The whole file, including comments outside the declarations.
+				start, end := pgf.Tok.Pos(0), node.End()
+				if n := len(node.Comments); n > 0 && node.Comments[n-1].End() > end {
+					end = node.Comments[n-1].End()
+				}
+				add(start, end)
+				continue
+			}
+			add(node.Pos(), node.End())
+			if doc := declDoc(node); doc != nil {
+				add(doc.Pos(), node.End())
+			}
+		}
+
+		tail := &result[i] // tail of the Parent linked list, built head first
+		for j, s := range spans {
+			rng, err := pgf.PosRange(s.start, s.end)
+			if err != nil {
+				return nil, err
+			}
+
+			// Add span to tail.
+			if j > 0 {
+				tail.Parent = &protocol.SelectionRange{}
+				tail = tail.Parent
+			}
+			tail.Range = rng
+		}
+	}
+	return result, nil
+}
+
+// A span is the extent of a selectable range.
+type span struct{ start, end token.Pos }
+
+// enclosingComment returns the comment that contains pos, and its
+// group, or nil if there is none.
+func enclosingComment(f *ast.File, pos token.Pos) (*ast.CommentGroup, *ast.Comment) {
+	for _, cg := range f.Comments {
+		if cg.Pos() <= pos && pos <= cg.End() {
+			for _, c := range cg.List {
+				if c.Pos() <= pos && pos <= c.End() {
+					return cg, c
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// docOwner returns the node, among the declarations of the file and
+// their specs and fields, whose doc comment is cg, or nil if there is
+// none.
+func docOwner(f *ast.File, cg *ast.CommentGroup) ast.Node {
+	var owner ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		if owner != nil || n == nil || n.End() < cg.Pos() {
+			return false // the owner follows its doc comment
+		}
+		if declDoc(n) == cg {
+			owner = n
+		}
+		return owner == nil
+	})
+	return owner
+}
+
+// declDoc returns the doc comment of a declaration, spec, or field, or
+// nil if it has none.
+func declDoc(n ast.Node) *ast.CommentGroup {
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		return n.Doc
+	case *ast.GenDecl:
+		return n.Doc
+	case *ast.TypeSpec:
+		return n.Doc
+	case *ast.ValueSpec:
+		return n.Doc
+	case *ast.Field:
+		return n.Doc
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/gopls/internal/spelling"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/event"
+)
+
+// SpellingDiagnostics reports common misspellings in the comments and
+// string literals of the open Go files of the snapshot, if the
+// spelling setting is enabled.
+//
+// Unlike analysis diagnostics, these do not depend on type information,
+// so they are computed directly from the syntax of each file.
+func SpellingDiagnostics(ctx context.Context, snapshot *cache.Snapshot) (map[protocol.DocumentURI][]*cache.Diagnostic, error) {
+	if !snapshot.Options().Spelling {
+		return nil, nil
+	}
+	dict := readDictionary(ctx, snapshot)
+	reports := make(map[protocol.DocumentURI][]*cache.Diagnostic)
+	for _, fh := range snapshot.Overlays() {
+		if snapshot.FileKind(fh) != file.Go || IsGenerated(ctx, snapshot, fh.URI()) {
+			continue
+		}
+		pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+		if err != nil {
+			return nil, err
+		}
+		diags, err := spellingDiagnostics(pgf, dict)
+		if err != nil {
+			return nil, err
+		}
+		reports[fh.URI()] = diags
+	}
+	return reports, nil
+}
+
+// readDictionary reads the spelling dictionary. The file is read
+// directly, not through the snapshot, since it is typically updated by
+// the AddToDictionary command, and editors may not report changes to
+// it. A missing file accepts no words.
+func readDictionary(ctx context.Context, snapshot *cache.Snapshot) spelling.Dictionary {
+	data, err := os.ReadFile(snapshot.SpellingDictionaryFile())
+	if err != nil && !os.IsNotExist(err) {
+		event.Log(ctx, fmt.Sprintf("reading spelling dictionary: %v", err))
+	}
+	return spelling.ParseDictionary(data)
+}
+
+// directiveRx matches a comment that is a directive, such as
+// "//go:build" or "//lint:ignore".
+var directiveRx = regexp.MustCompile(`^//[a-z0-9]+:`)
+
+// spellingDiagnostics returns the diagnostics for the misspellings in
+// the comments and string literals of a file.
+func spellingDiagnostics(pgf *parsego.File, dict spelling.Dictionary) ([]*cache.Diagnostic, error) {
+	var diags []*cache.Diagnostic
+	check := func(pos token.Pos, text string) error {
+		base, err := safetoken.Offset(pgf.Tok, pos)
+		if err != nil {
+			return err
+		}
+		for _, m := range spelling.Check(text, dict) {
+			diag, err := spellingDiagnostic(pgf, base+m.Offset, m)
+			if err != nil {
+				return err
+			}
+			diags = append(diags, diag)
+		}
+		return nil
+	}
+
+	for _, group := range pgf.File.Comments {
+		for _, c := range group.List {
+			if directiveRx.MatchString(c.Text) || strings.HasPrefix(c.Text, "//\t") {
+				continue // directive or indented code
+			}
+			if err := check(c.Slash, c.Text); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Check string literals that look like prose (they contain a
+	// space), other than import paths and struct tags.
+	tags := make(map[*ast.BasicLit]bool)
+	var err error
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ImportSpec:
+			return false
+		case *ast.Field:
+			if n.Tag != nil {
+				tags[n.Tag] = true
+			}
+		case *ast.BasicLit:
+			if n.Kind == token.STRING && !tags[n] && strings.Contains(n.Value, " ") {
+				err = check(n.Pos(), n.Value)
+			}
+		}
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return diags, nil
+}
+
+func spellingDiagnostic(pgf *parsego.File, offset int, m spelling.Misspelling) (*cache.Diagnostic, error) {
+	rng, err := pgf.Mapper.OffsetRange(offset, offset+len(m.Word))
+	if err != nil {
+		return nil, err
+	}
+	add, err := command.NewAddToDictionaryCommand(fmt.Sprintf("Add %q to dictionary", m.Word), command.AddToDictionaryArgs{
+		URI:  pgf.URI,
+		Word: m.Word,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cache.Diagnostic{
+		URI:      pgf.URI,
+		Range:    rng,
+		Severity: protocol.SeverityInformation,
+		Source:   cache.Spelling,
+		Message:  fmt.Sprintf("%q is a misspelling of %q", m.Word, m.Correction),
+		SuggestedFixes: []cache.SuggestedFix{
+			{
+				Title: fmt.Sprintf("Replace with %q", m.Correction),
+				Edits: map[protocol.DocumentURI][]protocol.TextEdit{
+					pgf.URI: {{Range: rng, NewText: m.Correction}},
+				},
+				ActionKind: protocol.QuickFix,
+			},
+			cache.SuggestedFixFromCommand(add, protocol.QuickFix),
+		},
+	}, nil
+}
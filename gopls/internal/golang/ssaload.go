@@ -0,0 +1,114 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the loading of packages for the whole-program
+// analyses (deep analysis, dead code, and pointer queries), which
+// need the syntax and types of all dependencies to build SSA, and so
+// can't use the snapshot's own type-checked packages.
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
+	"golang.org/x/tools/internal/gocommand"
+	"golang.org/x/tools/internal/packagesinternal"
+)
+
+// loadSyntax loads the packages matching patterns, and their tests if
+// requested, along with the syntax and types of all their
+// dependencies, as required to build SSA. The go command runs in dir.
+//
+// The load uses the environment and build flags of the snapshot's
+// view, and the contents of its unsaved overlays, so that the
+// packages agree with the snapshot's own view of the workspace.
+//
+// It returns an error if any package has errors.
+func loadSyntax(ctx context.Context, snapshot *cache.Snapshot, dir string, tests bool, patterns ...string) ([]*packages.Package, error) {
+	inv, cleanupInvocation, err := snapshot.GoCommandInvocation(false, &gocommand.Invocation{
+		WorkingDir: dir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupInvocation()
+
+	overlay := make(map[string][]byte)
+	for _, fh := range snapshot.Overlays() {
+		if fh.SameContentsOnDisk() {
+			continue
+		}
+		content, err := fh.Content()
+		if err != nil {
+			return nil, err
+		}
+		overlay[fh.URI().Path()] = content
+	}
+
+	cfg := &packages.Config{
+		Context:    ctx,
+		Mode:       packages.LoadAllSyntax,
+		Dir:        inv.WorkingDir,
+		Env:        inv.Env,
+		BuildFlags: inv.BuildFlags,
+		Overlay:    overlay,
+		Tests:      tests,
+	}
+	packagesinternal.SetModFile(cfg, inv.ModFile)
+	packagesinternal.SetModFlag(cfg, inv.ModFlag)
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	// SSA construction requires well-typed packages.
+	var loadErr error
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if loadErr == nil && len(pkg.Errors) > 0 {
+			loadErr = fmt.Errorf("package %s has errors: %v", pkg.PkgPath, pkg.Errors[0])
+		}
+	})
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	return pkgs, nil
+}
+
+// loadWorkspace loads the non-test workspace packages, and their tests
+// if requested, using loadSyntax. It also returns the set of paths of
+// workspace packages, including test packages. It returns no packages
+// if the workspace has none.
+func loadWorkspace(ctx context.Context, snapshot *cache.Snapshot, tests bool) ([]*packages.Package, map[string]bool, error) {
+	mps, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		workspace = make(map[string]bool) // paths of workspace packages, including test packages
+		patterns  []string                // paths of non-test workspace packages
+	)
+	for _, mp := range mps {
+		if metadata.IsCommandLineArguments(mp.ID) {
+			continue
+		}
+		workspace[string(mp.PkgPath)] = true
+		if mp.ForTest == "" {
+			patterns = append(patterns, string(mp.PkgPath))
+		}
+	}
+	if len(patterns) == 0 {
+		return nil, workspace, nil
+	}
+	sort.Strings(patterns)
+
+	initial, err := loadSyntax(ctx, snapshot, snapshot.View().Root().Path(), tests, patterns...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return initial, workspace, nil
+}
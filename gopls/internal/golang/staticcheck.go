@@ -0,0 +1,60 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/internal/event"
+)
+
+// A staticcheckConfig memoizes the set of staticcheck checks enabled by
+// the staticcheck.conf files that apply to each directory, so that
+// gopls reports the same staticcheck diagnostics as cmd/staticcheck.
+type staticcheckConfig struct {
+	ctx    context.Context
+	checks map[string]map[string]bool // nil for a directory whose configuration is invalid
+}
+
+func newStaticcheckConfig(ctx context.Context) *staticcheckConfig {
+	return &staticcheckConfig{ctx: ctx, checks: make(map[string]map[string]bool)}
+}
+
+// enabled reports whether the named check is enabled for packages in
+// dir. An invalid configuration file is logged and ignored.
+func (c *staticcheckConfig) enabled(dir, check string) bool {
+	checks, ok := c.checks[dir]
+	if !ok {
+		var err error
+		checks, err = settings.StaticcheckChecks(dir)
+		if err != nil {
+			event.Error(c.ctx, "loading staticcheck.conf", err)
+		}
+		c.checks[dir] = checks
+	}
+	if checks == nil {
+		return settings.StaticcheckAnalyzers[check].EnabledByDefault()
+	}
+	return checks[check]
+}
+
+// filter returns the diagnostics less those of staticcheck analyzers
+// that the configuration disables for the directory of their file,
+// unless the user's analyses setting enables the analyzer explicitly.
+func (c *staticcheckConfig) filter(opts *settings.Options, diags []*cache.Diagnostic) []*cache.Diagnostic {
+	var result []*cache.Diagnostic
+	for _, diag := range diags {
+		name := string(diag.Source)
+		if _, ok := settings.StaticcheckAnalyzers[name]; ok {
+			if _, explicit := opts.Analyses[name]; !explicit && !c.enabled(diag.URI.Dir().Path(), name) {
+				continue
+			}
+		}
+		result = append(result, diag)
+	}
+	return result
+}
@@ -0,0 +1,784 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the code actions that convert a string
+// concatenation to a call to fmt.Sprintf and back, and that replace
+// the repeated concatenation of a string in a loop by the use of a
+// strings.Builder.
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/types/typeutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+)
+
+// -- concatenation to fmt.Sprintf --
+
+// canConvertConcatToSprintf reports whether the range [start, end) is
+// within a non-constant string concatenation that may be converted to
+// a call to fmt.Sprintf, and returns the outermost concatenation and
+// its operands.
+func canConvertConcatToSprintf(file *ast.File, info *types.Info, start, end token.Pos) (*ast.BinaryExpr, []ast.Expr, bool) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	var concat *ast.BinaryExpr
+	for _, n := range path {
+		if bin, ok := n.(*ast.BinaryExpr); ok && isConcat(info, bin) {
+			concat = bin
+		} else if concat != nil && !is[*ast.ParenExpr](n) {
+			break
+		}
+	}
+	if concat == nil || info.Types[concat].Value != nil {
+		return nil, nil, false // not a concatenation, or a constant one
+	}
+	if !types.Identical(info.TypeOf(concat), types.Typ[types.String]) {
+		return nil, nil, false // fmt.Sprintf would change the type
+	}
+	operands := concatOperands(info, concat)
+	for _, x := range operands {
+		if lit, ok := x.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			return concat, operands, true
+		}
+	}
+	return nil, nil, false // no literal text to use as a format
+}
+
+// isConcat reports whether e is a string concatenation.
+func isConcat(info *types.Info, e *ast.BinaryExpr) bool {
+	t := info.TypeOf(e)
+	if e.Op != token.ADD || t == nil {
+		return false
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
+
+// concatOperands returns the operands of the string concatenation e,
+// flattening nested concatenations.
+func concatOperands(info *types.Info, e ast.Expr) []ast.Expr {
+	if bin, ok := astutil.Unparen(e).(*ast.BinaryExpr); ok && isConcat(info, bin) {
+		return append(concatOperands(info, bin.X), concatOperands(info, bin.Y)...)
+	}
+	return []ast.Expr{e}
+}
+
+// concatToSprintf is a fixer that converts a string concatenation to
+// a call to fmt.Sprintf, whose format consists of the literal
+// operands and a verb for each other one.
+func concatToSprintf(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+	info := pkg.TypesInfo()
+	concat, operands, ok := canConvertConcatToSprintf(pgf.File, info, start, end)
+	if !ok {
+		return nil, nil, fmt.Errorf("no string concatenation to convert")
+	}
+	text := func(e ast.Expr) (string, error) {
+		start, end, err := safetoken.Offsets(pgf.Tok, e.Pos(), e.End())
+		if err != nil {
+			return "", err
+		}
+		return string(pgf.Src[start:end]), nil
+	}
+
+	var (
+		format strings.Builder
+		args   []string
+		raw    = true // all literal operands are raw strings
+	)
+	for _, x := range operands {
+		if lit, ok := x.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			s := constant.StringVal(constant.MakeFromLiteral(lit.Value, lit.Kind, 0))
+			format.WriteString(strings.ReplaceAll(s, "%", "%%"))
+			raw = raw && strings.HasPrefix(lit.Value, "`")
+			continue
+		}
+		verb, arg := sprintfVerb(info, x)
+		s, err := text(arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		format.WriteString("%" + verb)
+		args = append(args, s)
+	}
+
+	adder := newImportAdder(snapshot, pkg, pgf)
+	sprintf, err := adder.member(concat.Pos(), "fmt", "Sprintf")
+	if err != nil {
+		return nil, nil, err
+	}
+	quoted := strconv.Quote(format.String())
+	if raw && !strings.Contains(format.String(), "`") {
+		quoted = "`" + format.String() + "`"
+	}
+	call := fmt.Sprintf("%s(%s, %s)", sprintf, quoted, strings.Join(args, ", "))
+
+	edits, err := adder.edits(snapshot, pgf)
+	if err != nil {
+		return nil, nil, err
+	}
+	edits = append(edits, analysis.TextEdit{Pos: concat.Pos(), End: concat.End(), NewText: []byte(call)})
+	return pkg.FileSet(), &analysis.SuggestedFix{TextEdits: edits}, nil
+}
+
+// sprintfVerb returns the fmt.Sprintf verb that formats the string
+// operand x of a concatenation, and the argument to format: the value
+// formatted by a call to strconv.Itoa, strconv.FormatBool,
+// strconv.Quote, fmt.Sprint, or a conversion of a rune to a string,
+// and otherwise x itself.
+func sprintfVerb(info *types.Info, x ast.Expr) (string, ast.Expr) {
+	call, ok := astutil.Unparen(x).(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 || call.Ellipsis.IsValid() {
+		return "s", x
+	}
+	arg := call.Args[0]
+	if tv, ok := info.Types[call.Fun]; ok && tv.IsType() {
+		if isRune(info.TypeOf(arg)) {
+			return "c", arg // string(r)
+		}
+		return "s", x
+	}
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	if !ok || fn.Pkg() == nil {
+		return "s", x
+	}
+	switch fn.Pkg().Path() + "." + fn.Name() {
+	case "strconv.Itoa":
+		return "d", arg
+	case "strconv.FormatInt", "strconv.FormatUint":
+		if len(call.Args) == 2 {
+			if base := info.Types[call.Args[1]].Value; base != nil && base.String() == "10" {
+				return "d", arg
+			}
+		}
+	case "strconv.FormatBool":
+		return "t", arg
+	case "strconv.Quote":
+		return "q", arg
+	case "fmt.Sprint":
+		if len(call.Args) == 1 {
+			return "v", arg
+		}
+	}
+	return "s", x
+}
+
+// -- fmt.Sprintf to concatenation --
+
+// canConvertSprintfToConcat reports whether the range [start, end)
+// is within a call to fmt.Sprintf that may be converted to a string
+// concatenation, and returns the call and the operands of the
+// concatenation, each either a string (literal text) or the argument
+// formatted by the verb.
+func canConvertSprintfToConcat(file *ast.File, info *types.Info, start, end token.Pos) (*ast.CallExpr, []any, bool) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	for _, n := range path {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		fn, ok := typeutil.Callee(info, call).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "fmt" || fn.Name() != "Sprintf" {
+			continue
+		}
+		operands, ok := sprintfOperands(info, call)
+		return call, operands, ok
+	}
+	return nil, nil, false
+}
+
+// A formattedArg is an argument of fmt.Sprintf and its verb.
+type formattedArg struct {
+	verb rune
+	arg  ast.Expr
+}
+
+// sprintfOperands returns the operands of the concatenation
+// equivalent to the call to fmt.Sprintf: a string for each run of
+// literal text of the format, and a formattedArg for each verb. It
+// reports false if the format is not a literal, or uses verbs,
+// flags, or argument types that a concatenation cannot express.
+func sprintfOperands(info *types.Info, call *ast.CallExpr) ([]any, bool) {
+	if len(call.Args) == 0 || call.Ellipsis.IsValid() {
+		return nil, false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil, false
+	}
+	format := constant.StringVal(constant.MakeFromLiteral(lit.Value, lit.Kind, 0))
+	args := call.Args[1:]
+
+	var (
+		operands []any
+		text     strings.Builder
+	)
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			text.WriteByte(format[i])
+			continue
+		}
+		if i+1 == len(format) {
+			return nil, false
+		}
+		i++
+		verb := rune(format[i])
+		if verb == '%' {
+			text.WriteByte('%')
+			continue
+		}
+		if len(args) == 0 || !concatVerb(info.TypeOf(args[0]), verb) {
+			return nil, false
+		}
+		if text.Len() > 0 {
+			operands = append(operands, text.String())
+			text.Reset()
+		}
+		operands = append(operands, formattedArg{verb, args[0]})
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		return nil, false // extra arguments
+	}
+	if text.Len() > 0 || len(operands) == 0 {
+		operands = append(operands, text.String())
+	}
+	return operands, true
+}
+
+// concatVerb reports whether the formatting of a value of type t by
+// the verb can be expressed without fmt.
+func concatVerb(t types.Type, verb rune) bool {
+	if t == nil {
+		return false
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok || hasStringMethod(t) {
+		return false
+	}
+	switch verb {
+	case 's', 'v':
+		return basic.Info()&types.IsString != 0 || verb == 'v' && basic.Info()&types.IsBoolean != 0
+	case 'q':
+		return basic.Info()&types.IsString != 0
+	case 'd':
+		return basic.Info()&types.IsInteger != 0
+	case 't':
+		return basic.Info()&types.IsBoolean != 0
+	case 'c':
+		return basic.Info()&types.IsInteger != 0
+	}
+	return false
+}
+
+// sprintfToConcat is a fixer that converts a call to fmt.Sprintf to a
+// string concatenation, formatting numbers, booleans, and quoted
+// strings with the strconv package.
+func sprintfToConcat(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+	info := pkg.TypesInfo()
+	call, operands, ok := canConvertSprintfToConcat(pgf.File, info, start, end)
+	if !ok {
+		return nil, nil, fmt.Errorf("no call to fmt.Sprintf to convert")
+	}
+	text := func(e ast.Expr) (string, error) {
+		start, end, err := safetoken.Offsets(pgf.Tok, e.Pos(), e.End())
+		if err != nil {
+			return "", err
+		}
+		return string(pgf.Src[start:end]), nil
+	}
+	raw := strings.HasPrefix(call.Args[0].(*ast.BasicLit).Value, "`")
+
+	// Delete the import of fmt if the call is its only use.
+	adder := newImportAdder(snapshot, pkg, pgf)
+	if spec := soleImportUse(pgf.File, info, call.Fun); spec != nil {
+		adder.remove(spec)
+	}
+	strconvFunc := func(name, arg string) (string, error) {
+		fn, err := adder.member(call.Pos(), "strconv", name)
+		if err != nil {
+			return "", err
+		}
+		return fn + "(" + arg + ")", nil
+	}
+
+	var parts []string
+	for _, op := range operands {
+		if s, ok := op.(string); ok {
+			if raw && !strings.Contains(s, "`") {
+				parts = append(parts, "`"+s+"`")
+			} else {
+				parts = append(parts, strconv.Quote(s))
+			}
+			continue
+		}
+		op := op.(formattedArg)
+		arg, err := text(op.arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		t := info.TypeOf(op.arg)
+		basic := t.Underlying().(*types.Basic)
+		switch op.verb {
+		case 's', 'v':
+			if basic.Info()&types.IsBoolean != 0 {
+				arg, err = strconvFunc("FormatBool", asType(arg, t, "bool"))
+			} else {
+				arg = asType(arg, t, "string")
+			}
+		case 'q':
+			arg, err = strconvFunc("Quote", asType(arg, t, "string"))
+		case 't':
+			arg, err = strconvFunc("FormatBool", asType(arg, t, "bool"))
+		case 'd':
+			switch {
+			case types.Identical(t, types.Typ[types.Int]) || basic.Kind() == types.UntypedInt:
+				arg, err = strconvFunc("Itoa", arg)
+			case basic.Info()&types.IsUnsigned != 0:
+				arg, err = strconvFunc("FormatUint", asType(arg, t, "uint64")+", 10")
+			default:
+				arg, err = strconvFunc("FormatInt", asType(arg, t, "int64")+", 10")
+			}
+		case 'c':
+			arg = "string(" + asType(arg, t, "rune") + ")"
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		parts = append(parts, arg)
+	}
+	concat := strings.Join(parts, " + ")
+
+	edits, err := adder.edits(snapshot, pgf)
+	if err != nil {
+		return nil, nil, err
+	}
+	edits = append(edits, analysis.TextEdit{Pos: call.Pos(), End: call.End(), NewText: []byte(concat)})
+	return pkg.FileSet(), &analysis.SuggestedFix{TextEdits: edits}, nil
+}
+
+// asType returns the expression x of type t, converted to the named
+// basic type if t is not already that type.
+func asType(x string, t types.Type, name string) string {
+	if basic, ok := t.(*types.Basic); ok && basic.Name() == name {
+		return x
+	}
+	return name + "(" + x + ")"
+}
+
+// soleImportUse returns the import spec of the file for the package
+// qualifier of the selector expression fun, if the file makes no
+// other reference to that import.
+func soleImportUse(file *ast.File, info *types.Info, fun ast.Expr) *ast.ImportSpec {
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	pkgName, ok := info.Uses[id].(*types.PkgName)
+	if !ok {
+		return nil
+	}
+	sole := true
+	ast.Inspect(file, func(n ast.Node) bool {
+		if other, ok := n.(*ast.Ident); ok && other != id && info.Uses[other] == pkgName {
+			sole = false
+		}
+		return sole
+	})
+	if !sole {
+		return nil
+	}
+	for _, spec := range file.Imports {
+		if info.Defs[spec.Name] == pkgName || info.Implicits[spec] == pkgName {
+			return spec
+		}
+	}
+	return nil
+}
+
+// hasStringMethod reports whether the method set of type t, or of a
+// pointer to it, has a String or Error method, which fmt would use to
+// format its values.
+func hasStringMethod(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	for _, name := range []string{"String", "Error"} {
+		if obj, _, _ := types.LookupFieldOrMethod(t, true, nil, name); obj != nil {
+			if _, ok := obj.(*types.Func); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRune reports whether t is an integer type other than byte, whose
+// conversion to a string is the UTF-8 encoding of a rune.
+func isRune(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsInteger != 0 && basic.Kind() != types.Uint8
+}
+
+// -- concatenation in a loop to strings.Builder --
+
+// A builderLoop is a loop that repeatedly appends to a string
+// variable, which a strings.Builder can build more efficiently.
+type builderLoop struct {
+	stmt    ast.Stmt          // the loop, or the labeled statement of a labeled loop
+	v       *types.Var        // the string variable
+	appends []*ast.AssignStmt // the statements that append to v
+	empty   bool              // v is declared empty just before the loop
+}
+
+// canUseStringBuilder reports whether the range [start, end) is
+// within a for or range loop that appends to a local string variable
+// with += (or v = v + ...) and does not otherwise use it, and that
+// may be rewritten to use a strings.Builder.
+func canUseStringBuilder(file *ast.File, info *types.Info, start, end token.Pos) (*builderLoop, bool) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	var (
+		loop     ast.Stmt
+		selected *ast.AssignStmt
+		i        int
+	)
+loop:
+	for i = range path {
+		switch n := path[i].(type) {
+		case *ast.AssignStmt:
+			if selected == nil {
+				selected = n
+			}
+		case *ast.ForStmt, *ast.RangeStmt:
+			loop = n.(ast.Stmt)
+			break loop
+		case *ast.FuncLit, *ast.FuncDecl:
+			break loop
+		}
+	}
+	if loop == nil {
+		return nil, false
+	}
+	var body *ast.BlockStmt
+	switch loop := loop.(type) {
+	case *ast.ForStmt:
+		body = loop.Body
+	case *ast.RangeStmt:
+		body = loop.Body
+	}
+	if selected != nil && !within(body, selected.Pos(), selected.End()) {
+		selected = nil // e.g. the init statement of the loop
+	}
+	if within(body, start, end) && selected == nil {
+		return nil, false // in the body, but not on an assignment
+	}
+
+	// Find the string variable that is appended to.
+	var v *types.Var
+	if selected != nil {
+		v = appendedVar(info, selected)
+		if v == nil {
+			return nil, false
+		}
+	} else {
+		ast.Inspect(body, func(n ast.Node) bool {
+			if assign, ok := n.(*ast.AssignStmt); ok && v == nil {
+				v = appendedVar(info, assign)
+			}
+			_, isLit := n.(*ast.FuncLit)
+			return v == nil && !isLit
+		})
+		if v == nil {
+			return nil, false
+		}
+	}
+
+	// The variable must be local and not captured by a function
+	// literal, so that no other code may observe its value during the
+	// loop.
+	var fn ast.Node
+	for _, n := range path[i+1:] {
+		if is[*ast.FuncDecl](n) || is[*ast.FuncLit](n) {
+			fn = n
+			break
+		}
+	}
+	if fn == nil || !within(fn, v.Pos(), v.Pos()) || !types.Identical(v.Type(), types.Typ[types.String]) {
+		return nil, false
+	}
+	captured := false
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok && lit != fn && usesVar(info, lit.Body, v, nil) {
+			captured = true
+		}
+		return !captured
+	})
+	if captured {
+		return nil, false
+	}
+
+	// Within the loop, the variable may only be appended to, by
+	// statements of a statement list, and the loop must complete
+	// before any other use.
+	bl := &builderLoop{stmt: loop, v: v}
+	ok := true
+	var stack []ast.Node
+	ast.Inspect(loop, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if appendedVar(info, n) == v {
+				switch stack[len(stack)-1].(type) {
+				case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+				default:
+					ok = false // not in a statement list
+				}
+				// The appended value must not depend on v.
+				var self ast.Expr
+				if n.Tok == token.ASSIGN {
+					self = leftmostOperand(info, n.Rhs[0]) // v = v + ...
+				}
+				ok = ok && !usesVar(info, n.Rhs[0], v, self)
+				bl.appends = append(bl.appends, n)
+				return false
+			}
+		case *ast.Ident:
+			if info.Uses[n] == v {
+				ok = false
+			}
+		case *ast.ReturnStmt:
+			ok = false
+		case *ast.BranchStmt:
+			if n.Tok == token.GOTO {
+				ok = false
+			} else if n.Label != nil {
+				label := info.Uses[n.Label]
+				ok = label != nil && within(loop, label.Pos(), label.Pos())
+			}
+		case *ast.FuncLit:
+			return false
+		}
+		stack = append(stack, n)
+		return ok
+	})
+	if !ok || len(bl.appends) == 0 {
+		return nil, false
+	}
+
+	// The loop (or its labeled statement) must be in a statement list.
+	parent := path[i+1]
+	if labeled, ok := parent.(*ast.LabeledStmt); ok {
+		bl.stmt = labeled
+		parent = path[i+2]
+	}
+	var list []ast.Stmt
+	switch parent := parent.(type) {
+	case *ast.BlockStmt:
+		list = parent.List
+	case *ast.CaseClause:
+		list = parent.Body
+	case *ast.CommClause:
+		list = parent.Body
+	default:
+		return nil, false
+	}
+	for j, stmt := range list {
+		if stmt == bl.stmt && j > 0 {
+			bl.empty = declaresEmpty(info, list[j-1], v)
+		}
+	}
+	return bl, true
+}
+
+// appendedVar returns the local string variable to which the
+// statement appends, with v += x or v = v + x, or nil.
+func appendedVar(info *types.Info, assign *ast.AssignStmt) *types.Var {
+	if len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return nil
+	}
+	id, ok := assign.Lhs[0].(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	v, ok := info.Uses[id].(*types.Var)
+	if !ok || !types.Identical(v.Type(), types.Typ[types.String]) {
+		return nil
+	}
+	switch assign.Tok {
+	case token.ADD_ASSIGN:
+		return v
+	case token.ASSIGN:
+		if x, ok := leftmostOperand(info, assign.Rhs[0]).(*ast.Ident); ok && info.Uses[x] == v {
+			return v
+		}
+	}
+	return nil
+}
+
+// usesVar reports whether n refers to v, other than by the
+// identifier except.
+func usesVar(info *types.Info, n ast.Node, v *types.Var, except ast.Expr) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id != except && info.Uses[id] == v {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// leftmostOperand returns the leftmost operand of the string
+// concatenation e, or nil if e is not a concatenation.
+func leftmostOperand(info *types.Info, e ast.Expr) ast.Expr {
+	bin, ok := astutil.Unparen(e).(*ast.BinaryExpr)
+	if !ok || !isConcat(info, bin) {
+		return nil
+	}
+	return concatOperands(info, bin)[0]
+}
+
+// declaresEmpty reports whether stmt declares v with an empty value,
+// as in var v string, var v = "", or v := "".
+func declaresEmpty(info *types.Info, stmt ast.Stmt, v *types.Var) bool {
+	isEmpty := func(e ast.Expr) bool {
+		value := info.Types[e].Value
+		return value != nil && value.Kind() == constant.String && constant.StringVal(value) == ""
+	}
+	switch stmt := stmt.(type) {
+	case *ast.AssignStmt:
+		return stmt.Tok == token.DEFINE && len(stmt.Lhs) == 1 && len(stmt.Rhs) == 1 &&
+			info.Defs[stmt.Lhs[0].(*ast.Ident)] == v && isEmpty(stmt.Rhs[0])
+	case *ast.DeclStmt:
+		decl := stmt.Decl.(*ast.GenDecl)
+		if decl.Tok != token.VAR || len(decl.Specs) != 1 {
+			return false
+		}
+		spec := decl.Specs[0].(*ast.ValueSpec)
+		return len(spec.Names) == 1 && info.Defs[spec.Names[0]] == v &&
+			(len(spec.Values) == 0 || isEmpty(spec.Values[0]))
+	}
+	return false
+}
+
+// useStringBuilder is a fixer that rewrites a loop that appends to a
+// string variable to write to a strings.Builder instead, and assigns
+// the built string to the variable after the loop.
+func useStringBuilder(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File, start, end token.Pos) (*token.FileSet, *analysis.SuggestedFix, error) {
+	info := pkg.TypesInfo()
+	bl, ok := canUseStringBuilder(pgf.File, info, start, end)
+	if !ok {
+		return nil, nil, fmt.Errorf("no loop that appends to a string")
+	}
+	text := func(e ast.Expr) (string, error) {
+		start, end, err := safetoken.Offsets(pgf.Tok, e.Pos(), e.End())
+		if err != nil {
+			return "", err
+		}
+		return string(pgf.Src[start:end]), nil
+	}
+
+	// Choose a name for the builder that is free at the loop and at
+	// each append.
+	var scopes []*types.Scope
+	for _, n := range append([]ast.Node{bl.stmt}, appendNodes(bl.appends)...) {
+		path, _ := astutil.PathEnclosingInterval(pgf.File, n.Pos(), n.End())
+		scopes = append(scopes, CollectScopes(info, path, n.Pos())...)
+	}
+	scopes = append(scopes, pkg.Types().Scope())
+	name, _ := generateIdentifier(0, "sb", func(name string) bool {
+		for _, scope := range scopes {
+			if scope != nil && scope.Lookup(name) != nil {
+				return true
+			}
+		}
+		return false
+	})
+
+	adder := newImportAdder(snapshot, pkg, pgf)
+	builder, err := adder.member(bl.stmt.Pos(), "strings", "Builder")
+	if err != nil {
+		return nil, nil, err
+	}
+	indent, err := calculateIndentation(pgf.Src, pgf.Tok, bl.stmt)
+	if err != nil {
+		return nil, nil, err
+	}
+	decl := fmt.Sprintf("var %s %s\n%s", name, builder, indent)
+	if !bl.empty {
+		decl += fmt.Sprintf("%s.WriteString(%s)\n%s", name, bl.v.Name(), indent)
+	}
+
+	edits, err := adder.edits(snapshot, pgf)
+	if err != nil {
+		return nil, nil, err
+	}
+	edits = append(edits,
+		analysis.TextEdit{Pos: bl.stmt.Pos(), End: bl.stmt.Pos(), NewText: []byte(decl)},
+		analysis.TextEdit{Pos: bl.stmt.End(), End: bl.stmt.End(), NewText: []byte(fmt.Sprintf("\n%s%s = %s.String()", indent, bl.v.Name(), name))})
+	for _, assign := range bl.appends {
+		operands := concatOperands(info, assign.Rhs[0])
+		if assign.Tok == token.ASSIGN {
+			operands = operands[1:] // v = v + ...
+		}
+		indent, err := calculateIndentation(pgf.Src, pgf.Tok, assign)
+		if err != nil {
+			return nil, nil, err
+		}
+		var writes []string
+		for _, x := range operands {
+			method := "WriteString"
+			if call, ok := astutil.Unparen(x).(*ast.CallExpr); ok && len(call.Args) == 1 {
+				if tv, ok := info.Types[call.Fun]; ok && tv.IsType() && isRune(info.TypeOf(call.Args[0])) {
+					x, method = call.Args[0], "WriteRune" // string(r)
+				}
+			}
+			arg, err := text(x)
+			if err != nil {
+				return nil, nil, err
+			}
+			if method == "WriteRune" {
+				arg = asType(arg, info.TypeOf(x), "rune")
+			}
+			writes = append(writes, fmt.Sprintf("%s.%s(%s)", name, method, arg))
+		}
+		edits = append(edits, analysis.TextEdit{
+			Pos:     assign.Pos(),
+			End:     assign.End(),
+			NewText: []byte(strings.Join(writes, "\n"+indent)),
+		})
+	}
+	return pkg.FileSet(), &analysis.SuggestedFix{TextEdits: edits}, nil
+}
+
+// appendNodes returns the assignments as a list of nodes.
+func appendNodes(appends []*ast.AssignStmt) []ast.Node {
+	nodes := make([]ast.Node, len(appends))
+	for i, assign := range appends {
+		nodes[i] = assign
+	}
+	return nodes
+}
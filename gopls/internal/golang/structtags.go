@@ -0,0 +1,350 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+// This file defines the code actions that add, remove, and
+// synchronize struct tags, such as `json:"name"`, on the fields of a
+// struct type.
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/settings"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/diff"
+)
+
+// getStructTagCodeActions returns the refactor.rewrite actions that
+// add, remove, or synchronize the tags of each key of the
+// structTagKeys setting, on the fields of the struct type at the
+// specified range, or on the selected fields of the struct type.
+// If reformat is set, the declaration of the type is reformatted, to
+// align the tags.
+func getStructTagCodeActions(fh file.Handle, pgf *parsego.File, rng protocol.Range, options *settings.Options, reformat bool) ([]protocol.CodeAction, error) {
+	start, end, err := pgf.RangePos(rng)
+	if err != nil {
+		return nil, err
+	}
+	decl, fields := structFieldsAt(pgf.File, start, end)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	var actions []protocol.CodeAction
+	for _, key := range options.StructTagKeys {
+		for _, op := range []struct {
+			title string
+			edit  func(name string, tags []structTag) ([]structTag, bool)
+		}{
+			{
+				title: fmt.Sprintf("Add %s tags", key),
+				edit: func(name string, tags []structTag) ([]structTag, bool) {
+					if name == "" || !ast.IsExported(name) || lookupTag(tags, key) >= 0 {
+						return nil, false
+					}
+					value := structTagName(name, options.StructTagCase)
+					if options.StructTagOmitEmpty {
+						value += ",omitempty"
+					}
+					return append(tags, structTag{key, value}), true
+				},
+			},
+			{
+				title: fmt.Sprintf("Remove %s tags", key),
+				edit: func(name string, tags []structTag) ([]structTag, bool) {
+					i := lookupTag(tags, key)
+					if i < 0 {
+						return nil, false
+					}
+					return append(tags[:i:i], tags[i+1:]...), true
+				},
+			},
+			{
+				title: fmt.Sprintf("Sync %s tag names with field names", key),
+				edit: func(name string, tags []structTag) ([]structTag, bool) {
+					i := lookupTag(tags, key)
+					if name == "" || i < 0 {
+						return nil, false
+					}
+					tagName, opts, hasOpts := strings.Cut(tags[i].value, ",")
+					value := structTagName(name, options.StructTagCase)
+					if tagName == "-" || tagName == value {
+						return nil, false // special name, or already in sync
+					}
+					if hasOpts {
+						value += "," + opts
+					}
+					tags = append([]structTag(nil), tags...)
+					tags[i].value = value
+					return tags, true
+				},
+			},
+		} {
+			edits, err := structTagEdits(pgf, decl, fields, op.edit, reformat)
+			if err != nil {
+				return nil, err
+			}
+			if len(edits) > 0 {
+				actions = append(actions, protocol.CodeAction{
+					Title: op.title,
+					Kind:  protocol.RefactorRewrite,
+					Edit:  protocol.NewWorkspaceEdit(protocol.DocumentChangeEdit(fh, edits)),
+				})
+			}
+		}
+	}
+	return actions, nil
+}
+
+// structFieldsAt returns the fields of the innermost struct type
+// that encloses the range [start, end), or whose declaration names
+// it, that overlap the range, or all its fields if none do, along
+// with the enclosing top-level declaration.
+func structFieldsAt(file *ast.File, start, end token.Pos) (ast.Decl, []*ast.Field) {
+	path, _ := astutil.PathEnclosingInterval(file, start, end)
+	if len(path) < 2 {
+		return nil, nil
+	}
+	decl, _ := path[len(path)-2].(ast.Decl)
+	var st *ast.StructType
+	for _, n := range path {
+		if n, ok := n.(*ast.StructType); ok {
+			st = n
+			break
+		}
+		if spec, ok := n.(*ast.TypeSpec); ok && spec.Name.Pos() <= start && end <= spec.Name.End() {
+			st, _ = spec.Type.(*ast.StructType)
+			break
+		}
+	}
+	if st == nil || decl == nil {
+		return nil, nil
+	}
+	var fields []*ast.Field
+	for _, field := range st.Fields.List {
+		if field.Pos() <= end && start <= field.End() {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		fields = st.Fields.List
+	}
+	return decl, fields
+}
+
+// structTagEdits returns the edits that change the tags of the fields
+// as specified by the edit function, which is called with the name of
+// each field, or "" if it declares several or none, and its tags, and
+// returns the new tags and whether they differ. The fields must
+// belong to the declaration decl, which is reformatted if reformat is
+// set.
+func structTagEdits(pgf *parsego.File, decl ast.Decl, fields []*ast.Field, edit func(string, []structTag) ([]structTag, bool), reformat bool) ([]protocol.TextEdit, error) {
+	var edits []diff.Edit
+	for _, field := range fields {
+		var tags []structTag
+		if field.Tag != nil {
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+			var ok bool
+			if tags, ok = parseStructTag(tag); !ok {
+				continue // not a conventional tag
+			}
+		}
+		var name string
+		if len(field.Names) == 1 {
+			name = field.Names[0].Name
+		}
+		newTags, ok := edit(name, tags)
+		if !ok {
+			continue
+		}
+		var (
+			start, end token.Pos
+			text       string
+		)
+		switch {
+		case field.Tag == nil:
+			start, end = field.Type.End(), field.Type.End()
+			text = " " + quoteStructTag(newTags)
+		case len(newTags) == 0:
+			start, end = field.Type.End(), field.Tag.End()
+		default:
+			start, end = field.Tag.Pos(), field.Tag.End()
+			text = quoteStructTag(newTags)
+		}
+		startOffset, endOffset, err := safetoken.Offsets(pgf.Tok, start, end)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, diff.Edit{Start: startOffset, End: endOffset, New: text})
+	}
+	if len(edits) == 0 {
+		return nil, nil
+	}
+	if reformat && pgf.ParseErr == nil {
+		// Reformat the declaration, to align the tags.
+		src, err := diff.Apply(string(pgf.Src), edits)
+		if err != nil {
+			return nil, err
+		}
+		if formatted, ok := reformatDecl(pgf.File, decl, src); ok {
+			edits = diff.Strings(string(pgf.Src), formatted)
+		}
+	}
+	return protocol.EditsFromDiffEdits(pgf.Mapper, edits)
+}
+
+// reformatDecl returns src, the edited content of a file, after
+// formatting the edited declaration that corresponds to decl of the
+// original file, and reports whether it succeeded.
+func reformatDecl(file *ast.File, decl ast.Decl, src string) (string, bool) {
+	index := -1
+	for i, d := range file.Decls {
+		if d == decl {
+			index = i
+		}
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil || index < 0 || index >= len(f.Decls) {
+		return "", false
+	}
+	newDecl := f.Decls[index]
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, &printer.CommentedNode{Node: newDecl, Comments: f.Comments}); err != nil {
+		return "", false
+	}
+	tok := fset.File(f.Pos())
+	start, end, err := safetoken.Offsets(tok, newDecl.Pos(), newDecl.End())
+	if err != nil {
+		return "", false
+	}
+	return src[:start] + buf.String() + src[end:], true
+}
+
+// A structTag is a key:"value" pair of a conventional struct tag.
+type structTag struct {
+	key, value string
+}
+
+// lookupTag returns the index of the tag with the specified key,
+// or -1 if there is none.
+func lookupTag(tags []structTag, key string) int {
+	for i, tag := range tags {
+		if tag.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseStructTag parses a conventional struct tag, reporting whether
+// it is well formed. It follows reflect.StructTag.Lookup.
+func parseStructTag(tag string) ([]structTag, bool) {
+	var tags []structTag
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			return tags, true
+		}
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return nil, false
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Scan the quoted value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return nil, false
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			return nil, false
+		}
+		tags = append(tags, structTag{key, value})
+		tag = tag[i+1:]
+	}
+}
+
+// quoteStructTag returns the literal of the struct tag with the
+// specified key:"value" pairs, preferring a raw string literal.
+func quoteStructTag(tags []structTag) string {
+	pairs := make([]string, len(tags))
+	for i, tag := range tags {
+		pairs[i] = tag.key + ":" + strconv.Quote(tag.value)
+	}
+	tag := strings.Join(pairs, " ")
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+	return "`" + tag + "`"
+}
+
+// structTagName returns the name derived from a field name for a
+// struct tag, in the specified case.
+func structTagName(field string, c settings.StructTagCase) string {
+	words := splitWords(field)
+	switch c {
+	case settings.SnakeCase:
+		for i, word := range words {
+			words[i] = strings.ToLower(word)
+		}
+		return strings.Join(words, "_")
+	case settings.CamelCase:
+		if len(words) > 0 {
+			words[0] = strings.ToLower(words[0])
+		}
+		return strings.Join(words, "")
+	}
+	return field
+}
+
+// splitWords splits a mixed-case identifier into its words, so that
+// an initialism is a single word: "HTTPServer" becomes "HTTP" and
+// "Server", and "UserID" becomes "User" and "ID". Underscores
+// separate words too.
+func splitWords(name string) []string {
+	var words []string
+	for _, part := range strings.Split(name, "_") {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			if unicode.IsUpper(runes[i]) && (!unicode.IsUpper(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			words = append(words, string(runes[start:]))
+		}
+	}
+	return words
+}
@@ -70,7 +70,9 @@ func stubMethodsFixer(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.
 	}
 
 	// Find subset of interface methods that the concrete type lacks.
-	ifaceType := si.Interface.Type().Underlying().(*types.Interface)
+	// For an instance of a generic interface, the underlying
+	// interface's methods have the type arguments substituted.
+	ifaceType := si.Interface.Underlying().(*types.Interface)
 
 	type missingFn struct {
 		fn         *types.Func
@@ -192,11 +194,14 @@ func stubMethodsFixer(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.
 		return name
 	}
 
-	// Format interface name (used only in a comment).
-	iface := si.Interface.Name()
-	if ipkg := si.Interface.Pkg(); ipkg != nil && ipkg != conc.Pkg() {
-		iface = ipkg.Name() + "." + iface
-	}
+	// Format interface name (used only in a comment),
+	// including any type arguments.
+	iface := types.TypeString(si.Interface, func(pkg *types.Package) string {
+		if pkg == conc.Pkg() {
+			return ""
+		}
+		return pkg.Name()
+	})
 
 	// Pointer receiver?
 	var star string
@@ -204,11 +209,12 @@ func stubMethodsFixer(ctx context.Context, snapshot *cache.Snapshot, pkg *cache.
 		star = "*"
 	}
 
-	// If there are any that have named receiver, choose the first one.
+	// If there are any that have named receiver, choose the first one,
+	// so that the new methods are consistent with the existing ones.
 	// Otherwise, use lowercase for the first letter of the object.
 	rn := strings.ToLower(si.Concrete.Obj().Name()[0:1])
 	for i := 0; i < si.Concrete.NumMethods(); i++ {
-		if recv := si.Concrete.Method(i).Type().(*types.Signature).Recv(); recv.Name() != "" {
+		if recv := si.Concrete.Method(i).Type().(*types.Signature).Recv(); recv.Name() != "" && recv.Name() != "_" {
 			rn = recv.Name()
 			break
 		}
@@ -10,6 +10,8 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"regexp"
+	"strings"
 
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/cache/parsego"
@@ -71,9 +73,83 @@ func DocumentSymbols(ctx context.Context, snapshot *cache.Snapshot, fh file.Hand
 			}
 		}
 	}
+
+	if pattern := snapshot.Options().SymbolSectionPattern; pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err // can't happen: the setting is validated
+		}
+		symbols, err = sectionSymbols(pgf, re, symbols)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return symbols, nil
 }
 
+// sectionSymbols groups the top-level symbols of a file into the
+// sections begun by the top-level comments that match re (see the
+// symbolSectionPattern setting). It returns the symbols that precede
+// the first section, followed by a symbol for each section whose
+// children are the symbols of the section.
+func sectionSymbols(pgf *parsego.File, re *regexp.Regexp, symbols []protocol.DocumentSymbol) ([]protocol.DocumentSymbol, error) {
+	var sections []protocol.DocumentSymbol
+	for _, cg := range pgf.File.Comments {
+	comments:
+		for _, c := range cg.List {
+			m := re.FindStringSubmatch(c.Text)
+			if m == nil {
+				continue
+			}
+			name := m[0]
+			if len(m) > 1 {
+				name = m[1]
+			}
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			for _, decl := range pgf.File.Decls {
+				if decl.Pos() <= c.Pos() && c.End() <= decl.End() {
+					continue comments // not a top-level comment
+				}
+			}
+			rng, err := pgf.PosRange(c.Pos(), c.End())
+			if err != nil {
+				return nil, err
+			}
+			sections = append(sections, protocol.DocumentSymbol{
+				Name:           name,
+				Kind:           protocol.Namespace,
+				Range:          rng,
+				SelectionRange: rng,
+			})
+		}
+	}
+	if len(sections) == 0 {
+		return symbols, nil
+	}
+
+	var result []protocol.DocumentSymbol
+	for _, sym := range symbols {
+		// Find the last section that begins before the symbol.
+		i := len(sections) - 1
+		for i >= 0 && protocol.ComparePosition(sections[i].Range.Start, sym.Range.Start) > 0 {
+			i--
+		}
+		if i < 0 {
+			result = append(result, sym)
+			continue
+		}
+		section := &sections[i]
+		section.Children = append(section.Children, sym)
+		if protocol.ComparePosition(sym.Range.End, section.Range.End) > 0 {
+			section.Range.End = sym.Range.End
+		}
+	}
+	return append(result, sections...), nil
+}
+
 func funcSymbol(m *protocol.Mapper, tf *token.File, decl *ast.FuncDecl) (protocol.DocumentSymbol, error) {
 	s := protocol.DocumentSymbol{
 		Name: decl.Name.Name,
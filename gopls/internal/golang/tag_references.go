@@ -0,0 +1,292 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/gopls/internal/util/slices"
+	"golang.org/x/tools/internal/event"
+)
+
+// This file defines the textual references to a struct field: the
+// string literals that match the key of the field's json or yaml tag.
+//
+// Encoders that use reflection refer to a field by its key, so a
+// field renamed or removed may break code that no type checker can
+// relate to it, such as a map lookup on decoded data, a test fixture,
+// or a path in a query language. The matches are found by a purely
+// textual search, so some may be coincidental.
+
+// tagKeys lists the struct tag keys whose names are searched for.
+var tagKeys = []string{"json", "yaml"}
+
+// A TextualReference is a string literal that matches the key of the
+// tag of a struct field, or that refers to a function, method, or
+// template through a string-keyed registry (see registrations.go).
+type TextualReference struct {
+	Location   protocol.Location
+	Key        string // the matched key
+	Definition bool   // the reference defines the key, as in {{define "key"}}
+}
+
+// TextualReferences returns the textual references, in order of
+// location, to the struct field denoted by the identifier at the
+// given position, searching the files of all workspace packages. It
+// returns nil if the identifier does not denote a field with a json
+// or yaml tag.
+func TextualReferences(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position) ([]TextualReference, error) {
+	ctx, done := event.Start(ctx, "golang.TextualReferences")
+	defer done()
+
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	pos, err := pgf.PositionPos(pp)
+	if err != nil {
+		return nil, err
+	}
+	candidates, _, err := objectsAt(pkg.TypesInfo(), pgf.File, pos)
+	if err != nil {
+		return nil, err
+	}
+	var field *types.Var
+	for obj := range candidates {
+		if v, ok := obj.(*types.Var); ok && v.IsField() {
+			field = v
+		}
+	}
+	if field == nil {
+		return nil, nil
+	}
+
+	keys, err := fieldTagKeys(ctx, snapshot, pkg.FileSet(), field)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	var matchers []*tagKeyMatcher
+	for _, key := range keys {
+		matchers = append(matchers, newTagKeyMatcher(key))
+	}
+
+	// Search each file of the workspace packages once.
+	// (Test variants share most of their files.)
+	workspace, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[protocol.DocumentURI]bool)
+	var refs []TextualReference
+	for _, mp := range workspace {
+		for _, uri := range mp.CompiledGoFiles {
+			if seen[uri] {
+				continue
+			}
+			seen[uri] = true
+
+			fh, err := snapshot.ReadFile(ctx, uri)
+			if err != nil {
+				return nil, err
+			}
+			content, err := fh.Content()
+			if err != nil {
+				return nil, err
+			}
+			var fileMatchers []*tagKeyMatcher
+			for _, m := range matchers {
+				if bytes.Contains(content, []byte(m.key)) {
+					fileMatchers = append(fileMatchers, m)
+				}
+			}
+			if len(fileMatchers) == 0 {
+				continue // cheap test to avoid parsing
+			}
+			pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+			if err != nil {
+				return nil, err
+			}
+			fileRefs, err := textualReferencesInFile(pgf, fileMatchers)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, fileRefs...)
+		}
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return protocol.CompareLocation(refs[i].Location, refs[j].Location) < 0
+	})
+	return refs, nil
+}
+
+// fieldTagKeys returns the distinct keys of the json and yaml tags of
+// the specified field, omitting those of ignored ("-") fields.
+func fieldTagKeys(ctx context.Context, snapshot *cache.Snapshot, fset *token.FileSet, field *types.Var) ([]string, error) {
+	if field.Pkg() == nil {
+		return nil, nil
+	}
+	// Find the field's declaration in its (full) syntax tree.
+	// The field may belong to a dependency, whose syntax the
+	// type checker discarded.
+	posn := safetoken.StartPosition(fset, field.Pos())
+	fh, err := snapshot.ReadFile(ctx, protocol.URIFromPath(posn.Filename))
+	if err != nil {
+		return nil, err
+	}
+	pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+	if err != nil {
+		return nil, err
+	}
+	pos, err := safetoken.Pos(pgf.Tok, posn.Offset)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, pos, pos)
+	var decl *ast.Field
+	for _, n := range path {
+		if f, ok := n.(*ast.Field); ok {
+			decl = f
+			break
+		}
+	}
+	if decl == nil || decl.Tag == nil {
+		return nil, nil
+	}
+	tag, err := strconv.Unquote(decl.Tag.Value)
+	if err != nil {
+		return nil, nil // malformed tag
+	}
+
+	var keys []string
+	for _, name := range tagKeys {
+		value, ok := reflect.StructTag(tag).Lookup(name)
+		if !ok {
+			continue
+		}
+		key, _, _ := strings.Cut(value, ",")
+		if key != "" && key != "-" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// textualReferencesInFile returns the matches of the keys in the
+// string literals of the file, other than import paths and struct tags.
+func textualReferencesInFile(pgf *parsego.File, matchers []*tagKeyMatcher) ([]TextualReference, error) {
+	var (
+		refs []TextualReference
+		err  error
+		tags = make(map[*ast.BasicLit]bool)
+	)
+	ast.Inspect(pgf.File, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.ImportSpec:
+			return false
+		case *ast.Field:
+			if n.Tag != nil {
+				tags[n.Tag] = true
+			}
+		case *ast.BasicLit:
+			if n.Kind != token.STRING || tags[n] {
+				break
+			}
+			for _, m := range matchers {
+				for _, span := range m.match(n.Value) {
+					var loc protocol.Location
+					loc, err = pgf.PosLocation(n.Pos()+token.Pos(span[0]), n.Pos()+token.Pos(span[1]))
+					if err != nil {
+						return false
+					}
+					refs = append(refs, TextualReference{Location: loc, Key: m.key})
+				}
+			}
+		}
+		return true
+	})
+	return refs, err
+}
+
+// A tagKeyMatcher finds the occurrences of a tag key in string literals.
+type tagKeyMatcher struct {
+	key string
+	// object keys in JSON text (as in {"key": 1}) or
+	// YAML text (as in "key: 1"), as written in Go source.
+	object *regexp.Regexp
+}
+
+func newTagKeyMatcher(key string) *tagKeyMatcher {
+	quoted := regexp.QuoteMeta(key)
+	return &tagKeyMatcher{
+		key: key,
+		object: regexp.MustCompile(
+			`\\?"(` + quoted + `)\\?"[ \t]*:` + // JSON
+				"|(?:^|\n|\\\\n|[`\"])[ \t]*(?:- )?(" + quoted + `)[ \t]*:`), // YAML
+	}
+}
+
+// match returns the [start, end) offsets of the occurrences of the key
+// within lit, the source text of a string literal, in order. The key
+// matches a literal whose value is the key, an element of a dotted
+// path such as ".a[0].key.b", or an object key in JSON or YAML text.
+func (m *tagKeyMatcher) match(lit string) [][2]int {
+	value, err := strconv.Unquote(lit)
+	if err != nil {
+		return nil
+	}
+	if value == m.key {
+		return [][2]int{{1, len(lit) - 1}}
+	}
+
+	var spans [][2]int
+
+	// Elements of a dotted path.
+	// The offsets of value and lit correspond
+	// (after the quote) when there are no escapes.
+	if !strings.Contains(lit, `\`) &&
+		strings.ContainsAny(value, ".[") &&
+		!strings.ContainsAny(value, " \t\n\"'`:") {
+		start := 0
+		for i := 0; i <= len(value); i++ {
+			if i < len(value) && !strings.ContainsRune(".[]", rune(value[i])) {
+				continue
+			}
+			if value[start:i] == m.key {
+				spans = append(spans, [2]int{1 + start, 1 + i})
+			}
+			start = i + 1
+		}
+	}
+
+	// Object keys.
+	for _, sub := range m.object.FindAllStringSubmatchIndex(lit, -1) {
+		for i := 2; i+1 < len(sub); i += 2 {
+			if sub[i] >= 0 {
+				spans = append(spans, [2]int{sub[i], sub[i+1]})
+			}
+		}
+	}
+
+	return spans
+}
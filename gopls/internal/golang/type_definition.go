@@ -47,7 +47,15 @@ func TypeDefinition(ctx context.Context, snapshot *cache.Snapshot, fh file.Handl
 
 	loc, err := mapPosition(ctx, pkg.FileSet(), snapshot, tname.Pos(), tname.Pos()+token.Pos(len(tname.Name())))
 	if err != nil {
-		return nil, err
+		// As for Definition, fall back to a view of the declaring
+		// package synthesized from its type information.
+		if tname.Pkg() == nil || tname.Pkg() == pkg.Types() {
+			return nil, err
+		}
+		loc, err = exportDataLocation(tname)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return []protocol.Location{loc}, nil
 }
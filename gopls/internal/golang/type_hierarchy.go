@@ -0,0 +1,344 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package golang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/parsego"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/aliases"
+	"golang.org/x/tools/internal/event"
+	"golang.org/x/tools/internal/typeparams"
+)
+
+// This file defines the type hierarchy queries.
+//
+// The supertypes of a named type are the interfaces it implements
+// (for a concrete type) and the named types it embeds (for a struct or
+// interface). The subtypes are the reverse: the concrete types that
+// implement an interface, and the structs and interfaces that embed a
+// type. The implements relation is computed by the same local and
+// global (methodsets index) searches as the textDocument/implementation
+// query; embedding is found by a references query.
+
+// PrepareTypeHierarchy returns the TypeHierarchyItem for the named type
+// at the given position, if any.
+func PrepareTypeHierarchy(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position) ([]protocol.TypeHierarchyItem, error) {
+	ctx, done := event.Start(ctx, "golang.PrepareTypeHierarchy")
+	defer done()
+
+	named, pkg, err := typeHierarchyType(ctx, snapshot, fh, pp)
+	if err != nil || named == nil {
+		return nil, err
+	}
+	loc, err := typeDeclLocation(ctx, snapshot, pkg.FileSet(), named.Obj())
+	if err != nil {
+		return nil, err
+	}
+	item, err := typeHierarchyItem(ctx, snapshot, loc)
+	if err != nil {
+		return nil, err
+	}
+	return []protocol.TypeHierarchyItem{item}, nil
+}
+
+// Supertypes returns the supertypes of the type whose declaration
+// is at the given position.
+func Supertypes(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position) ([]protocol.TypeHierarchyItem, error) {
+	ctx, done := event.Start(ctx, "golang.Supertypes")
+	defer done()
+
+	named, pkg, err := typeHierarchyType(ctx, snapshot, fh, pp)
+	if err != nil || named == nil {
+		return nil, err
+	}
+
+	var locs []protocol.Location
+	if !types.IsInterface(named) {
+		// Interfaces implemented by the concrete type.
+		// (An implementations query about a concrete
+		// type reports only interfaces.)
+		impls, err := implementations(ctx, snapshot, fh, pp, nil)
+		if err != nil {
+			return nil, err
+		}
+		locs = append(locs, impls...)
+	}
+
+	// Embedded types.
+	var embeddeds []types.Type
+	switch u := named.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if field := u.Field(i); field.Embedded() {
+				embeddeds = append(embeddeds, typeparams.Deref(field.Type()))
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < u.NumEmbeddeds(); i++ {
+			embeddeds = append(embeddeds, u.EmbeddedType(i))
+		}
+	}
+	for _, t := range embeddeds {
+		if embedded, ok := aliases.Unalias(t).(*types.Named); ok {
+			loc, err := typeDeclLocation(ctx, snapshot, pkg.FileSet(), embedded.Obj())
+			if err != nil {
+				return nil, err
+			}
+			locs = append(locs, loc)
+		}
+	}
+
+	return typeHierarchyItems(ctx, snapshot, locs)
+}
+
+// Subtypes returns the subtypes of the type whose declaration
+// is at the given position.
+func Subtypes(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position) ([]protocol.TypeHierarchyItem, error) {
+	ctx, done := event.Start(ctx, "golang.Subtypes")
+	defer done()
+
+	named, _, err := typeHierarchyType(ctx, snapshot, fh, pp)
+	if err != nil || named == nil {
+		return nil, err
+	}
+
+	var locs []protocol.Location
+	if types.IsInterface(named) {
+		// Concrete types that implement the interface.
+		// (An implementations query about an interface
+		// reports only concrete types.)
+		impls, err := implementations(ctx, snapshot, fh, pp, nil)
+		if err != nil {
+			return nil, err
+		}
+		locs = append(locs, impls...)
+	}
+
+	// Types that embed the type.
+	embedders, err := embedderLocations(ctx, snapshot, fh, pp)
+	if err != nil {
+		return nil, err
+	}
+	locs = append(locs, embedders...)
+
+	return typeHierarchyItems(ctx, snapshot, locs)
+}
+
+// typeHierarchyType returns the named type denoted by the identifier
+// at the given position, and the package that references it, or nil
+// if it does not denote a named type.
+func typeHierarchyType(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position) (*types.Named, *cache.Package, error) {
+	pkg, pgf, err := NarrowestPackageForFile(ctx, snapshot, fh.URI())
+	if err != nil {
+		return nil, nil, err
+	}
+	pos, err := pgf.PositionPos(pp)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, obj, _ := referencedObject(pkg, pgf, pos)
+	if _, ok := obj.(*types.TypeName); !ok {
+		return nil, nil, nil
+	}
+	named, _ := aliases.Unalias(obj.Type()).(*types.Named)
+	return named, pkg, nil
+}
+
+// typeDeclLocation returns the location of the name in the
+// declaration of the type denoted by obj.
+func typeDeclLocation(ctx context.Context, snapshot *cache.Snapshot, fset *token.FileSet, obj *types.TypeName) (protocol.Location, error) {
+	if obj.Pkg() == nil {
+		if obj == types.Universe.Lookup("error") {
+			return errorLocation(ctx, snapshot)
+		}
+		return protocol.Location{}, fmt.Errorf("no declaration of built-in type %s", obj.Name())
+	}
+	return mapPosition(ctx, fset, snapshot, obj.Pos(), adjustedObjEnd(obj))
+}
+
+// embedderLocations returns the locations of the names of the struct
+// and interface types that embed the type whose declaration is at the
+// given position.
+func embedderLocations(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, pp protocol.Position) ([]protocol.Location, error) {
+	refs, err := references(ctx, snapshot, fh, pp, false, nil)
+	if err != nil {
+		if errors.Is(err, ErrNoIdentFound) || errors.Is(err, errNoObjectFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Group references by file, to parse each file once.
+	refsByFile := make(map[protocol.DocumentURI][]protocol.Range)
+	for _, ref := range refs {
+		uri := ref.location.URI
+		refsByFile[uri] = append(refsByFile[uri], ref.location.Range)
+	}
+
+	var locs []protocol.Location
+	for uri, rngs := range refsByFile {
+		fh, err := snapshot.ReadFile(ctx, uri)
+		if err != nil {
+			return nil, err
+		}
+		pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+		if err != nil {
+			return nil, err
+		}
+		for _, rng := range rngs {
+			start, end, err := pgf.RangePos(rng)
+			if err != nil {
+				return nil, err
+			}
+			path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+			if spec := embeddingTypeSpec(path); spec != nil {
+				loc, err := pgf.NodeLocation(spec.Name)
+				if err != nil {
+					return nil, err
+				}
+				locs = append(locs, loc)
+			}
+		}
+	}
+	return locs, nil
+}
+
+// embeddingTypeSpec returns the declaration of the struct or interface
+// type that embeds the type named by the identifier path[0], or nil if
+// the identifier is not an embedded field or interface element.
+func embeddingTypeSpec(path []ast.Node) *ast.TypeSpec {
+	if len(path) == 0 {
+		return nil
+	}
+	if _, ok := path[0].(*ast.Ident); !ok {
+		return nil
+	}
+	// Skip over the qualifier, pointer, and type arguments,
+	// as in *pkg.T[int].
+	i := 1
+	for ; i < len(path); i++ {
+		child := path[i-1]
+		switch n := path[i].(type) {
+		case *ast.SelectorExpr:
+			if n.Sel != child {
+				return nil // qualifier
+			}
+			continue
+		case *ast.IndexExpr:
+			if n.X != child {
+				return nil // type argument
+			}
+			continue
+		case *ast.IndexListExpr:
+			if n.X != child {
+				return nil // type argument
+			}
+			continue
+		case *ast.StarExpr, *ast.ParenExpr:
+			continue
+		}
+		break
+	}
+	if i+3 >= len(path) {
+		return nil
+	}
+	field, ok := path[i].(*ast.Field)
+	if !ok || len(field.Names) > 0 {
+		return nil // not embedded
+	}
+	switch path[i+2].(type) {
+	case *ast.StructType, *ast.InterfaceType:
+	default:
+		return nil // e.g. an unnamed parameter of a func type
+	}
+	spec, _ := path[i+3].(*ast.TypeSpec)
+	return spec
+}
+
+// typeHierarchyItems returns the items for the type declarations whose
+// names are at the given locations, without duplicates, in order of
+// location.
+func typeHierarchyItems(ctx context.Context, snapshot *cache.Snapshot, locs []protocol.Location) ([]protocol.TypeHierarchyItem, error) {
+	sort.Slice(locs, func(i, j int) bool {
+		return protocol.CompareLocation(locs[i], locs[j]) < 0
+	})
+	items := []protocol.TypeHierarchyItem{}
+	for i, loc := range locs {
+		if i > 0 && loc == locs[i-1] {
+			continue // duplicate
+		}
+		item, err := typeHierarchyItem(ctx, snapshot, loc)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// typeHierarchyItem returns the item for the type declaration whose
+// name is at the given location.
+func typeHierarchyItem(ctx context.Context, snapshot *cache.Snapshot, loc protocol.Location) (protocol.TypeHierarchyItem, error) {
+	fh, err := snapshot.ReadFile(ctx, loc.URI)
+	if err != nil {
+		return protocol.TypeHierarchyItem{}, err
+	}
+	pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
+	if err != nil {
+		return protocol.TypeHierarchyItem{}, err
+	}
+	start, end, err := pgf.RangePos(loc.Range)
+	if err != nil {
+		return protocol.TypeHierarchyItem{}, err
+	}
+	path, _ := astutil.PathEnclosingInterval(pgf.File, start, end)
+	var spec *ast.TypeSpec
+	if len(path) > 1 {
+		spec, _ = path[1].(*ast.TypeSpec)
+	}
+	if spec == nil || spec.Name != path[0] {
+		return protocol.TypeHierarchyItem{}, fmt.Errorf("no type declaration at %v", loc)
+	}
+	rng, err := pgf.NodeRange(spec)
+	if err != nil {
+		return protocol.TypeHierarchyItem{}, err
+	}
+
+	kind := protocol.Class
+	switch spec.Type.(type) {
+	case *ast.InterfaceType:
+		kind = protocol.Interface
+	case *ast.StructType:
+		kind = protocol.Struct
+	}
+
+	// Report the package path, if the file belongs to a package.
+	// (builtin.go does not.)
+	pkgPath := pgf.File.Name.Name
+	if mp, err := NarrowestMetadataForFile(ctx, snapshot, loc.URI); err == nil {
+		pkgPath = string(mp.PkgPath)
+	}
+
+	return protocol.TypeHierarchyItem{
+		Name:           spec.Name.Name,
+		Kind:           kind,
+		Detail:         fmt.Sprintf("%s • %s", pkgPath, filepath.Base(loc.URI.Path())),
+		URI:            loc.URI,
+		Range:          rng,
+		SelectionRange: loc.Range,
+	}, nil
+}
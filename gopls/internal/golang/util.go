@@ -6,20 +6,22 @@ package golang
 
 import (
 	"context"
+	"fmt"
 	"go/ast"
 	"go/printer"
 	"go/token"
 	"go/types"
-	"regexp"
+	pathpkg "path"
 	"strings"
 
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/cache/metadata"
 	"golang.org/x/tools/gopls/internal/cache/parsego"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/util/astutil"
 	"golang.org/x/tools/gopls/internal/util/bug"
-	"golang.org/x/tools/gopls/internal/util/safetoken"
+	"golang.org/x/tools/internal/imports"
 	"golang.org/x/tools/internal/tokeninternal"
 )
 
@@ -38,17 +40,7 @@ func IsGenerated(ctx context.Context, snapshot *cache.Snapshot, uri protocol.Doc
 	if err != nil {
 		return false
 	}
-	for _, commentGroup := range pgf.File.Comments {
-		for _, comment := range commentGroup.List {
-			if matched := generatedRx.MatchString(comment.Text); matched {
-				// Check if comment is at the beginning of the line in source.
-				if safetoken.Position(pgf.Tok, comment.Slash).Column == 1 {
-					return true
-				}
-			}
-		}
-	}
-	return false
+	return pgf.Generated
 }
 
 // adjustedObjEnd returns the end position of obj, possibly modified for
@@ -76,11 +68,6 @@ func adjustedObjEnd(obj types.Object) token.Pos {
 	return obj.Pos() + token.Pos(nameLen)
 }
 
-// Matches cgo generated comment as well as the proposed standard:
-//
-//	https://golang.org/s/generatedcode
-var generatedRx = regexp.MustCompile(`// .*DO NOT EDIT\.?`)
-
 // FormatNode returns the "pretty-print" output for an ast node.
 func FormatNode(fset *token.FileSet, n ast.Node) string {
 	var buf strings.Builder
@@ -279,6 +266,114 @@ func importInfo(s metadata.Source, imp *ast.ImportSpec, mp *metadata.Package) (s
 	return name, pkgName, impPath, pkgPath
 }
 
+// An importAdder qualifies package-level names relative to the
+// imports of a file, recording a fix to add an import for each
+// package that the file does not yet import.
+type importAdder struct {
+	pkg      *cache.Package
+	imported map[string]string // path -> local name
+	fixes    []*imports.ImportFix
+}
+
+func newImportAdder(snapshot *cache.Snapshot, pkg *cache.Package, pgf *parsego.File) *importAdder {
+	imported := make(map[string]string)
+	for _, spec := range pgf.File.Imports {
+		path := string(metadata.UnquoteImportPath(spec))
+		name := importName(snapshot, pkg, path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "." {
+			name = ""
+		}
+		if name != "_" {
+			imported[path] = name
+		}
+	}
+	return &importAdder{pkg: pkg, imported: imported}
+}
+
+// qualifier is a types.Qualifier that imports p if necessary.
+func (a *importAdder) qualifier(p *types.Package) string {
+	if p == a.pkg.Types() {
+		return ""
+	}
+	return a.add(p)
+}
+
+// add returns the name by which the file refers to package p,
+// recording a fix to import it if necessary.
+func (a *importAdder) add(p *types.Package) string {
+	if name, ok := a.imported[p.Path()]; ok {
+		return name
+	}
+	fix := &imports.ImportFix{
+		StmtInfo:  imports.ImportInfo{ImportPath: p.Path()},
+		IdentName: p.Name(),
+		FixType:   imports.AddImport,
+	}
+	if p.Name() != pathpkg.Base(trimVersionSuffix(p.Path())) {
+		fix.StmtInfo.Name = p.Name()
+	}
+	a.fixes = append(a.fixes, fix)
+	a.imported[p.Path()] = p.Name()
+	return p.Name()
+}
+
+// remove records a fix to delete the import spec, which must be one
+// of the imports of the file.
+func (a *importAdder) remove(spec *ast.ImportSpec) {
+	fix := &imports.ImportFix{
+		StmtInfo: imports.ImportInfo{ImportPath: string(metadata.UnquoteImportPath(spec))},
+		FixType:  imports.DeleteImport,
+	}
+	if spec.Name != nil {
+		fix.StmtInfo.Name = spec.Name.Name
+	}
+	a.fixes = append(a.fixes, fix)
+	delete(a.imported, fix.StmtInfo.ImportPath)
+}
+
+// member returns the expression by which the file may refer to the
+// named member of the package with the given path at pos, which is
+// in pkg, recording a fix to import the package if necessary. It
+// returns an error if the name of the package is shadowed at pos.
+func (a *importAdder) member(pos token.Pos, path, name string) (string, error) {
+	qual := a.add(types.NewPackage(path, pathpkg.Base(path)))
+	if qual == "" {
+		return name, nil // dot-import
+	}
+	if scope := a.pkg.Types().Scope().Innermost(pos); scope != nil {
+		if _, obj := scope.LookupParent(qual, pos); obj != nil {
+			if pkgName, ok := obj.(*types.PkgName); !ok || pkgName.Imported().Path() != path {
+				return "", fmt.Errorf("the name %s is shadowed", qual)
+			}
+		}
+	}
+	return qual + "." + name, nil
+}
+
+// edits returns the edits that add the imports recorded by the
+// adder to the file pgf.
+func (a *importAdder) edits(snapshot *cache.Snapshot, pgf *parsego.File) ([]analysis.TextEdit, error) {
+	if len(a.fixes) == 0 {
+		return nil, nil
+	}
+	edits, err := ComputeImportFixEdits(snapshot, pgf, a.fixes...)
+	if err != nil {
+		return nil, err
+	}
+	var result []analysis.TextEdit
+	for _, edit := range edits {
+		start, end, err := pgf.RangePos(edit.Range)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, analysis.TextEdit{Pos: start, End: end, NewText: []byte(edit.NewText)})
+	}
+	return result, nil
+}
+
 // isDirective reports whether c is a comment directive.
 //
 // Copied and adapted from go/src/go/ast/ast.go.
@@ -7,10 +7,12 @@ package golang
 import (
 	"context"
 	"fmt"
+	"math"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"unicode"
 
 	"golang.org/x/tools/gopls/internal/cache"
@@ -42,7 +44,11 @@ const maxSymbols = 100
 // with a different configured SymbolMatcher per View. Therefore we assume that
 // Session level configuration will define the SymbolMatcher to be used for the
 // WorkspaceSymbols method.
-func WorkspaceSymbols(ctx context.Context, matcher settings.SymbolMatcher, style settings.SymbolStyle, snapshots []*cache.Snapshot, query string) ([]protocol.SymbolInformation, error) {
+//
+// If report is non-nil, it is called with each batch of results, in
+// order, as soon as no later match can outrank them, so that they can
+// be streamed to the client. Each result is reported exactly once.
+func WorkspaceSymbols(ctx context.Context, matcher settings.SymbolMatcher, style settings.SymbolStyle, snapshots []*cache.Snapshot, query string, report func([]protocol.SymbolInformation)) ([]protocol.SymbolInformation, error) {
 	ctx, done := event.Start(ctx, "golang.WorkspaceSymbols")
 	defer done()
 	if query == "" {
@@ -61,7 +67,7 @@ func WorkspaceSymbols(ctx context.Context, matcher settings.SymbolMatcher, style
 		panic(fmt.Errorf("unknown symbol style: %v", style))
 	}
 
-	return collectSymbols(ctx, snapshots, matcher, s, query)
+	return collectSymbols(ctx, snapshots, matcher, s, query, report)
 }
 
 // A matcherFunc returns the index and score of a symbol match.
@@ -287,7 +293,7 @@ func (c comboMatcher) match(chunks []string) (int, float64) {
 //     of zero indicates no match.
 //   - A symbolizer determines how we extract the symbol for an object. This
 //     enables the 'symbolStyle' configuration option.
-func collectSymbols(ctx context.Context, snapshots []*cache.Snapshot, matcherType settings.SymbolMatcher, symbolizer symbolizer, query string) ([]protocol.SymbolInformation, error) {
+func collectSymbols(ctx context.Context, snapshots []*cache.Snapshot, matcherType settings.SymbolMatcher, symbolizer symbolizer, query string, report func([]protocol.SymbolInformation)) ([]protocol.SymbolInformation, error) {
 	// Extract symbols from all files.
 	var work []symbolFile
 	var roots []string
@@ -328,47 +334,200 @@ func collectSymbols(ctx context.Context, snapshots []*cache.Snapshot, matcherTyp
 				continue
 			}
 			seen[uri] = true
-			work = append(work, symbolFile{uri, meta, syms})
+			work = append(work, symbolFile{uri, meta, syms, false})
+		}
+	}
+
+	// TODO(rfindley): use metadata to determine if the file is in a workspace
+	// package, rather than this heuristic.
+	for i := range work {
+		for _, root := range roots {
+			if strings.HasPrefix(string(work[i].uri), root) {
+				work[i].inWorkspace = true
+				break
+			}
 		}
 	}
 
-	// Match symbols in parallel.
+	// Match symbols in parallel, one package at a time.
 	// Each worker has its own symbolStore,
 	// which we merge at the end.
+	//
+	// Once a worker's store is full, its lowest score is a lower bound
+	// on the scores of the final results, which the workers share so
+	// that they can skip the remaining packages whose symbols cannot
+	// score higher. The packages are matched in order of decreasing
+	// upper bound, so that the bound rises quickly; for most queries,
+	// the packages outside the workspace are then never matched.
+	//
+	// The shards are matched in tiers of equal bound. After each
+	// tier, the results that score higher than the bound of the
+	// next are final, and may be reported.
+	shards := shardSymbols(work)
 	nmatchers := runtime.GOMAXPROCS(-1) // matching is CPU bound
-	results := make(chan *symbolStore)
-	for i := 0; i < nmatchers; i++ {
-		go func(i int) {
-			matcher := buildMatcher(matcherType, query)
-			store := new(symbolStore)
-			// Assign files to workers in round-robin fashion.
-			for j := i; j < len(work); j += nmatchers {
-				matchFile(store, symbolizer, matcher, roots, work[j])
-			}
-			results <- store
-		}(i)
+	var (
+		unified  symbolStore
+		floor    scoreFloor
+		reported = 0 // number of results of unified already reported
+	)
+	reportAbove := func(bound float64) {
+		if report == nil {
+			return
+		}
+		var batch []protocol.SymbolInformation
+		for ; reported < len(unified.res) && unified.res[reported].score > bound; reported++ {
+			batch = append(batch, unified.res[reported].asProtocolSymbolInformation())
+		}
+		if len(batch) > 0 {
+			report(batch)
+		}
 	}
+	for len(shards) > 0 && shards[0].bound > floor.load() && ctx.Err() == nil {
+		n := 1
+		for n < len(shards) && shards[n].bound == shards[0].bound {
+			n++
+		}
+		tier := shards[:n]
+		shards = shards[n:]
+
+		var next atomic.Int32 // index of the next shard of the tier to match
+		results := make(chan *symbolStore)
+		for i := 0; i < nmatchers; i++ {
+			go func() {
+				matcher := buildMatcher(matcherType, query)
+				store := new(symbolStore)
+				for ctx.Err() == nil {
+					j := int(next.Add(1)) - 1
+					if j >= len(tier) || tier[j].bound <= floor.load() {
+						break // the remaining shards cannot improve the results
+					}
+					for _, file := range tier[j].files {
+						matchFile(store, symbolizer, matcher, file)
+					}
+					if store.full() {
+						floor.raise(store.lowest())
+					}
+				}
+				results <- store
+			}()
+		}
 
-	// Gather and merge results as they arrive.
-	var unified symbolStore
-	for i := 0; i < nmatchers; i++ {
-		store := <-results
-		for _, syms := range store.res {
-			unified.store(syms)
+		// Gather and merge results as they arrive.
+		for i := 0; i < nmatchers; i++ {
+			store := <-results
+			for _, syms := range store.res {
+				unified.store(syms)
+			}
+		}
+		if unified.full() {
+			floor.raise(unified.lowest())
 		}
+		if len(shards) > 0 && ctx.Err() == nil {
+			reportAbove(shards[0].bound)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
+	reportAbove(0)
 	return unified.results(), nil
 }
 
+// A symbolShard holds the symbol files of a single package.
+type symbolShard struct {
+	files []symbolFile
+	bound float64 // upper bound on the scores of the symbols
+	size  int     // number of symbols
+}
+
+// shardSymbols groups the symbol files by package, in order of
+// decreasing upper bound on the scores of their symbols, and then of
+// decreasing size, so that the largest shards are not left until last.
+func shardSymbols(work []symbolFile) []*symbolShard {
+	byPkg := make(map[PackageID]*symbolShard)
+	var shards []*symbolShard
+	for _, file := range work {
+		shard, ok := byPkg[file.mp.ID]
+		if !ok {
+			shard = &symbolShard{bound: nonWorkspaceFactor}
+			byPkg[file.mp.ID] = shard
+			shards = append(shards, shard)
+		}
+		shard.files = append(shard.files, file)
+		shard.size += len(file.syms)
+		if file.inWorkspace {
+			shard.bound = 1 // matchers score at most 1
+		}
+	}
+	sort.Slice(shards, func(i, j int) bool {
+		x, y := shards[i], shards[j]
+		if x.bound != y.bound {
+			return x.bound > y.bound
+		}
+		if x.size != y.size {
+			return x.size > y.size
+		}
+		return x.files[0].mp.ID < y.files[0].mp.ID
+	})
+	return shards
+}
+
+// A scoreFloor is a score, shared by concurrent workers, that only
+// increases.
+type scoreFloor struct {
+	bits atomic.Uint64 // math.Float64bits of the score
+}
+
+func (f *scoreFloor) load() float64 {
+	return math.Float64frombits(f.bits.Load())
+}
+
+// raise raises the floor to score, if it is higher.
+func (f *scoreFloor) raise(score float64) {
+	for {
+		old := f.bits.Load()
+		if score <= math.Float64frombits(old) || f.bits.CompareAndSwap(old, math.Float64bits(score)) {
+			return
+		}
+	}
+}
+
 // symbolFile holds symbol information for a single file.
 type symbolFile struct {
-	uri  protocol.DocumentURI
-	mp   *metadata.Package
-	syms []cache.Symbol
+	uri         protocol.DocumentURI
+	mp          *metadata.Package
+	syms        []cache.Symbol
+	inWorkspace bool // the file is within a workspace folder
 }
 
+// Factors to apply to the match score for the purpose of downranking
+// results.
+//
+// These numbers were crudely calibrated based on trial-and-error using a
+// small number of sample queries. Adjust as necessary.
+//
+// All factors are multiplicative, meaning if more than one applies they are
+// multiplied together.
+const (
+	// nonWorkspaceFactor is applied to symbols outside the workspace.
+	// Developers are less likely to want to jump to code that they
+	// are not actively working on.
+	nonWorkspaceFactor = 0.5
+	// nonWorkspaceUnexportedFactor is applied to unexported symbols outside
+	// the workspace. Since one wouldn't usually jump to unexported
+	// symbols to understand a package API, they are particularly irrelevant.
+	nonWorkspaceUnexportedFactor = 0.5
+	// every field or method nesting level to access the field decreases
+	// the score by a factor of 1.0 - depth*depthFactor, up to a depth of
+	// 3.
+	//
+	// Use a small constant here, as this exists mostly to break ties
+	// (e.g. given a type Foo and a field x.Foo, prefer Foo).
+	depthFactor = 0.01
+)
+
 // matchFile scans a symbol file and adds matching symbols to the store.
-func matchFile(store *symbolStore, symbolizer symbolizer, matcher matcherFunc, roots []string, i symbolFile) {
+func matchFile(store *symbolStore, symbolizer symbolizer, matcher matcherFunc, i symbolFile) {
 	space := make([]string, 0, 3)
 	for _, sym := range i.syms {
 		symbolParts, score := symbolizer(space, sym.Name, i.mp, matcher)
@@ -378,32 +537,6 @@ func matchFile(store *symbolStore, symbolizer symbolizer, matcher matcherFunc, r
 			continue
 		}
 
-		// Factors to apply to the match score for the purpose of downranking
-		// results.
-		//
-		// These numbers were crudely calibrated based on trial-and-error using a
-		// small number of sample queries. Adjust as necessary.
-		//
-		// All factors are multiplicative, meaning if more than one applies they are
-		// multiplied together.
-		const (
-			// nonWorkspaceFactor is applied to symbols outside the workspace.
-			// Developers are less likely to want to jump to code that they
-			// are not actively working on.
-			nonWorkspaceFactor = 0.5
-			// nonWorkspaceUnexportedFactor is applied to unexported symbols outside
-			// the workspace. Since one wouldn't usually jump to unexported
-			// symbols to understand a package API, they are particularly irrelevant.
-			nonWorkspaceUnexportedFactor = 0.5
-			// every field or method nesting level to access the field decreases
-			// the score by a factor of 1.0 - depth*depthFactor, up to a depth of
-			// 3.
-			//
-			// Use a small constant here, as this exists mostly to break ties
-			// (e.g. given a type Foo and a field x.Foo, prefer Foo).
-			depthFactor = 0.01
-		)
-
 		startWord := true
 		exported := true
 		depth := 0.0
@@ -419,18 +552,8 @@ func matchFile(store *symbolStore, symbolizer symbolizer, matcher matcherFunc, r
 			}
 		}
 
-		// TODO(rfindley): use metadata to determine if the file is in a workspace
-		// package, rather than this heuristic.
-		inWorkspace := false
-		for _, root := range roots {
-			if strings.HasPrefix(string(i.uri), root) {
-				inWorkspace = true
-				break
-			}
-		}
-
 		// Apply downranking based on workspace position.
-		if !inWorkspace {
+		if !i.inWorkspace {
 			score *= nonWorkspaceFactor
 			if !exported {
 				score *= nonWorkspaceUnexportedFactor
@@ -485,7 +608,17 @@ func (sc *symbolStore) store(si symbolInformation) {
 }
 
 func (sc *symbolStore) tooLow(score float64) bool {
-	return score <= sc.res[len(sc.res)-1].score
+	return score <= sc.lowest()
+}
+
+// full reports whether the store holds maxSymbols results.
+func (sc *symbolStore) full() bool {
+	return sc.lowest() > 0
+}
+
+// lowest returns the lowest score in the store, or 0 if it is not full.
+func (sc *symbolStore) lowest() float64 {
+	return sc.res[len(sc.res)-1].score
 }
 
 func (sc *symbolStore) results() []protocol.SymbolInformation {
@@ -5,9 +5,12 @@
 package golang
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
 )
 
 func TestParseQuery(t *testing.T) {
@@ -136,3 +139,46 @@ func TestFiltererDisallow(t *testing.T) {
 		}
 	}
 }
+
+func TestShardSymbols(t *testing.T) {
+	a := &metadata.Package{ID: "a"}
+	b := &metadata.Package{ID: "b"}
+	c := &metadata.Package{ID: "c"}
+	syms := func(n int) []cache.Symbol { return make([]cache.Symbol, n) }
+	work := []symbolFile{
+		{uri: "file:///ws/a/a1.go", mp: a, syms: syms(1), inWorkspace: true},
+		{uri: "file:///dep/b/b.go", mp: b, syms: syms(10)},
+		{uri: "file:///ws/a/a2.go", mp: a, syms: syms(2), inWorkspace: true},
+		{uri: "file:///ws/c/c.go", mp: c, syms: syms(5), inWorkspace: true},
+	}
+
+	// Workspace packages first, larger ones first.
+	var got []string
+	for _, shard := range shardSymbols(work) {
+		got = append(got, fmt.Sprintf("%s:%d:%.1f", shard.files[0].mp.ID, len(shard.files), shard.bound))
+	}
+	want := fmt.Sprint([]string{"c:1:1.0", "a:2:1.0", "b:1:0.5"})
+	if fmt.Sprint(got) != want {
+		t.Errorf("shardSymbols = %v, want %v", got, want)
+	}
+}
+
+func TestScoreFloor(t *testing.T) {
+	var floor scoreFloor
+	var wg sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func(score float64) {
+			defer wg.Done()
+			floor.raise(score)
+		}(float64(i) / 100)
+	}
+	wg.Wait()
+	if got := floor.load(); got != 1 {
+		t.Errorf("floor = %v, want 1", got)
+	}
+	floor.raise(0.5)
+	if got := floor.load(); got != 1 {
+		t.Errorf("floor lowered to %v", got)
+	}
+}
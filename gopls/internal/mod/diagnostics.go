@@ -15,6 +15,7 @@ import (
 	"sync"
 
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
 	"golang.org/x/mod/semver"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/tools/gopls/internal/cache"
@@ -51,6 +52,19 @@ func UpgradeDiagnostics(ctx context.Context, snapshot *cache.Snapshot) (map[prot
 	return collectDiagnostics(ctx, snapshot, ModUpgradeDiagnostics)
 }
 
+// UpdateDiagnostics returns diagnostics for the available updates and
+// retractions of the modules required by the workspace, if the
+// dependencyUpdates setting is enabled.
+func UpdateDiagnostics(ctx context.Context, snapshot *cache.Snapshot) (map[protocol.DocumentURI][]*cache.Diagnostic, error) {
+	if !snapshot.Options().DependencyUpdates {
+		return nil, nil
+	}
+	ctx, done := event.Start(ctx, "mod.UpdateDiagnostics", snapshot.Labels()...)
+	defer done()
+
+	return collectDiagnostics(ctx, snapshot, ModUpdateDiagnostics)
+}
+
 // VulnerabilityDiagnostics returns vulnerability diagnostics for the active modules in the
 // workspace with known vulnerabilities.
 func VulnerabilityDiagnostics(ctx context.Context, snapshot *cache.Snapshot) (map[protocol.DocumentURI][]*cache.Diagnostic, error) {
@@ -180,6 +194,131 @@ func ModUpgradeDiagnostics(ctx context.Context, snapshot *cache.Snapshot, fh fil
 
 const upgradeCodeActionPrefix = "Upgrade to "
 
+// ModUpdateDiagnostics reports the available updates of the modules
+// required by the go.mod file, the retractions of their required
+// versions, and their deprecations. Modules with upgrades found by the
+// CheckUpgrades command are reported only if retracted or deprecated,
+// as the upgrade is already reported.
+func ModUpdateDiagnostics(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]*cache.Diagnostic, error) {
+	pm, err := snapshot.ParseMod(ctx, fh)
+	if err != nil {
+		// Parse errors are reported by ModParseDiagnostics.
+		if pm != nil && len(pm.ParseErrors) != 0 {
+			return nil, nil
+		}
+		return nil, err
+	}
+	updates, err := snapshot.ModUpdates(ctx, fh.URI())
+	if err != nil {
+		return nil, err
+	}
+	upgrades := snapshot.ModuleUpgrades(fh.URI())
+
+	var diagnostics []*cache.Diagnostic
+	for _, req := range pm.File.Require {
+		update, ok := updates[req.Mod.Path]
+		if !ok || update.Version != req.Mod.Version {
+			continue // no news, or stale
+		}
+		if snoozed, ok := snapshot.SnoozedUpdate(req.Mod.Path); ok && (update.Update == "" || semver.Compare(update.Update, snoozed) <= 0) {
+			continue
+		}
+		rng, err := pm.Mapper.OffsetRange(req.Syntax.Start.Byte, req.Syntax.End.Byte)
+		if err != nil {
+			return nil, err
+		}
+		snooze, err := command.NewSnoozeUpdateCommand("Snooze notices for "+req.Mod.Path, command.SnoozeUpdateArgs{
+			URI:     fh.URI(),
+			Module:  req.Mod.Path,
+			Version: update.Update,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if update.Deprecated != "" {
+			diagnostics = append(diagnostics, &cache.Diagnostic{
+				URI:            fh.URI(),
+				Range:          rng,
+				Severity:       protocol.SeverityWarning,
+				Source:         cache.DependencyUpdate,
+				Message:        fmt.Sprintf("%s is deprecated: %s", req.Mod.Path, update.Deprecated),
+				Tags:           []protocol.DiagnosticTag{protocol.Deprecated},
+				SuggestedFixes: []cache.SuggestedFix{cache.SuggestedFixFromCommand(snooze, protocol.QuickFix)},
+			})
+		}
+		if _, ok := upgrades[req.Mod.Path]; ok && update.Retracted == nil {
+			continue
+		}
+		if update.Update == "" && update.Retracted == nil {
+			continue // deprecated only
+		}
+		diag := &cache.Diagnostic{
+			URI:    fh.URI(),
+			Range:  rng,
+			Source: cache.DependencyUpdate,
+		}
+		if update.Retracted != nil {
+			diag.Severity = protocol.SeverityWarning
+			diag.Message = fmt.Sprintf("%s %s has been retracted", req.Mod.Path, req.Mod.Version)
+			if rationale := strings.Join(update.Retracted, "; "); rationale != "" {
+				diag.Message += ": " + rationale
+			}
+		} else {
+			diag.Severity = protocol.SeverityInformation
+			kind := "minor"
+			if semver.MajorMinor(update.Update) == semver.MajorMinor(req.Mod.Version) {
+				kind = "patch"
+			}
+			diag.Message = fmt.Sprintf("%s %s is available (%s release)", req.Mod.Path, update.Update, kind)
+		}
+		if update.Update != "" {
+			diag.Code = update.Update
+			diag.CodeHref = releaseNotesURL(req.Mod.Path, update.Update)
+			upgrade, err := command.NewUpgradeDependencyCommand(upgradeCodeActionPrefix+update.Update, command.DependencyArgs{
+				URI:        fh.URI(),
+				AddRequire: false,
+				GoCmdArgs:  []string{req.Mod.Path + "@" + update.Update},
+			})
+			if err != nil {
+				return nil, err
+			}
+			diag.SuggestedFixes = append(diag.SuggestedFixes, cache.SuggestedFixFromCommand(upgrade, protocol.QuickFix))
+		} else if update.Latest != "" {
+			// Move off the retracted version.
+			downgrade, err := command.NewUpgradeDependencyCommand("Downgrade to "+update.Latest, command.DependencyArgs{
+				URI:        fh.URI(),
+				AddRequire: false,
+				GoCmdArgs:  []string{req.Mod.Path + "@" + update.Latest},
+			})
+			if err != nil {
+				return nil, err
+			}
+			diag.SuggestedFixes = append(diag.SuggestedFixes, cache.SuggestedFixFromCommand(downgrade, protocol.QuickFix))
+		}
+		diag.SuggestedFixes = append(diag.SuggestedFixes, cache.SuggestedFixFromCommand(snooze, protocol.QuickFix))
+		diagnostics = append(diagnostics, diag)
+	}
+	return diagnostics, nil
+}
+
+// releaseNotesURL returns a link to the release notes of a version of
+// a module: the release page of its tag for a module hosted on
+// GitHub, or its documentation otherwise.
+func releaseNotesURL(modulePath, version string) string {
+	if prefix, _, ok := module.SplitPathVersion(modulePath); ok && strings.HasPrefix(prefix, "github.com/") {
+		// A module in a subdirectory of its repository is
+		// tagged with the path of the subdirectory.
+		if parts := strings.SplitN(prefix, "/", 4); len(parts) >= 3 {
+			tag := version
+			if len(parts) == 4 {
+				tag = parts[3] + "/" + version
+			}
+			return fmt.Sprintf("https://%s/releases/tag/%s", strings.Join(parts[:3], "/"), tag)
+		}
+	}
+	return fmt.Sprintf("https://pkg.go.dev/%s@%s", modulePath, version)
+}
+
 // ModVulnerabilityDiagnostics adds diagnostics for vulnerabilities in individual modules
 // if the vulnerability is recorded in the view.
 func ModVulnerabilityDiagnostics(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) (vulnDiagnostics []*cache.Diagnostic, err error) {
@@ -0,0 +1,128 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mod
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/versions"
+)
+
+// EditGoDirective returns the new contents of the files whose go
+// directives it changes: the go.mod file of the module containing uri,
+// whose go directive is set to the specified Go language version, such
+// as "1.21", and those whose changes keep the rest of the workspace
+// compatible with the new version:
+//
+//   - a toolchain directive of an edited module that names a toolchain
+//     older than the version is dropped, as the go directive supersedes
+//     it;
+//   - since Go 1.21, a module must declare a go version at least as new
+//     as that of each of its dependencies, so the go directive of each
+//     workspace module that requires the module is raised if older;
+//   - the go directive of the go.work file, which must be at least as
+//     new as that of each of its modules, is raised if older.
+func EditGoDirective(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI, version string) (map[protocol.DocumentURI][]byte, error) {
+	goVersion := "go" + version
+	if !versions.IsValid(goVersion) {
+		return nil, fmt.Errorf("invalid Go version %q", version)
+	}
+	// older reports whether the language version v, such as "1.20",
+	// predates version.
+	older := func(v string) bool {
+		return versions.Compare("go"+v, goVersion) < 0
+	}
+
+	modURI := snapshot.GoModForFile(uri)
+	if modURI == "" {
+		return nil, fmt.Errorf("no go.mod file found for %s", uri.Path())
+	}
+	fh, err := snapshot.ReadFile(ctx, modURI)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := snapshot.ParseMod(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+	if pm.File.Module == nil {
+		return nil, fmt.Errorf("%s has no module directive", modURI.Path())
+	}
+	modulePath := pm.File.Module.Mod.Path
+
+	contents := make(map[protocol.DocumentURI][]byte)
+	setGo := func(pm *cache.ParsedModule) error {
+		copied, err := modfile.Parse("", pm.Mapper.Content, nil)
+		if err != nil {
+			return err
+		}
+		if err := copied.AddGoStmt(version); err != nil {
+			return err
+		}
+		if copied.Toolchain != nil && versions.Compare(copied.Toolchain.Name, goVersion) < 0 {
+			copied.DropToolchainStmt()
+		}
+		copied.Cleanup()
+		contents[pm.URI], err = copied.Format()
+		return err
+	}
+	if err := setGo(pm); err != nil {
+		return nil, err
+	}
+
+	inWorkspace := false
+	for _, otherURI := range snapshot.View().ModFiles() {
+		if otherURI == modURI {
+			inWorkspace = true
+		} else if versions.Compare(goVersion, "go1.21") >= 0 {
+			fh, err := snapshot.ReadFile(ctx, otherURI)
+			if err != nil {
+				return nil, err
+			}
+			pm, err := snapshot.ParseMod(ctx, fh)
+			if err != nil {
+				return nil, err
+			}
+			if pm.File.Go != nil && !older(pm.File.Go.Version) {
+				continue
+			}
+			for _, req := range pm.File.Require {
+				if req.Mod.Path == modulePath {
+					if err := setGo(pm); err != nil {
+						return nil, err
+					}
+					break
+				}
+			}
+		}
+	}
+
+	if workURI := snapshot.View().GoWork(); workURI != "" && inWorkspace {
+		fh, err := snapshot.ReadFile(ctx, workURI)
+		if err != nil {
+			return nil, err
+		}
+		pw, err := snapshot.ParseWork(ctx, fh)
+		if err != nil {
+			return nil, err
+		}
+		if pw.File.Go == nil || older(pw.File.Go.Version) {
+			copied, err := modfile.ParseWork("", pw.Mapper.Content, nil)
+			if err != nil {
+				return nil, err
+			}
+			if err := copied.AddGoStmt(version); err != nil {
+				return nil, err
+			}
+			copied.Cleanup()
+			contents[workURI] = modfile.Format(copied.Syntax)
+		}
+	}
+	return contents, nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mod
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/internal/diff"
+)
+
+// restorePrefix marks the comments that record the replacements
+// superseded by a local replacement, for restoration by RemoveReplace.
+const restorePrefix = "// gopls: restore "
+
+// CodeActions returns the code actions to replace the dependencies
+// required within the specified range of a go.mod file by local
+// directories, or to remove such local replacements.
+func CodeActions(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]protocol.CodeAction, error) {
+	pm, err := snapshot.ParseMod(ctx, fh)
+	if err != nil || pm.File == nil {
+		return nil, nil // parse errors are reported as diagnostics
+	}
+	inRange := func(line *modfile.Line) bool {
+		lineRng, err := pm.Mapper.OffsetRange(line.Start.Byte, line.End.Byte)
+		return err == nil && protocol.Intersect(lineRng, rng)
+	}
+	action := func(cmd protocol.Command) protocol.CodeAction {
+		return protocol.CodeAction{
+			Title:   cmd.Title,
+			Kind:    protocol.RefactorRewrite,
+			Command: &cmd,
+		}
+	}
+
+	local := make(map[string]bool) // modules replaced by local directories
+	var actions []protocol.CodeAction
+	for _, r := range pm.File.Replace {
+		if !modfile.IsDirectoryPath(r.New.Path) {
+			continue
+		}
+		local[r.Old.Path] = true
+		if !inRange(r.Syntax) {
+			continue
+		}
+		title := "Remove local replace of " + r.Old.Path
+		if len(restoredReplaces(r.Syntax)) > 0 {
+			title = "Restore previous replace of " + r.Old.Path
+		}
+		cmd, err := command.NewRemoveReplaceCommand(title, command.RemoveReplaceArgs{
+			URI:        fh.URI(),
+			ModulePath: r.Old.Path,
+		})
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action(cmd))
+	}
+	for _, req := range pm.File.Require {
+		if local[req.Mod.Path] || !inRange(req.Syntax) {
+			continue
+		}
+		// Suggest a sibling directory of the module named after
+		// the dependency, if it contains the dependency.
+		if prefix, _, ok := module.SplitPathVersion(req.Mod.Path); ok {
+			dir := "../" + path.Base(prefix)
+			if _, err := checkReplacement(ctx, snapshot, fh.URI(), req.Mod.Path, dir); err == nil {
+				cmd, err := command.NewAddReplaceCommand("Replace with "+dir, command.AddReplaceArgs{
+					URI:        fh.URI(),
+					ModulePath: req.Mod.Path,
+					Dir:        dir,
+				})
+				if err != nil {
+					return nil, err
+				}
+				actions = append(actions, action(cmd))
+			}
+		}
+		cmd, err := command.NewAddReplaceCommand("Replace with local directory", command.AddReplaceArgs{
+			URI:        fh.URI(),
+			ModulePath: req.Mod.Path,
+		})
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action(cmd))
+	}
+	return actions, nil
+}
+
+// AddReplace returns the edits to the go.mod file that replace the
+// specified module by the module in the given directory, commenting
+// out its existing replacements. It reports an error if the directory
+// does not contain the module.
+func AddReplace(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, modulePath, dir string) ([]protocol.TextEdit, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("no directory specified for the replacement of %s", modulePath)
+	}
+	newPath, err := checkReplacement(ctx, snapshot, fh.URI(), modulePath, dir)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := snapshot.ParseMod(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+	return editModFile(pm, func(f *modfile.File) error {
+		var restore []modfile.Comment
+		for _, r := range f.Replace {
+			if r.Old.Path == modulePath {
+				if modfile.IsDirectoryPath(r.New.Path) {
+					// Keep the replacements superseded by this one.
+					restore = append(restore, restoredReplaces(r.Syntax)...)
+				} else {
+					restore = append(restore, modfile.Comment{Token: restorePrefix + replaceString(r)})
+				}
+				if err := f.DropReplace(r.Old.Path, r.Old.Version); err != nil {
+					return err
+				}
+			}
+		}
+		if err := f.AddReplace(modulePath, "", newPath, ""); err != nil {
+			return err
+		}
+		if len(restore) > 0 {
+			r := f.Replace[len(f.Replace)-1]
+			r.Syntax.Comments.Before = append(r.Syntax.Comments.Before, restore...)
+		}
+		return nil
+	})
+}
+
+// RemoveReplace returns the edits to the go.mod file that remove the
+// local replacement of the specified module, restoring the
+// replacements recorded by AddReplace.
+func RemoveReplace(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, modulePath string) ([]protocol.TextEdit, error) {
+	pm, err := snapshot.ParseMod(ctx, fh)
+	if err != nil {
+		return nil, err
+	}
+	return editModFile(pm, func(f *modfile.File) error {
+		var local *modfile.Replace
+		for _, r := range f.Replace {
+			if r.Old.Path == modulePath && modfile.IsDirectoryPath(r.New.Path) {
+				local = r
+				break
+			}
+		}
+		if local == nil {
+			return fmt.Errorf("no local replacement of %s", modulePath)
+		}
+		restore := restoredReplaces(local.Syntax)
+		if err := f.DropReplace(local.Old.Path, local.Old.Version); err != nil {
+			return err
+		}
+		for _, c := range restore {
+			// Parse the recorded directive as a go.mod file of its own.
+			text := strings.TrimPrefix(c.Token, restorePrefix)
+			old, err := modfile.Parse("", []byte(text), nil)
+			if err != nil || len(old.Replace) != 1 {
+				return fmt.Errorf("invalid replacement to restore: %q", text)
+			}
+			r := old.Replace[0]
+			if err := f.AddReplace(r.Old.Path, r.Old.Version, r.New.Path, r.New.Version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// checkReplacement reports an error if the specified directory,
+// relative to that of the go.mod file, does not contain the module.
+// Otherwise it returns the directory as a replacement path.
+func checkReplacement(ctx context.Context, snapshot *cache.Snapshot, modURI protocol.DocumentURI, modulePath, dir string) (string, error) {
+	abs := dir
+	if !filepath.IsAbs(dir) {
+		abs = filepath.Join(modURI.Dir().Path(), dir)
+	}
+	fh, err := snapshot.ReadFile(ctx, protocol.URIFromPath(filepath.Join(abs, "go.mod")))
+	if err != nil {
+		return "", err
+	}
+	content, err := fh.Content()
+	if err != nil {
+		return "", fmt.Errorf("%s does not contain a go.mod file", dir)
+	}
+	if got := modfile.ModulePath(content); got != modulePath {
+		return "", fmt.Errorf("%s contains module %q, not %s", dir, got, modulePath)
+	}
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	// A relative replacement path must start with ./ or ../.
+	newPath := filepath.ToSlash(filepath.Clean(dir))
+	if !modfile.IsDirectoryPath(newPath) {
+		newPath = "./" + newPath
+	}
+	return newPath, nil
+}
+
+// restoredReplaces returns the comments of the line of a local
+// replacement that record the replacements it superseded.
+func restoredReplaces(line *modfile.Line) []modfile.Comment {
+	var comments []modfile.Comment
+	for _, c := range line.Comments.Before {
+		if strings.HasPrefix(c.Token, restorePrefix) {
+			comments = append(comments, c)
+		}
+	}
+	return comments
+}
+
+// replaceString returns the replace directive for r.
+func replaceString(r *modfile.Replace) string {
+	s := "replace " + modfile.AutoQuote(r.Old.Path)
+	if r.Old.Version != "" {
+		s += " " + r.Old.Version
+	}
+	s += " => " + modfile.AutoQuote(r.New.Path)
+	if r.New.Version != "" {
+		s += " " + r.New.Version
+	}
+	return s
+}
+
+// editModFile returns the edits to the go.mod file made by the edit
+// function, applied to a private copy of its syntax.
+func editModFile(pm *cache.ParsedModule, edit func(*modfile.File) error) ([]protocol.TextEdit, error) {
+	copied, err := modfile.Parse("", pm.Mapper.Content, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := edit(copied); err != nil {
+		return nil, err
+	}
+	copied.Cleanup()
+	newContent, err := copied.Format()
+	if err != nil {
+		return nil, err
+	}
+	return protocol.EditsFromDiffEdits(pm.Mapper, diff.Bytes(pm.Mapper.Content, newContent))
+}
@@ -290,3 +290,33 @@ func (wdw *workDoneWriter) Write(p []byte) (n int, err error) {
 	// Don't fail just because of a failure to report progress.
 	return len(p), nil
 }
+
+// PartialResults returns a function that reports a batch of the
+// results of a request to the client, as a $/progress notification
+// with the request's partial result token, so that the client can
+// show them before the request completes. It returns nil if token is
+// nil, meaning the client does not want partial results.
+//
+// The LSP requires that, once the server has reported a partial
+// result, it reports all the results in this way, and responds to the
+// request with an empty result.
+func PartialResults[T any](ctx context.Context, client protocol.Client, token *protocol.ProgressToken) func([]T) {
+	if token == nil || *token == nil {
+		return nil
+	}
+	var mu sync.Mutex // serializes notifications, so batches arrive in order
+	return func(batch []T) {
+		if len(batch) == 0 {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		err := client.Progress(ctx, &protocol.ProgressParams{
+			Token: *token,
+			Value: batch,
+		})
+		if err != nil {
+			event.Error(ctx, "reporting partial results", err)
+		}
+	}
+}
@@ -20,6 +20,7 @@ type fakeClient struct {
 
 	mu                                        sync.Mutex
 	created, begun, reported, messages, ended int
+	partialResults                            [][]string
 }
 
 func (c *fakeClient) checkToken(token protocol.ProgressToken) {
@@ -43,7 +44,9 @@ func (c *fakeClient) Progress(ctx context.Context, params *protocol.ProgressPara
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.checkToken(params.Token)
-	switch params.Value.(type) {
+	switch value := params.Value.(type) {
+	case []string:
+		c.partialResults = append(c.partialResults, value)
 	case *protocol.WorkDoneProgressBegin:
 		c.begun++
 	case *protocol.WorkDoneProgressReport:
@@ -159,3 +162,21 @@ func TestProgressTracker_Cancellation(t *testing.T) {
 		}
 	}
 }
+
+func TestPartialResults(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeClient{}
+	if report := PartialResults[string](ctx, client, nil); report != nil {
+		t.Errorf("PartialResults(nil token) = non-nil, want nil")
+	}
+
+	var token protocol.ProgressToken = "a"
+	client.token = token
+	report := PartialResults[string](ctx, client, &token)
+	report([]string{"x", "y"})
+	report(nil) // not reported
+	report([]string{"z"})
+	if got, want := fmt.Sprint(client.partialResults), "[[x y] [z]]"; got != want {
+		t.Errorf("got partial results %s, want %s", got, want)
+	}
+}
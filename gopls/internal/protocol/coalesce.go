@@ -0,0 +1,134 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"golang.org/x/tools/internal/jsonrpc2"
+)
+
+// A changeCoalescer merges bursts of textDocument/didChange
+// notifications for the same document, such as those sent for a
+// multi-cursor edit or by reformat-on-type, so that the server updates
+// the document, and invalidates what depends on it, once per burst.
+//
+// A notification is merged into the previous message only if that
+// message is a didChange notification for the same document that is
+// still waiting for its turn to be handled, so the server observes
+// the same sequence of document states, less some intermediate ones,
+// and messages are never reordered.
+type changeCoalescer struct {
+	mu      sync.Mutex
+	last    *pendingChange                      // the last message queued, if a pending didChange
+	pending map[jsonrpc2.Request]*pendingChange // queued didChange notifications
+}
+
+// A pendingChange is a queued didChange notification, into which
+// later notifications may be merged until it is handled.
+type pendingChange struct {
+	params  DidChangeTextDocumentParams
+	count   int  // number of notifications merged
+	started bool // the notification is being handled
+}
+
+// queue returns a handler that records, or merges, each didChange
+// notification before passing it to next. It must be called for each
+// message in the order in which the messages are received.
+func (c *changeCoalescer) queue(next jsonrpc2.Handler) jsonrpc2.Handler {
+	return func(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+		var params DidChangeTextDocumentParams
+		if req.Method() != "textDocument/didChange" || json.Unmarshal(req.Params(), &params) != nil {
+			c.mu.Lock()
+			c.last = nil
+			c.mu.Unlock()
+			return next(ctx, reply, req)
+		}
+
+		c.mu.Lock()
+		if last := c.last; last != nil && !last.started && last.merge(&params) {
+			c.mu.Unlock()
+			return reply(ctx, nil, nil)
+		}
+		p := &pendingChange{params: params, count: 1}
+		if c.pending == nil {
+			c.pending = make(map[jsonrpc2.Request]*pendingChange)
+		}
+		c.pending[req] = p
+		c.last = p
+		c.mu.Unlock()
+		return next(ctx, reply, req)
+	}
+}
+
+// dequeue returns a handler that passes each message to handler,
+// replacing a didChange notification by the result of the merges
+// into it, if any. It must be called when the message is handled.
+func (c *changeCoalescer) dequeue(handler jsonrpc2.Handler) jsonrpc2.Handler {
+	return func(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+		c.mu.Lock()
+		p, ok := c.pending[req]
+		if ok {
+			delete(c.pending, req)
+			p.started = true
+		}
+		c.mu.Unlock()
+
+		if ok && p.count > 1 {
+			merged, err := jsonrpc2.NewNotification(req.Method(), &p.params)
+			if err != nil {
+				return err
+			}
+			req = merged
+			ctx = context.WithValue(ctx, coalescedKey{}, p.count)
+		}
+		return handler(ctx, reply, req)
+	}
+}
+
+// merge merges the changes of a later notification for the same
+// document into p, and reports whether it could. A change of the full
+// content may only be merged by replacing all previous changes.
+func (p *pendingChange) merge(later *DidChangeTextDocumentParams) bool {
+	if later.TextDocument.URI != p.params.TextDocument.URI {
+		return false
+	}
+	changes := later.ContentChanges
+	if len(changes) == 1 && isFullChange(changes[0]) {
+		p.params.ContentChanges = nil
+	} else {
+		for _, list := range [][]TextDocumentContentChangeEvent{p.params.ContentChanges, changes} {
+			for _, change := range list {
+				if isFullChange(change) {
+					return false
+				}
+			}
+		}
+	}
+	p.params.ContentChanges = append(p.params.ContentChanges, changes...)
+	p.params.TextDocument.Version = later.TextDocument.Version
+	p.count++
+	return true
+}
+
+// isFullChange reports whether change replaces the full content of
+// the document.
+func isFullChange(change TextDocumentContentChangeEvent) bool {
+	return change.Range == nil && change.RangeLength == 0
+}
+
+type coalescedKey struct{}
+
+// CoalescedNotifications returns the number of didChange
+// notifications that were merged into the one being handled by ctx, or
+// 1 if none were.
+func CoalescedNotifications(ctx context.Context) int {
+	if n, ok := ctx.Value(coalescedKey{}).(int); ok {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protocol
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/tools/internal/jsonrpc2"
+)
+
+func TestCoalesceChanges(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		handled []string
+		wg      sync.WaitGroup
+		unblock = make(chan struct{})
+	)
+	handler := func(ctx context.Context, reply jsonrpc2.Replier, req jsonrpc2.Request) error {
+		defer wg.Done()
+		if req.Method() == "test/block" {
+			<-unblock
+		}
+		desc := req.Method()
+		if req.Method() == "textDocument/didChange" {
+			var params DidChangeTextDocumentParams
+			if err := json.Unmarshal(req.Params(), &params); err != nil {
+				t.Error(err)
+			}
+			var texts []string
+			for _, change := range params.ContentChanges {
+				texts = append(texts, change.Text)
+			}
+			desc = fmt.Sprintf("%s v%d %s (%d)", params.TextDocument.URI, params.TextDocument.Version, strings.Join(texts, ""), CoalescedNotifications(ctx))
+		}
+		mu.Lock()
+		handled = append(handled, desc)
+		mu.Unlock()
+		return reply(ctx, nil, nil)
+	}
+	var c changeCoalescer
+	h := c.queue(jsonrpc2.AsyncHandler(c.dequeue(handler)))
+
+	a, b := DocumentURI("file:///a.go"), DocumentURI("file:///b.go")
+	rng := &Range{}
+	send := func(method string, params any) {
+		req, err := jsonrpc2.NewNotification(method, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		noReply := func(context.Context, any, error) error { return nil }
+		if err := h(context.Background(), noReply, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	change := func(uri DocumentURI, version int32, changes ...TextDocumentContentChangeEvent) {
+		send("textDocument/didChange", &DidChangeTextDocumentParams{
+			TextDocument:   VersionedTextDocumentIdentifier{Version: version, TextDocumentIdentifier: TextDocumentIdentifier{URI: uri}},
+			ContentChanges: changes,
+		})
+	}
+
+	// While the server is busy, queue bursts of changes.
+	wg.Add(7)
+	send("test/block", nil)
+	change(a, 1, TextDocumentContentChangeEvent{Range: rng, Text: "x"})
+	change(a, 2, TextDocumentContentChangeEvent{Range: rng, Text: "y"})
+	change(a, 3, TextDocumentContentChangeEvent{Range: rng, Text: "z"})
+	change(b, 1, TextDocumentContentChangeEvent{Text: "full"})
+	change(b, 2, TextDocumentContentChangeEvent{Range: rng, Text: "p"}) // not merged into a full change
+	change(b, 3, TextDocumentContentChangeEvent{Text: "full2"})         // replaces the changes it is merged into
+	send("test/other", nil)
+	change(b, 4, TextDocumentContentChangeEvent{Range: rng, Text: "q"}) // not merged across another message
+	change(a, 4, TextDocumentContentChangeEvent{Range: rng, Text: "w"})
+	close(unblock)
+	wg.Wait()
+
+	want := []string{
+		"test/block",
+		"file:///a.go v3 xyz (3)",
+		"file:///b.go v1 full (1)",
+		"file:///b.go v3 full2 (2)",
+		"test/other",
+		"file:///b.go v4 q (1)",
+		"file:///a.go v4 w (1)",
+	}
+	if fmt.Sprint(handled) != fmt.Sprint(want) {
+		t.Errorf("handled:\n%s\nwant:\n%s", strings.Join(handled, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -74,10 +74,15 @@ package protocol
 // instead of == for CodeActionKinds throughout gopls.
 // See golang/go#40438 for related discussion.
 const (
-	GoAssembly    CodeActionKind = "source.assembly"
-	GoDoc         CodeActionKind = "source.doc"
-	GoFreeSymbols CodeActionKind = "source.freesymbols"
-	GoTest        CodeActionKind = "goTest" // TODO(adonovan): rename "source.test"
+	GoAnalyzerDoc  CodeActionKind = "source.analyzerdoc"
+	GoAssembly     CodeActionKind = "source.assembly"
+	GoClones       CodeActionKind = "source.clones"
+	GoDeadCode     CodeActionKind = "source.deadcode"
+	GoDeepAnalysis CodeActionKind = "source.deepanalysis"
+	GoDoc          CodeActionKind = "source.doc"
+	GoFreeSymbols  CodeActionKind = "source.freesymbols"
+	GoRegenerate   CodeActionKind = "source.regenerate"
+	GoTest         CodeActionKind = "goTest" // TODO(adonovan): rename "source.test"
 )
 
 // CodeActionUnknownTrigger indicates that the trigger for a
@@ -26,78 +26,144 @@ import (
 const (
 	AddDependency           Command = "gopls.add_dependency"
 	AddImport               Command = "gopls.add_import"
+	AddReplace              Command = "gopls.add_replace"
 	AddTelemetryCounters    Command = "gopls.add_telemetry_counters"
+	AddToDictionary         Command = "gopls.add_to_dictionary"
+	AnalyzeNow              Command = "gopls.analyze_now"
+	AnalyzerDoc             Command = "gopls.analyzer_doc"
 	ApplyFix                Command = "gopls.apply_fix"
 	Assembly                Command = "gopls.assembly"
 	ChangeSignature         Command = "gopls.change_signature"
 	CheckUpgrades           Command = "gopls.check_upgrades"
+	ConvertMethod           Command = "gopls.convert_method"
+	CreatePackage           Command = "gopls.create_package"
+	DeepAnalysis            Command = "gopls.deep_analysis"
+	Describe                Command = "gopls.describe"
 	DiagnoseFiles           Command = "gopls.diagnose_files"
 	Doc                     Command = "gopls.doc"
 	EditGoDirective         Command = "gopls.edit_go_directive"
+	ExtractInterface        Command = "gopls.extract_interface"
 	FetchVulncheckResult    Command = "gopls.fetch_vulncheck_result"
+	FilterDiagnostics       Command = "gopls.filter_diagnostics"
+	FindDeadCode            Command = "gopls.find_dead_code"
+	FixAll                  Command = "gopls.fix_all"
 	FreeSymbols             Command = "gopls.free_symbols"
 	GCDetails               Command = "gopls.gc_details"
 	Generate                Command = "gopls.generate"
+	GenerateConstructor     Command = "gopls.generate_constructor"
+	GeneratedLocation       Command = "gopls.generated_location"
 	GoGetPackage            Command = "gopls.go_get_package"
+	Importers               Command = "gopls.importers"
+	ListClones              Command = "gopls.list_clones"
 	ListImports             Command = "gopls.list_imports"
 	ListKnownPackages       Command = "gopls.list_known_packages"
+	MatchingBracket         Command = "gopls.matching_bracket"
 	MaybePromptForTelemetry Command = "gopls.maybe_prompt_for_telemetry"
 	MemStats                Command = "gopls.mem_stats"
+	MoveDeclaration         Command = "gopls.move_declaration"
+	NextDiagnostic          Command = "gopls.next_diagnostic"
+	NextReference           Command = "gopls.next_reference"
+	Peers                   Command = "gopls.peers"
+	PointsTo                Command = "gopls.points_to"
 	RegenerateCgo           Command = "gopls.regenerate_cgo"
 	RemoveDependency        Command = "gopls.remove_dependency"
+	RemoveReplace           Command = "gopls.remove_replace"
 	ResetGoModDiagnostics   Command = "gopls.reset_go_mod_diagnostics"
+	RunAnalyzer             Command = "gopls.run_analyzer"
 	RunGoWorkCommand        Command = "gopls.run_go_work_command"
 	RunGovulncheck          Command = "gopls.run_govulncheck"
+	RunMain                 Command = "gopls.run_main"
 	RunTests                Command = "gopls.run_tests"
+	SafeDelete              Command = "gopls.safe_delete"
 	ScanImports             Command = "gopls.scan_imports"
+	SearchDocs              Command = "gopls.search_docs"
+	SelectRange             Command = "gopls.select_range"
+	ShowNextClone           Command = "gopls.show_next_clone"
+	SnoozeUpdate            Command = "gopls.snooze_update"
 	StartDebugging          Command = "gopls.start_debugging"
 	StartProfile            Command = "gopls.start_profile"
 	StopProfile             Command = "gopls.stop_profile"
+	TagReferences           Command = "gopls.tag_references"
 	Tidy                    Command = "gopls.tidy"
 	ToggleGCDetails         Command = "gopls.toggle_gc_details"
 	UpdateGoSum             Command = "gopls.update_go_sum"
 	UpgradeDependency       Command = "gopls.upgrade_dependency"
 	Vendor                  Command = "gopls.vendor"
+	ViewInfo                Command = "gopls.view_info"
 	Views                   Command = "gopls.views"
+	WhichErrs               Command = "gopls.which_errs"
 	WorkspaceStats          Command = "gopls.workspace_stats"
 )
 
 var Commands = []Command{
 	AddDependency,
 	AddImport,
+	AddReplace,
 	AddTelemetryCounters,
+	AddToDictionary,
+	AnalyzeNow,
+	AnalyzerDoc,
 	ApplyFix,
 	Assembly,
 	ChangeSignature,
 	CheckUpgrades,
+	ConvertMethod,
+	CreatePackage,
+	DeepAnalysis,
+	Describe,
 	DiagnoseFiles,
 	Doc,
 	EditGoDirective,
+	ExtractInterface,
 	FetchVulncheckResult,
+	FilterDiagnostics,
+	FindDeadCode,
+	FixAll,
 	FreeSymbols,
 	GCDetails,
 	Generate,
+	GenerateConstructor,
+	GeneratedLocation,
 	GoGetPackage,
+	Importers,
+	ListClones,
 	ListImports,
 	ListKnownPackages,
+	MatchingBracket,
 	MaybePromptForTelemetry,
 	MemStats,
+	MoveDeclaration,
+	NextDiagnostic,
+	NextReference,
+	Peers,
+	PointsTo,
 	RegenerateCgo,
 	RemoveDependency,
+	RemoveReplace,
 	ResetGoModDiagnostics,
+	RunAnalyzer,
 	RunGoWorkCommand,
 	RunGovulncheck,
+	RunMain,
 	RunTests,
+	SafeDelete,
 	ScanImports,
+	SearchDocs,
+	SelectRange,
+	ShowNextClone,
+	SnoozeUpdate,
 	StartDebugging,
 	StartProfile,
 	StopProfile,
+	TagReferences,
 	Tidy,
 	ToggleGCDetails,
 	UpdateGoSum,
 	UpgradeDependency,
 	Vendor,
+	ViewInfo,
 	Views,
+	WhichErrs,
 	WorkspaceStats,
 }
 
@@ -115,12 +181,32 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.AddImport(ctx, a0)
+	case AddReplace:
+		var a0 AddReplaceArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.AddReplace(ctx, a0)
 	case AddTelemetryCounters:
 		var a0 AddTelemetryCountersArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
 			return nil, err
 		}
 		return nil, s.AddTelemetryCounters(ctx, a0)
+	case AddToDictionary:
+		var a0 AddToDictionaryArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.AddToDictionary(ctx, a0)
+	case AnalyzeNow:
+		return nil, s.AnalyzeNow(ctx)
+	case AnalyzerDoc:
+		var a0 AnalyzerDocArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.AnalyzerDoc(ctx, a0)
 	case ApplyFix:
 		var a0 ApplyFixArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -147,6 +233,30 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.CheckUpgrades(ctx, a0)
+	case ConvertMethod:
+		var a0 ConvertMethodArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.ConvertMethod(ctx, a0)
+	case CreatePackage:
+		var a0 CreatePackageArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.CreatePackage(ctx, a0)
+	case DeepAnalysis:
+		var a0 URIArg
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.DeepAnalysis(ctx, a0)
+	case Describe:
+		var a0 protocol.Location
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.Describe(ctx, a0)
 	case DiagnoseFiles:
 		var a0 DiagnoseFilesArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -165,12 +275,36 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.EditGoDirective(ctx, a0)
+	case ExtractInterface:
+		var a0 ExtractInterfaceArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.ExtractInterface(ctx, a0)
 	case FetchVulncheckResult:
 		var a0 URIArg
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
 			return nil, err
 		}
 		return s.FetchVulncheckResult(ctx, a0)
+	case FilterDiagnostics:
+		var a0 FilterDiagnosticsArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.FilterDiagnostics(ctx, a0)
+	case FindDeadCode:
+		var a0 URIArg
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.FindDeadCode(ctx, a0)
+	case FixAll:
+		var a0 FixAllArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.FixAll(ctx, a0)
 	case FreeSymbols:
 		var a0 string
 		var a1 protocol.Location
@@ -190,12 +324,36 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.Generate(ctx, a0)
+	case GenerateConstructor:
+		var a0 GenerateConstructorArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.GenerateConstructor(ctx, a0)
+	case GeneratedLocation:
+		var a0 protocol.Location
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.GeneratedLocation(ctx, a0)
 	case GoGetPackage:
 		var a0 GoGetPackageArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
 			return nil, err
 		}
 		return nil, s.GoGetPackage(ctx, a0)
+	case Importers:
+		var a0 ImportersArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.Importers(ctx, a0)
+	case ListClones:
+		var a0 URIArg
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.ListClones(ctx, a0)
 	case ListImports:
 		var a0 URIArg
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -208,10 +366,46 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return s.ListKnownPackages(ctx, a0)
+	case MatchingBracket:
+		var a0 protocol.Location
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.MatchingBracket(ctx, a0)
 	case MaybePromptForTelemetry:
 		return nil, s.MaybePromptForTelemetry(ctx)
 	case MemStats:
 		return s.MemStats(ctx)
+	case MoveDeclaration:
+		var a0 MoveDeclarationArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.MoveDeclaration(ctx, a0)
+	case NextDiagnostic:
+		var a0 NextDiagnosticArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.NextDiagnostic(ctx, a0)
+	case NextReference:
+		var a0 NextReferenceArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.NextReference(ctx, a0)
+	case Peers:
+		var a0 protocol.Location
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.Peers(ctx, a0)
+	case PointsTo:
+		var a0 protocol.Location
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.PointsTo(ctx, a0)
 	case RegenerateCgo:
 		var a0 URIArg
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -224,12 +418,24 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.RemoveDependency(ctx, a0)
+	case RemoveReplace:
+		var a0 RemoveReplaceArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.RemoveReplace(ctx, a0)
 	case ResetGoModDiagnostics:
 		var a0 ResetGoModDiagnosticsArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
 			return nil, err
 		}
 		return nil, s.ResetGoModDiagnostics(ctx, a0)
+	case RunAnalyzer:
+		var a0 RunAnalyzerArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.RunAnalyzer(ctx, a0)
 	case RunGoWorkCommand:
 		var a0 RunGoWorkArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -242,14 +448,50 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return s.RunGovulncheck(ctx, a0)
+	case RunMain:
+		var a0 RunMainArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.RunMain(ctx, a0)
 	case RunTests:
 		var a0 RunTestsArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
 			return nil, err
 		}
 		return nil, s.RunTests(ctx, a0)
+	case SafeDelete:
+		var a0 SafeDeleteArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.SafeDelete(ctx, a0)
 	case ScanImports:
 		return nil, s.ScanImports(ctx)
+	case SearchDocs:
+		var a0 SearchDocsArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.SearchDocs(ctx, a0)
+	case SelectRange:
+		var a0 protocol.Location
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.SelectRange(ctx, a0)
+	case ShowNextClone:
+		var a0 protocol.Location
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.ShowNextClone(ctx, a0)
+	case SnoozeUpdate:
+		var a0 SnoozeUpdateArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return nil, s.SnoozeUpdate(ctx, a0)
 	case StartDebugging:
 		var a0 DebuggingArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -268,6 +510,12 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return s.StopProfile(ctx, a0)
+	case TagReferences:
+		var a0 protocol.Location
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.TagReferences(ctx, a0)
 	case Tidy:
 		var a0 URIArgs
 		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
@@ -298,8 +546,20 @@ func Dispatch(ctx context.Context, params *protocol.ExecuteCommandParams, s Inte
 			return nil, err
 		}
 		return nil, s.Vendor(ctx, a0)
+	case ViewInfo:
+		var a0 ViewInfoArgs
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.ViewInfo(ctx, a0)
 	case Views:
 		return s.Views(ctx)
+	case WhichErrs:
+		var a0 protocol.Location
+		if err := UnmarshalArgs(params.Arguments, &a0); err != nil {
+			return nil, err
+		}
+		return s.WhichErrs(ctx, a0)
 	case WorkspaceStats:
 		return s.WorkspaceStats(ctx)
 	}
@@ -330,6 +590,18 @@ func NewAddImportCommand(title string, a0 AddImportArgs) (protocol.Command, erro
 	}, nil
 }
 
+func NewAddReplaceCommand(title string, a0 AddReplaceArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   AddReplace.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewAddTelemetryCountersCommand(title string, a0 AddTelemetryCountersArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -342,6 +614,37 @@ func NewAddTelemetryCountersCommand(title string, a0 AddTelemetryCountersArgs) (
 	}, nil
 }
 
+func NewAddToDictionaryCommand(title string, a0 AddToDictionaryArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   AddToDictionary.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewAnalyzeNowCommand(title string) (protocol.Command, error) {
+	return protocol.Command{
+		Title:   title,
+		Command: AnalyzeNow.String(),
+	}, nil
+}
+
+func NewAnalyzerDocCommand(title string, a0 AnalyzerDocArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   AnalyzerDoc.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewApplyFixCommand(title string, a0 ApplyFixArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -390,6 +693,54 @@ func NewCheckUpgradesCommand(title string, a0 CheckUpgradesArgs) (protocol.Comma
 	}, nil
 }
 
+func NewConvertMethodCommand(title string, a0 ConvertMethodArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   ConvertMethod.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewCreatePackageCommand(title string, a0 CreatePackageArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   CreatePackage.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewDeepAnalysisCommand(title string, a0 URIArg) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   DeepAnalysis.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewDescribeCommand(title string, a0 protocol.Location) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   Describe.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewDiagnoseFilesCommand(title string, a0 DiagnoseFilesArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -426,6 +777,18 @@ func NewEditGoDirectiveCommand(title string, a0 EditGoDirectiveArgs) (protocol.C
 	}, nil
 }
 
+func NewExtractInterfaceCommand(title string, a0 ExtractInterfaceArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   ExtractInterface.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewFetchVulncheckResultCommand(title string, a0 URIArg) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -438,6 +801,42 @@ func NewFetchVulncheckResultCommand(title string, a0 URIArg) (protocol.Command,
 	}, nil
 }
 
+func NewFilterDiagnosticsCommand(title string, a0 FilterDiagnosticsArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   FilterDiagnostics.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewFindDeadCodeCommand(title string, a0 URIArg) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   FindDeadCode.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewFixAllCommand(title string, a0 FixAllArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   FixAll.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewFreeSymbolsCommand(title string, a0 string, a1 protocol.Location) (protocol.Command, error) {
 	args, err := MarshalArgs(a0, a1)
 	if err != nil {
@@ -474,6 +873,30 @@ func NewGenerateCommand(title string, a0 GenerateArgs) (protocol.Command, error)
 	}, nil
 }
 
+func NewGenerateConstructorCommand(title string, a0 GenerateConstructorArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   GenerateConstructor.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewGeneratedLocationCommand(title string, a0 protocol.Location) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   GeneratedLocation.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewGoGetPackageCommand(title string, a0 GoGetPackageArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -486,6 +909,30 @@ func NewGoGetPackageCommand(title string, a0 GoGetPackageArgs) (protocol.Command
 	}, nil
 }
 
+func NewImportersCommand(title string, a0 ImportersArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   Importers.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewListClonesCommand(title string, a0 URIArg) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   ListClones.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewListImportsCommand(title string, a0 URIArg) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -510,6 +957,18 @@ func NewListKnownPackagesCommand(title string, a0 URIArg) (protocol.Command, err
 	}, nil
 }
 
+func NewMatchingBracketCommand(title string, a0 protocol.Location) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   MatchingBracket.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewMaybePromptForTelemetryCommand(title string) (protocol.Command, error) {
 	return protocol.Command{
 		Title:   title,
@@ -524,6 +983,66 @@ func NewMemStatsCommand(title string) (protocol.Command, error) {
 	}, nil
 }
 
+func NewMoveDeclarationCommand(title string, a0 MoveDeclarationArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   MoveDeclaration.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewNextDiagnosticCommand(title string, a0 NextDiagnosticArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   NextDiagnostic.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewNextReferenceCommand(title string, a0 NextReferenceArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   NextReference.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewPeersCommand(title string, a0 protocol.Location) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   Peers.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewPointsToCommand(title string, a0 protocol.Location) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   PointsTo.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewRegenerateCgoCommand(title string, a0 URIArg) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -548,6 +1067,18 @@ func NewRemoveDependencyCommand(title string, a0 RemoveDependencyArgs) (protocol
 	}, nil
 }
 
+func NewRemoveReplaceCommand(title string, a0 RemoveReplaceArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   RemoveReplace.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewResetGoModDiagnosticsCommand(title string, a0 ResetGoModDiagnosticsArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -560,6 +1091,18 @@ func NewResetGoModDiagnosticsCommand(title string, a0 ResetGoModDiagnosticsArgs)
 	}, nil
 }
 
+func NewRunAnalyzerCommand(title string, a0 RunAnalyzerArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   RunAnalyzer.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewRunGoWorkCommandCommand(title string, a0 RunGoWorkArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -584,6 +1127,18 @@ func NewRunGovulncheckCommand(title string, a0 VulncheckArgs) (protocol.Command,
 	}, nil
 }
 
+func NewRunMainCommand(title string, a0 RunMainArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   RunMain.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewRunTestsCommand(title string, a0 RunTestsArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -596,6 +1151,18 @@ func NewRunTestsCommand(title string, a0 RunTestsArgs) (protocol.Command, error)
 	}, nil
 }
 
+func NewSafeDeleteCommand(title string, a0 SafeDeleteArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   SafeDelete.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewScanImportsCommand(title string) (protocol.Command, error) {
 	return protocol.Command{
 		Title:   title,
@@ -603,6 +1170,54 @@ func NewScanImportsCommand(title string) (protocol.Command, error) {
 	}, nil
 }
 
+func NewSearchDocsCommand(title string, a0 SearchDocsArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   SearchDocs.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewSelectRangeCommand(title string, a0 protocol.Location) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   SelectRange.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewShowNextCloneCommand(title string, a0 protocol.Location) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   ShowNextClone.String(),
+		Arguments: args,
+	}, nil
+}
+
+func NewSnoozeUpdateCommand(title string, a0 SnoozeUpdateArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   SnoozeUpdate.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewStartDebuggingCommand(title string, a0 DebuggingArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -639,6 +1254,18 @@ func NewStopProfileCommand(title string, a0 StopProfileArgs) (protocol.Command,
 	}, nil
 }
 
+func NewTagReferencesCommand(title string, a0 protocol.Location) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   TagReferences.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewTidyCommand(title string, a0 URIArgs) (protocol.Command, error) {
 	args, err := MarshalArgs(a0)
 	if err != nil {
@@ -699,6 +1326,18 @@ func NewVendorCommand(title string, a0 URIArg) (protocol.Command, error) {
 	}, nil
 }
 
+func NewViewInfoCommand(title string, a0 ViewInfoArgs) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   ViewInfo.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewViewsCommand(title string) (protocol.Command, error) {
 	return protocol.Command{
 		Title:   title,
@@ -706,6 +1345,18 @@ func NewViewsCommand(title string) (protocol.Command, error) {
 	}, nil
 }
 
+func NewWhichErrsCommand(title string, a0 protocol.Location) (protocol.Command, error) {
+	args, err := MarshalArgs(a0)
+	if err != nil {
+		return protocol.Command{}, err
+	}
+	return protocol.Command{
+		Title:     title,
+		Command:   WhichErrs.String(),
+		Arguments: args,
+	}, nil
+}
+
 func NewWorkspaceStatsCommand(title string) (protocol.Command, error) {
 	return protocol.Command{
 		Title:   title,
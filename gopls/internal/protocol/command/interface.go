@@ -45,6 +45,16 @@ type Interface interface {
 	// Applies a fix to a region of source code.
 	ApplyFix(context.Context, ApplyFixArgs) (*protocol.WorkspaceEdit, error)
 
+	// SelectRange: Select a range in an editor
+	//
+	// Shows the document at the specified location, selecting its
+	// range. Commands that edit code select a sensible range once
+	// their edits are applied, such as the name of an extracted
+	// function; when their edits are instead resolved for a code
+	// action, the code action has this command, which clients
+	// execute after applying the edits.
+	SelectRange(context.Context, protocol.Location) error
+
 	// Test: Run test(s)
 	//
 	// Runs `go test` for a specific set of test or benchmark functions.
@@ -78,9 +88,12 @@ type Interface interface {
 	// Runs `go mod vendor` for a module.
 	Vendor(context.Context, URIArg) error
 
-	// EditGoDirective: Run go mod edit -go=version
+	// EditGoDirective: Set the go directive of a module
 	//
-	// Runs `go mod edit -go=version` for a module.
+	// Sets the go directive of a module to the specified version,
+	// raising that of the go.work file, and of the workspace modules
+	// that require the module, if they are no longer compatible.
+	// A toolchain directive older than the version is removed.
 	EditGoDirective(context.Context, EditGoDirectiveArgs) error
 
 	// UpdateGoSum: Update go.sum
@@ -93,6 +106,13 @@ type Interface interface {
 	// Checks for module upgrades.
 	CheckUpgrades(context.Context, CheckUpgradesArgs) error
 
+	// SnoozeUpdate: Snooze update notices for a module
+	//
+	// Suppresses the notices of the dependencyUpdates setting about
+	// the specified module until a version newer than the specified
+	// one is available, for the rest of the session.
+	SnoozeUpdate(context.Context, SnoozeUpdateArgs) error
+
 	// AddDependency: Add a dependency
 	//
 	// Adds a dependency to the go.mod file for a module.
@@ -108,6 +128,22 @@ type Interface interface {
 	// Removes a dependency from the go.mod file of a module.
 	RemoveDependency(context.Context, RemoveDependencyArgs) error
 
+	// AddReplace: Replace a dependency with a local directory
+	//
+	// Adds a replace directive to the go.mod file of a module that
+	// points a dependency at a local directory, after checking that
+	// the directory contains a go.mod file declaring that module.
+	// Any existing replacements of the dependency are kept as comments
+	// so that RemoveReplace can restore them.
+	AddReplace(context.Context, AddReplaceArgs) error
+
+	// RemoveReplace: Remove a local replace directive
+	//
+	// Removes the replace directive that points a dependency at a
+	// local directory from the go.mod file of a module, restoring the
+	// replacements that AddReplace kept as comments.
+	RemoveReplace(context.Context, RemoveReplaceArgs) error
+
 	// ResetGoModDiagnostics: Reset go.mod diagnostics
 	//
 	// Reset diagnostics in the go.mod file of a module.
@@ -118,6 +154,13 @@ type Interface interface {
 	// Runs `go get` to fetch a package.
 	GoGetPackage(context.Context, GoGetPackageArgs) error
 
+	// CreatePackage: Create a missing package
+	//
+	// Creates the directory of a missing package of the module of
+	// a file that imports it, if necessary, with a doc.go file that
+	// declares the package.
+	CreatePackage(context.Context, CreatePackageArgs) error
+
 	// GCDetails: Toggle gc_details
 	//
 	// Toggle the calculation of gc annotations.
@@ -130,6 +173,105 @@ type Interface interface {
 	// Toggle the calculation of gc annotations.
 	ToggleGCDetails(context.Context, URIArg) error
 
+	// DeepAnalysis: Run deep analysis on this package
+	//
+	// Builds SSA for the package containing the specified file and
+	// all its dependencies, and reports potential nil dereferences
+	// and ignored error results, with explanations of the call paths
+	// that lead to them. The results are reported as diagnostics
+	// until the next edit to any file.
+	DeepAnalysis(context.Context, URIArg) error
+
+	// FindDeadCode: Find dead code in the workspace
+	//
+	// Computes the functions of the workspace packages that are
+	// unreachable from the main functions and tests of the
+	// workspace, and reports them as diagnostics until the next edit
+	// to any file. Functions whose doc comment, or whose receiver
+	// type's doc comment, contains a //gopls:keep directive are not
+	// reported.
+	FindDeadCode(context.Context, URIArg) error
+
+	// Describe: Describe the selected syntax
+	//
+	// Describes the innermost syntax node enclosing the specified
+	// range: for an expression, its type and constant value, if any;
+	// for an identifier, the declaration of the object to which it
+	// refers; for a type, its method set; and for a package name,
+	// its exported members.
+	Describe(context.Context, protocol.Location) (DescribeResult, error)
+
+	// PointsTo: Find what a pointer-like expression may point to
+	//
+	// Computes the allocation sites of the variables, maps,
+	// channels, slices, and functions to which the selected
+	// expression of pointer, map, channel, slice, or function type
+	// may refer, and for an expression of interface type, the
+	// conversions of concrete values that it may hold. The analysis
+	// builds SSA for the workspace and its dependencies; it is
+	// flow-insensitive, treats all instances of a struct field as
+	// one variable, and resolves dynamic calls by class hierarchy
+	// analysis, so its results are an over-approximation.
+	PointsTo(context.Context, protocol.Location) (PointsToResult, error)
+
+	// Peers: Find the peers of a channel operation
+	//
+	// Computes the allocation sites of the channel of the send,
+	// receive, or close operation at the specified location, and the
+	// other operations on the channels allocated there, using the
+	// same analysis as gopls.points_to.
+	Peers(context.Context, protocol.Location) (PeersResult, error)
+
+	// WhichErrs: Find the possible values of an error
+	//
+	// Computes the package-level error variables (such as io.EOF),
+	// the constants, and the concrete types of the values that the
+	// selected expression of type error may hold, using the same
+	// analysis as gopls.points_to.
+	WhichErrs(context.Context, protocol.Location) (WhichErrsResult, error)
+
+	// ListClones: List duplicated functions
+	//
+	// Lists the clusters of duplicated functions in the workspace of
+	// the specified file, largest functions first. Functions are
+	// duplicates if their signatures and bodies are the same, or
+	// differ only by a consistent renaming of identifiers and by the
+	// values of literals.
+	ListClones(context.Context, URIArg) (ListClonesResult, error)
+
+	// ShowNextClone: Jump to the next duplicate of a function
+	//
+	// Shows the next function, in order of location, of the cluster of
+	// duplicated functions that includes the function whose name is at
+	// the specified location, cycling back to the first after the last.
+	ShowNextClone(context.Context, protocol.Location) error
+
+	// AddToDictionary: Add a word to the spelling dictionary
+	//
+	// Adds a word to the file named by the spellingDictionary
+	// setting, in the workspace folder of the specified file, so that
+	// it is no longer reported as a misspelling.
+	AddToDictionary(context.Context, AddToDictionaryArgs) error
+
+	// RunAnalyzer: Run an analyzer once
+	//
+	// Runs the named analyzer over the package of the specified
+	// file, or over the workspace packages in the specified
+	// directory and its subdirectories, even if the analyzer is
+	// disabled by the analyses setting. It returns the analyzer's
+	// findings, by file, without publishing them as diagnostics.
+	RunAnalyzer(context.Context, RunAnalyzerArgs) (map[protocol.DocumentURI][]protocol.Diagnostic, error)
+
+	// FixAll: Fix all diagnostics of an analyzer
+	//
+	// Applies, at once, the quick fixes of all the diagnostics of the
+	// named analyzer in the package of the specified file, or in the
+	// workspace packages in the specified directory and its
+	// subdirectories. A fix that conflicts with the fix of an earlier
+	// diagnostic is left for a later application. Generated files are
+	// not edited.
+	FixAll(context.Context, FixAllArgs) (*protocol.WorkspaceEdit, error)
+
 	// ListKnownPackages: List known packages
 	//
 	// Retrieve a list of packages that are importable from the given URI.
@@ -216,20 +358,197 @@ type Interface interface {
 
 	// ChangeSignature: Perform a "change signature" refactoring
 	//
-	// This command is experimental, currently only supporting parameter removal.
+	// This command is experimental. It removes, reorders, or adds
+	// parameters of a function, rewriting its declaration and every call
+	// in the workspace. If neither a parameter to remove nor the new
+	// parameters are specified, the user is asked to reorder or remove
+	// the parameters interactively.
 	// Its signature will certainly change in the future (pun intended).
 	ChangeSignature(context.Context, ChangeSignatureArgs) (*protocol.WorkspaceEdit, error)
 
+	// MoveDeclaration: Move a declaration to another file or package
+	//
+	// Moves the top-level declaration named at the specified location
+	// to another file of its package, or to another package of the
+	// workspace, updating imports and all references to it. If no
+	// destination file is specified, the user is asked to choose a
+	// destination package.
+	MoveDeclaration(context.Context, MoveDeclarationArgs) (*protocol.WorkspaceEdit, error)
+
+	// SafeDelete: Delete an unreferenced declaration
+	//
+	// Deletes the top-level declaration named at the specified
+	// location, along with its doc comment, the methods of a deleted
+	// type, and the imports that become unused. If the declaration is
+	// referenced elsewhere in the workspace, nothing is deleted and
+	// the references are reported.
+	SafeDelete(context.Context, SafeDeleteArgs) (*protocol.WorkspaceEdit, error)
+
+	// ConvertMethod: Convert a function to a method, or vice versa
+	//
+	// Converts the function named at the specified location to a
+	// method of the named type of its first parameter, or the method
+	// named there to a function whose first parameter is the
+	// receiver. Every call in the workspace is rewritten: F(x, args)
+	// becomes x.F(args), and x.M(args) becomes M(x, args).
+	ConvertMethod(context.Context, ConvertMethodArgs) (*protocol.WorkspaceEdit, error)
+
+	// ExtractInterface: Extract an interface from a type
+	//
+	// Declares an interface with the specified methods of the type
+	// named at the specified location, just before the type's
+	// declaration, and changes the type of the specified function
+	// parameters from the type to the interface. If no methods are
+	// specified, the user is asked to choose the methods, and then
+	// the parameters, interactively.
+	ExtractInterface(context.Context, ExtractInterfaceArgs) (*protocol.WorkspaceEdit, error)
+
+	// GenerateConstructor: Generate a constructor for a struct type
+	//
+	// Declares a constructor for the struct type named at the
+	// specified location, just after the type's declaration, whose
+	// parameters set the specified fields. If Options is set, it
+	// also declares a functional option type and, for each other
+	// field, a function returning an option that sets it. If Fields
+	// is nil, the user is asked to choose the fields interactively.
+	GenerateConstructor(context.Context, GenerateConstructorArgs) (*protocol.WorkspaceEdit, error)
+
 	// DiagnoseFiles: Cause server to publish diagnostics for the specified files.
 	//
 	// This command is needed by the 'gopls {check,fix}' CLI subcommands.
 	DiagnoseFiles(context.Context, DiagnoseFilesArgs) error
 
+	// FilterDiagnostics: Mute or unmute sources of diagnostics
+	//
+	// Changes the set of diagnostic sources whose diagnostics are
+	// not published, for the rest of the session, without changing
+	// any settings. Each diagnostic is published with a hierarchical
+	// source such as "compiler", "compiler/syntax", "analyzer/printf",
+	// "vulncheck/govulncheck" or "upgrade-advisor/upgrade"; a filter
+	// mutes a source and all the sources below it, so "analyzer"
+	// mutes the diagnostics of all analyzers. It returns the muted
+	// filters and the sources of the current diagnostics.
+	FilterDiagnostics(context.Context, FilterDiagnosticsArgs) (FilterDiagnosticsResult, error)
+
+	// AnalyzeNow: Run all diagnostic sources now
+	//
+	// Computes and publishes the diagnostics of all sources for the
+	// workspace, including those that the analysisTriggers setting
+	// defers until a save, a period without changes, or this command.
+	AnalyzeNow(context.Context) error
+
+	// NextDiagnostic: Find the next or previous diagnostic
+	//
+	// Returns the location of the diagnostic that follows, or if
+	// Previous is set precedes, the specified position, among the
+	// diagnostics last published for all files, ordered by file and
+	// position and wrapping around at either end. If Severity is
+	// set, only diagnostics at least that severe are considered.
+	// It returns null if there is no such diagnostic. It is intended
+	// for clients that do not keep a store of diagnostics.
+	NextDiagnostic(context.Context, NextDiagnosticArgs) (*protocol.Location, error)
+
+	// NextReference: Find the next or previous reference
+	//
+	// Returns the location of the reference (or declaration) of the
+	// symbol at the specified position that follows, or if Previous
+	// is set precedes, that position, ordered by file and position
+	// and wrapping around at either end. It returns null if the
+	// symbol has no other references.
+	NextReference(context.Context, NextReferenceArgs) (*protocol.Location, error)
+
+	// MatchingBracket: Find the matching bracket
+	//
+	// Returns the location of the bracket that matches the one
+	// immediately after, or failing that before, the specified
+	// position in a Go file. Brackets are matched according to the
+	// syntax tree, so brackets within string literals and comments
+	// are ignored, and the square brackets of type parameters and
+	// instantiations are matched as well as those of index
+	// expressions. It returns null if there is no bracket at the
+	// position.
+	MatchingBracket(context.Context, protocol.Location) (*protocol.Location, error)
+
+	// TagReferences: Find references to a struct field, including textual matches of its tag
+	//
+	// Returns the references to the struct field at the specified
+	// location, followed by the string literals in the workspace
+	// that match the key of the field's json or yaml tag, as for the
+	// tagReferences setting, whether or not that setting is enabled.
+	// Each textual match is marked as such, since it may be
+	// unrelated to the field.
+	TagReferences(context.Context, protocol.Location) ([]TagReference, error)
+
+	// GeneratedLocation: Find the generated code for a line of source
+	//
+	// Returns the locations in the Go files of the workspace
+	// packages that //line directives map to the line of the
+	// specified location, such as the code that goyacc generates
+	// for a grammar rule, or that cgo generates for a Go file. It is
+	// the counterpart of the mapping of definitions and references
+	// by the lineDirectives setting, whether or not that setting is
+	// enabled. It returns an empty list if no code maps to the line.
+	GeneratedLocation(context.Context, protocol.Location) ([]protocol.Location, error)
+
+	// AnalyzerDoc: Show the documentation of an analyzer
+	//
+	// Returns, as Markdown, the documentation of the named analyzer:
+	// its complete description, an example of its fixes taken from
+	// its tests, if any, and a link to its package documentation.
+	// It is offered as a code action of kind source.analyzerdoc for
+	// each analyzer diagnostic in a code action request, for clients
+	// that can display the result.
+	AnalyzerDoc(context.Context, AnalyzerDocArgs) (AnalyzerDocResult, error)
+
+	// SearchDocs: Search the doc comments of the workspace
+	//
+	// Returns the declarations of the workspace packages and of
+	// their direct dependencies whose doc comments contain all the
+	// words of the query, ignoring case, along with the line of each
+	// comment that contains the first match. Package doc comments
+	// are searched too. Only exported declarations of dependencies
+	// are considered. It is intended for clients that offer a
+	// "search documentation" panel.
+	SearchDocs(context.Context, SearchDocsArgs) (SearchDocsResult, error)
+
+	// RunMain: Run a main package
+	//
+	// Builds and runs, as by `go run`, the main package of the
+	// specified file, or that file alone, streaming the output of
+	// the program through progress notifications; cancelling the
+	// progress kills the program. The arguments and environment of
+	// each run are remembered per package, or per file when it is
+	// run alone, in the gopls file cache, and are used by later
+	// runs, even in other sessions, that do not specify them.
+	//
+	// This command is asynchronous; clients must wait for the 'end' progress notification.
+	RunMain(context.Context, RunMainArgs) error
+
+	// Importers: List the importers of a package
+	//
+	// Returns the packages that import the package of the specified
+	// file, directly or, up to the specified depth, transitively,
+	// according to the loaded metadata of the workspace. Each
+	// importer has the locations of its import declarations through
+	// which it depends on the package, like a list of references.
+	// This is useful to assess the impact of a breaking change.
+	Importers(context.Context, ImportersArgs) (ImportersResult, error)
+
 	// Views: List current Views on the server.
 	//
 	// This command is intended for use by gopls tests only.
 	Views(context.Context) ([]View, error)
 
+	// ViewInfo: Describe the configuration of views
+	//
+	// Describes the effective configuration of each view, or of the
+	// view of the specified file: its type, its root, the go.mod or
+	// go.work file in use, the environment and build flags with which
+	// it loads packages, and the number of packages it has loaded.
+	// It is intended for clients that explain in a status bar item
+	// why gopls behaves as it does for the current file.
+	ViewInfo(context.Context, ViewInfoArgs) ([]ViewDescription, error)
+
 	// FreeSymbols: Browse free symbols referenced by the selection in a browser.
 	//
 	// This command is a query over a selected range of Go source
@@ -299,6 +618,51 @@ type URIArg struct {
 	URI protocol.DocumentURI
 }
 
+type AddToDictionaryArgs struct {
+	// A file in the workspace folder whose dictionary to update.
+	URI protocol.DocumentURI
+	// The word to add.
+	Word string
+}
+
+// ListClonesResult holds the result of the ListClones command.
+type ListClonesResult struct {
+	// The clusters of duplicated functions.
+	Clusters []CloneCluster
+}
+
+// A CloneCluster is a set of two or more duplicated functions.
+type CloneCluster struct {
+	// The number of tokens in the signature and body of each function.
+	Tokens int
+	// The functions of the cluster, in order of location.
+	Clones []Clone
+}
+
+// A Clone is a function that belongs to a CloneCluster.
+type Clone struct {
+	// The name of the function, such as "F", or "T.M" for a method.
+	Name string
+	// The location of the function name.
+	Location protocol.Location
+}
+
+type RunAnalyzerArgs struct {
+	// The name of the analyzer, such as "shadow".
+	Analyzer string
+	// A Go file, whose package to analyze, or a directory.
+	URI protocol.DocumentURI
+}
+
+type FixAllArgs struct {
+	// The name of the analyzer, such as "deprecated".
+	Analyzer string
+	// A Go file, whose package to fix, or a directory.
+	URI protocol.DocumentURI
+	// Whether to resolve and return the edits.
+	ResolveEdits bool
+}
+
 type URIArgs struct {
 	// The file URIs.
 	URIs []protocol.DocumentURI
@@ -331,10 +695,28 @@ type RemoveDependencyArgs struct {
 	OnlyDiagnostic bool
 }
 
+type AddReplaceArgs struct {
+	// The go.mod file URI.
+	URI protocol.DocumentURI
+	// The module path to replace.
+	ModulePath string
+	// The directory containing the replacement module, either absolute
+	// or relative to the directory of the go.mod file. Clients that
+	// offer a directory picker set it before executing the command.
+	Dir string
+}
+
+type RemoveReplaceArgs struct {
+	// The go.mod file URI.
+	URI protocol.DocumentURI
+	// The module path whose local replacement to remove.
+	ModulePath string
+}
+
 type EditGoDirectiveArgs struct {
 	// Any document URI within the relevant module.
 	URI protocol.DocumentURI
-	// The version to pass to `go mod edit -go`.
+	// The Go language version, such as "1.21".
 	Version string
 }
 
@@ -346,6 +728,13 @@ type GoGetPackageArgs struct {
 	AddRequire bool
 }
 
+type CreatePackageArgs struct {
+	// A file that imports the package.
+	URI protocol.DocumentURI
+	// The import path of the package, within the module of URI.
+	Pkg string
+}
+
 type AddImportArgs struct {
 	// ImportPath is the target import path that should
 	// be added to the URI file
@@ -439,6 +828,17 @@ type StopProfileResult struct {
 	File string
 }
 
+type SnoozeUpdateArgs struct {
+	// The go.mod file that requires the module.
+	URI protocol.DocumentURI
+	// The module path.
+	Module string
+	// The newest version for which to snooze notices, or "" to snooze
+	// notices of the retraction of the required version until any
+	// newer version is available.
+	Version string
+}
+
 type ResetGoModDiagnosticsArgs struct {
 	URIArg
 
@@ -534,7 +934,90 @@ type AddTelemetryCountersArgs struct {
 
 // ChangeSignatureArgs specifies a "change signature" refactoring to perform.
 type ChangeSignatureArgs struct {
+	// The location of an unused parameter to remove.
 	RemoveParameter protocol.Location
+	// The location of the name or parameters of the function whose
+	// parameters are changed to NewParams, if RemoveParameter is unset.
+	Function protocol.Location
+	// The parameters of the new signature, in order. If nil, the user
+	// is asked to reorder or remove the parameters of Function.
+	NewParams []ChangeSignatureParam
+	// Whether to resolve and return the edits.
+	ResolveEdits bool
+}
+
+// ChangeSignatureParam describes a parameter of the new signature in a
+// "change signature" refactoring.
+type ChangeSignatureParam struct {
+	// The index of an existing parameter, counting each name of a
+	// parameter group. It is ignored for a new parameter.
+	OldIndex int
+	// The name of a new parameter. It must be empty if and only if the
+	// function's parameters are unnamed.
+	Name string
+	// The type of a new parameter. A nonempty type indicates a new
+	// parameter.
+	Type string
+	// The expression passed for a new parameter by existing calls. It
+	// may refer to the function's other parameters by name.
+	Default string
+}
+
+// MoveDeclarationArgs specifies a "move declaration" refactoring to perform.
+type MoveDeclarationArgs struct {
+	// The name of the declaration to move.
+	Location protocol.Location
+	// The file to which the declaration is moved. It is created if
+	// it does not exist. A file in another directory moves the
+	// declaration to the package in that directory.
+	DestFile protocol.DocumentURI
+	// Whether to move the tests, benchmarks and examples named after
+	// the declaration to the test file corresponding to DestFile.
+	MoveTests bool
+	// Whether to resolve and return the edits.
+	ResolveEdits bool
+}
+
+// SafeDeleteArgs specifies a "safe delete" refactoring to perform.
+type SafeDeleteArgs struct {
+	// The name of the declaration to delete.
+	Location protocol.Location
+	// Whether to resolve and return the edits.
+	ResolveEdits bool
+}
+
+// ConvertMethodArgs specifies a function or method to convert.
+type ConvertMethodArgs struct {
+	// The name of the function or method.
+	Location protocol.Location
+	// Whether to resolve and return the edits.
+	ResolveEdits bool
+}
+
+type ExtractInterfaceArgs struct {
+	// The name of the type.
+	Location protocol.Location
+	// The name of the interface. If empty, the interface is named
+	// after its method, if it has only one, or else after the type.
+	Name string
+	// The names of the methods of the interface.
+	Methods []string
+	// The types of the function parameters to change to the
+	// interface. Each must be of the type, or a pointer to it, and
+	// its uses must all be calls of the methods of the interface.
+	Params []protocol.Location
+	// Whether to resolve and return the edits.
+	ResolveEdits bool
+}
+
+type GenerateConstructorArgs struct {
+	// The name of the struct type.
+	Location protocol.Location
+	// The names of the fields to set from the parameters of the
+	// constructor, which are declared in the order of the fields.
+	Fields []string
+	// Whether to declare functional options for the other fields.
+	Options bool
 	// Whether to resolve and return the edits.
 	ResolveEdits bool
 }
@@ -544,6 +1027,246 @@ type DiagnoseFilesArgs struct {
 	Files []protocol.DocumentURI
 }
 
+type FilterDiagnosticsArgs struct {
+	// Source filters to mute, such as "analyzer/printf".
+	Mute []string
+	// Source filters to unmute. Unmuting a filter that is not
+	// muted has no effect.
+	Unmute []string
+	// Reset unmutes all filters before applying Mute.
+	Reset bool
+}
+
+type FilterDiagnosticsResult struct {
+	// The muted source filters, in sorted order.
+	Muted []string
+	// The hierarchical sources of the diagnostics currently
+	// known to the server, muted or not, in sorted order.
+	Sources []string
+}
+
+type NextDiagnosticArgs struct {
+	// The current position.
+	Location protocol.Location
+	// Find the previous diagnostic instead of the next one.
+	Previous bool
+	// The least severe diagnostics to consider, or 0 for all:
+	// for example, 2 (Warning) considers errors and warnings.
+	Severity protocol.DiagnosticSeverity
+}
+
+type NextReferenceArgs struct {
+	// The current position, within an identifier.
+	Location protocol.Location
+	// Find the previous reference instead of the next one.
+	Previous bool
+}
+
+// A TagReference is a reference to a struct field.
+type TagReference struct {
+	Location protocol.Location
+	// Textual is set if the reference is a string literal that
+	// matches the key of the field's tag, not a use of the field.
+	Textual bool
+	// The tag key that the literal matches, if Textual.
+	Key string
+}
+
+type DescribeResult struct {
+	// A description of the syntax node, such as "function call".
+	Desc string
+	// The extent of the syntax node.
+	Location protocol.Location
+	// The kind of the node: "package", "type", "value", or empty
+	// for other syntax such as a statement.
+	Detail string
+	// The type of the value, or the described type.
+	Type string
+	// The value of a constant expression, if any.
+	Value string
+	// The declaration of the object to which an identifier refers.
+	Object *protocol.Location
+	// The method set of a described type.
+	Methods []string
+	// The exported members of a described package.
+	Members []string
+}
+
+type PointsToResult struct {
+	// The type of the selected expression.
+	Type string
+	// The objects to which the expression may refer, in order of
+	// location.
+	Labels []PointsToLabel
+}
+
+// A PointsToLabel is an object to which an expression may refer.
+type PointsToLabel struct {
+	// A description of the object, such as "new", "makechan",
+	// "function F", or "global v".
+	Desc string
+	// The type of the object: the element type of an allocated
+	// variable, or the dynamic type of an interface value.
+	Type string
+	// The location of the allocation, declaration, or conversion.
+	Location protocol.Location
+}
+
+type PeersResult struct {
+	// The type of the channel.
+	Type string
+	// The locations of the make(chan) operations of the channels.
+	Allocs []protocol.Location
+	// The locations of the operations on the channels.
+	Sends    []protocol.Location
+	Receives []protocol.Location
+	Closes   []protocol.Location
+}
+
+type WhichErrsResult struct {
+	// The package-level variables whose values the error may hold,
+	// as in "io.EOF".
+	Globals []string
+	// The constants that the error may hold, as in "syscall.ENOENT".
+	Constants []string
+	// The dynamic types of the other values that the error may hold,
+	// with the locations of their declarations.
+	Types []WhichErrsType
+}
+
+type WhichErrsType struct {
+	Type     string
+	Location protocol.Location
+}
+
+type AnalyzerDocArgs struct {
+	// A file whose configuration determines the available
+	// analyzers, which may include plugins.
+	URI protocol.DocumentURI
+	// The name of the analyzer, as in the source of its diagnostics.
+	Analyzer string
+}
+
+type AnalyzerDocResult struct {
+	// The documentation of the analyzer, in Markdown.
+	Markdown string
+}
+
+type SearchDocsArgs struct {
+	// The words to search for.
+	Query string
+	// The maximum number of matches, or 0 for the default of 100.
+	Limit int
+}
+
+type SearchDocsResult struct {
+	// The matching declarations: those of workspace packages first,
+	// then those of dependencies, each in package path order.
+	Matches []DocMatch
+	// Truncated is set if matches were omitted because of the limit.
+	Truncated bool
+}
+
+// A DocMatch is a declaration whose doc comment matches a query.
+type DocMatch struct {
+	// The path of the package of the declaration.
+	PkgPath string
+	// The name of the declared symbol, qualified by its type if it is
+	// a method or field, as in "T.M", or empty for a package doc
+	// comment.
+	Name string
+	// The kind of the symbol.
+	Kind protocol.SymbolKind
+	// The location of the declared name, or of the package clause.
+	Location protocol.Location
+	// The line of the doc comment that contains the first match.
+	Snippet string
+}
+
+type ImportersArgs struct {
+	// A file of the package whose importers are wanted.
+	URI protocol.DocumentURI
+	// The maximum length of the chain of imports from an importer to
+	// the package: 1 for direct importers only, or 0 for no limit.
+	Depth int
+}
+
+type ImportersResult struct {
+	// The importers, in order of increasing depth, then of package
+	// path.
+	Importers []Importer
+}
+
+// An Importer is a package that depends on another through its imports.
+type Importer struct {
+	// The path of the importing package. Its test files form distinct
+	// importers only if they are in an external test package.
+	PkgPath string
+	// The length of the shortest chain of imports from the importer
+	// to the package: 1 for a direct importer.
+	Depth int
+	// The locations of the import paths, in the importer's files,
+	// of the packages at the next step of such a chain: for a direct
+	// importer, the package itself.
+	Locations []protocol.Location
+}
+
+type RunMainArgs struct {
+	// A file of the main package to run.
+	URI protocol.DocumentURI
+	// If set, only the specified file is run, as by "go run file.go".
+	File bool
+	// The command-line arguments of the program.
+	Args []string
+	// Additional environment variables of the program, in the form
+	// "key=value".
+	//
+	// If both Args and Env are nil, those of the previous run of the
+	// package, or of the file if File is set, are used; otherwise they
+	// replace them.
+	Env []string
+}
+
+type ViewInfoArgs struct {
+	// A file whose view to describe. If empty, all views are
+	// described.
+	URI protocol.DocumentURI
+}
+
+// A ViewDescription describes the effective configuration of a view.
+type ViewDescription struct {
+	// The view ID.
+	ID string
+	// The type of the view, which determines how packages are
+	// loaded: "GoMod", "GoWork", "GOPATH", "AdHoc", or
+	// "GoPackagesDriver".
+	Type string
+	// The root directory of the view.
+	Root protocol.DocumentURI
+	// The workspace folder associated with the view.
+	Folder protocol.DocumentURI
+	// The go.mod file of a GoMod view.
+	GoMod protocol.DocumentURI
+	// The go.work file of a GoWork view.
+	GoWork protocol.DocumentURI
+	// The go.mod files of the modules of the workspace, in order.
+	ModFiles []protocol.DocumentURI
+	// The output of "go version".
+	GoVersion string
+	// The effective GOOS, GOARCH, and GOFLAGS.
+	GOOS, GOARCH, GOFLAGS string
+	// The buildFlags setting.
+	BuildFlags []string
+	// The build tags set by GOFLAGS and buildFlags, in order.
+	Tags []string
+	// The environment variable overrides specific to the view, such
+	// as those chosen for a file with build constraints.
+	EnvOverlay []string
+	// The number of loaded packages, and of workspace packages among
+	// them.
+	Packages, WorkspacePackages int
+}
+
 // A View holds summary information about a cache.View.
 type View struct {
 	ID         string               // view ID (the index of this view among all views created)
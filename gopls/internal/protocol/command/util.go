@@ -21,7 +21,7 @@ func (c Command) IsAsync() bool {
 	switch string(c) {
 	// TODO(adonovan): derive this list from interface.go somewhow.
 	// Unfortunately we can't even reference the enum from here...
-	case "gopls.run_tests", "gopls.run_govulncheck", "gopls.test":
+	case "gopls.run_tests", "gopls.run_govulncheck", "gopls.test", "gopls.run_main":
 		return true
 	}
 	return false
@@ -6,8 +6,13 @@ package protocol
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
+	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,13 +22,61 @@ import (
 
 type loggingStream struct {
 	stream jsonrpc2.Stream
+	opts   TraceOptions
 	logMu  sync.Mutex
 	log    io.Writer
 }
 
+// TraceOptions configures the trace of a LoggingStream.
+type TraceOptions struct {
+	// JSON selects structured output: one JSON object per message
+	// and line, rather than the format of the LSP inspector.
+	JSON bool
+
+	// Redact replaces the file paths and contents, and every other
+	// string that is not known to be safe, by hashes, so that a trace
+	// may be shared without revealing the source it was made on.
+	// Equal strings have equal hashes.
+	Redact bool
+
+	// Sampling maps an LSP method, or "*" for all others, to the
+	// fraction of its messages, between 0 and 1, that are traced.
+	// The response to a request is traced if the request is.
+	// Methods not in the map are always traced.
+	Sampling map[string]float64
+}
+
+// ParseSampling parses a comma-separated list of method=rate pairs,
+// such as "textDocument/hover=0.1,*=1", in the form of the Sampling
+// field of TraceOptions.
+func ParseSampling(s string) (map[string]float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	sampling := make(map[string]float64)
+	for _, pair := range strings.Split(s, ",") {
+		method, rate, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || method == "" {
+			return nil, fmt.Errorf("invalid sampling rate %q: want method=rate", pair)
+		}
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil || r < 0 || r > 1 {
+			return nil, fmt.Errorf("invalid sampling rate %q for %s: want a number between 0 and 1", rate, method)
+		}
+		sampling[method] = r
+	}
+	return sampling, nil
+}
+
 // LoggingStream returns a stream that does LSP protocol logging too
 func LoggingStream(str jsonrpc2.Stream, w io.Writer) jsonrpc2.Stream {
-	return &loggingStream{stream: str, log: w}
+	return TracingStream(str, w, TraceOptions{})
+}
+
+// TracingStream returns a stream that logs the LSP protocol messages
+// it reads and writes as configured by opts.
+func TracingStream(str jsonrpc2.Stream, w io.Writer, opts TraceOptions) jsonrpc2.Stream {
+	return &loggingStream{stream: str, opts: opts, log: w}
 }
 
 func (s *loggingStream) Read(ctx context.Context) (jsonrpc2.Message, int64, error) {
@@ -45,8 +98,9 @@ func (s *loggingStream) Close() error {
 }
 
 type req struct {
-	method string
-	start  time.Time
+	method  string
+	start   time.Time
+	sampled bool // whether the request was traced
 }
 
 type mapped struct {
@@ -107,6 +161,40 @@ func (s *loggingStream) logCommon(msg jsonrpc2.Message, isRead bool) {
 		return
 	}
 	tm := time.Now()
+
+	// Decide whether to trace the message, and record
+	// the request so that its response can be timed.
+	var (
+		method  string
+		elapsed time.Duration
+	)
+	switch msg := msg.(type) {
+	case *jsonrpc2.Call:
+		method = msg.Method()
+		sampled := s.sampled(method)
+		set(fmt.Sprint(msg.ID()), req{method: method, start: tm, sampled: sampled})
+		if !sampled {
+			return
+		}
+	case *jsonrpc2.Notification:
+		method = msg.Method()
+		if !s.sampled(method) {
+			return
+		}
+	case *jsonrpc2.Response:
+		cc := get(fmt.Sprint(msg.ID()))
+		if cc.method != "" && !cc.sampled {
+			return
+		}
+		method = cc.method
+		elapsed = tm.Sub(cc.start)
+	}
+
+	if s.opts.JSON {
+		s.logJSON(msg, tm, isRead, method, elapsed)
+		return
+	}
+
 	tmfmt := tm.Format("15:04:05.000 PM")
 
 	buf := strings.Builder{}
@@ -115,22 +203,161 @@ func (s *loggingStream) logCommon(msg jsonrpc2.Message, isRead bool) {
 	case *jsonrpc2.Call:
 		id := fmt.Sprint(msg.ID())
 		fmt.Fprintf(&buf, "%s request '%s - (%s)'.\n", direction, msg.Method(), id)
-		fmt.Fprintf(&buf, "Params: %s%s", msg.Params(), eor)
-		set(id, req{method: msg.Method(), start: tm})
+		fmt.Fprintf(&buf, "Params: %s%s", s.redact(msg.Params()), eor)
 	case *jsonrpc2.Notification:
 		fmt.Fprintf(&buf, "%s notification '%s'.\n", direction, msg.Method())
-		fmt.Fprintf(&buf, "Params: %s%s", msg.Params(), eor)
+		fmt.Fprintf(&buf, "Params: %s%s", s.redact(msg.Params()), eor)
 	case *jsonrpc2.Response:
 		id := fmt.Sprint(msg.ID())
 		if err := msg.Err(); err != nil {
-			fmt.Fprintf(s.log, "[Error - %s] %s #%s %s%s", pastTense, tmfmt, id, err, eor)
+			fmt.Fprintf(s.log, "[Error - %s] %s #%s %s%s", pastTense, tmfmt, id, s.redactString("message", err.Error()), eor)
 			return
 		}
-		cc := get(id)
-		elapsed := tm.Sub(cc.start)
 		fmt.Fprintf(&buf, "%s response '%s - (%s)' in %dms.\n",
-			direction, cc.method, id, elapsed/time.Millisecond)
-		fmt.Fprintf(&buf, "Result: %s%s", msg.Result(), eor)
+			direction, method, id, elapsed/time.Millisecond)
+		fmt.Fprintf(&buf, "Result: %s%s", s.redact(msg.Result()), eor)
 	}
 	s.log.Write([]byte(buf.String()))
 }
+
+// sampled reports whether to trace a message of the given method.
+func (s *loggingStream) sampled(method string) bool {
+	rate, ok := s.opts.Sampling[method]
+	if !ok {
+		rate, ok = s.opts.Sampling["*"]
+	}
+	return !ok || rate >= 1 || rand.Float64() < rate
+}
+
+// A traceEntry is the JSON form of a traced message.
+type traceEntry struct {
+	Time      time.Time       `json:"time"`
+	Direction string          `json:"direction"` // "client" (to server) or "server" (to client)
+	Kind      string          `json:"kind"`      // "request", "notification", or "response"
+	Method    string          `json:"method,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	ElapsedMS *int64          `json:"elapsedMs,omitempty"` // responses only
+	Params    json.RawMessage `json:"params,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+func (s *loggingStream) logJSON(msg jsonrpc2.Message, tm time.Time, isRead bool, method string, elapsed time.Duration) {
+	entry := traceEntry{Time: tm, Direction: "server", Method: method}
+	if isRead {
+		entry.Direction = "client"
+	}
+	switch msg := msg.(type) {
+	case *jsonrpc2.Call:
+		entry.Kind = "request"
+		entry.ID = fmt.Sprint(msg.ID())
+		entry.Params = s.redact(msg.Params())
+	case *jsonrpc2.Notification:
+		entry.Kind = "notification"
+		entry.Params = s.redact(msg.Params())
+	case *jsonrpc2.Response:
+		entry.Kind = "response"
+		entry.ID = fmt.Sprint(msg.ID())
+		ms := int64(elapsed / time.Millisecond)
+		entry.ElapsedMS = &ms
+		if err := msg.Err(); err != nil {
+			entry.Error = s.redactString("message", err.Error())
+		} else {
+			entry.Result = s.redact(msg.Result())
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Fprintf(s.log, "{\"error\": %q}\n", err)
+		return
+	}
+	s.log.Write(append(data, '\n'))
+}
+
+// safeTraceKeys are the keys of the JSON object fields whose string
+// values reveal nothing of the source, and are not redacted.
+var safeTraceKeys = map[string]bool{
+	"jsonrpc":     true,
+	"method":      true,
+	"kind":        true,
+	"languageId":  true,
+	"triggerKind": true,
+	"traceValue":  true,
+	"trace":       true,
+	"source":      true,
+	"code":        true,
+	"command":     true,
+	"valueSet":    true,
+}
+
+// redact returns the JSON value data with its strings redacted, if
+// the stream is configured to do so.
+func (s *loggingStream) redact(data json.RawMessage) json.RawMessage {
+	if !s.opts.Redact || len(data) == 0 {
+		return data
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return json.RawMessage(strconv.Quote(s.redactString("", string(data))))
+	}
+	v = s.redactValue("", v)
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return redacted
+}
+
+// redactValue redacts the strings of the JSON value v, which is the
+// value of a field with the given key.
+func (s *loggingStream) redactValue(key string, v any) any {
+	switch v := v.(type) {
+	case string:
+		return s.redactString(key, v)
+	case []any:
+		for i, elem := range v {
+			v[i] = s.redactValue(key, elem)
+		}
+	case map[string]any:
+		// The keys of some maps, such as WorkspaceEdit.changes, are URIs.
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		for _, k := range keys {
+			elem := s.redactValue(k, v[k])
+			if strings.Contains(k, "://") {
+				delete(v, k)
+				k = s.redactString("", k)
+			}
+			v[k] = elem
+		}
+	}
+	return v
+}
+
+// redactString redacts str, the value of a field with the given key,
+// if the stream is configured to do so. A URI keeps its scheme and
+// the extension of its path, but each segment of the path is hashed.
+func (s *loggingStream) redactString(key, str string) string {
+	if !s.opts.Redact || str == "" || safeTraceKeys[key] {
+		return str
+	}
+	if scheme, rest, ok := strings.Cut(str, "://"); ok && !strings.ContainsAny(scheme, " \n/") {
+		ext := path.Ext(rest)
+		segments := strings.Split(strings.TrimSuffix(rest, ext), "/")
+		for i, seg := range segments {
+			if seg != "" {
+				segments[i] = traceHash(seg)
+			}
+		}
+		return scheme + "://" + strings.Join(segments, "/") + ext
+	}
+	return fmt.Sprintf("<%s:%d>", traceHash(str), len(str))
+}
+
+// traceHash returns a short hash of str.
+func traceHash(str string) string {
+	sum := sha256.Sum256([]byte(str))
+	return fmt.Sprintf("%x", sum[:6])
+}
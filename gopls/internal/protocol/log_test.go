@@ -0,0 +1,112 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protocol_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/jsonrpc2"
+)
+
+// fakeStream is a jsonrpc2.Stream that reads the messages queued in
+// it, and discards what is written to it.
+type fakeStream struct{ queue []jsonrpc2.Message }
+
+func (s *fakeStream) Read(context.Context) (jsonrpc2.Message, int64, error) {
+	msg := s.queue[0]
+	s.queue = s.queue[1:]
+	return msg, 0, nil
+}
+
+func (*fakeStream) Write(context.Context, jsonrpc2.Message) (int64, error) { return 0, nil }
+func (*fakeStream) Close() error                                           { return nil }
+
+func TestParseSampling(t *testing.T) {
+	got, err := protocol.ParseSampling("textDocument/hover=0.25, *=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["textDocument/hover"] != 0.25 || got["*"] != 1 {
+		t.Errorf("ParseSampling returned %v", got)
+	}
+	for _, bad := range []string{"hover", "=0.5", "hover=x", "hover=2", "hover=-1"} {
+		if _, err := protocol.ParseSampling(bad); err == nil {
+			t.Errorf("ParseSampling(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestTraceJSON(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	stream := protocol.TracingStream(&fakeStream{}, &buf, protocol.TraceOptions{JSON: true, Redact: true})
+
+	params := map[string]any{
+		"textDocument": map[string]any{
+			"uri":        "file:///home/user/secret/main.go",
+			"languageId": "go",
+			"text":       "package secret",
+		},
+	}
+	notif, err := jsonrpc2.NewNotification("textDocument/didOpen", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Write(ctx, notif)
+
+	var entry struct {
+		Direction string
+		Kind      string
+		Method    string
+		Params    json.RawMessage
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid JSON trace %q: %v", buf.String(), err)
+	}
+	if entry.Direction != "server" || entry.Kind != "notification" || entry.Method != "textDocument/didOpen" {
+		t.Errorf("got entry %+v, want a server notification of textDocument/didOpen", entry)
+	}
+	got := string(entry.Params)
+	for _, secret := range []string{"home", "user", "secret"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("redacted params %s contain %q", got, secret)
+		}
+	}
+	for _, kept := range []string{`"file://`, `.go"`, `"languageId":"go"`} {
+		if !strings.Contains(got, kept) {
+			t.Errorf("redacted params %s do not contain %s", got, kept)
+		}
+	}
+}
+
+func TestTraceSampling(t *testing.T) {
+	ctx := context.Background()
+	id := jsonrpc2.NewStringID("trace-sampling-hover")
+	call, _ := jsonrpc2.NewCall(id, "textDocument/hover", nil)
+	resp, _ := jsonrpc2.NewResponse(id, nil, nil)
+	var buf bytes.Buffer
+	stream := protocol.TracingStream(&fakeStream{queue: []jsonrpc2.Message{call}}, &buf, protocol.TraceOptions{
+		Sampling: map[string]float64{"textDocument/hover": 0},
+	})
+
+	// Neither the request nor its response is traced.
+	stream.Read(ctx)
+	stream.Write(ctx, resp)
+	if buf.Len() > 0 {
+		t.Errorf("unsampled method was traced:\n%s", buf.String())
+	}
+
+	// Other methods are.
+	notif, _ := jsonrpc2.NewNotification("initialized", nil)
+	stream.Write(ctx, notif)
+	if !strings.Contains(buf.String(), "initialized") {
+		t.Errorf("sampled method was not traced:\n%s", buf.String())
+	}
+}
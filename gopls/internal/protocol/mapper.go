@@ -69,6 +69,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"unicode"
 	"unicode/utf8"
 
 	"golang.org/x/tools/gopls/internal/util/bug"
@@ -244,13 +245,153 @@ func (m *Mapper) line(offset int) (int, int, bool) {
 	} else {
 		eol = m.lineStart[line] - 1
 	}
-	cr := offset == eol && offset > 0 && m.Content[offset-1] == '\r'
+	// A \r at EOF is not a line ending.
+	cr := offset == eol && offset < len(m.Content) && offset > 0 && m.Content[offset-1] == '\r'
 
 	line-- // 0-based
 
 	return line, m.lineStart[line], cr
 }
 
+// -- display columns --
+
+// OffsetLineColDisplay converts a valid byte offset to line and
+// display column numbers, both 1-based. Tab stops occur every
+// tabWidth cells.
+//
+// A display column counts the cells of a fixed-width display, such as
+// a terminal, that precede the offset on its line: a tab advances to
+// the next tab stop, a combining mark or other zero-width rune
+// (Unicode categories Mn, Me, and Cf) occupies no cell, and any other
+// rune occupies one. (Wide East Asian characters are not
+// distinguished.)
+func (m *Mapper) OffsetLineColDisplay(offset, tabWidth int) (int, int) {
+	line, start, cr := m.line(offset)
+	if cr {
+		offset-- // retreat from \r at line end
+	}
+	col := 0
+	for _, r := range string(m.Content[start:offset]) {
+		col += runeCells(r, col, tabWidth)
+	}
+	return line + 1, col + 1
+}
+
+// LineColDisplayPosition converts a valid line and display column
+// number, both 1-based, to a protocol (UTF-16) position. Tab stops
+// occur every tabWidth cells. See OffsetLineColDisplay for the meaning
+// of display columns.
+//
+// A column within the cells of a tab denotes the position of the tab.
+// A column followed by zero-width runes denotes the position after them,
+// so that a combining mark is never separated from its base.
+func (m *Mapper) LineColDisplayPosition(line, colDisplay, tabWidth int) (Position, error) {
+	// Report a bug for inputs that are invalid for any file content.
+	if line < 1 {
+		return Position{}, bug.Errorf("invalid 1-based line number: %d", line)
+	}
+	if colDisplay < 1 {
+		return Position{}, bug.Errorf("invalid 1-based column number: %d", colDisplay)
+	}
+
+	m.initLines()
+	line0 := line - 1 // 0-based
+	if !(0 <= line0 && line0 < len(m.lineStart)) {
+		return Position{}, fmt.Errorf("line number %d out of range (max %d)", line, len(m.lineStart))
+	}
+
+	// content[start:end] is the line, sans line ending.
+	start := m.lineStart[line0]
+	end := len(m.Content)
+	if line0+1 < len(m.lineStart) {
+		end = m.lineStart[line0+1] - 1
+		if end > start && m.Content[end-1] == '\r' {
+			end--
+		}
+	}
+
+	// Advance runes up to the required number of cells.
+	want := colDisplay - 1
+	offset, col := start, 0
+	for offset < end {
+		r, sz := utf8.DecodeRune(m.Content[offset:end])
+		cells := runeCells(r, col, tabWidth)
+		if col+cells > want && col < want {
+			col = want // within a tab
+		}
+		if col == want && cells > 0 {
+			break
+		}
+		col += cells
+		offset += sz
+	}
+	if col < want {
+		return Position{}, fmt.Errorf("column is beyond end of line")
+	}
+
+	char := UTF16Len(m.Content[start:offset])
+	return Position{Line: uint32(line0), Character: uint32(char)}, nil
+}
+
+// runeCells returns the number of display cells occupied by rune r
+// at 0-based display column col, given tab stops every tabWidth cells.
+func runeCells(r rune, col, tabWidth int) int {
+	switch {
+	case r == '\t':
+		if tabWidth < 1 {
+			tabWidth = 1
+		}
+		return tabWidth - col%tabWidth
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	default:
+		return 1
+	}
+}
+
+// CheckOffset reports an error if the byte offset is not a valid
+// position, or if the conversions between it and the other notations
+// of position are inconsistent. It is intended for tests, such as fuzz
+// tests, of the Mapper and of code that computes offsets.
+//
+// An offset within a UTF-8 encoded rune or within a CRLF line ending
+// has no counterpart in the other notations, so it is invalid. The
+// content before the offset must be valid UTF-8.
+func (m *Mapper) CheckOffset(offset int) error {
+	posn, err := m.OffsetPosition(offset)
+	if err != nil {
+		return err
+	}
+	if offset < len(m.Content) {
+		if !utf8.RuneStart(m.Content[offset]) {
+			return fmt.Errorf("offset %d is within a rune", offset)
+		}
+		if offset > 0 && m.Content[offset-1] == '\r' && m.Content[offset] == '\n' {
+			return fmt.Errorf("offset %d is within a CRLF line ending", offset)
+		}
+	}
+
+	// offset -> UTF-16 position -> offset
+	got, err := m.PositionOffset(posn)
+	if err != nil {
+		return fmt.Errorf("offset %d has position %v, which is invalid: %v", offset, posn, err)
+	}
+	if got != offset {
+		return fmt.Errorf("offset %d has position %v, which has offset %d", offset, posn, got)
+	}
+
+	// offset -> UTF-8 line and column -> UTF-16 position
+	line, col8 := m.OffsetLineCol8(offset)
+	posn8, err := m.LineCol8Position(line, col8)
+	if err != nil {
+		return fmt.Errorf("offset %d has line and column %d:%d, which are invalid: %v", offset, line, col8, err)
+	}
+	if posn8 != posn {
+		return fmt.Errorf("offset %d has position %v, but line and column %d:%d have position %v", offset, posn, line, col8, posn8)
+	}
+	return nil
+}
+
 // OffsetMappedRange returns a MappedRange for the given byte offsets.
 // A MappedRange can be converted to any other form.
 func (m *Mapper) OffsetMappedRange(start, end int) (MappedRange, error) {
@@ -0,0 +1,65 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package protocol_test
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+)
+
+// FuzzMapper checks the consistency of the Mapper's conversions
+// between byte offsets, UTF-8 columns, and UTF-16 positions.
+func FuzzMapper(f *testing.F) {
+	for _, content := range []string{
+		"",
+		"abc",
+		"abc\n",
+		"abc\r",
+		"a\r\nb\r\n",
+		"a𐐀b\n𐐀\n",
+		"\n\n\r\n",
+	} {
+		f.Add(content)
+	}
+	f.Fuzz(func(t *testing.T, content string) {
+		if !utf8.ValidString(content) {
+			t.Skip("invalid UTF-8")
+		}
+		m := protocol.NewMapper("", []byte(content))
+		var valid []int // offsets that are valid positions
+		for offset := 0; offset <= len(content); offset++ {
+			err := m.CheckOffset(offset)
+			midRune := offset < len(content) && !utf8.RuneStart(content[offset])
+			midCRLF := 0 < offset && offset < len(content) && content[offset-1] == '\r' && content[offset] == '\n'
+			if err == nil {
+				valid = append(valid, offset)
+			} else if !midRune && !midCRLF {
+				t.Fatalf("CheckOffset(%q, %d): %v", content, offset, err)
+			}
+		}
+
+		// Distinct offsets have distinct positions, in the same order,
+		// and ranges between them convert back to the same offsets.
+		var prev protocol.Position
+		for i, offset := range valid {
+			posn, _ := m.OffsetPosition(offset)
+			if i > 0 && protocol.ComparePosition(prev, posn) >= 0 {
+				t.Fatalf("in %q, position %v of offset %d is not after %v", content, posn, offset, prev)
+			}
+			prev = posn
+
+			rng, err := m.OffsetRange(valid[0], offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			start, end, err := m.RangeOffsets(rng)
+			if err != nil || start != valid[0] || end != offset {
+				t.Fatalf("in %q, range %v of offsets [%d, %d) has offsets [%d, %d) (err=%v)", content, rng, valid[0], offset, start, end, err)
+			}
+		}
+	})
+}
@@ -447,3 +447,91 @@ func TestBytesOffset(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckOffset(t *testing.T) {
+	tests := []struct {
+		content string
+		offset  int
+		wantErr string // substring of the error, or "" if valid
+	}{
+		{"", 0, ""},
+		{"abc", 3, ""},                 // EOF without trailing newline
+		{"abc\n", 4, ""},               // EOF after trailing newline
+		{"abc\r", 4, ""},               // EOF after a \r that is not a line ending
+		{"a\r\nb", 1, ""},              // before CRLF
+		{"a\r\nb", 3, ""},              // after CRLF
+		{"a\r\nb", 2, "CRLF"},          // between \r and \n
+		{"a𐐀b", 1, ""},                 // before a surrogate pair
+		{"a𐐀b", 5, ""},                 // after a surrogate pair
+		{"a𐐀b", 2, "within a rune"},    // within a surrogate pair
+		{"a𐐀b", 4, "within a rune"},    // within a surrogate pair
+		{"abc", -1, "invalid offset"},  // negative
+		{"abc", 4, "invalid offset"},   // beyond EOF
+		{"a\xffb", 2, "invalid UTF-8"}, // invalid text before offset
+	}
+	for _, test := range tests {
+		m := protocol.NewMapper("", []byte(test.content))
+		err := m.CheckOffset(test.offset)
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("CheckOffset(%q, %d) failed: %v", test.content, test.offset, err)
+		case test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)):
+			t.Errorf("CheckOffset(%q, %d) = %v, want error containing %q", test.content, test.offset, err, test.wantErr)
+		}
+	}
+}
+
+func TestDisplayColumns(t *testing.T) {
+	const tabWidth = 4
+	tests := []struct {
+		content string
+		offset  int // byte offset of position
+		col     int // 1-based display column of position
+	}{
+		{"", 0, 1},
+		{"abc", 3, 4},
+		{"a\tb", 1, 2},         // before a tab
+		{"\tx", 1, 5},          // after a tab
+		{"ab\tx", 3, 5},        // after a partial tab
+		{"abc\tx", 4, 5},       // after a one-cell tab
+		{"x\ty\tz", 4, 9},      // after the second tab stop
+		{"éx", 3, 2},          // a combining mark (Mn) occupies no cell
+		{"é̂x", 5, 2},         // nor do two
+		{"a⃝", 4, 2},           // nor an enclosing mark (Me)
+		{"a‍b", 4, 2},          // nor a zero-width joiner (Cf)
+		{"é\tx", 4, 5},        // a mark before a tab
+		{"a𐐀b", 5, 3},          // a surrogate pair occupies one cell
+		{"x\n\ty\n", 3, 5},     // second line
+		{"x\n\ty\n", 5, 1},     // EOF after newline
+		{"ab\r\ncd\r\n", 2, 3}, // end of CRLF line
+		{"ab\r\ncd\r\n", 8, 1}, // EOF after CRLF
+		{"ab\r", 3, 4},         // a \r at EOF is not a line ending
+	}
+	for _, test := range tests {
+		m := protocol.NewMapper("", []byte(test.content))
+		wantPosn, err := m.OffsetPosition(test.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		line, col := m.OffsetLineColDisplay(test.offset, tabWidth)
+		if want := int(wantPosn.Line) + 1; line != want || col != test.col {
+			t.Errorf("OffsetLineColDisplay(%q, %d) = %d:%d, want %d:%d", test.content, test.offset, line, col, want, test.col)
+		}
+		posn, err := m.LineColDisplayPosition(line, test.col, tabWidth)
+		if err != nil {
+			t.Errorf("LineColDisplayPosition(%q, %d:%d) failed: %v", test.content, line, test.col, err)
+		} else if posn != wantPosn {
+			t.Errorf("LineColDisplayPosition(%q, %d:%d) = %v, want %v", test.content, line, test.col, posn, wantPosn)
+		}
+	}
+
+	// A column within a tab denotes the tab;
+	// a column beyond the end of the line is invalid.
+	m := protocol.NewMapper("", []byte("a\tb\nc"))
+	if posn, err := m.LineColDisplayPosition(1, 3, tabWidth); err != nil || posn != (protocol.Position{Line: 0, Character: 1}) {
+		t.Errorf("LineColDisplayPosition(1:3) = %v, %v, want 0:1", posn, err)
+	}
+	if posn, err := m.LineColDisplayPosition(1, 7, tabWidth); err == nil {
+		t.Errorf("LineColDisplayPosition(1:7) = %v, want error", posn)
+	}
+}
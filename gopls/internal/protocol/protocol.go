@@ -217,9 +217,12 @@ func req2to1(req2 *jsonrpc2_v2.Request) jsonrpc2.Request {
 }
 
 func Handlers(handler jsonrpc2.Handler) jsonrpc2.Handler {
+	var changes changeCoalescer
 	return CancelHandler(
-		jsonrpc2.AsyncHandler(
-			jsonrpc2.MustReplyHandler(handler)))
+		changes.queue(
+			jsonrpc2.AsyncHandler(
+				changes.dequeue(
+					jsonrpc2.MustReplyHandler(handler)))))
 }
 
 func CancelHandler(handler jsonrpc2.Handler) jsonrpc2.Handler {
@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strings"
 
+	"golang.org/x/tools/gopls/internal/baseline"
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/golang"
@@ -93,7 +94,7 @@ func (s *server) CodeAction(ctx context.Context, params *protocol.CodeActionPara
 	searchFixes:
 		for _, fix := range fixes {
 			for _, diag := range fix.Diagnostics {
-				if diag.Source == string(cache.Govulncheck) || diag.Source == string(cache.Vulncheck) {
+				if diag.Source == cache.Govulncheck.Path() || diag.Source == cache.Vulncheck.Path() {
 					vulnFixes[diag.Range] = append(vulnFixes[diag.Range], fix)
 					continue searchFixes
 				}
@@ -106,7 +107,15 @@ func (s *server) CodeAction(ctx context.Context, params *protocol.CodeActionPara
 			actions = append(actions, fixes...)
 		}
 
-		return actions, nil
+		if want[protocol.RefactorRewrite] {
+			rewrites, err := mod.CodeActions(ctx, snapshot, fh, params.Range)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, rewrites...)
+		}
+
+		return withoutExcluded(snapshot, uri, actions), nil
 
 	case file.Go:
 		// diagnostic-bundled code actions
@@ -120,6 +129,11 @@ func (s *server) CodeAction(ctx context.Context, params *protocol.CodeActionPara
 		if err != nil {
 			return nil, err
 		}
+		fixAll, err := s.fixAllCodeActions(ctx, uri, snapshot, params.Context.Diagnostics, want)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, fixAll...)
 
 		// computed code actions (may include quickfixes from diagnostics)
 		trigger := protocol.CodeActionUnknownTrigger
@@ -140,16 +154,22 @@ func (s *server) CodeAction(ctx context.Context, params *protocol.CodeActionPara
 			actions = slices.DeleteFunc(actions, func(a protocol.CodeAction) bool {
 				switch a.Kind {
 				case protocol.GoTest,
+					protocol.GoAnalyzerDoc,
 					protocol.GoDoc,
 					protocol.GoFreeSymbols,
-					protocol.GoAssembly:
+					protocol.GoAssembly,
+					protocol.GoClones,
+					protocol.GoDeadCode,
+					protocol.GoDeepAnalysis:
 					return false // read-only query
+				case protocol.GoRegenerate:
+					return false // the proper way to edit a generated file
 				}
 				return true // potential write operation
 			})
 		}
 
-		return actions, nil
+		return withoutExcluded(snapshot, uri, actions), nil
 
 	default:
 		// Unsupported file kind for a code action.
@@ -157,6 +177,19 @@ func (s *server) CodeAction(ctx context.Context, params *protocol.CodeActionPara
 	}
 }
 
+// withoutExcluded removes from actions those whose kinds are disabled
+// for the file by the codeActionExclusions setting.
+func withoutExcluded(snapshot *cache.Snapshot, uri protocol.DocumentURI, actions []protocol.CodeAction) []protocol.CodeAction {
+	opts := snapshot.Options()
+	if len(opts.CodeActionExclusions) == 0 {
+		return actions
+	}
+	relPath := baseline.RelPath(snapshot.Folder().Path(), uri.Path())
+	return slices.DeleteFunc(actions, func(a protocol.CodeAction) bool {
+		return opts.CodeActionExcluded(a.Kind, relPath)
+	})
+}
+
 // ResolveCodeAction resolves missing Edit information (that is, computes the
 // details of the necessary patch) in the given code action using the provided
 // Data field of the CodeAction, which should contain the raw json of a protocol.Command.
@@ -196,6 +229,14 @@ func (s *server) ResolveCodeAction(ctx context.Context, ca *protocol.CodeAction)
 		if ca.Edit, ok = edit.(*protocol.WorkspaceEdit); !ok {
 			return nil, fmt.Errorf("unable to resolve code action %q", ca.Title)
 		}
+		// Select the suggested range after the edits are applied.
+		if handler.selection != nil && ca.Command == nil {
+			cmd, err := command.NewSelectRangeCommand("Select", *handler.selection)
+			if err != nil {
+				return nil, err
+			}
+			ca.Command = &cmd
+		}
 	}
 	return ca, nil
 }
@@ -260,6 +301,71 @@ func codeActionsForDiagnostic(ctx context.Context, snapshot *cache.Snapshot, sd
 	return actions, nil
 }
 
+// fixAllCodeActions returns, for each analyzer that reported one of
+// the provided diagnostics with a quick fix, the code actions that fix
+// all its diagnostics in the package of the file and in the workspace.
+func (s *server) fixAllCodeActions(ctx context.Context, uri protocol.DocumentURI, snapshot *cache.Snapshot, pds []protocol.Diagnostic, want map[protocol.CodeActionKind]bool) ([]protocol.CodeAction, error) {
+	if !want[protocol.QuickFix] {
+		return nil, nil
+	}
+	var (
+		actions  []protocol.CodeAction
+		seen     = make(map[string]bool) // analyzers
+		pkgFiles map[protocol.DocumentURI]bool
+	)
+	for _, pd := range pds {
+		if seen[pd.Source] || !slices.ContainsFunc(s.findMatchingDiagnostics(uri, pd), hasQuickFixEdits) {
+			continue
+		}
+		seen[pd.Source] = true
+
+		if pkgFiles == nil {
+			mp, err := golang.NarrowestMetadataForFile(ctx, snapshot, uri)
+			if err != nil {
+				return nil, err
+			}
+			pkgFiles = make(map[protocol.DocumentURI]bool)
+			for _, f := range mp.CompiledGoFiles {
+				pkgFiles[f] = true
+			}
+		}
+		inPackage, inWorkspace := s.countFixableDiagnostics(pd.Source, pkgFiles, snapshot.Folder())
+		more, err := golang.FixAllCodeActions(snapshot, uri, pd, inPackage, inWorkspace)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, more...)
+	}
+	return actions, nil
+}
+
+// countFixableDiagnostics returns the number of diagnostics of the
+// specified source with quick fixes among those last published for the
+// specified package files, and for the files in folder.
+func (s *server) countFixableDiagnostics(source string, pkgFiles map[protocol.DocumentURI]bool, folder protocol.DocumentURI) (inPackage, inWorkspace int) {
+	s.diagnosticsMu.Lock()
+	defer s.diagnosticsMu.Unlock()
+
+	for uri, f := range s.diagnostics {
+		if !folder.Encloses(uri) {
+			continue
+		}
+		for _, sd := range f.published {
+			if sd.Source.Path() == source && hasQuickFixEdits(sd) {
+				inWorkspace++
+				if pkgFiles[uri] {
+					inPackage++
+				}
+			}
+		}
+	}
+	return inPackage, inWorkspace
+}
+
+func hasQuickFixEdits(sd *cache.Diagnostic) bool {
+	return golang.QuickFixEdits(sd) != nil
+}
+
 func (s *server) findMatchingDiagnostics(uri protocol.DocumentURI, pd protocol.Diagnostic) []*cache.Diagnostic {
 	s.diagnosticsMu.Lock()
 	defer s.diagnosticsMu.Unlock()
@@ -269,7 +375,7 @@ func (s *server) findMatchingDiagnostics(uri protocol.DocumentURI, pd protocol.D
 		for _, sd := range viewDiags.diagnostics {
 			sameDiagnostic := (pd.Message == strings.TrimSpace(sd.Message) && // extra space may have been trimmed when converting to protocol.Diagnostic
 				protocol.CompareRange(pd.Range, sd.Range) == 0 &&
-				pd.Source == string(sd.Source))
+				pd.Source == sd.Source.Path())
 
 			if sameDiagnostic {
 				sds = append(sds, sd)
@@ -13,6 +13,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -30,11 +31,13 @@ import (
 	"golang.org/x/tools/gopls/internal/debug"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/golang"
+	"golang.org/x/tools/gopls/internal/mod"
 	"golang.org/x/tools/gopls/internal/progress"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/protocol/command"
 	"golang.org/x/tools/gopls/internal/settings"
 	"golang.org/x/tools/gopls/internal/util/bug"
+	"golang.org/x/tools/gopls/internal/util/maps"
 	"golang.org/x/tools/gopls/internal/vulncheck"
 	"golang.org/x/tools/gopls/internal/vulncheck/scan"
 	"golang.org/x/tools/internal/diff"
@@ -69,6 +72,10 @@ func (s *server) ExecuteCommand(ctx context.Context, params *protocol.ExecuteCom
 type commandHandler struct {
 	s      *server
 	params *protocol.ExecuteCommandParams
+
+	// selection is the range suggested for selection after the
+	// resolved edits of a command are applied, if any.
+	selection *protocol.Location
 }
 
 func (h *commandHandler) MaybePromptForTelemetry(ctx context.Context) error {
@@ -207,13 +214,15 @@ func (c *commandHandler) ApplyFix(ctx context.Context, args command.ApplyFixArgs
 		// Note: no progress here. Applying fixes should be quick.
 		forURI: args.URI,
 	}, func(ctx context.Context, deps commandDeps) error {
-		changes, err := golang.ApplyFix(ctx, args.Fix, deps.snapshot, deps.fh, args.Range)
+		changes, sel, err := golang.ApplyFix(ctx, args.Fix, deps.snapshot, deps.fh, args.Range)
 		if err != nil {
 			return err
 		}
+		warnGeneratedEdits(ctx, c.s.client, deps.snapshot, changes)
 		wsedit := protocol.NewWorkspaceEdit(changes...)
 		if args.ResolveEdits {
 			result = wsedit
+			c.selection = sel
 			return nil
 		}
 		resp, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
@@ -225,11 +234,21 @@ func (c *commandHandler) ApplyFix(ctx context.Context, args command.ApplyFixArgs
 		if !resp.Applied {
 			return errors.New(resp.FailureReason)
 		}
+		if sel != nil {
+			openClientEditor(ctx, c.s.client, *sel)
+		}
 		return nil
 	})
 	return result, err
 }
 
+func (c *commandHandler) SelectRange(ctx context.Context, loc protocol.Location) error {
+	return c.run(ctx, commandConfig{}, func(ctx context.Context, _ commandDeps) error {
+		openClientEditor(ctx, c.s.client, loc)
+		return nil
+	})
+}
+
 func (c *commandHandler) RegenerateCgo(ctx context.Context, args command.URIArg) error {
 	return c.run(ctx, commandConfig{
 		progress: "Regenerating Cgo",
@@ -269,7 +288,7 @@ func (c *commandHandler) modifyState(ctx context.Context, source ModificationSou
 	go func() {
 		// Diagnosing with the background context ensures new snapshots are fully
 		// diagnosed.
-		c.s.diagnoseSnapshot(snapshot.BackgroundContext(), snapshot, nil, 0)
+		c.s.diagnoseSnapshot(snapshot.BackgroundContext(), snapshot, nil, 0, onSave)
 		release()
 		wg.Done()
 	}()
@@ -293,6 +312,18 @@ func (c *commandHandler) CheckUpgrades(ctx context.Context, args command.CheckUp
 	})
 }
 
+func (c *commandHandler) SnoozeUpdate(ctx context.Context, args command.SnoozeUpdateArgs) error {
+	return c.run(ctx, commandConfig{
+		forURI: args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		return c.modifyState(ctx, FromSnoozeUpdate, func() (*cache.Snapshot, func(), error) {
+			return c.s.session.InvalidateView(ctx, deps.snapshot.View(), cache.StateChange{
+				SnoozedUpdates: map[string]string{args.Module: args.Version},
+			})
+		})
+	})
+}
+
 func (c *commandHandler) AddDependency(ctx context.Context, args command.DependencyArgs) error {
 	return c.GoGetModule(ctx, args)
 }
@@ -408,19 +439,24 @@ func (c *commandHandler) EditGoDirective(ctx context.Context, args command.EditG
 	return c.run(ctx, commandConfig{
 		requireSave: true, // if go.mod isn't saved it could cause a problem
 		forURI:      args.URI,
-	}, func(ctx context.Context, _ commandDeps) error {
-		fh, snapshot, release, err := c.s.fileOf(ctx, args.URI)
+	}, func(ctx context.Context, deps commandDeps) error {
+		contents, err := mod.EditGoDirective(ctx, deps.snapshot, args.URI, args.Version)
 		if err != nil {
 			return err
 		}
-		defer release()
-		if err := c.s.runGoModUpdateCommands(ctx, snapshot, fh.URI(), func(invoke func(...string) (*bytes.Buffer, error)) error {
-			_, err := invoke("mod", "edit", "-go", args.Version)
-			return err
-		}); err != nil {
-			return err
+		uris := maps.Keys(contents)
+		sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+		var changes []protocol.DocumentChange
+		for _, uri := range uris {
+			change, err := computeEditChange(ctx, deps.snapshot, uri, contents[uri])
+			if err != nil {
+				return err
+			}
+			if change.Valid() {
+				changes = append(changes, change)
+			}
 		}
-		return nil
+		return applyChanges(ctx, c.s.client, changes)
 	})
 }
 
@@ -450,18 +486,49 @@ func (c *commandHandler) RemoveDependency(ctx context.Context, args command.Remo
 		if err != nil {
 			return err
 		}
-		response, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
-			Edit: *protocol.NewWorkspaceEdit(
-				protocol.DocumentChangeEdit(deps.fh, edits)),
-		})
+		return c.applyModEdits(ctx, deps.fh, edits)
+	})
+}
+
+func (c *commandHandler) AddReplace(ctx context.Context, args command.AddReplaceArgs) error {
+	return c.run(ctx, commandConfig{
+		progress: "Adding replace directive",
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		edits, err := mod.AddReplace(ctx, deps.snapshot, deps.fh, args.ModulePath, args.Dir)
 		if err != nil {
 			return err
 		}
-		if !response.Applied {
-			return fmt.Errorf("edits not applied because of %s", response.FailureReason)
+		return c.applyModEdits(ctx, deps.fh, edits)
+	})
+}
+
+func (c *commandHandler) RemoveReplace(ctx context.Context, args command.RemoveReplaceArgs) error {
+	return c.run(ctx, commandConfig{
+		progress: "Removing replace directive",
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		edits, err := mod.RemoveReplace(ctx, deps.snapshot, deps.fh, args.ModulePath)
+		if err != nil {
+			return err
 		}
-		return nil
+		return c.applyModEdits(ctx, deps.fh, edits)
+	})
+}
+
+// applyModEdits applies the edits to the go.mod file through the client.
+func (c *commandHandler) applyModEdits(ctx context.Context, fh file.Handle, edits []protocol.TextEdit) error {
+	response, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+		Edit: *protocol.NewWorkspaceEdit(
+			protocol.DocumentChangeEdit(fh, edits)),
 	})
+	if err != nil {
+		return err
+	}
+	if !response.Applied {
+		return fmt.Errorf("edits not applied because of %s", response.FailureReason)
+	}
+	return nil
 }
 
 // dropDependency returns the edits to remove the given require from the go.mod
@@ -533,6 +600,9 @@ func (c *commandHandler) RunTests(ctx context.Context, args command.RunTestsArgs
 		requireSave: true,              // go test honors overlays, but tests themselves cannot
 		forURI:      args.URI,
 	}, func(ctx context.Context, deps commandDeps) error {
+		if err := deps.snapshot.LoadTestVariants(ctx); err != nil {
+			return err
+		}
 		return c.runTests(ctx, deps.snapshot, deps.work, args.URI, args.Tests, args.Benchmarks)
 	})
 }
@@ -653,6 +723,81 @@ func (c *commandHandler) Generate(ctx context.Context, args command.GenerateArgs
 	})
 }
 
+func (c *commandHandler) RunMain(ctx context.Context, args command.RunMainArgs) error {
+	return c.run(ctx, commandConfig{
+		progress: "Running main package", // (asynchronous)
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		config := golang.RunConfig{Args: args.Args, Env: args.Env}
+		if config.Args == nil && config.Env == nil {
+			config = golang.LoadRunConfig(ctx, args.URI, args.File)
+		} else {
+			golang.SaveRunConfig(ctx, args.URI, args.File, config)
+		}
+
+		// Rather than "go run", which would hold the go command
+		// runner until the program exits, blocking all package
+		// loading, build the executable and run it directly.
+		tmpDir, err := os.MkdirTemp("", "gopls-run-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		exe := filepath.Join(tmpDir, "main")
+		if runtime.GOOS == "windows" {
+			exe += ".exe"
+		}
+		target := "."
+		if args.File {
+			target = filepath.Base(args.URI.Path())
+		}
+		dir := args.URI.Dir().Path()
+		inv, cleanupInvocation, err := deps.snapshot.GoCommandInvocation(false, &gocommand.Invocation{
+			Verb:       "build",
+			Args:       []string{"-o", exe, target},
+			Env:        config.Env,
+			WorkingDir: dir,
+		})
+		if err != nil {
+			return err
+		}
+		defer cleanupInvocation()
+		ew := progress.NewEventWriter(ctx, "run")
+		out := io.MultiWriter(ew, progress.NewWorkDoneWriter(ctx, deps.work))
+		if err := deps.snapshot.View().GoCommandRunner().RunPiped(ctx, *inv, out, out); err != nil {
+			return err
+		}
+
+		cmd := exec.CommandContext(ctx, exe, config.Args...)
+		cmd.Dir = dir
+		cmd.Env = inv.Env
+		cmd.Stdout = out
+		cmd.Stderr = out
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err() // canceled: the program was killed
+			}
+			return fmt.Errorf("running %s: %v", target, err)
+		}
+		return nil
+	})
+}
+
+func (c *commandHandler) Importers(ctx context.Context, args command.ImportersArgs) (command.ImportersResult, error) {
+	var result command.ImportersResult
+	err := c.run(ctx, commandConfig{
+		forURI: args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		importers, err := golang.Importers(ctx, deps.snapshot, args.URI, args.Depth)
+		if err != nil {
+			return err
+		}
+		result.Importers = importers
+		return nil
+	})
+	return result, err
+}
+
 func (c *commandHandler) GoGetPackage(ctx context.Context, args command.GoGetPackageArgs) error {
 	return c.run(ctx, commandConfig{
 		forURI:   args.URI,
@@ -696,6 +841,28 @@ func (c *commandHandler) GoGetPackage(ctx context.Context, args command.GoGetPac
 	})
 }
 
+func (c *commandHandler) CreatePackage(ctx context.Context, args command.CreatePackageArgs) error {
+	return c.run(ctx, commandConfig{
+		forURI: args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		changes, placeholder, err := golang.CreatePackage(ctx, deps.snapshot, args.URI, args.Pkg)
+		if err != nil {
+			return err
+		}
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Edit: *protocol.NewWorkspaceEdit(changes...),
+		})
+		if err != nil {
+			return err
+		}
+		if !r.Applied {
+			return fmt.Errorf("failed to apply edits: %v", r.FailureReason)
+		}
+		openClientEditor(ctx, c.s.client, placeholder)
+		return nil
+	})
+}
+
 func (s *server) runGoModUpdateCommands(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI, run func(invoke func(...string) (*bytes.Buffer, error)) error) error {
 	// TODO(rfindley): can/should this use findRootPattern?
 	modURI := snapshot.GoModForFile(uri)
@@ -857,6 +1024,247 @@ func (c *commandHandler) ToggleGCDetails(ctx context.Context, args command.URIAr
 	})
 }
 
+func (c *commandHandler) DeepAnalysis(ctx context.Context, args command.URIArg) error {
+	return c.run(ctx, commandConfig{
+		progress: "Running deep analysis",
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		meta, err := golang.NarrowestMetadataForFile(ctx, deps.snapshot, deps.fh.URI())
+		if err != nil {
+			return err
+		}
+		files := deps.snapshot.FileIdentities()
+		diags, err := golang.DeepAnalyze(ctx, deps.snapshot, meta)
+		if err != nil {
+			return err
+		}
+		if len(diags) == 0 {
+			showMessage(ctx, c.s.client, protocol.Info, "Deep analysis found no problems")
+		}
+		return c.modifyState(ctx, FromDeepAnalysis, func() (*cache.Snapshot, func(), error) {
+			return c.s.session.InvalidateView(ctx, deps.snapshot.View(), cache.StateChange{
+				DeepAnalysis: map[metadata.PackageID]map[protocol.DocumentURI][]*cache.Diagnostic{
+					meta.ID: diags,
+				},
+				ResultFiles: files,
+			})
+		})
+	})
+}
+
+func (c *commandHandler) FindDeadCode(ctx context.Context, args command.URIArg) error {
+	return c.run(ctx, commandConfig{
+		progress: "Finding dead code",
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		files := deps.snapshot.FileIdentities()
+		diags, err := golang.FindDeadCode(ctx, deps.snapshot)
+		if err != nil {
+			return err
+		}
+		if len(diags) == 0 {
+			showMessage(ctx, c.s.client, protocol.Info, "No dead code found")
+			diags = make(map[protocol.DocumentURI][]*cache.Diagnostic) // non-nil: replace earlier results
+		}
+		return c.modifyState(ctx, FromFindDeadCode, func() (*cache.Snapshot, func(), error) {
+			return c.s.session.InvalidateView(ctx, deps.snapshot.View(), cache.StateChange{
+				DeadCode:    diags,
+				ResultFiles: files,
+			})
+		})
+	})
+}
+
+func (c *commandHandler) Describe(ctx context.Context, loc protocol.Location) (command.DescribeResult, error) {
+	var result command.DescribeResult
+	err := c.run(ctx, commandConfig{
+		forURI: loc.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		if kind := deps.snapshot.FileKind(deps.fh); kind != file.Go {
+			return fmt.Errorf("can't describe syntax in %s file", kind)
+		}
+		var err error
+		result, err = golang.Describe(ctx, deps.snapshot, deps.fh, loc.Range)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) PointsTo(ctx context.Context, loc protocol.Location) (command.PointsToResult, error) {
+	var result command.PointsToResult
+	err := c.run(ctx, commandConfig{
+		progress: "Computing points-to set",
+		forURI:   loc.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result, err = golang.PointsTo(ctx, deps.snapshot, deps.fh, loc.Range)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) Peers(ctx context.Context, loc protocol.Location) (command.PeersResult, error) {
+	var result command.PeersResult
+	err := c.run(ctx, commandConfig{
+		progress: "Finding channel peers",
+		forURI:   loc.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result, err = golang.Peers(ctx, deps.snapshot, deps.fh, loc.Range)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) WhichErrs(ctx context.Context, loc protocol.Location) (command.WhichErrsResult, error) {
+	var result command.WhichErrsResult
+	err := c.run(ctx, commandConfig{
+		progress: "Finding possible errors",
+		forURI:   loc.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		var err error
+		result, err = golang.WhichErrs(ctx, deps.snapshot, deps.fh, loc.Range)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) ListClones(ctx context.Context, args command.URIArg) (command.ListClonesResult, error) {
+	var result command.ListClonesResult
+	err := c.run(ctx, commandConfig{
+		progress: "Finding duplicated functions",
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		clusters, err := golang.FindClones(ctx, deps.snapshot)
+		if err != nil {
+			return err
+		}
+		for _, cluster := range clusters {
+			cc := command.CloneCluster{Tokens: cluster.Tokens}
+			for _, clone := range cluster.Clones {
+				cc.Clones = append(cc.Clones, command.Clone{
+					Name:     clone.Name,
+					Location: clone.Location,
+				})
+			}
+			result.Clusters = append(result.Clusters, cc)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (c *commandHandler) ShowNextClone(ctx context.Context, loc protocol.Location) error {
+	return c.run(ctx, commandConfig{
+		forURI: loc.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		clusters, err := golang.FindClones(ctx, deps.snapshot)
+		if err != nil {
+			return err
+		}
+		for _, cluster := range clusters {
+			for i, clone := range cluster.Clones {
+				if clone.Location.URI == loc.URI && protocol.Intersect(clone.Location.Range, loc.Range) {
+					next := cluster.Clones[(i+1)%len(cluster.Clones)]
+					openClientEditor(ctx, c.s.client, next.Location)
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("no duplicates of the function at %s:%d", loc.URI.Path(), loc.Range.Start.Line+1)
+	})
+}
+
+func (c *commandHandler) AddToDictionary(ctx context.Context, args command.AddToDictionaryArgs) error {
+	return c.run(ctx, commandConfig{
+		forURI: args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		filename := deps.snapshot.SpellingDictionaryFile()
+		data, err := os.ReadFile(filename)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+		data = append(data, args.Word+"\n"...)
+		if err := os.WriteFile(filename, data, 0666); err != nil {
+			return err
+		}
+		// Diagnose again, now that the word is accepted.
+		return c.modifyState(ctx, FromAddToDictionary, func() (*cache.Snapshot, func(), error) {
+			return c.s.session.InvalidateView(ctx, deps.snapshot.View(), cache.StateChange{})
+		})
+	})
+}
+
+func (c *commandHandler) RunAnalyzer(ctx context.Context, args command.RunAnalyzerArgs) (map[protocol.DocumentURI][]protocol.Diagnostic, error) {
+	result := make(map[protocol.DocumentURI][]protocol.Diagnostic)
+	err := c.run(ctx, commandConfig{
+		progress: "Running " + args.Analyzer,
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		diags, err := golang.RunAnalyzer(ctx, deps.snapshot, args.Analyzer, args.URI)
+		if err != nil {
+			return err
+		}
+		// A file may belong to several of the analyzed packages
+		// (such as a package and its test variant).
+		byURI := make(map[protocol.DocumentURI][]*cache.Diagnostic)
+		type key struct {
+			uri  protocol.DocumentURI
+			hash file.Hash
+		}
+		seen := make(map[key]bool)
+		for _, diag := range diags {
+			if k := (key{diag.URI, hashDiagnostic(diag)}); !seen[k] {
+				seen[k] = true
+				byURI[diag.URI] = append(byURI[diag.URI], diag)
+			}
+		}
+		for uri, diags := range byURI {
+			sortDiagnostics(diags)
+			result[uri] = toProtocolDiagnostics(diags)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (c *commandHandler) FixAll(ctx context.Context, args command.FixAllArgs) (*protocol.WorkspaceEdit, error) {
+	var result *protocol.WorkspaceEdit
+	err := c.run(ctx, commandConfig{
+		progress: "Fixing " + args.Analyzer + " diagnostics",
+		forURI:   args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		changes, fixed, err := golang.FixAll(ctx, deps.snapshot, args.Analyzer, args.URI)
+		if err != nil {
+			return err
+		}
+		if fixed == 0 {
+			return fmt.Errorf("no %s diagnostics to fix", args.Analyzer)
+		}
+		wsedit := protocol.NewWorkspaceEdit(changes...)
+		if args.ResolveEdits {
+			result = wsedit
+			return nil
+		}
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Label: fmt.Sprintf("Fix %d %s diagnostics", fixed, args.Analyzer),
+			Edit:  *wsedit,
+		})
+		if err != nil {
+			return err
+		}
+		if !r.Applied {
+			return fmt.Errorf("failed to apply edits: %v", r.FailureReason)
+		}
+		showMessage(ctx, c.s.client, protocol.Info, fmt.Sprintf("Fixed %d %s diagnostics in %d files.", fixed, args.Analyzer, len(changes)))
+		return nil
+	})
+	return result, err
+}
+
 func (c *commandHandler) ListKnownPackages(ctx context.Context, args command.URIArg) (command.ListKnownPackagesResult, error) {
 	var result command.ListKnownPackagesResult
 	err := c.run(ctx, commandConfig{
@@ -1058,7 +1466,7 @@ func (c *commandHandler) RunGovulncheck(ctx context.Context, args command.Vulnch
 
 		// Diagnosing with the background context ensures new snapshots are fully
 		// diagnosed.
-		c.s.diagnoseSnapshot(snapshot.BackgroundContext(), snapshot, nil, 0)
+		c.s.diagnoseSnapshot(snapshot.BackgroundContext(), snapshot, nil, 0, onSave)
 
 		affecting := make(map[string]bool, len(result.Entries))
 		for _, finding := range result.Findings {
@@ -1282,6 +1690,22 @@ func showMessage(ctx context.Context, cli protocol.Client, typ protocol.MessageT
 	return true
 }
 
+// warnGeneratedEdits shows a warning if the changes edit generated
+// files, as such edits are lost when the files are next regenerated.
+func warnGeneratedEdits(ctx context.Context, cli protocol.Client, snapshot *cache.Snapshot, changes []protocol.DocumentChange) {
+	var generated []string
+	for _, change := range changes {
+		if edit := change.TextDocumentEdit; edit != nil && golang.IsGenerated(ctx, snapshot, edit.TextDocument.URI) {
+			generated = append(generated, filepath.Base(edit.TextDocument.URI.Path()))
+		}
+	}
+	if len(generated) > 0 {
+		sort.Strings(generated)
+		msg := fmt.Sprintf("This change edits generated files (%s); the edits will be lost when they are regenerated.", strings.Join(generated, ", "))
+		showMessage(ctx, cli, protocol.Warning, msg)
+	}
+}
+
 // openClientBrowser causes the LSP client to open the specified URL
 // in an external browser.
 func openClientBrowser(ctx context.Context, cli protocol.Client, url protocol.URI) {
@@ -1331,30 +1755,449 @@ func showDocumentImpl(ctx context.Context, cli protocol.Client, url protocol.URI
 
 func (c *commandHandler) ChangeSignature(ctx context.Context, args command.ChangeSignatureArgs) (*protocol.WorkspaceEdit, error) {
 	var result *protocol.WorkspaceEdit
+	uri := args.RemoveParameter.URI
+	if uri == "" {
+		uri = args.Function.URI
+	}
 	err := c.run(ctx, commandConfig{
-		forURI: args.RemoveParameter.URI,
+		forURI: uri,
 	}, func(ctx context.Context, deps commandDeps) error {
-		// For now, gopls only supports removing unused parameters.
-		docedits, err := golang.RemoveUnusedParameter(ctx, deps.fh, args.RemoveParameter.Range, deps.snapshot)
-		if err != nil {
+		// Calls in test files must be rewritten too.
+		if err := deps.snapshot.LoadTestVariants(ctx); err != nil {
 			return err
 		}
-		wsedit := protocol.NewWorkspaceEdit(docedits...)
-		if args.ResolveEdits {
-			result = wsedit
+		var (
+			docedits []protocol.DocumentChange
+			err      error
+		)
+		if args.RemoveParameter.URI != "" {
+			docedits, err = golang.RemoveUnusedParameter(ctx, deps.fh, args.RemoveParameter.Range, deps.snapshot)
+		} else {
+			newParams := args.NewParams
+			if newParams == nil {
+				newParams, err = c.promptForNewParams(ctx, deps.snapshot, deps.fh, args.Function.Range)
+				if err != nil || newParams == nil {
+					return err // e.g. dialog was dismissed
+				}
+			}
+			docedits, err = golang.ChangeSignature(ctx, deps.snapshot, deps.fh, args.Function.Range, newParams)
+		}
+		if err != nil {
+			return err
+		}
+		warnGeneratedEdits(ctx, c.s.client, deps.snapshot, docedits)
+		wsedit := protocol.NewWorkspaceEdit(docedits...)
+		if args.ResolveEdits {
+			result = wsedit
+			return nil
+		}
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Edit: *wsedit,
+		})
+		if !r.Applied {
+			return fmt.Errorf("failed to apply edits: %v", r.FailureReason)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (c *commandHandler) MoveDeclaration(ctx context.Context, args command.MoveDeclarationArgs) (*protocol.WorkspaceEdit, error) {
+	var result *protocol.WorkspaceEdit
+	err := c.run(ctx, commandConfig{
+		forURI: args.Location.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		dest := args.DestFile
+		if dest == "" {
+			var err error
+			dest, err = c.promptForMoveDestination(ctx, deps.snapshot, args.Location.URI)
+			if err != nil || dest == "" {
+				return err // e.g. dialog was dismissed
+			}
+		}
+		docedits, err := golang.MoveDeclaration(ctx, deps.snapshot, deps.fh, args.Location.Range, dest, args.MoveTests)
+		if err != nil {
+			return err
+		}
+		warnGeneratedEdits(ctx, c.s.client, deps.snapshot, docedits)
+		wsedit := protocol.NewWorkspaceEdit(docedits...)
+		if args.ResolveEdits {
+			result = wsedit
+			return nil
+		}
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Edit: *wsedit,
+		})
+		if err != nil {
+			return err
+		}
+		if !r.Applied {
+			return fmt.Errorf("failed to apply edits: %v", r.FailureReason)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (c *commandHandler) SafeDelete(ctx context.Context, args command.SafeDeleteArgs) (*protocol.WorkspaceEdit, error) {
+	var result *protocol.WorkspaceEdit
+	err := c.run(ctx, commandConfig{
+		forURI: args.Location.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		docedits, err := golang.SafeDelete(ctx, deps.snapshot, deps.fh, args.Location.Range)
+		if err != nil {
+			return err
+		}
+		warnGeneratedEdits(ctx, c.s.client, deps.snapshot, docedits)
+		wsedit := protocol.NewWorkspaceEdit(docedits...)
+		if args.ResolveEdits {
+			result = wsedit
+			return nil
+		}
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Edit: *wsedit,
+		})
+		if err != nil {
+			return err
+		}
+		if !r.Applied {
+			return fmt.Errorf("failed to apply edits: %v", r.FailureReason)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (c *commandHandler) ConvertMethod(ctx context.Context, args command.ConvertMethodArgs) (*protocol.WorkspaceEdit, error) {
+	var result *protocol.WorkspaceEdit
+	err := c.run(ctx, commandConfig{
+		forURI: args.Location.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		docedits, err := golang.ConvertMethod(ctx, deps.snapshot, deps.fh, args.Location.Range)
+		if err != nil {
+			return err
+		}
+		warnGeneratedEdits(ctx, c.s.client, deps.snapshot, docedits)
+		wsedit := protocol.NewWorkspaceEdit(docedits...)
+		if args.ResolveEdits {
+			result = wsedit
+			return nil
+		}
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Edit: *wsedit,
+		})
+		if err != nil {
+			return err
+		}
+		if !r.Applied {
+			return fmt.Errorf("failed to apply edits: %v", r.FailureReason)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// promptForMoveDestination asks the user to choose the package to
+// which a declaration in the specified file should be moved, and
+// returns the file of that package that should receive it, or "" if
+// the user made no choice.
+func (c *commandHandler) promptForMoveDestination(ctx context.Context, snapshot *cache.Snapshot, uri protocol.DocumentURI) (protocol.DocumentURI, error) {
+	dests, err := golang.MoveDestinations(ctx, snapshot, uri)
+	if err != nil {
+		return "", err
+	}
+	if len(dests) == 0 {
+		return "", fmt.Errorf("no package of the module can receive the declaration")
+	}
+	params := &protocol.ShowMessageRequestParams{
+		Type:    protocol.Info,
+		Message: "Move the declaration to which package?",
+	}
+	for _, dest := range dests {
+		params.Actions = append(params.Actions, protocol.MessageActionItem{Title: string(dest.PkgPath)})
+	}
+	item, err := c.s.client.ShowMessageRequest(ctx, params)
+	if err != nil || item == nil {
+		return "", err
+	}
+	for _, dest := range dests {
+		if string(dest.PkgPath) == item.Title {
+			return dest.File, nil
+		}
+	}
+	return "", fmt.Errorf("unknown package %q", item.Title)
+}
+
+// promptForNewParams asks the user to reorder or remove the parameters of
+// the function whose name or parameters contain rng, one step at a time,
+// and returns the resulting parameters, or nil if the user made no
+// choice.
+func (c *commandHandler) promptForNewParams(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]command.ChangeSignatureParam, error) {
+	name, params, err := golang.SignatureParams(ctx, snapshot, fh, rng)
+	if err != nil {
+		return nil, err
+	}
+	const apply = "Apply"
+	order := make([]int, len(params)) // indices of the remaining parameters
+	for i := range order {
+		order[i] = i
+	}
+	for {
+		var descs []string
+		for _, i := range order {
+			descs = append(descs, params[i])
+		}
+		req := &protocol.ShowMessageRequestParams{
+			Type:    protocol.Info,
+			Message: fmt.Sprintf("Change the signature of %s to (%s)?", name, strings.Join(descs, ", ")),
+			Actions: []protocol.MessageActionItem{{Title: apply}},
+		}
+		// Each choice other than apply is a step applied to order.
+		steps := make(map[string]func())
+		for k, desc := range descs {
+			k := k
+			if k > 0 {
+				title := fmt.Sprintf("Move #%d (%s) earlier", k+1, desc)
+				steps[title] = func() { order[k-1], order[k] = order[k], order[k-1] }
+				req.Actions = append(req.Actions, protocol.MessageActionItem{Title: title})
+			}
+			title := fmt.Sprintf("Remove #%d (%s)", k+1, desc)
+			steps[title] = func() { order = append(order[:k], order[k+1:]...) }
+			req.Actions = append(req.Actions, protocol.MessageActionItem{Title: title})
+		}
+		item, err := c.s.client.ShowMessageRequest(ctx, req)
+		if err != nil || item == nil {
+			return nil, err
+		}
+		if item.Title == apply {
+			break
+		}
+		step, ok := steps[item.Title]
+		if !ok {
+			return nil, fmt.Errorf("unknown choice %q", item.Title)
+		}
+		step()
+	}
+	newParams := make([]command.ChangeSignatureParam, 0, len(order))
+	for _, i := range order {
+		newParams = append(newParams, command.ChangeSignatureParam{OldIndex: i})
+	}
+	return newParams, nil
+}
+
+func (c *commandHandler) ExtractInterface(ctx context.Context, args command.ExtractInterfaceArgs) (*protocol.WorkspaceEdit, error) {
+	var result *protocol.WorkspaceEdit
+	err := c.run(ctx, commandConfig{
+		forURI: args.Location.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		rng := args.Location.Range
+		methods, params := args.Methods, args.Params
+		if len(methods) == 0 {
+			var err error
+			methods, params, err = c.promptForInterface(ctx, deps.snapshot, deps.fh, rng)
+			if err != nil || methods == nil {
+				return err // e.g. dialog was dismissed
+			}
+		}
+		docedits, sel, err := golang.ExtractInterface(ctx, deps.snapshot, deps.fh, rng, args.Name, methods, params)
+		if err != nil {
+			return err
+		}
+		warnGeneratedEdits(ctx, c.s.client, deps.snapshot, docedits)
+		wsedit := protocol.NewWorkspaceEdit(docedits...)
+		if args.ResolveEdits {
+			result = wsedit
+			c.selection = sel
+			return nil
+		}
+		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
+			Edit: *wsedit,
+		})
+		if err != nil {
+			return err
+		}
+		if !r.Applied {
+			return fmt.Errorf("failed to apply edits: %v", r.FailureReason)
+		}
+		openClientEditor(ctx, c.s.client, *sel)
+		return nil
+	})
+	return result, err
+}
+
+// promptForInterface asks the user to choose the methods of the
+// interface to extract from the type named at rng, and then the
+// parameters whose type should change to the interface. It returns
+// nil methods if the user made no choice.
+func (c *commandHandler) promptForInterface(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range) ([]string, []protocol.Location, error) {
+	tname, all, err := golang.ExtractInterfaceMethods(ctx, snapshot, fh, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	chosen := make([]bool, len(all))
+	for i := range chosen {
+		chosen[i] = true
+	}
+	ok, err := c.promptForSubset(ctx, func(names []string) string {
+		return fmt.Sprintf("Extract an interface from %s with methods %s?", tname, strings.Join(names, ", "))
+	}, all, chosen)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+	var methods []string
+	for i, name := range all {
+		if chosen[i] {
+			methods = append(methods, name)
+		}
+	}
+	if len(methods) == 0 {
+		return nil, nil, fmt.Errorf("no methods were chosen for the interface")
+	}
+
+	candidates, err := golang.ExtractInterfaceParams(ctx, snapshot, fh, rng, methods)
+	if err != nil || len(candidates) == 0 {
+		return methods, nil, err
+	}
+	descs := make([]string, len(candidates))
+	for i, p := range candidates {
+		descs[i] = p.Desc
+	}
+	chosen = make([]bool, len(candidates))
+	ok, err = c.promptForSubset(ctx, func(descs []string) string {
+		if len(descs) == 0 {
+			return fmt.Sprintf("Use the interface for parameters of type %s? (none)", tname)
+		}
+		return fmt.Sprintf("Use the interface for parameters of type %s: %s?", tname, strings.Join(descs, ", "))
+	}, descs, chosen)
+	if err != nil || !ok {
+		return nil, nil, err
+	}
+	var params []protocol.Location
+	for i, p := range candidates {
+		if chosen[i] {
+			params = append(params, p.Location)
+		}
+	}
+	return methods, params, nil
+}
+
+func (c *commandHandler) GenerateConstructor(ctx context.Context, args command.GenerateConstructorArgs) (*protocol.WorkspaceEdit, error) {
+	var result *protocol.WorkspaceEdit
+	err := c.run(ctx, commandConfig{
+		forURI: args.Location.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		rng := args.Location.Range
+		fields := args.Fields
+		if fields == nil {
+			var (
+				ok  bool
+				err error
+			)
+			fields, ok, err = c.promptForConstructor(ctx, deps.snapshot, deps.fh, rng, args.Options)
+			if err != nil || !ok {
+				return err // e.g. dialog was dismissed
+			}
+		}
+		docedits, sel, err := golang.GenerateConstructor(ctx, deps.snapshot, deps.fh, rng, fields, args.Options)
+		if err != nil {
+			return err
+		}
+		warnGeneratedEdits(ctx, c.s.client, deps.snapshot, docedits)
+		wsedit := protocol.NewWorkspaceEdit(docedits...)
+		if args.ResolveEdits {
+			result = wsedit
+			c.selection = sel
 			return nil
 		}
 		r, err := c.s.client.ApplyEdit(ctx, &protocol.ApplyWorkspaceEditParams{
 			Edit: *wsedit,
 		})
+		if err != nil {
+			return err
+		}
 		if !r.Applied {
 			return fmt.Errorf("failed to apply edits: %v", r.FailureReason)
 		}
+		openClientEditor(ctx, c.s.client, *sel)
 		return nil
 	})
 	return result, err
 }
 
+// promptForConstructor asks the user to choose the fields of the
+// struct type named at rng that are parameters of its constructor:
+// initially all of them, or with options, none. It reports whether
+// the user made a choice.
+func (c *commandHandler) promptForConstructor(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, rng protocol.Range, options bool) ([]string, bool, error) {
+	name, all, err := golang.ConstructorFields(ctx, snapshot, fh, rng)
+	if err != nil {
+		return nil, false, err
+	}
+	chosen := make([]bool, len(all))
+	for i := range chosen {
+		chosen[i] = !options
+	}
+	ok, err := c.promptForSubset(ctx, func(names []string) string {
+		params := strings.Join(names, ", ")
+		if options {
+			params += ", opts"
+		}
+		return fmt.Sprintf("Generate %s(%s)?", name, strings.TrimPrefix(params, ", "))
+	}, all, chosen)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	fields := []string{} // non-nil
+	for i, name := range all {
+		if chosen[i] {
+			fields = append(fields, name)
+		}
+	}
+	return fields, true, nil
+}
+
+// promptForSubset asks the user to choose a subset of items, starting
+// from the chosen ones, by repeatedly including or excluding an item
+// until they apply the choice. The message describes the chosen items.
+// It updates chosen, and reports whether the user applied the choice.
+func (c *commandHandler) promptForSubset(ctx context.Context, message func(chosen []string) string, items []string, chosen []bool) (bool, error) {
+	const apply = "Apply"
+	for {
+		var names []string
+		for i, item := range items {
+			if chosen[i] {
+				names = append(names, item)
+			}
+		}
+		req := &protocol.ShowMessageRequestParams{
+			Type:    protocol.Info,
+			Message: message(names),
+			Actions: []protocol.MessageActionItem{{Title: apply}},
+		}
+		toggles := make(map[string]int) // title -> index of item
+		for i, item := range items {
+			title := "Include " + item
+			if chosen[i] {
+				title = "Exclude " + item
+			}
+			toggles[title] = i
+			req.Actions = append(req.Actions, protocol.MessageActionItem{Title: title})
+		}
+		item, err := c.s.client.ShowMessageRequest(ctx, req)
+		if err != nil || item == nil {
+			return false, err
+		}
+		if item.Title == apply {
+			return true, nil
+		}
+		i, ok := toggles[item.Title]
+		if !ok {
+			return false, fmt.Errorf("unknown choice %q", item.Title)
+		}
+		chosen[i] = !chosen[i]
+	}
+}
+
 func (c *commandHandler) DiagnoseFiles(ctx context.Context, args command.DiagnoseFilesArgs) error {
 	return c.run(ctx, commandConfig{
 		progress: "Diagnose files",
@@ -1391,13 +2234,188 @@ func (c *commandHandler) DiagnoseFiles(ctx context.Context, args command.Diagnos
 				// Use the operation context for diagnosis, rather than
 				// snapshot.BackgroundContext, because this operation does not create
 				// new snapshots (so they should also be diagnosed by other means).
-				c.s.diagnoseSnapshot(ctx, snapshot, nil, 0)
+				c.s.diagnoseSnapshot(ctx, snapshot, nil, 0, onSave)
+			}()
+		}
+		wg.Wait()
+
+		return nil
+	})
+}
+
+func (c *commandHandler) FilterDiagnostics(ctx context.Context, args command.FilterDiagnosticsArgs) (command.FilterDiagnosticsResult, error) {
+	var result command.FilterDiagnosticsResult
+	err := c.run(ctx, commandConfig{}, func(ctx context.Context, _ commandDeps) error {
+		var err error
+		result, err = c.s.filterDiagnostics(ctx, args)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) AnalyzeNow(ctx context.Context) error {
+	return c.run(ctx, commandConfig{
+		progress: "Analyzing workspace",
+	}, func(ctx context.Context, _ commandDeps) error {
+		var wg sync.WaitGroup
+		for _, view := range c.s.session.Views() {
+			snapshot, release, err := view.Snapshot()
+			if err != nil {
+				continue // view is shut down
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer release()
+
+				// As for DiagnoseFiles, use the operation context,
+				// since this operation does not create new snapshots.
+				c.s.diagnoseSnapshot(ctx, snapshot, nil, 0, onDemand)
 			}()
 		}
 		wg.Wait()
+		return nil
+	})
+}
+
+func (c *commandHandler) NextDiagnostic(ctx context.Context, args command.NextDiagnosticArgs) (*protocol.Location, error) {
+	var result *protocol.Location
+	err := c.run(ctx, commandConfig{}, func(ctx context.Context, _ commandDeps) error {
+		result = c.s.nextDiagnostic(args)
+		return nil
+	})
+	return result, err
+}
 
+func (c *commandHandler) NextReference(ctx context.Context, args command.NextReferenceArgs) (*protocol.Location, error) {
+	var result *protocol.Location
+	err := c.run(ctx, commandConfig{
+		forURI: args.Location.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		if kind := deps.snapshot.FileKind(deps.fh); kind != file.Go {
+			return fmt.Errorf("can't find references in %s file", kind)
+		}
+		refs, err := golang.References(ctx, deps.snapshot, deps.fh, args.Location.Range.Start, true, nil)
+		if err != nil {
+			return err
+		}
+		// Start from the reference containing the position, so that
+		// the current reference is neither next nor previous.
+		from := args.Location
+		for _, ref := range refs {
+			if ref.URI == from.URI && protocol.Intersect(ref.Range, from.Range) {
+				from = ref
+				break
+			}
+		}
+		result = nextLocation(refs, from, args.Previous)
 		return nil
 	})
+	return result, err
+}
+
+func (c *commandHandler) MatchingBracket(ctx context.Context, loc protocol.Location) (*protocol.Location, error) {
+	var result *protocol.Location
+	err := c.run(ctx, commandConfig{
+		forURI: loc.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		if kind := deps.snapshot.FileKind(deps.fh); kind != file.Go {
+			return fmt.Errorf("can't match brackets in %s file", kind)
+		}
+		var err error
+		result, err = golang.MatchingBracket(ctx, deps.snapshot, deps.fh, loc.Range.Start)
+		return err
+	})
+	return result, err
+}
+
+func (c *commandHandler) TagReferences(ctx context.Context, loc protocol.Location) ([]command.TagReference, error) {
+	var result []command.TagReference
+	err := c.run(ctx, commandConfig{
+		forURI: loc.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		if kind := deps.snapshot.FileKind(deps.fh); kind != file.Go {
+			return fmt.Errorf("can't find references in %s file", kind)
+		}
+		textual, err := golang.TextualReferences(ctx, deps.snapshot, deps.fh, loc.Range.Start)
+		if err != nil {
+			return err
+		}
+		// The ordinary references include the textual ones
+		// if the tagReferences option is enabled.
+		isTextual := make(map[protocol.Location]bool)
+		for _, ref := range textual {
+			isTextual[ref.Location] = true
+		}
+		refs, err := golang.References(ctx, deps.snapshot, deps.fh, loc.Range.Start, true, nil)
+		if err != nil {
+			return err
+		}
+		result = []command.TagReference{}
+		for _, ref := range refs {
+			if !isTextual[ref] {
+				result = append(result, command.TagReference{Location: ref})
+			}
+		}
+		for _, ref := range textual {
+			result = append(result, command.TagReference{
+				Location: ref.Location,
+				Textual:  true,
+				Key:      ref.Key,
+			})
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (c *commandHandler) GeneratedLocation(ctx context.Context, loc protocol.Location) ([]protocol.Location, error) {
+	var result []protocol.Location
+	err := c.run(ctx, commandConfig{
+		forURI: loc.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		locs, err := golang.GeneratedLocations(ctx, deps.snapshot, loc)
+		if err != nil {
+			return err
+		}
+		result = append([]protocol.Location{}, locs...)
+		return nil
+	})
+	return result, err
+}
+
+func (c *commandHandler) AnalyzerDoc(ctx context.Context, args command.AnalyzerDocArgs) (command.AnalyzerDocResult, error) {
+	var result command.AnalyzerDocResult
+	err := c.run(ctx, commandConfig{
+		forURI: args.URI,
+	}, func(ctx context.Context, deps commandDeps) error {
+		markdown, err := golang.AnalyzerDoc(deps.snapshot, args.Analyzer, true)
+		if err != nil {
+			return err
+		}
+		result.Markdown = markdown
+		return nil
+	})
+	return result, err
+}
+
+func (c *commandHandler) SearchDocs(ctx context.Context, args command.SearchDocsArgs) (command.SearchDocsResult, error) {
+	var result command.SearchDocsResult
+	err := c.run(ctx, commandConfig{}, func(ctx context.Context, _ commandDeps) error {
+		var snapshots []*cache.Snapshot
+		for _, v := range c.s.session.Views() {
+			snapshot, release, err := v.Snapshot()
+			if err != nil {
+				continue // snapshot is shutting down
+			}
+			defer release()
+			snapshots = append(snapshots, snapshot)
+		}
+		var err error
+		result, err = golang.SearchDocs(ctx, snapshots, args.Query, args.Limit)
+		return err
+	})
+	return result, err
 }
 
 func (c *commandHandler) Views(ctx context.Context) ([]command.View, error) {
@@ -1414,6 +2432,82 @@ func (c *commandHandler) Views(ctx context.Context) ([]command.View, error) {
 	return summaries, nil
 }
 
+func (c *commandHandler) ViewInfo(ctx context.Context, args command.ViewInfoArgs) ([]command.ViewDescription, error) {
+	views := c.s.session.Views()
+	if args.URI != "" {
+		snapshot, release, err := c.s.session.SnapshotOf(ctx, args.URI)
+		if err != nil {
+			return nil, err
+		}
+		views = []*cache.View{snapshot.View()}
+		release()
+	}
+	var infos []command.ViewDescription
+	for _, view := range views {
+		snapshot, release, err := view.Snapshot()
+		if err != nil {
+			continue // view was shut down
+		}
+		info := command.ViewDescription{
+			ID:         view.ID(),
+			Type:       view.Type().String(),
+			Root:       view.Root(),
+			Folder:     view.Folder().Dir,
+			ModFiles:   view.ModFiles(),
+			GoVersion:  view.GoVersionString(),
+			GOOS:       view.GOOS(),
+			GOARCH:     view.GOARCH(),
+			GOFLAGS:    view.Folder().Env.GOFLAGS,
+			BuildFlags: snapshot.Options().BuildFlags,
+			EnvOverlay: view.EnvOverlay(),
+		}
+		sort.Slice(info.ModFiles, func(i, j int) bool { return info.ModFiles[i] < info.ModFiles[j] })
+		switch view.Type() {
+		case cache.GoModView:
+			info.GoMod = view.GoMod()
+		case cache.GoWorkView:
+			info.GoWork = view.GoWork()
+		}
+		for _, kv := range info.EnvOverlay {
+			if strings.HasPrefix(kv, "GOFLAGS=") {
+				info.GOFLAGS = strings.TrimPrefix(kv, "GOFLAGS=")
+			}
+		}
+		info.Tags = buildTags(append(strings.Fields(info.GOFLAGS), info.BuildFlags...))
+		for _, mp := range snapshot.MetadataGraph().Packages {
+			info.Packages++
+			if snapshot.IsWorkspacePackage(ctx, mp.ID) {
+				info.WorkspacePackages++
+			}
+		}
+		release()
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// buildTags returns the build tags set by the -tags flags among the
+// specified go command flags, sorted and without duplicates.
+func buildTags(flags []string) []string {
+	set := make(map[string]bool)
+	for i, flag := range flags {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(flag, "-"), "=")
+		if name != "tags" || !strings.HasPrefix(flag, "-") {
+			continue
+		}
+		if !hasValue && i+1 < len(flags) {
+			value = flags[i+1]
+		}
+		// Tags were once separated by spaces, now by commas.
+		for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			set[tag] = true
+		}
+	}
+	tags := maps.Keys(set)
+	sort.Strings(tags)
+	return tags
+}
+
 func (c *commandHandler) FreeSymbols(ctx context.Context, viewID string, loc protocol.Location) error {
 	web, err := c.s.getWeb()
 	if err != nil {
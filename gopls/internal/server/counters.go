@@ -26,3 +26,7 @@ var (
 		complUsed,
 	}
 )
+
+// staleFilesRecovered counts the recoveries from stale state, in which the
+// periodic check found files that had changed on disk without notification.
+var staleFilesRecovered = counter.New("gopls/stale-files:recovered")
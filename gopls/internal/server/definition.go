@@ -36,7 +36,14 @@ func (s *server) Definition(ctx context.Context, params *protocol.DefinitionPara
 	case file.Tmpl:
 		return template.Definition(snapshot, fh, params.Position)
 	case file.Go:
-		return golang.Definition(ctx, snapshot, fh, params.Position)
+		locs, err := golang.Definition(ctx, snapshot, fh, params.Position)
+		if err != nil {
+			return nil, err
+		}
+		if snapshot.Options().LineDirectives {
+			locs = golang.OriginalLocations(ctx, snapshot, locs)
+		}
+		return locs, nil
 	default:
 		return nil, fmt.Errorf("can't find definitions for file type %s", kind)
 	}
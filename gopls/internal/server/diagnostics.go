@@ -24,6 +24,7 @@ import (
 	"golang.org/x/tools/gopls/internal/label"
 	"golang.org/x/tools/gopls/internal/mod"
 	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
 	"golang.org/x/tools/gopls/internal/settings"
 	"golang.org/x/tools/gopls/internal/template"
 	"golang.org/x/tools/gopls/internal/util/maps"
@@ -34,8 +35,9 @@ import (
 
 // fileDiagnostics holds the current state of published diagnostics for a file.
 type fileDiagnostics struct {
-	publishedHash file.Hash // hash of the last set of diagnostics published for this URI
-	mustPublish   bool      // if set, publish diagnostics even if they haven't changed
+	publishedHash file.Hash           // hash of the last set of diagnostics published for this URI
+	published     []*cache.Diagnostic // the last set of diagnostics published for this URI
+	mustPublish   bool                // if set, publish diagnostics even if they haven't changed
 
 	// Orphaned file diagnostics are not necessarily associated with any *View
 	// (since they are orphaned). Instead, keep track of the modification ID at
@@ -100,7 +102,58 @@ func sortDiagnostics(d []*cache.Diagnostic) {
 	})
 }
 
+// A passTrigger identifies the event that causes a diagnostic pass,
+// which determines the diagnostic sources it computes according to the
+// analysisTriggers setting.
+type passTrigger int
+
+const (
+	onEdit   passTrigger = iota // a change to a file
+	onSave                      // a save, or another event such as a load
+	onIdle                      // a period without changes after a change
+	onDemand                    // the gopls.analyze_now command
+)
+
+// triggered reports whether a pass caused by the given trigger
+// computes the diagnostics of the source with the given path. For an
+// onIdle pass, idle is the time since the change.
+func triggered(opts *settings.Options, path string, trigger passTrigger, idle time.Duration) bool {
+	switch when, delay := opts.AnalysisTrigger(path); when {
+	case settings.AnalyzeOnEdit:
+		return true
+	case settings.AnalyzeOnSave:
+		return trigger == onSave || trigger == onDemand
+	case settings.AnalyzeManual:
+		return trigger == onDemand
+	default:
+		return trigger == onSave || trigger == onDemand || trigger == onIdle && delay <= idle
+	}
+}
+
+// idleDelays returns the distinct delays of the sources that the
+// analysisTriggers setting defers until a period without changes,
+// in increasing order.
+func idleDelays(opts *settings.Options) []time.Duration {
+	seen := make(map[time.Duration]bool)
+	var delays []time.Duration
+	for source := range opts.AnalysisTriggers {
+		if _, delay := opts.AnalysisTrigger(source); delay > 0 && !seen[delay] {
+			seen[delay] = true
+			delays = append(delays, delay)
+		}
+	}
+	sort.Slice(delays, func(i, j int) bool { return delays[i] < delays[j] })
+	return delays
+}
+
 func (s *server) diagnoseChangedViews(ctx context.Context, modID uint64, lastChange map[*cache.View][]protocol.DocumentURI, cause ModificationSource) {
+	start := time.Now()
+
+	trigger := onSave
+	if cause == FromDidChange {
+		trigger = onEdit
+	}
+
 	// Collect views needing diagnosis.
 	s.modificationMu.Lock()
 	needsDiagnosis := maps.Keys(s.viewsToDiagnose)
@@ -135,7 +188,7 @@ func (s *server) diagnoseChangedViews(ctx context.Context, modID uint64, lastCha
 		go func(snapshot *cache.Snapshot, uris []protocol.DocumentURI) {
 			defer release()
 			defer wg.Done()
-			s.diagnoseSnapshot(ctx, snapshot, uris, snapshot.Options().DiagnosticsDelay)
+			s.diagnoseSnapshot(ctx, snapshot, uris, snapshot.Options().DiagnosticsDelay, trigger)
 			s.modificationMu.Lock()
 
 			// Only remove v from s.viewsToDiagnose if the context is not cancelled.
@@ -148,6 +201,17 @@ func (s *server) diagnoseChangedViews(ctx context.Context, modID uint64, lastCha
 				delete(s.viewsToDiagnose, v)
 			}
 			s.modificationMu.Unlock()
+
+			// Compute the sources deferred until a period without
+			// changes in the background, until the next change
+			// cancels ctx.
+			if delays := idleDelays(snapshot.Options()); trigger == onEdit && len(delays) > 0 {
+				release := snapshot.Acquire()
+				go func() {
+					defer release()
+					s.diagnoseIdle(ctx, snapshot, start, delays)
+				}()
+			}
 		}(snapshot, uris)
 	}
 
@@ -165,6 +229,27 @@ func (s *server) diagnoseChangedViews(ctx context.Context, modID uint64, lastCha
 	}
 }
 
+// diagnoseIdle computes and publishes the diagnostics of the given
+// snapshot once each of the given delays has elapsed since the change
+// at time start, unless ctx is cancelled first.
+func (s *server) diagnoseIdle(ctx context.Context, snapshot *cache.Snapshot, start time.Time, delays []time.Duration) {
+	for _, delay := range delays {
+		select {
+		case <-time.After(time.Until(start.Add(delay))):
+		case <-ctx.Done():
+			return
+		}
+		diagnostics, err := s.diagnose(ctx, snapshot, onIdle, delay)
+		if err != nil {
+			if ctx.Err() == nil {
+				event.Error(ctx, "warning: while diagnosing idle snapshot", err, snapshot.Labels()...)
+			}
+			return
+		}
+		s.updateDiagnostics(ctx, snapshot, diagnostics, true)
+	}
+}
+
 // diagnoseSnapshot computes and publishes diagnostics for the given snapshot.
 //
 // If delay is non-zero, computing diagnostics does not start until after this
@@ -180,7 +265,10 @@ func (s *server) diagnoseChangedViews(ctx context.Context, modID uint64, lastCha
 // if an operation creates a new snapshot, it is responsible for ensuring that
 // snapshot (or a subsequent snapshot in the same View) is eventually
 // diagnosed.
-func (s *server) diagnoseSnapshot(ctx context.Context, snapshot *cache.Snapshot, changedURIs []protocol.DocumentURI, delay time.Duration) {
+//
+// The trigger determines the diagnostic sources that are computed;
+// the diagnostics of the others are those of the previous pass.
+func (s *server) diagnoseSnapshot(ctx context.Context, snapshot *cache.Snapshot, changedURIs []protocol.DocumentURI, delay time.Duration, trigger passTrigger) {
 	ctx, done := event.Start(ctx, "Server.diagnoseSnapshot", snapshot.Labels()...)
 	defer done()
 
@@ -228,7 +316,7 @@ func (s *server) diagnoseSnapshot(ctx context.Context, snapshot *cache.Snapshot,
 		}
 	}
 
-	diagnostics, err := s.diagnose(ctx, snapshot)
+	diagnostics, err := s.diagnose(ctx, snapshot, trigger, 0)
 	if err != nil {
 		if ctx.Err() == nil {
 			event.Error(ctx, "warning: while diagnosing snapshot", err, snapshot.Labels()...)
@@ -305,10 +393,51 @@ func (s *server) diagnoseChangedFiles(ctx context.Context, snapshot *cache.Snaps
 			}
 		}
 	}
+	if snapshot.Options().LineDirectives {
+		lines := golang.NewDirectiveMapper(ctx, snapshot)
+		for uri, fileDiags := range diags {
+			diags[uri] = relateOriginalLocations(lines, uri, fileDiags)
+		}
+	}
 	return diags, nil
 }
 
-func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot) (diagMap, error) {
+// relateOriginalLocations returns the diagnostics of the specified
+// file, with each diagnostic that a //line directive maps to a
+// location in the original source related to that location, for the
+// lineDirectives option. The affected diagnostics are copied, as they
+// may be shared with the cache.
+func relateOriginalLocations(lines *golang.DirectiveMapper, uri protocol.DocumentURI, diags []*cache.Diagnostic) []*cache.Diagnostic {
+	var result []*cache.Diagnostic
+	for i, diag := range diags {
+		orig, ok := lines.Original(protocol.Location{URI: uri, Range: diag.Range})
+		if !ok {
+			if result != nil {
+				result = append(result, diag)
+			}
+			continue
+		}
+		if result == nil {
+			result = append(make([]*cache.Diagnostic, 0, len(diags)), diags[:i]...)
+		}
+		related := *diag
+		related.Related = append(diag.Related[:len(diag.Related):len(diag.Related)], protocol.DiagnosticRelatedInformation{
+			Location: orig,
+			Message:  "original source",
+		})
+		result = append(result, &related)
+	}
+	if result == nil {
+		return diags
+	}
+	return result
+}
+
+// diagnose computes the diagnostics of the given snapshot. It computes
+// only the sources selected by the trigger and, for an onIdle pass,
+// the time since the change (see triggered); the diagnostics of the
+// other sources are those of the previous pass.
+func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot, trigger passTrigger, idle time.Duration) (_ diagMap, err error) {
 	ctx, done := event.Start(ctx, "Server.diagnose", snapshot.Labels()...)
 	defer done()
 
@@ -330,6 +459,34 @@ func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot) (diagMa
 		diagnosticsMu sync.Mutex
 		diagnostics   = make(diagMap)
 	)
+
+	// Skip the computations of the sources not selected by the
+	// trigger, and retain their diagnostics from the previous pass.
+	// Each analyzer is a separate source.
+	opts := snapshot.Options()
+	skipped := make(map[string]bool) // paths of skipped sources
+	run := func(source string) bool {
+		if triggered(opts, source, trigger, idle) {
+			return true
+		}
+		skipped[source] = true
+		return false
+	}
+	includeAnalyzer := func(analyzer string) bool {
+		return triggered(opts, cache.DiagnosticSource(analyzer).Path(), trigger, idle)
+	}
+	defer func() {
+		if err == nil {
+			s.retainDiagnostics(snapshot.View(), diagnostics, func(path string) bool {
+				for source := range skipped {
+					if cache.MatchesSourceFilter(path, source) {
+						return true
+					}
+				}
+				return strings.HasPrefix(path, "analyzer/") && !triggered(opts, path, trigger, idle)
+			})
+		}
+	}()
 	// common code for dispatching diagnostics
 	store := func(operation string, diagsByFile diagMap, err error) {
 		if err != nil {
@@ -338,9 +495,16 @@ func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot) (diagMa
 			}
 			return
 		}
+		var lines *golang.DirectiveMapper
+		if opts.LineDirectives {
+			lines = golang.NewDirectiveMapper(ctx, snapshot)
+		}
 		diagnosticsMu.Lock()
 		defer diagnosticsMu.Unlock()
 		for uri, diags := range diagsByFile {
+			if lines != nil {
+				diags = relateOriginalLocations(lines, uri, diags)
+			}
 			diagnostics[uri] = append(diagnostics[uri], diags...)
 		}
 	}
@@ -363,18 +527,31 @@ func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot) (diagMa
 	store("diagnosing go.mod file", modReports, modErr)
 
 	// Diagnose go.mod upgrades.
-	upgradeReports, upgradeErr := mod.UpgradeDiagnostics(ctx, snapshot)
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
+	if run(cache.UpgradeNotification.Path()) {
+		upgradeReports, upgradeErr := mod.UpgradeDiagnostics(ctx, snapshot)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		store("diagnosing go.mod upgrades", upgradeReports, upgradeErr)
+	}
+
+	// Diagnose available updates of dependencies.
+	if run(cache.DependencyUpdate.Path()) {
+		updateReports, updateErr := mod.UpdateDiagnostics(ctx, snapshot)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		store("diagnosing dependency updates", updateReports, updateErr)
 	}
-	store("diagnosing go.mod upgrades", upgradeReports, upgradeErr)
 
 	// Diagnose vulnerabilities.
-	vulnReports, vulnErr := mod.VulnerabilityDiagnostics(ctx, snapshot)
-	if ctx.Err() != nil {
-		return nil, ctx.Err()
+	if run("vulncheck") {
+		vulnReports, vulnErr := mod.VulnerabilityDiagnostics(ctx, snapshot)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		store("diagnosing vulnerabilities", vulnReports, vulnErr)
 	}
-	store("diagnosing vulnerabilities", vulnReports, vulnErr)
 
 	workspacePkgs, err := snapshot.WorkspaceMetadata(ctx)
 	if s.shouldIgnoreError(snapshot, err) {
@@ -420,12 +597,14 @@ func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot) (diagMa
 	// Maybe run go mod tidy (if it has been invalidated).
 	//
 	// Since go mod tidy can be slow, we run it concurrently to diagnostics.
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		modTidyReports, err := mod.TidyDiagnostics(ctx, snapshot)
-		store("running go mod tidy", modTidyReports, err)
-	}()
+	if run(cache.ModTidyError.Path()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			modTidyReports, err := mod.TidyDiagnostics(ctx, snapshot)
+			store("running go mod tidy", modTidyReports, err)
+		}()
+	}
 
 	// Run type checking and go/analysis diagnosis of packages in parallel.
 	//
@@ -480,12 +659,51 @@ func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot) (diagMa
 		}
 	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		gcDetailsReports, err := s.gcDetailsDiagnostics(ctx, snapshot, toDiagnose)
-		store("collecting gc_details", gcDetailsReports, err)
-	}()
+	if run(cache.OptimizationDetailsError.Path()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gcDetailsReports, err := s.gcDetailsDiagnostics(ctx, snapshot, toDiagnose)
+			store("collecting gc_details", gcDetailsReports, err)
+		}()
+	}
+
+	// Report the results of any deep analysis (see the DeepAnalysis command).
+	store("deep analysis", snapshot.DeepAnalysisDiagnostics(), nil)
+
+	// Report the results of any dead code detection (see the
+	// FindDeadCode command).
+	store("dead code", snapshot.DeadCodeDiagnostics(), nil)
+
+	// Find duplicated functions in the workspace.
+	if run(cache.Clones.Path()) {
+		cloneReports, err := golang.CloneDiagnostics(ctx, snapshot)
+		store("finding clones", cloneReports, err)
+	}
+
+	// Check the API of the workspace packages against a released
+	// version, which may need to be downloaded and loaded.
+	if run(cache.APICompatibility.Path()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			apiReports, err := golang.APICompatibilityDiagnostics(ctx, snapshot)
+			store("checking API compatibility", apiReports, err)
+		}()
+	}
+
+	// Report changes to the interfaces satisfied by the types of
+	// open files since they were last saved.
+	if run(cache.InterfaceChanges.Path()) {
+		interfaceReports, err := s.interfaces.Diagnostics(ctx, snapshot)
+		store("checking interface changes", interfaceReports, err)
+	}
+
+	// Check the spelling of comments and strings in open files.
+	if run(cache.Spelling.Path()) {
+		spellingReports, err := golang.SpellingDiagnostics(ctx, snapshot)
+		store("checking spelling", spellingReports, err)
+	}
 
 	// Package diagnostics and analysis diagnostics must both be computed and
 	// merged before they can be reported.
@@ -509,7 +727,7 @@ func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot) (diagMa
 		var err error
 		// TODO(rfindley): here and above, we should avoid using the first result
 		// if err is non-nil (though as of today it's OK).
-		analysisDiags, err = golang.Analyze(ctx, snapshot, toAnalyze, s.progress)
+		analysisDiags, err = golang.Analyze(ctx, snapshot, toAnalyze, s.progress, includeAnalyzer)
 		if err != nil {
 			event.Error(ctx, "warning: analyzing package", err, append(snapshot.Labels(), label.Package.Of(keys.Join(maps.Keys(toDiagnose))))...)
 			return
@@ -533,6 +751,21 @@ func (s *server) diagnose(ctx context.Context, snapshot *cache.Snapshot) (diagMa
 	return diagnostics, nil
 }
 
+// retainDiagnostics adds to diagnostics those of the previous
+// pass over the view whose source paths satisfy retain.
+func (s *server) retainDiagnostics(view *cache.View, diagnostics diagMap, retain func(path string) bool) {
+	s.diagnosticsMu.Lock()
+	defer s.diagnosticsMu.Unlock()
+
+	for uri, diags := range s.lastPass[view] {
+		for _, diag := range diags {
+			if retain(diag.Source.Path()) {
+				diagnostics[uri] = append(diagnostics[uri], diag)
+			}
+		}
+	}
+}
+
 func (s *server) gcDetailsDiagnostics(ctx context.Context, snapshot *cache.Snapshot, toDiagnose map[metadata.PackageID]*metadata.Package) (diagMap, error) {
 	// Process requested gc_details diagnostics.
 	//
@@ -698,6 +931,20 @@ func (s *server) updateDiagnostics(ctx context.Context, snapshot *cache.Snapshot
 		viewMap[v] = unit{}
 	}
 
+	// Record the final diagnostics of the view, from which the
+	// next pass retains the sources it does not compute.
+	if final {
+		if s.lastPass == nil {
+			s.lastPass = make(map[*cache.View]diagMap)
+		}
+		s.lastPass[snapshot.View()] = diagnostics
+		for view := range s.lastPass {
+			if _, ok := viewMap[view]; !ok {
+				delete(s.lastPass, view) // view no longer exists
+			}
+		}
+	}
+
 	// updateAndPublish updates diagnostics for a file, checking both the latest
 	// diagnostics for the current snapshot, as well as reconciling the set of
 	// views.
@@ -832,6 +1079,9 @@ func (s *server) publishFileDiagnosticsLocked(ctx context.Context, views viewSet
 	// diagSuffixes records the set of view suffixes for a given diagnostic.
 	diagSuffixes := make(map[file.Hash][]diagSuffix)
 	add := func(diag *cache.Diagnostic, suffix string) {
+		if s.isMutedLocked(diag) {
+			return
+		}
 		h := hashDiagnostic(diag)
 		diagSuffixes[h] = append(diagSuffixes[h], diagSuffix{diag, suffix})
 	}
@@ -936,11 +1186,90 @@ func (s *server) publishFileDiagnosticsLocked(ctx context.Context, views viewSet
 			return err
 		}
 		f.publishedHash = hash
+		f.published = unique
 		f.mustPublish = false
 	}
 	return nil
 }
 
+// isMutedLocked reports whether the source of the diagnostic is muted by
+// one of the filters of the gopls.filter_diagnostics command, while holding
+// s.diagnosticsMu.
+func (s *server) isMutedLocked(diag *cache.Diagnostic) bool {
+	if len(s.mutedSources) == 0 {
+		return false
+	}
+	path := diag.Source.Path()
+	for filter := range s.mutedSources {
+		if cache.MatchesSourceFilter(path, filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterDiagnostics updates the set of muted diagnostic sources and
+// republishes the diagnostics of all files whose published diagnostics
+// change as a result.
+func (s *server) filterDiagnostics(ctx context.Context, args command.FilterDiagnosticsArgs) (command.FilterDiagnosticsResult, error) {
+	for _, filter := range append(args.Mute, args.Unmute...) {
+		if filter == "" || strings.HasPrefix(filter, "/") || strings.HasSuffix(filter, "/") {
+			return command.FilterDiagnosticsResult{}, fmt.Errorf("invalid diagnostic source filter %q", filter)
+		}
+	}
+
+	viewSet := make(viewSet)
+	for _, v := range s.session.Views() {
+		viewSet[v] = unit{}
+	}
+
+	s.diagnosticsMu.Lock()
+	defer s.diagnosticsMu.Unlock()
+
+	if args.Reset {
+		s.mutedSources = nil
+	}
+	for _, filter := range args.Unmute {
+		delete(s.mutedSources, filter)
+	}
+	for _, filter := range args.Mute {
+		if s.mutedSources == nil {
+			s.mutedSources = make(map[string]unit)
+		}
+		s.mutedSources[filter] = unit{}
+	}
+
+	sources := make(map[string]unit)
+	for uri, f := range s.diagnostics {
+		for _, diag := range f.orphanedFileDiagnostics {
+			sources[diag.Source.Path()] = unit{}
+		}
+		for _, viewDiags := range f.byView {
+			for _, diag := range viewDiags.diagnostics {
+				sources[diag.Source.Path()] = unit{}
+			}
+		}
+		fh, err := s.session.ReadFile(ctx, uri)
+		if err != nil {
+			return command.FilterDiagnosticsResult{}, err
+		}
+		if err := s.publishFileDiagnosticsLocked(ctx, viewSet, uri, fh.Version(), f); err != nil {
+			return command.FilterDiagnosticsResult{}, err
+		}
+	}
+
+	var result command.FilterDiagnosticsResult
+	for filter := range s.mutedSources {
+		result.Muted = append(result.Muted, filter)
+	}
+	for source := range sources {
+		result.Sources = append(result.Sources, source)
+	}
+	sort.Strings(result.Muted)
+	sort.Strings(result.Sources)
+	return result, nil
+}
+
 func toProtocolDiagnostics(diagnostics []*cache.Diagnostic) []protocol.Diagnostic {
 	reports := []protocol.Diagnostic{}
 	for _, diag := range diagnostics {
@@ -949,7 +1278,7 @@ func toProtocolDiagnostics(diagnostics []*cache.Diagnostic) []protocol.Diagnosti
 			Message:            strings.TrimSpace(diag.Message),
 			Range:              diag.Range,
 			Severity:           diag.Severity,
-			Source:             string(diag.Source),
+			Source:             diag.Source.Path(),
 			Tags:               protocol.NonNilSlice(diag.Tags),
 			RelatedInformation: diag.Related,
 			Data:               diag.BundledFixes,
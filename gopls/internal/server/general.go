@@ -32,6 +32,7 @@ import (
 	"golang.org/x/tools/gopls/internal/util/maps"
 	"golang.org/x/tools/internal/event"
 	"golang.org/x/tools/internal/jsonrpc2"
+	"golang.org/x/tools/internal/xcontext"
 )
 
 func (s *server) Initialize(ctx context.Context, params *protocol.ParamInitialize) (*protocol.InitializeResult, error) {
@@ -132,6 +133,7 @@ func (s *server) Initialize(ctx context.Context, params *protocol.ParamInitializ
 			},
 			DefinitionProvider:         &protocol.Or_ServerCapabilities_definitionProvider{Value: true},
 			TypeDefinitionProvider:     &protocol.Or_ServerCapabilities_typeDefinitionProvider{Value: true},
+			TypeHierarchyProvider:      &protocol.Or_ServerCapabilities_typeHierarchyProvider{Value: true},
 			ImplementationProvider:     &protocol.Or_ServerCapabilities_implementationProvider{Value: true},
 			DocumentFormattingProvider: &protocol.Or_ServerCapabilities_documentFormattingProvider{Value: true},
 			DocumentSymbolProvider:     &protocol.Or_ServerCapabilities_documentSymbolProvider{Value: true},
@@ -221,6 +223,10 @@ func (s *server) Initialized(ctx context.Context, params *protocol.InitializedPa
 	// for users to ignore or dismiss the question.
 	go s.maybePromptForTelemetry(ctx, options.TelemetryPrompt)
 
+	if options.StaleFileCheckInterval > 0 {
+		go s.watchStaleFiles(xcontext.Detach(ctx), options.StaleFileCheckInterval)
+	}
+
 	return nil
 }
 
@@ -335,7 +341,7 @@ func (s *server) addFolders(ctx context.Context, folders []protocol.WorkspaceFol
 		// Diagnose the newly created view asynchronously.
 		ndiagnose.Add(1)
 		go func() {
-			s.diagnoseSnapshot(snapshot.BackgroundContext(), snapshot, nil, 0)
+			s.diagnoseSnapshot(snapshot.BackgroundContext(), snapshot, nil, 0, onSave)
 			<-initialized
 			release()
 			ndiagnose.Done()
@@ -503,7 +509,7 @@ func (s *server) fetchFolderOptions(ctx context.Context, folder protocol.Documen
 
 	opts = opts.Clone()
 	for _, config := range configs {
-		s.handleOptionErrors(ctx, opts.Set(config))
+		s.handleOptionErrors(ctx, opts.SetWorkspace(config))
 	}
 	return opts, nil
 }
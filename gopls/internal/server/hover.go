@@ -7,6 +7,7 @@ package server
 import (
 	"context"
 
+	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/golang"
 	"golang.org/x/tools/gopls/internal/label"
@@ -48,7 +49,14 @@ func (s *server) Hover(ctx context.Context, params *protocol.HoverParams) (_ *pr
 				}
 			}
 		}
-		return golang.Hover(ctx, snapshot, fh, params.Position, pkgURL)
+		h, err := golang.Hover(ctx, snapshot, fh, params.Position, pkgURL)
+		if err != nil {
+			return nil, err
+		}
+		if snapshot.Options().AnalyzerDocsInHover {
+			h = s.withAnalyzerDocs(snapshot, fh.URI(), params.Position, h)
+		}
+		return h, nil
 	case file.Tmpl:
 		return template.Hover(ctx, snapshot, fh, params.Position)
 	case file.Work:
@@ -56,3 +64,46 @@ func (s *server) Hover(ctx context.Context, params *protocol.HoverParams) (_ *pr
 	}
 	return nil, nil // empty result
 }
+
+// withAnalyzerDocs returns the hover h, extended with the brief
+// documentation of each analyzer whose last published diagnostics for
+// the file span the line of the position, and an example of its fixes.
+// h may be nil.
+func (s *server) withAnalyzerDocs(snapshot *cache.Snapshot, uri protocol.DocumentURI, pos protocol.Position, h *protocol.Hover) *protocol.Hover {
+	var diags []*cache.Diagnostic
+	s.diagnosticsMu.Lock()
+	if f, ok := s.diagnostics[uri]; ok {
+		for _, diag := range f.published {
+			// Editors typically show a diagnostic for its whole
+			// line, so it need not enclose the position.
+			if diag.Range.Start.Line <= pos.Line && pos.Line <= diag.Range.End.Line {
+				diags = append(diags, diag)
+			}
+		}
+	}
+	s.diagnosticsMu.Unlock()
+
+	seen := make(map[cache.DiagnosticSource]bool)
+	for _, diag := range diags {
+		if seen[diag.Source] {
+			continue
+		}
+		seen[diag.Source] = true
+		markdown, err := golang.AnalyzerDoc(snapshot, string(diag.Source), false)
+		if err != nil {
+			continue // not an analyzer diagnostic
+		}
+		if h == nil {
+			h = &protocol.Hover{
+				Contents: protocol.MarkupContent{
+					Kind:  snapshot.Options().PreferredContentFormat,
+					Value: markdown,
+				},
+				Range: diag.Range,
+			}
+		} else {
+			h.Contents.Value += "\n\n---\n\n" + markdown
+		}
+	}
+	return h
+}
@@ -10,6 +10,7 @@ import (
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/golang"
 	"golang.org/x/tools/gopls/internal/label"
+	"golang.org/x/tools/gopls/internal/progress"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/telemetry"
 	"golang.org/x/tools/internal/event"
@@ -32,5 +33,13 @@ func (s *server) Implementation(ctx context.Context, params *protocol.Implementa
 	if snapshot.FileKind(fh) != file.Go {
 		return nil, nil // empty result
 	}
-	return golang.Implementation(ctx, snapshot, fh, params.Position)
+	report := progress.PartialResults[protocol.Location](ctx, s.client, params.PartialResultToken)
+	locs, err := golang.Implementation(ctx, snapshot, fh, params.Position, report)
+	if err != nil {
+		return nil, err
+	}
+	if report != nil {
+		return []protocol.Location{}, nil // all reported as partial results
+	}
+	return locs, nil
 }
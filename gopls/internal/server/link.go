@@ -11,11 +11,13 @@ import (
 	"go/ast"
 	"go/token"
 	"net/url"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
 
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/cache/metadata"
 	"golang.org/x/tools/gopls/internal/cache/parsego"
@@ -76,13 +78,73 @@ func modLinks(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]
 		// Shift the start position to the location of the
 		// dependency within the require statement.
 		target := cache.BuildLink(snapshot.Options().LinkTarget, "mod/"+req.Mod.String(), "")
+		if dir := replacementDir(pm.File, req.Mod, fh.URI().Dir().Path()); dir != "" {
+			target = string(protocol.URIFromPath(filepath.Join(dir, "go.mod")))
+		}
 		l, err := toProtocolLink(pm.Mapper, target, start+i, start+i+len(dep))
 		if err != nil {
 			return nil, err
 		}
 		links = append(links, l)
 	}
-	// TODO(ridersofrohan): handle links for replace and exclude directives.
+	// Create links for the module paths of replace and exclude
+	// directives. A directory that replaces a module links to its
+	// go.mod file.
+	addModLink := func(syntax *modfile.Line, path, target string) error {
+		start, end := syntax.Start.Byte, syntax.End.Byte
+		i := bytes.Index(pm.Mapper.Content[start:end], []byte(path))
+		if i == -1 {
+			return nil
+		}
+		l, err := toProtocolLink(pm.Mapper, target, start+i, start+i+len(path))
+		if err != nil {
+			return err
+		}
+		links = append(links, l)
+		return nil
+	}
+	modLink := func(syntax *modfile.Line, path, version string) error {
+		if syntax == nil || path == "" || snapshot.IsGoPrivatePath(path) {
+			return nil
+		}
+		mod := path
+		if version != "" {
+			mod += "@" + version
+		}
+		target := cache.BuildLink(snapshot.Options().LinkTarget, "mod/"+mod, "")
+		return addModLink(syntax, path, target)
+	}
+	for _, rep := range pm.File.Replace {
+		if err := modLink(rep.Syntax, rep.Old.Path, rep.Old.Version); err != nil {
+			return nil, err
+		}
+		if rep.Syntax == nil {
+			continue
+		}
+		// Search after the arrow, as the new path may equal or
+		// extend the old one.
+		newSyntax := *rep.Syntax
+		if i := bytes.Index(pm.Mapper.Content[newSyntax.Start.Byte:newSyntax.End.Byte], []byte("=>")); i >= 0 {
+			newSyntax.Start.Byte += i
+		}
+		if modfile.IsDirectoryPath(rep.New.Path) {
+			dir := rep.New.Path
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(fh.URI().Dir().Path(), dir)
+			}
+			target := string(protocol.URIFromPath(filepath.Join(dir, "go.mod")))
+			if err := addModLink(&newSyntax, rep.New.Path, target); err != nil {
+				return nil, err
+			}
+		} else if err := modLink(&newSyntax, rep.New.Path, rep.New.Version); err != nil {
+			return nil, err
+		}
+	}
+	for _, exc := range pm.File.Exclude {
+		if err := modLink(exc.Syntax, exc.Mod.Path, exc.Mod.Version); err != nil {
+			return nil, err
+		}
+	}
 	if syntax := pm.File.Syntax; syntax == nil {
 		return links, nil
 	}
@@ -107,6 +169,21 @@ func modLinks(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]
 	return links, nil
 }
 
+// replacementDir returns the absolute path of the directory that
+// replaces the specified module version in the go.mod file in modDir,
+// or "" if it is not replaced by a directory.
+func replacementDir(f *modfile.File, mod module.Version, modDir string) string {
+	for _, rep := range f.Replace {
+		if rep.Old.Path == mod.Path && (rep.Old.Version == "" || rep.Old.Version == mod.Version) && modfile.IsDirectoryPath(rep.New.Path) {
+			if filepath.IsAbs(rep.New.Path) {
+				return rep.New.Path
+			}
+			return filepath.Join(modDir, rep.New.Path)
+		}
+	}
+	return ""
+}
+
 // goLinks returns the set of hyperlink annotations for the specified Go file.
 func goLinks(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]protocol.DocumentLink, error) {
 
@@ -120,13 +197,12 @@ func goLinks(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]p
 	// Create links for import specs.
 	if snapshot.Options().ImportShortcut.ShowLinks() {
 
-		// If links are to pkg.go.dev, append module version suffixes.
-		// This requires the import map from the package metadata. Ignore errors.
+		// The import map from the package metadata is needed to
+		// append module version suffixes for pkg.go.dev, and to find
+		// packages that are linked locally. Ignore errors.
 		var depsByImpPath map[golang.ImportPath]golang.PackageID
-		if strings.ToLower(snapshot.Options().LinkTarget) == "pkg.go.dev" {
-			if meta, err := golang.NarrowestMetadataForFile(ctx, snapshot, fh.URI()); err == nil {
-				depsByImpPath = meta.DepsByImpPath
-			}
+		if meta, err := golang.NarrowestMetadataForFile(ctx, snapshot, fh.URI()); err == nil {
+			depsByImpPath = meta.DepsByImpPath
 		}
 
 		for _, imp := range pgf.File.Imports {
@@ -134,23 +210,35 @@ func goLinks(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]p
 			if importPath == "" {
 				continue // bad import
 			}
-			// See golang/go#36998: don't link to modules matching GOPRIVATE.
-			if snapshot.IsGoPrivatePath(string(importPath)) {
-				continue
-			}
+			mp := snapshot.Metadata(depsByImpPath[importPath])
 
-			urlPath := string(importPath)
+			var targetURL string
+			if private := snapshot.IsGoPrivatePath(string(importPath)); private || mp != nil && isLocalPackage(ctx, snapshot, mp) {
+				// Link to the package itself: its documentation
+				// is not (or not yet) served by the link target.
+				// See golang/go#36998: don't link to modules
+				// matching GOPRIVATE.
+				if mp == nil {
+					continue
+				}
+				targetURL = localPackageLink(ctx, snapshot, mp)
+				if targetURL == "" {
+					continue
+				}
+			} else {
+				urlPath := string(importPath)
 
-			// For pkg.go.dev, append module version suffix to package import path.
-			if mp := snapshot.Metadata(depsByImpPath[importPath]); mp != nil && mp.Module != nil && mp.Module.Path != "" && mp.Module.Version != "" {
-				urlPath = strings.Replace(urlPath, mp.Module.Path, mp.Module.Path+"@"+mp.Module.Version, 1)
+				// For pkg.go.dev, append module version suffix to package import path.
+				if strings.ToLower(snapshot.Options().LinkTarget) == "pkg.go.dev" && mp != nil && mp.Module != nil && mp.Module.Path != "" && mp.Module.Version != "" {
+					urlPath = strings.Replace(urlPath, mp.Module.Path, mp.Module.Path+"@"+mp.Module.Version, 1)
+				}
+				targetURL = cache.BuildLink(snapshot.Options().LinkTarget, urlPath, "")
 			}
 
 			start, end, err := safetoken.Offsets(pgf.Tok, imp.Path.Pos(), imp.Path.End())
 			if err != nil {
 				return nil, err
 			}
-			targetURL := cache.BuildLink(snapshot.Options().LinkTarget, urlPath, "")
 			// Account for the quotation marks in the positions.
 			l, err := toProtocolLink(pgf.Mapper, targetURL, start+len(`"`), end-len(`"`))
 			if err != nil {
@@ -205,6 +293,36 @@ func goLinks(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle) ([]p
 	return links, nil
 }
 
+// isLocalPackage reports whether the package is linked to its files
+// rather than to its documentation: those of workspace packages, and
+// of modules replaced by a local directory, may not match any
+// published version.
+func isLocalPackage(ctx context.Context, snapshot *cache.Snapshot, mp *metadata.Package) bool {
+	if mp.Module != nil && mp.Module.Replace != nil && mp.Module.Replace.Version == "" {
+		return true
+	}
+	return snapshot.IsWorkspacePackage(ctx, mp.ID)
+}
+
+// localPackageLink returns the URI of the file of the package that
+// holds its package documentation, or of its first file if none does,
+// or "" if it has no files.
+func localPackageLink(ctx context.Context, snapshot *cache.Snapshot, mp *metadata.Package) string {
+	if len(mp.GoFiles) == 0 {
+		return ""
+	}
+	for _, uri := range mp.GoFiles {
+		fh, err := snapshot.ReadFile(ctx, uri)
+		if err != nil {
+			continue
+		}
+		if pgf, err := snapshot.ParseGo(ctx, fh, parsego.Header); err == nil && pgf.File.Doc != nil {
+			return string(uri)
+		}
+	}
+	return string(mp.GoFiles[0])
+}
+
 // acceptedSchemes controls the schemes that URLs must have to be shown to the
 // user. Other schemes can't be opened by LSP clients, so linkifying them is
 // distracting. See golang/go#43990.
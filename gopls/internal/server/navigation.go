@@ -0,0 +1,75 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+)
+
+// nextDiagnostic returns the location of the published diagnostic
+// that follows (or precedes) args.Location, or nil if there is none.
+func (s *server) nextDiagnostic(args command.NextDiagnosticArgs) *protocol.Location {
+	s.diagnosticsMu.Lock()
+	var locs []protocol.Location
+	for uri, f := range s.diagnostics {
+		for _, diag := range f.published {
+			if args.Severity != 0 && (diag.Severity == 0 || diag.Severity > args.Severity) {
+				continue
+			}
+			locs = append(locs, protocol.Location{URI: uri, Range: diag.Range})
+		}
+	}
+	s.diagnosticsMu.Unlock()
+
+	return nextLocation(locs, args.Location, args.Previous)
+}
+
+// nextLocation returns the first of locs whose start follows the start
+// of from, or if previous is set the last one whose start precedes it,
+// wrapping around at either end. It returns nil if locs contains no
+// location other than one starting at from.
+func nextLocation(locs []protocol.Location, from protocol.Location, previous bool) *protocol.Location {
+	// Compare locations by their start alone.
+	start := func(loc protocol.Location) protocol.Location {
+		return protocol.Location{URI: loc.URI, Range: protocol.Range{Start: loc.Range.Start, End: loc.Range.Start}}
+	}
+	from = start(from)
+
+	var (
+		best  *protocol.Location // the nearest location in the desired direction
+		first *protocol.Location // the location to wrap around to
+	)
+	for i := range locs {
+		loc := &locs[i]
+		cmp := protocol.CompareLocation(start(*loc), from)
+		if previous {
+			cmp = -cmp
+		}
+		switch {
+		case cmp > 0:
+			if best == nil || towards(*loc, *best, previous) {
+				best = loc
+			}
+		case cmp < 0:
+			if first == nil || towards(*loc, *first, previous) {
+				first = loc
+			}
+		}
+	}
+	if best == nil {
+		best = first
+	}
+	return best
+}
+
+// towards reports whether x is before y, or after y if previous is set.
+func towards(x, y protocol.Location, previous bool) bool {
+	cmp := protocol.CompareLocation(x, y)
+	if previous {
+		return cmp > 0
+	}
+	return cmp < 0
+}
@@ -10,6 +10,7 @@ import (
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/golang"
 	"golang.org/x/tools/gopls/internal/label"
+	"golang.org/x/tools/gopls/internal/progress"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/telemetry"
 	"golang.org/x/tools/gopls/internal/template"
@@ -34,7 +35,28 @@ func (s *server) References(ctx context.Context, params *protocol.ReferenceParam
 	case file.Tmpl:
 		return template.References(ctx, snapshot, fh, params)
 	case file.Go:
-		return golang.References(ctx, snapshot, fh, params.Position, params.Context.IncludeDeclaration)
+		// References from test files must be reported too.
+		if err := snapshot.LoadTestVariants(ctx); err != nil {
+			return nil, err
+		}
+		report := progress.PartialResults[protocol.Location](ctx, s.client, params.PartialResultToken)
+		if report != nil && snapshot.Options().LineDirectives {
+			reportGenerated := report
+			report = func(locs []protocol.Location) {
+				reportGenerated(golang.OriginalLocations(ctx, snapshot, locs))
+			}
+		}
+		refs, err := golang.References(ctx, snapshot, fh, params.Position, params.Context.IncludeDeclaration, report)
+		if err != nil {
+			return nil, err
+		}
+		if report != nil {
+			return []protocol.Location{}, nil // all reported as partial results
+		}
+		if snapshot.Options().LineDirectives {
+			refs = golang.OriginalLocations(ctx, snapshot, refs)
+		}
+		return refs, nil
 	}
 	return nil, nil // empty result
 }
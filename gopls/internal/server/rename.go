@@ -30,6 +30,11 @@ func (s *server) Rename(ctx context.Context, params *protocol.RenameParams) (*pr
 		return nil, fmt.Errorf("cannot rename in file of type %s", kind)
 	}
 
+	// Renaming must update the test files too.
+	if err := snapshot.LoadTestVariants(ctx); err != nil {
+		return nil, err
+	}
+
 	// Because we don't handle directory renaming within golang.Rename, golang.Rename returns
 	// boolean value isPkgRenaming to determine whether an DocumentChanges of type RenameFile should
 	// be added to the return protocol.WorkspaceEdit value.
@@ -57,6 +62,7 @@ func (s *server) Rename(ctx context.Context, params *protocol.RenameParams) (*pr
 			protocol.URIFromPath(newDir))
 		changes = append(changes, change)
 	}
+	warnGeneratedEdits(ctx, s.client, snapshot, changes)
 
 	return protocol.NewWorkspaceEdit(changes...), nil
 }
@@ -8,9 +8,8 @@ import (
 	"context"
 	"fmt"
 
-	"golang.org/x/tools/go/ast/astutil"
-	"golang.org/x/tools/gopls/internal/cache/parsego"
 	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/golang"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/internal/event"
 )
@@ -40,36 +39,5 @@ func (s *server) SelectionRange(ctx context.Context, params *protocol.SelectionR
 		return nil, fmt.Errorf("SelectionRange not supported for file of type %s", kind)
 	}
 
-	pgf, err := snapshot.ParseGo(ctx, fh, parsego.Full)
-	if err != nil {
-		return nil, err
-	}
-
-	result := make([]protocol.SelectionRange, len(params.Positions))
-	for i, protocolPos := range params.Positions {
-		pos, err := pgf.PositionPos(protocolPos)
-		if err != nil {
-			return nil, err
-		}
-
-		path, _ := astutil.PathEnclosingInterval(pgf.File, pos, pos)
-
-		tail := &result[i] // tail of the Parent linked list, built head first
-
-		for j, node := range path {
-			rng, err := pgf.NodeRange(node)
-			if err != nil {
-				return nil, err
-			}
-
-			// Add node to tail.
-			if j > 0 {
-				tail.Parent = &protocol.SelectionRange{}
-				tail = tail.Parent
-			}
-			tail.Range = rng
-		}
-	}
-
-	return result, nil
+	return golang.SelectionRanges(ctx, snapshot, fh, params.Positions)
 }
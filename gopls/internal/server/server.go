@@ -105,9 +105,23 @@ type server struct {
 	watchedGlobPatterns    map[protocol.RelativePattern]unit
 	watchRegistrationCount int
 
-	diagnosticsMu sync.Mutex // guards map and its values
+	diagnosticsMu sync.Mutex // guards map and its values, mutedSources, and lastPass
 	diagnostics   map[protocol.DocumentURI]*fileDiagnostics
 
+	// lastPass holds the diagnostics of the last complete pass over
+	// each view, from which a pass retains those of the sources that
+	// the analysisTriggers setting excludes from it.
+	lastPass map[*cache.View]diagMap
+
+	// mutedSources is the set of hierarchical diagnostic source filters
+	// (see cache.DiagnosticSource.Path) whose diagnostics are not
+	// published. It is changed by the gopls.filter_diagnostics command.
+	mutedSources map[string]unit
+
+	// interfaces tracks the interfaces satisfied by the types of open
+	// files, to report the changes made by unsaved edits.
+	interfaces golang.InterfaceTracker
+
 	// diagnosticsSema limits the concurrency of diagnostics runs, which can be
 	// expensive.
 	diagnosticsSema chan unit
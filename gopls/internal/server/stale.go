@@ -0,0 +1,64 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/tools/gopls/internal/file"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/internal/event"
+)
+
+// watchStaleFiles checks every interval, until the server shuts down,
+// whether files read by the views have changed on disk without
+// notification from the client, and reloads any such files.
+func (s *server) watchStaleFiles(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.stateMu.Lock()
+		shutDown := s.state >= serverShutDown
+		s.stateMu.Unlock()
+		if shutDown {
+			return
+		}
+		s.checkStaleFiles(ctx)
+	}
+}
+
+// checkStaleFiles reports the files of all views that have changed on disk
+// without notification as modifications, causing the affected packages
+// to be reloaded and diagnosed again.
+func (s *server) checkStaleFiles(ctx context.Context) {
+	var (
+		mods []file.Modification
+		seen = make(map[protocol.DocumentURI]bool)
+	)
+	for _, view := range s.session.Views() {
+		snapshot, release, err := view.Snapshot()
+		if err != nil {
+			continue // view is shut down
+		}
+		for _, mod := range snapshot.StaleFiles(ctx) {
+			if !seen[mod.URI] {
+				seen[mod.URI] = true
+				mods = append(mods, mod)
+			}
+		}
+		release()
+	}
+	if len(mods) == 0 {
+		return
+	}
+
+	staleFilesRecovered.Inc()
+	event.Log(ctx, fmt.Sprintf("reloading %d files changed on disk without notification (e.g. %s)", len(mods), mods[0].URI))
+	if err := s.didModifyFiles(ctx, mods, FromStaleFileCheck); err != nil {
+		event.Error(ctx, "reloading stale files", err)
+	}
+}
@@ -64,6 +64,26 @@ const (
 	// FromToggleGCDetails refers to state changes resulting from toggling
 	// gc_details on or off for a package.
 	FromToggleGCDetails
+
+	// FromDeepAnalysis refers to state changes resulting from the
+	// DeepAnalysis command.
+	FromDeepAnalysis
+
+	// FromAddToDictionary refers to state changes resulting from the
+	// AddToDictionary command.
+	FromAddToDictionary
+
+	// FromSnoozeUpdate refers to state changes resulting from the
+	// SnoozeUpdate command.
+	FromSnoozeUpdate
+
+	// FromFindDeadCode refers to state changes resulting from the
+	// FindDeadCode command.
+	FromFindDeadCode
+
+	// FromStaleFileCheck refers to files found to have changed on disk
+	// without notification by the periodic check for stale files.
+	FromStaleFileCheck
 )
 
 func (m ModificationSource) String() string {
@@ -86,6 +106,16 @@ func (m ModificationSource) String() string {
 		return "from check upgrades"
 	case FromResetGoModDiagnostics:
 		return "from resetting go.mod diagnostics"
+	case FromDeepAnalysis:
+		return "from deep analysis"
+	case FromAddToDictionary:
+		return "from adding to the dictionary"
+	case FromSnoozeUpdate:
+		return "from snoozing update notices"
+	case FromFindDeadCode:
+		return "from finding dead code"
+	case FromStaleFileCheck:
+		return "files changed on disk without notification"
 	default:
 		return "unknown file modification"
 	}
@@ -232,11 +262,19 @@ func (s *server) didModifyFiles(ctx context.Context, modifications []file.Modifi
 	defer wg.Done()
 
 	if s.Options().VerboseWorkDoneProgress {
-		work := s.progress.Start(ctx, DiagnosticWorkTitle(cause), "Calculating file diagnostics...", nil, nil)
-		go func() {
-			wg.Wait()
-			work.End(ctx, "Done.")
-		}()
+		// Report the work once for each didChange notification,
+		// including those that were coalesced into this one.
+		n := 1
+		if cause == FromDidChange {
+			n = protocol.CoalescedNotifications(ctx)
+		}
+		for i := 0; i < n; i++ {
+			work := s.progress.Start(ctx, DiagnosticWorkTitle(cause), "Calculating file diagnostics...", nil, nil)
+			go func() {
+				wg.Wait()
+				work.End(ctx, "Done.")
+			}()
+		}
 	}
 
 	s.stateMu.Lock()
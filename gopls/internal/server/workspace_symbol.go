@@ -9,6 +9,7 @@ import (
 
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/golang"
+	"golang.org/x/tools/gopls/internal/progress"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/telemetry"
 	"golang.org/x/tools/internal/event"
@@ -37,5 +38,13 @@ func (s *server) Symbol(ctx context.Context, params *protocol.WorkspaceSymbolPar
 		defer release()
 		snapshots = append(snapshots, snapshot)
 	}
-	return golang.WorkspaceSymbols(ctx, matcher, style, snapshots, params.Query)
+	report := progress.PartialResults[protocol.SymbolInformation](ctx, s.client, params.PartialResultToken)
+	syms, err := golang.WorkspaceSymbols(ctx, matcher, style, snapshots, params.Query, report)
+	if err != nil {
+		return nil, err
+	}
+	if report != nil {
+		return []protocol.SymbolInformation{}, nil // all reported as partial results
+	}
+	return syms, nil
 }
@@ -46,17 +46,27 @@ import (
 	"golang.org/x/tools/go/analysis/passes/unsafeptr"
 	"golang.org/x/tools/go/analysis/passes/unusedresult"
 	"golang.org/x/tools/go/analysis/passes/unusedwrite"
+	"golang.org/x/tools/gopls/internal/analysis/alwaysnilerror"
+	"golang.org/x/tools/gopls/internal/analysis/contextfield"
+	"golang.org/x/tools/gopls/internal/analysis/contextparam"
 	"golang.org/x/tools/gopls/internal/analysis/deprecated"
+	"golang.org/x/tools/gopls/internal/analysis/dotimport"
 	"golang.org/x/tools/gopls/internal/analysis/embeddirective"
 	"golang.org/x/tools/gopls/internal/analysis/fillreturns"
+	"golang.org/x/tools/gopls/internal/analysis/generatedirective"
+	"golang.org/x/tools/gopls/internal/analysis/goroutineleak"
 	"golang.org/x/tools/gopls/internal/analysis/infertypeargs"
+	"golang.org/x/tools/gopls/internal/analysis/locks"
+	"golang.org/x/tools/gopls/internal/analysis/naming"
 	"golang.org/x/tools/gopls/internal/analysis/nonewvars"
 	"golang.org/x/tools/gopls/internal/analysis/norangeoverfunc"
 	"golang.org/x/tools/gopls/internal/analysis/noresultvalues"
 	"golang.org/x/tools/gopls/internal/analysis/simplifycompositelit"
 	"golang.org/x/tools/gopls/internal/analysis/simplifyrange"
 	"golang.org/x/tools/gopls/internal/analysis/simplifyslice"
+	"golang.org/x/tools/gopls/internal/analysis/structlayout"
 	"golang.org/x/tools/gopls/internal/analysis/stubmethods"
+	"golang.org/x/tools/gopls/internal/analysis/taint"
 	"golang.org/x/tools/gopls/internal/analysis/undeclaredname"
 	"golang.org/x/tools/gopls/internal/analysis/unusedparams"
 	"golang.org/x/tools/gopls/internal/analysis/unusedvariable"
@@ -71,6 +81,10 @@ import (
 type Analyzer struct {
 	analyzer    *analysis.Analyzer
 	enabled     bool
+	forced      bool
+	onSave      bool // too costly to run on every edit
+	local       bool // diagnostics in a declaration depend only on it
+	style       bool // reports mere matters of style
 	actionKinds []protocol.CodeActionKind
 	severity    protocol.DiagnosticSeverity
 	tags        []protocol.DiagnosticTag
@@ -83,6 +97,47 @@ func (a *Analyzer) Analyzer() *analysis.Analyzer { return a.analyzer }
 // This value can be configured per-analysis in user settings.
 func (a *Analyzer) EnabledByDefault() bool { return a.enabled }
 
+// WithEnabledByDefault returns a copy of the analyzer that is enabled
+// by default if and only if enabled is set.
+func (a *Analyzer) WithEnabledByDefault(enabled bool) *Analyzer {
+	copy := *a
+	copy.enabled = enabled
+	return &copy
+}
+
+// Forced returns a copy of the analyzer that runs even if the analyses
+// setting disables it, for explicit requests such as the RunAnalyzer
+// command.
+func (a *Analyzer) Forced() *Analyzer {
+	copy := *a
+	copy.forced = true
+	return &copy
+}
+
+// Enabled reports whether the analyzer should run with the given
+// options: if it is forced, if the analyses setting enables it, or if
+// it is enabled by default and the analyses setting does not disable it.
+func (a *Analyzer) Enabled(o *Options) bool {
+	enabled, ok := o.Analyses[a.analyzer.Name]
+	return a.forced || enabled || !ok && a.enabled
+}
+
+// OnSave reports whether the analyzer runs only on packages whose
+// files are all saved, because it is too costly to run on every edit.
+func (a *Analyzer) OnSave() bool { return a.onSave }
+
+// Local reports whether the analyzer is declaration-local: whether
+// the diagnostics it reports within each top-level function depend
+// only on the syntax of that function and the types of the package.
+// The results of local analyzers for unchanged functions are reused
+// after an edit to another function of the package.
+func (a *Analyzer) Local() bool { return a.local }
+
+// Style reports whether the analyzer reports mere matters of style,
+// which are not reported in generated files unless the
+// styleDiagnosticsInGeneratedFiles setting is enabled.
+func (a *Analyzer) Style() bool { return a.style }
+
 // ActionKinds is the set of kinds of code action this analyzer produces.
 //
 // If left unset, it defaults to QuickFix.
@@ -140,26 +195,26 @@ func init() {
 		// The traditional vet suite:
 		{analyzer: appends.Analyzer, enabled: true},
 		{analyzer: asmdecl.Analyzer, enabled: true},
-		{analyzer: assign.Analyzer, enabled: true},
-		{analyzer: atomic.Analyzer, enabled: true},
-		{analyzer: bools.Analyzer, enabled: true},
+		{analyzer: assign.Analyzer, enabled: true, local: true},
+		{analyzer: atomic.Analyzer, enabled: true, local: true},
+		{analyzer: bools.Analyzer, enabled: true, local: true},
 		{analyzer: buildtag.Analyzer, enabled: true},
 		{analyzer: cgocall.Analyzer, enabled: true},
-		{analyzer: composite.Analyzer, enabled: true},
+		{analyzer: composite.Analyzer, enabled: true, local: true},
 		{analyzer: copylock.Analyzer, enabled: true},
-		{analyzer: defers.Analyzer, enabled: true},
-		{analyzer: deprecated.Analyzer, enabled: true, severity: protocol.SeverityHint, tags: []protocol.DiagnosticTag{protocol.Deprecated}},
+		{analyzer: defers.Analyzer, enabled: true, local: true},
+		{analyzer: deprecated.Analyzer, enabled: true, severity: protocol.SeverityHint},
 		{analyzer: directive.Analyzer, enabled: true},
-		{analyzer: errorsas.Analyzer, enabled: true},
+		{analyzer: errorsas.Analyzer, enabled: true, local: true},
 		{analyzer: framepointer.Analyzer, enabled: true},
-		{analyzer: httpresponse.Analyzer, enabled: true},
-		{analyzer: ifaceassert.Analyzer, enabled: true},
+		{analyzer: httpresponse.Analyzer, enabled: true, local: true},
+		{analyzer: ifaceassert.Analyzer, enabled: true, local: true},
 		{analyzer: loopclosure.Analyzer, enabled: true},
 		{analyzer: lostcancel.Analyzer, enabled: true},
-		{analyzer: nilfunc.Analyzer, enabled: true},
+		{analyzer: nilfunc.Analyzer, enabled: true, local: true},
 		{analyzer: printf.Analyzer, enabled: true},
-		{analyzer: shift.Analyzer, enabled: true},
-		{analyzer: sigchanyzer.Analyzer, enabled: true},
+		{analyzer: shift.Analyzer, enabled: true, local: true},
+		{analyzer: sigchanyzer.Analyzer, enabled: true, local: true},
 		{analyzer: slog.Analyzer, enabled: true},
 		{analyzer: stdmethods.Analyzer, enabled: true},
 		{analyzer: stdversion.Analyzer, enabled: true},
@@ -167,35 +222,51 @@ func init() {
 		{analyzer: structtag.Analyzer, enabled: true},
 		{analyzer: testinggoroutine.Analyzer, enabled: true},
 		{analyzer: tests.Analyzer, enabled: true},
-		{analyzer: timeformat.Analyzer, enabled: true},
-		{analyzer: unmarshal.Analyzer, enabled: true},
+		{analyzer: timeformat.Analyzer, enabled: true, local: true},
+		{analyzer: unmarshal.Analyzer, enabled: true, local: true},
 		{analyzer: unreachable.Analyzer, enabled: true},
 		{analyzer: unsafeptr.Analyzer, enabled: true},
-		{analyzer: unusedresult.Analyzer, enabled: true},
+		{analyzer: unusedresult.Analyzer, enabled: true, local: true},
 
 		// not suitable for vet:
 		// - some (nilness) use go/ssa; see #59714.
 		// - others don't meet the "frequency" criterion;
 		//   see GOROOT/src/cmd/vet/README.
 		{analyzer: atomicalign.Analyzer, enabled: true},
-		{analyzer: deepequalerrors.Analyzer, enabled: true},
+		{analyzer: deepequalerrors.Analyzer, enabled: true, local: true},
 		{analyzer: nilness.Analyzer, enabled: true}, // uses go/ssa
 		{analyzer: sortslice.Analyzer, enabled: true},
 		{analyzer: embeddirective.Analyzer, enabled: true},
+		{analyzer: generatedirective.Analyzer, enabled: true},
+		{analyzer: goroutineleak.Analyzer, enabled: true},
+		{analyzer: contextparam.Analyzer, enabled: true},
+		{analyzer: locks.Analyzer, enabled: true}, // uses go/ssa
 
 		// disabled due to high false positives
 		{analyzer: fieldalignment.Analyzer, enabled: false}, // never a bug
+		{analyzer: structlayout.Analyzer, enabled: false},   // never a bug
 		{analyzer: shadow.Analyzer, enabled: false},         // very noisy
 		{analyzer: useany.Analyzer, enabled: false},         // never a bug
+		{analyzer: contextfield.Analyzer, enabled: false},   // often deliberate
+		{analyzer: dotimport.Analyzer, enabled: false},      // a matter of style
+
+		// opt-in checks of naming conventions, usually done by linters
+		{analyzer: naming.Analyzer, enabled: false, style: true},
+
+		// opt-in taint analysis suite; costly, so run only on save
+		{analyzer: taint.SQLAnalyzer, enabled: false, onSave: true},     // uses go/ssa
+		{analyzer: taint.CommandAnalyzer, enabled: false, onSave: true}, // uses go/ssa
+		{analyzer: taint.PathAnalyzer, enabled: false, onSave: true},    // uses go/ssa
 
 		// "simplifiers": analyzers that offer mere style fixes
 		// gofmt -s suite:
-		{analyzer: simplifycompositelit.Analyzer, enabled: true, actionKinds: []protocol.CodeActionKind{protocol.SourceFixAll, protocol.QuickFix}},
-		{analyzer: simplifyrange.Analyzer, enabled: true, actionKinds: []protocol.CodeActionKind{protocol.SourceFixAll, protocol.QuickFix}},
-		{analyzer: simplifyslice.Analyzer, enabled: true, actionKinds: []protocol.CodeActionKind{protocol.SourceFixAll, protocol.QuickFix}},
+		{analyzer: simplifycompositelit.Analyzer, enabled: true, local: true, style: true, actionKinds: []protocol.CodeActionKind{protocol.SourceFixAll, protocol.QuickFix}},
+		{analyzer: simplifyrange.Analyzer, enabled: true, local: true, style: true, actionKinds: []protocol.CodeActionKind{protocol.SourceFixAll, protocol.QuickFix}},
+		{analyzer: simplifyslice.Analyzer, enabled: true, local: true, style: true, actionKinds: []protocol.CodeActionKind{protocol.SourceFixAll, protocol.QuickFix}},
 		// other simplifiers:
-		{analyzer: infertypeargs.Analyzer, enabled: true, severity: protocol.SeverityHint},
-		{analyzer: unusedparams.Analyzer, enabled: true},
+		{analyzer: infertypeargs.Analyzer, enabled: true, local: true, style: true, severity: protocol.SeverityHint},
+		{analyzer: unusedparams.Analyzer, enabled: true, style: true},
+		{analyzer: alwaysnilerror.Analyzer, enabled: true},
 		{analyzer: unusedwrite.Analyzer, enabled: true}, // uses go/ssa
 
 		// type-error analyzers
@@ -7,4 +7,12 @@
 
 package settings
 
+import "golang.org/x/tools/go/analysis"
+
 const StaticcheckSupported = false
+
+// StaticcheckChecks returns nil, as staticcheck is not supported.
+func StaticcheckChecks(dir string) (map[string]bool, error) { return nil, nil }
+
+// StaticcheckConfigDigest returns "", as staticcheck is not supported.
+func StaticcheckConfigDigest(analyzers []*analysis.Analyzer, dir string) string { return "" }
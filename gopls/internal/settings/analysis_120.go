@@ -8,8 +8,17 @@
 package settings
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/config"
 	"honnef.co/go/tools/quickfix"
 	"honnef.co/go/tools/simple"
 	"honnef.co/go/tools/staticcheck"
@@ -43,6 +52,9 @@ func init() {
 				continue
 			}
 
+			if a.Analyzer.URL == "" {
+				a.Analyzer.URL = "https://staticcheck.dev/docs/checks/#" + a.Analyzer.Name
+			}
 			StaticcheckAnalyzers[a.Analyzer.Name] = &Analyzer{
 				analyzer: a.Analyzer,
 				enabled:  !a.Doc.NonDefault,
@@ -61,4 +73,95 @@ func init() {
 	})
 	add(stylecheck.Analyzers, nil)
 	add(quickfix.Analyzers, nil)
+
+	// As in cmd/staticcheck, the default "checks" configuration
+	// disables the checks that are not enabled by default, so that a
+	// staticcheck.conf file need only mention the exceptions.
+	checks := []string{"all"}
+	for name, a := range StaticcheckAnalyzers {
+		if !a.enabled {
+			checks = append(checks, "-"+name)
+		}
+	}
+	config.DefaultConfig.Checks = checks
+}
+
+// StaticcheckChecks returns the set of staticcheck checks enabled by
+// the configuration that applies to packages in the given directory:
+// that of the staticcheck.conf files in it and its parent
+// directories, merged with the default configuration.
+func StaticcheckChecks(dir string) (map[string]bool, error) {
+	cfg, err := config.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	enabled := make(map[string]bool)
+	for _, check := range cfg.Checks {
+		b := true
+		if len(check) > 1 && check[0] == '-' {
+			b = false
+			check = check[1:]
+		}
+		for name := range StaticcheckAnalyzers {
+			if matchCheck(check, name) {
+				enabled[name] = b
+			}
+		}
+	}
+	return enabled, nil
+}
+
+// matchCheck reports whether the pattern from the "checks" list of a
+// staticcheck.conf file matches the named check. A pattern is "all",
+// "*", a check name, a category followed by "*" (such as "S*", which
+// matches S1000 but not SA1000), or a check name prefix followed by
+// "*" (such as "SA1*").
+func matchCheck(pattern, name string) bool {
+	if pattern == "all" || pattern == "*" {
+		return true
+	}
+	if !strings.HasSuffix(pattern, "*") {
+		return pattern == name
+	}
+	prefix := pattern[:len(pattern)-1]
+	if strings.IndexFunc(prefix, unicode.IsNumber) < 0 {
+		// A category: compare the letters preceding the number.
+		if i := strings.IndexFunc(name, unicode.IsNumber); i >= 0 {
+			return name[:i] == prefix
+		}
+		return false
+	}
+	return strings.HasPrefix(name, prefix)
+}
+
+// StaticcheckConfigDigest returns a digest of the staticcheck.conf
+// files that apply to packages in dir, or "" if none of the analyzers
+// depends on the configuration. Analyzers such as ST1003 read these
+// files directly, so they must contribute to the cache key of their
+// results.
+func StaticcheckConfigDigest(analyzers []*analysis.Analyzer, dir string) string {
+	found := false
+	for _, a := range analyzers {
+		if a == config.Analyzer {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ""
+	}
+	h := sha256.New()
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, config.ConfigName))
+		if err == nil {
+			fmt.Fprintf(h, "%s %d\n", dir, len(data))
+			h.Write(data)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
 }
@@ -0,0 +1,169 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package settings
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/gopls/internal/util/maps"
+)
+
+// Analyzer flags are package-level variables of each analyzer, shared
+// by all sessions, whereas the analyzerFlags setting may differ between
+// sessions. So the flags are set just before analysis, and a lock
+// prevents them from changing while any analysis is running.
+var analyzerFlags struct {
+	mu      sync.RWMutex
+	applied map[string]map[string]string // current non-default values, by analyzer and flag name
+}
+
+// UseAnalyzerFlags sets the flags of analyzers to the values of the
+// analyzerFlags setting, resetting any flags set previously but not
+// in flags to their default values. It returns a function that must
+// be called once the analyzers have finished running; until then,
+// calls with different flag values are blocked.
+func UseAnalyzerFlags(flags map[string]map[string]string) (release func(), err error) {
+	analyzerFlags.mu.RLock()
+	if equalFlags(analyzerFlags.applied, flags) {
+		return analyzerFlags.mu.RUnlock, nil
+	}
+	analyzerFlags.mu.RUnlock()
+
+	analyzerFlags.mu.Lock()
+	// Reset the previous flags; the first error is reported.
+	for name, values := range analyzerFlags.applied {
+		for fname := range values {
+			if _, ok := flags[name][fname]; !ok {
+				if f := lookupFlag(name, fname); f != nil {
+					if err2 := f.Value.Set(f.DefValue); err2 != nil && err == nil {
+						err = fmt.Errorf("resetting flag %s.%s: %v", name, fname, err2)
+					}
+				}
+			}
+		}
+	}
+	// Apply the new ones, in a deterministic order.
+	applied := make(map[string]map[string]string)
+	for _, name := range sortedKeys(flags) {
+		for _, fname := range sortedKeys(flags[name]) {
+			value := flags[name][fname]
+			f := lookupFlag(name, fname)
+			if f == nil {
+				continue // unknown flags were rejected by the setting
+			}
+			if err2 := f.Value.Set(value); err2 != nil {
+				if err == nil {
+					err = fmt.Errorf("setting flag %s.%s=%q: %v", name, fname, value, err2)
+				}
+				continue
+			}
+			if applied[name] == nil {
+				applied[name] = make(map[string]string)
+			}
+			applied[name][fname] = value
+		}
+	}
+	analyzerFlags.applied = applied
+	analyzerFlags.mu.Unlock()
+
+	// Another goroutine may change the flags between Unlock and RLock,
+	// but that only costs efficiency, not correctness: the flag values
+	// are part of the analysis cache key.
+	analyzerFlags.mu.RLock()
+	return analyzerFlags.mu.RUnlock, err
+}
+
+func equalFlags(x, y map[string]map[string]string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for name, xvalues := range x {
+		yvalues, ok := y[name]
+		if !ok || len(xvalues) != len(yvalues) {
+			return false
+		}
+		for fname, v := range xvalues {
+			if w, ok := yvalues[fname]; !ok || v != w {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
+
+// lookupAnalyzer returns the built-in or staticcheck analyzer of the
+// given name, or nil.
+func lookupAnalyzer(name string) *analysis.Analyzer {
+	if a, ok := DefaultAnalyzers[name]; ok {
+		return a.analyzer
+	}
+	if a, ok := StaticcheckAnalyzers[name]; ok {
+		return a.analyzer
+	}
+	return nil
+}
+
+// lookupFlag returns the named flag of the named analyzer, or nil.
+func lookupFlag(analyzer, name string) *flag.Flag {
+	if a := lookupAnalyzer(analyzer); a != nil {
+		return a.Flags.Lookup(name)
+	}
+	return nil
+}
+
+// parseAnalyzerFlags validates the value of the analyzerFlags setting,
+// a JSON object mapping analyzer names to objects that map flag names
+// to values, and returns the flag values in string form.
+func parseAnalyzerFlags(value any) (map[string]map[string]string, error) {
+	all, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid type %T (want JSON object)", value)
+	}
+	result := make(map[string]map[string]string)
+	for name, v := range all {
+		if lookupAnalyzer(name) == nil {
+			return nil, fmt.Errorf("unknown analyzer %q", name)
+		}
+		flags, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid flags for %q: invalid type %T (want JSON object)", name, v)
+		}
+		values := make(map[string]string)
+		for fname, fvalue := range flags {
+			f := lookupFlag(name, fname)
+			if f == nil {
+				return nil, fmt.Errorf("analyzer %q has no flag %q", name, fname)
+			}
+			var str string
+			switch fvalue := fvalue.(type) {
+			case string:
+				str = fvalue
+			case bool, float64:
+				str = fmt.Sprint(fvalue)
+			default:
+				return nil, fmt.Errorf("invalid value for flag %s.%s: invalid type %T (want string, number, or boolean)", name, fname, fvalue)
+			}
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+				if _, err := strconv.ParseBool(str); err != nil {
+					return nil, fmt.Errorf("invalid value for boolean flag %s.%s: %q", name, fname, str)
+				}
+			}
+			values[fname] = str
+		}
+		result[name] = values
+	}
+	return result, nil
+}
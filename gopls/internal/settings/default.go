@@ -49,13 +49,20 @@ func DefaultOptions(overrides ...func(*Options)) *Options {
 						protocol.RefactorRewrite:       true,
 						protocol.RefactorInline:        true,
 						protocol.RefactorExtract:       true,
+						protocol.RefactorMove:          true,
+						protocol.GoAnalyzerDoc:         true,
 						protocol.GoAssembly:            true,
+						protocol.GoClones:              true,
+						protocol.GoDeadCode:            true,
+						protocol.GoDeepAnalysis:        true,
 						protocol.GoDoc:                 true,
 						protocol.GoFreeSymbols:         true,
+						protocol.GoRegenerate:          true,
 					},
 					file.Mod: {
 						protocol.SourceOrganizeImports: true,
 						protocol.QuickFix:              true,
+						protocol.RefactorRewrite:       true,
 					},
 					file.Work: {},
 					file.Sum:  {},
@@ -82,6 +89,7 @@ func DefaultOptions(overrides ...func(*Options)) *Options {
 						DiagnosticsDelay:          1 * time.Second,
 						DiagnosticsTrigger:        DiagnosticsOnEdit,
 						AnalysisProgressReporting: true,
+						SpellingDictionary:        ".gopls-dictionary",
 					},
 					InlayHintOptions: InlayHintOptions{},
 					DocumentationOptions: DocumentationOptions{
@@ -90,10 +98,11 @@ func DefaultOptions(overrides ...func(*Options)) *Options {
 						LinksInHover: true,
 					},
 					NavigationOptions: NavigationOptions{
-						ImportShortcut: BothShortcuts,
-						SymbolMatcher:  SymbolFastFuzzy,
-						SymbolStyle:    DynamicSymbols,
-						SymbolScope:    AllSymbolScope,
+						ImportShortcut:       BothShortcuts,
+						SymbolMatcher:        SymbolFastFuzzy,
+						SymbolStyle:          DynamicSymbols,
+						SymbolScope:          AllSymbolScope,
+						SymbolSectionPattern: `^//\s*MARK:[\s-]*(.*)$`,
 					},
 					CompletionOptions: CompletionOptions{
 						Matcher:                        Fuzzy,
@@ -111,6 +120,12 @@ func DefaultOptions(overrides ...func(*Options)) *Options {
 						CodeLensRunGovulncheck:    false, // TODO(hyangah): enable
 					},
 				},
+				FormattingOptions: FormattingOptions{
+					FormatSkipDirective: "//gofmt:skip-file",
+					StructTagKeys:       []string{"json", "yaml"},
+					StructTagCase:       SnakeCase,
+					FillStructDepth:     1,
+				},
 			},
 			InternalOptions: InternalOptions{
 				CompleteUnimported:          true,
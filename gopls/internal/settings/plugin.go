@@ -0,0 +1,111 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package settings
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// pluginSymbol is the name of the variable, of type
+// []*analysis.Analyzer, that an analyzer plugin must export.
+const pluginSymbol = "Analyzers"
+
+// A loadedPlugin records the outcome of loading a plugin.
+type loadedPlugin struct {
+	analyzers []*Analyzer
+	err       error
+}
+
+var plugins struct {
+	mu      sync.Mutex
+	loaded  map[string]*loadedPlugin      // keyed by absolute file name
+	digests map[*analysis.Analyzer]string // digest of plugin file, for each plugin analyzer
+}
+
+// PluginDigest returns a digest of the plugin file that provided the
+// analyzer, or "" if it is not a plugin analyzer. Since plugin
+// analyzers are not part of the gopls executable, their results must
+// be cached under keys that distinguish different builds.
+func PluginDigest(a *analysis.Analyzer) string {
+	plugins.mu.Lock()
+	defer plugins.mu.Unlock()
+	return plugins.digests[a]
+}
+
+// PluginAnalyzers returns the analyzers exported by the Go plugins
+// named by the analyzerPlugins setting. Each plugin is loaded at most
+// once per process, since the runtime cannot unload or reload them.
+//
+// The analyzers of plugins that were loaded successfully are returned
+// even if others failed, along with the first error.
+func PluginAnalyzers(filenames []string) ([]*Analyzer, error) {
+	plugins.mu.Lock()
+	defer plugins.mu.Unlock()
+
+	var (
+		result   []*Analyzer
+		firstErr error
+	)
+	for _, filename := range filenames {
+		p, ok := plugins.loaded[filename]
+		if !ok {
+			p = new(loadedPlugin)
+			p.analyzers, p.err = loadPlugin(filename)
+			if plugins.loaded == nil {
+				plugins.loaded = make(map[string]*loadedPlugin)
+			}
+			plugins.loaded[filename] = p
+		}
+		if p.err != nil && firstErr == nil {
+			firstErr = p.err
+		}
+		result = append(result, p.analyzers...)
+	}
+	return result, firstErr
+}
+
+// loadPlugin opens the named plugin and returns its analyzers, which
+// are enabled by default. It is called with plugins.mu held.
+func loadPlugin(filename string) ([]*Analyzer, error) {
+	if !filepath.IsAbs(filename) {
+		return nil, fmt.Errorf("analyzer plugin %q: path must be absolute", filename)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("analyzer plugin: %v", err)
+	}
+	digest := fmt.Sprintf("%x", sha256.Sum256(data))
+	sym, err := lookupPluginSymbol(filename, pluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("analyzer plugin %q: %v", filename, err)
+	}
+	ptr, ok := sym.(*[]*analysis.Analyzer)
+	if !ok {
+		return nil, fmt.Errorf("analyzer plugin %q: %s has type %T, want []*analysis.Analyzer (is the plugin built with the same version of golang.org/x/tools?)", filename, pluginSymbol, sym)
+	}
+	if err := analysis.Validate(*ptr); err != nil {
+		return nil, fmt.Errorf("analyzer plugin %q: %v", filename, err)
+	}
+	var analyzers []*Analyzer
+	for _, a := range *ptr {
+		if _, ok := DefaultAnalyzers[a.Name]; ok {
+			return nil, fmt.Errorf("analyzer plugin %q: analyzer %q conflicts with a built-in analyzer", filename, a.Name)
+		}
+		analyzers = append(analyzers, &Analyzer{analyzer: a, enabled: true})
+	}
+	if plugins.digests == nil {
+		plugins.digests = make(map[*analysis.Analyzer]string)
+	}
+	for _, a := range *ptr {
+		plugins.digests[a] = digest
+	}
+	return analyzers, nil
+}
@@ -0,0 +1,23 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build (linux || darwin || freebsd) && cgo
+// +build linux darwin freebsd
+// +build cgo
+
+package settings
+
+import "plugin"
+
+// pluginsSupported reports whether this build of gopls can load Go plugins.
+const pluginsSupported = true
+
+// lookupPluginSymbol opens the named Go plugin and looks up a symbol.
+func lookupPluginSymbol(filename, symbol string) (any, error) {
+	p, err := plugin.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	return p.Lookup(symbol)
+}
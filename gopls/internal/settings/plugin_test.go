@@ -0,0 +1,65 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package settings
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/internal/testenv"
+)
+
+func TestPluginAnalyzers(t *testing.T) {
+	if !pluginsSupported {
+		t.Skip("Go plugins are not supported by this build")
+	}
+	testenv.NeedsGoBuild(t)
+	if testing.Short() {
+		t.Skip("skipping plugin build in short mode")
+	}
+
+	filename := filepath.Join(t.TempDir(), "plugin.so")
+	cmd := exec.Command("go", "build", "-buildmode=plugin", "-o", filename, "./testdata/plugin")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("building plugin: %v\n%s", err, out)
+	}
+
+	analyzers, err := PluginAnalyzers([]string{filename})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analyzers) != 1 || analyzers[0].Analyzer().Name != "plugintest" {
+		t.Fatalf("PluginAnalyzers returned %v, want the plugintest analyzer", analyzers)
+	}
+	if !analyzers[0].EnabledByDefault() {
+		t.Errorf("plugin analyzer is not enabled by default")
+	}
+	if PluginDigest(analyzers[0].Analyzer()) == "" {
+		t.Errorf("plugin analyzer has no digest")
+	}
+}
+
+func TestSetWorkspace_AnalyzerPlugins(t *testing.T) {
+	opts := DefaultOptions()
+	if errs := opts.Set(map[string]any{"analyzerPlugins": []any{}}); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	// A workspace may not name a plugin, which would run its code.
+	errs := opts.SetWorkspace(map[string]any{"analyzerPlugins": []any{"/tmp/evil.so"}})
+	if len(errs) != 1 {
+		t.Errorf("SetWorkspace(analyzerPlugins) returned %v, want one error", errs)
+	}
+	if len(opts.AnalyzerPlugins) != 0 {
+		t.Errorf("SetWorkspace set AnalyzerPlugins to %v", opts.AnalyzerPlugins)
+	}
+
+	// But it may repeat the user's setting, as clients often send
+	// the same settings in both places.
+	if errs := opts.SetWorkspace(map[string]any{"analyzerPlugins": []any{}}); len(errs) > 0 {
+		t.Errorf("SetWorkspace(analyzerPlugins) with the user's value returned %v", errs)
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !((linux || darwin || freebsd) && cgo)
+// +build !linux,!darwin,!freebsd !cgo
+
+package settings
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// pluginsSupported reports whether this build of gopls can load Go plugins.
+const pluginsSupported = false
+
+// lookupPluginSymbol reports that Go plugins are not supported.
+func lookupPluginSymbol(filename, symbol string) (any, error) {
+	return nil, fmt.Errorf("Go plugins are not supported by this build of gopls (%s/%s, cgo required)", runtime.GOOS, runtime.GOARCH)
+}
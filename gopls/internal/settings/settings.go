@@ -6,14 +6,19 @@ package settings
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
 	"time"
 
+	"golang.org/x/mod/semver"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/util/maps"
+	"golang.org/x/tools/gopls/internal/util/pathutil"
 	"golang.org/x/tools/gopls/internal/util/slices"
 )
 
@@ -140,6 +145,16 @@ type BuildOptions struct {
 	// be removed.
 	AllowImplicitNetworkAccess bool `status:"experimental"`
 
+	// DeferTestVariants causes gopls to omit test variants of packages,
+	// such as "p [p.test]" and "p_test [p.test]", from the initial
+	// workspace load. A package's test variants are loaded on demand
+	// when one of its _test.go files is opened, and the test variants of
+	// all workspace packages are loaded when a test command is run or a
+	// workspace-wide operation such as renaming needs them. This can
+	// substantially reduce the time and memory needed to load large
+	// workspaces with extensive test suites.
+	DeferTestVariants bool `status:"experimental"`
+
 	// StandaloneTags specifies a set of build constraints that identify
 	// individual Go source files that make up the entire main package of an
 	// executable.
@@ -159,6 +174,20 @@ type BuildOptions struct {
 	//
 	// This setting is only supported when gopls is built with Go 1.16 or later.
 	StandaloneTags []string
+
+	// ScratchDirectories lists directories, relative to the workspace
+	// folder, in which each Go file is treated as an independent main
+	// package, as if it were a standalone file (see standaloneTags),
+	// whatever its build constraints. This suits a directory of
+	// throwaway programs, which typically each declare func main.
+	//
+	// The directories and their subdirectories are excluded from the
+	// packages of the enclosing module, as if by directoryFilters, so
+	// their files are not reported as a broken package. As with other
+	// standalone files, an open scratch file has full language support,
+	// and the `run` code lens offers to run it. Test files are not
+	// affected.
+	ScratchDirectories []string `status:"experimental"`
 }
 
 // Note: UIOptions must be comparable with reflect.DeepEqual.
@@ -186,6 +215,30 @@ type UIOptions struct {
 	// ```
 	Codelenses map[CodeLensSource]bool
 
+	// CodeActionExclusions disables code actions of the given kinds
+	// for files whose path, relative to the workspace folder, matches
+	// one of a list of glob patterns, using the syntax of
+	// analysisExclusions. Each key is a code action kind, which also
+	// matches the kinds nested within it, or "*" to match all kinds. A
+	// pattern prefixed by "!" enables the kind again for the files it
+	// matches, overriding the preceding patterns of the list. It is
+	// intended for clients that apply code actions of some kinds, such
+	// as `source.organizeImports` or `source.fixAll`, automatically on
+	// save.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// ...
+	// "codeActionExclusions": {
+	//   "source.organizeImports": ["gen/**", "!gen/main.go"],
+	//   "refactor.extract": ["third_party/**"],
+	//   "*": ["testdata/**"]
+	// }
+	// ...
+	// ```
+	CodeActionExclusions map[string][]string `status:"experimental"`
+
 	// SemanticTokens controls whether the LSP server will send
 	// semantic tokens to the client.
 	SemanticTokens bool `status:"experimental"`
@@ -253,6 +306,32 @@ const (
 	// the import, or in C header files included by it.
 	CodeLensRegenerateCgo CodeLensSource = "regenerate_cgo"
 
+	// Run main packages
+	//
+	// This codelens source annotates the `main` function of each
+	// main package with commands to run the package, or only the
+	// file, as by `go run`. The output of the program is streamed
+	// through progress notifications; cancelling the progress
+	// kills the program.
+	//
+	// The arguments and environment of a program are remembered
+	// per package across sessions. To change them, execute the
+	// `gopls.run_main` command with explicit arguments.
+	//
+	// This source is off by default, like `test`, because
+	// progress notifications are not a great UX for streamed
+	// output.
+	CodeLensRun CodeLensSource = "run"
+
+	// Show importers of a package
+	//
+	// This codelens source annotates the `package` clause of each
+	// Go file with the number of workspace packages that directly
+	// import its package, and a command to list them with the
+	// locations of their imports. This is useful before making a
+	// breaking change to the API of a package.
+	CodeLensImporters CodeLensSource = "importers"
+
 	// Run govulncheck
 	//
 	// This codelens source annotates the `module` directive in a
@@ -357,6 +436,14 @@ type DocumentationOptions struct {
 
 	// LinksInHover controls the presence of documentation links in hover markdown.
 	LinksInHover LinksInHoverEnum
+
+	// AnalyzerDocsInHover extends the hover over a line that has an
+	// analyzer diagnostic with the summary of the analyzer, an
+	// example of its fixes taken from its tests, and a link to its
+	// documentation. The complete documentation is available from
+	// the gopls.analyzer_doc command, which is offered as a code
+	// action of kind source.analyzerdoc for each analyzer diagnostic.
+	AnalyzerDocsInHover bool `status:"experimental"`
 }
 
 // LinksInHoverEnum has legal values:
@@ -377,8 +464,75 @@ type FormattingOptions struct {
 	// separately.
 	Local string
 
+	// ImportGroups specifies the order of the groups, separated by
+	// blank lines, into which the organization of imports sorts the
+	// imports of a file, superseding local. Each element is `"std"`
+	// for the standard library, `"module"` for the module of the file,
+	// `"other"` for imports that belong to no other group, or an
+	// import path prefix. An import belongs to the group of the
+	// longest matching prefix or module path. Imports that are
+	// already present are regrouped, and their comments preserved.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// ...
+	// "importGroups": ["std", "other", "example.com/corp", "module"]
+	// ...
+	// ```
+	ImportGroups []string `status:"experimental"`
+
 	// Gofumpt indicates if we should run gofumpt formatting.
 	Gofumpt bool
+
+	// FormatExclusions disables formatting and the organization of
+	// imports for files whose path, relative to the workspace folder,
+	// matches one of a list of glob patterns, using the syntax of
+	// analysisExclusions. Diagnostics are still reported for such
+	// files. It is intended for files whose formatting is deliberately
+	// nonstandard, such as tables with hand-aligned columns.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// ...
+	// "formatExclusions": ["**/tables/*.go"]
+	// ...
+	// ```
+	FormatExclusions []string `status:"experimental"`
+
+	// FormatSkipDirective is a comment that, if it appears before the
+	// package clause of a file, disables formatting and the
+	// organization of imports for the file, as for formatExclusions.
+	// The empty string disables the directive.
+	FormatSkipDirective string `status:"experimental"`
+
+	// StructTagKeys are the keys of the struct tags, such as "json",
+	// that the code actions on a struct type or a selection of its
+	// fields add, remove, or synchronize with the names of the fields.
+	StructTagKeys []string `status:"experimental"`
+
+	// StructTagCase determines the names that the struct tag code
+	// actions derive from the names of fields.
+	StructTagCase StructTagCase `status:"experimental"`
+
+	// StructTagOmitEmpty causes the struct tags added by code actions
+	// to have the omitempty option, as in `json:"name,omitempty"`.
+	StructTagOmitEmpty bool `status:"experimental"`
+
+	// DotImportPaths are the import paths of packages, such as the
+	// assertion libraries of test frameworks, whose imports the
+	// "Convert to dot-import" code action may convert to
+	// dot-imports. Dot-imports of other packages are discouraged,
+	// so the action is not offered for them.
+	DotImportPaths []string `status:"experimental"`
+
+	// FillStructDepth is the depth of nesting up to which the "Fill"
+	// code actions on a struct literal fill the fields of nested struct
+	// literals. At depth 1, a field of struct type is filled with an
+	// empty literal such as `T{}`; at depth 2, the fields of that
+	// literal are filled too, and so on.
+	FillStructDepth int `status:"experimental"`
 }
 
 // Note: DiagnosticOptions must be comparable with reflect.DeepEqual.
@@ -400,9 +554,168 @@ type DiagnosticOptions struct {
 	// ```
 	Analyses map[string]bool
 
+	// AnalysisSeverities overrides the severity of the diagnostics
+	// reported by the named analyzers. Each value must be one of
+	// "error", "warning", "information", or "hint".
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// ...
+	// "analysisSeverities": {
+	//   "unusedparams": "hint", // Demote unusedparams findings to hints.
+	//   "printf": "error"       // Promote printf findings to errors.
+	// }
+	// ...
+	// ```
+	AnalysisSeverities map[string]string `status:"experimental"`
+
+	// AnalysisExclusions disables analyzers for files whose path,
+	// relative to the workspace folder, matches one of a list of glob
+	// patterns. Each key is the name of an analyzer, or "*" to match all
+	// analyzers. In addition to the usual `*`, `?`, and `[...]`
+	// operators, patterns support `**` to match zero or more complete
+	// path segments.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// ...
+	// "analysisExclusions": {
+	//   "*": ["**/zz_generated*.go"],   // No analysis of generated code.
+	//   "shadow": ["third_party/**"]    // No shadow checks in forked code.
+	// }
+	// ...
+	// ```
+	AnalysisExclusions map[string][]string `status:"experimental"`
+
+	// StyleDiagnosticsInGeneratedFiles enables the diagnostics of
+	// analyzers that report mere matters of style, such as the
+	// simplifications of "gofmt -s" and unused parameters, in
+	// generated files, which are identified by a comment such as
+	// "// Code generated by stringer; DO NOT EDIT.". By default, these
+	// diagnostics are not reported in generated files, which are not
+	// meant to be edited.
+	StyleDiagnosticsInGeneratedFiles bool `status:"experimental"`
+
+	// AnalyzerFlags sets the flags of analyzers, which otherwise run
+	// with their default flag values, like the flags of `go vet` such
+	// as `-printf.funcs`. Each key is the name of an analyzer, and each
+	// value is an object that maps flag names to values. Unknown
+	// analyzers and flags are rejected.
+	//
+	// Since the analyzers are shared by all workspaces of a gopls
+	// process, analyses of workspaces with different flag values do
+	// not run concurrently. A few flags, such as `funcs` of the printf
+	// analyzer, accumulate values, so removing them takes effect only
+	// after gopls is restarted.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// ...
+	// "analyzerFlags": {
+	//   "printf": {"funcs": "mylog.Logf,mylog.Errorf"}, // Also check these printf wrappers.
+	//   "shadow": {"strict": true}                       // Report all shadowing.
+	// }
+	// ...
+	// ```
+	AnalyzerFlags map[string]map[string]string `status:"experimental"`
+
+	// DiagnosticsBaseline is the path of a baseline file, relative to
+	// the workspace folder, that records the fingerprints of existing
+	// analysis diagnostics. Diagnostics recorded in the baseline are not
+	// reported, so that only new findings are shown.
+	//
+	// The file may be created or updated by running
+	// `gopls check -write-baseline=file ./...` in the workspace folder.
+	DiagnosticsBaseline string `status:"experimental"`
+
+	// AnalyzerPlugins is a list of absolute paths of Go plugins, built
+	// with `go build -buildmode=plugin`, that provide additional
+	// analyzers. Each plugin must export a variable
+	// `Analyzers []*analysis.Analyzer`, and must be built with the same
+	// Go toolchain and versions of golang.org/x/tools dependencies as
+	// gopls itself. Plugin analyzers are enabled by default, and may be
+	// disabled by name using the `analyses` setting.
+	//
+	// Plugins are loaded once per gopls process; restart gopls after
+	// rebuilding a plugin. Go plugins are supported only on Linux,
+	// macOS, and FreeBSD, in builds of gopls that use cgo.
+	//
+	// Since plugins run native code, this setting may be set only in
+	// the user's configuration, which the client sends in the
+	// initialization options, and not in the configuration of a
+	// workspace.
+	AnalyzerPlugins []string `status:"experimental"`
+
+	// AnalyzerTools configures external analyzer tools: executables,
+	// such as those built from a main package that calls
+	// `unitchecker.Main`, `singlechecker.Main`, or
+	// `multichecker.Main`, that `go vet -vettool` accepts. Each key is
+	// the absolute path of a tool, and each value is a list of flags to
+	// pass to it, such as analyzer flags. Unlike plugins, tools need
+	// not be built with the same versions of Go and golang.org/x/tools
+	// as gopls.
+	//
+	// gopls runs each tool by `go vet -vettool`, so that the tool
+	// also analyzes the dependencies of each package and its analyzers
+	// can use facts about them. Since that is costly, the tools run only
+	// on packages whose files are all saved, and their results are
+	// reused until the package or one of its dependencies changes. The
+	// diagnostics and suggested fixes of each analyzer are reported as
+	// if it were built in: the `analyses`, `analysisSeverities`, and
+	// `analysisExclusions` settings apply to them by analyzer name.
+	//
+	// Since tools run native code, this setting, like
+	// `analyzerPlugins`, may be set only in the user's configuration.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// ...
+	// "analyzerTools": {
+	//   "/home/me/go/bin/mychecks": ["-mycheck.strict"]
+	// }
+	// ...
+	// ```
+	AnalyzerTools map[string][]string `status:"experimental"`
+
+	// Spelling enables the reporting of common misspellings of English
+	// words in the comments and string literals of open Go files. Each
+	// diagnostic offers to replace the word with its correction, or to
+	// add the word to the dictionary named by `spellingDictionary`.
+	Spelling bool `status:"experimental"`
+
+	// SpellingDictionary is the path of a file, relative to the
+	// workspace folder, that lists words (one per line) that should not
+	// be reported as misspellings.
+	SpellingDictionary string `status:"experimental"`
+
+	// Clones enables the detection of duplicated functions in the
+	// workspace: those whose signatures and bodies are the same, or
+	// differ only by a consistent renaming of identifiers and by the
+	// values of literals. Each function of a cluster of duplicates is
+	// reported as a hint, whose related information links to the others.
+	// The `gopls.list_clones` command lists all clusters.
+	Clones bool `status:"experimental"`
+
+	// InterfaceChanges enables the reporting of changes, made by unsaved
+	// edits, to the set of interfaces satisfied by the types of open
+	// files. While a file has unsaved edits, each interface, declared in
+	// the type's package or exported by one of its imports, that the type
+	// newly satisfies or no longer satisfies is reported as an
+	// informational diagnostic on the type's declaration.
+	InterfaceChanges bool `status:"experimental"`
+
 	// Staticcheck enables additional analyses from staticcheck.io.
 	// These analyses are documented on
 	// [Staticcheck's website](https://staticcheck.io/docs/checks/).
+	//
+	// The checks that run on each package, and options such as
+	// initialisms, are determined by the `staticcheck.conf` files in
+	// its directory and their parents, as for the staticcheck command.
+	// The `analyses` setting takes precedence for the checks it names.
 	Staticcheck bool `status:"experimental"`
 
 	// Annotations specifies the various kinds of optimization diagnostics
@@ -412,6 +725,28 @@ type DiagnosticOptions struct {
 	// Vulncheck enables vulnerability scanning.
 	Vulncheck VulncheckMode `status:"experimental"`
 
+	// DependencyUpdates enables a background check for newer minor and
+	// patch versions of the modules required by each go.mod file, for
+	// retractions of the required versions, and for deprecations of the
+	// modules, using `go list -m -u -retracted`, which may access the
+	// network. Available updates are reported as informational
+	// diagnostics on the require directives, with links to the release
+	// notes of the new versions; retracted versions and deprecated
+	// modules are reported as warnings. The notices for a module may be
+	// snoozed until a newer version is released.
+	DependencyUpdates bool `status:"experimental"`
+
+	// APICompatibility is the released version of each workspace
+	// module, such as "v1.4.0", against which to check the exported API
+	// of the module's packages as you edit, or "latest" for its newest
+	// tagged version. The released version is read from the module
+	// cache, or downloaded. Incompatible changes, such as the removal of
+	// an exported declaration or a change to its type, are reported as
+	// "breaking change" warnings on the changed declaration. Packages
+	// whose import path contains an "internal" element, and main
+	// packages, are not checked. The empty string disables the check.
+	APICompatibility string `status:"experimental"`
+
 	// DiagnosticsDelay controls the amount of time that gopls waits
 	// after the most recent file modification before computing deep diagnostics.
 	// Simple diagnostics (parsing and type-checking) are always run immediately
@@ -423,6 +758,37 @@ type DiagnosticOptions struct {
 	// DiagnosticsTrigger controls when to run diagnostics.
 	DiagnosticsTrigger DiagnosticsTrigger `status:"experimental"`
 
+	// AnalysisTriggers controls when gopls computes the diagnostics of
+	// each source, so that the costlier sources can trade freshness for
+	// CPU time. Each key is a hierarchical diagnostic source, such as
+	// "analyzer", "analyzer/printf", "gopls/spelling", "vulncheck", or
+	// "upgrade-advisor"; the most specific key that is the source or
+	// one of its ancestors applies. Each value is one of:
+	//
+	//   - "Edit": after each change (the default);
+	//   - "Save": only when a file is saved or opened, and on events
+	//     such as a workspace load or configuration change;
+	//   - "Manual": only when the `gopls.analyze_now` command runs;
+	//   - a duration, such as "2s": once no file has changed for that
+	//     long, as well as on save.
+	//
+	// Until a source runs again, its previous diagnostics remain.
+	// Parsing and type checking (the "compiler" source) always run
+	// after each change.
+	//
+	// Example Usage:
+	//
+	// ```json5
+	// ...
+	// "analysisTriggers": {
+	//   "analyzer": "Save",       // Analyze only on save.
+	//   "analyzer/printf": "1s",  // ...except printf, once idle for 1s.
+	//   "vulncheck": "Manual"     // Check vulnerabilities on demand.
+	// }
+	// ...
+	// ```
+	AnalysisTriggers map[string]string `status:"experimental"`
+
 	// AnalysisProgressReporting controls whether gopls sends progress
 	// notifications when construction of its index of analysis facts is taking a
 	// long time. Cancelling these notifications will cancel the indexing task,
@@ -468,6 +834,56 @@ type NavigationOptions struct {
 	// packages. When the scope is "all", gopls searches all loaded packages,
 	// including dependencies and the standard library.
 	SymbolScope SymbolScope
+
+	// SymbolSectionPattern is a regular expression that identifies the
+	// top-level comments that begin a section of a file, such as
+	// "// MARK: Parsing". In documentSymbol responses, which
+	// clients display as an outline of the file, the declarations
+	// of each section appear as the children of a symbol for the
+	// section, named by the first submatch of the pattern, if any,
+	// or by the entire match. An empty pattern disables sections.
+	SymbolSectionPattern string `status:"experimental"`
+
+	// DynamicCalls enables the resolution of dynamic calls in the
+	// outgoing call hierarchy, so that it does not end at each call
+	// through an interface or function value. A call of an interface
+	// method also leads to each concrete method that implements it,
+	// and a call of a function-typed variable or field leads to each
+	// function or method with an identical signature that is used as a
+	// value in the caller's package. These approximate callees are
+	// marked "dynamic" in the details of the call.
+	DynamicCalls bool `status:"experimental"`
+
+	// TagReferences extends the references to a struct field that
+	// has a json or yaml tag with the string literals in the
+	// workspace that match the key of the tag: literals equal to the
+	// key, as in a map lookup; elements of dotted paths such as
+	// ".items[0].name"; and object keys in JSON or YAML text, as in a
+	// test fixture. These textual matches are a heuristic, not the
+	// result of type checking, and are reported as such by the
+	// gopls.tag_references command.
+	TagReferences bool `status:"experimental"`
+
+	// RegistrationReferences extends references and renaming
+	// through string-keyed registries. The references to a function
+	// include the patterns under which it is registered as an HTTP
+	// handler, by calls to methods named Handle or HandleFunc. The
+	// references to a method of a type registered as a net/rpc
+	// service include the "Service.Method" strings that name it, and
+	// renaming the method updates them. The references to a template
+	// name in a string literal are the literals that define, look up,
+	// or execute the template of that name. These relations are found
+	// by syntactic patterns, so they are a heuristic.
+	RegistrationReferences bool `status:"experimental"`
+
+	// LineDirectives maps the results of navigation through //line
+	// directives, such as those that goyacc, cgo, and some protoc
+	// plugins write to generated files. A definition or reference in
+	// a generated file is reported at the location in the original
+	// source to which its directive refers, if that file exists, and
+	// a diagnostic in a generated file is related to that location.
+	// The gopls.generated_location command maps the other way.
+	LineDirectives bool `status:"experimental"`
 }
 
 // UserOptions holds custom Gopls configuration (not part of the LSP) that is
@@ -588,6 +1004,12 @@ type InternalOptions struct {
 	// It is intended to be used for testing only.
 	ReportAnalysisProgressAfter time.Duration
 
+	// StaleFileCheckInterval sets how often gopls checks whether
+	// workspace files it has read have changed on disk without a
+	// notification from the client, in which case it reloads them.
+	// Zero, the default, disables the check.
+	StaleFileCheckInterval time.Duration
+
 	// TelemetryPrompt controls whether gopls prompts about enabling Go telemetry.
 	//
 	// Once the prompt is answered, gopls doesn't ask again, but TelemetryPrompt
@@ -634,6 +1056,17 @@ func (s ImportShortcut) ShowDefinition() bool {
 	return s == BothShortcuts || s == DefinitionShortcut
 }
 
+type StructTagCase string
+
+const (
+	// Snake case, as in "user_id" for a field UserID.
+	SnakeCase StructTagCase = "snake_case"
+	// Camel case, as in "userID" for a field UserID.
+	CamelCase StructTagCase = "camelCase"
+	// The name of the field itself, as in "UserID".
+	KeepCase StructTagCase = "keep"
+)
+
 type Matcher string
 
 const (
@@ -720,16 +1153,137 @@ const (
 	// TODO: support "Manual"?
 )
 
+// severities maps the values of the analysisSeverities setting to
+// their protocol form.
+var severities = map[string]protocol.DiagnosticSeverity{
+	"error":       protocol.SeverityError,
+	"warning":     protocol.SeverityWarning,
+	"information": protocol.SeverityInformation,
+	"hint":        protocol.SeverityHint,
+}
+
+// AnalysisSeverity returns the severity configured for diagnostics of
+// the named analyzer by the analysisSeverities setting, or zero if
+// there is no override.
+func (o *Options) AnalysisSeverity(analyzer string) protocol.DiagnosticSeverity {
+	return severities[o.AnalysisSeverities[analyzer]]
+}
+
+// Values of the analysisTriggers setting, other than durations.
+const (
+	AnalyzeOnEdit = "Edit"
+	AnalyzeOnSave = "Save"
+	AnalyzeManual = "Manual"
+)
+
+// AnalysisTrigger returns the trigger configured by the
+// analysisTriggers setting for the diagnostic source with the given
+// hierarchical path: [AnalyzeOnEdit], [AnalyzeOnSave], [AnalyzeManual],
+// or "" and a positive duration, for a source that runs once no file
+// has changed for that long.
+func (o *Options) AnalysisTrigger(path string) (string, time.Duration) {
+	trigger, best := AnalyzeOnEdit, ""
+	for filter, value := range o.AnalysisTriggers {
+		if (path == filter || strings.HasPrefix(path, filter+"/")) && len(filter) > len(best) {
+			trigger, best = value, filter
+		}
+	}
+	if d, err := time.ParseDuration(trigger); err == nil {
+		return "", d
+	}
+	return trigger, 0
+}
+
+// AnalysisExcluded reports whether the named analyzer is disabled by
+// the analysisExclusions setting for the file with the given
+// slash-separated path, relative to the workspace folder.
+func (o *Options) AnalysisExcluded(analyzer, relPath string) bool {
+	for _, key := range [...]string{analyzer, "*"} {
+		for _, glob := range o.AnalysisExclusions[key] {
+			if pathutil.MatchGlob(glob, relPath) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CodeActionExcluded reports whether code actions of the given kind
+// are disabled by the codeActionExclusions setting for the file with
+// the given slash-separated path, relative to the workspace folder.
+func (o *Options) CodeActionExcluded(kind protocol.CodeActionKind, relPath string) bool {
+	for key, globs := range o.CodeActionExclusions {
+		if key != "*" && key != string(kind) && !strings.HasPrefix(string(kind), key+".") {
+			continue
+		}
+		excluded := false
+		for _, glob := range globs {
+			if strings.HasPrefix(glob, "!") {
+				if pathutil.MatchGlob(glob[len("!"):], relPath) {
+					excluded = false
+				}
+			} else if pathutil.MatchGlob(glob, relPath) {
+				excluded = true
+			}
+		}
+		if excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatExcluded reports whether formatting is disabled by the
+// formatExclusions setting for the file with the given slash-separated
+// path, relative to the workspace folder.
+func (o *Options) FormatExcluded(relPath string) bool {
+	for _, glob := range o.FormatExclusions {
+		if pathutil.MatchGlob(glob, relPath) {
+			return true
+		}
+	}
+	return false
+}
+
 // Set updates *options based on the provided JSON value:
 // null, bool, string, number, array, or object.
 // On failure, it returns one or more non-nil errors.
 func (o *Options) Set(value any) (errors []error) {
+	return o.setAll(value, false)
+}
+
+// SetWorkspace is like Set, but is used for the settings of a
+// workspace/configuration response, which may come from files in the
+// workspace. Settings that would cause gopls to load or run code named
+// by the configuration (see [userOnlySettings]) may be set only in
+// the initialization options; SetWorkspace reports an error for any
+// value of such a setting that differs from the current one.
+func (o *Options) SetWorkspace(value any) (errors []error) {
+	return o.setAll(value, true)
+}
+
+// userOnlySettings holds the names of the settings that may be set
+// only in the initialization options of the client, which come from
+// the user's configuration, and not from the workspace, since a
+// malicious repository could otherwise cause gopls to run its code.
+var userOnlySettings = map[string]bool{
+	"analyzerPlugins": true,
+	"analyzerTools":   true,
+}
+
+func (o *Options) setAll(value any, workspace bool) (errors []error) {
 	switch value := value.(type) {
 	case nil:
 	case map[string]any:
 		seen := make(map[string]struct{})
 		for name, value := range value {
-			if err := o.set(name, value, seen); err != nil {
+			var err error
+			if workspace && userOnlySettings[name] {
+				err = o.checkUserOnly(name, value)
+			} else {
+				err = o.set(name, value, seen)
+			}
+			if err != nil {
 				err := fmt.Errorf("setting option %v: %w", name, err)
 				errors = append(errors, err)
 			}
@@ -802,15 +1356,84 @@ func (o *Options) Clone() *Options {
 	}
 	// Fully clone any slice or map fields. Only UserOptions can be modified.
 	result.Analyses = maps.Clone(o.Analyses)
+	result.AnalysisSeverities = maps.Clone(o.AnalysisSeverities)
+	result.AnalysisExclusions = maps.Clone(o.AnalysisExclusions)
+	result.AnalysisTriggers = maps.Clone(o.AnalysisTriggers)
+	result.AnalyzerFlags = maps.Clone(o.AnalyzerFlags)
 	result.Codelenses = maps.Clone(o.Codelenses)
+	result.CodeActionExclusions = maps.Clone(o.CodeActionExclusions)
 	result.SetEnvSlice(o.EnvSlice())
 	result.BuildFlags = slices.Clone(o.BuildFlags)
 	result.DirectoryFilters = slices.Clone(o.DirectoryFilters)
+	result.AnalyzerPlugins = slices.Clone(o.AnalyzerPlugins)
+	result.AnalyzerTools = maps.Clone(o.AnalyzerTools)
 	result.StandaloneTags = slices.Clone(o.StandaloneTags)
+	result.ScratchDirectories = slices.Clone(o.ScratchDirectories)
 
 	return result
 }
 
+// checkUserOnly reports an error if the value of a setting of the
+// workspace configuration that may be set only by the user differs
+// from its current value.
+func (o *Options) checkUserOnly(name string, value any) error {
+	var same bool
+	switch name {
+	case "analyzerPlugins":
+		filenames, err := parseAnalyzerPlugins(value)
+		if err != nil {
+			return err
+		}
+		same = slices.Equal(filenames, o.AnalyzerPlugins)
+	case "analyzerTools":
+		tools, err := parseAnalyzerTools(value)
+		if err != nil {
+			return err
+		}
+		same = len(tools) == len(o.AnalyzerTools)
+		for tool, args := range tools {
+			if userArgs, ok := o.AnalyzerTools[tool]; !ok || !slices.Equal(args, userArgs) {
+				same = false
+			}
+		}
+	}
+	if !same {
+		return fmt.Errorf("may be set only in the user's configuration, not the workspace's")
+	}
+	return nil
+}
+
+// parseAnalyzerPlugins parses the value of the analyzerPlugins setting.
+func parseAnalyzerPlugins(value any) ([]string, error) {
+	return asStringSlice(value)
+}
+
+// parseAnalyzerTools parses the value of the analyzerTools setting.
+// It does not check that the tools exist.
+func parseAnalyzerTools(value any) (map[string][]string, error) {
+	all, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid type %T (want JSON object)", value)
+	}
+	m := make(map[string][]string)
+	for tool, flags := range all {
+		if !filepath.IsAbs(tool) {
+			return nil, fmt.Errorf("analyzer tool %q: path must be absolute", tool)
+		}
+		args, err := asStringSlice(flags)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %v", tool, err)
+		}
+		for _, arg := range args {
+			if !strings.HasPrefix(arg, "-") {
+				return nil, fmt.Errorf("invalid flag %q for analyzer tool %q", arg, tool)
+			}
+		}
+		m[tool] = args
+	}
+	return m, nil
+}
+
 // validateDirectoryFilter validates if the filter string
 // - is not empty
 // - start with either + or -
@@ -923,6 +1546,28 @@ func (o *Options) set(name string, value any, seen map[string]struct{}) error {
 			WorkspaceSymbolScope,
 			AllSymbolScope)
 
+	case "symbolSectionPattern":
+		var pattern string
+		if err := setString(&pattern, value); err != nil {
+			return err
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid symbolSectionPattern: %v", err)
+		}
+		o.SymbolSectionPattern = pattern
+
+	case "dynamicCalls":
+		return setBool(&o.DynamicCalls, value)
+
+	case "tagReferences":
+		return setBool(&o.TagReferences, value)
+
+	case "registrationReferences":
+		return setBool(&o.RegistrationReferences, value)
+
+	case "lineDirectives":
+		return setBool(&o.LineDirectives, value)
+
 	case "hoverKind":
 		return setEnum(&o.HoverKind, value,
 			NoDocumentation,
@@ -943,6 +1588,9 @@ func (o *Options) set(name string, value any, seen map[string]struct{}) error {
 				value)
 		}
 
+	case "analyzerDocsInHover":
+		return setBool(&o.AnalyzerDocsInHover, value)
+
 	case "importShortcut":
 		return setEnum(&o.ImportShortcut, value,
 			BothShortcuts,
@@ -952,6 +1600,92 @@ func (o *Options) set(name string, value any, seen map[string]struct{}) error {
 	case "analyses":
 		return setBoolMap(&o.Analyses, value)
 
+	case "analysisSeverities":
+		all, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid type %T (want JSON object)", value)
+		}
+		m := make(map[string]string)
+		for a, sev := range all {
+			str, err := asString(sev)
+			if err != nil {
+				return fmt.Errorf("invalid value for %q: %v", a, err)
+			}
+			if _, ok := severities[strings.ToLower(str)]; !ok {
+				return fmt.Errorf("invalid severity %q for %q (want error, warning, information, or hint)", str, a)
+			}
+			m[a] = strings.ToLower(str)
+		}
+		o.AnalysisSeverities = m
+
+	case "styleDiagnosticsInGeneratedFiles":
+		return setBool(&o.StyleDiagnosticsInGeneratedFiles, value)
+
+	case "analysisExclusions":
+		all, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid type %T (want JSON object)", value)
+		}
+		m := make(map[string][]string)
+		for a, patterns := range all {
+			globs, err := asStringSlice(patterns)
+			if err != nil {
+				return fmt.Errorf("invalid value for %q: %v", a, err)
+			}
+			for _, glob := range globs {
+				if err := pathutil.ValidateGlob(glob); err != nil {
+					return err
+				}
+			}
+			m[a] = globs
+		}
+		o.AnalysisExclusions = m
+
+	case "analyzerFlags":
+		flags, err := parseAnalyzerFlags(value)
+		if err != nil {
+			return err
+		}
+		o.AnalyzerFlags = flags
+
+	case "diagnosticsBaseline":
+		return setString(&o.DiagnosticsBaseline, value)
+
+	case "spelling":
+		return setBool(&o.Spelling, value)
+
+	case "spellingDictionary":
+		return setString(&o.SpellingDictionary, value)
+
+	case "clones":
+		return setBool(&o.Clones, value)
+
+	case "interfaceChanges":
+		return setBool(&o.InterfaceChanges, value)
+
+	case "analyzerPlugins":
+		filenames, err := parseAnalyzerPlugins(value)
+		if err != nil {
+			return err
+		}
+		o.AnalyzerPlugins = filenames
+		// Load the plugins now so that errors are reported promptly.
+		if _, err := PluginAnalyzers(filenames); err != nil {
+			return err
+		}
+
+	case "analyzerTools":
+		m, err := parseAnalyzerTools(value)
+		if err != nil {
+			return err
+		}
+		for tool := range m {
+			if _, err := os.Stat(tool); err != nil {
+				return fmt.Errorf("analyzer tool: %v", err)
+			}
+		}
+		o.AnalyzerTools = m
+
 	case "hints":
 		return setBoolMap(&o.Hints, value)
 
@@ -963,6 +1697,19 @@ func (o *Options) set(name string, value any, seen map[string]struct{}) error {
 			ModeVulncheckOff,
 			ModeVulncheckImports)
 
+	case "dependencyUpdates":
+		return setBool(&o.DependencyUpdates, value)
+
+	case "apiCompatibility":
+		var version string
+		if err := setString(&version, value); err != nil {
+			return err
+		}
+		if version != "" && version != "latest" && !semver.IsValid(version) {
+			return fmt.Errorf("invalid module version %q", version)
+		}
+		o.APICompatibility = version
+
 	case "codelenses", "codelens":
 		lensOverrides, err := asBoolMap[CodeLensSource](value)
 		if err != nil {
@@ -977,6 +1724,29 @@ func (o *Options) set(name string, value any, seen map[string]struct{}) error {
 			return deprecatedError("codelenses")
 		}
 
+	case "codeActionExclusions":
+		all, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid type %T (want JSON object)", value)
+		}
+		m := make(map[string][]string)
+		for kind, patterns := range all {
+			if kind == "" {
+				return fmt.Errorf("invalid empty code action kind")
+			}
+			globs, err := asStringSlice(patterns)
+			if err != nil {
+				return fmt.Errorf("invalid value for %q: %v", kind, err)
+			}
+			for _, glob := range globs {
+				if err := pathutil.ValidateGlob(strings.TrimPrefix(glob, "!")); err != nil {
+					return err
+				}
+			}
+			m[kind] = globs
+		}
+		o.CodeActionExclusions = m
+
 	case "staticcheck":
 		v, err := asBool(value)
 		if err != nil {
@@ -991,6 +1761,23 @@ func (o *Options) set(name string, value any, seen map[string]struct{}) error {
 	case "local":
 		return setString(&o.Local, value)
 
+	case "importGroups":
+		groups, err := asStringSlice(value)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool)
+		for _, group := range groups {
+			if group == "" {
+				return fmt.Errorf("empty import group")
+			}
+			if seen[group] {
+				return fmt.Errorf("duplicate import group %q", group)
+			}
+			seen[group] = true
+		}
+		o.ImportGroups = groups
+
 	case "verboseOutput":
 		return setBool(&o.VerboseOutput, value)
 
@@ -1011,6 +1798,59 @@ func (o *Options) set(name string, value any, seen map[string]struct{}) error {
 		}
 		o.Gofumpt = v
 
+	case "formatExclusions":
+		globs, err := asStringSlice(value)
+		if err != nil {
+			return err
+		}
+		for _, glob := range globs {
+			if err := pathutil.ValidateGlob(glob); err != nil {
+				return err
+			}
+		}
+		o.FormatExclusions = globs
+
+	case "formatSkipDirective":
+		v, err := asString(value)
+		if err != nil {
+			return err
+		}
+		if v != "" && !strings.HasPrefix(v, "//") {
+			return fmt.Errorf("invalid directive %q: must be a line comment", v)
+		}
+		o.FormatSkipDirective = v
+
+	case "structTagKeys":
+		keys, err := asStringSlice(value)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if !validStructTagKey(key) {
+				return fmt.Errorf("invalid struct tag key %q", key)
+			}
+		}
+		o.StructTagKeys = keys
+
+	case "structTagCase":
+		return setEnum(&o.StructTagCase, value,
+			SnakeCase,
+			CamelCase,
+			KeepCase)
+
+	case "structTagOmitEmpty":
+		return setBool(&o.StructTagOmitEmpty, value)
+
+	case "dotImportPaths":
+		paths, err := asStringSlice(value)
+		if err != nil {
+			return err
+		}
+		o.DotImportPaths = paths
+
+	case "fillStructDepth":
+		return setInt(&o.FillStructDepth, value, 1, maxFillStructDepth)
+
 	case "completeFunctionCalls":
 		return setBool(&o.CompleteFunctionCalls, value)
 
@@ -1050,12 +1890,58 @@ func (o *Options) set(name string, value any, seen map[string]struct{}) error {
 			DiagnosticsOnEdit,
 			DiagnosticsOnSave)
 
+	case "analysisTriggers":
+		all, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid type %T (want JSON object)", value)
+		}
+		m := make(map[string]string)
+		for source, v := range all {
+			str, err := asString(v)
+			if err != nil {
+				return fmt.Errorf("invalid value for %q: %v", source, err)
+			}
+			if source == "" || strings.HasPrefix(source, "/") || strings.HasSuffix(source, "/") {
+				return fmt.Errorf("invalid diagnostic source %q", source)
+			}
+			switch str {
+			case AnalyzeOnEdit, AnalyzeOnSave, AnalyzeManual:
+			default:
+				if d, err := time.ParseDuration(str); err != nil || d <= 0 {
+					return fmt.Errorf("invalid trigger %q for %q (want Edit, Save, Manual, or a positive duration)", str, source)
+				}
+			}
+			m[source] = str
+		}
+		o.AnalysisTriggers = m
+
 	case "analysisProgressReporting":
 		return setBool(&o.AnalysisProgressReporting, value)
 
+	case "deferTestVariants":
+		return setBool(&o.DeferTestVariants, value)
+
 	case "standaloneTags":
 		return setStringSlice(&o.StandaloneTags, value)
 
+	case "scratchDirectories":
+		dirs, err := asStringSlice(value)
+		if err != nil {
+			return err
+		}
+		var scratch []string
+		for _, dir := range dirs {
+			if filepath.IsAbs(dir) {
+				return fmt.Errorf("invalid scratch directory %q: must be relative to the workspace folder", dir)
+			}
+			dir = path.Clean(filepath.ToSlash(dir))
+			if dir == "." || dir == ".." || strings.HasPrefix(dir, "../") {
+				return fmt.Errorf("invalid scratch directory %q: must be a subdirectory of the workspace folder", dir)
+			}
+			scratch = append(scratch, dir)
+		}
+		o.ScratchDirectories = scratch
+
 	case "subdirWatchPatterns":
 		return setEnum(&o.SubdirWatchPatterns, value,
 			SubdirWatchPatternsOn,
@@ -1065,6 +1951,9 @@ func (o *Options) set(name string, value any, seen map[string]struct{}) error {
 	case "reportAnalysisProgressAfter":
 		return setDuration(&o.ReportAnalysisProgressAfter, value)
 
+	case "staleFileCheckInterval":
+		return setDuration(&o.StaleFileCheckInterval, value)
+
 	case "telemetryPrompt":
 		return setBool(&o.TelemetryPrompt, value)
 
@@ -1206,6 +2095,24 @@ func asBool(value any) (bool, error) {
 	return b, nil
 }
 
+// maxFillStructDepth bounds the fillStructDepth setting, as the
+// literals of recursive types could otherwise grow without limit.
+const maxFillStructDepth = 10
+
+// setInt sets dest to value, a JSON number, which must be an integer
+// in the interval [min, max].
+func setInt(dest *int, value any, min, max int) error {
+	f, ok := value.(float64)
+	if !ok || f != float64(int(f)) {
+		return fmt.Errorf("invalid value %v (want integer)", value)
+	}
+	if n := int(f); n < min || n > max {
+		return fmt.Errorf("invalid value %d (want %d to %d)", n, min, max)
+	}
+	*dest = int(f)
+	return nil
+}
+
 func setDuration(dest *time.Duration, value any) error {
 	str, err := asString(value)
 	if err != nil {
@@ -1329,6 +2236,18 @@ func asStringSlice(value any) ([]string, error) {
 	return slice, nil
 }
 
+// validStructTagKey reports whether key is a valid key of a
+// conventional struct tag: a non-empty string of characters other
+// than control characters, space, quote, and colon.
+func validStructTagKey(key string) bool {
+	for _, r := range key {
+		if r <= ' ' || r == '"' || r == ':' || r == 0x7f {
+			return false
+		}
+	}
+	return key != ""
+}
+
 func setEnum[S ~string](dest *S, value any, options ...S) error {
 	enum, err := asEnum(value, options...)
 	if err != nil {
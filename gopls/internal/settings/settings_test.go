@@ -5,9 +5,13 @@
 package settings
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
+
+	"golang.org/x/tools/gopls/internal/protocol"
 )
 
 func TestDefaultsEquivalence(t *testing.T) {
@@ -160,6 +164,21 @@ func TestSetOption(t *testing.T) {
 				return len(o.DirectoryFilters) == 0
 			},
 		},
+		{
+			name:  "scratchDirectories",
+			value: []any{"scratch/", "tools/play"},
+			check: func(o Options) bool {
+				return reflect.DeepEqual(o.ScratchDirectories, []string{"scratch", "tools/play"})
+			},
+		},
+		{
+			name:      "scratchDirectories",
+			value:     []any{"../outside"},
+			wantError: true,
+			check: func(o Options) bool {
+				return len(o.ScratchDirectories) == 0
+			},
+		},
 		{
 			name: "annotations",
 			value: map[string]any{
@@ -193,6 +212,197 @@ func TestSetOption(t *testing.T) {
 				return o.Vulncheck == ModeVulncheckImports
 			},
 		},
+		{
+			name:  "analysisSeverities",
+			value: map[string]any{"printf": "Error", "shadow": "hint"},
+			check: func(o Options) bool {
+				return o.AnalysisSeverity("printf") == protocol.SeverityError &&
+					o.AnalysisSeverity("shadow") == protocol.SeverityHint &&
+					o.AnalysisSeverity("unusedparams") == 0
+			},
+		},
+		{
+			name:      "analysisSeverities",
+			value:     map[string]any{"printf": "fatal"},
+			wantError: true,
+			check:     func(o Options) bool { return o.AnalysisSeverities == nil },
+		},
+		{
+			name:  "analysisExclusions",
+			value: map[string]any{"*": []any{"gen/**"}, "shadow": []any{"**/*_test.go"}},
+			check: func(o Options) bool {
+				return o.AnalysisExcluded("printf", "gen/a.go") &&
+					o.AnalysisExcluded("shadow", "a/a_test.go") &&
+					!o.AnalysisExcluded("printf", "a/a_test.go")
+			},
+		},
+		{
+			name:      "analysisExclusions",
+			value:     map[string]any{"*": []any{"a/[b"}},
+			wantError: true,
+			check:     func(o Options) bool { return o.AnalysisExclusions == nil },
+		},
+		{
+			name:  "formatExclusions",
+			value: []any{"tables/**"},
+			check: func(o Options) bool {
+				return o.FormatExcluded("tables/a/a.go") && !o.FormatExcluded("a/a.go")
+			},
+		},
+		{
+			name:      "formatExclusions",
+			value:     []any{"a/[b"},
+			wantError: true,
+			check:     func(o Options) bool { return o.FormatExclusions == nil },
+		},
+		{
+			name:  "importGroups",
+			value: []any{"std", "module", "example.com/corp", "other"},
+			check: func(o Options) bool { return len(o.ImportGroups) == 4 && o.ImportGroups[2] == "example.com/corp" },
+		},
+		{
+			name:      "importGroups",
+			value:     []any{"std", "std"},
+			wantError: true,
+			check:     func(o Options) bool { return o.ImportGroups == nil },
+		},
+		{
+			name:  "formatSkipDirective",
+			value: "//fmt:off",
+			check: func(o Options) bool { return o.FormatSkipDirective == "//fmt:off" },
+		},
+		{
+			name:      "formatSkipDirective",
+			value:     "fmt:off",
+			wantError: true,
+			check:     func(o Options) bool { return o.FormatSkipDirective == "" },
+		},
+		{
+			name:  "structTagKeys",
+			value: []any{"json", "db"},
+			check: func(o Options) bool { return len(o.StructTagKeys) == 2 && o.StructTagKeys[1] == "db" },
+		},
+		{
+			name:      "structTagKeys",
+			value:     []any{"json:"},
+			wantError: true,
+			check:     func(o Options) bool { return o.StructTagKeys == nil },
+		},
+		{
+			name:  "structTagCase",
+			value: "camelcase",
+			check: func(o Options) bool { return o.StructTagCase == CamelCase },
+		},
+		{
+			name:      "structTagCase",
+			value:     "kebab-case",
+			wantError: true,
+			check:     func(o Options) bool { return o.StructTagCase == "" },
+		},
+		{
+			name:  "codeActionExclusions",
+			value: map[string]any{"source.organizeImports": []any{"gen/**", "!gen/main.go"}, "*": []any{"testdata/**"}},
+			check: func(o Options) bool {
+				return o.CodeActionExcluded("source.organizeImports", "gen/a.go") &&
+					!o.CodeActionExcluded("source.organizeImports", "gen/main.go") &&
+					!o.CodeActionExcluded("source.fixAll", "gen/a.go") &&
+					o.CodeActionExcluded("refactor.extract.function", "testdata/a.go")
+			},
+		},
+		{
+			name:      "codeActionExclusions",
+			value:     map[string]any{"refactor": []any{"!a/[b"}},
+			wantError: true,
+			check:     func(o Options) bool { return o.CodeActionExclusions == nil },
+		},
+		{
+			name:  "dotImportPaths",
+			value: []any{"github.com/onsi/gomega"},
+			check: func(o Options) bool {
+				return len(o.DotImportPaths) == 1 && o.DotImportPaths[0] == "github.com/onsi/gomega"
+			},
+		},
+		{
+			name:  "fillStructDepth",
+			value: 3.0,
+			check: func(o Options) bool { return o.FillStructDepth == 3 },
+		},
+		{
+			name:      "fillStructDepth",
+			value:     1.5,
+			wantError: true,
+			check:     func(o Options) bool { return o.FillStructDepth == 0 },
+		},
+		{
+			name:      "fillStructDepth",
+			value:     0.0,
+			wantError: true,
+			check:     func(o Options) bool { return o.FillStructDepth == 0 },
+		},
+		{
+			name:      "analyzerPlugins",
+			value:     []any{"relative/plugin.so"},
+			wantError: true,
+			check:     func(o Options) bool { return len(o.AnalyzerPlugins) == 1 },
+		},
+		{
+			name:  "analyzerFlags",
+			value: map[string]any{"printf": map[string]any{"funcs": "logf"}, "shadow": map[string]any{"strict": true}},
+			check: func(o Options) bool {
+				return o.AnalyzerFlags["printf"]["funcs"] == "logf" && o.AnalyzerFlags["shadow"]["strict"] == "true"
+			},
+		},
+		{
+			name:      "analyzerFlags",
+			value:     map[string]any{"nosuchanalyzer": map[string]any{"funcs": "logf"}},
+			wantError: true,
+			check:     func(o Options) bool { return o.AnalyzerFlags == nil },
+		},
+		{
+			name:      "analyzerFlags",
+			value:     map[string]any{"printf": map[string]any{"nosuchflag": "x"}},
+			wantError: true,
+			check:     func(o Options) bool { return o.AnalyzerFlags == nil },
+		},
+		{
+			name:      "analyzerFlags",
+			value:     map[string]any{"shadow": map[string]any{"strict": "maybe"}},
+			wantError: true,
+			check:     func(o Options) bool { return o.AnalyzerFlags == nil },
+		},
+		{
+			name:  "apiCompatibility",
+			value: "latest",
+			check: func(o Options) bool { return o.APICompatibility == "latest" },
+		},
+		{
+			name:      "apiCompatibility",
+			value:     "1.2",
+			wantError: true,
+			check:     func(o Options) bool { return o.APICompatibility == "" },
+		},
+		{
+			name:  "styleDiagnosticsInGeneratedFiles",
+			value: true,
+			check: func(o Options) bool { return o.StyleDiagnosticsInGeneratedFiles },
+		},
+		{
+			name:  "symbolSectionPattern",
+			value: "^// region (.*)$",
+			check: func(o Options) bool { return o.SymbolSectionPattern == "^// region (.*)$" },
+		},
+		{
+			name:      "symbolSectionPattern",
+			value:     "^// (region",
+			wantError: true,
+			check:     func(o Options) bool { return o.SymbolSectionPattern == "" },
+		},
+		{
+			name:      "analyzerTools",
+			value:     map[string]any{"relative/tool": []any{}},
+			wantError: true,
+			check:     func(o Options) bool { return o.AnalyzerTools == nil },
+		},
 	}
 
 	if !StaticcheckSupported {
@@ -225,3 +435,35 @@ func TestSetOption(t *testing.T) {
 		}
 	}
 }
+
+func TestSetWorkspace_AnalyzerTools(t *testing.T) {
+	tool := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(tool, nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	user := map[string]any{tool: []any{"-v"}}
+
+	opts := DefaultOptions()
+	if errs := opts.Set(map[string]any{"analyzerTools": user}); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	// A workspace may not name a tool, which would run its code.
+	errs := opts.SetWorkspace(map[string]any{"analyzerTools": map[string]any{"/tmp/evil": []any{}}})
+	if len(errs) != 1 {
+		t.Errorf("SetWorkspace(analyzerTools) returned %v, want one error", errs)
+	}
+	if _, ok := opts.AnalyzerTools["/tmp/evil"]; ok {
+		t.Errorf("SetWorkspace set AnalyzerTools to %v", opts.AnalyzerTools)
+	}
+
+	// Nor change the flags of the user's tools.
+	if errs := opts.SetWorkspace(map[string]any{"analyzerTools": map[string]any{tool: []any{"-x"}}}); len(errs) != 1 {
+		t.Errorf("SetWorkspace(analyzerTools) with other flags returned %v, want one error", errs)
+	}
+
+	// But it may repeat the user's setting.
+	if errs := opts.SetWorkspace(map[string]any{"analyzerTools": user}); len(errs) > 0 {
+		t.Errorf("SetWorkspace(analyzerTools) with the user's value returned %v", errs)
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The plugin command is an analyzer plugin for TestPluginAnalyzers.
+package main
+
+import "golang.org/x/tools/go/analysis"
+
+var Analyzers = []*analysis.Analyzer{{
+	Name: "plugintest",
+	Doc:  "plugintest is a test analyzer that reports nothing.",
+	Run:  func(*analysis.Pass) (any, error) { return nil, nil },
+}}
@@ -0,0 +1,153 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package spelling finds common misspellings of English words in
+// text such as comments and string literals.
+//
+// Rather than reporting every word absent from a dictionary, which
+// would flag the many identifiers, abbreviations, and terms of art that
+// appear in source code, it reports only the words in a bundled list
+// of frequent misspellings, each with its correction. A project may
+// accept a word (for example, a proper name that coincides with a
+// misspelling) by listing it in a dictionary file.
+package spelling
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed misspellings.txt
+var misspellingsData []byte
+
+// corrections maps each known misspelling to its correction.
+var corrections = parseMisspellings(misspellingsData)
+
+func parseMisspellings(data []byte) map[string]string {
+	m := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		wrong, right, ok := strings.Cut(line, " ")
+		if !ok || wrong != strings.ToLower(wrong) {
+			panic(fmt.Sprintf("invalid misspellings.txt line: %q", line))
+		}
+		m[wrong] = right
+	}
+	return m
+}
+
+// A Misspelling is a misspelled word found by Check.
+type Misspelling struct {
+	Offset     int    // byte offset of the word within the text
+	Word       string // the word as it appears in the text
+	Correction string // the suggested replacement, in the case of Word
+}
+
+// A Dictionary is a set of words, in lower case, that are accepted
+// even if they appear in the list of misspellings.
+type Dictionary map[string]bool
+
+// ParseDictionary parses the contents of a dictionary file, which
+// holds one word per line. Blank lines and lines beginning with '#'
+// are ignored.
+func ParseDictionary(data []byte) Dictionary {
+	dict := make(Dictionary)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		word := strings.TrimSpace(sc.Text())
+		if word != "" && !strings.HasPrefix(word, "#") {
+			dict[strings.ToLower(word)] = true
+		}
+	}
+	return dict
+}
+
+// Check returns the misspelled words in text that are not in dict.
+//
+// A word is a maximal sequence of letters. Words that are adjacent to
+// digits, underscores, or other characters that may form part of an
+// identifier or path (as in "recieve_all" or "seperate.go"), and words
+// in mixed case (as in "recieveAll"), are assumed to be code and are
+// not checked. A backslash and the character that follows it are
+// treated as an escape sequence that separates words.
+func Check(text string, dict Dictionary) []Misspelling {
+	var result []Misspelling
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r == '\\' {
+			i += size
+			if i < len(text) {
+				_, size = utf8.DecodeRuneInString(text[i:])
+				i += size
+			}
+			continue
+		}
+		if !unicode.IsLetter(r) {
+			i += size
+			continue
+		}
+		start := i
+		for i < len(text) {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if !unicode.IsLetter(r) {
+				break
+			}
+			i += size
+		}
+		word := text[start:i]
+		if codeLike(text, start, i) {
+			continue
+		}
+		lower := strings.ToLower(word)
+		correction, ok := corrections[lower]
+		if !ok || dict[lower] {
+			continue
+		}
+		var c string
+		switch word {
+		case lower:
+			c = correction
+		case strings.ToUpper(word):
+			c = strings.ToUpper(correction)
+		case strings.ToUpper(word[:1]) + lower[1:]:
+			c = strings.ToUpper(correction[:1]) + correction[1:]
+		default:
+			continue // mixed case: probably an identifier
+		}
+		result = append(result, Misspelling{Offset: start, Word: word, Correction: c})
+	}
+	return result
+}
+
+// codeLike reports whether the word text[start:end] appears to be part
+// of an identifier, file name, or other non-prose token.
+func codeLike(text string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if r == '_' || r == '.' || r == '/' || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	if end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if r == '_' || unicode.IsDigit(r) {
+			return true
+		}
+		// A dot or slash followed by a letter, as in "foo.go" or
+		// "foo/bar", but not a full stop.
+		if (r == '.' || r == '/') && end+size < len(text) {
+			if next, _ := utf8.DecodeRuneInString(text[end+size:]); unicode.IsLetter(next) {
+				return true
+			}
+		}
+	}
+	return false
+}
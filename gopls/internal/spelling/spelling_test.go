@@ -0,0 +1,32 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spelling
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dict := ParseDictionary([]byte("# accepted words\nWich\n\n"))
+	for _, test := range []struct {
+		text string
+		want []Misspelling
+	}{
+		{"nothing to see here", nil},
+		{"we recieve it", []Misspelling{{3, "recieve", "receive"}}},
+		{"Seperate, SEPERATE", []Misspelling{{0, "Seperate", "Separate"}, {10, "SEPERATE", "SEPARATE"}}},
+		{"recieveAll recieve_all recieve2 seperate.go a/recieve", nil},
+		{"the end, which ends in seperate.", []Misspelling{{23, "seperate", "separate"}}},
+		{`line one\nrecieve`, []Misspelling{{10, "recieve", "receive"}}},
+		{"wich is accepted", nil},
+		{"alot of text", []Misspelling{{0, "alot", "a lot"}}},
+	} {
+		got := Check(test.text, dict)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Check(%q) = %v, want %v", test.text, got, test.want)
+		}
+	}
+}
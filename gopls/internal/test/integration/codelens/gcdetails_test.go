@@ -54,7 +54,7 @@ func main() {
 			Diagnostics(
 				ForFile("main.go"),
 				WithMessage("42 escapes"),
-				WithSeverityTags("optimizer details", protocol.SeverityInformation, nil),
+				WithSeverityTags("compiler/optimizer-details", protocol.SeverityInformation, nil),
 			),
 		)
 
@@ -67,7 +67,7 @@ func f(x int) *int { return &x }`)
 		env.AfterChange(Diagnostics(
 			ForFile("main.go"),
 			WithMessage("x escapes"),
-			WithSeverityTags("optimizer details", protocol.SeverityInformation, nil),
+			WithSeverityTags("compiler/optimizer-details", protocol.SeverityInformation, nil),
 		))
 
 		// Toggle the GC details code lens again so now it should be off.
@@ -0,0 +1,126 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package codelens
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestRunMain(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.go --
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+func main() {
+	out := strings.Join(os.Args[1:], " ") + ";" + os.Getenv("GREETING") + ";" + hello
+	os.WriteFile("out.txt", []byte(out), 0666)
+}
+-- hello.go --
+package main
+
+const hello = "package"
+-- file/main.go --
+package main
+
+import "os"
+
+const hello = "file"
+
+func main() {
+	os.WriteFile("out.txt", []byte(hello), 0666)
+}
+`
+	WithOptions(
+		Settings{"codelenses": map[string]bool{"run": true}},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		env.OpenFile("hello.go")
+
+		// Only the main function is annotated.
+		var lenses []protocol.CodeLens
+		for _, lens := range env.CodeLens("main.go") {
+			if lens.Command.Command == command.RunMain.String() {
+				lenses = append(lenses, lens)
+			}
+		}
+		if len(lenses) != 2 || lenses[0].Command.Title != "run main package" || lenses[1].Command.Title != "run file" {
+			t.Fatalf("got run code lenses %v, want [run main package, run file]", lenses)
+		}
+		if lenses := env.CodeLens("hello.go"); len(lenses) != 0 {
+			t.Errorf("got code lenses %v for a file without main", lenses)
+		}
+
+		runs := uint64(0)
+		run := func(args command.RunMainArgs) string {
+			t.Helper()
+			cmd, err := command.NewRunMainCommand("", args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, nil)
+			runs++
+			env.Await(CompletedWork("Running main package", runs, true))
+			out := "out.txt"
+			if args.File {
+				out = "file/out.txt"
+			}
+			data, err := env.Sandbox.Workdir.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env.RemoveWorkspaceFile(out)
+			return string(data)
+		}
+
+		// The arguments and environment are passed to the program...
+		uri := env.Sandbox.Workdir.URI("main.go")
+		if got, want := run(command.RunMainArgs{URI: uri, Args: []string{"a", "b"}, Env: []string{"GREETING=hi"}}), "a b;hi;package"; got != want {
+			t.Errorf("first run wrote %q, want %q", got, want)
+		}
+
+		// ...and remembered by the code lens.
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   lenses[0].Command.Command,
+			Arguments: lenses[0].Command.Arguments,
+		}, nil)
+		runs++
+		env.Await(CompletedWork("Running main package", runs, true))
+		if data, err := env.Sandbox.Workdir.ReadFile("out.txt"); err != nil {
+			t.Fatal(err)
+		} else if got, want := string(data), "a b;hi;package"; got != want {
+			t.Errorf("code lens run wrote %q, want %q", got, want)
+		}
+		env.RemoveWorkspaceFile("out.txt")
+
+		// Explicit empty arguments replace them.
+		if got, want := run(command.RunMainArgs{URI: uri, Args: []string{}}), ";;package"; got != want {
+			t.Errorf("run without arguments wrote %q, want %q", got, want)
+		}
+		if got, want := run(command.RunMainArgs{URI: uri}), ";;package"; got != want {
+			t.Errorf("remembered run without arguments wrote %q, want %q", got, want)
+		}
+
+		// A single file can be run.
+		if got, want := run(command.RunMainArgs{URI: env.Sandbox.Workdir.URI("file/main.go"), File: true}), "file"; got != want {
+			t.Errorf("file run wrote %q, want %q", got, want)
+		}
+	})
+}
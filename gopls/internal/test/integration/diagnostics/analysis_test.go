@@ -6,8 +6,10 @@ package diagnostics
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
+	"golang.org/x/tools/gopls/internal/baseline"
 	"golang.org/x/tools/gopls/internal/cache"
 	"golang.org/x/tools/gopls/internal/protocol"
 	. "golang.org/x/tools/gopls/internal/test/integration"
@@ -125,3 +127,168 @@ func main() {
 		}
 	})
 }
+
+func TestAnalysisSeveritiesAndExclusions(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "fmt"
+
+func _() {
+	fmt.Printf("%d", "x")
+}
+-- gen/gen.go --
+package gen
+
+import "fmt"
+
+func _() {
+	fmt.Printf("%d", "x")
+}
+`
+	WithOptions(
+		Settings{
+			"analysisSeverities": map[string]any{"printf": "error"},
+			"analysisExclusions": map[string]any{"*": []any{"gen/**"}},
+		},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.OpenFile("gen/gen.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "fmt.Printf"), WithSeverityTags("analyzer/printf", protocol.SeverityError, nil)),
+			NoDiagnostics(ForFile("gen/gen.go")),
+		)
+	})
+}
+
+func TestDiagnosticsBaseline(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "fmt"
+
+func _() {
+	fmt.Printf("%d", "x")
+}
+`
+	WithOptions(
+		Settings{"diagnosticsBaseline": "baseline.json"},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "fmt.Printf")),
+			ReadDiagnostics("a/a.go", &d),
+		)
+
+		// Record the existing finding in the baseline.
+		set := make(baseline.Set)
+		lines := strings.Split(env.BufferText("a/a.go"), "\n")
+		for _, diag := range d.Diagnostics {
+			line := lines[diag.Range.Start.Line]
+			set[baseline.Fingerprint("a/a.go", diag.Source, fmt.Sprint(diag.Code), diag.Message, line)] = true
+		}
+		content, err := baseline.Format(set)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.WriteWorkspaceFile("baseline.json", string(content))
+
+		// Moving the finding to another line does not unsuppress it,
+		// but a new finding is reported.
+		env.RegexpReplace("a/a.go", "func _", "\n\nfunc _")
+		env.RegexpReplace("a/a.go", "}\n$", "\tfmt.Printf(\"%s\", 1)\n}\n")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf\("%s"`)),
+			NoDiagnostics(env.AtRegexp("a/a.go", `fmt.Printf\("%d"`)),
+		)
+	})
+}
+
+// TestDiagnosticsBaseline_Malformed checks that an unreadable baseline
+// file suppresses nothing, rather than preventing analysis diagnostics.
+func TestDiagnosticsBaseline_Malformed(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- baseline.json --
+{not json
+-- a/a.go --
+package a
+
+import "fmt"
+
+func _() {
+	fmt.Printf("%d", "x")
+}
+`
+	WithOptions(
+		Settings{"diagnosticsBaseline": "baseline.json"},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "fmt.Printf")),
+		)
+	})
+}
+
+// TestIncrementalAnalysis checks that the diagnostics of
+// declaration-local analyzers in unchanged functions are preserved,
+// at their new positions, after an edit to another function.
+func TestIncrementalAnalysis(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func F(x []int) []int {
+	return x[1:len(x)]
+}
+
+func G(y []int) []int {
+	return y[1:len(y)]
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "len\\(x\\)"), FromSource("analyzer/simplifyslice")),
+			Diagnostics(env.AtRegexp("a/a.go", "len\\(y\\)"), FromSource("analyzer/simplifyslice")),
+		)
+
+		// Lengthen F: the finding in G moves down.
+		env.RegexpReplace("a/a.go", "return x", "x = append(x, 1)\n\tx = append(x, 2)\n\treturn x")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "len\\(x\\)"), FromSource("analyzer/simplifyslice")),
+			Diagnostics(env.AtRegexp("a/a.go", "len\\(y\\)"), FromSource("analyzer/simplifyslice")),
+		)
+
+		// Fix F: the finding in G remains.
+		env.RegexpReplace("a/a.go", "x\\[1:len\\(x\\)\\]", "x[1:]")
+		env.AfterChange(
+			NoDiagnostics(env.AtRegexp("a/a.go", "x\\[1:\\]")),
+			Diagnostics(env.AtRegexp("a/a.go", "len\\(y\\)"), FromSource("analyzer/simplifyslice")),
+		)
+
+		// Changing the types of the package reanalyzes G.
+		env.RegexpReplace("a/a.go", "func G\\(y \\[\\]int\\) \\[\\]int", "func G(y string) string")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "len\\(y\\)"), FromSource("analyzer/simplifyslice")),
+		)
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
 
 	"golang.org/x/tools/gopls/internal/protocol"
@@ -538,7 +539,11 @@ func _() {
 	}
 }
 `
-	Run(t, generated, func(t *testing.T, env *Env) {
+	// Style diagnostics such as simplifyrange are reported in
+	// generated files only on request.
+	WithOptions(
+		Settings{"styleDiagnosticsInGeneratedFiles": true},
+	).Run(t, generated, func(t *testing.T, env *Env) {
 		env.OpenFile("main.go")
 		var d protocol.PublishDiagnosticsParams
 		env.AfterChange(
@@ -1908,7 +1913,7 @@ const C = 0b10
 	Run(t, files, func(t *testing.T, env *Env) {
 		env.OnceMet(
 			InitialWorkspaceLoad,
-			Diagnostics(env.AtRegexp("main.go", `0b10`), WithMessage("go1.13 or later")),
+			Diagnostics(env.AtRegexp("main.go", `0b10`), WithMessage("requires go1.13 or later, but go.mod declares go 1.12")),
 		)
 		env.WriteWorkspaceFile("go.mod", "module mod.com \n\ngo 1.13\n")
 		env.AfterChange(
@@ -1970,6 +1975,39 @@ func F[T any](_ T) {
 	})
 }
 
+// Test that no quick fix to upgrade the go directive is offered when the
+// file's version is set by a build constraint.
+func TestEditGoDirective_BuildConstraint(t *testing.T) {
+	testenv.NeedsGo1Point(t, 22) // uses range over int
+
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.22
+-- main.go --
+//go:build go1.21
+
+package main
+
+func F() {
+	for range 10 {
+	}
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		var d protocol.PublishDiagnosticsParams
+		env.OnceMet(
+			InitialWorkspaceLoad,
+			Diagnostics(env.AtRegexp("main.go", `10`), WithMessage("requires go1.22 or later")),
+			ReadDiagnostics("main.go", &d),
+		)
+		if fixes := env.GetQuickFixes("main.go", d.Diagnostics); len(fixes) != 0 {
+			t.Errorf("got quick fixes %v, wanted none", fixes)
+		}
+	})
+}
+
 func TestEditGoDirectiveWorkspace(t *testing.T) {
 	const files = `
 -- go.mod --
@@ -2007,6 +2045,78 @@ func F[T any](_ T) {
 	})
 }
 
+func TestEditGoDirectiveCompatibility(t *testing.T) {
+	testenv.NeedsGo1Point(t, 22) // uses range over int
+
+	const files = `
+-- go.work --
+go 1.21
+
+use (
+	./a
+	./b
+	./c
+)
+-- a/go.mod --
+module example.com/a
+
+go 1.21
+
+toolchain go1.21.0
+-- a/a.go --
+package a
+
+func F() {
+	for range 10 {
+	}
+}
+-- b/go.mod --
+module example.com/b
+
+go 1.21
+
+require example.com/a v0.0.0
+-- b/b.go --
+package b
+
+import "example.com/a"
+
+var _ = a.F
+-- c/go.mod --
+module example.com/c
+
+go 1.21
+-- c/c.go --
+package c
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "10"), WithMessage("requires go1.22 or later")),
+			ReadDiagnostics("a/a.go", &d),
+		)
+		env.ApplyQuickFixes("a/a.go", d.Diagnostics)
+		env.AfterChange(NoDiagnostics(ForFile("a/a.go")))
+
+		// The toolchain directive is obsolete, and the module that
+		// requires a and the go.work file must declare go 1.22 too.
+		want := map[string]string{
+			"a/go.mod": "module example.com/a\n\ngo 1.22\n",
+			"b/go.mod": "module example.com/b\n\ngo 1.22\n\nrequire example.com/a v0.0.0\n",
+			"c/go.mod": "module example.com/c\n\ngo 1.21\n",
+		}
+		for name, want := range want {
+			if got := env.ReadWorkspaceFile(name); got != want {
+				t.Errorf("%s after quick fix:\n%s\nwant:\n%s", name, got, want)
+			}
+		}
+		if got := env.ReadWorkspaceFile("go.work"); !strings.HasPrefix(got, "go 1.22\n") {
+			t.Errorf("go.work after quick fix:\n%s\nwant go 1.22", got)
+		}
+	})
+}
+
 // This test demonstrates that analysis facts are correctly propagated
 // across packages.
 func TestInterpackageAnalysis(t *testing.T) {
@@ -2149,7 +2259,7 @@ func (B) New() {}
 			Diagnostics(
 				env.AtRegexp("a/a.go", "new.*Obsolete"),
 				WithMessage("use New instead."),
-				WithSeverityTags("deprecated", protocol.SeverityHint, []protocol.DiagnosticTag{protocol.Deprecated}),
+				WithSeverityTags("analyzer/deprecated", protocol.SeverityHint, []protocol.DiagnosticTag{protocol.Deprecated}),
 			),
 		)
 	})
@@ -54,16 +54,16 @@ const Q = p.P + 1
 			InitialWorkspaceLoad,
 			Diagnostics(
 				env.AtRegexp("a/a.go", "import\n()"),
-				FromSource(string(cache.ParseError)),
+				FromSource(cache.ParseError.Path()),
 			),
 			Diagnostics(
 				AtPosition("c/c.go", 0, 0),
-				FromSource(string(cache.ListError)),
+				FromSource(cache.ListError.Path()),
 				WithMessage("may indicate failure to perform cgo processing"),
 			),
 			Diagnostics(
 				env.AtRegexp("p/p.go", `"a.com/q"`),
-				FromSource(string(cache.ListError)),
+				FromSource(cache.ListError.Path()),
 				WithMessage("import cycle not allowed"),
 			),
 		)
@@ -7,6 +7,7 @@ package diagnostics
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"golang.org/x/tools/gopls/internal/protocol"
 	. "golang.org/x/tools/gopls/internal/test/integration"
@@ -139,3 +140,59 @@ func main() {
 		env.AfterChange(Diagnostics(env.AtRegexp("main.go", "Thing")))
 	})
 }
+
+// TestBurstOfChanges checks that the server ends up with the content of
+// the editor after a burst of changes, which reach it back to back over
+// a slow connection and may be coalesced.
+func TestBurstOfChanges(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.16
+-- main.go --
+package main
+
+func main() {
+}
+`
+	WithOptions(
+		Latency(20*time.Millisecond),
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		env.AfterChange(NoDiagnostics())
+
+		// Type a declaration, one character at a time.
+		insert := func(pos protocol.Position, text string) {
+			for _, r := range text {
+				env.EditBuffer("main.go", protocol.TextEdit{
+					Range:   protocol.Range{Start: pos, End: pos},
+					NewText: string(r),
+				})
+				pos.Character++
+			}
+		}
+		insert(protocol.Position{Line: 3}, "\tvar xyz int\n")
+
+		// Change two lines at once, which sends the full content.
+		env.EditBuffer("main.go",
+			protocol.TextEdit{Range: protocol.Range{Start: protocol.Position{Line: 0, Character: 8}, End: protocol.Position{Line: 0, Character: 12}}, NewText: "main"},
+			protocol.TextEdit{Range: protocol.Range{Start: protocol.Position{Line: 3, Character: 5}, End: protocol.Position{Line: 3, Character: 8}}, NewText: "abc"},
+		)
+		insert(protocol.Position{Line: 4}, "\tvar def string\n")
+
+		want := "package main\n\nfunc main() {\n\tvar abc int\n\tvar def string\n}\n"
+		if got := env.BufferText("main.go"); got != want {
+			t.Fatalf("editor content:\n%s\nwant:\n%s", got, want)
+		}
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("main.go", "abc")),
+			Diagnostics(env.AtRegexp("main.go", "def")),
+			ReadDiagnostics("main.go", &d),
+		)
+		if len(d.Diagnostics) != 2 {
+			t.Errorf("got %d diagnostics, want 2: %v", len(d.Diagnostics), d.Diagnostics)
+		}
+	})
+}
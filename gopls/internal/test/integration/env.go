@@ -6,14 +6,18 @@ package integration
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
 	"golang.org/x/tools/gopls/internal/test/integration/fake"
 	"golang.org/x/tools/internal/jsonrpc2/servertest"
+	"golang.org/x/tools/internal/xcontext"
 )
 
 // Env holds the building blocks of an editor testing environment, providing
@@ -32,6 +36,9 @@ type Env struct {
 	Editor *fake.Editor
 
 	Awaiter *Awaiter
+
+	// awaitTimeout bounds each call to AwaitFunc, if positive.
+	awaitTimeout time.Duration
 }
 
 // An Awaiter keeps track of relevant LSP state, so that it may be asserted
@@ -49,6 +56,10 @@ type Awaiter struct {
 	nextWaiterID int
 	state        State
 	waiters      map[int]*condition
+
+	// partialResults holds the partial results reported for each
+	// partial result token, as JSON values.
+	partialResults map[protocol.ProgressToken][]json.RawMessage
 }
 
 func NewAwaiter(workdir *fake.Workdir) *Awaiter {
@@ -60,10 +71,23 @@ func NewAwaiter(workdir *fake.Workdir) *Awaiter {
 			startedWork:   make(map[string]uint64),
 			completedWork: make(map[string]uint64),
 		},
-		waiters: make(map[int]*condition),
+		waiters:        make(map[int]*condition),
+		partialResults: make(map[protocol.ProgressToken][]json.RawMessage),
 	}
 }
 
+// PartialResults decodes the partial results reported so far for the
+// given partial result token into the slice pointed to by ptr.
+func (a *Awaiter) PartialResults(token protocol.ProgressToken, ptr interface{}) error {
+	a.mu.Lock()
+	data, err := json.Marshal(a.partialResults[token])
+	a.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, ptr)
+}
+
 // Hooks returns LSP client hooks required for awaiting asynchronous expectations.
 func (a *Awaiter) Hooks() fake.ClientHooks {
 	return fake.ClientHooks{
@@ -108,6 +132,23 @@ type workProgress struct {
 	complete           bool // seen 'end'
 }
 
+// Diagnostics returns the most recent diagnostics published for the
+// file with the given relative path, or nil if there are none.
+func (s State) Diagnostics(name string) *protocol.PublishDiagnosticsParams {
+	return s.diagnostics[name]
+}
+
+// Logs returns the log messages received from the server so far.
+func (s State) Logs() []*protocol.LogMessageParams {
+	return s.logs
+}
+
+// CompletedWork returns the number of completed progress reports with
+// the given title.
+func (s State) CompletedWork(title string) uint64 {
+	return s.completedWork[title]
+}
+
 // This method, provided for debugging, accesses mutable fields without a lock,
 // so it must not be called concurrent with any State mutation.
 func (s State) String() string {
@@ -216,6 +257,17 @@ func (a *Awaiter) onProgress(_ context.Context, m *protocol.ProgressParams) erro
 	defer a.mu.Unlock()
 	work, ok := a.state.work[m.Token]
 	if !ok {
+		if batch, ok := m.Value.([]interface{}); ok {
+			// partial results of a request
+			for _, result := range batch {
+				data, err := json.Marshal(result)
+				if err != nil {
+					panic(err)
+				}
+				a.partialResults[m.Token] = append(a.partialResults[m.Token], data)
+			}
+			return nil
+		}
 		panic(fmt.Sprintf("got progress report for unknown report %v: %v", m.Token, m))
 	}
 	v := m.Value.(map[string]interface{})
@@ -304,6 +356,92 @@ func (e *Env) Await(expectations ...Expectation) {
 	}
 }
 
+// AwaitFunc blocks until pred reports true for the current state.
+//
+// Unlike Await, AwaitFunc gives up when ctx is done or after the timeout
+// configured by the AwaitTimeout option, if any, so it may be used to
+// wait for an arbitrary condition instead of sleeping. On failure, it
+// reports the full server logs, the views and their packages, and the
+// outstanding diagnostics, to explain why the condition did not arise.
+//
+// pred is called with the Awaiter's lock held after each change to the
+// state, so it must not call back into the Env.
+func (e *Env) AwaitFunc(ctx context.Context, pred func(State) bool) {
+	e.T.Helper()
+	if e.awaitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.awaitTimeout)
+		defer cancel()
+	}
+	expectation := Expectation{
+		Check: func(s State) Verdict {
+			if pred(s) {
+				return Met
+			}
+			return Unmet
+		},
+		Description: "AwaitFunc predicate",
+	}
+	if err := e.Awaiter.Await(ctx, expectation); err != nil {
+		e.T.Fatalf("%v\n%s", err, e.serverDump())
+	}
+}
+
+// serverDump returns a description of the server state for a failed
+// AwaitFunc: the full text of the server logs, and a summary of each
+// view and its metadata.
+func (e *Env) serverDump() string {
+	var b strings.Builder
+	b.WriteString("#### server logs:\n")
+	e.Awaiter.mu.Lock()
+	for _, msg := range e.Awaiter.state.logs {
+		fmt.Fprintf(&b, "\t%v: %s\n", msg.Type, msg.Message)
+	}
+	e.Awaiter.mu.Unlock()
+
+	b.WriteString("\n#### views:\n")
+	// e.Ctx may be done if the test timed out: query the server
+	// regardless, but don't wait for long.
+	ctx, cancel := context.WithTimeout(xcontext.Detach(e.Ctx), 5*time.Second)
+	defer cancel()
+	views, err := e.viewInfo(ctx)
+	if err != nil {
+		fmt.Fprintf(&b, "\terror: %v\n", err)
+	}
+	for _, v := range views {
+		fmt.Fprintf(&b, "\t%s (%s) %s: %d packages, %d workspace packages\n", v.ID, v.Type, v.Root, v.Packages, v.WorkspacePackages)
+		for _, mod := range v.ModFiles {
+			fmt.Fprintf(&b, "\t\tmodule %s\n", mod)
+		}
+	}
+	return b.String()
+}
+
+// viewInfo returns the descriptions of all views, using the
+// gopls.view_info command.
+func (e *Env) viewInfo(ctx context.Context) ([]command.ViewDescription, error) {
+	cmd, err := command.NewViewInfoCommand("", command.ViewInfoArgs{})
+	if err != nil {
+		return nil, err
+	}
+	response, err := e.Editor.ExecuteCommand(ctx, &protocol.ExecuteCommandParams{
+		Command:   cmd.Command,
+		Arguments: cmd.Arguments,
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var views []command.ViewDescription
+	if err := json.Unmarshal(data, &views); err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
 // OnceMet blocks until the precondition is met by the state or becomes
 // unmeetable. If it was met, OnceMet checks that the state meets all
 // expectations in mustMeets.
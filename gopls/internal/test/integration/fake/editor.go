@@ -16,6 +16,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/protocol/command"
@@ -134,6 +135,13 @@ type EditorConfig struct {
 	// If non-nil, MessageResponder is used to respond to ShowMessageRequest
 	// messages.
 	MessageResponder func(params *protocol.ShowMessageRequestParams) (*protocol.MessageActionItem, error)
+
+	// Latency, if positive, simulates a slow connection to the server:
+	// the editor holds each message to the server for this duration,
+	// then sends it along with all those that accumulated meanwhile,
+	// back to back. Notifications are then sent asynchronously, so
+	// errors sending them are not reported.
+	Latency time.Duration
 }
 
 // NewEditor creates a new Editor.
@@ -158,7 +166,11 @@ func (e *Editor) Connect(ctx context.Context, connector servertest.Connector, ho
 	e.cancelConn = cancelConn
 
 	e.serverConn = conn
-	e.Server = protocol.ServerDispatcher(conn)
+	if latency := e.Config().Latency; latency > 0 {
+		e.Server = protocol.ServerDispatcher(newLatencyConn(conn, latency))
+	} else {
+		e.Server = protocol.ServerDispatcher(conn)
+	}
 	e.client = &Client{editor: e, hooks: hooks}
 	conn.Go(bgCtx,
 		protocol.Handlers(
@@ -973,6 +985,9 @@ func (e *Editor) ApplyCodeAction(ctx context.Context, action protocol.CodeAction
 				return err
 			}
 			action.Edit = ca.Edit
+			if action.Command == nil {
+				action.Command = ca.Command
+			}
 		}
 	}
 
@@ -1345,6 +1360,111 @@ func (e *Editor) SignatureHelp(ctx context.Context, loc protocol.Location) (*pro
 	return e.Server.SignatureHelp(ctx, params)
 }
 
+// SelectionRange returns the selection ranges at the given positions
+// of an open buffer, as returned by the connected LSP server. If no
+// server is connected, it returns (nil, nil).
+func (e *Editor) SelectionRange(ctx context.Context, path string, positions []protocol.Position) ([]protocol.SelectionRange, error) {
+	if e.Server == nil {
+		return nil, nil
+	}
+	if !e.HasBuffer(path) {
+		return nil, fmt.Errorf("buffer %q is not open", path)
+	}
+	params := &protocol.SelectionRangeParams{
+		TextDocument: e.TextDocumentIdentifier(path),
+		Positions:    positions,
+	}
+	return e.Server.SelectionRange(ctx, params)
+}
+
+// PrepareTypeHierarchy returns the type hierarchy items for the type at
+// loc, as returned by the connected LSP server. If no server is
+// connected, it returns (nil, nil).
+func (e *Editor) PrepareTypeHierarchy(ctx context.Context, loc protocol.Location) ([]protocol.TypeHierarchyItem, error) {
+	if e.Server == nil {
+		return nil, nil
+	}
+	if err := e.checkBufferLocation(loc); err != nil {
+		return nil, err
+	}
+	params := &protocol.TypeHierarchyPrepareParams{
+		TextDocumentPositionParams: protocol.LocationTextDocumentPositionParams(loc),
+	}
+	return e.Server.PrepareTypeHierarchy(ctx, params)
+}
+
+// Supertypes returns the supertypes of a type hierarchy item, as
+// returned by the connected LSP server. If no server is connected, it
+// returns (nil, nil).
+func (e *Editor) Supertypes(ctx context.Context, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+	if e.Server == nil {
+		return nil, nil
+	}
+	return e.Server.Supertypes(ctx, &protocol.TypeHierarchySupertypesParams{Item: item})
+}
+
+// Subtypes returns the subtypes of a type hierarchy item, as returned
+// by the connected LSP server. If no server is connected, it returns
+// (nil, nil).
+func (e *Editor) Subtypes(ctx context.Context, item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+	if e.Server == nil {
+		return nil, nil
+	}
+	return e.Server.Subtypes(ctx, &protocol.TypeHierarchySubtypesParams{Item: item})
+}
+
+// Moniker returns the monikers of the symbol at loc, as returned by the
+// connected LSP server. If no server is connected, it returns (nil, nil).
+func (e *Editor) Moniker(ctx context.Context, loc protocol.Location) ([]protocol.Moniker, error) {
+	if e.Server == nil {
+		return nil, nil
+	}
+	if err := e.checkBufferLocation(loc); err != nil {
+		return nil, err
+	}
+	params := &protocol.MonikerParams{
+		TextDocumentPositionParams: protocol.LocationTextDocumentPositionParams(loc),
+	}
+	return e.Server.Moniker(ctx, params)
+}
+
+// InlineValue returns the inline values for the range of loc, when
+// execution has stopped at the end of that range, as returned by the
+// connected LSP server. If no server is connected, it returns (nil, nil).
+func (e *Editor) InlineValue(ctx context.Context, loc protocol.Location) ([]protocol.InlineValue, error) {
+	if e.Server == nil {
+		return nil, nil
+	}
+	if err := e.checkBufferLocation(loc); err != nil {
+		return nil, err
+	}
+	params := &protocol.InlineValueParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: loc.URI},
+		Range:        loc.Range,
+		Context: protocol.InlineValueContext{
+			StoppedLocation: protocol.Range{Start: loc.Range.End, End: loc.Range.End},
+		},
+	}
+	return e.Server.InlineValue(ctx, params)
+}
+
+// WillRenameFiles returns the workspace edit that the connected LSP
+// server would apply before the renaming of oldPath to newPath, without
+// applying it or renaming the file. If no server is connected, it
+// returns (nil, nil).
+func (e *Editor) WillRenameFiles(ctx context.Context, oldPath, newPath string) (*protocol.WorkspaceEdit, error) {
+	if e.Server == nil {
+		return nil, nil
+	}
+	params := &protocol.RenameFilesParams{
+		Files: []protocol.FileRename{{
+			OldURI: string(e.sandbox.Workdir.URI(oldPath)),
+			NewURI: string(e.sandbox.Workdir.URI(newPath)),
+		}},
+	}
+	return e.Server.WillRenameFiles(ctx, params)
+}
+
 func (e *Editor) RenameFile(ctx context.Context, oldPath, newPath string) error {
 	closed, opened, err := e.renameBuffers(oldPath, newPath)
 	if err != nil {
@@ -1457,7 +1577,9 @@ func (e *Editor) applyWorkspaceEdit(ctx context.Context, wsedit *protocol.Worksp
 
 func (e *Editor) applyTextDocumentEdit(ctx context.Context, change protocol.TextDocumentEdit) error {
 	path := e.sandbox.Workdir.URIToPath(change.TextDocument.URI)
-	if ver := int32(e.BufferVersion(path)); ver != change.TextDocument.Version {
+	// Version 0 denotes a file not open in the editor, such as one
+	// created by a preceding CreateFile change.
+	if ver := int32(e.BufferVersion(path)); change.TextDocument.Version != 0 && ver != change.TextDocument.Version {
 		return fmt.Errorf("buffer versions for %q do not match: have %d, editing %d", path, ver, change.TextDocument.Version)
 	}
 	if !e.HasBuffer(path) {
@@ -0,0 +1,94 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"golang.org/x/tools/internal/jsonrpc2"
+)
+
+// A latencyConn is a jsonrpc2.Conn that delays the messages it sends,
+// to simulate a slow connection: it holds the first message for the
+// given latency, then sends it and all messages queued meanwhile back
+// to back, preserving their order.
+type latencyConn struct {
+	jsonrpc2.Conn
+	latency time.Duration
+	queue   chan func()
+}
+
+func newLatencyConn(conn jsonrpc2.Conn, latency time.Duration) *latencyConn {
+	c := &latencyConn{
+		Conn:    conn,
+		latency: latency,
+		queue:   make(chan func(), 1000),
+	}
+	go c.run()
+	return c
+}
+
+// run sends the queued messages until the connection is done.
+func (c *latencyConn) run() {
+	for {
+		select {
+		case send := <-c.queue:
+			time.Sleep(c.latency)
+			send()
+			for n := len(c.queue); n > 0; n-- {
+				(<-c.queue)()
+			}
+		case <-c.Done():
+			return
+		}
+	}
+}
+
+func (c *latencyConn) Call(ctx context.Context, method string, params, result interface{}) (jsonrpc2.ID, error) {
+	var (
+		id   jsonrpc2.ID
+		err  error
+		done = make(chan struct{})
+	)
+	// Messages queued while the call awaits its response are delayed
+	// until it arrives, as the caller is blocked meanwhile anyway.
+	if !c.enqueue(func() {
+		id, err = c.Conn.Call(ctx, method, params, result)
+		close(done)
+	}) {
+		return id, c.Err()
+	}
+	select {
+	case <-done:
+		return id, err
+	case <-c.Done():
+		return id, c.Err()
+	}
+}
+
+func (c *latencyConn) Notify(ctx context.Context, method string, params interface{}) error {
+	// Marshal the parameters now, as the caller may reuse them.
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	if !c.enqueue(func() { c.Conn.Notify(ctx, method, json.RawMessage(data)) }) {
+		return c.Err()
+	}
+	return nil
+}
+
+// enqueue queues a message to be sent, and reports whether it could,
+// as the connection was not done.
+func (c *latencyConn) enqueue(send func()) bool {
+	select {
+	case c.queue <- send:
+		return true
+	case <-c.Done():
+		return false
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+const analysisTriggersSrc = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "fmt"
+
+func _() {
+	fmt.Printf("%d", "s")
+}
+`
+
+func TestAnalysisTriggerManual(t *testing.T) {
+	WithOptions(
+		Settings{"analysisTriggers": map[string]any{"analyzer/printf": "Manual"}},
+	).Run(t, analysisTriggersSrc, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange(
+			NoDiagnostics(ForFile("a/a.go")),
+		)
+
+		cmd, err := command.NewAnalyzeNowCommand("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		}, nil)
+		env.Await(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf`), FromSource("analyzer/printf")),
+		)
+
+		// The diagnostics remain after an edit, until the next pass.
+		env.RegexpReplace("a/a.go", `"s"\)`, `"s") // edited`)
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf`), FromSource("analyzer/printf")),
+		)
+	})
+}
+
+func TestAnalysisTriggerSave(t *testing.T) {
+	WithOptions(
+		Settings{"analysisTriggers": map[string]any{"analyzer": "Save"}},
+	).Run(t, analysisTriggersSrc, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf`), FromSource("analyzer/printf")),
+		)
+
+		// Fixing the call does not remove the diagnostic until the file is saved.
+		env.RegexpReplace("a/a.go", `%d`, `%s`)
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf`), FromSource("analyzer/printf")),
+		)
+		env.SaveBuffer("a/a.go")
+		env.AfterChange(
+			NoDiagnostics(ForFile("a/a.go")),
+		)
+	})
+}
+
+func TestAnalysisTriggerIdle(t *testing.T) {
+	WithOptions(
+		Settings{"analysisTriggers": map[string]any{"analyzer": "Edit", "analyzer/printf": "1s"}},
+	).Run(t, analysisTriggersSrc, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf`), FromSource("analyzer/printf")),
+		)
+
+		// The diagnostic goes away once there have been no changes
+		// for the delay.
+		env.RegexpReplace("a/a.go", `%d`, `%s`)
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf`), FromSource("analyzer/printf")),
+		)
+		env.Await(
+			NoDiagnostics(ForFile("a/a.go")),
+		)
+	})
+}
+
+func TestAnalysisTriggersSetting(t *testing.T) {
+	WithOptions(
+		Settings{"analysisTriggers": map[string]any{"analyzer": "Sometimes"}},
+	).Run(t, analysisTriggersSrc, func(t *testing.T, env *Env) {
+		env.OnceMet(
+			InitialWorkspaceLoad,
+			ShownMessage("invalid trigger"),
+		)
+	})
+}
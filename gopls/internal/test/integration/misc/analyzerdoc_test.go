@@ -0,0 +1,90 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestAnalyzerDoc(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func _(x int) {
+	x = x
+}
+`
+	WithOptions(
+		Settings{"analyzerDocsInHover": true},
+	).Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "x = x"), FromSource("analyzer/assign")),
+			ReadDiagnostics("a/a.go", &d),
+		)
+
+		// The code action shows the complete documentation.
+		var action *protocol.CodeAction
+		for _, a := range env.CodeAction(env.RegexpSearch("a/a.go", "x = x"), d.Diagnostics, 0) {
+			if a.Kind == protocol.GoAnalyzerDoc {
+				a := a
+				action = &a
+			}
+		}
+		if action == nil {
+			t.Fatalf("no %s code action", protocol.GoAnalyzerDoc)
+		}
+		var result command.AnalyzerDocResult
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   action.Command.Command,
+			Arguments: action.Command.Arguments,
+		}, &result)
+		for _, want := range []string{
+			"## `assign`: check for useless assignments",
+			"This checker reports assignments of the form x = x",
+			"### Example",
+			"```go\n",
+			"Package documentation: [assign](https://pkg.go.dev/golang.org/x/tools/go/analysis/passes/assign)",
+		} {
+			if !strings.Contains(result.Markdown, want) {
+				t.Errorf("AnalyzerDoc result does not contain %q:\n%s", want, result.Markdown)
+			}
+		}
+
+		// The hover over the diagnostic shows the brief documentation.
+		content, _ := env.Hover(env.RegexpSearch("a/a.go", "= x"))
+		if content == nil || !strings.Contains(content.Value, "**`assign`**: check for useless assignments") {
+			t.Errorf("hover does not document the assign analyzer: %v", content)
+		} else if strings.Contains(content.Value, "This checker reports") {
+			t.Errorf("hover contains the complete documentation of the assign analyzer:\n%s", content.Value)
+		}
+
+		// An unknown analyzer has no documentation.
+		cmd, err := command.NewAnalyzerDocCommand("", command.AnalyzerDocArgs{
+			URI:      env.Sandbox.Workdir.URI("a/a.go"),
+			Analyzer: "nosuchanalyzer",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		}); err == nil {
+			t.Error("AnalyzerDoc(nosuchanalyzer) succeeded, want error")
+		}
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+	"golang.org/x/tools/internal/testenv"
+)
+
+func TestAnalyzerTools(t *testing.T) {
+	testenv.NeedsGoBuild(t)
+
+	// The badcall tool reports calls to functions documented as BAD,
+	// using facts about the packages that declare them.
+	tool := filepath.Join(t.TempDir(), "badcall")
+	if out, err := exec.Command("go", "build", "-o", tool, "./testdata/badcall").CombinedOutput(); err != nil {
+		t.Fatalf("building analyzer tool: %v\n%s", err, out)
+	}
+
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+// F is BAD.
+func F() {}
+-- b/b.go --
+package b
+
+import "mod.com/a"
+
+func _() {
+	a.F()
+}
+`
+	WithOptions(
+		Settings{"analyzerTools": map[string]any{tool: []any{}}},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("b/b.go")
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(
+				env.AtRegexp("b/b.go", "F"),
+				WithMessage("call of bad function F"),
+				FromSource("analyzer/badcall"),
+			),
+			ReadDiagnostics("b/b.go", &d),
+		)
+
+		// Unsaved packages are not analyzed by tools.
+		env.RegexpReplace("b/b.go", "a.F\\(\\)", "a.F()\n\ta.F()")
+		env.AfterChange(NoDiagnostics(ForFile("b/b.go")))
+		env.SaveBuffer("b/b.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("b/b.go", "F\\(\\)\n"), FromSource("analyzer/badcall")),
+		)
+
+		// The suggested fixes of tools are available.
+		env.RegexpReplace("b/b.go", "\n\ta.F\\(\\)\n}", "\n}")
+		env.SaveBuffer("b/b.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("b/b.go", "F"), FromSource("analyzer/badcall")),
+			ReadDiagnostics("b/b.go", &d),
+		)
+		env.ApplyQuickFixes("b/b.go", d.Diagnostics)
+		env.SaveBuffer("b/b.go")
+		env.AfterChange(NoDiagnostics(ForFile("b/b.go")))
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+const apiProxy = `
+-- example.com/api@v1.0.0/go.mod --
+module example.com/api
+
+go 1.18
+-- example.com/api@v1.0.0/api.go --
+package api
+
+func Parse(s string) int { return len(s) }
+
+func Format(n int) string { return "" }
+
+type Config struct {
+	Name string
+	Size int
+}
+-- example.com/api@v1.0.0/internal/impl/impl.go --
+package impl
+
+func Helper() {}
+-- example.com/api@v1.1.0/go.mod --
+module example.com/api
+
+go 1.18
+-- example.com/api@v1.1.0/api.go --
+package api
+
+func Parse(s string) int { return len(s) }
+
+func Format(n int) string { return "" }
+
+type Config struct {
+	Name string
+	Size int
+}
+
+func Added() {}
+`
+
+func TestAPICompatibility(t *testing.T) {
+	const files = `
+-- go.mod --
+module example.com/api
+
+go 1.18
+-- api.go --
+package api
+
+func Parse(s []byte) int { return len(s) }
+
+type Config struct {
+	Name string
+}
+
+func Added() {}
+-- internal/impl/impl.go --
+package impl
+`
+	for _, version := range []string{"v1.0.0", "latest"} {
+		t.Run(version, func(t *testing.T) {
+			WithOptions(
+				ProxyFiles(apiProxy),
+				Settings{"apiCompatibility": version},
+			).Run(t, files, func(t *testing.T, env *Env) {
+				env.OpenFile("api.go")
+				want := "v1.0.0"
+				if version == "latest" {
+					want = "v1.1.0"
+				}
+				env.AfterChange(
+					Diagnostics(
+						env.AtRegexp("api.go", "Parse"),
+						WithMessage("breaking change since "+want+": Parse: changed from func(string) int to func([]byte) int"),
+						WithSeverityTags("gopls/api-compatibility", protocol.SeverityWarning, nil),
+					),
+					// A removed function is reported on the package clause.
+					Diagnostics(env.AtRegexp("api.go", "package (api)"), WithMessage("Format: removed")),
+					Diagnostics(env.AtRegexp("api.go", "Config"), WithMessage("Config.Size: removed")),
+					NoDiagnostics(ForFile("internal/impl/impl.go")),
+				)
+
+				// Restoring the API resolves the diagnostics.
+				env.RegexpReplace("api.go", `\[\]byte`, "string")
+				env.AfterChange(
+					NoDiagnostics(env.AtRegexp("api.go", "Parse")),
+					Diagnostics(env.AtRegexp("api.go", "package (api)"), WithMessage("Format: removed")),
+				)
+			})
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+	"time"
+
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestAwaitFunc(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func _() {
+	x := 1
+}
+`
+	WithOptions(
+		AwaitTimeout(30*time.Second),
+	).Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AwaitFunc(env.Ctx, func(s State) bool {
+			d := s.Diagnostics("a/a.go")
+			return d != nil && len(d.Diagnostics) == 1
+		})
+		env.RegexpReplace("a/a.go", "x := 1", "_ = 1")
+		env.AwaitFunc(env.Ctx, func(s State) bool {
+			d := s.Diagnostics("a/a.go")
+			return d != nil && len(d.Diagnostics) == 0
+		})
+	})
+}
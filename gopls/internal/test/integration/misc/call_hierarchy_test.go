@@ -34,3 +34,57 @@ package pkg
 		env.Editor.Server.PrepareCallHierarchy(env.Ctx, &params)
 	})
 }
+
+func TestOutgoingDynamicCalls(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a.go --
+package a
+
+type Shape interface{ Area() float64 }
+
+type Square struct{}
+
+func (Square) Area() float64 { return 0 }
+
+func Total(s Shape) float64 {
+	return s.Area()
+}
+`
+	WithOptions(
+		Settings{"dynamicCalls": true},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a.go")
+		items, err := env.Editor.Server.PrepareCallHierarchy(env.Ctx, &protocol.CallHierarchyPrepareParams{
+			TextDocumentPositionParams: protocol.LocationTextDocumentPositionParams(env.RegexpSearch("a.go", "Total")),
+		})
+		if err != nil || len(items) != 1 {
+			t.Fatalf("PrepareCallHierarchy returned %v, %v; want one item", items, err)
+		}
+		calls, err := env.Editor.Server.OutgoingCalls(env.Ctx, &protocol.CallHierarchyOutgoingCallsParams{Item: items[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The call leads to the interface method (statically)
+		// and to Square.Area (dynamically).
+		got := make(map[protocol.Location]string)
+		for _, call := range calls {
+			got[protocol.Location{URI: call.To.URI, Range: call.To.Range}] = call.To.Detail
+		}
+		want := map[protocol.Location]string{
+			env.RegexpSearch("a.go", `(Area)\(\) float64 }`): "mod.com • a.go",
+			env.RegexpSearch("a.go", `(Area)\(\) float64 {`): "dynamic • mod.com • a.go",
+		}
+		if len(got) != len(want) {
+			t.Errorf("OutgoingCalls returned %v, want %v", got, want)
+		}
+		for loc, detail := range want {
+			if got[loc] != detail {
+				t.Errorf("OutgoingCalls: detail of call to %v is %q, want %q", loc, got[loc], detail)
+			}
+		}
+	})
+}
@@ -0,0 +1,210 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/gopls/internal/test/compare"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+// changeSignature executes the change signature command for the function
+// named by re in the given file, returning its error.
+func changeSignature(env *Env, path, re string, newParams []command.ChangeSignatureParam) error {
+	cmd, err := command.NewChangeSignatureCommand("", command.ChangeSignatureArgs{
+		Function:  env.RegexpSearch(path, re),
+		NewParams: newParams,
+	})
+	if err != nil {
+		env.T.Fatal(err)
+	}
+	_, err = env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+		Command:   cmd.Command,
+		Arguments: cmd.Arguments,
+	})
+	return err
+}
+
+func TestChangeSignatureAcrossModules(t *testing.T) {
+	const src = `
+-- go.work --
+go 1.18
+
+use (
+	./a
+	./b
+)
+-- a/go.mod --
+module example.com/a
+
+go 1.18
+-- a/a.go --
+package a
+
+// F formats x and y.
+func F(x int, y string) string {
+	return y + string(rune('0'+x))
+}
+
+func G() string { return F(1, "a") }
+-- b/go.mod --
+module example.com/b
+
+go 1.18
+
+require example.com/a v0.0.0
+-- b/b.go --
+package b
+
+import "example.com/a"
+
+var _ = a.F(2, "b")
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		err := changeSignature(env, "a/a.go", `func (F)`, []command.ChangeSignatureParam{
+			{OldIndex: 1},
+			{Name: "sep", Type: "string", Default: `"-"`},
+			{OldIndex: 0},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		const wantA = `package a
+
+// F formats x and y.
+func F(y string, sep string, x int) string {
+	return y + string(rune('0'+x))
+}
+
+func G() string { return F("a", "-", 1) }
+`
+		const wantB = `package b
+
+import "example.com/a"
+
+var _ = a.F("b", "-", 2)
+`
+		for path, want := range map[string]string{
+			"a/a.go": wantA,
+			"b/b.go": wantB,
+		} {
+			if got := env.BufferText(path); got != want {
+				t.Errorf("%s after change: unexpected content:\n%s", path, compare.Text(want, got))
+			}
+		}
+	})
+}
+
+func TestChangeSignaturePrompt(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func F(a, b int, c string) string { return c }
+
+var _ = F(1, 2, "c")
+`
+	var choices []string // remaining choices, in order
+	respond := func(params *protocol.ShowMessageRequestParams) (*protocol.MessageActionItem, error) {
+		if len(choices) == 0 {
+			return nil, nil
+		}
+		for _, item := range params.Actions {
+			if item.Title == choices[0] {
+				choices = choices[1:]
+				return &item, nil
+			}
+		}
+		t.Errorf("no choice %q in %v", choices[0], params.Actions)
+		return nil, nil
+	}
+	WithOptions(
+		MessageResponder(respond),
+	).Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		choices = []string{"Move #3 (c string) earlier", "Remove #3 (b int)", "Apply"}
+		loc := env.RegexpSearch("a/a.go", `func (F)`)
+		var found bool
+		for _, action := range env.CodeAction(loc, nil, protocol.CodeActionInvoked) {
+			if action.Title == "Refactor: change signature..." {
+				env.ApplyCodeAction(action)
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("no code action to change the signature of F")
+		}
+		if len(choices) > 0 {
+			t.Errorf("choices not offered: %v", choices)
+		}
+		const want = `package a
+
+func F(a int, c string) string { return c }
+
+var _ = F(1, "c")
+`
+		if got := env.BufferText("a/a.go"); got != want {
+			t.Errorf("after change: unexpected content:\n%s", compare.Text(want, got))
+		}
+	})
+}
+
+func TestChangeSignatureConflicts(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+var x = 0
+
+func F(a int) int { return a + x }
+
+var f = F
+
+var _ = F(1)
+-- b/b.go --
+package b
+
+import "example.com/a"
+
+var g = a.F
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+
+		// Both references to F as a value are reported.
+		err := changeSignature(env, "a/a.go", `func (F)`, []command.ChangeSignatureParam{
+			{OldIndex: 0},
+			{Name: "b", Type: "int", Default: "0"},
+		})
+		for _, want := range []string{"a.go:7:9: found non-call function reference", "b.go:5:11: found non-call function reference"} {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("changing signature: got error %v, want %q", err, want)
+			}
+		}
+
+		// A new parameter must not shadow a name used by the body.
+		err = changeSignature(env, "a/a.go", `func (F)`, []command.ChangeSignatureParam{
+			{OldIndex: 0},
+			{Name: "x", Type: "int", Default: "0"},
+		})
+		if want := "new parameter x would shadow the x referenced"; err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("changing signature: got error %v, want %q", err, want)
+		}
+	})
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestClones(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func Sum(xs []int) (total int) {
+	for i, x := range xs {
+		if i%2 == 0 && x > 0 {
+			total += x * 2
+		} else {
+			total -= x
+		}
+	}
+	return total
+}
+-- b/b.go --
+package b
+
+type T struct{}
+
+func (T) Product(values []int) (acc int) {
+	for j, v := range values {
+		if j%3 == 0 && v > 1 {
+			acc += v * 5
+		} else {
+			acc -= v
+		}
+	}
+	return acc
+}
+
+func unique(values []int) (acc int) {
+	for j, v := range values {
+		if j%3 == 0 && v > 1 {
+			acc += v * 5
+		} else {
+			acc -= j
+		}
+	}
+	return acc
+}
+`
+	WithOptions(
+		Settings{"clones": true},
+	).Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "Sum"), WithMessage("Sum duplicates T.Product"), WithSeverityTags("gopls/clones", protocol.SeverityHint, nil)),
+			Diagnostics(env.AtRegexp("b/b.go", "Product"), WithMessage("T.Product duplicates Sum")),
+			NoDiagnostics(env.AtRegexp("b/b.go", "unique")),
+			ReadDiagnostics("a/a.go", &d),
+		)
+		if len(d.Diagnostics) != 1 || len(d.Diagnostics[0].RelatedInformation) != 1 ||
+			d.Diagnostics[0].RelatedInformation[0].Location != env.RegexpSearch("b/b.go", "Product") {
+			t.Errorf("got diagnostics %v, want one related to T.Product", d.Diagnostics)
+		}
+
+		// The list of clones.
+		cmd, err := command.NewListClonesCommand("", command.URIArg{URI: env.Sandbox.Workdir.URI("a/a.go")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result command.ListClonesResult
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		}, &result)
+		if len(result.Clusters) != 1 || len(result.Clusters[0].Clones) != 2 ||
+			result.Clusters[0].Clones[0].Name != "Sum" || result.Clusters[0].Clones[1].Name != "T.Product" {
+			t.Errorf("ListClones returned %+v, want one cluster of Sum and T.Product", result)
+		}
+
+		// The code action jumps to the next clone.
+		var action *protocol.CodeAction
+		for _, a := range env.CodeAction(env.RegexpSearch("a/a.go", "Sum"), d.Diagnostics, 0) {
+			if a.Kind == protocol.GoClones {
+				a := a
+				action = &a
+			}
+		}
+		if action == nil {
+			t.Fatalf("no %s code action", protocol.GoClones)
+		}
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   action.Command.Command,
+			Arguments: action.Command.Arguments,
+		}, nil)
+		var shown []*protocol.ShowDocumentParams
+		env.OnceMet(
+			ShownDocument(protocol.URI(env.Sandbox.Workdir.URI("b/b.go"))),
+			ShownDocuments(&shown),
+		)
+		if want := env.RegexpSearch("b/b.go", "Product").Range; shown[0].Selection == nil || *shown[0].Selection != want {
+			t.Errorf("showDocument selection = %v, want %v", shown[0].Selection, want)
+		}
+
+		// Edits that remove the duplication remove the hints.
+		env.RegexpReplace("a/a.go", "total -= x", "total -= x + 1")
+		env.AfterChange(
+			NoDiagnostics(ForFile("a/a.go")),
+			NoDiagnostics(ForFile("b/b.go")),
+		)
+	})
+}
@@ -16,7 +16,7 @@ import (
 
 // This test exercises the filtering of code actions in generated files.
 // Most code actions, being potential edits, are discarded, but
-// some (GoTest, GoDoc) are pure queries, and so are allowed.
+// some (GoTest, GoDoc, GoDeepAnalysis) are pure queries, and so are allowed.
 func TestCodeActionsInGeneratedFiles(t *testing.T) {
 	const src = `
 -- go.mod --
@@ -64,12 +64,16 @@ func g() {}
 
 		check("src/a.go",
 			protocol.GoAssembly,
+			protocol.GoDeadCode,
+			protocol.GoDeepAnalysis,
 			protocol.GoDoc,
 			protocol.GoFreeSymbols,
 			protocol.RefactorExtract,
 			protocol.RefactorInline)
 		check("gen/a.go",
 			protocol.GoAssembly,
+			protocol.GoDeadCode,
+			protocol.GoDeepAnalysis,
 			protocol.GoDoc,
 			protocol.GoFreeSymbols)
 	})
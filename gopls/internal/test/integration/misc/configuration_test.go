@@ -251,3 +251,46 @@ func TestDeprecatedSettings(t *testing.T) {
 		)
 	})
 }
+
+func TestAnalyzerFlags(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func _() {
+	x := 1
+	_ = x
+	if true {
+		x := 2 // reported only in strict mode
+		_ = x
+	}
+}
+`
+	WithOptions(
+		Settings{"analyses": map[string]any{"shadow": true}},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange(
+			NoDiagnostics(ForFile("a/a.go")),
+		)
+		cfg := env.Editor.Config()
+		cfg.Settings["analyzerFlags"] = map[string]any{
+			"shadow": map[string]any{"strict": true},
+		}
+		env.ChangeConfiguration(cfg)
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "x := 2"), FromSource("analyzer/shadow")),
+		)
+		cfg.Settings["analyzerFlags"] = map[string]any{
+			"shadow": map[string]any{"strict": false},
+		}
+		env.ChangeConfiguration(cfg)
+		env.AfterChange(
+			NoDiagnostics(ForFile("a/a.go")),
+		)
+	})
+}
@@ -0,0 +1,265 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+	"golang.org/x/tools/gopls/internal/test/compare"
+)
+
+func TestGenerateConstructorPrompt(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "time"
+
+type Server struct {
+	addr    string
+	Type    int
+	timeout time.Duration
+}
+`
+	var choices []string // remaining choices, in order
+	respond := func(params *protocol.ShowMessageRequestParams) (*protocol.MessageActionItem, error) {
+		if len(choices) == 0 {
+			return nil, nil
+		}
+		for _, item := range params.Actions {
+			if item.Title == choices[0] {
+				choices = choices[1:]
+				return &item, nil
+			}
+		}
+		t.Errorf("no choice %q in %v", choices[0], params.Actions)
+		return nil, nil
+	}
+	WithOptions(
+		MessageResponder(respond),
+	).Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		choices = []string{"Exclude timeout", "Apply"}
+		loc := env.RegexpSearch("a/a.go", `type (Server)`)
+		var found bool
+		for _, action := range env.CodeAction(loc, nil, protocol.CodeActionInvoked) {
+			if action.Title == "Generate constructor for Server..." {
+				env.ApplyCodeAction(action)
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("no code action to generate a constructor for Server")
+		}
+		if len(choices) > 0 {
+			t.Errorf("choices not offered: %v", choices)
+		}
+		const want = `package a
+
+import "time"
+
+type Server struct {
+	addr    string
+	Type    int
+	timeout time.Duration
+}
+
+// NewServer returns a new Server.
+func NewServer(addr string, Type int) *Server {
+	return &Server{
+		addr: addr,
+		Type: Type,
+	}
+}
+`
+		if got := env.BufferText("a/a.go"); got != want {
+			t.Errorf("after generation: unexpected content:\n%s", compare.Text(want, got))
+		}
+
+		// Now that there is a constructor, none is offered.
+		for _, action := range env.CodeAction(loc, nil, protocol.CodeActionInvoked) {
+			if action.Kind == protocol.RefactorRewrite && action.Command != nil &&
+				action.Command.Command == command.GenerateConstructor.String() {
+				t.Errorf("unexpected code action %q", action.Title)
+			}
+		}
+	})
+}
+
+func TestGenerateConstructorOptions(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "time"
+
+type Server struct {
+	addr    string
+	timeout time.Duration
+	*Logger
+}
+
+type Logger struct{}
+
+type pair[K comparable, V any] struct {
+	key K
+	val V
+}
+-- b/b.go --
+package b
+
+type Client struct {
+	retries int
+}
+
+// Existing options are named after the types they configure.
+type Conn struct{ port int }
+
+type ConnOption func(*Conn)
+
+func WithConnPort(port int) ConnOption {
+	return func(c *Conn) { c.port = port }
+}
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.OpenFile("b/b.go")
+		generate := func(loc protocol.Location, options bool, fields ...string) error {
+			cmd, err := command.NewGenerateConstructorCommand("", command.GenerateConstructorArgs{
+				Location: loc,
+				Fields:   append([]string{}, fields...), // non-nil
+				Options:  options,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			})
+			return err
+		}
+
+		server := env.RegexpSearch("a/a.go", `type (Server)`)
+		if err := generate(server, true, "missing"); err == nil {
+			t.Errorf("GenerateConstructor(missing) succeeded, want error")
+		}
+		if err := generate(server, true, "addr"); err != nil {
+			t.Fatal(err)
+		}
+		if err := generate(env.RegexpSearch("a/a.go", `type (pair)`), false, "key", "val"); err != nil {
+			t.Fatal(err)
+		}
+		const wantA = `package a
+
+import "time"
+
+type Server struct {
+	addr    string
+	timeout time.Duration
+	*Logger
+}
+
+// NewServer returns a new Server configured by opts.
+func NewServer(addr string, opts ...Option) *Server {
+	s := &Server{
+		addr: addr,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// An Option configures a Server created by NewServer.
+type Option func(*Server)
+
+// WithTimeout returns an option that sets the timeout field of a Server.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = timeout
+	}
+}
+
+// WithLogger returns an option that sets the Logger field of a Server.
+func WithLogger(logger *Logger) Option {
+	return func(s *Server) {
+		s.Logger = logger
+	}
+}
+
+type Logger struct{}
+
+type pair[K comparable, V any] struct {
+	key K
+	val V
+}
+
+// newPair returns a new pair.
+func newPair[K comparable, V any](key K, val V) *pair[K, V] {
+	return &pair[K, V]{
+		key: key,
+		val: val,
+	}
+}
+`
+		if got := env.BufferText("a/a.go"); got != wantA {
+			t.Errorf("after generation: unexpected content of a.go:\n%s", compare.Text(wantA, got))
+		}
+
+		// The names follow the pattern of the existing options.
+		if err := generate(env.RegexpSearch("b/b.go", `type (Client)`), true); err != nil {
+			t.Fatal(err)
+		}
+		const wantB = `package b
+
+type Client struct {
+	retries int
+}
+
+// NewClient returns a new Client configured by opts.
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// A ClientOption configures a Client created by NewClient.
+type ClientOption func(*Client)
+
+// WithClientRetries returns an option that sets the retries field of a Client.
+func WithClientRetries(retries int) ClientOption {
+	return func(c *Client) {
+		c.retries = retries
+	}
+}
+
+// Existing options are named after the types they configure.
+type Conn struct{ port int }
+
+type ConnOption func(*Conn)
+
+func WithConnPort(port int) ConnOption {
+	return func(c *Conn) { c.port = port }
+}
+`
+		if got := env.BufferText("b/b.go"); got != wantB {
+			t.Errorf("after generation: unexpected content of b.go:\n%s", compare.Text(wantB, got))
+		}
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/server"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestFindDeadCode(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- main.go --
+package main
+
+import (
+	"fmt"
+
+	"example.com/a"
+)
+
+func main() {
+	fmt.Println(a.Used(), a.T{})
+}
+
+func unused() {}
+-- a/a.go --
+package a
+
+func Used() int { return helper() }
+
+func helper() int { return 1 }
+
+func Unused() {}
+
+// Kept is part of the API.
+//
+//gopls:keep
+func Kept() {}
+
+type T struct{}
+
+func (T) String() string { return "T" }
+
+func (T) dead() {}
+
+// K is part of the API.
+//
+//gopls:keep
+type K struct{}
+
+func (K) M() {}
+
+func tested() {}
+-- a/a_test.go --
+package a
+
+import "testing"
+
+func TestTested(t *testing.T) { tested() }
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange(NoDiagnostics(ForFile("a/a.go")))
+
+		findDeadCode := func() {
+			t.Helper()
+			var action *protocol.CodeAction
+			for _, a := range env.CodeActionForFile("a/a.go", nil) {
+				if a.Kind == protocol.GoDeadCode {
+					a := a
+					action = &a
+				}
+			}
+			if action == nil {
+				t.Fatalf("no %s code action", protocol.GoDeadCode)
+			}
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   action.Command.Command,
+				Arguments: action.Command.Arguments,
+			}, nil)
+		}
+
+		findDeadCode()
+		var diags protocol.PublishDiagnosticsParams
+		env.OnceMet(
+			CompletedWork(server.DiagnosticWorkTitle(server.FromFindDeadCode), 1, true),
+			Diagnostics(env.AtRegexp("main.go", "unused"), WithMessage("function unused is unreachable")),
+			Diagnostics(env.AtRegexp("a/a.go", "Unused"), WithMessage("function Unused is unreachable")),
+			Diagnostics(env.AtRegexp("a/a.go", "dead"), WithMessage("method T.dead is unreachable")),
+			ReadDiagnostics("a/a.go", &diags),
+		)
+		if got := len(diags.Diagnostics); got != 2 {
+			t.Errorf("got %d diagnostics in a/a.go, want 2 (Unused, T.dead): %v", got, diags.Diagnostics)
+		}
+
+		// The quick fix keeps the function.
+		var unusedDiag []protocol.Diagnostic
+		for _, d := range diags.Diagnostics {
+			if d.Message == "function Unused is unreachable from main functions and tests" {
+				unusedDiag = append(unusedDiag, d)
+			}
+		}
+		env.ApplyQuickFixes("a/a.go", unusedDiag)
+
+		// Edits discard the results.
+		env.AfterChange(NoDiagnostics(ForFile("a/a.go")))
+
+		// The analysis sees unsaved edits.
+		findDeadCode()
+		env.OnceMet(
+			CompletedWork(server.DiagnosticWorkTitle(server.FromFindDeadCode), 2, true),
+			NoDiagnostics(env.AtRegexp("a/a.go", "Unused")),
+			Diagnostics(env.AtRegexp("a/a.go", "dead")),
+		)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/server"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestDeepAnalysis(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "os"
+
+type T struct{ f int }
+
+func get(t *T) int { return t.f }
+
+func indirect(t *T) int { return get(t) }
+
+func checked(t *T) int {
+	if t == nil {
+		return 0
+	}
+	return t.f
+}
+
+func remove(name string) error { return os.Remove(name) }
+
+func Never() error { return nil }
+
+func _() {
+	indirect(nil)
+	checked(nil)
+	remove("x")
+	Never()
+}
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange(NoDiagnostics(ForFile("a/a.go")))
+
+		// Run the deep analysis through its code action.
+		var action *protocol.CodeAction
+		for _, a := range env.CodeActionForFile("a/a.go", nil) {
+			if a.Kind == protocol.GoDeepAnalysis {
+				a := a
+				action = &a
+			}
+		}
+		if action == nil {
+			t.Fatalf("no %s code action", protocol.GoDeepAnalysis)
+		}
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   action.Command.Command,
+			Arguments: action.Command.Arguments,
+		}, nil)
+
+		var diags protocol.PublishDiagnosticsParams
+		env.OnceMet(
+			CompletedWork(server.DiagnosticWorkTitle(server.FromDeepAnalysis), 1, true),
+			Diagnostics(env.AtRegexp("a/a.go", `indirect\(nil\)`), WithMessage("nil passed for parameter t of indirect")),
+			Diagnostics(env.AtRegexp("a/a.go", `remove\("x"\)`), WithMessage("error result of remove is ignored")),
+			ReadDiagnostics("a/a.go", &diags),
+		)
+		if got := len(diags.Diagnostics); got != 2 {
+			t.Errorf("got %d diagnostics, want 2 (none for checked and Never): %v", got, diags.Diagnostics)
+		}
+		for _, diag := range diags.Diagnostics {
+			if len(diag.RelatedInformation) == 0 {
+				t.Errorf("diagnostic %q has no explanation", diag.Message)
+			}
+		}
+		// The explanation of the nil dereference follows the calls.
+		var got []string
+		for _, rel := range diags.Diagnostics[0].RelatedInformation {
+			got = append(got, rel.Message)
+		}
+		want := []string{"indirect passes t to parameter t of get", "get dereferences t"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("explanation of %q is %q, want %q", diags.Diagnostics[0].Message, got, want)
+		}
+
+		// The results are discarded by the next edit.
+		env.RegexpReplace("a/a.go", `checked\(nil\)`, "checked(&T{})")
+		env.AfterChange(NoDiagnostics(ForFile("a/a.go")))
+	})
+}
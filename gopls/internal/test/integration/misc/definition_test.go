@@ -5,12 +5,14 @@
 package misc
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/test/compare"
 	. "golang.org/x/tools/gopls/internal/test/integration"
@@ -595,3 +597,114 @@ func _(err error) {
 		}
 	})
 }
+
+func TestDefinitionOfAlias(t *testing.T) {
+	const src = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- c/c.go --
+package c
+
+type C struct{}
+-- b/b.go --
+package b
+
+import "mod.com/c"
+
+type B = c.C
+-- a.go --
+package a
+
+import "mod.com/b"
+
+type A = b.B
+
+var _ A
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a.go")
+
+		loc := env.RegexpSearch("a.go", `_ (A)`)
+		params := &protocol.DefinitionParams{}
+		params.TextDocument.URI = loc.URI
+		params.Position = loc.Range.Start
+		locs, err := env.Editor.Server.Definition(env.Ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, loc := range locs {
+			got = append(got, fmt.Sprintf("%s:%d", filepath.ToSlash(env.Sandbox.Workdir.URIToPath(loc.URI)), loc.Range.Start.Line))
+		}
+		want := []string{"a.go:4", "c/c.go:2"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Definition(A) mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestDefinitionOfBodylessFunc(t *testing.T) {
+	const src = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import _ "unsafe"
+
+func Add(x, y int) int
+
+//go:linkname now mod.com/b.now
+func now() int64
+
+var _ = Add(1, 2) + int(now())
+-- a/add_amd64.s --
+#include "textflag.h"
+
+TEXT ·Add(SB),NOSPLIT,$0-24
+	RET
+-- a/add_arm64.s --
+TEXT mod.com∕a·Add<ABIInternal>(SB),$0
+	RET
+-- b/b.go --
+package b
+
+func now() int64 { return 0 }
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		definitions := func(re string) []string {
+			loc := env.RegexpSearch("a/a.go", re)
+			params := &protocol.DefinitionParams{}
+			params.TextDocument.URI = loc.URI
+			params.Position = loc.Range.Start
+			locs, err := env.Editor.Server.Definition(env.Ctx, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, loc := range locs {
+				got = append(got, fmt.Sprintf("%s:%d:%d", filepath.ToSlash(env.Sandbox.Workdir.URIToPath(loc.URI)), loc.Range.Start.Line, loc.Range.Start.Character))
+			}
+			return got
+		}
+
+		// An assembly function is defined by its TEXT directives,
+		// then by its Go declaration.
+		want := []string{"a/add_amd64.s:2:5", "a/add_arm64.s:0:5", "a/a.go:4:5"}
+		if diff := cmp.Diff(want, definitions(`_ = (Add)`)); diff != "" {
+			t.Errorf("Definition(Add) mismatch (-want +got):\n%s", diff)
+		}
+
+		// A function pulled in by a linkname directive is defined
+		// by its target.
+		want = []string{"b/b.go:2:5", "a/a.go:7:5"}
+		if diff := cmp.Diff(want, definitions(`int\((now)`)); diff != "" {
+			t.Errorf("Definition(now) mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
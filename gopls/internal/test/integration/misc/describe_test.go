@@ -0,0 +1,89 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestDescribe(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "errors"
+
+type T int
+
+func (T) String() string { return "" }
+
+const C = 1 << 4
+
+var _ = errors.New("x")
+
+func _() {
+	var x T
+	_ = x + C
+}
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		describe := func(re string) command.DescribeResult {
+			cmd, err := command.NewDescribeCommand("", env.RegexpSearch("a/a.go", re))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result command.DescribeResult
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, &result)
+			return result
+		}
+
+		// A reference to a type.
+		got := describe(`var x (T)`)
+		if got.Desc != "reference to type T int" || got.Detail != "type" {
+			t.Errorf("describe T: got %q (%s)", got.Desc, got.Detail)
+		}
+		if want := []string{"func (T).String() string"}; !reflect.DeepEqual(got.Methods, want) {
+			t.Errorf("describe T: methods = %v, want %v", got.Methods, want)
+		}
+		if want := env.RegexpSearch("a/a.go", `type (T)`); got.Object == nil || *got.Object != want {
+			t.Errorf("describe T: object at %v, want %v", got.Object, want)
+		}
+
+		// A constant expression.
+		got = describe(`(x \+ C)`)
+		if got.Desc != "binary + operation" || got.Type != "T" || got.Value != "" {
+			t.Errorf("describe x + C: got %+v", got)
+		}
+		got = describe(`\+ (C)`)
+		if got.Type != "untyped int" || got.Value != "16" {
+			t.Errorf("describe C: got %+v", got)
+		}
+
+		// A qualified identifier.
+		got = describe(`errors.(New)`)
+		if got.Desc != "reference to func errors.New(text string) error" || got.Detail != "value" {
+			t.Errorf("describe errors.New: got %q (%s)", got.Desc, got.Detail)
+		}
+
+		// A package name.
+		got = describe(`(errors).New`)
+		if got.Detail != "package" || len(got.Members) == 0 {
+			t.Errorf("describe errors: got %+v", got)
+		}
+	})
+}
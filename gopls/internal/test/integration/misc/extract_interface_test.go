@@ -0,0 +1,201 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+	"golang.org/x/tools/gopls/internal/test/compare"
+)
+
+func TestExtractInterfacePrompt(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "io"
+
+// Store stores things.
+type Store struct{}
+
+// Get returns the named thing.
+func (s *Store) Get(name string) []byte { return nil }
+
+func (s *Store) Put(name string, data []byte) {}
+
+func (s *Store) Copy(w io.Writer) error { return nil }
+
+func Read(s *Store) []byte { return s.Get("x") }
+
+func Write(s *Store) { s.Put("x", nil) }
+
+func Both(s, t *Store) { s.Get("x"); t.Get("y") }
+-- a/b.go --
+package a
+
+func Other(s *Store) []byte { return s.Get("y") }
+
+var f = Twice
+
+func Twice(s *Store) { s.Get("x") }
+`
+	var choices []string // remaining choices, in order
+	respond := func(params *protocol.ShowMessageRequestParams) (*protocol.MessageActionItem, error) {
+		if len(choices) == 0 {
+			return nil, nil
+		}
+		for _, item := range params.Actions {
+			if item.Title == choices[0] {
+				choices = choices[1:]
+				return &item, nil
+			}
+		}
+		t.Errorf("no choice %q in %v", choices[0], params.Actions)
+		return nil, nil
+	}
+	WithOptions(
+		MessageResponder(respond),
+	).Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.OpenFile("a/b.go")
+		// Write uses Put, and Twice is used as a value, so neither
+		// is offered.
+		choices = []string{
+			"Exclude Put", "Exclude Copy", "Apply",
+			"Include Read(s)", "Include Other(s)", "Include Both(s, t)", "Apply",
+		}
+		loc := env.RegexpSearch("a/a.go", `type (Store)`)
+		var found bool
+		for _, action := range env.CodeAction(loc, nil, protocol.CodeActionInvoked) {
+			if action.Title == "Extract interface from Store..." {
+				env.ApplyCodeAction(action)
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("no code action to extract an interface from Store")
+		}
+		if len(choices) > 0 {
+			t.Errorf("choices not offered: %v", choices)
+		}
+		const wantA = `package a
+
+import "io"
+
+// Getter is implemented by *Store.
+type Getter interface {
+	// Get returns the named thing.
+	Get(name string) []byte
+}
+
+// Store stores things.
+type Store struct{}
+
+// Get returns the named thing.
+func (s *Store) Get(name string) []byte { return nil }
+
+func (s *Store) Put(name string, data []byte) {}
+
+func (s *Store) Copy(w io.Writer) error { return nil }
+
+func Read(s Getter) []byte { return s.Get("x") }
+
+func Write(s *Store) { s.Put("x", nil) }
+
+func Both(s, t Getter) { s.Get("x"); t.Get("y") }
+`
+		if got := env.BufferText("a/a.go"); got != wantA {
+			t.Errorf("after extraction: unexpected content of a.go:\n%s", compare.Text(wantA, got))
+		}
+		const wantB = `package a
+
+func Other(s Getter) []byte { return s.Get("y") }
+
+var f = Twice
+
+func Twice(s *Store) { s.Get("x") }
+`
+		if got := env.BufferText("a/b.go"); got != wantB {
+			t.Errorf("after extraction: unexpected content of b.go:\n%s", compare.Text(wantB, got))
+		}
+	})
+}
+
+func TestExtractInterface(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+type T struct{ B }
+
+func (T) Read(p []byte) (int, error) { return 0, nil }
+-- a/b.go --
+package a
+
+import "io"
+
+type B struct{}
+
+func (*B) Copy(w io.Writer) error { return nil }
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		extract := func(name string, methods ...string) error {
+			cmd, err := command.NewExtractInterfaceCommand("", command.ExtractInterfaceArgs{
+				Location: env.RegexpSearch("a/a.go", `type (T)`),
+				Name:     name,
+				Methods:  methods,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			})
+			return err
+		}
+
+		if err := extract("", "Missing"); err == nil {
+			t.Errorf("ExtractInterface(Missing) succeeded, want error")
+		}
+		if err := extract("B", "Read"); err == nil {
+			t.Errorf("ExtractInterface(B) succeeded, want error for existing name")
+		}
+
+		// The promoted method needs an import of io, and a pointer.
+		if err := extract("Copier", "Read", "Copy"); err != nil {
+			t.Fatal(err)
+		}
+		const want = `package a
+
+import "io"
+
+// Copier is implemented by *T.
+type Copier interface {
+	Read(p []byte) (int, error)
+	Copy(w io.Writer) error
+}
+
+type T struct{ B }
+
+func (T) Read(p []byte) (int, error) { return 0, nil }
+`
+		if got := env.BufferText("a/a.go"); got != want {
+			t.Errorf("after extraction: unexpected content:\n%s", compare.Text(want, got))
+		}
+	})
+}
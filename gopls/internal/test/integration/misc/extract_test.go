@@ -64,3 +64,81 @@ func newFunction() int {
 		}
 	})
 }
+
+func TestExtractFunctionBranches(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.12
+-- main.go --
+package main
+
+func Foo(xs []int) (n int) {
+	for _, x := range xs {
+		if x < 0 {
+			continue
+		}
+		if x == 0 {
+			return
+		}
+		n += x
+	}
+	return n
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		loc := env.RegexpSearch("main.go", `(?s)if x < 0 {.*n \+= x`)
+		actions, err := env.Editor.CodeAction(env.Ctx, loc, nil, protocol.CodeActionUnknownTrigger)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var extractFunc *protocol.CodeAction
+		for _, action := range actions {
+			if action.Kind == protocol.RefactorExtract && action.Title == "Extract function" {
+				extractFunc = &action
+				break
+			}
+		}
+		if extractFunc == nil {
+			t.Fatal("could not find extract function action")
+		}
+
+		env.ApplyCodeAction(*extractFunc)
+		want := `package main
+
+func Foo(xs []int) (n int) {
+	for _, x := range xs {
+		var shouldReturn bool
+		var returnValue int
+		var shouldContinue bool
+		n, shouldReturn, returnValue, shouldContinue = newFunction(x, n)
+		if shouldReturn {
+			return returnValue
+		}
+		if shouldContinue {
+			continue
+		}
+	}
+	return n
+}
+
+func newFunction(x int, n int) (int, bool, int, bool) {
+	if x < 0 {
+		return n, false, 0, true
+	}
+	if x == 0 {
+		return 0, true, n, false
+	}
+	n += x
+	return n, false, 0, false
+}
+`
+		if got := env.BufferText("main.go"); got != want {
+			t.Fatalf("TestExtractFunctionBranches failed:\n%s", compare.Text(want, got))
+		}
+		env.AfterChange(NoDiagnostics(ForFile("main.go")))
+	})
+}
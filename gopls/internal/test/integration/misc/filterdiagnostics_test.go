@@ -0,0 +1,108 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestFilterDiagnostics(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "fmt"
+
+func _() {
+	fmt.Printf("%d", "s")
+}
+-- b/b.go --
+package b
+
+var x int = "s"
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.OpenFile("b/b.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf`), FromSource("analyzer/printf")),
+			Diagnostics(env.AtRegexp("b/b.go", `"s"`), FromSource("compiler")),
+		)
+
+		filter := func(args command.FilterDiagnosticsArgs) command.FilterDiagnosticsResult {
+			cmd, err := command.NewFilterDiagnosticsCommand("", args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result command.FilterDiagnosticsResult
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, &result)
+			return result
+		}
+
+		// Muting "analyzer" mutes all analyzers.
+		got := filter(command.FilterDiagnosticsArgs{Mute: []string{"analyzer"}})
+		want := command.FilterDiagnosticsResult{
+			Muted:   []string{"analyzer"},
+			Sources: []string{"analyzer/printf", "compiler"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("FilterDiagnostics(mute analyzer) mismatch (-want +got):\n%s", diff)
+		}
+		env.Await(
+			NoDiagnostics(ForFile("a/a.go"), FromSource("analyzer/printf")),
+			Diagnostics(env.AtRegexp("b/b.go", `"s"`), FromSource("compiler")),
+		)
+
+		// Muted sources stay muted after edits.
+		env.RegexpReplace("a/a.go", `"s"\)`, `"s") // edited`)
+		env.AfterChange(
+			NoDiagnostics(ForFile("a/a.go"), FromSource("analyzer/printf")),
+			Diagnostics(env.AtRegexp("b/b.go", `"s"`), FromSource("compiler")),
+		)
+
+		// Muting a parent of a source mutes it; unmuting restores.
+		got = filter(command.FilterDiagnosticsArgs{Mute: []string{"compiler"}, Unmute: []string{"analyzer"}})
+		if diff := cmp.Diff([]string{"compiler"}, got.Muted); diff != "" {
+			t.Errorf("FilterDiagnostics(mute compiler) mismatch (-want +got):\n%s", diff)
+		}
+		env.Await(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf`), FromSource("analyzer/printf")),
+			NoDiagnostics(ForFile("b/b.go")),
+		)
+
+		// Reset unmutes everything.
+		got = filter(command.FilterDiagnosticsArgs{Reset: true})
+		if len(got.Muted) != 0 {
+			t.Errorf("FilterDiagnostics(reset) muted %v, want none", got.Muted)
+		}
+		env.Await(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf`), FromSource("analyzer/printf")),
+			Diagnostics(env.AtRegexp("b/b.go", `"s"`), FromSource("compiler")),
+		)
+
+		cmd, err := command.NewFilterDiagnosticsCommand("", command.FilterDiagnosticsArgs{Mute: []string{"analyzer/"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		}); err == nil {
+			t.Errorf("FilterDiagnostics(mute analyzer/) succeeded, want error")
+		}
+	})
+}
@@ -159,3 +159,75 @@ func _() {
 		// yay, no panic
 	})
 }
+
+// TestFixSelection checks that applying a fix selects a sensible
+// range, whether the server applies the edits or they are resolved.
+func TestFixSelection(t *testing.T) {
+	const src = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- main.go --
+package main
+
+type Info struct {
+	Count int
+	Words []string
+}
+
+func Foo() int {
+	_ = Info{}
+	return 1 + 2
+}
+`
+	for _, capabilities := range []string{
+		"{}",
+		`{ "textDocument": {"codeAction": {	"dataSupport": true, "resolveSupport": { "properties": ["edit"] } } } }`,
+	} {
+		WithOptions(CapabilitiesJSON([]byte(capabilities))).Run(t, src, func(t *testing.T, env *Env) {
+			env.OpenFile("main.go")
+			// apply applies the code action of the specified kind at the
+			// location, and returns the text selected afterwards.
+			apply := func(loc protocol.Location, kind protocol.CodeActionKind, title string) string {
+				env.Awaiter.ResetShownDocuments()
+				actions, err := env.Editor.CodeActions(env.Ctx, loc, nil, kind)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, action := range actions {
+					if action.Title == title {
+						env.ApplyCodeAction(action)
+						var shown []*protocol.ShowDocumentParams
+						env.Await(ShownDocuments(&shown))
+						if len(shown) != 1 || shown[0].Selection == nil {
+							t.Fatalf("%s: got shown documents %v, want one selection", title, shown)
+						}
+						rng := *shown[0].Selection
+						m, err := env.Editor.Mapper("main.go")
+						if err != nil {
+							t.Fatal(err)
+						}
+						start, end, err := m.RangeOffsets(rng)
+						if err != nil {
+							t.Fatal(err)
+						}
+						return string(m.Content[start:end])
+					}
+				}
+				t.Fatalf("no code action %q", title)
+				return ""
+			}
+
+			if got := apply(env.RegexpSearch("main.go", "Info{}"), protocol.RefactorRewrite, "Fill Info"); got != "0" {
+				t.Errorf("after fill struct, selection is %q, want the value of Count", got)
+			}
+			if got := apply(env.RegexpSearch("main.go", "1 \\+ 2"), protocol.RefactorExtract, "Extract variable"); got != "x" {
+				t.Errorf("after extracting a variable, selection is %q, want its name", got)
+			}
+			if got := apply(env.RegexpSearch("main.go", "_ = Info{(?s:.*?)\n\t}"), protocol.RefactorExtract, "Extract function"); got != "newFunction" {
+				t.Errorf("after extracting a function, selection is %q, want its name", got)
+			}
+		})
+	}
+}
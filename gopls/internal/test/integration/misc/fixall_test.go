@@ -0,0 +1,88 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestFixAll(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func _(s []int) {
+	for i, _ := range s {
+		for j, _ := range s {
+			_, _ = i, j
+		}
+	}
+}
+-- a/a2.go --
+package a
+
+func _(m map[string]int) {
+	for k, _ := range m {
+		_ = k
+	}
+}
+-- b/b.go --
+package b
+
+func _(s []string) {
+	for i, _ := range s {
+		_ = i
+	}
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "i, (_)"), WithMessage("simplify range")),
+			Diagnostics(env.AtRegexp("a/a2.go", "k, (_)")),
+			NoDiagnostics(ForFile("b/b.go")), // only the packages of open files are analyzed
+			ReadDiagnostics("a/a.go", &d),
+		)
+
+		var diag protocol.Diagnostic
+		for _, dg := range d.Diagnostics {
+			if dg.Source == "analyzer/simplifyrange" {
+				diag = dg
+				break
+			}
+		}
+		titles := make(map[string]protocol.CodeAction)
+		for _, action := range env.GetQuickFixes("a/a.go", []protocol.Diagnostic{diag}) {
+			titles[action.Title] = action
+		}
+		if _, ok := titles["Fix all 3 simplifyrange diagnostics in package"]; !ok {
+			t.Errorf("no action to fix the package; got %v", titles)
+		}
+		action, ok := titles["Fix all simplifyrange diagnostics in workspace"]
+		if !ok {
+			t.Fatalf("no action to fix the workspace; got %v", titles)
+		}
+
+		env.ApplyCodeAction(action)
+		for _, path := range []string{"a/a.go", "a/a2.go", "b/b.go"} {
+			if got := env.BufferText(path); strings.Contains(got, ", _ := range") {
+				t.Errorf("%s was not fixed:\n%s", path, got)
+			}
+		}
+		env.AfterChange(
+			ShownMessage("Fixed 4 simplifyrange diagnostics in 3 files."),
+			NoDiagnostics(WithMessage("simplify range")),
+		)
+	})
+}
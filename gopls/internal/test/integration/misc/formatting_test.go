@@ -392,3 +392,56 @@ func _() {
 		env.FormatBuffer("foo.go") // golang/go#61692: must not panic
 	})
 }
+
+func TestFormatExclusions(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- tables/t.go --
+package tables
+
+import (
+	"os"
+	"fmt"
+)
+
+var T = []int{
+	1,   2,
+	30, 40,
+}
+-- skip.go --
+//gofmt:skip-file
+
+package main
+
+import (
+	"os"
+	"fmt"
+)
+
+var T = []int{
+	1,   2,
+	30, 40,
+}
+`
+	WithOptions(
+		Settings{"formatExclusions": []string{"tables/**"}},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		for _, path := range []string{"tables/t.go", "skip.go"} {
+			env.OpenFile(path)
+			want := env.BufferText(path)
+
+			// Diagnostics are still reported.
+			env.AfterChange(Diagnostics(env.AtRegexp(path, `"os"`)))
+
+			env.FormatBuffer(path)
+			env.OrganizeImports(path)
+			env.SaveBuffer(path)
+			if got := env.BufferText(path); got != want {
+				t.Errorf("%s was changed:\n%s", path, compare.Text(want, got))
+			}
+		}
+	})
+}
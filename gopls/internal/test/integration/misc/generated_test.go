@@ -0,0 +1,91 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+const generatedFiles = `
+-- go.mod --
+module example.com
+
+go 1.19
+-- a/a.go --
+package a
+
+//go:generate stringer -type=Color
+
+type Color int
+
+type T struct{}
+
+var _ = []T{T{}}
+-- a/color_string.go --
+// Code generated by "stringer -type=Color"; DO NOT EDIT.
+
+package a
+
+var _ = []T{T{}}
+
+func (c Color) String() string { return "" }
+`
+
+func TestStyleDiagnosticsInGeneratedFiles(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		WithOptions(
+			Settings{"styleDiagnosticsInGeneratedFiles": enabled},
+		).Run(t, generatedFiles, func(t *testing.T, env *Env) {
+			env.OpenFile("a/color_string.go")
+			generated := NoDiagnostics(ForFile("a/color_string.go"))
+			if enabled {
+				generated = Diagnostics(env.AtRegexp("a/color_string.go", `T{}`), WithMessage("redundant type"))
+			}
+			env.AfterChange(
+				Diagnostics(env.AtRegexp("a/a.go", `T{}`), WithMessage("redundant type")),
+				generated,
+			)
+		})
+	}
+}
+
+func TestRegenerateCodeAction(t *testing.T) {
+	Run(t, generatedFiles, func(t *testing.T, env *Env) {
+		for _, test := range []struct {
+			filename string
+			want     bool
+		}{
+			{"a/a.go", false},
+			{"a/color_string.go", true},
+		} {
+			env.OpenFile(test.filename)
+			var got *protocol.CodeAction
+			for _, action := range env.CodeActionForFile(test.filename, nil) {
+				if action.Kind == protocol.GoRegenerate {
+					got = &action
+				}
+			}
+			if (got != nil) != test.want {
+				t.Errorf("%s: got regenerate action %v, want %t", test.filename, got, test.want)
+			}
+			if got != nil && (got.Command == nil || got.Command.Command != "gopls.generate") {
+				t.Errorf("%s: regenerate action has command %v, want gopls.generate", test.filename, got.Command)
+			}
+		}
+	})
+}
+
+func TestRenameWarnsOfGeneratedEdits(t *testing.T) {
+	Run(t, generatedFiles, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.Rename(env.RegexpSearch("a/a.go", `type (Color)`), "Colour")
+		env.AfterChange(
+			ShownMessage("This change edits generated files (color_string.go)"),
+		)
+	})
+}
@@ -131,3 +131,67 @@ func TestFoo2(t *testing.T) {}
 
 	})
 }
+
+func TestCreatePackage(t *testing.T) {
+	const files = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import (
+	"example.com/empty"
+	"example.com/missing/sub"
+	"golang.org/x/other/v2"
+)
+
+var _ = sub.X
+var _ = empty.Y
+var _ = other.Z
+-- empty/README --
+This directory has no Go files.
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(ReadDiagnostics("a/a.go", &d))
+
+		// The fix is offered for packages of the module only.
+		var titles []string
+		var creates []protocol.CodeAction
+		for _, action := range env.GetQuickFixes("a/a.go", d.Diagnostics) {
+			titles = append(titles, action.Title)
+			if action.Command != nil && action.Command.Command == command.CreatePackage.String() {
+				creates = append(creates, action)
+			}
+		}
+		want := []string{
+			"Create package example.com/empty",
+			"Create package example.com/missing/sub",
+			"go get package golang.org/x/other/v2",
+		}
+		if diff := cmp.Diff(want, titles); diff != "" {
+			t.Errorf("unexpected quick fixes (-want +got):\n%s", diff)
+		}
+
+		for _, action := range creates {
+			env.ApplyCodeAction(action)
+		}
+		for path, name := range map[string]string{
+			"missing/sub/doc.go": "sub",
+			"empty/doc.go":       "empty",
+		} {
+			want := "// Package " + name + " ...\npackage " + name + "\n"
+			if got := env.BufferText(path); got != want {
+				t.Errorf("%s: got %q, want %q", path, got, want)
+			}
+		}
+		env.AfterChange(
+			NoDiagnostics(ForFile("missing/sub/doc.go")),
+			Diagnostics(env.AtRegexp("a/a.go", `sub\.(X)`), WithMessage("undefined: sub.X")),
+			Diagnostics(env.AtRegexp("a/a.go", `empty\.(Y)`), WithMessage("undefined: empty.Y")),
+		)
+	})
+}
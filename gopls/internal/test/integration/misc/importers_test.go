@@ -0,0 +1,106 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestImporters(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.test
+
+go 1.18
+-- a/a.go --
+package a
+
+const A = 1
+-- a/a_x_test.go --
+package a_test
+
+import "mod.test/a"
+
+var _ = a.A
+-- b/b.go --
+package b
+
+import "mod.test/a"
+
+const B = a.A
+-- c/c.go --
+package c
+
+import "mod.test/b"
+
+const C = b.B
+-- d/d.go --
+package d
+-- d/d_test.go --
+package d
+
+import "mod.test/c"
+
+var _ = c.C
+`
+	WithOptions(
+		Settings{"codelenses": map[string]bool{"importers": true}},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+
+		importers := func(depth int) string {
+			cmd, err := command.NewImportersCommand("", command.ImportersArgs{
+				URI:   env.Sandbox.Workdir.URI("a/a.go"),
+				Depth: depth,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result command.ImportersResult
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, &result)
+			var got string
+			for _, imp := range result.Importers {
+				got += fmt.Sprintf("%s %d", imp.PkgPath, imp.Depth)
+				for _, loc := range imp.Locations {
+					got += fmt.Sprintf(" %s:%d", env.Sandbox.Workdir.URIToPath(loc.URI), loc.Range.Start.Line+1)
+				}
+				got += "\n"
+			}
+			return got
+		}
+
+		const direct = `mod.test/a_test 1 a/a_x_test.go:3
+mod.test/b 1 b/b.go:3
+`
+		if diff := cmp.Diff(direct, importers(1)); diff != "" {
+			t.Errorf("direct importers: unexpected result (-want +got):\n%s", diff)
+		}
+		const all = direct + `mod.test/c 2 c/c.go:3
+mod.test/d 3 d/d_test.go:3
+`
+		if diff := cmp.Diff(all, importers(0)); diff != "" {
+			t.Errorf("all importers: unexpected result (-want +got):\n%s", diff)
+		}
+
+		var titles []string
+		for _, lens := range env.CodeLens("a/a.go") {
+			if lens.Command.Command == command.Importers.String() {
+				titles = append(titles, lens.Command.Title)
+			}
+		}
+		if len(titles) != 1 || titles[0] != "2 importers" {
+			t.Errorf("got importers code lenses %q, want [2 importers]", titles)
+		}
+	})
+}
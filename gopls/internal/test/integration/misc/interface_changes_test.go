@@ -0,0 +1,98 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+const interfaceChangesSrc = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "fmt"
+
+type Shape interface {
+	Area() float64
+}
+
+var _ fmt.Stringer = Square{}
+
+type Square struct{ side float64 }
+
+func (s Square) String() string { return fmt.Sprint(s.side) }
+
+func (s Square) Perimeter() float64 { return 4 * s.side }
+`
+
+func TestInterfaceChanges(t *testing.T) {
+	WithOptions(
+		Settings{"interfaceChanges": true},
+	).Run(t, interfaceChangesSrc, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange(
+			NoDiagnostics(ForFile("a/a.go")),
+		)
+
+		// Renaming a method gains one interface and loses another.
+		env.RegexpReplace("a/a.go", "Perimeter", "Area")
+		env.RegexpReplace("a/a.go", `String\(\) string`, "Name() string")
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "Square struct"), WithMessage("Square now implements Shape"), WithSeverityTags("gopls/interface-changes", protocol.SeverityInformation, nil)),
+			Diagnostics(env.AtRegexp("a/a.go", "Square struct"), WithMessage("Square no longer implements fmt.Stringer")),
+			ReadDiagnostics("a/a.go", &d),
+		)
+		for _, diag := range d.Diagnostics {
+			if diag.Message == "Square now implements Shape" {
+				if len(diag.RelatedInformation) != 1 || diag.RelatedInformation[0].Location != env.RegexpSearch("a/a.go", "Shape") {
+					t.Errorf("got related information %v, want the location of Shape", diag.RelatedInformation)
+				}
+			}
+		}
+
+		// Saving the file accepts the changes.
+		env.SaveBuffer("a/a.go")
+		env.AfterChange(
+			NoDiagnostics(env.AtRegexp("a/a.go", "Square struct")),
+		)
+	})
+}
+
+// Test that the baseline is recorded only from saved files: a package
+// first seen with unsaved edits has no changes until it is saved.
+func TestInterfaceChanges_UnsavedBaseline(t *testing.T) {
+	Run(t, interfaceChangesSrc, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.RegexpReplace("a/a.go", "Perimeter", "Area")
+		env.AfterChange()
+
+		cfg := env.Editor.Config()
+		cfg.Settings = map[string]any{
+			"interfaceChanges": true,
+		}
+		env.ChangeConfiguration(cfg)
+		env.AfterChange()
+		env.RegexpReplace("a/a.go", `String\(\) string`, "Name() string")
+		env.AfterChange(
+			NoDiagnostics(env.AtRegexp("a/a.go", "Square struct")),
+		)
+
+		// Once saved, the files provide the baseline.
+		env.SaveBuffer("a/a.go")
+		env.AfterChange()
+		env.RegexpReplace("a/a.go", "Area", "Perimeter")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "Square struct"), WithMessage("Square no longer implements Shape")),
+		)
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+const lineDirectiveFiles = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- calc/calc.y --
+%{
+package calc
+%}
+
+eval: expr
+
+%%
+
+fail: error
+{
+	return undefined
+}
+-- calc/calc.go --
+// Code generated by goyacc calc.y. DO NOT EDIT.
+
+package calc
+
+//line calc.y:5
+func Eval(s string) int {
+	return len(s)
+}
+
+func Fail() int {
+//line calc.y:11:1
+	return undefined
+}
+-- calc/main.go --
+package calc
+
+func _() { Eval("x") }
+`
+
+func TestLineDirectives(t *testing.T) {
+	WithOptions(
+		Settings{"lineDirectives": true},
+	).Run(t, lineDirectiveFiles, func(t *testing.T, env *Env) {
+		env.OpenFile("calc/main.go")
+		env.OpenFile("calc/calc.y")
+
+		// The definition of Eval is the line of the grammar.
+		evalRule := env.RegexpSearch("calc/calc.y", `eval: expr`)
+		evalRule.Range.End.Character = uint32(len("eval: expr"))
+		call := env.RegexpSearch("calc/main.go", `Eval`)
+		if got := env.GoToDefinition(call); got != evalRule {
+			t.Errorf("Definition(Eval) = %v, want %v", got, evalRule)
+		}
+
+		// So is its declaring reference.
+		refs := env.References(call)
+		if len(refs) != 2 || refs[0] != evalRule || refs[1].URI != call.URI {
+			t.Errorf("References(Eval) = %v, want [%v, %v]", refs, evalRule, call)
+		}
+
+		// The type error in the generated file is related to the grammar.
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("calc/calc.go", "undefined\n"), WithMessage("undefined")),
+			ReadDiagnostics("calc/calc.go", &d),
+		)
+		undefined := env.RegexpSearch("calc/calc.y", `undefined`)
+		if len(d.Diagnostics) != 1 || len(d.Diagnostics[0].RelatedInformation) != 1 {
+			t.Fatalf("got diagnostics %v, want one with related information", d.Diagnostics)
+		}
+		if got := d.Diagnostics[0].RelatedInformation[0].Location; got != undefined {
+			t.Errorf("related location = %v, want %v", got, undefined)
+		}
+	})
+}
+
+func TestLineDirectivesDisabled(t *testing.T) {
+	Run(t, lineDirectiveFiles, func(t *testing.T, env *Env) {
+		env.OpenFile("calc/main.go")
+		call := env.RegexpSearch("calc/main.go", `Eval`)
+		want := env.RegexpSearch("calc/calc.go", `Eval`)
+		if got := env.GoToDefinition(call); got != want {
+			t.Errorf("Definition(Eval) = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestGeneratedLocation(t *testing.T) {
+	Run(t, lineDirectiveFiles, func(t *testing.T, env *Env) {
+		env.OpenFile("calc/calc.y")
+
+		generated := func(loc protocol.Location) []protocol.Location {
+			cmd, err := command.NewGeneratedLocationCommand("", loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result []protocol.Location
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, &result)
+			return result
+		}
+
+		// Without a column, the whole generated line is reported.
+		evalDecl := env.RegexpSearch("calc/calc.go", `func Eval\(s string\) int {`)
+		if got := generated(env.RegexpSearch("calc/calc.y", `expr`)); len(got) != 1 || got[0] != evalDecl {
+			t.Errorf("GeneratedLocation(eval rule) = %v, want [%v]", got, evalDecl)
+		}
+
+		// With a column, the corresponding point is reported.
+		want := env.RegexpSearch("calc/calc.go", `undefined`)
+		want.Range.End = want.Range.Start
+		if got := generated(env.RegexpSearch("calc/calc.y", `undefined`)); len(got) != 1 || got[0] != want {
+			t.Errorf("GeneratedLocation(undefined) = %v, want [%v]", got, want)
+		}
+
+		// A line to which only a directive maps has no generated location.
+		if got := generated(env.RegexpSearch("calc/calc.y", `\{\n`)); len(got) != 0 {
+			t.Errorf("GeneratedLocation({) = %v, want none", got)
+		}
+	})
+}
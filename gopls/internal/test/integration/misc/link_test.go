@@ -5,9 +5,12 @@
 package misc
 
 import (
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
+	"golang.org/x/tools/gopls/internal/protocol"
 	. "golang.org/x/tools/gopls/internal/test/integration"
 )
 
@@ -82,9 +85,10 @@ const Hello = "Hello"
 		if content == nil || strings.Contains(content.Value, modLink) {
 			t.Errorf("hover: got %v in go.mod, want contains %q", content, modLink)
 		}
+		// The import of a private package links to its file instead.
 		links = env.DocumentLink("main.go")
-		if len(links) != 0 {
-			t.Errorf("documentLink: got %d document links for main.go, want 0\nlinks: %v", len(links), links)
+		if len(links) != 1 || !strings.HasPrefix(*links[0].Target, "file://") || !strings.HasSuffix(*links[0].Target, "/pkg/const.go") {
+			t.Errorf("documentLink: got links %+v for main.go, want one link to pkg/const.go", links)
 		}
 		links = env.DocumentLink("go.mod")
 		if len(links) != 0 {
@@ -92,3 +96,85 @@ const Hello = "Hello"
 		}
 	})
 }
+
+func TestDocumentLinkLocal(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.test
+
+go 1.18
+
+require (
+	import.test v1.2.3
+	local.test v0.0.0
+)
+
+replace local.test => ./local
+
+replace import.test v1.2.3 => other.test v1.0.0
+
+exclude import.test v1.2.2
+-- main.go --
+package main
+
+import (
+	"local.test/lib"
+	"mod.test/util"
+)
+
+func main() {
+	util.F()
+	lib.G()
+}
+-- util/util.go --
+package util
+
+func F() {}
+-- local/go.mod --
+module local.test
+
+go 1.18
+-- local/lib/lib.go --
+package lib
+
+func G() {}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main.go")
+		env.OpenFile("go.mod")
+
+		// Imports of workspace packages and of packages of locally
+		// replaced modules link to their files.
+		var got []string
+		for _, l := range env.DocumentLink("main.go") {
+			got = append(got, env.Sandbox.Workdir.URIToPath(protocol.DocumentURI(*l.Target)))
+		}
+		if want := []string{"local/lib/lib.go", "util/util.go"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("documentLink: got targets %v for main.go, want %v", got, want)
+		}
+
+		// go.mod links the module paths of require, replace, and
+		// exclude directives; a replacement directory links to its
+		// go.mod file.
+		got = nil
+		for _, l := range env.DocumentLink("go.mod") {
+			target := *l.Target
+			if strings.HasPrefix(target, "file://") {
+				target = env.Sandbox.Workdir.URIToPath(protocol.DocumentURI(target))
+			}
+			got = append(got, fmt.Sprintf("%d: %s", l.Range.Start.Line, target))
+		}
+		want := []string{
+			"5: https://pkg.go.dev/mod/import.test@v1.2.3",
+			"6: local/go.mod",
+			"9: https://pkg.go.dev/mod/local.test",
+			"9: local/go.mod",
+			"11: https://pkg.go.dev/mod/import.test@v1.2.3",
+			"11: https://pkg.go.dev/mod/other.test@v1.0.0",
+			"13: https://pkg.go.dev/mod/import.test@v1.2.2",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("documentLink: got links for go.mod:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	})
+}
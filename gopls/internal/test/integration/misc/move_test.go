@@ -0,0 +1,242 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/gopls/internal/test/compare"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+// applyMoveAction applies the refactor.move code action at the
+// declaration named by re in the file whose title begins with prefix.
+func applyMoveAction(t *testing.T, env *Env, path, re, prefix string) {
+	t.Helper()
+	loc := env.RegexpSearch(path, re)
+	for _, action := range env.CodeAction(loc, nil, protocol.CodeActionInvoked) {
+		if action.Kind == protocol.RefactorMove && strings.HasPrefix(action.Title, prefix) {
+			env.ApplyCodeAction(action)
+			return
+		}
+	}
+	t.Fatalf("no %q code action at %s in %s", prefix, re, path)
+}
+
+func TestMoveDeclarationToFile(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+// Copyright notice.
+
+package a
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Shout returns s in upper case.
+func Shout(s string) string {
+	return strings.ToUpper(s) + "!"
+}
+
+func Print() { fmt.Println(Shout("hi")) }
+-- a/a_test.go --
+package a
+
+import "testing"
+
+func TestShout(t *testing.T) {
+	if Shout("a") != "A!" {
+		t.Fail()
+	}
+}
+
+func TestPrint(t *testing.T) { Print() }
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		applyMoveAction(t, env, "a/a.go", `func (Shout)`, "Move Shout to file shout.go")
+
+		const wantA = `// Copyright notice.
+
+package a
+
+import (
+	"fmt"
+)
+
+func Print() { fmt.Println(Shout("hi")) }
+`
+		const wantShout = `// Copyright notice.
+
+package a
+
+import (
+	"strings"
+)
+
+// Shout returns s in upper case.
+func Shout(s string) string {
+	return strings.ToUpper(s) + "!"
+}
+`
+		const wantShoutTest = `// Copyright notice.
+
+package a
+
+import (
+	"testing"
+)
+
+func TestShout(t *testing.T) {
+	if Shout("a") != "A!" {
+		t.Fail()
+	}
+}
+`
+		for path, want := range map[string]string{
+			"a/a.go":          wantA,
+			"a/shout.go":      wantShout,
+			"a/shout_test.go": wantShoutTest,
+		} {
+			if got := env.BufferText(path); got != want {
+				t.Errorf("%s after move: unexpected content:\n%s", path, compare.Text(want, got))
+			}
+		}
+		env.AfterChange(NoDiagnostics())
+	})
+}
+
+func TestMoveDeclarationToPackage(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "example.com/b"
+
+// T is a point.
+type T struct{ X, Y int }
+
+func (t T) Sum() int { return t.X + t.Y + b.Zero }
+
+func F() int { return T{X: 1, Y: 2}.Sum() }
+-- b/b.go --
+package b
+
+const Zero = 0
+-- c/c.go --
+package c
+
+import "example.com/a"
+
+var _ = a.T{}
+var _ = a.F()
+`
+	respond := func(params *protocol.ShowMessageRequestParams) (*protocol.MessageActionItem, error) {
+		for _, item := range params.Actions {
+			if item.Title == "example.com/b" {
+				return &item, nil
+			}
+		}
+		return nil, nil
+	}
+	WithOptions(
+		MessageResponder(respond),
+	).Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		applyMoveAction(t, env, "a/a.go", `type (T)`, "Move T to package...")
+
+		const wantA = `package a
+
+import "example.com/b"
+
+func F() int { return b.T{X: 1, Y: 2}.Sum() }
+`
+		const wantB = `package b
+
+// T is a point.
+type T struct{ X, Y int }
+
+func (t T) Sum() int { return t.X + t.Y + Zero }
+`
+		const wantC = `package c
+
+import (
+	"example.com/a"
+	"example.com/b"
+)
+
+var _ = b.T{}
+var _ = a.F()
+`
+		for path, want := range map[string]string{
+			"a/a.go": wantA,
+			"b/a.go": wantB,
+			"c/c.go": wantC,
+		} {
+			if got := env.BufferText(path); got != want {
+				t.Errorf("%s after move: unexpected content:\n%s", path, compare.Text(want, got))
+			}
+		}
+		env.AfterChange(NoDiagnostics())
+	})
+}
+
+func TestMoveDeclarationErrors(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func F() int { return g() }
+
+func g() int { return 1 }
+-- b/b.go --
+package b
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		loc := env.RegexpSearch("a/a.go", `func (F)`)
+		for _, action := range env.CodeAction(loc, nil, protocol.CodeActionInvoked) {
+			if action.Kind != protocol.RefactorMove || !strings.HasPrefix(action.Title, "Move F to package") {
+				continue
+			}
+			var args command.MoveDeclarationArgs
+			if err := command.UnmarshalArgs(action.Command.Arguments, &args); err != nil {
+				t.Fatal(err)
+			}
+			args.DestFile = env.Sandbox.Workdir.URI("b/b.go")
+			cmd, err := command.NewMoveDeclarationCommand("", args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			})
+			const want = "F refers to g, which would remain in package a"
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("moving F: got error %v, want %q", err, want)
+			}
+			return
+		}
+		t.Fatal("no code action to move F to another package")
+	})
+}
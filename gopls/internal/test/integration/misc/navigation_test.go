@@ -0,0 +1,226 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestNextDiagnostic(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "fmt"
+
+func _() {
+	fmt.Printf("%d", "s")
+}
+-- b/b.go --
+package b
+
+var x int = "s"
+
+var y string = 1
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.OpenFile("b/b.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", `fmt.Printf`)),
+			Diagnostics(env.AtRegexp("b/b.go", `"s"`)),
+			Diagnostics(env.AtRegexp("b/b.go", `1`)),
+		)
+
+		next := func(args command.NextDiagnosticArgs) *protocol.Location {
+			cmd, err := command.NewNextDiagnosticCommand("", args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result *protocol.Location
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, &result)
+			return result
+		}
+
+		printf := env.RegexpSearch("a/a.go", `fmt.Printf`)
+		s := env.RegexpSearch("b/b.go", `"s"`)
+		one := env.RegexpSearch("b/b.go", `1`)
+		for _, test := range []struct {
+			name string
+			args command.NextDiagnosticArgs
+			want protocol.Location
+		}{
+			{"next in other file", command.NextDiagnosticArgs{Location: printf}, s},
+			{"next in same file", command.NextDiagnosticArgs{Location: s}, one},
+			{"next wraps", command.NextDiagnosticArgs{Location: one}, printf},
+			{"previous wraps", command.NextDiagnosticArgs{Location: printf, Previous: true}, one},
+			{"previous", command.NextDiagnosticArgs{Location: one, Previous: true}, s},
+			// The printf diagnostic is a warning.
+			{"errors only", command.NextDiagnosticArgs{Location: one, Severity: protocol.SeverityError}, s},
+		} {
+			got := next(test.args)
+			if got == nil || got.URI != test.want.URI || got.Range.Start != test.want.Range.Start {
+				t.Errorf("%s: NextDiagnostic(%v) = %v, want %v", test.name, test.args, got, test.want)
+			}
+		}
+	})
+}
+
+func TestNextReference(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func F() {}
+
+func _() {
+	F()
+	F()
+}
+-- b/b.go --
+package b
+
+import "mod.com/a"
+
+func _() { a.F() }
+
+func G() {}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+
+		next := func(loc protocol.Location, previous bool) *protocol.Location {
+			cmd, err := command.NewNextReferenceCommand("", command.NextReferenceArgs{
+				Location: loc,
+				Previous: previous,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result *protocol.Location
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, &result)
+			return result
+		}
+
+		decl := env.RegexpSearch("a/a.go", `F\(\) {}`)
+		call1 := env.RegexpSearch("a/a.go", `F\(\)\n\tF`)
+		call2 := env.RegexpSearch("a/a.go", `F\(\)\n}`)
+		call3 := env.RegexpSearch("b/b.go", `F\(\) }`)
+
+		// Start in the middle of an identifier.
+		mid := decl
+		mid.Range.Start.Character++
+		mid.Range.End = mid.Range.Start
+
+		for _, test := range []struct {
+			name     string
+			from     protocol.Location
+			previous bool
+			want     protocol.Location
+		}{
+			{"next from declaration", mid, false, call1},
+			{"next", call1, false, call2},
+			{"next in other file", call2, false, call3},
+			{"next wraps", call3, false, decl},
+			{"previous wraps", mid, true, call3},
+			{"previous", call2, true, call1},
+		} {
+			got := next(test.from, test.previous)
+			if got == nil || got.URI != test.want.URI || got.Range.Start != test.want.Range.Start {
+				t.Errorf("%s: NextReference(%v, %t) = %v, want %v", test.name, test.from, test.previous, got, test.want)
+			}
+		}
+
+		// A symbol without other references has no next reference.
+		if got := next(env.RegexpSearch("b/b.go", `G\(\)`), false); got != nil {
+			t.Errorf("NextReference(G) = %v, want nil", got)
+		}
+	})
+}
+
+func TestMatchingBracket(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+type Pair[K comparable, V any] struct{ k K; v V }
+
+func _() {
+	p := Pair[string, int]{k: "}"} // {
+	_ = p
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+
+		match := func(loc protocol.Location) *protocol.Location {
+			cmd, err := command.NewMatchingBracketCommand("", loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result *protocol.Location
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, &result)
+			return result
+		}
+
+		// after returns the location just after the one found by re.
+		after := func(re string) protocol.Location {
+			loc := env.RegexpSearch("a/a.go", re)
+			loc.Range.Start = loc.Range.End
+			return loc
+		}
+
+		for _, test := range []struct {
+			name       string
+			from, want protocol.Location
+		}{
+			{"type parameters", env.RegexpSearch("a/a.go", `\[K`), env.RegexpSearch("a/a.go", `\] struct`)},
+			{"struct type", env.RegexpSearch("a/a.go", `} *\n`), env.RegexpSearch("a/a.go", `{ k`)},
+			{"instantiation", env.RegexpSearch("a/a.go", `\[string`), env.RegexpSearch("a/a.go", `\]{`)},
+			{"following bracket first", after(`int\]`), env.RegexpSearch("a/a.go", `} //`)},
+			{"composite literal", env.RegexpSearch("a/a.go", `{k:`), env.RegexpSearch("a/a.go", `} //`)},
+			{"function body", after(`= p\n}`), env.RegexpSearch("a/a.go", `{\n\tp :=`)},
+		} {
+			got := match(test.from)
+			if got == nil || got.URI != test.want.URI || got.Range.Start != test.want.Range.Start {
+				t.Errorf("%s: MatchingBracket(%v) = %v, want %v", test.name, test.from, got, test.want)
+			}
+		}
+
+		// Brackets within strings and comments have no match.
+		for _, re := range []string{`"}"`, `// {`} {
+			loc := env.RegexpSearch("a/a.go", re)
+			loc.Range.Start.Character++
+			if got := match(loc); got != nil {
+				t.Errorf("MatchingBracket(%s) = %v, want nil", re, got)
+			}
+		}
+	})
+}
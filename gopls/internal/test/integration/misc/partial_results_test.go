@@ -0,0 +1,164 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+// TestPartialResults checks that references, implementations, and
+// workspace symbols are reported as partial results when the client
+// provides a partial result token, and that they are the same as the
+// results reported without one.
+func TestPartialResults(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+type Shape interface {
+	Area() float64
+}
+
+type Square struct{}
+
+func (Square) Area() float64 { return 1 }
+
+func Total(s Shape) float64 { return s.Area() }
+-- b/b.go --
+package b
+
+import "mod.com/a"
+
+type Circle struct{}
+
+func (Circle) Area() float64 { return 3 }
+
+var _ = a.Total(Circle{}) + a.Total(a.Square{})
+-- c/c.go --
+package c
+
+import "mod.com/a"
+
+type Triangle struct{}
+
+func (Triangle) Area() float64 { return 0.5 }
+
+var _ = a.Total(Triangle{})
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+
+		// partialResults waits for the partial results of the
+		// given token to match the complete results in want.
+		// (The response may overtake the notifications that
+		// precede it.)
+		partialResults := func(token protocol.ProgressToken, ptr interface{}, want interface{}) {
+			t.Helper()
+			deadline := time.Now().Add(10 * time.Second)
+			for {
+				if err := env.Awaiter.PartialResults(token, ptr); err != nil {
+					t.Fatal(err)
+				}
+				got := fmt.Sprint(sortedStrings(ptr))
+				if got == fmt.Sprint(sortedStrings(want)) {
+					return
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("partial results for %v:\n%s\nwant:\n%s", token, got, fmt.Sprint(sortedStrings(want)))
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+
+		loc := env.RegexpSearch("a/a.go", `func \(Square\) (Area)`)
+
+		// references
+		wantRefs := env.References(loc)
+		if len(wantRefs) < 2 {
+			t.Fatalf("References returned %d results, want several", len(wantRefs))
+		}
+		var token protocol.ProgressToken = "references"
+		refs, err := env.Editor.Server.References(env.Ctx, &protocol.ReferenceParams{
+			TextDocumentPositionParams: protocol.LocationTextDocumentPositionParams(loc),
+			PartialResultParams:        protocol.PartialResultParams{PartialResultToken: &token},
+			Context:                    protocol.ReferenceContext{IncludeDeclaration: true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(refs) > 0 {
+			t.Errorf("References returned %d results, want all as partial results", len(refs))
+		}
+		var gotRefs []protocol.Location
+		partialResults(token, &gotRefs, &wantRefs)
+
+		// implementations
+		loc = env.RegexpSearch("a/a.go", `type (Shape)`)
+		wantImpls := env.Implementations(loc)
+		if len(wantImpls) != 3 {
+			t.Fatalf("Implementations returned %d results, want 3", len(wantImpls))
+		}
+		token = "implementation"
+		impls, err := env.Editor.Server.Implementation(env.Ctx, &protocol.ImplementationParams{
+			TextDocumentPositionParams: protocol.LocationTextDocumentPositionParams(loc),
+			PartialResultParams:        protocol.PartialResultParams{PartialResultToken: &token},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(impls) > 0 {
+			t.Errorf("Implementation returned %d results, want all as partial results", len(impls))
+		}
+		var gotImpls []protocol.Location
+		partialResults(token, &gotImpls, &wantImpls)
+
+		// workspace symbols
+		wantSyms := env.Symbol("Area")
+		if len(wantSyms) != 4 {
+			t.Fatalf("Symbol returned %d results, want 4", len(wantSyms))
+		}
+		token = "symbol"
+		syms, err := env.Editor.Server.Symbol(env.Ctx, &protocol.WorkspaceSymbolParams{
+			Query:               "Area",
+			PartialResultParams: protocol.PartialResultParams{PartialResultToken: &token},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(syms) > 0 {
+			t.Errorf("Symbol returned %d results, want all as partial results", len(syms))
+		}
+		var gotSyms []protocol.SymbolInformation
+		partialResults(token, &gotSyms, &wantSyms)
+	})
+}
+
+// sortedStrings formats the elements of the slice pointed to by ptr,
+// in sorted order.
+func sortedStrings(ptr interface{}) []string {
+	var strs []string
+	switch ptr := ptr.(type) {
+	case *[]protocol.Location:
+		for _, loc := range *ptr {
+			strs = append(strs, fmt.Sprint(loc))
+		}
+	case *[]protocol.SymbolInformation:
+		for _, sym := range *ptr {
+			strs = append(strs, fmt.Sprint(sym.Name, sym.Location))
+		}
+	}
+	sort.Strings(strs)
+	return strs
+}
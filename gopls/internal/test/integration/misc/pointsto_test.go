@@ -0,0 +1,165 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+const pointsToSrc = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import (
+	"errors"
+	"io"
+)
+
+type T struct{ next *T }
+
+func newT() *T { return &T{} }
+
+func link(x, y *T) { x.next = y }
+
+func Ptrs() *T {
+	a, b := new(T), newT()
+	link(a, b)
+	return a.next
+}
+
+func Chans() {
+	ch := make(chan int)
+	other := make(chan int)
+	go func() { ch <- 1 }()
+	<-ch
+	close(ch)
+	other <- 2
+}
+
+type MyErr struct{}
+
+func (*MyErr) Error() string { return "" }
+
+var ErrBad = errors.New("bad")
+
+func read(i int) error {
+	switch i {
+	case 0:
+		return io.EOF
+	case 1:
+		return ErrBad
+	}
+	return &MyErr{}
+}
+
+func Errs() {
+	err := read(0)
+	_ = err
+}
+`
+
+// pointsToQuery runs the command and decodes its result.
+func pointsToQuery(t *testing.T, env *Env, cmd protocol.Command, result any) {
+	t.Helper()
+	env.ExecuteCommand(&protocol.ExecuteCommandParams{
+		Command:   cmd.Command,
+		Arguments: cmd.Arguments,
+	}, result)
+}
+
+func TestPointsTo(t *testing.T) {
+	Run(t, pointsToSrc, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		cmd, err := command.NewPointsToCommand("", env.RegexpSearch("a/a.go", `return (a.next)`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result command.PointsToResult
+		pointsToQuery(t, env, cmd, &result)
+		if result.Type != "*T" {
+			t.Errorf("Type = %q, want *T", result.Type)
+		}
+		// a.next holds only b, the composite literal of newT.
+		var descs []string
+		for _, l := range result.Labels {
+			descs = append(descs, l.Desc)
+			if l.Type != "T" {
+				t.Errorf("label %s has type %s, want T", l.Desc, l.Type)
+			}
+		}
+		if want := []string{"composite literal"}; !reflect.DeepEqual(descs, want) {
+			t.Errorf("labels = %v, want %v", descs, want)
+		} else if got, want := result.Labels[0].Location.Range.Start, env.RegexpSearch("a/a.go", `return &T(\{)`).Range.Start; got != want {
+			t.Errorf("label at %v, want %v", got, want)
+		}
+	})
+}
+
+func TestPeers(t *testing.T) {
+	Run(t, pointsToSrc, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		cmd, err := command.NewPeersCommand("", env.RegexpSearch("a/a.go", `(<-)ch`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result command.PeersResult
+		pointsToQuery(t, env, cmd, &result)
+		start := func(locs []protocol.Location) []protocol.Position {
+			var positions []protocol.Position
+			for _, loc := range locs {
+				positions = append(positions, loc.Range.Start)
+			}
+			return positions
+		}
+		at := func(re string) []protocol.Position {
+			return []protocol.Position{env.RegexpSearch("a/a.go", re).Range.Start}
+		}
+		for _, test := range []struct {
+			name string
+			got  []protocol.Location
+			want []protocol.Position
+		}{
+			{"Allocs", result.Allocs, at(`ch := make(\()`)},
+			{"Sends", result.Sends, at(`ch (<-) 1`)},
+			{"Receives", result.Receives, at(`(<-)ch`)},
+			{"Closes", result.Closes, at(`close(\()ch`)},
+		} {
+			if got := start(test.got); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s = %v, want %v", test.name, got, test.want)
+			}
+		}
+	})
+}
+
+func TestWhichErrs(t *testing.T) {
+	Run(t, pointsToSrc, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		cmd, err := command.NewWhichErrsCommand("", env.RegexpSearch("a/a.go", `_ = (err)`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result command.WhichErrsResult
+		pointsToQuery(t, env, cmd, &result)
+		if want := []string{"ErrBad", "io.EOF"}; !reflect.DeepEqual(result.Globals, want) {
+			t.Errorf("Globals = %v, want %v", result.Globals, want)
+		}
+		var types []string
+		for _, typ := range result.Types {
+			types = append(types, typ.Type)
+		}
+		if want := []string{"*MyErr"}; !reflect.DeepEqual(types, want) {
+			t.Errorf("Types = %v, want %v", types, want)
+		}
+	})
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
 	"golang.org/x/tools/gopls/internal/test/integration"
 	. "golang.org/x/tools/gopls/internal/test/integration"
 )
@@ -572,3 +573,50 @@ func fileLocations(env *integration.Env, locs []protocol.Location) []string {
 	sort.Strings(got)
 	return got
 }
+
+func TestTagReferences(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a.go --
+package a
+
+type T struct {
+	F int ` + "`json:\"f\"`" + `
+}
+
+var _ = T{}.F
+
+var _ = map[string]int{"f": 1}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a.go")
+		loc := env.RegexpSearch("a.go", `(F) int`)
+		cmd, err := command.NewTagReferencesCommand("", loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []command.TagReference
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		}, &got)
+		want := []command.TagReference{
+			{Location: loc},
+			{Location: env.RegexpSearch("a.go", `T\{\}\.(F)`)},
+			{Location: env.RegexpSearch("a.go", `"(f)": 1`), Textual: true, Key: "f"},
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("TagReferences: unexpected result (-want +got):\n%s", diff)
+		}
+
+		// Without the tagReferences setting, the ordinary
+		// references query reports no textual references.
+		refs := env.References(loc)
+		if len(refs) != 2 {
+			t.Errorf("References returned %d locations, want 2: %v", len(refs), refs)
+		}
+	})
+}
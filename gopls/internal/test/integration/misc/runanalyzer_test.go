@@ -0,0 +1,89 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestRunAnalyzer(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func _() error {
+	err := f()
+	if true {
+		err := f()
+		_ = err
+	}
+	return err
+}
+
+func f() error { return F() }
+
+func F() error { return nil }
+-- a/b/b.go --
+package b
+
+func _(x int) {
+	if true {
+		x := 1
+		_ = x
+	}
+	_ = x
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		// The shadow analyzer is disabled by default.
+		env.AfterChange(NoDiagnostics(ForFile("a/a.go")))
+
+		runAnalyzerCommand := func(name, path string) *protocol.ExecuteCommandParams {
+			cmd, err := command.NewRunAnalyzerCommand("", command.RunAnalyzerArgs{
+				Analyzer: name,
+				URI:      env.Sandbox.Workdir.URI(path),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return &protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}
+		}
+
+		// A file: only its package is analyzed.
+		var result map[protocol.DocumentURI][]protocol.Diagnostic
+		env.ExecuteCommand(runAnalyzerCommand("shadow", "a/a.go"), &result)
+		if len(result) != 1 || len(result[env.Sandbox.Workdir.URI("a/a.go")]) != 1 {
+			t.Errorf("RunAnalyzer(shadow, a/a.go) = %v, want one diagnostic in a/a.go", result)
+		}
+
+		// A directory: all packages within it are analyzed.
+		result = nil
+		env.ExecuteCommand(runAnalyzerCommand("shadow", "a"), &result)
+		for _, path := range []string{"a/a.go", "a/b/b.go"} {
+			if diags := result[env.Sandbox.Workdir.URI(path)]; len(diags) != 1 || diags[0].Source != "analyzer/shadow" {
+				t.Errorf("RunAnalyzer(shadow, a) reported %v for %s, want one shadow diagnostic", diags, path)
+			}
+		}
+
+		// The results are not published.
+		env.AfterChange(NoDiagnostics(ForFile("a/a.go")))
+
+		if _, err := env.Editor.ExecuteCommand(env.Ctx, runAnalyzerCommand("nosuchanalyzer", "a/a.go")); err == nil {
+			t.Errorf("RunAnalyzer(nosuchanalyzer) succeeded, want error")
+		}
+	})
+}
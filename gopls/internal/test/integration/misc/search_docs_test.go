@@ -0,0 +1,106 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestSearchDocs(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+// Package a manages the widget cache.
+package a
+
+import "strings"
+
+// Cache holds widgets in memory.
+type Cache struct {
+	// Size is the number of cached widgets.
+	Size int
+}
+
+// Evict removes the least recently used widget from the cache.
+func (c *Cache) Evict() {}
+
+// normalize returns the key of a widget, in lower case.
+func normalize(name string) string {
+	return strings.ToLower(name)
+}
+
+const (
+	// MaxWidgets is the capacity of a Cache.
+	MaxWidgets = 10
+)
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		search := func(query string, limit int) command.SearchDocsResult {
+			cmd, err := command.NewSearchDocsCommand("", command.SearchDocsArgs{Query: query, Limit: limit})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var result command.SearchDocsResult
+			env.ExecuteCommand(&protocol.ExecuteCommandParams{
+				Command:   cmd.Command,
+				Arguments: cmd.Arguments,
+			}, &result)
+			return result
+		}
+		names := func(result command.SearchDocsResult) []string {
+			var names []string
+			for _, m := range result.Matches {
+				names = append(names, m.PkgPath+"."+m.Name)
+			}
+			return names
+		}
+
+		got := search("WIDGETS", 0)
+		want := []string{"mod.com/a.Cache", "mod.com/a.Cache.Size", "mod.com/a.MaxWidgets"}
+		if diff := cmp.Diff(want, names(got)); diff != "" || got.Truncated {
+			t.Errorf("search(WIDGETS) mismatch (truncated=%t) (-want +got):\n%s", got.Truncated, diff)
+		}
+		if m := got.Matches[1]; m.Kind != protocol.Field || m.Snippet != "Size is the number of cached widgets." {
+			t.Errorf("match for Cache.Size has kind %v and snippet %q", m.Kind, m.Snippet)
+		}
+		if loc := got.Matches[0].Location; loc != env.RegexpSearch("a/a.go", "type (Cache)") {
+			t.Errorf("location of Cache = %v", loc)
+		}
+
+		// A doc comment must contain all the words.
+		got = search("widget lower", 0)
+		want = []string{"mod.com/a.normalize"}
+		if diff := cmp.Diff(want, names(got)); diff != "" {
+			t.Errorf("search(widget lower) mismatch (-want +got):\n%s", diff)
+		}
+
+		// Package doc comments match too, and the limit applies.
+		got = search("widget", 2)
+		want = []string{"mod.com/a.", "mod.com/a.Cache"}
+		if diff := cmp.Diff(want, names(got)); diff != "" || !got.Truncated {
+			t.Errorf("search(widget, 2) mismatch (truncated=%t) (-want +got):\n%s", got.Truncated, diff)
+		}
+
+		// Exported declarations of dependencies are searched.
+		got = search("letters mapped to their lower case", 0)
+		found := false
+		for _, name := range names(got) {
+			if name == "strings.ToLower" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("search(letters mapped to their lower case) = %v, want strings.ToLower among them", names(got))
+		}
+	})
+}
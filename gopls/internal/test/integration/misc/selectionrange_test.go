@@ -0,0 +1,56 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestSelectionRange(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a.go --
+package a
+
+func f(x, y int) int {
+	return x + y*2
+}
+`
+	Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a.go")
+		loc := env.RegexpSearch("a.go", `y\*2`)
+		ranges := env.SelectionRange("a.go", loc.Range.Start)
+		if len(ranges) != 1 {
+			t.Fatalf("SelectionRange returned %d ranges, want 1", len(ranges))
+		}
+
+		// The enclosing ranges include the binary expressions y*2 and x + y*2.
+		text, _ := env.Editor.BufferText("a.go")
+		m := protocol.NewMapper(loc.URI, []byte(text))
+		var got []string
+		for r := &ranges[0]; r != nil; r = r.Parent {
+			start, end, err := m.RangeOffsets(r.Range)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, text[start:end])
+		}
+		for _, want := range []string{"y*2", "x + y*2"} {
+			found := false
+			for _, s := range got {
+				found = found || s == want
+			}
+			if !found {
+				t.Errorf("SelectionRange ranges %q do not include %q", got, want)
+			}
+		}
+	})
+}
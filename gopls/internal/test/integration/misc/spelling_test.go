@@ -0,0 +1,97 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/server"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestSpelling(t *testing.T) {
+	const src = `
+-- go.mod --
+module example.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import "errors"
+
+// Recieve returns the data.
+func Recieve() error {
+	return errors.New("cannot seperate the fields")
+}
+
+type T struct {
+	F int ` + "`help:\"recieve all data\"`" + `
+}
+`
+	WithOptions(
+		Settings{"spelling": true},
+	).Run(t, src, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		var diags protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "Recieve returns"), WithMessage(`"Recieve" is a misspelling of "Receive"`)),
+			Diagnostics(env.AtRegexp("a/a.go", "seperate"), WithMessage(`"seperate" is a misspelling of "separate"`)),
+			ReadDiagnostics("a/a.go", &diags),
+		)
+		if got := len(diags.Diagnostics); got != 2 {
+			t.Fatalf("got %d diagnostics, want 2 (none for identifiers or tags): %v", got, diags.Diagnostics)
+		}
+
+		// Replace the misspelling in the string.
+		var seperate, recieve protocol.Diagnostic
+		for _, d := range diags.Diagnostics {
+			if strings.Contains(d.Message, "seperate") {
+				seperate = d
+			} else {
+				recieve = d
+			}
+		}
+		var replace, add *protocol.CodeAction
+		for _, action := range env.GetQuickFixes("a/a.go", []protocol.Diagnostic{seperate}) {
+			if action.Title == `Replace with "separate"` {
+				action := action
+				replace = &action
+			}
+		}
+		if replace == nil {
+			t.Fatalf("no replacement quick fix for %q", seperate.Message)
+		}
+		env.ApplyCodeAction(*replace)
+		if got := env.BufferText("a/a.go"); !strings.Contains(got, "cannot separate the fields") {
+			t.Errorf("replacement was not applied:\n%s", got)
+		}
+		env.AfterChange(NoDiagnostics(env.AtRegexp("a/a.go", "separate")))
+
+		// Accept the name of the function.
+		for _, action := range env.GetQuickFixes("a/a.go", []protocol.Diagnostic{recieve}) {
+			if action.Title == `Add "Recieve" to dictionary` {
+				action := action
+				add = &action
+			}
+		}
+		if add == nil {
+			t.Fatalf("no dictionary quick fix for %q", recieve.Message)
+		}
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   add.Command.Command,
+			Arguments: add.Command.Arguments,
+		}, nil)
+		env.AfterChange(
+			CompletedWork(server.DiagnosticWorkTitle(server.FromAddToDictionary), 1, true),
+			NoDiagnostics(ForFile("a/a.go")),
+		)
+		if got, want := env.ReadWorkspaceFile(".gopls-dictionary"), "Recieve\n"; got != want {
+			t.Errorf("dictionary contains %q, want %q", got, want)
+		}
+	})
+}
@@ -7,6 +7,7 @@ package misc
 import (
 	"testing"
 
+	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/internal/aliases"
 	"golang.org/x/tools/internal/testenv"
 
@@ -79,12 +80,12 @@ var FooErr error = errors.New("foo")
 	).Run(t, files, func(t *testing.T, env *Env) {
 		env.OpenFile("a/a.go")
 		env.AfterChange(
-			Diagnostics(env.AtRegexp("a/a.go", "sort.Slice"), FromSource("sortslice")),
-			Diagnostics(env.AtRegexp("a/a.go", "sort.Slice.(slice)"), FromSource("SA1028")),
-			Diagnostics(env.AtRegexp("a/a.go", "var (FooErr)"), FromSource("ST1012")),
-			Diagnostics(env.AtRegexp("a/a.go", `"12234"`), FromSource("SA1024")),
-			Diagnostics(env.AtRegexp("a/a.go", "testGenerics.*(p P)"), FromSource("SA4009")),
-			Diagnostics(env.AtRegexp("a/a.go", "q = (&\\*p)"), FromSource("SA4001")),
+			Diagnostics(env.AtRegexp("a/a.go", "sort.Slice"), FromSource("analyzer/sortslice")),
+			Diagnostics(env.AtRegexp("a/a.go", "sort.Slice.(slice)"), FromSource("analyzer/SA1028")),
+			Diagnostics(env.AtRegexp("a/a.go", "var (FooErr)"), FromSource("analyzer/ST1012")),
+			Diagnostics(env.AtRegexp("a/a.go", `"12234"`), FromSource("analyzer/SA1024")),
+			Diagnostics(env.AtRegexp("a/a.go", "testGenerics.*(p P)"), FromSource("analyzer/SA4009")),
+			Diagnostics(env.AtRegexp("a/a.go", "q = (&\\*p)"), FromSource("analyzer/SA4001")),
 		)
 	})
 }
@@ -132,7 +133,59 @@ func Foo(enabled interface{}) {
 	).Run(t, files, func(t *testing.T, env *Env) {
 		env.OpenFile("p.go")
 		env.AfterChange(
-			Diagnostics(env.AtRegexp("p.go", ", (enabled)"), FromSource("SA9008")),
+			Diagnostics(env.AtRegexp("p.go", ", (enabled)"), FromSource("analyzer/SA9008")),
 		)
 	})
 }
+
+func TestStaticcheckConfig(t *testing.T) {
+	testenv.NeedsGo1Point(t, 20) // staticcheck is only supported at Go 1.20+
+
+	const files = `
+-- go.mod --
+module mod.test
+
+go 1.18
+-- staticcheck.conf --
+checks = ["inherit", "ST1003", "-ST1012"]
+initialisms = ["inherit", "FOO"]
+-- a/a.go --
+package a
+
+import "errors"
+
+var BazErr = errors.New("baz") // ST1012, but disabled
+
+func GetFoo() {} // ST1003, enabled with custom initialisms
+-- b/staticcheck.conf --
+checks = ["inherit", "-ST1003", "ST1012"]
+-- b/b.go --
+package b
+
+import "errors"
+
+var BarErr = errors.New("bar") // ST1012, reenabled
+
+func GetFoo() {} // ST1003, but disabled
+`
+
+	WithOptions(
+		Settings{"staticcheck": true},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.OpenFile("b/b.go")
+		var diags protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", "GetFoo"), FromSource("analyzer/ST1003"), WithMessage("GetFOO")),
+			NoDiagnostics(env.AtRegexp("a/a.go", "BazErr")),
+			Diagnostics(env.AtRegexp("b/b.go", "BarErr"), FromSource("analyzer/ST1012")),
+			NoDiagnostics(env.AtRegexp("b/b.go", "GetFoo")),
+			ReadDiagnostics("a/a.go", &diags),
+		)
+		for _, d := range diags.Diagnostics {
+			if want := "https://staticcheck.dev/docs/checks/#ST1003"; d.Source == "analyzer/ST1003" && (d.CodeDescription == nil || d.CodeDescription.Href != want) {
+				t.Errorf("ST1003 diagnostic has code description %v, want %s", d.CodeDescription, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestTaintAnalysis(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+import (
+	"database/sql"
+	"net/http"
+)
+
+func handle(db *sql.DB, r *http.Request) {
+	name := r.FormValue("name")
+	query := "SELECT * FROM users WHERE name = '" + name + "'"
+	db.Query(query)
+}
+`
+	WithOptions(
+		Settings{"analyses": map[string]any{"sqlinjection": true}},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		var diags protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", `db.Query`), FromSource("analyzer/sqlinjection")),
+			ReadDiagnostics("a/a.go", &diags),
+		)
+		// The related information traces the flow from its source.
+		var got []string
+		for _, rel := range diags.Diagnostics[0].RelatedInformation {
+			got = append(got, rel.Message)
+		}
+		want := []string{
+			"untrusted input from (*net/http.Request).FormValue",
+			"flows into string concatenation",
+			"flows into string concatenation",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("related information of %q is %q, want %q", diags.Diagnostics[0].Message, got, want)
+		}
+
+		// The analysis does not run while the package has unsaved changes...
+		env.RegexpReplace("a/a.go", `db.Query\(query\)`, "db.Query(query + \"\")")
+		env.AfterChange(NoDiagnostics(ForFile("a/a.go")))
+
+		// ...but does once it is saved.
+		env.SaveBuffer("a/a.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a.go", `db.Query`), FromSource("analyzer/sqlinjection")),
+		)
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The badcall command is an analyzer tool for TestAnalyzerTools. It
+// reports calls to functions, in any package, whose doc comment
+// contains "BAD", which requires facts about imported packages.
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+var analyzer = &analysis.Analyzer{
+	Name:      "badcall",
+	Doc:       "report calls to functions documented as BAD",
+	Run:       run,
+	FactTypes: []analysis.Fact{new(isBad)},
+}
+
+type isBad struct{}
+
+func (*isBad) AFact() {}
+
+func run(pass *analysis.Pass) (any, error) {
+	for _, f := range pass.Files {
+		for _, decl := range f.Decls {
+			if decl, ok := decl.(*ast.FuncDecl); ok && strings.Contains(decl.Doc.Text(), "BAD") {
+				pass.ExportObjectFact(pass.TypesInfo.Defs[decl.Name], new(isBad))
+			}
+		}
+	}
+	for _, f := range pass.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				var id *ast.Ident
+				switch fun := call.Fun.(type) {
+				case *ast.Ident:
+					id = fun
+				case *ast.SelectorExpr:
+					id = fun.Sel
+				}
+				if fn, ok := pass.TypesInfo.Uses[id].(*types.Func); ok && pass.ImportObjectFact(fn, new(isBad)) {
+					pass.Report(analysis.Diagnostic{
+						Pos:     id.Pos(),
+						End:     id.End(),
+						Message: "call of bad function " + fn.Name(),
+						SuggestedFixes: []analysis.SuggestedFix{{
+							Message: "Delete call",
+							TextEdits: []analysis.TextEdit{{
+								Pos: call.Pos(),
+								End: call.End(),
+							}},
+						}},
+					})
+				}
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+func main() { unitchecker.Main(analyzer) }
@@ -0,0 +1,70 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/gopls/internal/protocol"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestTypeHierarchy(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+type Shape interface{ Area() float64 }
+
+type Base struct{}
+
+type Square struct{ Base }
+
+func (Square) Area() float64 { return 0 }
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+
+		items := env.PrepareTypeHierarchy(env.RegexpSearch("a/a.go", "Square struct"))
+		if got := itemNames(items); !cmp.Equal(got, []string{"Square"}) {
+			t.Fatalf("PrepareTypeHierarchy(Square) = %v, want [Square]", got)
+		}
+		square := items[0]
+
+		// Square implements Shape and embeds Base.
+		if got, want := itemNames(env.Supertypes(square)), []string{"Base", "Shape"}; !cmp.Equal(got, want) {
+			t.Errorf("Supertypes(Square) = %v, want %v", got, want)
+		}
+		if got := itemNames(env.Subtypes(square)); len(got) > 0 {
+			t.Errorf("Subtypes(Square) = %v, want none", got)
+		}
+
+		shape := env.PrepareTypeHierarchy(env.RegexpSearch("a/a.go", "Shape interface"))[0]
+		if got, want := itemNames(env.Subtypes(shape)), []string{"Square"}; !cmp.Equal(got, want) {
+			t.Errorf("Subtypes(Shape) = %v, want %v", got, want)
+		}
+
+		base := env.PrepareTypeHierarchy(env.RegexpSearch("a/a.go", "Base struct"))[0]
+		if got, want := itemNames(env.Subtypes(base)), []string{"Square"}; !cmp.Equal(got, want) {
+			t.Errorf("Subtypes(Base) = %v, want %v", got, want)
+		}
+	})
+}
+
+// itemNames returns the sorted names of the type hierarchy items.
+func itemNames(items []protocol.TypeHierarchyItem) []string {
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	sort.Strings(names)
+	return names
+}
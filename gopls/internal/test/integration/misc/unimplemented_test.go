@@ -0,0 +1,47 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package misc
+
+import (
+	"strings"
+	"testing"
+
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+// Test that the requests that gopls does not implement fail with a
+// "not yet implemented" error, rather than an empty result.
+func TestUnimplementedRequests(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+func F() {
+	x := 1
+	println(x)
+}
+`
+	Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		loc := env.RegexpSearch("a/a.go", `println\(x\)`)
+
+		check := func(method string, err error) {
+			t.Helper()
+			if err == nil || !strings.Contains(err.Error(), `"`+method+`" not yet implemented`) {
+				t.Errorf("%s: got error %v, want not implemented", method, err)
+			}
+		}
+		_, err := env.Editor.Moniker(env.Ctx, loc)
+		check("Moniker", err)
+		_, err = env.Editor.InlineValue(env.Ctx, loc)
+		check("InlineValue", err)
+		_, err = env.Editor.WillRenameFiles(env.Ctx, "a/a.go", "a/b.go")
+		check("WillRenameFiles", err)
+	})
+}
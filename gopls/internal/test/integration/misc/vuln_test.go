@@ -483,7 +483,7 @@ func TestRunVulncheckPackageDiagnostics(t *testing.T) {
 					{
 						msg:      "golang.org/amod has known vulnerabilities GO-2022-01, GO-2022-03.",
 						severity: protocol.SeverityInformation,
-						source:   string(cache.Vulncheck),
+						source:   cache.Vulncheck.Path(),
 						codeActions: []string{
 							"Run govulncheck to verify",
 							"Upgrade to v1.0.6",
@@ -503,7 +503,7 @@ func TestRunVulncheckPackageDiagnostics(t *testing.T) {
 					{
 						msg:      "golang.org/bmod has a vulnerability GO-2022-02.",
 						severity: protocol.SeverityInformation,
-						source:   string(cache.Vulncheck),
+						source:   cache.Vulncheck.Path(),
 						codeActions: []string{
 							"Run govulncheck to verify",
 						},
@@ -669,7 +669,7 @@ func TestRunVulncheckWarning(t *testing.T) {
 					{
 						msg:      "golang.org/amod has a vulnerability used in the code: GO-2022-01.",
 						severity: protocol.SeverityWarning,
-						source:   string(cache.Govulncheck),
+						source:   cache.Govulncheck.Path(),
 						codeActions: []string{
 							"Upgrade to v1.0.4",
 							"Upgrade to latest",
@@ -679,7 +679,7 @@ func TestRunVulncheckWarning(t *testing.T) {
 					{
 						msg:      "golang.org/amod has a vulnerability GO-2022-03 that is not used in the code.",
 						severity: protocol.SeverityInformation,
-						source:   string(cache.Govulncheck),
+						source:   cache.Govulncheck.Path(),
 						codeActions: []string{
 							"Upgrade to v1.0.6",
 							"Upgrade to latest",
@@ -699,7 +699,7 @@ func TestRunVulncheckWarning(t *testing.T) {
 					{
 						msg:      "golang.org/bmod has a vulnerability used in the code: GO-2022-02.",
 						severity: protocol.SeverityWarning,
-						source:   string(cache.Govulncheck),
+						source:   cache.Govulncheck.Path(),
 						codeActions: []string{
 							"Reset govulncheck result", // no fix, but we should give an option to reset.
 						},
@@ -822,7 +822,7 @@ func TestGovulncheckInfo(t *testing.T) {
 					{
 						msg:      "golang.org/bmod has a vulnerability GO-2022-02 that is not used in the code.",
 						severity: protocol.SeverityInformation,
-						source:   string(cache.Govulncheck),
+						source:   cache.Govulncheck.Path(),
 						codeActions: []string{
 							"Reset govulncheck result",
 						},
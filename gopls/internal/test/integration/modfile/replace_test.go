@@ -0,0 +1,122 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modfile
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	"golang.org/x/tools/gopls/internal/test/compare"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+const replaceProxy = `
+-- example.com/lib@v1.0.0/go.mod --
+module example.com/lib
+
+go 1.12
+-- example.com/lib@v1.0.0/lib.go --
+package lib
+
+const Name = "lib"
+-- example.com/lib@v1.0.1/go.mod --
+module example.com/lib
+
+go 1.12
+-- example.com/lib@v1.0.1/lib.go --
+package lib
+
+const Name = "lib"
+`
+
+func TestLocalReplace(t *testing.T) {
+	const files = `
+-- main/go.mod --
+module mod.com
+
+go 1.18
+
+require example.com/lib v1.0.0
+
+replace example.com/lib => example.com/lib v1.0.1
+-- main/main.go --
+package main
+
+import "example.com/lib"
+
+var _ = lib.Name
+-- lib/go.mod --
+module example.com/lib
+
+go 1.18
+-- lib/lib.go --
+package lib
+
+const Name = "local"
+-- other/go.mod --
+module other.com
+
+go 1.18
+`
+	const wantReplaced = `module mod.com
+
+go 1.18
+
+require example.com/lib v1.0.0
+
+// gopls: restore replace example.com/lib => example.com/lib v1.0.1
+replace example.com/lib => ../lib
+`
+	WithOptions(
+		ProxyFiles(replaceProxy),
+		WriteGoSum("main"),
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("main/go.mod")
+		original := env.BufferText("main/go.mod")
+
+		applyAction := func(re, title string) {
+			t.Helper()
+			loc := env.RegexpSearch("main/go.mod", re)
+			var titles []string
+			for _, action := range env.CodeAction(loc, nil, 0) {
+				if action.Title == title {
+					env.ApplyCodeAction(action)
+					return
+				}
+				titles = append(titles, action.Title)
+			}
+			t.Fatalf("no code action %q at %q; got %q", title, re, titles)
+		}
+
+		applyAction("require", "Replace with ../lib")
+		if got := env.BufferText("main/go.mod"); got != wantReplaced {
+			t.Errorf("go.mod after replace does not match:\n%s", compare.Text(wantReplaced, got))
+		}
+
+		// The directory must contain the replaced module.
+		cmd, err := command.NewAddReplaceCommand("", command.AddReplaceArgs{
+			URI:        env.Sandbox.Workdir.URI("main/go.mod"),
+			ModulePath: "example.com/lib",
+			Dir:        "../other",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = env.Editor.ExecuteCommand(env.Ctx, &protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		})
+		if err == nil || !strings.Contains(err.Error(), `contains module "other.com"`) {
+			t.Errorf("AddReplace(../other) returned error %v, want mismatched module error", err)
+		}
+
+		applyAction("=> ../lib", "Restore previous replace of example.com/lib")
+		if got := env.BufferText("main/go.mod"); got != original {
+			t.Errorf("go.mod after restore does not match:\n%s", compare.Text(original, got))
+		}
+	})
+}
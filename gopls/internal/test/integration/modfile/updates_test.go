@@ -0,0 +1,217 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modfile
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/server"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+const updatesProxy = `
+-- example.com/lib@v1.0.0/go.mod --
+module example.com/lib
+
+go 1.12
+-- example.com/lib@v1.0.0/lib.go --
+package lib
+
+const Name = "lib"
+-- example.com/lib@v1.1.0/go.mod --
+module example.com/lib
+
+go 1.12
+
+retract v1.0.0 // contains a data race
+-- example.com/lib@v1.1.0/lib.go --
+package lib
+
+const Name = "lib"
+-- github.com/owner/repo@v1.2.0/go.mod --
+module github.com/owner/repo
+
+go 1.12
+-- github.com/owner/repo@v1.2.0/repo.go --
+package repo
+
+const Name = "repo"
+-- github.com/owner/repo@v1.2.1/go.mod --
+module github.com/owner/repo
+
+go 1.12
+-- github.com/owner/repo@v1.2.1/repo.go --
+package repo
+
+const Name = "repo"
+`
+
+func TestDependencyUpdates(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+
+require (
+	example.com/lib v1.0.0
+	github.com/owner/repo v1.2.0
+)
+-- main.go --
+package main
+
+import (
+	"example.com/lib"
+	"github.com/owner/repo"
+)
+
+var _, _ = lib.Name, repo.Name
+`
+	WithOptions(
+		ProxyFiles(updatesProxy),
+		WriteGoSum("."),
+		Settings{"dependencyUpdates": true},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("go.mod")
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(
+				env.AtRegexp("go.mod", "example.com/lib"),
+				WithMessage("example.com/lib v1.0.0 has been retracted: contains a data race"),
+				WithSeverityTags("upgrade-advisor/dependency-update", protocol.SeverityWarning, nil),
+			),
+			Diagnostics(
+				env.AtRegexp("go.mod", "github.com/owner/repo"),
+				WithMessage("github.com/owner/repo v1.2.1 is available (patch release)"),
+				WithSeverityTags("upgrade-advisor/dependency-update", protocol.SeverityInformation, nil),
+			),
+			ReadDiagnostics("go.mod", &d),
+		)
+		var repoDiag protocol.Diagnostic
+		for _, diag := range d.Diagnostics {
+			if diag.Code == "v1.2.1" {
+				repoDiag = diag
+			}
+		}
+		if repoDiag.CodeDescription == nil {
+			t.Fatalf("no code description for %v", repoDiag)
+		}
+		if got, want := repoDiag.CodeDescription.Href, protocol.URI("https://github.com/owner/repo/releases/tag/v1.2.1"); got != want {
+			t.Errorf("CodeDescription.Href = %q, want %q", got, want)
+		}
+
+		// Snoozing the notice hides it until a newer version is released.
+		var snoozed bool
+		for _, action := range env.GetQuickFixes("go.mod", []protocol.Diagnostic{repoDiag}) {
+			if action.Title == "Snooze notices for github.com/owner/repo" {
+				env.ApplyCodeAction(action)
+				snoozed = true
+			}
+		}
+		if !snoozed {
+			t.Fatal("no snooze action for github.com/owner/repo")
+		}
+		env.OnceMet(
+			CompletedWork(server.DiagnosticWorkTitle(server.FromSnoozeUpdate), 1, true),
+			NoDiagnostics(env.AtRegexp("go.mod", "github.com/owner/repo")),
+			Diagnostics(env.AtRegexp("go.mod", "example.com/lib")),
+		)
+	})
+}
+
+const retractionsProxy = `
+-- example.com/old@v1.0.0/go.mod --
+module example.com/old
+
+go 1.12
+-- example.com/old@v1.0.0/old.go --
+package old
+
+const Name = "old"
+-- example.com/old@v1.1.0/go.mod --
+module example.com/old
+
+go 1.12
+
+retract v1.1.0 // published by mistake
+-- example.com/old@v1.1.0/old.go --
+package old
+
+const Name = "old"
+-- example.com/dep@v1.0.0/go.mod --
+// Deprecated: use example.com/new instead.
+module example.com/dep
+
+go 1.12
+-- example.com/dep@v1.0.0/dep.go --
+package dep
+
+const Name = "dep"
+`
+
+func TestRetractionsAndDeprecations(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+
+require (
+	example.com/dep v1.0.0
+	example.com/old v1.1.0
+)
+-- main.go --
+package main
+
+import (
+	"example.com/dep"
+	"example.com/old"
+)
+
+var _, _ = dep.Name, old.Name
+`
+	WithOptions(
+		ProxyFiles(retractionsProxy),
+		WriteGoSum("."),
+		Settings{"dependencyUpdates": true},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("go.mod")
+		var d protocol.PublishDiagnosticsParams
+		env.AfterChange(
+			Diagnostics(
+				env.AtRegexp("go.mod", "example.com/dep"),
+				WithMessage("example.com/dep is deprecated: use example.com/new instead."),
+				WithSeverityTags("upgrade-advisor/dependency-update", protocol.SeverityWarning, []protocol.DiagnosticTag{protocol.Deprecated}),
+			),
+			Diagnostics(
+				env.AtRegexp("go.mod", "example.com/old"),
+				WithMessage("example.com/old v1.1.0 has been retracted: published by mistake"),
+			),
+			ReadDiagnostics("go.mod", &d),
+		)
+
+		// The retracted version has no newer version to upgrade to,
+		// so the quick fix downgrades it.
+		var downgraded bool
+		for _, action := range env.GetQuickFixes("go.mod", d.Diagnostics) {
+			if action.Title == "Downgrade to v1.0.0" {
+				env.ApplyCodeAction(action)
+				downgraded = true
+			}
+		}
+		if !downgraded {
+			t.Fatal("no downgrade action for example.com/old")
+		}
+		env.AfterChange(
+			NoDiagnostics(env.AtRegexp("go.mod", "example.com/old")),
+			Diagnostics(env.AtRegexp("go.mod", "example.com/dep")),
+		)
+		if got := env.BufferText("go.mod"); !strings.Contains(got, "example.com/old v1.0.0") {
+			t.Errorf("go.mod does not require example.com/old v1.0.0:\n%s", got)
+		}
+	})
+}
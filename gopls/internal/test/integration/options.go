@@ -7,6 +7,7 @@ package integration
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/gopls/internal/test/integration/fake"
@@ -19,6 +20,7 @@ type runConfig struct {
 	modes         Mode
 	noLogsOnError bool
 	writeGoSum    []string
+	awaitTimeout  time.Duration
 }
 
 func defaultConfig() runConfig {
@@ -83,6 +85,14 @@ func NoLogsOnError() RunOption {
 	})
 }
 
+// AwaitTimeout sets the time after which Env.AwaitFunc gives up waiting
+// for its condition. By default, it waits until the test times out.
+func AwaitTimeout(d time.Duration) RunOption {
+	return optionSetter(func(opts *runConfig) {
+		opts.awaitTimeout = d
+	})
+}
+
 // WindowsLineEndings configures the editor to use windows line endings.
 func WindowsLineEndings() RunOption {
 	return optionSetter(func(opts *runConfig) {
@@ -90,6 +100,15 @@ func WindowsLineEndings() RunOption {
 	})
 }
 
+// Latency configures the editor to simulate a slow connection to the
+// server, holding its messages for the given duration before sending
+// them in bursts.
+func Latency(d time.Duration) RunOption {
+	return optionSetter(func(opts *runConfig) {
+		opts.editor.Latency = d
+	})
+}
+
 // ClientName sets the LSP client name.
 func ClientName(name string) RunOption {
 	return optionSetter(func(opts *runConfig) {
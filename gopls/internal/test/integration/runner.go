@@ -227,6 +227,8 @@ func (r *Runner) Run(t *testing.T, files string, test TestFunc, opts ...RunOptio
 				Editor:  editor,
 				Server:  ts,
 				Awaiter: awaiter,
+
+				awaitTimeout: config.awaitTimeout,
 			}
 			defer func() {
 				if t.Failed() && r.PrintGoroutinesOnFailure {
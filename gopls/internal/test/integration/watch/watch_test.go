@@ -711,3 +711,37 @@ func TestSomething(t *testing.T) {}
 		)
 	})
 }
+
+// Test that the periodic check for stale files recovers from changes on
+// disk that the client did not report.
+func TestStaleFileCheck(t *testing.T) {
+	const files = `
+-- go.mod --
+module mod.com
+
+go 1.18
+-- a/a.go --
+package a
+
+const C = 1
+-- b/b.go --
+package b
+
+import "mod.com/a"
+
+var _ int = a.C
+`
+	WithOptions(
+		Settings{"staleFileCheckInterval": "50ms"},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("b/b.go")
+		env.AfterChange(NoDiagnostics(ForFile("b/b.go")))
+
+		// Change a.go without notifying gopls.
+		path := env.Sandbox.Workdir.URI("a/a.go").Path()
+		if err := os.WriteFile(path, []byte("package a\n\nconst C = \"one\"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		env.Await(Diagnostics(env.AtRegexp("b/b.go", `a.C`)))
+	})
+}
@@ -236,3 +236,69 @@ const C = foo.Foo
 		)
 	})
 }
+
+// Test that with deferTestVariants, test packages are loaded only once a
+// test file is opened, or once references must account for test files.
+func TestDeferTestVariants(t *testing.T) {
+	const src = `
+-- go.mod --
+module mod.test
+
+go 1.18
+-- a/a.go --
+package a
+
+func F() int { return 1 }
+-- a/a_test.go --
+package a
+
+var _ int = "a" // type error, reported only once tests are loaded
+
+var _ = F()
+-- b/b.go --
+package b
+
+func G() {}
+-- b/b_test.go --
+package b
+
+var _ int = "b"
+
+var _ = G
+`
+	WithOptions(
+		Settings{"deferTestVariants": true},
+	).Run(t, src, func(t *testing.T, env *Env) {
+		env.OnceMet(
+			InitialWorkspaceLoad,
+			NoDiagnostics(ForFile("a/a_test.go")),
+			NoDiagnostics(ForFile("b/b_test.go")),
+		)
+
+		// Opening a test file loads the tests of its package only.
+		env.OpenFile("a/a_test.go")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("a/a_test.go", `"a"`)),
+			NoDiagnostics(ForFile("b/b_test.go")),
+		)
+
+		// Finding references loads the tests of all packages.
+		env.OpenFile("b/b.go")
+		refs := env.References(env.RegexpSearch("b/b.go", `func (G)`))
+		var found bool
+		for _, ref := range refs {
+			if strings.HasSuffix(string(ref.URI), "b/b_test.go") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("References(G) = %v, want a reference in b/b_test.go", refs)
+		}
+
+		// Test files are now diagnosed after the next change.
+		env.RegexpReplace("b/b.go", "func G", "// G does nothing.\nfunc G")
+		env.AfterChange(
+			Diagnostics(env.AtRegexp("b/b_test.go", `"b"`)),
+		)
+	})
+}
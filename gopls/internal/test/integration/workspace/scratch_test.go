@@ -0,0 +1,80 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package workspace
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+const scratchFiles = `
+-- go.mod --
+module mod.test
+
+go 1.18
+-- lib/lib.go --
+package lib
+
+const K = 1
+-- scratch/a.go --
+package main
+
+import "mod.test/lib"
+
+func main() {
+	println(lib.K)
+}
+-- scratch/b.go --
+package main
+
+func main() {}
+`
+
+func TestScratchDirectories(t *testing.T) {
+	WithOptions(
+		Settings{
+			"scratchDirectories": []string{"scratch"},
+			"codelenses":         map[string]bool{"run": true},
+		},
+	).Run(t, scratchFiles, func(t *testing.T, env *Env) {
+		// Each scratch file is a package of its own, so the two
+		// main functions do not conflict.
+		env.OpenFile("scratch/a.go")
+		env.OpenFile("scratch/b.go")
+		env.AfterChange(NoDiagnostics())
+
+		// Scratch files have full language support.
+		got := env.GoToDefinition(env.RegexpSearch("scratch/a.go", `lib\.(K)`))
+		if want := env.RegexpSearch("lib/lib.go", "K"); got != want {
+			t.Errorf("Definition(lib.K) = %v, want %v", got, want)
+		}
+
+		// They are not part of the module's packages.
+		if syms := env.Symbol("main"); len(syms) != 2 {
+			t.Errorf("got %d symbols named main, want 2 (one per scratch file): %v", len(syms), syms)
+		}
+
+		// A scratch file can only be run alone.
+		var titles []string
+		for _, lens := range env.CodeLens("scratch/a.go") {
+			if lens.Command.Command == command.RunMain.String() {
+				titles = append(titles, lens.Command.Title)
+			}
+		}
+		if len(titles) != 1 || titles[0] != "run file" {
+			t.Errorf("got run code lenses %q, want [run file]", titles)
+		}
+	})
+}
+
+func TestScratchDirectoriesDisabled(t *testing.T) {
+	Run(t, scratchFiles, func(t *testing.T, env *Env) {
+		env.OpenFile("scratch/a.go")
+		env.OpenFile("scratch/b.go")
+		env.AfterChange(Diagnostics(env.AtRegexp("scratch/b.go", "func (main)"), WithMessage("redeclared")))
+	})
+}
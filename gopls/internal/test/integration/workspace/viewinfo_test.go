@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package workspace
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/protocol"
+	"golang.org/x/tools/gopls/internal/protocol/command"
+	. "golang.org/x/tools/gopls/internal/test/integration"
+)
+
+func TestViewInfo(t *testing.T) {
+	const files = `
+-- go.work --
+go 1.18
+
+use (
+	./a
+	./b
+)
+-- a/go.mod --
+module example.com/a
+
+go 1.18
+-- a/a.go --
+package a
+-- b/go.mod --
+module example.com/b
+
+go 1.18
+-- b/b.go --
+package b
+
+import _ "example.com/a"
+`
+	WithOptions(
+		EnvVars{"GOFLAGS": "-tags=baz"},
+		Settings{"buildFlags": []string{"-tags", "foo,bar"}},
+	).Run(t, files, func(t *testing.T, env *Env) {
+		env.OpenFile("a/a.go")
+		env.AfterChange()
+
+		cmd, err := command.NewViewInfoCommand("", command.ViewInfoArgs{URI: env.Sandbox.Workdir.URI("a/a.go")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var infos []command.ViewDescription
+		env.ExecuteCommand(&protocol.ExecuteCommandParams{
+			Command:   cmd.Command,
+			Arguments: cmd.Arguments,
+		}, &infos)
+		if len(infos) != 1 {
+			t.Fatalf("got %d views, want 1", len(infos))
+		}
+		info := infos[0]
+		if info.Type != "GoWork" || info.GoWork != env.Sandbox.Workdir.URI("go.work") || info.GoMod != "" {
+			t.Errorf("got view type %s, go.work %q, go.mod %q; want GoWork view of go.work", info.Type, info.GoWork, info.GoMod)
+		}
+		wantModFiles := []protocol.DocumentURI{env.Sandbox.Workdir.URI("a/go.mod"), env.Sandbox.Workdir.URI("b/go.mod")}
+		if !reflect.DeepEqual(info.ModFiles, wantModFiles) {
+			t.Errorf("got ModFiles %v, want %v", info.ModFiles, wantModFiles)
+		}
+		if info.GOFLAGS != "-tags=baz" {
+			t.Errorf("got GOFLAGS %q, want -tags=baz", info.GOFLAGS)
+		}
+		if want := []string{"bar", "baz", "foo"}; !reflect.DeepEqual(info.Tags, want) {
+			t.Errorf("got Tags %v, want %v", info.Tags, want)
+		}
+		if info.WorkspacePackages != 2 || info.Packages < 2 {
+			t.Errorf("got %d packages, %d in the workspace; want 2 workspace packages", info.Packages, info.WorkspacePackages)
+		}
+	})
+}
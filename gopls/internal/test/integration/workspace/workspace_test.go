@@ -751,24 +751,42 @@ use (
 )
 -- foo/go.mod --
 module example.com/foo
+
+go 1.18
+-- foo/foo.go --
+package foo
+-- foo/sub/sub.go --
+package sub
+-- foo/sub/sub_test.go --
+package sub_test
 -- bar/go.mod --
 module example.com/bar
+
+go 1.17
+-- bar/bar.go --
+package bar
 -- bar/baz/go.mod --
 module example.com/bar/baz
 `
 	Run(t, files, func(t *testing.T, env *Env) {
 		env.OpenFile("go.work")
+		env.AfterChange()
 
 		tcs := map[string]string{
-			`\./foo`:      "example.com/foo",
-			`(?m)\./bar$`: "example.com/bar",
-			`\./bar/baz`:  "example.com/bar/baz",
+			`\./foo`:      "example.com/foo\n\ngo 1.18, 2 packages",
+			`(?m)\./bar$`: "example.com/bar\n\ngo 1.17, 1 package",
+			`\./bar/baz`:  "example.com/bar/baz\n\nno go directive, 0 packages",
+			`go 1\.18`: `Workspace of 3 modules:
+
+- ./foo: example.com/foo (go 1.18, 2 packages)
+- ./bar: example.com/bar (go 1.17, 1 package)
+- ./bar/baz: example.com/bar/baz (no go directive, 0 packages)`,
 		}
 
 		for hoverRE, want := range tcs {
 			got, _ := env.Hover(env.RegexpSearch("go.work", hoverRE))
 			if got.Value != want {
-				t.Errorf(`hover on %q: got %q, want %q`, hoverRE, got, want)
+				t.Errorf(`hover on %q: got %q, want %q`, hoverRE, got.Value, want)
 			}
 		}
 	})
@@ -509,6 +509,77 @@ func (e *Env) SignatureHelp(loc protocol.Location) *protocol.SignatureHelp {
 	return sighelp
 }
 
+// SelectionRange wraps Editor.SelectionRange, calling t.Fatal on any error.
+func (e *Env) SelectionRange(path string, positions ...protocol.Position) []protocol.SelectionRange {
+	e.T.Helper()
+	ranges, err := e.Editor.SelectionRange(e.Ctx, path, positions)
+	if err != nil {
+		e.T.Fatal(err)
+	}
+	return ranges
+}
+
+// PrepareTypeHierarchy wraps Editor.PrepareTypeHierarchy, calling t.Fatal on
+// any error.
+func (e *Env) PrepareTypeHierarchy(loc protocol.Location) []protocol.TypeHierarchyItem {
+	e.T.Helper()
+	items, err := e.Editor.PrepareTypeHierarchy(e.Ctx, loc)
+	if err != nil {
+		e.T.Fatal(err)
+	}
+	return items
+}
+
+// Supertypes wraps Editor.Supertypes, calling t.Fatal on any error.
+func (e *Env) Supertypes(item protocol.TypeHierarchyItem) []protocol.TypeHierarchyItem {
+	e.T.Helper()
+	items, err := e.Editor.Supertypes(e.Ctx, item)
+	if err != nil {
+		e.T.Fatal(err)
+	}
+	return items
+}
+
+// Subtypes wraps Editor.Subtypes, calling t.Fatal on any error.
+func (e *Env) Subtypes(item protocol.TypeHierarchyItem) []protocol.TypeHierarchyItem {
+	e.T.Helper()
+	items, err := e.Editor.Subtypes(e.Ctx, item)
+	if err != nil {
+		e.T.Fatal(err)
+	}
+	return items
+}
+
+// Moniker wraps Editor.Moniker, calling t.Fatal on any error.
+func (e *Env) Moniker(loc protocol.Location) []protocol.Moniker {
+	e.T.Helper()
+	monikers, err := e.Editor.Moniker(e.Ctx, loc)
+	if err != nil {
+		e.T.Fatal(err)
+	}
+	return monikers
+}
+
+// InlineValue wraps Editor.InlineValue, calling t.Fatal on any error.
+func (e *Env) InlineValue(loc protocol.Location) []protocol.InlineValue {
+	e.T.Helper()
+	values, err := e.Editor.InlineValue(e.Ctx, loc)
+	if err != nil {
+		e.T.Fatal(err)
+	}
+	return values
+}
+
+// WillRenameFiles wraps Editor.WillRenameFiles, calling t.Fatal on any error.
+func (e *Env) WillRenameFiles(oldPath, newPath string) *protocol.WorkspaceEdit {
+	e.T.Helper()
+	wsedit, err := e.Editor.WillRenameFiles(e.Ctx, oldPath, newPath)
+	if err != nil {
+		e.T.Fatal(err)
+	}
+	return wsedit
+}
+
 // Completion executes a completion request on the server.
 func (e *Env) Completion(loc protocol.Location) *protocol.CompletionList {
 	e.T.Helper()
@@ -115,8 +115,10 @@ The following markers are supported within marker tests:
     in-line range, and compares the resulting formatted unified *edits*
     (notably, not the full file content) with the golden directory.
 
-  - codeactionerr(start, end, kind, wantError): specifies a codeaction that
-    fails with an error that matches the expectation.
+  - codeactionerr(start, end, kind, wantError, ...titles): specifies a
+    codeaction that fails with an error that matches the expectation.
+    If titles are provided, they are used to filter the matching code
+    action, as for codeaction.
 
   - codelens(location, title): specifies that a codelens is expected at the
     given location, with given title. Must be used in conjunction with
@@ -243,6 +245,15 @@ The following markers are supported within marker tests:
     (TODO(rfindley): accept a label rather than a completion item). Check
     the result snippet matches the provided snippet.
 
+  - subtypes(src location, want ...location): makes a
+    typeHierarchy/subtypes query for the type declared at the src
+    location, and checks that the set of subtype locations matches want.
+    (These locations are the names of the type declarations.)
+
+  - supertypes(src location, want ...location): makes a
+    typeHierarchy/supertypes query for the type declared at the src
+    location, and checks that the set of supertype locations matches want.
+
   - symbol(golden): makes a textDocument/documentSymbol request
     for the enclosing file, formats the response with one symbol
     per line, sorts it, and compares against the named golden file.
@@ -539,8 +539,10 @@ var actionMarkerFuncs = map[string]func(marker){
 	"selectionrange":   actionMarkerFunc(selectionRangeMarker),
 	"signature":        actionMarkerFunc(signatureMarker),
 	"snippet":          actionMarkerFunc(snippetMarker),
+	"subtypes":         actionMarkerFunc(subtypesMarker),
 	"suggestedfix":     actionMarkerFunc(suggestedfixMarker),
 	"suggestedfixerr":  actionMarkerFunc(suggestedfixErrMarker),
+	"supertypes":       actionMarkerFunc(supertypesMarker),
 	"symbol":           actionMarkerFunc(symbolMarker),
 	"token":            actionMarkerFunc(tokenMarker),
 	"typedef":          actionMarkerFunc(typedefMarker),
@@ -1905,10 +1907,10 @@ func codeActionEditMarker(mark marker, loc protocol.Location, actionKind string,
 	checkDiffs(mark, changed, g)
 }
 
-func codeActionErrMarker(mark marker, start, end protocol.Location, actionKind string, wantErr stringMatcher) {
+func codeActionErrMarker(mark marker, start, end protocol.Location, actionKind string, wantErr stringMatcher, titles ...string) {
 	loc := start
 	loc.Range.End = end.Range.End
-	_, err := codeAction(mark.run.env, loc.URI, loc.Range, actionKind, nil, nil)
+	_, err := codeAction(mark.run.env, loc.URI, loc.Range, actionKind, nil, titles)
 	wantErr.checkErr(mark, err)
 }
 
@@ -1960,7 +1962,12 @@ func documentLinkMarker(mark marker, g *Golden) {
 			continue
 		}
 		loc := protocol.Location{URI: mark.uri(), Range: l.Range}
-		fmt.Fprintln(&b, mark.run.fmtLocDetails(loc, false), *l.Target)
+		target := *l.Target
+		if strings.HasPrefix(target, "file:") {
+			// Show local targets relative to the workdir.
+			target = mark.run.env.Sandbox.Workdir.URIToPath(protocol.DocumentURI(target))
+		}
+		fmt.Fprintln(&b, mark.run.fmtLocDetails(loc, false), target)
 	}
 
 	compareGolden(mark, b.Bytes(), g)
@@ -2272,6 +2279,62 @@ func callHierarchy(mark marker, src protocol.Location, getCalls callHierarchyFun
 	}
 }
 
+func subtypesMarker(mark marker, src protocol.Location, want ...protocol.Location) {
+	typeHierarchy(mark, src, func(item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+		return mark.server().Subtypes(mark.ctx(), &protocol.TypeHierarchySubtypesParams{Item: item})
+	}, want)
+}
+
+func supertypesMarker(mark marker, src protocol.Location, want ...protocol.Location) {
+	typeHierarchy(mark, src, func(item protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error) {
+		return mark.server().Supertypes(mark.ctx(), &protocol.TypeHierarchySupertypesParams{Item: item})
+	}, want)
+}
+
+type typeHierarchyFunc = func(protocol.TypeHierarchyItem) ([]protocol.TypeHierarchyItem, error)
+
+func typeHierarchy(mark marker, src protocol.Location, getTypes typeHierarchyFunc, want []protocol.Location) {
+	items, err := mark.server().PrepareTypeHierarchy(mark.ctx(), &protocol.TypeHierarchyPrepareParams{
+		TextDocumentPositionParams: protocol.LocationTextDocumentPositionParams(src),
+	})
+	if err != nil {
+		mark.errorf("PrepareTypeHierarchy failed: %v", err)
+		return
+	}
+	if nitems := len(items); nitems != 1 {
+		mark.errorf("PrepareTypeHierarchy returned %d items, want exactly 1", nitems)
+		return
+	}
+	if loc := typeItemLocation(items[0]); loc != src {
+		mark.errorf("PrepareTypeHierarchy found type %v, want %v", loc, src)
+		return
+	}
+	results, err := getTypes(items[0])
+	if err != nil {
+		mark.errorf("type hierarchy failed: %v", err)
+		return
+	}
+	got := []protocol.Location{}
+	for _, item := range results {
+		got = append(got, typeItemLocation(item))
+	}
+	sort.Slice(want, func(i, j int) bool {
+		return protocol.CompareLocation(want[i], want[j]) < 0
+	})
+	if d := cmp.Diff(want, got); d != "" {
+		mark.errorf("type hierarchy: unexpected results (-want +got):\n%s", d)
+	}
+}
+
+// typeItemLocation returns the location of the name of the type
+// declaration denoted by item.
+func typeItemLocation(item protocol.TypeHierarchyItem) protocol.Location {
+	return protocol.Location{
+		URI:   item.URI,
+		Range: item.SelectionRange,
+	}
+}
+
 func inlayhintsMarker(mark marker, g *Golden) {
 	hints := mark.run.env.InlayHints(mark.path())
 
@@ -0,0 +1,56 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathutil
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether the slash-separated path name matches the
+// glob pattern. In addition to the operators supported by [path.Match],
+// which apply within a single path segment, a segment consisting of
+// "**" matches zero or more complete segments.
+//
+// Malformed patterns match nothing; see [ValidateGlob].
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); !ok || err != nil {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ValidateGlob returns an error if pattern is not a well-formed
+// pattern for [MatchGlob].
+func ValidateGlob(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("empty glob pattern")
+	}
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid glob pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pathutil_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/gopls/internal/util/pathutil"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"a.go", "a.go", true},
+		{"*.go", "a.go", true},
+		{"*.go", "dir/a.go", false},
+		{"**/*.go", "a.go", true},
+		{"**/*.go", "dir/sub/a.go", true},
+		{"dir/**", "dir/sub/a.go", true},
+		{"dir/**", "other/a.go", false},
+		{"dir/**/zz_*.go", "dir/zz_gen.go", true},
+		{"dir/**/zz_*.go", "dir/x/y/zz_gen.go", true},
+		{"dir/**/zz_*.go", "dir/x/y/gen.go", false},
+		{"[", "[", false}, // malformed
+	}
+	for _, test := range tests {
+		if got := pathutil.MatchGlob(test.pattern, test.name); got != test.want {
+			t.Errorf("MatchGlob(%q, %q) = %t, want %t", test.pattern, test.name, got, test.want)
+		}
+	}
+}
+
+func TestValidateGlob(t *testing.T) {
+	for _, pattern := range []string{"**/*.go", "a/b?/[cd]"} {
+		if err := pathutil.ValidateGlob(pattern); err != nil {
+			t.Errorf("ValidateGlob(%q) failed: %v", pattern, err)
+		}
+	}
+	for _, pattern := range []string{"", "a/[b"} {
+		if err := pathutil.ValidateGlob(pattern); err == nil {
+			t.Errorf("ValidateGlob(%q) succeeded, want error", pattern)
+		}
+	}
+}
@@ -23,6 +23,21 @@ func Contains[S ~[]E, E comparable](slice S, x E) bool {
 	return false
 }
 
+// Equal reports whether two slices are equal: the same length and all
+// elements equal. Empty and nil slices are considered equal.
+// TODO(adonovan): use go1.21 slices.Equal.
+func Equal[S ~[]E, E comparable](s1, s2 S) bool {
+	if len(s1) != len(s2) {
+		return false
+	}
+	for i := range s1 {
+		if s1[i] != s2[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // IndexFunc returns the first index i satisfying f(s[i]),
 // or -1 if none do.
 // TODO(adonovan): use go1.21 slices.IndexFunc.
@@ -8,9 +8,11 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"strings"
 
 	"golang.org/x/mod/modfile"
 	"golang.org/x/tools/gopls/internal/cache"
+	"golang.org/x/tools/gopls/internal/cache/metadata"
 	"golang.org/x/tools/gopls/internal/file"
 	"golang.org/x/tools/gopls/internal/protocol"
 	"golang.org/x/tools/internal/event"
@@ -35,6 +37,14 @@ func Hover(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, positi
 		return nil, fmt.Errorf("computing cursor offset: %w", err)
 	}
 
+	// On the go statement, summarize the modules of the workspace.
+	if pw.File.Go != nil {
+		s, e := pw.File.Go.Syntax.Start.Byte, pw.File.Go.Syntax.End.Byte
+		if s <= offset && offset <= e {
+			return workspaceHover(ctx, snapshot, pw, s, e)
+		}
+	}
+
 	// Confirm that the cursor is inside a use statement, and then find
 	// the position of the use statement's directory path.
 	use, pathStart, pathEnd := usePath(pw, offset)
@@ -44,19 +54,17 @@ func Hover(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, positi
 		return nil, nil
 	}
 
-	// Get the mod file denoted by the use.
-	modfh, err := snapshot.ReadFile(ctx, modFileURI(pw, use))
+	counts, err := packageCounts(ctx, snapshot)
 	if err != nil {
-		return nil, fmt.Errorf("getting modfile handle: %w", err)
+		return nil, err
 	}
-	pm, err := snapshot.ParseMod(ctx, modfh)
+	mod, err := describeModule(ctx, snapshot, pw, use, counts)
 	if err != nil {
-		return nil, fmt.Errorf("getting modfile handle: %w", err)
+		return nil, err
 	}
-	if pm.File.Module == nil {
+	if mod.path == "" {
 		return nil, fmt.Errorf("modfile has no module declaration")
 	}
-	mod := pm.File.Module.Mod
 
 	// Get the range to highlight for the hover.
 	rng, err := pw.Mapper.OffsetRange(pathStart, pathEnd)
@@ -67,12 +75,122 @@ func Hover(ctx context.Context, snapshot *cache.Snapshot, fh file.Handle, positi
 	return &protocol.Hover{
 		Contents: protocol.MarkupContent{
 			Kind:  options.PreferredContentFormat,
-			Value: mod.Path,
+			Value: fmt.Sprintf("%s\n\n%s, %s", mod.path, mod.goLine(), pluralPackages(mod.packages)),
+		},
+		Range: rng,
+	}, nil
+}
+
+// workspaceHover returns the hover for the go statement of the go.work
+// file, which spans the offsets start to end: a summary of each module
+// of the workspace.
+func workspaceHover(ctx context.Context, snapshot *cache.Snapshot, pw *cache.ParsedWorkFile, start, end int) (*protocol.Hover, error) {
+	counts, err := packageCounts(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Workspace of %d module", len(pw.File.Use))
+	if len(pw.File.Use) != 1 {
+		b.WriteString("s")
+	}
+	b.WriteString(":\n")
+	for _, use := range pw.File.Use {
+		mod, err := describeModule(ctx, snapshot, pw, use, counts)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !mod.exists:
+			fmt.Fprintf(&b, "\n- %s: no go.mod file", use.Path)
+		case mod.path == "":
+			fmt.Fprintf(&b, "\n- %s: no module declaration", use.Path)
+		default:
+			fmt.Fprintf(&b, "\n- %s: %s (%s, %s)", use.Path, mod.path, mod.goLine(), pluralPackages(mod.packages))
+		}
+	}
+
+	rng, err := pw.Mapper.OffsetRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	options := snapshot.Options()
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{
+			Kind:  options.PreferredContentFormat,
+			Value: b.String(),
 		},
 		Range: rng,
 	}, nil
 }
 
+// A moduleInfo describes the module denoted by a use statement.
+type moduleInfo struct {
+	exists    bool   // whether the go.mod file exists
+	path      string // module path, or "" if there is no module statement
+	goVersion string // version of the go statement, or ""
+	packages  int    // number of loaded packages of the module
+}
+
+// goLine returns a description of the module's go statement.
+func (m moduleInfo) goLine() string {
+	if m.goVersion == "" {
+		return "no go directive"
+	}
+	return "go " + m.goVersion
+}
+
+// describeModule returns a description of the module denoted by the
+// use statement. counts holds the number of loaded packages of each
+// module, keyed by the URI of its go.mod file.
+func describeModule(ctx context.Context, snapshot *cache.Snapshot, pw *cache.ParsedWorkFile, use *modfile.Use, counts map[protocol.DocumentURI]int) (moduleInfo, error) {
+	modURI := modFileURI(pw, use)
+	modfh, err := snapshot.ReadFile(ctx, modURI)
+	if err != nil {
+		return moduleInfo{}, fmt.Errorf("getting modfile handle: %w", err)
+	}
+	if _, err := modfh.Content(); err != nil {
+		return moduleInfo{}, nil // the go.mod file does not exist
+	}
+	pm, err := snapshot.ParseMod(ctx, modfh)
+	if err != nil {
+		return moduleInfo{}, fmt.Errorf("getting modfile handle: %w", err)
+	}
+	info := moduleInfo{exists: true, packages: counts[modURI]}
+	if pm.File.Module != nil {
+		info.path = pm.File.Module.Mod.Path
+	}
+	if pm.File.Go != nil {
+		info.goVersion = pm.File.Go.Version
+	}
+	return info, nil
+}
+
+// packageCounts returns the number of workspace packages of each
+// module, keyed by the URI of its go.mod file. Test variants are not
+// counted.
+func packageCounts(ctx context.Context, snapshot *cache.Snapshot) (map[protocol.DocumentURI]int, error) {
+	mps, err := snapshot.WorkspaceMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[protocol.DocumentURI]int)
+	for _, mp := range mps {
+		if mp.Module == nil || mp.Module.GoMod == "" || mp.ForTest != "" || metadata.IsCommandLineArguments(mp.ID) {
+			continue
+		}
+		counts[protocol.URIFromPath(mp.Module.GoMod)]++
+	}
+	return counts, nil
+}
+
+func pluralPackages(n int) string {
+	if n == 1 {
+		return "1 package"
+	}
+	return fmt.Sprintf("%d packages", n)
+}
+
 func usePath(pw *cache.ParsedWorkFile, offset int) (use *modfile.Use, pathStart, pathEnd int) {
 	for _, u := range pw.File.Use {
 		path := []byte(u.Path)
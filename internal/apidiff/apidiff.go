@@ -31,12 +31,8 @@ func Changes(old, new *types.Package) Report {
 	d := newDiffer(old, new)
 	d.checkPackage()
 	r := Report{}
-	for _, m := range d.incompatibles.collect() {
-		r.Changes = append(r.Changes, Change{Message: m, Compatible: false})
-	}
-	for _, m := range d.compatibles.collect() {
-		r.Changes = append(r.Changes, Change{Message: m, Compatible: true})
-	}
+	r.Changes = append(r.Changes, d.incompatibles.collect(false)...)
+	r.Changes = append(r.Changes, d.compatibles.collect(true)...)
 	return r
 }
 
@@ -38,8 +38,10 @@ func (m messageSet) add(obj types.Object, part, msg string) {
 	s[part] = msg
 }
 
-func (m messageSet) collect() []string {
-	var s []string
+// collect returns the changes of the set, in order of message, each
+// classified as compatible or not.
+func (m messageSet) collect(compatible bool) []Change {
+	var changes []Change
 	for obj, parts := range m {
 		// Format each object name relative to its own package.
 		objstring := objectString(obj)
@@ -51,11 +53,18 @@ func (m messageSet) collect() []string {
 			} else {
 				p = dotjoin(objstring, part)
 			}
-			s = append(s, p+": "+msg)
+			changes = append(changes, Change{
+				Message:    p + ": " + msg,
+				Compatible: compatible,
+				Object:     obj,
+				Part:       part,
+			})
 		}
 	}
-	sort.Strings(s)
-	return s
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Message < changes[j].Message
+	})
+	return changes
 }
 
 func objectString(obj types.Object) string {
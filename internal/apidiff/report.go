@@ -7,6 +7,7 @@ package apidiff
 import (
 	"bytes"
 	"fmt"
+	"go/types"
 	"io"
 )
 
@@ -19,6 +20,14 @@ type Report struct {
 type Change struct {
 	Message    string
 	Compatible bool
+
+	// Object is the object to which the change applies: usually that
+	// of the old package, but that of the new package for additions.
+	// Part, if not empty, names the affected field or method of the
+	// object, or begins with a comma and describes the method set in
+	// which a method was removed.
+	Object types.Object
+	Part   string
 }
 
 func (r Report) messages(compatible bool) []string {
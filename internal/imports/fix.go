@@ -64,15 +64,67 @@ var importToGroup = []func(localPrefix, importPath string) (num int, ok bool){
 	},
 }
 
-func importGroup(localPrefix, importPath string) int {
+// importGroup returns the group number of importPath: its index in
+// opt.Groups if they are specified, or otherwise the number assigned
+// by importToGroup.
+func importGroup(opt *Options, importPath string) int {
+	if len(opt.Groups) > 0 {
+		return configuredImportGroup(opt.Groups, opt.ModulePath, importPath)
+	}
 	for _, fn := range importToGroup {
-		if n, ok := fn(localPrefix, importPath); ok {
+		if n, ok := fn(opt.LocalPrefix, importPath); ok {
 			return n
 		}
 	}
 	return 0
 }
 
+// configuredImportGroup returns the index in groups of the group of
+// importPath, as described at Options.Groups, or len(groups) if it
+// belongs to none of them.
+func configuredImportGroup(groups []string, modulePath, importPath string) int {
+	matches := func(prefix string) bool {
+		return prefix != "" && (strings.HasPrefix(importPath, prefix) || strings.TrimSuffix(prefix, "/") == importPath)
+	}
+	best, bestLen := -1, -1
+	std, other := -1, -1
+	for i, g := range groups {
+		prefix := g
+		switch g {
+		case "std":
+			std = i
+			continue
+		case "other":
+			other = i
+			continue
+		case "module":
+			if modulePath == "" {
+				continue
+			}
+			if importPath != modulePath && !strings.HasPrefix(importPath, modulePath+"/") {
+				continue
+			}
+			prefix = modulePath
+		default:
+			if !matches(prefix) {
+				continue
+			}
+		}
+		if len(prefix) > bestLen {
+			best, bestLen = i, len(prefix)
+		}
+	}
+	switch {
+	case best >= 0:
+		return best
+	case std >= 0 && !strings.Contains(strings.Split(importPath, "/")[0], "."):
+		return std
+	case other >= 0:
+		return other
+	}
+	return len(groups)
+}
+
 type ImportFixType int
 
 const (
@@ -2058,6 +2058,123 @@ const _ = runtime.GOOS
 	}
 }
 
+// Tests that the Groups option sorts imports, including those that are
+// already present, into the specified groups.
+func TestImportGroups(t *testing.T) {
+	modules := []packagestest.Module{
+		{
+			Name:  "corp.com",
+			Files: fm{"lib/lib.go": "package lib \n const A = 1"},
+		},
+		{
+			Name:  "other.org",
+			Files: fm{"x/x.go": "package x \n const B = 1"},
+		},
+	}
+	tests := []struct {
+		name   string
+		groups []string
+		src    string
+		want   string
+	}{
+		{
+			name:   "add",
+			groups: []string{"std", "module", "corp.com", "other"},
+			src:    "package main \n const _ = lib.A + x.B + y.C \n const _ = runtime.GOOS",
+			want: `package main
+
+import (
+	"runtime"
+
+	"test.com/y"
+
+	"corp.com/lib"
+
+	"other.org/x"
+)
+
+const _ = lib.A + x.B + y.C
+const _ = runtime.GOOS
+`,
+		},
+		{
+			name:   "regroup",
+			groups: []string{"std", "other", "corp.com", "module"},
+			src: `package main
+
+import (
+	"corp.com/lib" // the library
+	"runtime"
+
+	"other.org/x"
+	"test.com/y"
+)
+
+const _ = lib.A + x.B + y.C
+const _ = runtime.GOOS
+`,
+			want: `package main
+
+import (
+	"runtime"
+
+	"other.org/x"
+
+	"corp.com/lib" // the library
+
+	"test.com/y"
+)
+
+const _ = lib.A + x.B + y.C
+const _ = runtime.GOOS
+`,
+		},
+		{
+			name:   "no_other",
+			groups: []string{"corp.com", "std"},
+			src:    "package main \n const _ = lib.A + x.B \n const _ = runtime.GOOS",
+			want: `package main
+
+import (
+	"corp.com/lib"
+
+	"runtime"
+
+	"other.org/x"
+)
+
+const _ = lib.A + x.B
+const _ = runtime.GOOS
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testConfig{
+				// The module being processed has to be first so it's the primary module.
+				modules: append([]packagestest.Module{{
+					Name: "test.com",
+					Files: fm{
+						"t.go":   tt.src,
+						"y/y.go": "package y \n const C = 1",
+					},
+				}}, modules...),
+			}.test(t, func(t *goimportTest) {
+				options := &Options{
+					Groups:     tt.groups,
+					ModulePath: "test.com",
+					TabWidth:   8,
+					TabIndent:  true,
+					Comments:   true,
+					Fragment:   true,
+				}
+				t.assertProcessEquals("test.com", "t.go", nil, options, tt.want)
+			})
+		})
+	}
+}
+
 // Tests that "package documentation" files are ignored.
 func TestIgnoreDocumentationPackage(t *testing.T) {
 	const input = `package x
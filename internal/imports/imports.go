@@ -34,6 +34,24 @@ type Options struct {
 	// into another group after 3rd-party packages.
 	LocalPrefix string
 
+	// Groups, if non-empty, specifies the order of the groups into
+	// which imports are sorted, superseding LocalPrefix. Each element
+	// is "std" for the standard library, "module" for the module
+	// whose path is ModulePath, "other" for imports that match no
+	// other group, or an import path prefix. An import belongs to
+	// the group of the longest matching prefix or module path, and
+	// otherwise to "std" (if it is a standard package) or "other".
+	// Imports that belong to no group follow all the groups.
+	//
+	// When Groups is set, runs of imports separated only by blank
+	// lines are merged before sorting, so that imports that are
+	// already present are regrouped.
+	Groups []string
+
+	// ModulePath is the path of the module of the file being
+	// processed, used by the "module" element of Groups.
+	ModulePath string
+
 	Fragment  bool // Accept fragment of a source file (no package statement)
 	AllErrors bool // Report all errors (not just the first 10 on different lines)
 
@@ -114,7 +132,7 @@ func ApplyFixes(fixes []*ImportFix, filename string, src []byte, opt *Options, e
 // formatted file, and returns the postpocessed result.
 func formatFile(fset *token.FileSet, file *ast.File, src []byte, adjust func(orig []byte, src []byte) []byte, opt *Options) ([]byte, error) {
 	mergeImports(file)
-	sortImports(opt.LocalPrefix, fset.File(file.Pos()), file)
+	sortImports(opt, fset.File(file.Pos()), file)
 	var spacesBefore []string // import paths we need spaces before
 	for _, impSection := range astutil.Imports(fset, file) {
 		// Within each block of contiguous imports, see if any
@@ -124,7 +142,7 @@ func formatFile(fset *token.FileSet, file *ast.File, src []byte, adjust func(ori
 		lastGroup := -1
 		for _, importSpec := range impSection {
 			importPath, _ := strconv.Unquote(importSpec.Path.Value)
-			groupNum := importGroup(opt.LocalPrefix, importPath)
+			groupNum := importGroup(opt, importPath)
 			if groupNum != lastGroup && lastGroup != -1 {
 				spacesBefore = append(spacesBefore, importPath)
 			}
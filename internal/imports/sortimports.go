@@ -16,10 +16,11 @@ import (
 )
 
 // sortImports sorts runs of consecutive import lines in import blocks in f.
+// If opt.Groups is set, runs separated only by blank lines are merged.
 // It also removes duplicate imports when it is possible to do so without data loss.
 //
 // It may mutate the token.File and the ast.File.
-func sortImports(localPrefix string, tokFile *token.File, f *ast.File) {
+func sortImports(opt *Options, tokFile *token.File, f *ast.File) {
 	for i, d := range f.Decls {
 		d, ok := d.(*ast.GenDecl)
 		if !ok || d.Tok != token.IMPORT {
@@ -43,12 +44,16 @@ func sortImports(localPrefix string, tokFile *token.File, f *ast.File) {
 		specs := d.Specs[:0]
 		for j, s := range d.Specs {
 			if j > i && tokFile.Line(s.Pos()) > 1+tokFile.Line(d.Specs[j-1].End()) {
+				if len(opt.Groups) > 0 && !commentBetween(tokFile, f, d.Specs[j-1], s) {
+					// Regroup: merge runs separated only by blank lines.
+					continue
+				}
 				// j begins a new run.  End this one.
-				specs = append(specs, sortSpecs(localPrefix, tokFile, f, d.Specs[i:j])...)
+				specs = append(specs, sortSpecs(opt, tokFile, f, d.Specs[i:j])...)
 				i = j
 			}
 		}
-		specs = append(specs, sortSpecs(localPrefix, tokFile, f, d.Specs[i:])...)
+		specs = append(specs, sortSpecs(opt, tokFile, f, d.Specs[i:])...)
 		d.Specs = specs
 
 		// Deduping can leave a blank line before the rparen; clean that up.
@@ -63,6 +68,18 @@ func sortImports(localPrefix string, tokFile *token.File, f *ast.File) {
 	}
 }
 
+// commentBetween reports whether a comment lies on a line of its own
+// between the import specs prev and next.
+func commentBetween(tokFile *token.File, f *ast.File, prev, next ast.Spec) bool {
+	prevLine := tokFile.Line(prev.End())
+	for _, g := range f.Comments {
+		if g.Pos() > prev.End() && g.End() < next.Pos() && tokFile.Line(g.Pos()) > prevLine {
+			return true
+		}
+	}
+	return false
+}
+
 // mergeImports merges all the import declarations into the first one.
 // Taken from golang.org/x/tools/ast/astutil.
 // This does not adjust line numbers properly
@@ -150,7 +167,7 @@ type posSpan struct {
 
 // sortSpecs sorts the import specs within each import decl.
 // It may mutate the token.File.
-func sortSpecs(localPrefix string, tokFile *token.File, f *ast.File, specs []ast.Spec) []ast.Spec {
+func sortSpecs(opt *Options, tokFile *token.File, f *ast.File, specs []ast.Spec) []ast.Spec {
 	// Can't short-circuit here even if specs are already sorted,
 	// since they might yet need deduplication.
 	// A lone import, however, may be safely ignored.
@@ -199,7 +216,7 @@ func sortSpecs(localPrefix string, tokFile *token.File, f *ast.File, specs []ast
 	// Reassign the import paths to have the same position sequence.
 	// Reassign each comment to abut the end of its spec.
 	// Sort the comments by new position.
-	sort.Sort(byImportSpec{localPrefix, specs})
+	sort.Sort(byImportSpec{opt, specs})
 
 	// Dedup. Thanks to our sorting, we can just consider
 	// adjacent pairs of imports.
@@ -262,8 +279,8 @@ func sortSpecs(localPrefix string, tokFile *token.File, f *ast.File, specs []ast
 }
 
 type byImportSpec struct {
-	localPrefix string
-	specs       []ast.Spec // slice of *ast.ImportSpec
+	opt   *Options
+	specs []ast.Spec // slice of *ast.ImportSpec
 }
 
 func (x byImportSpec) Len() int      { return len(x.specs) }
@@ -272,8 +289,8 @@ func (x byImportSpec) Less(i, j int) bool {
 	ipath := importPath(x.specs[i])
 	jpath := importPath(x.specs[j])
 
-	igroup := importGroup(x.localPrefix, ipath)
-	jgroup := importGroup(x.localPrefix, jpath)
+	igroup := importGroup(x.opt, ipath)
+	jgroup := importGroup(x.opt, jpath)
 	if igroup != jgroup {
 		return igroup < jgroup
 	}
@@ -46,9 +46,18 @@ type gobCallee struct {
 	HasBareReturn    bool                   // uses bare return in non-void function
 	Returns          [][]returnOperandFlags // metadata about result expressions for each return
 	Labels           []string               // names of all control labels
+	Locals           []local                // objects declared in the outermost block of the body
 	Falcon           falconResult           // falcon constraint system
 }
 
+// A local records an object declared in the outermost block of the
+// callee's body, and the references to it, so that it may be renamed
+// to avoid capturing a name of the caller. Gob-serializable.
+type local struct {
+	Name string
+	Refs []int // byte offsets of the declaring and referring identifiers, relative to the FuncDecl
+}
+
 // returnOperandFlags records metadata about a single result expression in a return
 // statement.
 type returnOperandFlags int
@@ -321,6 +330,32 @@ func AnalyzeCallee(logf func(string, ...any), fset *token.FileSet, pkg *types.Pa
 		return true
 	})
 
+	// Record the objects declared in the outermost block of the
+	// body, which share a scope with the parameters.
+	var locals []local
+	{
+		index := make(map[types.Object]int)
+		bodyScope := info.Scopes[decl.Type]
+		ast.Inspect(decl.Body, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && id.Name != "_" {
+				obj, ok := info.Defs[id]
+				if !ok {
+					obj = info.Uses[id]
+				}
+				if obj != nil && obj.Parent() == bodyScope && within(obj.Pos(), decl.Body) {
+					i, ok := index[obj]
+					if !ok {
+						i = len(locals)
+						index[obj] = i
+						locals = append(locals, local{Name: obj.Name()})
+					}
+					locals[i].Refs = append(locals[i].Refs, int(id.Pos()-decl.Pos()))
+				}
+			}
+			return true
+		})
+	}
+
 	// Reject attempts to inline cgo-generated functions.
 	for _, obj := range freeObjs {
 		// There are others (iconst fconst sconst fpvar macro)
@@ -365,6 +400,7 @@ func AnalyzeCallee(logf func(string, ...any), fset *token.FileSet, pkg *types.Pa
 		HasBareReturn:    hasBareReturn,
 		Returns:          returnInfo,
 		Labels:           labels,
+		Locals:           locals,
 		Falcon:           falcon,
 	}}, nil
 }
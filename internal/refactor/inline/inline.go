@@ -624,6 +624,25 @@ func (st *state) inlineCall() (*inlineCallResult, error) {
 		}
 	}
 
+	// Locate the identifiers of the callee's locals now, before
+	// parameter substitution mixes in syntax from the caller,
+	// so that a strategy may rename them.
+	localIdents := make([][]*ast.Ident, len(callee.Locals))
+	if len(callee.Locals) > 0 {
+		byPos := make(map[token.Pos]*ast.Ident)
+		ast.Inspect(calleeDecl, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				byPos[id.Pos()] = id
+			}
+			return true
+		})
+		for i, l := range callee.Locals {
+			for _, offset := range l.Refs {
+				localIdents[i] = append(localIdents[i], byPos[calleeDecl.Pos()+token.Pos(offset)])
+			}
+		}
+	}
+
 	// Gather the effective call arguments, including the receiver.
 	// Later, elements will be eliminated (=> nil) by parameter substitution.
 	args, err := st.arguments(caller, calleeDecl, assign1)
@@ -1088,19 +1107,54 @@ func (st *state) inlineCall() (*inlineCallResult, error) {
 		return res, nil
 	}
 
-	// TODO(adonovan): parameterless call to { stmts; return expr }
-	// from one of these contexts:
-	//    x, y     = f()
-	//    x, y    := f()
-	//    var x, y = f()
-	// =>
-	//    var (x T1, y T2); { stmts; x, y = expr }
+	// Special case: call to { stmts; return exprs } in assignment context.
 	//
-	// Because the params are no longer declared simultaneously
-	// we need to check that (for example) x ∉ freevars(T2),
-	// in addition to the usual checks for arg/result conversions,
-	// complex control, etc.
-	// Also test cases where expr is an n-ary call (spread returns).
+	// Inlining:
+	//         x, y  = f(args)
+	//         x, y := f(args)
+	// where:
+	//	   func f(params) (T1, T2) { stmts; return expr1, expr2 }
+	// reduces to:
+	//         { var (bindings); stmts; x, y = expr1, expr2 }
+	//         var (x T1; y T2); { var (bindings); stmts; x, y = expr1, expr2 }
+	// so long as:
+	// - the callee's sole return statement is the last
+	//   statement of its body, and is not a bare return;
+	// - callee does not use defer;
+	// - there is no label conflict between caller and callee;
+	// - all parameters and result vars can be eliminated
+	//   or replaced by a binding decl;
+	// - the assignment is in unrestricted statement context,
+	//   the call is its only operand, and its left side
+	//   consists of identifiers;
+	// - the names on the left side are not bound by the binding
+	//   decl (callee locals that would capture them are renamed);
+	// - the variables declared by the assignment do not
+	//   capture names used by the block.
+	//
+	// The declared variables have the callee's result types,
+	// so implicit return conversions are preserved.
+	//
+	// TODO(adonovan): also handle var x, y = f(), and
+	// parameterless calls in other statement contexts.
+	if stmt, ok := parent.(*ast.AssignStmt); ok &&
+		len(stmt.Rhs) == 1 &&
+		len(callee.Returns) == 1 &&
+		!callee.HasBareReturn &&
+		!callee.HasDefer &&
+		!hasLabelConflict(caller.path, callee.Labels) &&
+		(!needBindingDecl || bindingDecl != nil) &&
+		allResultsUnreferenced {
+		if block, ok := st.assignBlock(stmt, calleeDecl, bindingDecl, localIdents); ok {
+			logf("strategy: reduce assign-context call to { stmts; return exprs }")
+			res.old = stmt
+			res.new = block
+			// A block that declares the assigned variables must be spliced
+			// into the caller's block; see assignBlock.
+			res.elideBraces = stmt.Tok == token.DEFINE
+			return res, nil
+		}
+	}
 
 	// Literalization isn't quite infallible.
 	// Consider a spread call to a method in which
@@ -3134,6 +3188,187 @@ func (st *state) assignStmts(callerStmt *ast.AssignStmt, returnOperands []ast.Ex
 	}}, true
 }
 
+// assignBlock returns the block that replaces the assignment statement
+// callerStmt, whose sole operand is a call to a callee of the form
+// { stmts; return exprs }, by the callee's body with the return
+// statement converted into an assignment; or (nil, false) if no such
+// rewrite is possible. See the "call to { stmts; return exprs } in
+// assignment context" strategy.
+//
+// If callerStmt declares variables, the result is a block of two
+// statements to be spliced into the caller's block: a declaration of
+// the variables, and the block.
+func (st *state) assignBlock(callerStmt *ast.AssignStmt, calleeDecl *ast.FuncDecl, bindingDecl *bindingDeclInfo, localIdents [][]*ast.Ident) (*ast.BlockStmt, bool) {
+	logf, caller, callee := st.opts.Logf, st.caller, &st.callee.impl
+
+	// The assignment must be in unrestricted statement context.
+	switch caller.path[nodeIndex(caller.path, callerStmt)+1].(type) {
+	case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+	default:
+		return nil, false
+	}
+
+	// The sole return must be the last statement.
+	body := calleeDecl.Body.List
+	if len(body) < 2 {
+		return nil, false // a call to { return exprs } is handled by other strategies
+	}
+	ret, ok := body[len(body)-1].(*ast.ReturnStmt)
+	if !ok {
+		return nil, false
+	}
+	results := ret.Results
+	resultInfo := callee.Returns[0]
+	spread := len(results) != len(callerStmt.Lhs)
+
+	// The declared result types, one per result.
+	var resultTypes []ast.Expr
+	for _, field := range calleeDecl.Type.Results.List {
+		resultTypes = append(resultTypes, field.Type)
+		for i := 1; i < len(field.Names); i++ {
+			resultTypes = append(resultTypes, field.Type)
+		}
+	}
+
+	// Collect the names on the left side, and the variables it declares.
+	// Operands other than identifiers (such as m[k()]) are evaluated
+	// before the call, so moving them after the callee body could
+	// change the order of effects.
+	lhsNames := make(map[string]bool)
+	var defs []int // indexes in Lhs of declared variables
+	for i, expr := range callerStmt.Lhs {
+		id, ok := expr.(*ast.Ident)
+		if !ok {
+			logf("cannot reduce: non-identifier %T on left side", expr)
+			return nil, false
+		}
+		if id.Name == "_" {
+			continue
+		}
+		lhsNames[id.Name] = true
+		if callerStmt.Tok == token.DEFINE && caller.Info.Defs[id] != nil {
+			defs = append(defs, i)
+		}
+	}
+
+	// The binding decl must not capture names of the left side.
+	if bindingDecl != nil {
+		for name := range bindingDecl.names {
+			if lhsNames[name] {
+				logf("cannot reduce: binding decl would capture %q", name)
+				return nil, false
+			}
+		}
+	}
+
+	// Rename the callee's locals that would capture names of the left side.
+	if len(lhsNames) > 0 {
+		used := make(map[string]bool) // names in the callee
+		ast.Inspect(calleeDecl, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+			return true
+		})
+		for i, l := range callee.Locals {
+			if !lhsNames[l.Name] {
+				continue
+			}
+			name := l.Name
+			for n := 1; used[name] || lhsNames[name] || caller.lookup(name) != nil; n++ {
+				name = l.Name + strconv.Itoa(n)
+			}
+			used[name] = true
+			logf("renaming local %q to %q", l.Name, name)
+			for _, id := range localIdents[i] {
+				id.Name = name
+			}
+		}
+	}
+
+	// Convert the return operands as needed to preserve their types.
+	var rhs []ast.Expr
+	for i, expr := range results {
+		if !spread {
+			id, _ := callerStmt.Lhs[i].(*ast.Ident)
+			blank := id != nil && id.Name == "_"
+			def := exists(defs, func(_, j int) bool { return j == i })
+			if resultInfo[i]&nonTrivialResult != 0 && !def ||
+				resultInfo[i]&untypedNilResult != 0 && blank {
+				expr = convert(resultTypes[i], expr)
+			}
+		} else if exists(resultInfo, func(_ int, flags returnOperandFlags) bool { return flags&nonTrivialResult != 0 }) {
+			return nil, false // there is no way to reify conversions in a spread return
+		}
+		rhs = append(rhs, expr)
+	}
+	var lhs []ast.Expr
+	for _, expr := range callerStmt.Lhs {
+		expr = internalastutil.CloneNode(expr)
+		clearPositions(expr)
+		lhs = append(lhs, expr)
+	}
+
+	clearPositions(calleeDecl.Body)
+	block := &ast.BlockStmt{List: append(body[:len(body)-1:len(body)-1], &ast.AssignStmt{
+		Lhs: lhs,
+		Tok: token.ASSIGN,
+		Rhs: rhs,
+	})}
+	if bindingDecl != nil {
+		block.List = prepend(bindingDecl.stmt, block.List...)
+	}
+	if len(defs) == 0 {
+		return block, true
+	}
+
+	// Declare the variables before the block. They must not
+	// capture names used by the block or by their types.
+	var specs []ast.Spec
+	for _, i := range defs {
+		name := callerStmt.Lhs[i].(*ast.Ident).Name
+		specs = append(specs, &ast.ValueSpec{
+			Names: []*ast.Ident{makeIdent(name)},
+			Type:  resultTypes[i],
+		})
+	}
+	declared := declares([]ast.Stmt{&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: specs}}})
+	captured := false
+	inspect := func(root ast.Node) {
+		ast.Inspect(root, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				ast.Inspect(n.X, func(n ast.Node) bool {
+					if id, ok := n.(*ast.Ident); ok && declared[id.Name] {
+						captured = true
+					}
+					return true
+				})
+				return false
+			case *ast.Ident:
+				if declared[n.Name] {
+					captured = true
+				}
+			}
+			return true
+		})
+	}
+	for _, stmt := range block.List[:len(block.List)-1] {
+		inspect(stmt)
+	}
+	for _, expr := range append(rhs, resultTypes...) {
+		inspect(expr)
+	}
+	if captured {
+		logf("cannot reduce: declared variables would capture names of the block")
+		return nil, false
+	}
+	return &ast.BlockStmt{List: []ast.Stmt{
+		&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: specs}},
+		block,
+	}}, true
+}
+
 // tailCallSafeReturn reports whether the callee's return statements may be safely
 // used to return from the function enclosing the caller (which must exist).
 func tailCallSafeReturn(caller *Caller, calleeSymbol *types.Func, callee *gobCallee) bool {
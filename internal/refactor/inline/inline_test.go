@@ -1011,6 +1011,96 @@ func TestAssignmentCallStrategy(t *testing.T) {
 	})
 }
 
+func TestAssignStmtsStrategy(t *testing.T) {
+	runTests(t, []testcase{
+		{
+			"Call to { stmts; return expr } in assignment context.",
+			`func f(x int) int { y := x * 2; println(y); return y + 1 }`,
+			`func _() { var z int; z = f(1); _ = z }`,
+			`func _() {
+	var z int
+	y := 1 * 2
+	println(y)
+	z = y + 1
+	_ = z
+}`,
+		},
+		{
+			"Declared variables have the result types.",
+			`func f() (int32, error) { println(); return 0, nil }`,
+			`func _() { a, err := f(); _, _ = a, err }`,
+			`func _() {
+	var (
+		a   int32
+		err error
+	)
+	{
+		println()
+		a, err = 0, nil
+	}
+	_, _ = a, err
+}`,
+		},
+		{
+			"Callee locals are renamed to avoid capturing the left side.",
+			`func f() int { z := 1; println(z); return z }`,
+			`func _() { var z int; z = f(); _ = z }`,
+			`func _() {
+	var z int
+	z1 := 1
+	println(z1)
+	z = z1
+	_ = z
+}`,
+		},
+		{
+			"Parameters are bound by a declaration in the block.",
+			`func f(x int) int { println(x); return x + x }`,
+			`func _() { z := f(g()); _ = z }; func g() int`,
+			`func _() {
+	var z int
+	{
+		var x int = g()
+		println(x)
+		z = x + x
+	}
+	_ = z
+}
+func g() int`,
+		},
+		{
+			"Multiple return statements are not reduced.",
+			`func f(x int) int { if x > 0 { return 1 }; return 0 }`,
+			`func _() { var z int; z = f(1); _ = z }`,
+			`func _() {
+	var z int
+	z = func() int {
+		if 1 > 0 {
+			return 1
+		}
+		return 0
+	}()
+	_ = z
+}`,
+		},
+		{
+			"Left operands are evaluated before the call.",
+			`func f() int { println(); return 0 }`,
+			`func _(m map[int]int) { m[len(m)] = f() }`,
+			`func _(m map[int]int) { m[len(m)] = func() int { println(); return 0 }() }`,
+		},
+		{
+			"Not in statement context.",
+			`func f() int { println(); return 0 }`,
+			`func _() { for x := f(); x < 1; x++ {} }`,
+			`func _() {
+	for x := func() int { println(); return 0 }(); x < 1; x++ {
+	}
+}`,
+		},
+	})
+}
+
 func TestVariadic(t *testing.T) {
 	runTests(t, []testcase{
 		{